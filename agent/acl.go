@@ -1,7 +1,11 @@
 package agent
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
 	"fmt"
+	"sort"
+	"strconv"
 
 	"github.com/hashicorp/serf/serf"
 
@@ -200,3 +204,58 @@ func (a *Agent) filterChecksWithAuthorizer(authz acl.Authorizer, checks *map[str
 	}
 	return nil
 }
+
+// aclAccessKey returns a key which identifies the access granted by the token,
+// so that streaming views can be shared by tokens with identical access. The
+// key is a hash of the policies, roles, service and node identities, and
+// enterprise meta of the token. Tokens which can not safely share a view, such
+// as tokens with an expiration time or tokens managed by the agent, return an
+// error so that their views are keyed by the token.
+func (a *Agent) aclAccessKey(secretID string) (string, error) {
+	ident, err := a.delegate.ResolveTokenToIdentity(secretID)
+	if err != nil {
+		return "", err
+	}
+	if ident == nil {
+		// ACLs are disabled, every token has the same access.
+		return "acls-disabled", nil
+	}
+
+	token, ok := ident.(*structs.ACLToken)
+	if !ok {
+		return "", fmt.Errorf("token of type %T can not share a view", ident)
+	}
+	if token.HasExpirationTime() {
+		return "", fmt.Errorf("token with an expiration time can not share a view")
+	}
+
+	h := sha256.New()
+	write := func(values ...string) {
+		for _, v := range values {
+			h.Write([]byte(v))
+			h.Write([]byte{0})
+		}
+		h.Write([]byte{1})
+	}
+
+	write(strconv.FormatBool(token.Local))
+	write(sortedStrings(token.PolicyIDs())...)
+	write(sortedStrings(token.RoleIDs())...)
+	for _, id := range token.ServiceIdentityList() {
+		write(append([]string{id.ServiceName}, sortedStrings(id.Datacenters)...)...)
+	}
+	for _, id := range token.NodeIdentityList() {
+		write(id.NodeName, id.Datacenter)
+	}
+	if entMeta := token.EnterpriseMetadata(); entMeta != nil {
+		write(entMeta.PartitionOrEmpty(), entMeta.NamespaceOrEmpty())
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
+
+func sortedStrings(values []string) []string {
+	sorted := make([]string, len(values))
+	copy(sorted, values)
+	sort.Strings(sorted)
+	return sorted
+}
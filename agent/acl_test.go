@@ -540,3 +540,60 @@ func TestACL_ResolveIdentity(t *testing.T) {
 	require.Error(t, err)
 
 }
+
+func TestACL_aclAccessKey(t *testing.T) {
+	t.Parallel()
+
+	expiration := time.Now().Add(time.Hour)
+	tokens := map[string]*structs.ACLToken{
+		"one": {
+			Policies: []structs.ACLTokenPolicyLink{{ID: "p1"}, {ID: "p2"}},
+			Roles:    []structs.ACLTokenRoleLink{{ID: "r1"}},
+		},
+		"two": {
+			Policies: []structs.ACLTokenPolicyLink{{ID: "p2"}, {ID: "p1"}},
+			Roles:    []structs.ACLTokenRoleLink{{ID: "r1"}},
+		},
+		"other-policies": {
+			Policies: []structs.ACLTokenPolicyLink{{ID: "p1"}},
+			Roles:    []structs.ACLTokenRoleLink{{ID: "r1"}},
+		},
+		"service-identity": {
+			Policies:          []structs.ACLTokenPolicyLink{{ID: "p1"}, {ID: "p2"}},
+			Roles:             []structs.ACLTokenRoleLink{{ID: "r1"}},
+			ServiceIdentities: []*structs.ACLServiceIdentity{{ServiceName: "web"}},
+		},
+		"expires": {
+			Policies:       []structs.ACLTokenPolicyLink{{ID: "p1"}, {ID: "p2"}},
+			Roles:          []structs.ACLTokenRoleLink{{ID: "r1"}},
+			ExpirationTime: &expiration,
+		},
+	}
+	resolveIdent := func(token string) (structs.ACLIdentity, error) {
+		ident, ok := tokens[token]
+		if !ok {
+			return nil, acl.ErrNotFound
+		}
+		return ident, nil
+	}
+	a := NewTestACLAgent(t, t.Name(), TestACLConfig(), nil, resolveIdent)
+
+	keyOne, err := a.aclAccessKey("one")
+	require.NoError(t, err)
+
+	keyTwo, err := a.aclAccessKey("two")
+	require.NoError(t, err)
+	require.Equal(t, keyOne, keyTwo)
+
+	for _, token := range []string{"other-policies", "service-identity"} {
+		key, err := a.aclAccessKey(token)
+		require.NoError(t, err)
+		require.NotEqual(t, keyOne, key, token)
+	}
+
+	_, err = a.aclAccessKey("expires")
+	require.Error(t, err)
+
+	_, err = a.aclAccessKey("unknown")
+	require.Error(t, err)
+}
@@ -38,8 +38,15 @@ import (
 	"github.com/hashicorp/consul/agent/dns"
 	"github.com/hashicorp/consul/agent/local"
 	"github.com/hashicorp/consul/agent/proxycfg"
+	"github.com/hashicorp/consul/agent/rpcclient/catalog"
+	"github.com/hashicorp/consul/agent/rpcclient/configentry"
 	"github.com/hashicorp/consul/agent/rpcclient/health"
+	"github.com/hashicorp/consul/agent/rpcclient/intention"
+	"github.com/hashicorp/consul/agent/rpcclient/kv"
+	"github.com/hashicorp/consul/agent/rpcclient/node"
+	"github.com/hashicorp/consul/agent/rpcclient/preparedquery"
 	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/agent/submatview"
 	"github.com/hashicorp/consul/agent/systemd"
 	"github.com/hashicorp/consul/agent/token"
 	"github.com/hashicorp/consul/agent/xds"
@@ -51,6 +58,7 @@ import (
 	"github.com/hashicorp/consul/lib/mutex"
 	"github.com/hashicorp/consul/lib/routine"
 	"github.com/hashicorp/consul/logging"
+	"github.com/hashicorp/consul/proto/pbsubscribe"
 	"github.com/hashicorp/consul/tlsutil"
 	"github.com/hashicorp/consul/types"
 )
@@ -346,7 +354,13 @@ type Agent struct {
 
 	// TODO: pass directly to HTTPHandlers and DNSServer once those are passed
 	// into Agent, which will allow us to remove this field.
-	rpcClientHealth *health.Client
+	rpcClientHealth        *health.Client
+	rpcClientKV            *kv.Client
+	rpcClientCatalog       *catalog.Client
+	rpcClientIntention     *intention.Client
+	rpcClientConfigEntry   *configentry.Client
+	rpcClientNode          *node.Client
+	rpcClientPreparedQuery *preparedquery.Client
 
 	// routineManager is responsible for managing longer running go routines
 	// run by the Agent
@@ -405,6 +419,13 @@ func New(bd BaseDeps) (*Agent, error) {
 		return nil, err
 	}
 
+	var streamClient submatview.StreamClient = pbsubscribe.NewStateChangeSubscriptionClient(conn)
+	if a.config.StreamingMultiplexSubscriptions {
+		streamClient = submatview.NewMultiplexedClient(
+			pbsubscribe.NewStateChangeSubscriptionClient(conn),
+			bd.Logger.Named("rpcclient.subscribe"))
+	}
+
 	a.rpcClientHealth = &health.Client{
 		Cache:     bd.Cache,
 		NetRPC:    &a,
@@ -413,11 +434,64 @@ func New(bd BaseDeps) (*Agent, error) {
 		MaterializerDeps: health.MaterializerDeps{
 			Conn:   conn,
 			Logger: bd.Logger.Named("rpcclient.health"),
+			Client: streamClient,
 		},
 		UseStreamingBackend: a.config.UseStreamingBackend,
 		QueryOptionDefaults: config.ApplyDefaultQueryOptions(a.config),
 	}
 
+	a.rpcClientKV = &kv.Client{
+		NetRPC:              &a,
+		ViewStore:           bd.ViewStore,
+		StreamClient:        streamClient,
+		UseStreamingBackend: a.config.UseStreamingBackend,
+		QueryOptionDefaults: config.ApplyDefaultQueryOptions(a.config),
+	}
+
+	a.rpcClientCatalog = &catalog.Client{
+		NetRPC:              &a,
+		Cache:               bd.Cache,
+		ViewStore:           bd.ViewStore,
+		StreamClient:        streamClient,
+		CacheName:           cachetype.CatalogListServicesName,
+		UseStreamingBackend: a.config.UseStreamingBackend,
+		QueryOptionDefaults: config.ApplyDefaultQueryOptions(a.config),
+	}
+
+	a.rpcClientIntention = &intention.Client{
+		NetRPC:              &a,
+		Cache:               bd.Cache,
+		ViewStore:           bd.ViewStore,
+		StreamClient:        streamClient,
+		CacheName:           cachetype.IntentionMatchName,
+		UseStreamingBackend: a.config.UseStreamingBackend,
+		QueryOptionDefaults: config.ApplyDefaultQueryOptions(a.config),
+	}
+
+	a.rpcClientConfigEntry = &configentry.Client{
+		NetRPC:              &a,
+		Cache:               bd.Cache,
+		ViewStore:           bd.ViewStore,
+		StreamClient:        streamClient,
+		UseStreamingBackend: a.config.UseStreamingBackend,
+		QueryOptionDefaults: config.ApplyDefaultQueryOptions(a.config),
+	}
+
+	a.rpcClientNode = &node.Client{
+		NetRPC:              &a,
+		ViewStore:           bd.ViewStore,
+		StreamClient:        streamClient,
+		UseStreamingBackend: a.config.UseStreamingBackend,
+		QueryOptionDefaults: config.ApplyDefaultQueryOptions(a.config),
+	}
+
+	a.rpcClientPreparedQuery = &preparedquery.Client{
+		Cache:               bd.Cache,
+		Health:              a.rpcClientHealth,
+		UseStreamingBackend: a.config.UseStreamingBackend,
+		Logger:              bd.Logger.Named("rpcclient.preparedquery"),
+	}
+
 	a.serviceManager = NewServiceManager(&a)
 
 	// We used to do this in the Start method. However it doesn't need to go
@@ -575,15 +649,24 @@ func (a *Agent) Start(ctx context.Context) error {
 		return fmt.Errorf("unexpected ACL default policy value of %q", a.config.ACLResolverSettings.ACLDefaultPolicy)
 	}
 
+	if a.config.ViewStore.ShareByACLPolicies {
+		a.baseDeps.ViewStore.SetTokenKeyFunc(a.aclAccessKey)
+	}
+	a.baseDeps.ViewStore.SetDefaultTokenSource(userTokenSource{tokens: a.tokens})
+	a.baseDeps.ViewStore.SetHealthCheckFunc(a.checkDatacenterHealth)
+	a.baseDeps.ViewStore.SetIndexProbeFunc(a.probeViewIndex)
 	go a.baseDeps.ViewStore.Run(&lib.StopChannelContext{StopCh: a.shutdownCh})
+	go a.refreshViewStoreTokens()
 
 	// Start the proxy config manager.
 	a.proxyConfig, err = proxycfg.NewManager(proxycfg.ManagerConfig{
-		Cache:  a.cache,
-		Health: a.rpcClientHealth,
-		Logger: a.logger.Named(logging.ProxyConfig),
-		State:  a.State,
-		Tokens: a.baseDeps.Tokens,
+		Cache:         a.cache,
+		Health:        a.rpcClientHealth,
+		Intentions:    a.rpcClientIntention,
+		ConfigEntries: a.rpcClientConfigEntry,
+		Logger:        a.logger.Named(logging.ProxyConfig),
+		State:         a.State,
+		Tokens:        a.baseDeps.Tokens,
 		Source: &structs.QuerySource{
 			Datacenter:    a.config.Datacenter,
 			Segment:       a.config.SegmentName,
@@ -687,6 +770,94 @@ var Gauges = []prometheus.GaugeDefinition{
 	},
 }
 
+// userTokenSource is the submatview.TokenSource of the user token of the
+// agent. It is the default TokenSource of the ViewStore, so the views of the
+// requests made with the user token subscribe with its current value.
+type userTokenSource struct {
+	tokens *token.Store
+}
+
+func (s userTokenSource) Name() string {
+	return "agent-user-token"
+}
+
+func (s userTokenSource) Token() string {
+	return s.tokens.UserToken()
+}
+
+// refreshViewStoreTokens restarts the streaming subscriptions of the
+// ViewStore whose token changed, each time the user token of the agent is
+// updated. It runs until the agent is shutdown.
+func (a *Agent) refreshViewStoreTokens() {
+	notifier := a.tokens.Notify(token.TokenKindUser)
+	defer a.tokens.StopNotify(notifier)
+
+	for {
+		select {
+		case <-notifier.Ch:
+			a.baseDeps.ViewStore.RefreshTokens()
+		case <-a.shutdownCh:
+			return
+		}
+	}
+}
+
+// checkDatacenterHealth is the health check of the remote datacenters used by
+// the streaming subscriptions of the ViewStore. A datacenter is healthy when
+// its servers have a leader.
+func (a *Agent) checkDatacenterHealth(_ context.Context, dc string) error {
+	args := structs.DCSpecificRequest{Datacenter: dc}
+	var leader string
+	if err := a.RPC("Status.Leader", &args, &leader); err != nil {
+		return err
+	}
+	if leader == "" {
+		return structs.ErrNoLeader
+	}
+	return nil
+}
+
+// viewIndexProbeWait is the MaxQueryTime of the blocking query used by
+// probeViewIndex. It is short because the query only needs the index of the
+// service, which is returned without the instances while it matches the view.
+const viewIndexProbeWait = 50 * time.Millisecond
+
+// probeViewIndex is the submatview.IndexProbeFunc of the view store. It reads
+// the index of the health of a service from the servers, so that the view
+// store can report how far behind the views are. Other topics are not probed.
+//
+// A view with an index is probed with a blocking query from that index, which
+// asks the servers to leave out the instances of the service if the index has
+// not changed.
+func (a *Agent) probeViewIndex(_ context.Context, req pbsubscribe.SubscribeRequest) (uint64, error) {
+	switch req.Topic {
+	case pbsubscribe.Topic_ServiceHealth, pbsubscribe.Topic_ServiceHealthConnect:
+	default:
+		return 0, nil
+	}
+
+	args := structs.ServiceSpecificRequest{
+		Datacenter:     req.Datacenter,
+		ServiceName:    req.Key,
+		Connect:        req.Topic == pbsubscribe.Topic_ServiceHealthConnect,
+		EnterpriseMeta: structs.NewEnterpriseMetaWithPartition(req.Partition, req.Namespace),
+		QueryOptions: structs.QueryOptions{
+			Token:      req.Token,
+			AllowStale: true,
+		},
+	}
+	if req.Index > 0 {
+		args.MinQueryIndex = req.Index
+		args.MaxQueryTime = viewIndexProbeWait
+		args.AllowNotModifiedResponse = true
+	}
+	var out structs.IndexedCheckServiceNodes
+	if err := a.RPC("Health.ServiceNodes", &args, &out); err != nil {
+		return 0, err
+	}
+	return out.Index, nil
+}
+
 // Failed returns a channel which is closed when the first server goroutine exits
 // with a non-nil error.
 func (a *Agent) Failed() <-chan struct{} {
@@ -1407,6 +1578,12 @@ func (a *Agent) ShutdownAgent() error {
 		a.cache.Close()
 	}
 
+	// Save the streaming views so that they can resume their subscriptions
+	// after the agent restarts.
+	if err := a.baseDeps.ViewStore.SaveSnapshots(); err != nil {
+		a.logger.Warn("failed to persist materialized views", "error", err)
+	}
+
 	a.rpcClientHealth.Close()
 
 	var err error
@@ -3828,6 +4005,10 @@ func (a *Agent) registerCache() {
 
 	a.cache.RegisterType(cachetype.PreparedQueryName, &cachetype.PreparedQuery{RPC: a})
 
+	a.cache.RegisterType(cachetype.PreparedQueryExplainName, &cachetype.PreparedQueryExplain{RPC: a})
+
+	a.cache.RegisterType(cachetype.NodeCoordinatesName, &cachetype.NodeCoordinates{RPC: a})
+
 	a.cache.RegisterType(cachetype.NodeServicesName, &cachetype.NodeServices{RPC: a})
 
 	a.cache.RegisterType(cachetype.ResolvedServiceConfigName, &cachetype.ResolvedServiceConfig{RPC: a})
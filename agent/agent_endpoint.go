@@ -23,6 +23,7 @@ import (
 	"github.com/hashicorp/consul/agent/consul"
 	"github.com/hashicorp/consul/agent/debug"
 	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/agent/submatview"
 	token_store "github.com/hashicorp/consul/agent/token"
 	"github.com/hashicorp/consul/agent/xds/proxysupport"
 	"github.com/hashicorp/consul/api"
@@ -1613,3 +1614,73 @@ func (s *HTTPHandlers) AgentHost(resp http.ResponseWriter, req *http.Request) (i
 
 	return debug.CollectHostInfo(), nil
 }
+
+// GET /v1/agent/streaming-cache
+//
+// Retrieves information about the materialized views held by the agent to
+// serve blocking queries with the streaming backend. When the snapshot query
+// parameter is set, it retrieves a snapshot of the views for debug bundles
+// instead, which is redacted when the redact query parameter is also set.
+// Requires an operator:read ACL token.
+func (s *HTTPHandlers) AgentStreamingCache(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if err := s.requireOperatorAccess(req, false); err != nil {
+		return nil, err
+	}
+
+	query := req.URL.Query()
+	if _, ok := query["snapshot"]; !ok {
+		return s.agent.baseDeps.ViewStore.Entries(), nil
+	}
+
+	_, redact := query["redact"]
+	snap, err := s.agent.baseDeps.ViewStore.Snapshot(submatview.SnapshotOptions{Redact: redact})
+	if err != nil {
+		return nil, err
+	}
+	return json.RawMessage(snap), nil
+}
+
+// StreamingCacheClearResult is the response of the streaming cache clear
+// endpoint.
+type StreamingCacheClearResult struct {
+	// Cleared is the number of materialized views that were cleared.
+	Cleared int
+}
+
+// PUT /v1/agent/streaming-cache/clear
+//
+// Discards the materialized views whose Type, Datacenter and Key, joined with
+// "/", start with the prefix query parameter, or all views if it is empty.
+// Views in use by blocking queries or watches are restarted from a new
+// snapshot. Requires an operator:write ACL token.
+func (s *HTTPHandlers) AgentStreamingCacheClear(resp http.ResponseWriter, req *http.Request) (interface{}, error) {
+	if err := s.requireOperatorAccess(req, true); err != nil {
+		return nil, err
+	}
+
+	prefix := req.URL.Query().Get("prefix")
+	cleared := s.agent.baseDeps.ViewStore.Clear(prefix)
+	s.agent.logger.Info("cleared streaming cache entries", "prefix", prefix, "count", cleared)
+	return StreamingCacheClearResult{Cleared: cleared}, nil
+}
+
+// requireOperatorAccess fetches the ACL token of req, if any, and returns a
+// permission denied error unless it grants operator:read access, or
+// operator:write access when write is true.
+func (s *HTTPHandlers) requireOperatorAccess(req *http.Request, write bool) error {
+	var token string
+	s.parseToken(req, &token)
+	authz, err := s.agent.delegate.ResolveTokenAndDefaultMeta(token, nil, nil)
+	if err != nil {
+		return err
+	}
+
+	decision := authz.OperatorRead(nil)
+	if write {
+		decision = authz.OperatorWrite(nil)
+	}
+	if decision != acl.Allow {
+		return acl.ErrPermissionDenied
+	}
+	return nil
+}
@@ -35,6 +35,7 @@ import (
 	"github.com/hashicorp/consul/agent/debug"
 	"github.com/hashicorp/consul/agent/local"
 	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/agent/submatview"
 	"github.com/hashicorp/consul/agent/token"
 	tokenStore "github.com/hashicorp/consul/agent/token"
 	"github.com/hashicorp/consul/agent/xds/proxysupport"
@@ -6827,6 +6828,165 @@ func TestAgent_HostBadACL(t *testing.T) {
 	assert.Nil(respRaw)
 }
 
+func TestAgent_StreamingCache(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+
+	a := NewTestAgent(t, `
+	rpc { enable_streaming = true }
+	use_streaming_backend = true
+`)
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	// A cached health query is served by a materialized view.
+	req, _ := http.NewRequest("GET", "/v1/health/service/web?cached", nil)
+	_, err := a.srv.HealthServiceNodes(httptest.NewRecorder(), req)
+	require.NoError(t, err)
+
+	req, _ = http.NewRequest("GET", "/v1/agent/streaming-cache", nil)
+	resp := httptest.NewRecorder()
+	obj, err := a.srv.AgentStreamingCache(resp, req)
+	require.NoError(t, err)
+	require.Equal(t, http.StatusOK, resp.Code)
+
+	entries := obj.([]submatview.EntryInfo)
+	require.Len(t, entries, 1)
+	require.Equal(t, "dc1", entries[0].Datacenter)
+	require.Equal(t, "agent.rpcclient.health.serviceRequest", entries[0].Type)
+	require.Equal(t, "ServiceHealth", entries[0].Topic)
+	require.NotNil(t, entries[0].Expires)
+}
+
+func TestAgent_StreamingCacheBadACL(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+
+	a := NewTestAgent(t, TestACLConfig())
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	req, _ := http.NewRequest("GET", "/v1/agent/streaming-cache", nil)
+	_, err := a.srv.AgentStreamingCache(httptest.NewRecorder(), req)
+	require.True(t, acl.IsErrPermissionDenied(err))
+}
+
+func TestAgent_StreamingCacheSnapshot(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+
+	a := NewTestAgent(t, `
+	rpc { enable_streaming = true }
+	use_streaming_backend = true
+`)
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	req, _ := http.NewRequest("GET", "/v1/health/service/web?cached", nil)
+	_, err := a.srv.HealthServiceNodes(httptest.NewRecorder(), req)
+	require.NoError(t, err)
+	key := a.baseDeps.ViewStore.Entries()[0].Key
+
+	snapshot := func(t *testing.T, url string) submatview.StoreSnapshot {
+		req, _ := http.NewRequest("GET", url, nil)
+		obj, err := a.srv.AgentStreamingCache(httptest.NewRecorder(), req)
+		require.NoError(t, err)
+
+		var snap submatview.StoreSnapshot
+		require.NoError(t, json.Unmarshal(obj.(json.RawMessage), &snap))
+		require.Len(t, snap.Entries, 1)
+		require.Equal(t, "ServiceHealth", snap.Entries[0].Topic)
+		return snap
+	}
+
+	t.Run("not redacted", func(t *testing.T) {
+		snap := snapshot(t, "/v1/agent/streaming-cache?snapshot")
+		require.False(t, snap.Redacted)
+		require.Equal(t, key, snap.Entries[0].Key)
+		require.Equal(t, "dc1", snap.Entries[0].Datacenter)
+	})
+
+	t.Run("redacted", func(t *testing.T) {
+		snap := snapshot(t, "/v1/agent/streaming-cache?snapshot&redact")
+		require.True(t, snap.Redacted)
+		require.NotEqual(t, key, snap.Entries[0].Key)
+		require.NotEqual(t, "dc1", snap.Entries[0].Datacenter)
+		require.Equal(t, "agent.rpcclient.health.serviceRequest", snap.Entries[0].Type)
+	})
+}
+
+func TestAgent_StreamingCacheSnapshotBadACL(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+
+	a := NewTestAgent(t, TestACLConfig())
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	req, _ := http.NewRequest("GET", "/v1/agent/streaming-cache?snapshot", nil)
+	_, err := a.srv.AgentStreamingCache(httptest.NewRecorder(), req)
+	require.True(t, acl.IsErrPermissionDenied(err))
+}
+
+func TestAgent_StreamingCacheClear(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+
+	a := NewTestAgent(t, `
+	rpc { enable_streaming = true }
+	use_streaming_backend = true
+`)
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	req, _ := http.NewRequest("GET", "/v1/health/service/web?cached", nil)
+	_, err := a.srv.HealthServiceNodes(httptest.NewRecorder(), req)
+	require.NoError(t, err)
+
+	req, _ = http.NewRequest("PUT", "/v1/agent/streaming-cache/clear?prefix=agent.rpcclient.health.serviceRequest/dc2/", nil)
+	obj, err := a.srv.AgentStreamingCacheClear(httptest.NewRecorder(), req)
+	require.NoError(t, err)
+	require.Equal(t, StreamingCacheClearResult{Cleared: 0}, obj)
+	require.Len(t, a.baseDeps.ViewStore.Entries(), 1)
+
+	req, _ = http.NewRequest("PUT", "/v1/agent/streaming-cache/clear?prefix=agent.rpcclient.health.serviceRequest/dc1/", nil)
+	obj, err = a.srv.AgentStreamingCacheClear(httptest.NewRecorder(), req)
+	require.NoError(t, err)
+	require.Equal(t, StreamingCacheClearResult{Cleared: 1}, obj)
+	require.Len(t, a.baseDeps.ViewStore.Entries(), 0)
+}
+
+func TestAgent_StreamingCacheClearBadACL(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+
+	a := NewTestAgent(t, TestACLConfig())
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1")
+
+	req, _ := http.NewRequest("PUT", "/v1/agent/streaming-cache/clear", nil)
+	_, err := a.srv.AgentStreamingCacheClear(httptest.NewRecorder(), req)
+	require.True(t, acl.IsErrPermissionDenied(err))
+}
+
 // Thie tests that a proxy with an ExposeConfig is returned as expected.
 func TestAgent_Services_ExposeConfig(t *testing.T) {
 	if testing.Short() {
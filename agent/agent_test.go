@@ -41,12 +41,14 @@ import (
 	"github.com/hashicorp/consul/agent/connect"
 	"github.com/hashicorp/consul/agent/consul"
 	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/agent/submatview"
 	"github.com/hashicorp/consul/agent/token"
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/internal/go-sso/oidcauth/oidcauthtest"
 	"github.com/hashicorp/consul/ipaddr"
 	"github.com/hashicorp/consul/lib"
 	"github.com/hashicorp/consul/proto/pbautoconf"
+	"github.com/hashicorp/consul/proto/pbsubscribe"
 	"github.com/hashicorp/consul/sdk/freeport"
 	"github.com/hashicorp/consul/sdk/testutil"
 	"github.com/hashicorp/consul/sdk/testutil/retry"
@@ -316,7 +318,8 @@ func TestAgent_HTTPMaxHeaderBytes(t *testing.T) {
 					},
 					HTTPMaxHeaderBytes: tt.maxHeaderBytes,
 				},
-				Cache: cache.New(cache.Options{}),
+				Cache:     cache.New(cache.Options{}),
+				ViewStore: submatview.NewStore(hclog.New(nil), submatview.StoreOptions{}),
 			}
 			bd, err = initEnterpriseBaseDeps(bd, nil)
 			require.NoError(t, err)
@@ -5301,7 +5304,8 @@ func TestAgent_ListenHTTP_MultipleAddresses(t *testing.T) {
 				&net.TCPAddr{IP: net.ParseIP("127.0.0.1"), Port: ports[1]},
 			},
 		},
-		Cache: cache.New(cache.Options{}),
+		Cache:     cache.New(cache.Options{}),
+		ViewStore: submatview.NewStore(hclog.New(nil), submatview.StoreOptions{}),
 	}
 
 	bd, err = initEnterpriseBaseDeps(bd, nil)
@@ -5351,3 +5355,180 @@ func uniqueAddrs(srvs []apiServer) map[string]struct{} {
 	}
 	return result
 }
+
+func TestAgent_probeViewIndex(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+
+	a := NewTestAgent(t, "")
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	args := &structs.RegisterRequest{
+		Datacenter: "dc1",
+		Node:       "foo",
+		Address:    "127.0.0.1",
+		Service: &structs.NodeService{
+			Service: "web",
+		},
+	}
+	var out struct{}
+	require.NoError(t, a.RPC("Catalog.Register", args, &out))
+
+	req := pbsubscribe.SubscribeRequest{
+		Topic:      pbsubscribe.Topic_ServiceHealth,
+		Key:        "web",
+		Datacenter: "dc1",
+	}
+	index, err := a.probeViewIndex(context.Background(), req)
+	require.NoError(t, err)
+	require.NotZero(t, index)
+
+	// A view which is up to date is probed with a blocking query from its
+	// index, which returns the same index.
+	req.Index = index
+	probed, err := a.probeViewIndex(context.Background(), req)
+	require.NoError(t, err)
+	require.Equal(t, index, probed)
+
+	req.Topic = pbsubscribe.Topic_KV
+	index, err = a.probeViewIndex(context.Background(), req)
+	require.NoError(t, err)
+	require.Zero(t, index, "other topics are not probed")
+}
+
+func TestAgent_New_SharedBaseDeps(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	dataDir := testutil.TempDir(t, "agent")
+	loader := func(source config.Source) (config.LoadResult, error) {
+		opts := config.LoadOpts{
+			HCL:           []string{TestConfigHCL(NodeID()), fmt.Sprintf(`data_dir = "%s"`, dataDir)},
+			DefaultConfig: source,
+		}
+		result, err := config.Load(opts)
+		if result.RuntimeConfig != nil {
+			result.RuntimeConfig.Telemetry.Disable = true
+		}
+		return result, err
+	}
+	bd, err := NewBaseDeps(loader, testutil.NewLogBuffer(t))
+	require.NoError(t, err)
+
+	// The views are registered with the ViewStore once, by NewBaseDeps.
+	for i := 0; i < 2; i++ {
+		_, err := New(bd)
+		require.NoError(t, err)
+	}
+
+	_, err = bd.ViewStore.NewRequest(submatview.RequestSpec{
+		Subscribe: pbsubscribe.SubscribeRequest{Topic: pbsubscribe.Topic_KV, Key: "foo"},
+		Client:    pbsubscribe.NewStateChangeSubscriptionClient(nil),
+	})
+	require.NoError(t, err)
+}
+
+func TestAgent_ViewStoreUserTokenRotation(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+
+	a := NewTestAgent(t, TestACLConfig())
+	defer a.Shutdown()
+	testrpc.WaitForLeader(t, a.RPC, "dc1", testrpc.WithToken("root"))
+
+	policyReq := structs.ACLPolicySetRequest{
+		Policy: structs.ACLPolicy{
+			Name:  "read-all",
+			Rules: `service_prefix "" { policy = "read" } node_prefix "" { policy = "read" }`,
+		},
+		Datacenter:   "dc1",
+		WriteRequest: structs.WriteRequest{Token: "root"},
+	}
+	var policy structs.ACLPolicy
+	require.NoError(t, a.RPC("ACL.PolicySet", &policyReq, &policy))
+
+	makeToken := func(t *testing.T) structs.ACLToken {
+		req := structs.ACLTokenSetRequest{
+			ACLToken: structs.ACLToken{
+				Policies: []structs.ACLTokenPolicyLink{{Name: "read-all"}},
+			},
+			Datacenter:   "dc1",
+			WriteRequest: structs.WriteRequest{Token: "root"},
+		}
+		var token structs.ACLToken
+		require.NoError(t, a.RPC("ACL.TokenSet", &req, &token))
+		return token
+	}
+	register := func(t *testing.T, node string) {
+		args := &structs.RegisterRequest{
+			Datacenter:   "dc1",
+			Node:         node,
+			Address:      "127.0.0.1",
+			Service:      &structs.NodeService{Service: "web"},
+			WriteRequest: structs.WriteRequest{Token: "root"},
+		}
+		var out struct{}
+		require.NoError(t, a.RPC("Catalog.Register", args, &out))
+	}
+
+	first, second := makeToken(t), makeToken(t)
+	a.tokens.UpdateUserToken(first.SecretID, token.TokenSourceAPI)
+	register(t, "node1")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	ch := make(chan cache.UpdateEvent, 10)
+	req := structs.ServiceSpecificRequest{
+		Datacenter:   "dc1",
+		ServiceName:  "web",
+		QueryOptions: structs.QueryOptions{Token: a.tokens.UserToken()},
+	}
+	require.NoError(t, a.rpcClientHealth.Notify(ctx, req, "web", ch))
+
+	nextNodes := func(t *testing.T) int {
+		t.Helper()
+		select {
+		case event := <-ch:
+			require.NoError(t, event.Err)
+			return len(event.Result.(*structs.IndexedCheckServiceNodes).Nodes)
+		case <-time.After(10 * time.Second):
+			t.Fatal("timeout waiting for an update")
+			return 0
+		}
+	}
+	require.Equal(t, 1, nextNodes(t))
+
+	// The view continues with the new user token after the old one is
+	// deleted.
+	a.tokens.UpdateUserToken(second.SecretID, token.TokenSourceAPI)
+	deleteReq := structs.ACLTokenDeleteRequest{
+		TokenID:      first.AccessorID,
+		Datacenter:   "dc1",
+		WriteRequest: structs.WriteRequest{Token: "root"},
+	}
+	var deleted string
+	require.NoError(t, a.RPC("ACL.TokenDelete", &deleteReq, &deleted))
+
+	register(t, "node2")
+	retry.Run(t, func(r *retry.R) {
+		select {
+		case event := <-ch:
+			if event.Err != nil {
+				t.Fatalf("unexpected error: %v", event.Err)
+			}
+			if n := len(event.Result.(*structs.IndexedCheckServiceNodes).Nodes); n != 2 {
+				r.Fatalf("expected 2 nodes, got %d", n)
+			}
+		case <-time.After(time.Second):
+			r.Fatal("timeout waiting for an update")
+		}
+	})
+}
@@ -0,0 +1,48 @@
+package cachetype
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/consul/agent/cache"
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+// Recommended name for registration.
+const NodeCoordinatesName = "node-coordinates"
+
+// NodeCoordinates supports fetching the network coordinates of the nodes of a
+// datacenter, to sort results by their distance from a node.
+type NodeCoordinates struct {
+	RegisterOptionsNoRefresh
+	RPC RPC
+}
+
+func (c *NodeCoordinates) Fetch(_ cache.FetchOptions, req cache.Request) (cache.FetchResult, error) {
+	var result cache.FetchResult
+
+	// The request should be a DCSpecificRequest.
+	reqReal, ok := req.(*structs.DCSpecificRequest)
+	if !ok {
+		return result, fmt.Errorf(
+			"Internal cache failure: request wrong type: %T", req)
+	}
+
+	// Lightweight copy this object so that manipulating QueryOptions doesn't race.
+	dup := *reqReal
+	reqReal = &dup
+
+	// Always allow stale - there's no point in hitting leader if the request is
+	// going to be served from cache and endup arbitrarily stale anyway.
+	reqReal.AllowStale = true
+
+	// Fetch
+	var reply structs.IndexedCoordinates
+	if err := c.RPC.RPC("Coordinate.ListNodes", reqReal, &reply); err != nil {
+		return result, err
+	}
+
+	result.Value = &reply
+	result.Index = reply.QueryMeta.Index
+
+	return result, nil
+}
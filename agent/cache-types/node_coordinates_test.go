@@ -0,0 +1,54 @@
+package cachetype
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/cache"
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+func TestNodeCoordinates(t *testing.T) {
+	rpc := TestRPC(t)
+	defer rpc.AssertExpectations(t)
+	typ := &NodeCoordinates{RPC: rpc}
+
+	// Expect the proper RPC call. This also sets the expected value
+	// since that is return-by-pointer in the arguments.
+	var resp *structs.IndexedCoordinates
+	rpc.On("RPC", "Coordinate.ListNodes", mock.Anything, mock.Anything).Return(nil).
+		Run(func(args mock.Arguments) {
+			req := args.Get(1).(*structs.DCSpecificRequest)
+			require.Equal(t, "dc1", req.Datacenter)
+			require.True(t, req.AllowStale)
+
+			reply := args.Get(2).(*structs.IndexedCoordinates)
+			reply.Coordinates = structs.Coordinates{{Node: "node1"}}
+			reply.QueryMeta.Index = 48
+			resp = reply
+		})
+
+	// Fetch
+	result, err := typ.Fetch(cache.FetchOptions{}, &structs.DCSpecificRequest{
+		Datacenter: "dc1",
+	})
+	require.NoError(t, err)
+	require.Equal(t, cache.FetchResult{
+		Value: resp,
+		Index: 48,
+	}, result)
+}
+
+func TestNodeCoordinates_badReqType(t *testing.T) {
+	rpc := TestRPC(t)
+	defer rpc.AssertExpectations(t)
+	typ := &NodeCoordinates{RPC: rpc}
+
+	// Fetch
+	_, err := typ.Fetch(cache.FetchOptions{}, cache.TestRequest(
+		t, cache.RequestInfo{Key: "foo", MinIndex: 64}))
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "wrong type")
+}
@@ -0,0 +1,48 @@
+package cachetype
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/consul/agent/cache"
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+// Recommended name for registration.
+const PreparedQueryExplainName = "prepared-query-explain"
+
+// PreparedQueryExplain supports fetching the definition of a prepared query,
+// with its template rendered, so that it can be executed by the agent.
+type PreparedQueryExplain struct {
+	RegisterOptionsNoRefresh
+	RPC RPC
+}
+
+func (c *PreparedQueryExplain) Fetch(_ cache.FetchOptions, req cache.Request) (cache.FetchResult, error) {
+	var result cache.FetchResult
+
+	// The request should be a PreparedQueryExecuteRequest.
+	reqReal, ok := req.(*structs.PreparedQueryExecuteRequest)
+	if !ok {
+		return result, fmt.Errorf(
+			"Internal cache failure: request wrong type: %T", req)
+	}
+
+	// Lightweight copy this object so that manipulating QueryOptions doesn't race.
+	dup := *reqReal
+	reqReal = &dup
+
+	// Always allow stale - there's no point in hitting leader if the request is
+	// going to be served from cache and endup arbitrarily stale anyway.
+	reqReal.AllowStale = true
+
+	// Fetch
+	var reply structs.PreparedQueryExplainResponse
+	if err := c.RPC.RPC("PreparedQuery.Explain", reqReal, &reply); err != nil {
+		return result, err
+	}
+
+	result.Value = &reply
+	result.Index = reply.QueryMeta.Index
+
+	return result, nil
+}
@@ -56,6 +56,28 @@ func (c *Cache) Notify(
 	r Request,
 	correlationID string,
 	ch chan<- UpdateEvent,
+) error {
+	return c.NotifyCallback(ctx, t, r, correlationID, func(ctx context.Context, event UpdateEvent) {
+		select {
+		case ch <- event:
+		case <-ctx.Done():
+		}
+	})
+}
+
+// Callback is the function type accepted by NotifyCallback.
+type Callback func(ctx context.Context, event UpdateEvent)
+
+// NotifyCallback allows you to receive notifications about changes to a cache
+// result in the same way as Notify, but accepts a callback function instead of
+// a channel. The callback is called from the notify loop, so the next update
+// is not fetched until the callback returns.
+func (c *Cache) NotifyCallback(
+	ctx context.Context,
+	t string,
+	r Request,
+	correlationID string,
+	cb Callback,
 ) error {
 	c.typesLock.RLock()
 	tEntry, ok := c.types[t]
@@ -65,7 +87,7 @@ func (c *Cache) Notify(
 	}
 
 	if tEntry.Opts.SupportsBlocking {
-		go c.notifyBlockingQuery(ctx, newGetOptions(tEntry, r), correlationID, ch)
+		go c.notifyBlockingQuery(ctx, newGetOptions(tEntry, r), correlationID, cb)
 		return nil
 	}
 
@@ -73,11 +95,11 @@ func (c *Cache) Notify(
 	if info.MaxAge == 0 {
 		return fmt.Errorf("Cannot use Notify for polling cache types without specifying the MaxAge")
 	}
-	go c.notifyPollingQuery(ctx, newGetOptions(tEntry, r), correlationID, ch)
+	go c.notifyPollingQuery(ctx, newGetOptions(tEntry, r), correlationID, cb)
 	return nil
 }
 
-func (c *Cache) notifyBlockingQuery(ctx context.Context, r getOptions, correlationID string, ch chan<- UpdateEvent) {
+func (c *Cache) notifyBlockingQuery(ctx context.Context, r getOptions, correlationID string, cb Callback) {
 	// Always start at 0 index to deliver the initial (possibly currently cached
 	// value).
 	index := uint64(0)
@@ -101,10 +123,8 @@ func (c *Cache) notifyBlockingQuery(ctx context.Context, r getOptions, correlati
 		// Check the index of the value returned in the cache entry to be sure it
 		// changed
 		if index == 0 || index < meta.Index {
-			u := UpdateEvent{correlationID, res, meta, err}
-			select {
-			case ch <- u:
-			case <-ctx.Done():
+			cb(ctx, UpdateEvent{correlationID, res, meta, err})
+			if ctx.Err() != nil {
 				return
 			}
 
@@ -143,7 +163,7 @@ func (c *Cache) notifyBlockingQuery(ctx context.Context, r getOptions, correlati
 	}
 }
 
-func (c *Cache) notifyPollingQuery(ctx context.Context, r getOptions, correlationID string, ch chan<- UpdateEvent) {
+func (c *Cache) notifyPollingQuery(ctx context.Context, r getOptions, correlationID string, cb Callback) {
 	index := uint64(0)
 	failures := uint(0)
 
@@ -166,10 +186,8 @@ func (c *Cache) notifyPollingQuery(ctx context.Context, r getOptions, correlatio
 
 		// Check for a change in the value or an index change
 		if index < meta.Index || !reflect.DeepEqual(lastValue, res) {
-			u := UpdateEvent{correlationID, res, meta, err}
-			select {
-			case ch <- u:
-			case <-ctx.Done():
+			cb(ctx, UpdateEvent{correlationID, res, meta, err})
+			if ctx.Err() != nil {
 				return
 			}
 
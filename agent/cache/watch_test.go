@@ -160,6 +160,44 @@ func TestCacheNotify(t *testing.T) {
 	// important things to get working.
 }
 
+func TestCacheNotifyCallback(t *testing.T) {
+	t.Parallel()
+
+	typ := TestType(t)
+	typ.On("RegisterOptions").Return(RegisterOptions{})
+	defer typ.AssertExpectations(t)
+	c := New(Options{})
+	c.RegisterType("t", typ)
+
+	trigger := make(chan time.Time)
+	typ.Static(FetchResult{Value: 1, Index: 4}, nil).Once()
+	typ.Static(FetchResult{Value: 12, Index: 5}, nil).WaitUntil(trigger)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ch := make(chan UpdateEvent, 1)
+	cb := func(_ context.Context, event UpdateEvent) {
+		ch <- event
+	}
+	err := c.NotifyCallback(ctx, "t", TestRequest(t, RequestInfo{Key: "hello"}), "test", cb)
+	require.NoError(t, err)
+
+	TestCacheNotifyChResult(t, ch, UpdateEvent{
+		CorrelationID: "test",
+		Result:        1,
+		Meta:          ResultMeta{Hit: false, Index: 4},
+	})
+
+	close(trigger)
+
+	TestCacheNotifyChResult(t, ch, UpdateEvent{
+		CorrelationID: "test",
+		Result:        12,
+		Meta:          ResultMeta{Hit: false, Index: 5},
+	})
+}
+
 func TestCacheNotifyPolling(t *testing.T) {
 	if testing.Short() {
 		t.Skip("too slow for testing.Short")
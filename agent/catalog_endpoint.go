@@ -282,36 +282,17 @@ func (s *HTTPHandlers) CatalogServices(resp http.ResponseWriter, req *http.Reque
 	if done := s.parse(resp, req, &args.Datacenter, &args.QueryOptions); done {
 		return nil, nil
 	}
-	var out structs.IndexedServices
-	defer setMeta(resp, &out.QueryMeta)
+	out, md, err := s.agent.rpcClientCatalog.ListServices(req.Context(), args)
+	if err != nil {
+		metrics.IncrCounterWithLabels([]string{"client", "rpc", "error", "catalog_services"}, 1,
+			s.nodeMetricsLabels())
+		return nil, err
+	}
 
 	if args.QueryOptions.UseCache {
-		raw, m, err := s.agent.cache.Get(req.Context(), cachetype.CatalogListServicesName, &args)
-		if err != nil {
-			metrics.IncrCounterWithLabels([]string{"client", "rpc", "error", "catalog_services"}, 1,
-				s.nodeMetricsLabels())
-			return nil, err
-		}
-		reply, ok := raw.(*structs.IndexedServices)
-		if !ok {
-			// This should never happen, but we want to protect against panics
-			return nil, fmt.Errorf("internal error: response type not correct")
-		}
-		defer setCacheMeta(resp, &m)
-		out = *reply
-	} else {
-	RETRY_ONCE:
-		if err := s.agent.RPC("Catalog.ListServices", &args, &out); err != nil {
-			metrics.IncrCounterWithLabels([]string{"client", "rpc", "error", "catalog_services"}, 1,
-				s.nodeMetricsLabels())
-			return nil, err
-		}
-		if args.QueryOptions.AllowStale && args.MaxStaleDuration > 0 && args.MaxStaleDuration < out.LastContact {
-			args.AllowStale = false
-			args.MaxStaleDuration = 0
-			goto RETRY_ONCE
-		}
+		setCacheMeta(resp, &md)
 	}
+	defer setMeta(resp, &out.QueryMeta)
 
 	out.ConsistencyLevel = args.QueryOptions.ConsistencyLevel()
 
@@ -444,9 +425,10 @@ func (s *HTTPHandlers) CatalogNodeServices(resp http.ResponseWriter, req *http.R
 
 	// Make the RPC request
 	var out structs.IndexedNodeServices
+	var err error
 	defer setMeta(resp, &out.QueryMeta)
 RETRY_ONCE:
-	if err := s.agent.RPC("Catalog.NodeServices", &args, &out); err != nil {
+	if out, err = s.agent.rpcClientNode.NodeServices(req.Context(), args); err != nil {
 		metrics.IncrCounterWithLabels([]string{"client", "rpc", "error", "catalog_node_services"}, 1,
 			s.nodeMetricsLabels())
 		return nil, err
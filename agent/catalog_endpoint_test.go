@@ -524,6 +524,58 @@ func TestCatalogServices(t *testing.T) {
 	}
 }
 
+func TestCatalogServices_Blocking_Streaming(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	a := NewTestAgent(t, `
+rpc { enable_streaming = true }
+use_streaming_backend = true
+`)
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	register := func(node, service string, tags ...string) {
+		args := &structs.RegisterRequest{
+			Datacenter: "dc1",
+			Node:       node,
+			Address:    "127.0.0.1",
+			Service: &structs.NodeService{
+				Service: service,
+				Tags:    tags,
+			},
+		}
+		var out struct{}
+		require.NoError(t, a.RPC("Catalog.Register", args, &out))
+	}
+	register("foo", "api", "v1")
+
+	req, _ := http.NewRequest("GET", "/v1/catalog/services", nil)
+	resp := httptest.NewRecorder()
+	_, err := a.srv.CatalogServices(resp, req)
+	require.NoError(t, err)
+	index := resp.Header().Get("X-Consul-Index")
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		register("bar", "api", "v2")
+	}()
+
+	req, _ = http.NewRequest("GET", "/v1/catalog/services?index="+index, nil)
+	resp = httptest.NewRecorder()
+	obj, err := a.srv.CatalogServices(resp, req)
+	require.NoError(t, err)
+	require.Equal(t, "streaming", resp.Header().Get("X-Consul-Query-Backend"))
+	require.NotEqual(t, index, resp.Header().Get("X-Consul-Index"))
+
+	services := obj.(structs.Services)
+	require.Len(t, services, 2)
+	require.ElementsMatch(t, []string{"v1", "v2"}, services["api"])
+	require.Contains(t, services, "consul")
+}
+
 func TestCatalogServices_NodeMetaFilter(t *testing.T) {
 	if testing.Short() {
 		t.Skip("too slow for testing.Short")
@@ -1257,6 +1309,57 @@ func TestCatalogNodeServiceList(t *testing.T) {
 	require.Equal(t, args.Service.Proxy, proxySvc.Proxy)
 }
 
+func TestCatalogNodeServices_Blocking_Streaming(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	a := NewTestAgent(t, `
+rpc { enable_streaming = true }
+use_streaming_backend = true
+`)
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	register := func(service string) {
+		args := &structs.RegisterRequest{
+			Datacenter: "dc1",
+			Node:       "foo",
+			Address:    "127.0.0.1",
+			Service: &structs.NodeService{
+				Service: service,
+			},
+		}
+		var out struct{}
+		require.NoError(t, a.RPC("Catalog.Register", args, &out))
+	}
+	register("api")
+
+	req, _ := http.NewRequest("GET", "/v1/catalog/node/foo", nil)
+	resp := httptest.NewRecorder()
+	_, err := a.srv.CatalogNodeServices(resp, req)
+	require.NoError(t, err)
+	index := resp.Header().Get("X-Consul-Index")
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		register("web")
+	}()
+
+	req, _ = http.NewRequest("GET", "/v1/catalog/node/foo?index="+index, nil)
+	resp = httptest.NewRecorder()
+	obj, err := a.srv.CatalogNodeServices(resp, req)
+	require.NoError(t, err)
+	require.Equal(t, "streaming", resp.Header().Get("X-Consul-Query-Backend"))
+	require.NotEqual(t, index, resp.Header().Get("X-Consul-Index"))
+
+	services := obj.(*structs.NodeServices)
+	require.Equal(t, "foo", services.Node.Node)
+	require.Len(t, services.Services, 2)
+	require.Contains(t, services.Services, "web")
+}
+
 func TestCatalogNodeServices_Filter(t *testing.T) {
 	if testing.Short() {
 		t.Skip("too slow for testing.Short")
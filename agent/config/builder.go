@@ -32,7 +32,9 @@ import (
 	"github.com/hashicorp/consul/agent/consul"
 	"github.com/hashicorp/consul/agent/consul/authmethod/ssoauth"
 	"github.com/hashicorp/consul/agent/dns"
+	agentgrpc "github.com/hashicorp/consul/agent/grpc"
 	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/agent/submatview"
 	"github.com/hashicorp/consul/agent/token"
 	"github.com/hashicorp/consul/ipaddr"
 	"github.com/hashicorp/consul/lib"
@@ -808,6 +810,10 @@ func (b *builder) build() (rt RuntimeConfig, err error) {
 	// build runtime config
 	//
 	dataDir := stringVal(c.DataDir)
+	var viewStoreSnapshotDir string
+	if boolVal(c.Cache.StreamingPersistViews) {
+		viewStoreSnapshotDir = dataDir
+	}
 	rt = RuntimeConfig{
 		// non-user configurable values
 		AEInterval:                 b.durationVal("ae_interval", c.AEInterval),
@@ -960,6 +966,69 @@ func (b *builder) build() (rt RuntimeConfig, err error) {
 				c.Cache.EntryFetchMaxBurst, cache.DefaultEntryFetchMaxBurst,
 			),
 		},
+		ViewStore: submatview.StoreOptions{
+			IdleTTL: b.durationValWithDefault(
+				"cache.streaming_entry_ttl", c.Cache.StreamingEntryTTL, submatview.DefaultIdleTTL,
+			),
+			MaxEntries:         intVal(c.Cache.StreamingMaxEntries),
+			ShareByACLPolicies: boolVal(c.Cache.StreamingShareByACLPolicies),
+			ShareNamespaces:    boolVal(c.Cache.StreamingShareNamespaces),
+			CoalesceNotify:     boolVal(c.Cache.StreamingCoalesceNotify),
+			Backoff: submatview.Backoff{
+				InitialWait: b.durationValWithDefault(
+					"cache.streaming_retry_initial_wait", c.Cache.StreamingRetryInitialWait, submatview.DefaultBackoff.InitialWait,
+				),
+				MaxWait: b.durationValWithDefault(
+					"cache.streaming_retry_max_wait", c.Cache.StreamingRetryMaxWait, submatview.DefaultBackoff.MaxWait,
+				),
+				Jitter: intValWithDefault(c.Cache.StreamingRetryJitter, submatview.DefaultBackoff.Jitter),
+				ResetAfter: b.durationVal(
+					"cache.streaming_retry_reset_after", c.Cache.StreamingRetryResetAfter,
+				),
+				CircuitBreakerThreshold: intVal(c.Cache.StreamingCircuitBreakerThreshold),
+				CircuitBreakerCooldown: b.durationVal(
+					"cache.streaming_circuit_breaker_cooldown", c.Cache.StreamingCircuitBreakerCooldown,
+				),
+			},
+			EventHistorySize: intValWithDefault(
+				c.Cache.StreamingEventHistorySize, submatview.DefaultEventHistorySize,
+			),
+			Debounce: submatview.Debounce{
+				Window: b.durationVal(
+					"cache.streaming_debounce_window", c.Cache.StreamingDebounceWindow,
+				),
+				BypassSnapshot: boolValWithDefault(c.Cache.StreamingDebounceBypassSnapshot, true),
+			},
+			MaxResultItems: intVal(c.Cache.StreamingMaxResultItems),
+			WatchdogTimeout: b.durationVal(
+				"cache.streaming_watchdog_timeout", c.Cache.StreamingWatchdogTimeout,
+			),
+			IndexProbeInterval: b.durationVal(
+				"cache.streaming_index_probe_interval", c.Cache.StreamingIndexProbeInterval,
+			),
+			MaxConcurrentSnapshots: intVal(c.Cache.StreamingMaxConcurrentSnapshots),
+			MaxSizeBytes:           int64(intVal(c.Cache.StreamingMaxSizeBytes)),
+			Failover: submatview.Failover{
+				Datacenters: c.Cache.StreamingFailoverDatacenters,
+				Threshold: intValWithDefault(
+					c.Cache.StreamingFailoverThreshold, submatview.DefaultFailover.Threshold,
+				),
+				HealthCheckInterval: b.durationValWithDefault(
+					"cache.streaming_health_check_interval", c.Cache.StreamingHealthCheckInterval,
+					submatview.DefaultFailover.HealthCheckInterval,
+				),
+			},
+			SnapshotDir: viewStoreSnapshotDir,
+		},
+		StreamingKeepaliveInterval: b.durationValWithDefault(
+			"cache.streaming_keepalive_interval", c.Cache.StreamingKeepaliveInterval,
+			agentgrpc.DefaultKeepaliveInterval,
+		),
+		StreamingKeepaliveTimeout: b.durationValWithDefault(
+			"cache.streaming_keepalive_timeout", c.Cache.StreamingKeepaliveTimeout,
+			agentgrpc.DefaultKeepaliveTimeout,
+		),
+		StreamingMultiplexSubscriptions: boolVal(c.Cache.StreamingMultiplexSubscriptions),
 		CAFile:                                 stringVal(c.CAFile),
 		CAPath:                                 stringVal(c.CAPath),
 		CertFile:                               stringVal(c.CertFile),
@@ -1097,6 +1166,62 @@ func (b *builder) build() (rt RuntimeConfig, err error) {
 	if rt.Cache.EntryFetchRate <= 0 {
 		return RuntimeConfig{}, fmt.Errorf("cache.entry_fetch_rate must be strictly positive, was: %v", rt.Cache.EntryFetchRate)
 	}
+	if rt.ViewStore.IdleTTL <= 0 {
+		return RuntimeConfig{}, fmt.Errorf("cache.streaming_entry_ttl must be strictly positive, was: %v", rt.ViewStore.IdleTTL)
+	}
+	if rt.ViewStore.MaxEntries < 0 {
+		return RuntimeConfig{}, fmt.Errorf("cache.streaming_max_entries must be positive, was: %v", rt.ViewStore.MaxEntries)
+	}
+	if backoff := rt.ViewStore.Backoff; backoff.InitialWait <= 0 || backoff.MaxWait < backoff.InitialWait {
+		return RuntimeConfig{}, fmt.Errorf("cache.streaming_retry_initial_wait must be strictly positive and not greater than cache.streaming_retry_max_wait, was: %v and %v",
+			backoff.InitialWait, backoff.MaxWait)
+	}
+	if rt.ViewStore.Backoff.Jitter < 0 || rt.ViewStore.Backoff.Jitter > 100 {
+		return RuntimeConfig{}, fmt.Errorf("cache.streaming_retry_jitter must be between 0 and 100, was: %v", rt.ViewStore.Backoff.Jitter)
+	}
+	if rt.ViewStore.Backoff.ResetAfter < 0 {
+		return RuntimeConfig{}, fmt.Errorf("cache.streaming_retry_reset_after must be positive, was: %v", rt.ViewStore.Backoff.ResetAfter)
+	}
+	if rt.ViewStore.Backoff.CircuitBreakerThreshold < 0 {
+		return RuntimeConfig{}, fmt.Errorf("cache.streaming_circuit_breaker_threshold must be positive, was: %v", rt.ViewStore.Backoff.CircuitBreakerThreshold)
+	}
+	if rt.ViewStore.Debounce.Window < 0 {
+		return RuntimeConfig{}, fmt.Errorf("cache.streaming_debounce_window must be positive, was: %v", rt.ViewStore.Debounce.Window)
+	}
+	if rt.ViewStore.EventHistorySize < 0 {
+		return RuntimeConfig{}, fmt.Errorf("cache.streaming_event_history_size must be positive, was: %v", rt.ViewStore.EventHistorySize)
+	}
+	if rt.ViewStore.Backoff.CircuitBreakerCooldown < 0 {
+		return RuntimeConfig{}, fmt.Errorf("cache.streaming_circuit_breaker_cooldown must be positive, was: %v", rt.ViewStore.Backoff.CircuitBreakerCooldown)
+	}
+	if rt.ViewStore.Failover.Threshold < 0 {
+		return RuntimeConfig{}, fmt.Errorf("cache.streaming_failover_threshold must be positive, was: %v", rt.ViewStore.Failover.Threshold)
+	}
+	if rt.ViewStore.Failover.HealthCheckInterval < 0 {
+		return RuntimeConfig{}, fmt.Errorf("cache.streaming_health_check_interval must be positive, was: %v", rt.ViewStore.Failover.HealthCheckInterval)
+	}
+	if rt.ViewStore.MaxResultItems < 0 {
+		return RuntimeConfig{}, fmt.Errorf("cache.streaming_max_result_items must be positive, was: %v", rt.ViewStore.MaxResultItems)
+	}
+	if rt.ViewStore.WatchdogTimeout < 0 {
+		return RuntimeConfig{}, fmt.Errorf("cache.streaming_watchdog_timeout must be positive, was: %v", rt.ViewStore.WatchdogTimeout)
+	}
+	if rt.ViewStore.IndexProbeInterval < 0 {
+		return RuntimeConfig{}, fmt.Errorf("cache.streaming_index_probe_interval must be positive, was: %v", rt.ViewStore.IndexProbeInterval)
+	}
+	if rt.ViewStore.MaxConcurrentSnapshots < 0 {
+		return RuntimeConfig{}, fmt.Errorf("cache.streaming_max_concurrent_snapshots must be positive, was: %v", rt.ViewStore.MaxConcurrentSnapshots)
+	}
+	if rt.ViewStore.MaxSizeBytes < 0 {
+		return RuntimeConfig{}, fmt.Errorf("cache.streaming_max_size_bytes must be positive, was: %v", rt.ViewStore.MaxSizeBytes)
+	}
+	if rt.StreamingKeepaliveInterval < agentgrpc.MinKeepaliveInterval {
+		return RuntimeConfig{}, fmt.Errorf("cache.streaming_keepalive_interval must be at least %v, was: %v",
+			agentgrpc.MinKeepaliveInterval, rt.StreamingKeepaliveInterval)
+	}
+	if rt.StreamingKeepaliveTimeout <= 0 {
+		return RuntimeConfig{}, fmt.Errorf("cache.streaming_keepalive_timeout must be positive, was: %v", rt.StreamingKeepaliveTimeout)
+	}
 
 	if rt.UIConfig.MetricsProvider == "prometheus" {
 		// Handle defaulting for the built-in version of prometheus.
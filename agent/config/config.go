@@ -118,6 +118,73 @@ type Cache struct {
 	EntryFetchMaxBurst *int `mapstructure:"entry_fetch_max_burst"`
 	// EntryFetchRate represents the max calls/sec for a single cache entry
 	EntryFetchRate *float64 `mapstructure:"entry_fetch_rate"`
+	// StreamingEntryTTL is how long an unused streaming cache entry is retained
+	StreamingEntryTTL *string `mapstructure:"streaming_entry_ttl"`
+	// StreamingMaxEntries is the maximum number of streaming cache entries
+	StreamingMaxEntries *int `mapstructure:"streaming_max_entries"`
+	// StreamingShareByACLPolicies shares streaming cache entries between tokens
+	// which resolve to the same ACL policies and roles.
+	StreamingShareByACLPolicies *bool `mapstructure:"streaming_share_by_acl_policies"`
+	// StreamingShareNamespaces shares streaming cache entries between the
+	// requests for the same service in different namespaces of a partition.
+	StreamingShareNamespaces *bool `mapstructure:"streaming_share_namespaces"`
+	// StreamingCoalesceNotify coalesces the updates of streaming cache
+	// watches which are not ready to receive them.
+	StreamingCoalesceNotify *bool `mapstructure:"streaming_coalesce_notify"`
+	// StreamingRetryInitialWait, StreamingRetryMaxWait, StreamingRetryJitter,
+	// and StreamingRetryResetAfter configure the backoff of streaming
+	// subscriptions which failed.
+	StreamingRetryInitialWait *string `mapstructure:"streaming_retry_initial_wait"`
+	StreamingRetryMaxWait     *string `mapstructure:"streaming_retry_max_wait"`
+	StreamingRetryJitter      *int    `mapstructure:"streaming_retry_jitter"`
+	StreamingRetryResetAfter  *string `mapstructure:"streaming_retry_reset_after"`
+	// StreamingCircuitBreakerThreshold is the number of consecutive failures
+	// of a streaming subscription which open its circuit breaker, and
+	// StreamingCircuitBreakerCooldown is the wait between attempts while it
+	// is open.
+	StreamingCircuitBreakerThreshold *int    `mapstructure:"streaming_circuit_breaker_threshold"`
+	StreamingCircuitBreakerCooldown  *string `mapstructure:"streaming_circuit_breaker_cooldown"`
+	// StreamingPersistViews saves the streaming cache entries to the data
+	// directory when the agent shuts down, and restores them on start.
+	StreamingPersistViews *bool `mapstructure:"streaming_persist_views"`
+	// StreamingEventHistorySize is the number of recent updates kept for each
+	// streaming cache entry to return delta results.
+	StreamingEventHistorySize *int `mapstructure:"streaming_event_history_size"`
+	// StreamingDebounceWindow is how long streaming cache entries accumulate
+	// events before they update the view, and
+	// StreamingDebounceBypassSnapshot applies the first snapshot immediately.
+	StreamingDebounceWindow         *string `mapstructure:"streaming_debounce_window"`
+	StreamingDebounceBypassSnapshot *bool   `mapstructure:"streaming_debounce_bypass_snapshot"`
+	// StreamingFailoverDatacenters are the datacenters streaming cache entries
+	// for a remote datacenter fall back to after
+	// StreamingFailoverThreshold consecutive failures, and
+	// StreamingHealthCheckInterval is how often the remote datacenter is
+	// checked while it is used.
+	StreamingFailoverDatacenters []string `mapstructure:"streaming_failover_datacenters"`
+	StreamingFailoverThreshold   *int     `mapstructure:"streaming_failover_threshold"`
+	StreamingHealthCheckInterval *string  `mapstructure:"streaming_health_check_interval"`
+	// StreamingMaxResultItems is the maximum number of items returned from a
+	// streaming cache entry by a request which does not ask for a page.
+	StreamingMaxResultItems *int `mapstructure:"streaming_max_result_items"`
+	// StreamingKeepaliveInterval and StreamingKeepaliveTimeout configure the
+	// gRPC keepalive pings of the connections to the servers used by streaming
+	// subscriptions, and StreamingWatchdogTimeout restarts subscriptions which
+	// received no events or heartbeats for that long.
+	StreamingKeepaliveInterval *string `mapstructure:"streaming_keepalive_interval"`
+	StreamingKeepaliveTimeout  *string `mapstructure:"streaming_keepalive_timeout"`
+	StreamingWatchdogTimeout   *string `mapstructure:"streaming_watchdog_timeout"`
+	// StreamingMultiplexSubscriptions carries the streaming subscriptions of
+	// the agent over a single stream for each server connection.
+	StreamingMultiplexSubscriptions *bool `mapstructure:"streaming_multiplex_subscriptions"`
+	// StreamingIndexProbeInterval is how often the index of the streaming
+	// cache entries is compared to the index of their data on the servers.
+	StreamingIndexProbeInterval *string `mapstructure:"streaming_index_probe_interval"`
+	// StreamingMaxConcurrentSnapshots is the maximum number of streaming
+	// cache entries which subscribe from a new snapshot at the same time.
+	StreamingMaxConcurrentSnapshots *int `mapstructure:"streaming_max_concurrent_snapshots"`
+	// StreamingMaxSizeBytes is the maximum approximate size of the streaming
+	// cache entries, after which idle entries are evicted.
+	StreamingMaxSizeBytes *int `mapstructure:"streaming_max_size_bytes"`
 }
 
 // Config defines the format of a configuration file in either JSON or
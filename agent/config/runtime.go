@@ -14,6 +14,7 @@ import (
 	"github.com/hashicorp/consul/agent/consul"
 	"github.com/hashicorp/consul/agent/dns"
 	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/agent/submatview"
 	"github.com/hashicorp/consul/agent/token"
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/lib"
@@ -377,6 +378,39 @@ type RuntimeConfig struct {
 	// Cache represent cache configuration of agent
 	Cache cache.Options
 
+	// ViewStore represents the configuration of the materialized view store
+	// used by the streaming backend.
+	//
+	// hcl: cache { streaming_entry_ttl = "duration" streaming_max_entries = int streaming_share_by_acl_policies = bool
+	//   streaming_retry_initial_wait = "duration" streaming_retry_max_wait = "duration" streaming_retry_jitter = int
+	//   streaming_retry_reset_after = "duration" streaming_circuit_breaker_threshold = int
+	//   streaming_circuit_breaker_cooldown = "duration" streaming_persist_views = bool
+	//   streaming_event_history_size = int streaming_debounce_window = "duration"
+	//   streaming_debounce_bypass_snapshot = bool streaming_failover_datacenters = []string
+	//   streaming_failover_threshold = int streaming_health_check_interval = "duration"
+	//   streaming_max_result_items = int streaming_watchdog_timeout = "duration"
+	//   streaming_index_probe_interval = "duration" streaming_max_concurrent_snapshots = int
+	//   streaming_max_size_bytes = int streaming_share_namespaces = bool
+	//   streaming_coalesce_notify = bool }
+	ViewStore submatview.StoreOptions
+
+	// StreamingKeepaliveInterval is the time without activity after which the
+	// gRPC connections to the servers used by the streaming backend are
+	// checked with a keepalive ping, and StreamingKeepaliveTimeout is how long
+	// the agent waits for the reply before it closes the connection.
+	//
+	// hcl: cache { streaming_keepalive_interval = "duration" streaming_keepalive_timeout = "duration" }
+	StreamingKeepaliveInterval time.Duration
+	StreamingKeepaliveTimeout  time.Duration
+
+	// StreamingMultiplexSubscriptions carries the subscriptions of the
+	// streaming backend over a single stream to the servers, instead of a
+	// stream for each subscription. Servers which do not support it are
+	// subscribed to with a stream for each subscription.
+	//
+	// hcl: cache { streaming_multiplex_subscriptions = bool }
+	StreamingMultiplexSubscriptions bool
+
 	// CAFile is a path to a certificate authority file. This is used with
 	// VerifyIncoming or VerifyOutgoing to verify the TLS connection.
 	//
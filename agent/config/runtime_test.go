@@ -27,6 +27,7 @@ import (
 	"github.com/hashicorp/consul/agent/checks"
 	"github.com/hashicorp/consul/agent/consul"
 	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/agent/submatview"
 	"github.com/hashicorp/consul/agent/token"
 	"github.com/hashicorp/consul/lib"
 	"github.com/hashicorp/consul/logging"
@@ -5380,6 +5381,37 @@ func TestLoad_FullConfig(t *testing.T) {
 			EntryFetchMaxBurst: 42,
 			EntryFetchRate:     0.334,
 		},
+		StreamingKeepaliveInterval:      20 * time.Second,
+		StreamingKeepaliveTimeout:       5 * time.Second,
+		StreamingMultiplexSubscriptions: true,
+		ViewStore: submatview.StoreOptions{
+			IdleTTL:                31 * time.Minute,
+			MaxEntries:             4096,
+			ShareByACLPolicies:     true,
+			ShareNamespaces:        true,
+			CoalesceNotify:         true,
+			IndexProbeInterval:     30 * time.Second,
+			MaxConcurrentSnapshots: 16,
+			MaxSizeBytes:           268435456,
+			Backoff: submatview.Backoff{
+				InitialWait:             150 * time.Millisecond,
+				MaxWait:                 45 * time.Second,
+				Jitter:                  20,
+				ResetAfter:              10 * time.Second,
+				CircuitBreakerThreshold: 8,
+				CircuitBreakerCooldown:  2 * time.Minute,
+			},
+			EventHistorySize: 128,
+			Debounce:         submatview.Debounce{Window: 250 * time.Millisecond},
+			MaxResultItems:   2500,
+			WatchdogTimeout:  90 * time.Second,
+			Failover: submatview.Failover{
+				Datacenters:         []string{"fz2ks8on", "bxz3mhfw"},
+				Threshold:           5,
+				HealthCheckInterval: 45 * time.Second,
+			},
+			SnapshotDir: dataDir,
+		},
 		CAFile:             "erA7T0PM",
 		CAPath:             "mQEN1Mfp",
 		CertFile:           "7s4QAzDk",
@@ -6260,6 +6292,37 @@ func TestRuntimeConfig_Sanitize(t *testing.T) {
 			EntryFetchMaxBurst: 42,
 			EntryFetchRate:     0.334,
 		},
+		StreamingKeepaliveInterval:      20 * time.Second,
+		StreamingKeepaliveTimeout:       5 * time.Second,
+		StreamingMultiplexSubscriptions: true,
+		ViewStore: submatview.StoreOptions{
+			IdleTTL:                31 * time.Minute,
+			MaxEntries:             4096,
+			ShareByACLPolicies:     true,
+			ShareNamespaces:        true,
+			CoalesceNotify:         true,
+			IndexProbeInterval:     30 * time.Second,
+			MaxConcurrentSnapshots: 16,
+			MaxSizeBytes:           268435456,
+			Backoff: submatview.Backoff{
+				InitialWait:             150 * time.Millisecond,
+				MaxWait:                 45 * time.Second,
+				Jitter:                  20,
+				ResetAfter:              10 * time.Second,
+				CircuitBreakerThreshold: 8,
+				CircuitBreakerCooldown:  2 * time.Minute,
+			},
+			EventHistorySize: 128,
+			Debounce:         submatview.Debounce{Window: 250 * time.Millisecond},
+			MaxResultItems:   2500,
+			WatchdogTimeout:  90 * time.Second,
+			Failover: submatview.Failover{
+				Datacenters:         []string{"dc2", "dc3"},
+				Threshold:           5,
+				HealthCheckInterval: 45 * time.Second,
+			},
+			SnapshotDir: "/var/lib/consul",
+		},
 		ConsulCoordinateUpdatePeriod: 15 * time.Second,
 		RaftProtocol:                 3,
 		RetryJoinLAN: []string{
@@ -88,6 +88,32 @@ bootstrap_expect = 53
 cache = {
     entry_fetch_max_burst = 42
     entry_fetch_rate = 0.334
+    streaming_entry_ttl = "31m"
+    streaming_max_entries = 4096
+    streaming_share_by_acl_policies = true
+    streaming_share_namespaces = true
+    streaming_coalesce_notify = true
+    streaming_retry_initial_wait = "150ms"
+    streaming_retry_max_wait = "45s"
+    streaming_retry_jitter = 20
+    streaming_retry_reset_after = "10s"
+    streaming_circuit_breaker_threshold = 8
+    streaming_circuit_breaker_cooldown = "2m"
+    streaming_persist_views = true
+    streaming_event_history_size = 128
+    streaming_debounce_window = "250ms"
+    streaming_debounce_bypass_snapshot = false
+    streaming_failover_datacenters = ["fz2ks8on", "bxz3mhfw"]
+    streaming_failover_threshold = 5
+    streaming_health_check_interval = "45s"
+    streaming_max_result_items = 2500
+    streaming_keepalive_interval = "20s"
+    streaming_keepalive_timeout = "5s"
+    streaming_watchdog_timeout = "90s"
+    streaming_index_probe_interval = "30s"
+    streaming_max_concurrent_snapshots = 16
+    streaming_max_size_bytes = 268435456
+    streaming_multiplex_subscriptions = true
 },
 use_streaming_backend = true
 ca_file = "erA7T0PM"
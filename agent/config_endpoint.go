@@ -34,6 +34,10 @@ func (s *HTTPHandlers) configGet(resp http.ResponseWriter, req *http.Request) (i
 	}
 	pathArgs := strings.SplitN(strings.TrimPrefix(req.URL.Path, "/v1/config/"), "/", 2)
 
+	if !s.agent.config.HTTPUseCache {
+		args.QueryOptions.UseCache = false
+	}
+
 	switch len(pathArgs) {
 	case 2:
 		// Both kind/name provided.
@@ -44,10 +48,13 @@ func (s *HTTPHandlers) configGet(resp http.ResponseWriter, req *http.Request) (i
 			return nil, err
 		}
 
-		var reply structs.ConfigEntryResponse
-		if err := s.agent.RPC("ConfigEntry.Get", &args, &reply); err != nil {
+		reply, m, err := s.agent.rpcClientConfigEntry.Get(req.Context(), args)
+		if err != nil {
 			return nil, err
 		}
+		if args.QueryOptions.UseCache {
+			setCacheMeta(resp, &m)
+		}
 		setMeta(resp, &reply.QueryMeta)
 
 		if reply.Entry == nil {
@@ -62,10 +69,13 @@ func (s *HTTPHandlers) configGet(resp http.ResponseWriter, req *http.Request) (i
 		// Only kind provided, list entries.
 		args.Kind = pathArgs[0]
 
-		var reply structs.IndexedConfigEntries
-		if err := s.agent.RPC("ConfigEntry.List", &args, &reply); err != nil {
+		reply, m, err := s.agent.rpcClientConfigEntry.List(req.Context(), args)
+		if err != nil {
 			return nil, err
 		}
+		if args.QueryOptions.UseCache {
+			setCacheMeta(resp, &m)
+		}
 		setMeta(resp, &reply.QueryMeta)
 
 		return reply.Entries, nil
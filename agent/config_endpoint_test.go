@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/stretchr/testify/require"
@@ -142,6 +143,59 @@ func TestConfig_Get(t *testing.T) {
 	})
 }
 
+func TestConfig_Get_Blocking_Streaming(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	a := NewTestAgent(t, `
+rpc { enable_streaming = true }
+use_streaming_backend = true
+`)
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	apply := func(entry structs.ConfigEntry) {
+		req := structs.ConfigEntryRequest{Datacenter: "dc1", Entry: entry}
+		var out bool
+		require.NoError(t, a.RPC("ConfigEntry.Apply", &req, &out))
+	}
+	apply(&structs.ServiceConfigEntry{Name: "foo", Protocol: "tcp"})
+	apply(&structs.ServiceConfigEntry{Name: "bar"})
+
+	req, _ := http.NewRequest("GET", "/v1/config/service-defaults/foo", nil)
+	resp := httptest.NewRecorder()
+	_, err := a.srv.Config(resp, req)
+	require.NoError(t, err)
+	index := resp.Header().Get("X-Consul-Index")
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		// Changes to other entries of the kind do not unblock the query.
+		apply(&structs.ServiceConfigEntry{Name: "bar", Protocol: "grpc"})
+		time.Sleep(100 * time.Millisecond)
+		apply(&structs.ServiceConfigEntry{Name: "foo", Protocol: "http"})
+	}()
+
+	req, _ = http.NewRequest("GET", "/v1/config/service-defaults/foo?index="+index, nil)
+	resp = httptest.NewRecorder()
+	obj, err := a.srv.Config(resp, req)
+	require.NoError(t, err)
+	require.Equal(t, "streaming", resp.Header().Get("X-Consul-Query-Backend"))
+
+	entry := obj.(*structs.ServiceConfigEntry)
+	require.Equal(t, "foo", entry.Name)
+	require.Equal(t, "http", entry.Protocol)
+
+	req, _ = http.NewRequest("GET", "/v1/config/service-defaults?index=1", nil)
+	resp = httptest.NewRecorder()
+	obj, err = a.srv.Config(resp, req)
+	require.NoError(t, err)
+	require.Equal(t, "streaming", resp.Header().Get("X-Consul-Query-Backend"))
+	require.Len(t, obj.([]structs.ConfigEntry), 2)
+}
+
 func TestConfig_Delete(t *testing.T) {
 	if testing.Short() {
 		t.Skip("too slow for testing.Short")
@@ -676,6 +676,21 @@ func TestHealth_ServiceNodes(t *testing.T) {
 			t.Fatalf("Bad: %v", nodes[1])
 		}
 	}
+	require.False(t, out2.QueryMeta.NotModified)
+
+	t.Run("with option AllowNotModifiedResponse", func(t *testing.T) {
+		req.QueryOptions = structs.QueryOptions{
+			MinQueryIndex:            out2.QueryMeta.Index,
+			MaxQueryTime:             20 * time.Millisecond,
+			AllowNotModifiedResponse: true,
+		}
+		err := msgpackrpc.CallWithCodec(codec, "Health.ServiceNodes", &req, &out2)
+		require.NoError(t, err)
+
+		require.Equal(t, req.QueryOptions.MinQueryIndex, out2.QueryMeta.Index)
+		require.Len(t, out2.Nodes, 0)
+		require.True(t, out2.QueryMeta.NotModified, "NotModified should be true")
+	})
 }
 
 func TestHealth_ServiceNodes_MultipleServiceTags(t *testing.T) {
@@ -2,7 +2,6 @@ package consul
 
 import (
 	"fmt"
-	"strings"
 	"time"
 
 	"github.com/armon/go-metrics"
@@ -535,24 +534,9 @@ func (p *PreparedQuery) execute(query *structs.PreparedQuery,
 		return err
 	}
 
-	// Filter out any unhealthy nodes.
-	nodes = nodes.FilterIgnore(query.Service.OnlyPassing,
-		query.Service.IgnoreCheckIDs)
-
-	// Apply the node metadata filters, if any.
-	if len(query.Service.NodeMeta) > 0 {
-		nodes = nodeMetaFilter(query.Service.NodeMeta, nodes)
-	}
-
-	// Apply the service metadata filters, if any.
-	if len(query.Service.ServiceMeta) > 0 {
-		nodes = serviceMetaFilter(query.Service.ServiceMeta, nodes)
-	}
-
-	// Apply the tag filters, if any.
-	if len(query.Service.Tags) > 0 {
-		nodes = tagFilter(query.Service.Tags, nodes)
-	}
+	// Filter out any unhealthy nodes, and the nodes which do not match the
+	// metadata and tag filters.
+	nodes = query.Service.FilterNodes(nodes)
 
 	// Capture the nodes and pass the DNS information through to the reply.
 	reply.Service = query.Service.Service
@@ -566,82 +550,6 @@ func (p *PreparedQuery) execute(query *structs.PreparedQuery,
 	return nil
 }
 
-// tagFilter returns a list of nodes who satisfy the given tags. Nodes must have
-// ALL the given tags, and NONE of the forbidden tags (prefixed with !). Note
-// for performance this modifies the original slice.
-func tagFilter(tags []string, nodes structs.CheckServiceNodes) structs.CheckServiceNodes {
-	// Build up lists of required and disallowed tags.
-	must, not := make([]string, 0), make([]string, 0)
-	for _, tag := range tags {
-		tag = strings.ToLower(tag)
-		if strings.HasPrefix(tag, "!") {
-			tag = tag[1:]
-			not = append(not, tag)
-		} else {
-			must = append(must, tag)
-		}
-	}
-
-	n := len(nodes)
-	for i := 0; i < n; i++ {
-		node := nodes[i]
-
-		// Index the tags so lookups this way are cheaper.
-		index := make(map[string]struct{})
-		if node.Service != nil {
-			for _, tag := range node.Service.Tags {
-				tag = strings.ToLower(tag)
-				index[tag] = struct{}{}
-			}
-		}
-
-		// Bail if any of the required tags are missing.
-		for _, tag := range must {
-			if _, ok := index[tag]; !ok {
-				goto DELETE
-			}
-		}
-
-		// Bail if any of the disallowed tags are present.
-		for _, tag := range not {
-			if _, ok := index[tag]; ok {
-				goto DELETE
-			}
-		}
-
-		// At this point, the service is ok to leave in the list.
-		continue
-
-	DELETE:
-		nodes[i], nodes[n-1] = nodes[n-1], structs.CheckServiceNode{}
-		n--
-		i--
-	}
-	return nodes[:n]
-}
-
-// nodeMetaFilter returns a list of the nodes who satisfy the given metadata filters. Nodes
-// must have ALL the given tags.
-func nodeMetaFilter(filters map[string]string, nodes structs.CheckServiceNodes) structs.CheckServiceNodes {
-	var filtered structs.CheckServiceNodes
-	for _, node := range nodes {
-		if structs.SatisfiesMetaFilters(node.Node.Meta, filters) {
-			filtered = append(filtered, node)
-		}
-	}
-	return filtered
-}
-
-func serviceMetaFilter(filters map[string]string, nodes structs.CheckServiceNodes) structs.CheckServiceNodes {
-	var filtered structs.CheckServiceNodes
-	for _, node := range nodes {
-		if structs.SatisfiesMetaFilters(node.Service.Meta, filters) {
-			filtered = append(filtered, node)
-		}
-	}
-	return filtered
-}
-
 // queryServer is a wrapper that makes it easier to test the failover logic.
 type queryServer interface {
 	GetLogger() hclog.Logger
@@ -6,7 +6,6 @@ import (
 	"net/rpc"
 	"os"
 	"reflect"
-	"sort"
 	"strings"
 	"testing"
 	"time"
@@ -2558,98 +2557,6 @@ func TestPreparedQuery_Execute_ConnectExact(t *testing.T) {
 		codec, "PreparedQuery.Apply", &query, &query.Query.ID))
 }
 
-func TestPreparedQuery_tagFilter(t *testing.T) {
-	t.Parallel()
-	testNodes := func() structs.CheckServiceNodes {
-		return structs.CheckServiceNodes{
-			structs.CheckServiceNode{
-				Node:    &structs.Node{Node: "node1"},
-				Service: &structs.NodeService{Tags: []string{"foo"}},
-			},
-			structs.CheckServiceNode{
-				Node:    &structs.Node{Node: "node2"},
-				Service: &structs.NodeService{Tags: []string{"foo", "BAR"}},
-			},
-			structs.CheckServiceNode{
-				Node: &structs.Node{Node: "node3"},
-			},
-			structs.CheckServiceNode{
-				Node:    &structs.Node{Node: "node4"},
-				Service: &structs.NodeService{Tags: []string{"foo", "baz"}},
-			},
-			structs.CheckServiceNode{
-				Node:    &structs.Node{Node: "node5"},
-				Service: &structs.NodeService{Tags: []string{"foo", "zoo"}},
-			},
-			structs.CheckServiceNode{
-				Node:    &structs.Node{Node: "node6"},
-				Service: &structs.NodeService{Tags: []string{"bar"}},
-			},
-		}
-	}
-
-	// This always sorts so that it's not annoying to compare after the swap
-	// operations that the algorithm performs.
-	stringify := func(nodes structs.CheckServiceNodes) string {
-		var names []string
-		for _, node := range nodes {
-			names = append(names, node.Node.Node)
-		}
-		sort.Strings(names)
-		return strings.Join(names, "|")
-	}
-
-	ret := stringify(tagFilter([]string{}, testNodes()))
-	if ret != "node1|node2|node3|node4|node5|node6" {
-		t.Fatalf("bad: %s", ret)
-	}
-
-	ret = stringify(tagFilter([]string{"foo"}, testNodes()))
-	if ret != "node1|node2|node4|node5" {
-		t.Fatalf("bad: %s", ret)
-	}
-
-	ret = stringify(tagFilter([]string{"!foo"}, testNodes()))
-	if ret != "node3|node6" {
-		t.Fatalf("bad: %s", ret)
-	}
-
-	ret = stringify(tagFilter([]string{"!foo", "bar"}, testNodes()))
-	if ret != "node6" {
-		t.Fatalf("bad: %s", ret)
-	}
-
-	ret = stringify(tagFilter([]string{"!foo", "!bar"}, testNodes()))
-	if ret != "node3" {
-		t.Fatalf("bad: %s", ret)
-	}
-
-	ret = stringify(tagFilter([]string{"nope"}, testNodes()))
-	if ret != "" {
-		t.Fatalf("bad: %s", ret)
-	}
-
-	ret = stringify(tagFilter([]string{"bar"}, testNodes()))
-	if ret != "node2|node6" {
-		t.Fatalf("bad: %s", ret)
-	}
-
-	ret = stringify(tagFilter([]string{"BAR"}, testNodes()))
-	if ret != "node2|node6" {
-		t.Fatalf("bad: %s", ret)
-	}
-
-	ret = stringify(tagFilter([]string{"bAr"}, testNodes()))
-	if ret != "node2|node6" {
-		t.Fatalf("bad: %s", ret)
-	}
-
-	ret = stringify(tagFilter([]string{""}, testNodes()))
-	if ret != "" {
-		t.Fatalf("bad: %s", ret)
-	}
-}
-
 func TestPreparedQuery_Wrapper(t *testing.T) {
 	if testing.Short() {
 		t.Skip("too slow for testing.Short")
@@ -55,7 +55,7 @@ func (e EventPayloadCheckServiceNode) MatchesKey(key, namespace, partition strin
 	}
 
 	return (key == "" || strings.EqualFold(key, name)) &&
-		(namespace == "" || strings.EqualFold(namespace, ns)) &&
+		(namespace == "" || namespace == structs.WildcardSpecifier || strings.EqualFold(namespace, ns)) &&
 		(partition == "" || strings.EqualFold(partition, ap))
 }
 
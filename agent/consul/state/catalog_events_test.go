@@ -2320,6 +2320,20 @@ func TestEventPayloadCheckServiceNode_FilterByKey(t *testing.T) {
 			namespace: "ns1",
 			expected:  false,
 		},
+		{
+			name:      "key match, wildcard namespace",
+			payload:   newPayloadCheckServiceNode("srv1", "ns1"),
+			key:       "srv1",
+			namespace: structs.WildcardSpecifier,
+			expected:  true,
+		},
+		{
+			name:      "key mismatch, wildcard namespace",
+			payload:   newPayloadCheckServiceNode("srv1", "ns1"),
+			key:       "srv2",
+			namespace: structs.WildcardSpecifier,
+			expected:  false,
+		},
 		{
 			name:      "override key match",
 			payload:   newPayloadCheckServiceNodeWithOverride("proxy", "ns1", "srv1", ""),
@@ -0,0 +1,178 @@
+package state
+
+import (
+	"strings"
+
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/consul/stream"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/proto/pbsubscribe"
+)
+
+// EventPayloadNode is used as the Payload for a stream.Event to indicate
+// changes to a node, or to a service or check registered on the node. Exactly
+// one of Node, Service, or Check is set.
+//
+// The stream.Payload methods implemented by EventPayloadNode do not mutate the
+// payload, making it safe to use in an Event sent to
+// stream.EventPublisher.Publish.
+type EventPayloadNode struct {
+	Op      pbsubscribe.CatalogOp
+	Node    *structs.Node
+	Service *structs.ServiceNode
+	Check   *structs.HealthCheck
+}
+
+// NodeName returns the name of the node the payload applies to.
+func (e EventPayloadNode) NodeName() string {
+	switch {
+	case e.Service != nil:
+		return e.Service.Node
+	case e.Check != nil:
+		return e.Check.Node
+	default:
+		return e.Node.Node
+	}
+}
+
+// HasReadPermission uses the same rules as the Catalog.NodeServices and
+// Health.NodeChecks endpoints. A service requires read access to both the node
+// and the service, and a check for a service also requires read access to the
+// service.
+func (e EventPayloadNode) HasReadPermission(authz acl.Authorizer) bool {
+	var authzContext acl.AuthorizerContext
+	switch {
+	case e.Service != nil:
+		e.Service.FillAuthzContext(&authzContext)
+		return authz.NodeRead(e.Service.Node, &authzContext) == acl.Allow &&
+			authz.ServiceRead(e.Service.ServiceName, &authzContext) == acl.Allow
+	case e.Check != nil:
+		e.Check.FillAuthzContext(&authzContext)
+		return authz.NodeRead(e.Check.Node, &authzContext) == acl.Allow &&
+			(e.Check.ServiceName == "" || authz.ServiceRead(e.Check.ServiceName, &authzContext) == acl.Allow)
+	default:
+		e.Node.FillAuthzContext(&authzContext)
+		return authz.NodeRead(e.Node.Node, &authzContext) == acl.Allow
+	}
+}
+
+// MatchesKey returns true if the name of the node matches key. An empty key
+// matches every node. Nodes are not namespaced, so the namespace is only
+// matched by services and checks.
+func (e EventPayloadNode) MatchesKey(key, namespace, partition string) bool {
+	var entMeta *structs.EnterpriseMeta
+	switch {
+	case e.Service != nil:
+		entMeta = &e.Service.EnterpriseMeta
+	case e.Check != nil:
+		entMeta = &e.Check.EnterpriseMeta
+	default:
+		entMeta = e.Node.GetEnterpriseMeta()
+		namespace = ""
+	}
+	return (key == "" || strings.EqualFold(key, e.NodeName())) &&
+		(namespace == "" || strings.EqualFold(namespace, entMeta.NamespaceOrDefault())) &&
+		(partition == "" || strings.EqualFold(partition, entMeta.PartitionOrDefault()))
+}
+
+// nodeSnapshot returns a stream.SnapshotFunc that provides a snapshot of
+// stream.Events for the node of the request, and for the services and checks
+// registered on the node. When the request has no key the snapshot contains
+// every node.
+func nodeSnapshot(db ReadDB) stream.SnapshotFunc {
+	return func(req stream.SubscribeRequest, buf stream.SnapshotAppender) (uint64, error) {
+		tx := db.ReadTxn()
+		defer tx.Abort()
+
+		entMeta := structs.NewEnterpriseMetaWithPartition(req.Partition, req.Namespace)
+		idx := catalogMaxIndex(tx, &entMeta, true)
+
+		var nodes []*structs.Node
+		if req.Key != "" {
+			node, err := getNodeTxn(tx, req.Key, structs.NodeEnterpriseMetaInPartition(req.Partition))
+			if err != nil {
+				return 0, err
+			}
+			if node != nil {
+				nodes = append(nodes, node)
+			}
+		} else {
+			iter, err := tx.Get(tableNodes, indexID+"_prefix", structs.NodeEnterpriseMetaInPartition(req.Partition))
+			if err != nil {
+				return 0, err
+			}
+			for node := iter.Next(); node != nil; node = iter.Next() {
+				nodes = append(nodes, node.(*structs.Node))
+			}
+		}
+
+		for _, node := range nodes {
+			events, err := nodeSnapshotEvents(tx, idx, node, &entMeta)
+			if err != nil {
+				return 0, err
+			}
+			buf.Append(events)
+		}
+		return idx, nil
+	}
+}
+
+// nodeSnapshotEvents returns the events that register node, along with its
+// services and checks.
+func nodeSnapshotEvents(tx ReadTxn, idx uint64, node *structs.Node, entMeta *structs.EnterpriseMeta) ([]stream.Event, error) {
+	newEvent := func(payload EventPayloadNode) stream.Event {
+		payload.Op = pbsubscribe.CatalogOp_Register
+		return stream.Event{Index: idx, Topic: topicNode, Payload: payload}
+	}
+	events := []stream.Event{newEvent(EventPayloadNode{Node: node})}
+
+	services, err := catalogServiceListByNode(tx, node.Node, entMeta, false)
+	if err != nil {
+		return nil, err
+	}
+	for service := services.Next(); service != nil; service = services.Next() {
+		events = append(events, newEvent(EventPayloadNode{Service: service.(*structs.ServiceNode)}))
+	}
+
+	checks, err := catalogListChecksByNode(tx, Query{Value: node.Node, EnterpriseMeta: *entMeta})
+	if err != nil {
+		return nil, err
+	}
+	for check := checks.Next(); check != nil; check = checks.Next() {
+		events = append(events, newEvent(EventPayloadNode{Check: check.(*structs.HealthCheck)}))
+	}
+	return events, nil
+}
+
+// NodeEventsFromChanges returns the events that should be emitted for the
+// changes to nodes, services, and checks in a set of changes to the state
+// store.
+//
+// A node which is renamed is deleted and registered again with its new name,
+// so subscribers to the previous name receive a Deregister of the node.
+func NodeEventsFromChanges(_ ReadTxn, changes Changes) ([]stream.Event, error) {
+	var events []stream.Event
+	for _, change := range changes.Changes {
+		payload := EventPayloadNode{Op: pbsubscribe.CatalogOp_Register}
+		if change.Deleted() {
+			payload.Op = pbsubscribe.CatalogOp_Deregister
+		}
+
+		switch change.Table {
+		case tableNodes:
+			payload.Node = changeObject(change).(*structs.Node)
+		case tableServices:
+			payload.Service = changeObject(change).(*structs.ServiceNode)
+		case tableChecks:
+			payload.Check = changeObject(change).(*structs.HealthCheck)
+		default:
+			continue
+		}
+		events = append(events, stream.Event{
+			Index:   changes.Index,
+			Topic:   topicNode,
+			Payload: payload,
+		})
+	}
+	return events, nil
+}
@@ -0,0 +1,160 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/consul/stream"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/proto/pbsubscribe"
+)
+
+// nodePayloadID identifies the node, service, or check of a payload in tests.
+func nodePayloadID(p EventPayloadNode) string {
+	switch {
+	case p.Service != nil:
+		return p.Service.Node + "/service/" + p.Service.ServiceID
+	case p.Check != nil:
+		return p.Check.Node + "/check/" + string(p.Check.CheckID)
+	default:
+		return p.Node.Node
+	}
+}
+
+func TestNodeSnapshot(t *testing.T) {
+	store := NewStateStore(nil)
+
+	counter := newIndexCounter()
+	require.NoError(t, store.EnsureRegistration(counter.Next(), testServiceRegistration(t, "db")))
+	require.NoError(t, store.EnsureRegistration(counter.Next(), testServiceRegistration(t, "web")))
+	require.NoError(t, store.EnsureRegistration(counter.Next(), testServiceRegistration(t, "web", regNode2)))
+
+	fn := nodeSnapshot((*readDB)(store.db.db))
+
+	run := func(t *testing.T, key string) []string {
+		buf := &snapshotAppender{}
+		idx, err := fn(stream.SubscribeRequest{Key: key}, buf)
+		require.NoError(t, err)
+		require.Equal(t, counter.Last(), idx)
+
+		var got []string
+		for _, events := range buf.events {
+			for _, event := range events {
+				require.Equal(t, topicNode, event.Topic)
+				payload := event.Payload.(EventPayloadNode)
+				require.Equal(t, pbsubscribe.CatalogOp_Register, payload.Op)
+				got = append(got, nodePayloadID(payload))
+			}
+		}
+		return got
+	}
+
+	t.Run("with a key", func(t *testing.T) {
+		require.ElementsMatch(t, []string{
+			"node1",
+			"node1/service/db",
+			"node1/service/web",
+			"node1/check/serf-health",
+			"node1/check/service:db",
+			"node1/check/service:web",
+		}, run(t, "node1"))
+	})
+
+	t.Run("without a key", func(t *testing.T) {
+		require.Len(t, run(t, ""), 10)
+	})
+
+	t.Run("unknown node", func(t *testing.T) {
+		require.Empty(t, run(t, "node3"))
+	})
+}
+
+func TestNodeEventsFromChanges(t *testing.T) {
+	s := testStateStore(t)
+
+	setupTx := s.db.WriteTxn(10)
+	require.NoError(t, s.ensureRegistrationTxn(setupTx, 10, false, testServiceRegistration(t, "db"), false))
+	require.NoError(t, setupTx.Commit())
+
+	t.Run("register and deregister a service", func(t *testing.T) {
+		tx := s.db.WriteTxn(100)
+		defer tx.Abort()
+		require.NoError(t, s.ensureRegistrationTxn(tx, 100, false, testServiceRegistration(t, "web"), false))
+		require.NoError(t, s.deleteServiceTxn(tx, 100, "node1", "db", nil))
+
+		events, err := NodeEventsFromChanges(tx, Changes{Index: 100, Changes: tx.Changes()})
+		require.NoError(t, err)
+
+		ops := make(map[string]pbsubscribe.CatalogOp)
+		for _, event := range events {
+			require.Equal(t, topicNode, event.Topic)
+			require.Equal(t, uint64(100), event.Index)
+			payload := event.Payload.(EventPayloadNode)
+			ops[nodePayloadID(payload)] = payload.Op
+		}
+		require.Equal(t, pbsubscribe.CatalogOp_Register, ops["node1/service/web"])
+		require.Equal(t, pbsubscribe.CatalogOp_Register, ops["node1/check/service:web"])
+		require.Equal(t, pbsubscribe.CatalogOp_Deregister, ops["node1/service/db"])
+		require.Equal(t, pbsubscribe.CatalogOp_Deregister, ops["node1/check/service:db"])
+	})
+
+	t.Run("rename a node", func(t *testing.T) {
+		tx := s.db.WriteTxn(100)
+		defer tx.Abort()
+		req := testNodeRegistration(t)
+		req.Node = "node-renamed"
+		req.Checks = nil
+		require.NoError(t, s.ensureRegistrationTxn(tx, 100, false, req, false))
+
+		events, err := NodeEventsFromChanges(tx, Changes{Index: 100, Changes: tx.Changes()})
+		require.NoError(t, err)
+
+		ops := make(map[string]pbsubscribe.CatalogOp)
+		for _, event := range events {
+			payload := event.Payload.(EventPayloadNode)
+			ops[nodePayloadID(payload)] = payload.Op
+		}
+		require.Equal(t, pbsubscribe.CatalogOp_Deregister, ops["node1"])
+		require.Equal(t, pbsubscribe.CatalogOp_Deregister, ops["node1/service/db"])
+		require.Equal(t, pbsubscribe.CatalogOp_Register, ops["node-renamed"])
+	})
+}
+
+func TestEventPayloadNode_MatchesKey(t *testing.T) {
+	node := EventPayloadNode{Node: &structs.Node{Node: "node1"}}
+	require.True(t, node.MatchesKey("", "", ""))
+	require.True(t, node.MatchesKey("NODE1", "", ""))
+	require.True(t, node.MatchesKey("node1", "ns1", ""))
+	require.False(t, node.MatchesKey("node2", "", ""))
+
+	service := EventPayloadNode{Service: &structs.ServiceNode{Node: "node1", ServiceName: "web"}}
+	require.True(t, service.MatchesKey("node1", "", ""))
+	require.False(t, service.MatchesKey("web", "", ""))
+
+	check := EventPayloadNode{Check: &structs.HealthCheck{Node: "node1", CheckID: "serf-health"}}
+	require.True(t, check.MatchesKey("node1", "", ""))
+	require.False(t, check.MatchesKey("node2", "", ""))
+}
+
+func TestEventPayloadNode_HasReadPermission(t *testing.T) {
+	policy, err := acl.NewPolicyFromSource("", 0, `
+node "node1" { policy = "read" }
+service "web" { policy = "read" }
+`, acl.SyntaxCurrent, nil, nil)
+	require.NoError(t, err)
+	authz, err := acl.NewPolicyAuthorizerWithDefaults(acl.DenyAll(), []*acl.Policy{policy}, nil)
+	require.NoError(t, err)
+
+	require.True(t, EventPayloadNode{Node: &structs.Node{Node: "node1"}}.HasReadPermission(authz))
+	require.False(t, EventPayloadNode{Node: &structs.Node{Node: "node2"}}.HasReadPermission(authz))
+
+	require.True(t, EventPayloadNode{Service: &structs.ServiceNode{Node: "node1", ServiceName: "web"}}.HasReadPermission(authz))
+	require.False(t, EventPayloadNode{Service: &structs.ServiceNode{Node: "node1", ServiceName: "db"}}.HasReadPermission(authz))
+
+	require.True(t, EventPayloadNode{Check: &structs.HealthCheck{Node: "node1", CheckID: "serf-health"}}.HasReadPermission(authz))
+	require.True(t, EventPayloadNode{Check: &structs.HealthCheck{Node: "node1", ServiceName: "web"}}.HasReadPermission(authz))
+	require.False(t, EventPayloadNode{Check: &structs.HealthCheck{Node: "node1", ServiceName: "db"}}.HasReadPermission(authz))
+	require.False(t, EventPayloadNode{Check: &structs.HealthCheck{Node: "node2", CheckID: "serf-health"}}.HasReadPermission(authz))
+}
@@ -0,0 +1,92 @@
+package state
+
+import (
+	"strings"
+
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/consul/stream"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/proto/pbsubscribe"
+)
+
+// EventPayloadCatalogService is used as the Payload for a stream.Event to
+// indicate changes to a service instance in the catalog.
+//
+// The stream.Payload methods implemented by EventPayloadCatalogService do not
+// mutate the payload, making it safe to use in an Event sent to
+// stream.EventPublisher.Publish.
+type EventPayloadCatalogService struct {
+	Op    pbsubscribe.CatalogOp
+	Value *structs.ServiceNode
+}
+
+func (e EventPayloadCatalogService) HasReadPermission(authz acl.Authorizer) bool {
+	var authzContext acl.AuthorizerContext
+	e.Value.FillAuthzContext(&authzContext)
+	return authz.ServiceRead(e.Value.ServiceName, &authzContext) == acl.Allow
+}
+
+// MatchesKey returns true if the name of the service matches key. An empty key
+// matches every service.
+func (e EventPayloadCatalogService) MatchesKey(key, namespace, partition string) bool {
+	return (key == "" || strings.EqualFold(key, e.Value.ServiceName)) &&
+		(namespace == "" || strings.EqualFold(namespace, e.Value.EnterpriseMeta.NamespaceOrDefault())) &&
+		(partition == "" || strings.EqualFold(partition, e.Value.EnterpriseMeta.PartitionOrDefault()))
+}
+
+// catalogServicesSnapshot returns a stream.SnapshotFunc that provides a
+// snapshot of stream.Events for every service instance in the catalog.
+func catalogServicesSnapshot(db ReadDB) stream.SnapshotFunc {
+	return func(req stream.SubscribeRequest, buf stream.SnapshotAppender) (uint64, error) {
+		tx := db.ReadTxn()
+		defer tx.Abort()
+
+		entMeta := structs.NewEnterpriseMetaWithPartition(req.Partition, req.Namespace)
+		idx := catalogServicesMaxIndex(tx, &entMeta)
+		services, err := catalogServiceListNoWildcard(tx, &entMeta)
+		if err != nil {
+			return 0, err
+		}
+
+		for service := services.Next(); service != nil; service = services.Next() {
+			sn := service.(*structs.ServiceNode)
+			if req.Key != "" && !strings.EqualFold(req.Key, sn.ServiceName) {
+				continue
+			}
+			buf.Append([]stream.Event{{
+				Index: idx,
+				Topic: topicCatalogServices,
+				Payload: EventPayloadCatalogService{
+					Op:    pbsubscribe.CatalogOp_Register,
+					Value: sn,
+				},
+			}})
+		}
+		return idx, nil
+	}
+}
+
+// CatalogServicesEventsFromChanges returns the events that should be emitted
+// for the changes to service instances in a set of changes to the state store.
+func CatalogServicesEventsFromChanges(_ ReadTxn, changes Changes) ([]stream.Event, error) {
+	var events []stream.Event
+	for _, change := range changes.Changes {
+		if change.Table != tableServices {
+			continue
+		}
+
+		op := pbsubscribe.CatalogOp_Register
+		if change.Deleted() {
+			op = pbsubscribe.CatalogOp_Deregister
+		}
+		events = append(events, stream.Event{
+			Index: changes.Index,
+			Topic: topicCatalogServices,
+			Payload: EventPayloadCatalogService{
+				Op:    op,
+				Value: changeObject(change).(*structs.ServiceNode),
+			},
+		})
+	}
+	return events, nil
+}
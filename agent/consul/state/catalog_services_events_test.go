@@ -0,0 +1,72 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/consul/stream"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/proto/pbsubscribe"
+)
+
+func TestCatalogServicesSnapshot(t *testing.T) {
+	store := NewStateStore(nil)
+
+	counter := newIndexCounter()
+	require.NoError(t, store.EnsureRegistration(counter.Next(), testServiceRegistration(t, "db")))
+	require.NoError(t, store.EnsureRegistration(counter.Next(), testServiceRegistration(t, "web")))
+	require.NoError(t, store.EnsureRegistration(counter.Next(), testServiceRegistration(t, "web", regNode2)))
+
+	fn := catalogServicesSnapshot((*readDB)(store.db.db))
+	buf := &snapshotAppender{}
+
+	idx, err := fn(stream.SubscribeRequest{}, buf)
+	require.NoError(t, err)
+	require.Equal(t, counter.Last(), idx)
+
+	names := make(map[string]int)
+	for _, events := range buf.events {
+		require.Len(t, events, 1)
+		require.Equal(t, topicCatalogServices, events[0].Topic)
+		payload := events[0].Payload.(EventPayloadCatalogService)
+		require.Equal(t, pbsubscribe.CatalogOp_Register, payload.Op)
+		names[payload.Value.ServiceName]++
+	}
+	require.Equal(t, map[string]int{"db": 1, "web": 2}, names)
+}
+
+func TestCatalogServicesEventsFromChanges(t *testing.T) {
+	s := testStateStore(t)
+
+	setupTx := s.db.WriteTxn(10)
+	require.NoError(t, s.ensureRegistrationTxn(setupTx, 10, false, testServiceRegistration(t, "db"), false))
+	require.NoError(t, setupTx.Commit())
+
+	tx := s.db.WriteTxn(100)
+	require.NoError(t, s.ensureRegistrationTxn(tx, 100, false, testServiceRegistration(t, "web"), false))
+	require.NoError(t, s.deleteServiceTxn(tx, 100, "node1", "db", nil))
+
+	events, err := CatalogServicesEventsFromChanges(tx, Changes{Index: 100, Changes: tx.Changes()})
+	require.NoError(t, err)
+
+	ops := make(map[string]pbsubscribe.CatalogOp)
+	for _, event := range events {
+		require.Equal(t, topicCatalogServices, event.Topic)
+		require.Equal(t, uint64(100), event.Index)
+		payload := event.Payload.(EventPayloadCatalogService)
+		ops[payload.Value.ServiceName] = payload.Op
+	}
+	require.Equal(t, map[string]pbsubscribe.CatalogOp{
+		"web": pbsubscribe.CatalogOp_Register,
+		"db":  pbsubscribe.CatalogOp_Deregister,
+	}, ops)
+}
+
+func TestEventPayloadCatalogService_MatchesKey(t *testing.T) {
+	payload := EventPayloadCatalogService{Value: &structs.ServiceNode{ServiceName: "web"}}
+
+	require.True(t, payload.MatchesKey("", "", ""))
+	require.True(t, payload.MatchesKey("WEB", "", ""))
+	require.False(t, payload.MatchesKey("db", "", ""))
+}
@@ -0,0 +1,87 @@
+package state
+
+import (
+	"strings"
+
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/consul/stream"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/proto/pbsubscribe"
+)
+
+// EventPayloadConfigEntry is used as the Payload for a stream.Event to
+// indicate changes to a config entry.
+//
+// The stream.Payload methods implemented by EventPayloadConfigEntry do not
+// mutate the payload, making it safe to use in an Event sent to
+// stream.EventPublisher.Publish.
+type EventPayloadConfigEntry struct {
+	Op    pbsubscribe.UpdateOp
+	Value structs.ConfigEntry
+}
+
+func (e EventPayloadConfigEntry) HasReadPermission(authz acl.Authorizer) bool {
+	return e.Value.CanRead(authz)
+}
+
+// MatchesKey returns true if the kind of the config entry matches key. An
+// empty key matches every kind. A wildcard namespace or partition matches
+// every namespace or partition.
+func (e EventPayloadConfigEntry) MatchesKey(key, namespace, partition string) bool {
+	entMeta := e.Value.GetEnterpriseMeta()
+	return (key == "" || strings.EqualFold(key, e.Value.GetKind())) &&
+		(namespace == "" || namespace == structs.WildcardSpecifier || strings.EqualFold(namespace, entMeta.NamespaceOrDefault())) &&
+		(partition == "" || partition == structs.WildcardSpecifier || strings.EqualFold(partition, entMeta.PartitionOrDefault()))
+}
+
+// configEntriesSnapshot returns a stream.SnapshotFunc that provides a snapshot
+// of stream.Events for every config entry of the kind in the request key.
+func configEntriesSnapshot(db ReadDB) stream.SnapshotFunc {
+	return func(req stream.SubscribeRequest, buf stream.SnapshotAppender) (uint64, error) {
+		tx := db.ReadTxn()
+		defer tx.Abort()
+
+		entMeta := structs.NewEnterpriseMetaWithPartition(req.Partition, req.Namespace)
+		idx, entries, err := configEntriesByKindTxn(tx, nil, req.Key, &entMeta)
+		if err != nil {
+			return 0, err
+		}
+
+		for _, entry := range entries {
+			buf.Append([]stream.Event{{
+				Index: idx,
+				Topic: topicConfigEntries,
+				Payload: EventPayloadConfigEntry{
+					Op:    pbsubscribe.UpdateOp_Upsert,
+					Value: entry,
+				},
+			}})
+		}
+		return idx, nil
+	}
+}
+
+// ConfigEntryEventsFromChanges returns the events that should be emitted for
+// the changes to config entries in a set of changes to the state store.
+func ConfigEntryEventsFromChanges(_ ReadTxn, changes Changes) ([]stream.Event, error) {
+	var events []stream.Event
+	for _, change := range changes.Changes {
+		if change.Table != tableConfigEntries {
+			continue
+		}
+
+		op := pbsubscribe.UpdateOp_Upsert
+		if change.Deleted() {
+			op = pbsubscribe.UpdateOp_Delete
+		}
+		events = append(events, stream.Event{
+			Index: changes.Index,
+			Topic: topicConfigEntries,
+			Payload: EventPayloadConfigEntry{
+				Op:    op,
+				Value: changeObject(change).(structs.ConfigEntry),
+			},
+		})
+	}
+	return events, nil
+}
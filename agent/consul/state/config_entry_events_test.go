@@ -0,0 +1,88 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/consul/stream"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/proto/pbsubscribe"
+)
+
+func TestConfigEntriesSnapshot(t *testing.T) {
+	s := testConfigStateStore(t)
+
+	require.NoError(t, s.EnsureConfigEntry(1, &structs.ServiceConfigEntry{
+		Kind: structs.ServiceDefaults,
+		Name: "web",
+	}))
+	require.NoError(t, s.EnsureConfigEntry(2, &structs.IngressGatewayConfigEntry{
+		Kind: structs.IngressGateway,
+		Name: "ingress-a",
+	}))
+	require.NoError(t, s.EnsureConfigEntry(3, &structs.IngressGatewayConfigEntry{
+		Kind: structs.IngressGateway,
+		Name: "ingress-b",
+	}))
+
+	fn := configEntriesSnapshot((*readDB)(s.db.db))
+	buf := &snapshotAppender{}
+
+	idx, err := fn(stream.SubscribeRequest{Key: structs.IngressGateway}, buf)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), idx)
+
+	var got []string
+	for _, events := range buf.events {
+		require.Len(t, events, 1)
+		require.Equal(t, topicConfigEntries, events[0].Topic)
+		payload := events[0].Payload.(EventPayloadConfigEntry)
+		require.Equal(t, pbsubscribe.UpdateOp_Upsert, payload.Op)
+		got = append(got, payload.Value.GetKind()+"/"+payload.Value.GetName())
+	}
+	require.ElementsMatch(t, []string{"ingress-gateway/ingress-a", "ingress-gateway/ingress-b"}, got)
+}
+
+func TestConfigEntryEventsFromChanges(t *testing.T) {
+	s := testConfigStateStore(t)
+
+	require.NoError(t, s.EnsureConfigEntry(10, &structs.ServiceConfigEntry{
+		Kind: structs.ServiceDefaults,
+		Name: "db",
+	}))
+
+	tx := s.db.WriteTxn(100)
+	require.NoError(t, ensureConfigEntryTxn(tx, 100, &structs.ServiceConfigEntry{
+		Kind:     structs.ServiceDefaults,
+		Name:     "web",
+		Protocol: "http",
+	}))
+	require.NoError(t, deleteConfigEntryTxn(tx, 100, structs.ServiceDefaults, "db", nil))
+
+	events, err := ConfigEntryEventsFromChanges(tx, Changes{Index: 100, Changes: tx.Changes()})
+	require.NoError(t, err)
+
+	ops := make(map[string]pbsubscribe.UpdateOp)
+	for _, event := range events {
+		require.Equal(t, topicConfigEntries, event.Topic)
+		require.Equal(t, uint64(100), event.Index)
+		payload := event.Payload.(EventPayloadConfigEntry)
+		ops[payload.Value.GetName()] = payload.Op
+	}
+	require.Equal(t, map[string]pbsubscribe.UpdateOp{
+		"web": pbsubscribe.UpdateOp_Upsert,
+		"db":  pbsubscribe.UpdateOp_Delete,
+	}, ops)
+}
+
+func TestEventPayloadConfigEntry_MatchesKey(t *testing.T) {
+	payload := EventPayloadConfigEntry{Value: &structs.IngressGatewayConfigEntry{
+		Kind: structs.IngressGateway,
+		Name: "ingress",
+	}}
+	require.True(t, payload.MatchesKey("", "", ""))
+	require.True(t, payload.MatchesKey("Ingress-Gateway", "", ""))
+	require.True(t, payload.MatchesKey(structs.IngressGateway, "*", "*"))
+	require.False(t, payload.MatchesKey(structs.TerminatingGateway, "", ""))
+}
@@ -0,0 +1,159 @@
+package state
+
+import (
+	"strings"
+
+	memdb "github.com/hashicorp/go-memdb"
+
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/consul/stream"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/proto/pbsubscribe"
+)
+
+// EventPayloadIntention is used as the Payload for a stream.Event to indicate
+// changes to an intention.
+//
+// The stream.Payload methods implemented by EventPayloadIntention do not
+// mutate the payload, making it safe to use in an Event sent to
+// stream.EventPublisher.Publish.
+type EventPayloadIntention struct {
+	Op    pbsubscribe.UpdateOp
+	Value *structs.Intention
+}
+
+// HasReadPermission returns true if the token may read either end of the
+// intention, the same as the ACL filtering of the Intention.List RPC.
+func (e EventPayloadIntention) HasReadPermission(authz acl.Authorizer) bool {
+	return e.Value.CanRead(authz)
+}
+
+// MatchesKey returns true if the destination of the intention is the service
+// named by key, or is a wildcard. An empty key matches every intention.
+func (e EventPayloadIntention) MatchesKey(key, namespace, partition string) bool {
+	ixn := e.Value
+	entMeta := ixn.DestinationEnterpriseMeta()
+	return (key == "" || ixn.DestinationName == structs.WildcardSpecifier || strings.EqualFold(key, ixn.DestinationName)) &&
+		(namespace == "" || ixn.DestinationNS == structs.WildcardSpecifier || strings.EqualFold(namespace, entMeta.NamespaceOrDefault())) &&
+		(partition == "" || strings.EqualFold(partition, entMeta.PartitionOrDefault()))
+}
+
+// intentionsSnapshot returns a stream.SnapshotFunc that provides a snapshot of
+// stream.Events for every intention which matches the request key.
+func intentionsSnapshot(db ReadDB) stream.SnapshotFunc {
+	return func(req stream.SubscribeRequest, buf stream.SnapshotAppender) (uint64, error) {
+		tx := db.ReadTxn()
+		defer tx.Abort()
+
+		usingConfigEntries, err := areIntentionsInConfigEntries(tx, nil)
+		if err != nil {
+			return 0, err
+		}
+
+		entMeta := structs.NewEnterpriseMetaWithPartition(req.Partition, req.Namespace)
+		var (
+			idx  uint64
+			ixns structs.Intentions
+		)
+		if usingConfigEntries {
+			idx, ixns, _, err = configIntentionsListTxn(tx, nil, &entMeta)
+		} else {
+			idx, ixns, _, err = legacyIntentionsListTxn(tx, nil, &entMeta)
+		}
+		if err != nil {
+			return 0, err
+		}
+
+		for _, ixn := range ixns {
+			payload := EventPayloadIntention{Op: pbsubscribe.UpdateOp_Upsert, Value: ixn}
+			if !payload.MatchesKey(req.Key, req.Namespace, req.Partition) {
+				continue
+			}
+			buf.Append([]stream.Event{{
+				Index:   idx,
+				Topic:   topicIntentions,
+				Payload: payload,
+			}})
+		}
+		return idx, nil
+	}
+}
+
+// IntentionEventsFromChanges returns the events that should be emitted for the
+// changes to intentions in a set of changes to the state store. Only changes
+// to the canonical store of intentions are included, so that the deletion of
+// the legacy intentions during the migration to config entries does not
+// remove the intentions from the views of subscribers.
+func IntentionEventsFromChanges(tx ReadTxn, changes Changes) ([]stream.Event, error) {
+	var (
+		usingConfigEntries bool
+		checked            bool
+		events             []stream.Event
+	)
+	for _, change := range changes.Changes {
+		if change.Table != tableConnectIntentions && change.Table != tableConfigEntries {
+			continue
+		}
+		if !checked {
+			var err error
+			usingConfigEntries, err = areIntentionsInConfigEntries(tx, nil)
+			if err != nil {
+				return nil, err
+			}
+			checked = true
+		}
+
+		switch {
+		case change.Table == tableConnectIntentions && !usingConfigEntries:
+			op := pbsubscribe.UpdateOp_Upsert
+			if change.Deleted() {
+				op = pbsubscribe.UpdateOp_Delete
+			}
+			events = append(events, newIntentionEvent(changes.Index, op, changeObject(change).(*structs.Intention)))
+
+		case change.Table == tableConfigEntries && usingConfigEntries:
+			events = append(events, intentionEventsFromConfigEntryChange(changes.Index, change)...)
+		}
+	}
+	return events, nil
+}
+
+// intentionEventsFromConfigEntryChange returns the events for a change to a
+// service-intentions config entry. Sources which were removed from the entry
+// are sent as a Remove, and the sources in the updated entry as an Upsert.
+func intentionEventsFromConfigEntryChange(idx uint64, change memdb.Change) []stream.Event {
+	before, _ := change.Before.(*structs.ServiceIntentionsConfigEntry)
+	after, _ := change.After.(*structs.ServiceIntentionsConfigEntry)
+	if before == nil && after == nil {
+		return nil
+	}
+
+	var events []stream.Event
+	current := make(map[structs.ServiceName]struct{})
+	if after != nil {
+		for _, src := range after.Sources {
+			current[src.SourceServiceName()] = struct{}{}
+		}
+	}
+	if before != nil {
+		for _, src := range before.Sources {
+			if _, ok := current[src.SourceServiceName()]; !ok {
+				events = append(events, newIntentionEvent(idx, pbsubscribe.UpdateOp_Delete, before.ToIntention(src)))
+			}
+		}
+	}
+	if after != nil {
+		for _, ixn := range after.ToIntentions() {
+			events = append(events, newIntentionEvent(idx, pbsubscribe.UpdateOp_Upsert, ixn))
+		}
+	}
+	return events
+}
+
+func newIntentionEvent(idx uint64, op pbsubscribe.UpdateOp, ixn *structs.Intention) stream.Event {
+	return stream.Event{
+		Index:   idx,
+		Topic:   topicIntentions,
+		Payload: EventPayloadIntention{Op: op, Value: ixn},
+	}
+}
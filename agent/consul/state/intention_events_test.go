@@ -0,0 +1,134 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/consul/stream"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/proto/pbsubscribe"
+)
+
+func TestIntentionsSnapshot(t *testing.T) {
+	testBothIntentionFormats(t, func(t *testing.T, s *Store, legacy bool) {
+		if legacy {
+			require.NoError(t, s.LegacyIntentionSet(1, testEventIntention("api", "web")))
+			require.NoError(t, s.LegacyIntentionSet(2, testEventIntention("*", "*")))
+			require.NoError(t, s.LegacyIntentionSet(3, testEventIntention("web", "db")))
+		} else {
+			require.NoError(t, s.EnsureConfigEntry(1, testEventIntentionsConfigEntry("web", "api")))
+			require.NoError(t, s.EnsureConfigEntry(2, testEventIntentionsConfigEntry("*", "*")))
+			require.NoError(t, s.EnsureConfigEntry(3, testEventIntentionsConfigEntry("db", "web")))
+		}
+
+		fn := intentionsSnapshot((*readDB)(s.db.db))
+		buf := &snapshotAppender{}
+
+		idx, err := fn(stream.SubscribeRequest{Key: "web"}, buf)
+		require.NoError(t, err)
+		require.Equal(t, uint64(3), idx)
+
+		var got []string
+		for _, events := range buf.events {
+			require.Len(t, events, 1)
+			require.Equal(t, topicIntentions, events[0].Topic)
+			payload := events[0].Payload.(EventPayloadIntention)
+			require.Equal(t, pbsubscribe.UpdateOp_Upsert, payload.Op)
+			got = append(got, payload.Value.SourceName+"->"+payload.Value.DestinationName)
+		}
+		require.ElementsMatch(t, []string{"api->web", "*->*"}, got)
+	})
+}
+
+func TestIntentionEventsFromChanges_Legacy(t *testing.T) {
+	s := testStateStore(t)
+
+	removed := testEventIntention("api", "db")
+	require.NoError(t, s.LegacyIntentionSet(10, removed))
+
+	tx := s.db.WriteTxn(100)
+	require.NoError(t, legacyIntentionSetTxn(tx, 100, testEventIntention("api", "web")))
+	require.NoError(t, legacyIntentionDeleteTxn(tx, 100, removed.ID))
+
+	events, err := IntentionEventsFromChanges(tx, Changes{Index: 100, Changes: tx.Changes()})
+	require.NoError(t, err)
+	require.Equal(t, map[string]pbsubscribe.UpdateOp{
+		"api->web": pbsubscribe.UpdateOp_Upsert,
+		"api->db":  pbsubscribe.UpdateOp_Delete,
+	}, intentionEventOps(t, events))
+}
+
+func TestIntentionEventsFromChanges_ConfigEntries(t *testing.T) {
+	s := testConfigStateStore(t)
+
+	require.NoError(t, s.EnsureConfigEntry(10, testEventIntentionsConfigEntry("web", "api", "admin")))
+	require.NoError(t, s.EnsureConfigEntry(11, testEventIntentionsConfigEntry("db", "web")))
+
+	tx := s.db.WriteTxn(100)
+	require.NoError(t, ensureConfigEntryTxn(tx, 100, testEventIntentionsConfigEntry("web", "api")))
+	require.NoError(t, deleteConfigEntryTxn(tx, 100, structs.ServiceIntentions, "db", nil))
+	// Other kinds of config entries do not emit events.
+	require.NoError(t, ensureConfigEntryTxn(tx, 100, &structs.ServiceConfigEntry{
+		Kind: structs.ServiceDefaults,
+		Name: "web",
+	}))
+
+	events, err := IntentionEventsFromChanges(tx, Changes{Index: 100, Changes: tx.Changes()})
+	require.NoError(t, err)
+	require.Equal(t, map[string]pbsubscribe.UpdateOp{
+		"api->web":   pbsubscribe.UpdateOp_Upsert,
+		"admin->web": pbsubscribe.UpdateOp_Delete,
+		"web->db":    pbsubscribe.UpdateOp_Delete,
+	}, intentionEventOps(t, events))
+}
+
+func TestEventPayloadIntention_MatchesKey(t *testing.T) {
+	payload := EventPayloadIntention{Value: testEventIntention("api", "web")}
+	require.True(t, payload.MatchesKey("", "", ""))
+	require.True(t, payload.MatchesKey("WEB", "", ""))
+	require.False(t, payload.MatchesKey("db", "", ""))
+
+	wildcard := EventPayloadIntention{Value: testEventIntention("api", "*")}
+	require.True(t, wildcard.MatchesKey("db", "", ""))
+}
+
+func intentionEventOps(t *testing.T, events []stream.Event) map[string]pbsubscribe.UpdateOp {
+	t.Helper()
+	ops := make(map[string]pbsubscribe.UpdateOp)
+	for _, event := range events {
+		require.Equal(t, topicIntentions, event.Topic)
+		require.Equal(t, uint64(100), event.Index)
+		payload := event.Payload.(EventPayloadIntention)
+		ops[payload.Value.SourceName+"->"+payload.Value.DestinationName] = payload.Op
+	}
+	return ops
+}
+
+func testEventIntention(src, dst string) *structs.Intention {
+	ixn := &structs.Intention{
+		ID:              testUUID(),
+		SourceNS:        "default",
+		SourceName:      src,
+		DestinationNS:   "default",
+		DestinationName: dst,
+		SourceType:      structs.IntentionSourceConsul,
+		Action:          structs.IntentionActionAllow,
+	}
+	ixn.UpdatePrecedence()
+	return ixn
+}
+
+func testEventIntentionsConfigEntry(dst string, srcs ...string) *structs.ServiceIntentionsConfigEntry {
+	conf := &structs.ServiceIntentionsConfigEntry{
+		Kind: structs.ServiceIntentions,
+		Name: dst,
+	}
+	for _, src := range srcs {
+		conf.Sources = append(conf.Sources, &structs.SourceIntention{
+			Name:   src,
+			Action: structs.IntentionActionAllow,
+		})
+	}
+	return conf
+}
@@ -0,0 +1,88 @@
+package state
+
+import (
+	"strings"
+
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/consul/stream"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/proto/pbsubscribe"
+)
+
+// EventPayloadKV is used as the Payload for a stream.Event to indicate changes
+// to an entry in the KV store.
+//
+// The stream.Payload methods implemented by EventPayloadKV do not mutate the
+// payload, making it safe to use in an Event sent to
+// stream.EventPublisher.Publish.
+type EventPayloadKV struct {
+	Op    pbsubscribe.UpdateOp
+	Value *structs.DirEntry
+}
+
+func (e EventPayloadKV) HasReadPermission(authz acl.Authorizer) bool {
+	var authzContext acl.AuthorizerContext
+	e.Value.FillAuthzContext(&authzContext)
+	return authz.KeyRead(e.Value.Key, &authzContext) == acl.Allow
+}
+
+// MatchesKey returns true if the key of the entry has the prefix key.
+func (e EventPayloadKV) MatchesKey(key, namespace, partition string) bool {
+	return strings.HasPrefix(e.Value.Key, key) &&
+		(namespace == "" || strings.EqualFold(namespace, e.Value.EnterpriseMeta.NamespaceOrDefault())) &&
+		(partition == "" || strings.EqualFold(partition, e.Value.EnterpriseMeta.PartitionOrDefault()))
+}
+
+// kvSnapshot returns a stream.SnapshotFunc that provides a snapshot of
+// stream.Events for every entry with a key that has the prefix of the
+// request key.
+func kvSnapshot(db ReadDB) stream.SnapshotFunc {
+	return func(req stream.SubscribeRequest, buf stream.SnapshotAppender) (uint64, error) {
+		tx := db.ReadTxn()
+		defer tx.Abort()
+
+		entMeta := structs.NewEnterpriseMetaWithPartition(req.Partition, req.Namespace)
+		idx := kvsMaxIndex(tx, &entMeta)
+		_, entries, err := kvsListEntriesTxn(tx, nil, req.Key, &entMeta)
+		if err != nil {
+			return 0, err
+		}
+
+		for _, entry := range entries {
+			buf.Append([]stream.Event{{
+				Index: idx,
+				Topic: topicKV,
+				Payload: EventPayloadKV{
+					Op:    pbsubscribe.UpdateOp_Upsert,
+					Value: entry,
+				},
+			}})
+		}
+		return idx, nil
+	}
+}
+
+// KVEventsFromChanges returns the events that should be emitted for the
+// changes to the KV store in a set of changes to the state store.
+func KVEventsFromChanges(_ ReadTxn, changes Changes) ([]stream.Event, error) {
+	var events []stream.Event
+	for _, change := range changes.Changes {
+		if change.Table != "kvs" {
+			continue
+		}
+
+		op := pbsubscribe.UpdateOp_Upsert
+		if change.Deleted() {
+			op = pbsubscribe.UpdateOp_Delete
+		}
+		events = append(events, stream.Event{
+			Index: changes.Index,
+			Topic: topicKV,
+			Payload: EventPayloadKV{
+				Op:    op,
+				Value: changeObject(change).(*structs.DirEntry),
+			},
+		})
+	}
+	return events, nil
+}
@@ -0,0 +1,77 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/consul/stream"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/proto/pbsubscribe"
+)
+
+func TestKVSnapshot(t *testing.T) {
+	store := NewStateStore(nil)
+
+	require.NoError(t, store.KVSSet(1, &structs.DirEntry{Key: "web/one", Value: []byte("1")}))
+	require.NoError(t, store.KVSSet(2, &structs.DirEntry{Key: "web/two", Value: []byte("2")}))
+	require.NoError(t, store.KVSSet(3, &structs.DirEntry{Key: "db/one", Value: []byte("3")}))
+
+	fn := kvSnapshot((*readDB)(store.db.db))
+	buf := &snapshotAppender{}
+
+	idx, err := fn(stream.SubscribeRequest{Key: "web/"}, buf)
+	require.NoError(t, err)
+	require.Equal(t, uint64(3), idx)
+
+	require.Len(t, buf.events, 2)
+	var keys []string
+	for _, events := range buf.events {
+		require.Len(t, events, 1)
+		require.Equal(t, topicKV, events[0].Topic)
+		require.Equal(t, uint64(3), events[0].Index)
+
+		payload := events[0].Payload.(EventPayloadKV)
+		require.Equal(t, pbsubscribe.UpdateOp_Upsert, payload.Op)
+		keys = append(keys, payload.Value.Key)
+	}
+	require.Equal(t, []string{"web/one", "web/two"}, keys)
+}
+
+func TestKVEventsFromChanges(t *testing.T) {
+	s := testStateStore(t)
+
+	setupTx := s.db.WriteTxn(10)
+	require.NoError(t, kvsSetTxn(setupTx, 10, &structs.DirEntry{Key: "gone"}, false))
+	require.NoError(t, setupTx.Commit())
+
+	tx := s.db.WriteTxn(100)
+	require.NoError(t, kvsSetTxn(tx, 100, &structs.DirEntry{Key: "new", Value: []byte("v")}, false))
+	require.NoError(t, s.kvsDeleteTxn(tx, 100, "gone", nil))
+
+	events, err := KVEventsFromChanges(tx, Changes{Index: 100, Changes: tx.Changes()})
+	require.NoError(t, err)
+	require.Len(t, events, 2)
+
+	ops := make(map[string]pbsubscribe.UpdateOp)
+	for _, event := range events {
+		require.Equal(t, topicKV, event.Topic)
+		require.Equal(t, uint64(100), event.Index)
+		payload := event.Payload.(EventPayloadKV)
+		ops[payload.Value.Key] = payload.Op
+	}
+	require.Equal(t, map[string]pbsubscribe.UpdateOp{
+		"new":  pbsubscribe.UpdateOp_Upsert,
+		"gone": pbsubscribe.UpdateOp_Delete,
+	}, ops)
+}
+
+func TestEventPayloadKV_MatchesKey(t *testing.T) {
+	payload := EventPayloadKV{Value: &structs.DirEntry{Key: "web/config"}}
+
+	require.True(t, payload.MatchesKey("", "", ""))
+	require.True(t, payload.MatchesKey("web/", "", ""))
+	require.True(t, payload.MatchesKey("web/config", "", ""))
+	require.False(t, payload.MatchesKey("web/config/", "", ""))
+	require.False(t, payload.MatchesKey("db/", "", ""))
+}
@@ -181,6 +181,11 @@ func (db *readDB) ReadTxn() AbortTxn {
 var (
 	topicServiceHealth        = pbsubscribe.Topic_ServiceHealth
 	topicServiceHealthConnect = pbsubscribe.Topic_ServiceHealthConnect
+	topicKV                   = pbsubscribe.Topic_KV
+	topicCatalogServices      = pbsubscribe.Topic_CatalogServices
+	topicIntentions           = pbsubscribe.Topic_Intentions
+	topicConfigEntries        = pbsubscribe.Topic_ConfigEntries
+	topicNode                 = pbsubscribe.Topic_Node
 )
 
 func processDBChanges(tx ReadTxn, changes Changes) ([]stream.Event, error) {
@@ -188,6 +193,11 @@ func processDBChanges(tx ReadTxn, changes Changes) ([]stream.Event, error) {
 	fns := []func(tx ReadTxn, changes Changes) ([]stream.Event, error){
 		aclChangeUnsubscribeEvent,
 		ServiceHealthEventsFromChanges,
+		KVEventsFromChanges,
+		CatalogServicesEventsFromChanges,
+		IntentionEventsFromChanges,
+		ConfigEntryEventsFromChanges,
+		NodeEventsFromChanges,
 		// TODO: add other table handlers here.
 	}
 	for _, fn := range fns {
@@ -204,5 +214,10 @@ func newSnapshotHandlers(db ReadDB) stream.SnapshotHandlers {
 	return stream.SnapshotHandlers{
 		topicServiceHealth:        serviceHealthSnapshot(db, topicServiceHealth),
 		topicServiceHealthConnect: serviceHealthSnapshot(db, topicServiceHealthConnect),
+		topicKV:                   kvSnapshot(db),
+		topicCatalogServices:      catalogServicesSnapshot(db),
+		topicIntentions:           intentionsSnapshot(db),
+		topicConfigEntries:        configEntriesSnapshot(db),
+		topicNode:                 nodeSnapshot(db),
 	}
 }
@@ -2,6 +2,8 @@ package consul
 
 import (
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/hashicorp/consul/acl"
 	"github.com/hashicorp/consul/agent/consul/stream"
@@ -27,9 +29,31 @@ func (s subscribeBackend) ResolveTokenAndDefaultMeta(
 var _ subscribe.Backend = (*subscribeBackend)(nil)
 
 func (s subscribeBackend) Forward(info structs.RPCInfo, f func(*grpc.ClientConn) error) (handled bool, err error) {
-	return s.srv.ForwardGRPC(s.connPool, info, f)
+	dc := info.RequestDatacenter()
+	if dc == "" || dc == s.srv.config.Datacenter {
+		return s.srv.ForwardGRPC(s.connPool, info, f)
+	}
+	// Without any known servers in the datacenter the stream would wait for a
+	// server to be added, so fail like an RPC to the datacenter does instead.
+	if !s.srv.router.HasDatacenter(dc) {
+		return true, structs.ErrNoDCPath
+	}
+	return s.srv.ForwardGRPC(s.connPool, info, func(conn *grpc.ClientConn) error {
+		err := f(conn)
+		// The stream to the server in the remote datacenter broke. Move to
+		// another server in the datacenter, so that the subscription is not
+		// pinned to the same server when the client subscribes again.
+		if status.Code(err) == codes.Unavailable {
+			s.srv.router.CycleGRPCServer(dc)
+		}
+		return err
+	})
 }
 
 func (s subscribeBackend) Subscribe(req *stream.SubscribeRequest) (*stream.Subscription, error) {
 	return s.srv.fsm.State().EventPublisher().Subscribe(req)
 }
+
+func (s subscribeBackend) KeyListPolicyEnabled() bool {
+	return s.srv.config.ACLEnableKeyListPolicy
+}
@@ -92,6 +92,10 @@ type dnsConfig struct {
 	// TTLStict sets TTLs to service by full name match. It Has higher priority than TTLRadix
 	TTLStrict          map[string]time.Duration
 	DisableCompression bool
+	// UseStreamingBackend serves service lookups from the materialized views of
+	// the streaming backend, regardless of UseCache. It is only set on client
+	// agents.
+	UseStreamingBackend bool
 
 	enterpriseDNSConfig
 }
@@ -174,6 +178,9 @@ func GetDNSConfig(conf *config.RuntimeConfig) (*dnsConfig, error) {
 		},
 		enterpriseDNSConfig: getEnterpriseDNSConfig(conf),
 	}
+	// Servers answer lookups from their own state store, so only client agents
+	// serve them from the materialized views of the streaming backend.
+	cfg.UseStreamingBackend = conf.UseStreamingBackend && !conf.ServerMode
 	if conf.DNSServiceTTL != nil {
 		cfg.TTLRadix = radix.New()
 		cfg.TTLStrict = make(map[string]time.Duration)
@@ -1258,6 +1265,7 @@ func (d *DNSServer) lookupServiceNodes(cfg *dnsConfig, lookup serviceLookup) (st
 		ServiceName: lookup.Service,
 		ServiceTags: serviceTags,
 		TagFilter:   lookup.Tag != "",
+		PassingOnly: cfg.OnlyPassing,
 		QueryOptions: structs.QueryOptions{
 			Token:            d.agent.tokens.UserToken(),
 			AllowStale:       cfg.AllowStale,
@@ -1267,6 +1275,12 @@ func (d *DNSServer) lookupServiceNodes(cfg *dnsConfig, lookup serviceLookup) (st
 		},
 		EnterpriseMeta: lookup.EnterpriseMeta,
 	}
+	// The materialized view of the service answers the lookup without a round
+	// trip to the servers. Results which are too stale according to MaxStale
+	// are still read from the leader.
+	if cfg.UseStreamingBackend && d.agent.rpcClientHealth.UsesStreaming(args) {
+		args.QueryOptions.UseCache = true
+	}
 
 	out, _, err := d.agent.rpcClientHealth.ServiceNodes(context.TODO(), args)
 	if err != nil {
@@ -1424,15 +1438,10 @@ func (d *DNSServer) lookupPreparedQuery(cfg *dnsConfig, args structs.PreparedQue
 
 RPC:
 	if cfg.UseCache {
-		raw, m, err := d.agent.cache.Get(context.TODO(), cachetype.PreparedQueryName, &args)
+		reply, m, err := d.agent.rpcClientPreparedQuery.Execute(context.TODO(), &args)
 		if err != nil {
 			return nil, err
 		}
-		reply, ok := raw.(*structs.PreparedQueryExecuteResponse)
-		if !ok {
-			// This should never happen, but we want to protect against panics
-			return nil, err
-		}
 
 		d.logger.Trace("cache results for prepared query",
 			"cache_hit", m.Hit,
@@ -20,6 +20,7 @@ import (
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/lib"
+	"github.com/hashicorp/consul/proto/pbsubscribe"
 	"github.com/hashicorp/consul/sdk/testutil/retry"
 	"github.com/hashicorp/consul/testrpc"
 )
@@ -1648,6 +1649,167 @@ func TestDNS_ServiceLookup(t *testing.T) {
 	}
 }
 
+func TestDNS_ServiceLookup_StreamingBackend(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	s := NewTestAgent(t, `
+		node_name = "test-server"
+		rpc{
+			enable_streaming=true
+		}
+	`)
+	defer s.Shutdown()
+	a := NewTestAgent(t, `
+		node_name = "test-client"
+		bootstrap = false
+		server = false
+		use_streaming_backend=true
+	`)
+	defer a.Shutdown()
+
+	addr := fmt.Sprintf("127.0.0.1:%d", s.Config.SerfPortLAN)
+	_, err := a.JoinLAN([]string{addr}, nil)
+	require.NoError(t, err)
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	args := &structs.RegisterRequest{
+		Datacenter: "dc1",
+		Node:       "foo",
+		Address:    "127.0.0.1",
+		Service: &structs.NodeService{
+			Service: "db",
+			Port:    12345,
+		},
+	}
+	var out struct{}
+	require.NoError(t, a.RPC("Catalog.Register", args, &out))
+
+	m := new(dns.Msg)
+	m.SetQuestion("db.service.consul.", dns.TypeSRV)
+	c := new(dns.Client)
+	in, _, err := c.Exchange(m, a.DNSAddr())
+	require.NoError(t, err)
+	require.Len(t, in.Answer, 1)
+	srvRec, ok := in.Answer[0].(*dns.SRV)
+	require.True(t, ok, "Bad: %#v", in.Answer[0])
+	require.Equal(t, uint16(12345), srvRec.Port)
+	require.Equal(t, "foo.node.dc1.consul.", srvRec.Target)
+
+	// The lookup is served by a materialized view, even though dns_config
+	// does not enable use_cache.
+	entries := a.baseDeps.ViewStore.Entries()
+	require.Len(t, entries, 1)
+	require.Equal(t, pbsubscribe.Topic_ServiceHealth.String(), entries[0].Topic)
+	require.Equal(t, "dc1", entries[0].Datacenter)
+
+	// Later registrations are answered from the updated view.
+	args.Node = "bar"
+	args.Address = "127.0.0.2"
+	require.NoError(t, a.RPC("Catalog.Register", args, &out))
+	retry.Run(t, func(r *retry.R) {
+		in, _, err := c.Exchange(m, a.DNSAddr())
+		require.NoError(r, err)
+		require.Len(r, in.Answer, 2)
+	})
+}
+
+func TestDNS_PreparedQueryLookup_StreamingBackend(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	s := NewTestAgent(t, `
+		node_name = "test-server"
+		rpc{
+			enable_streaming=true
+		}
+	`)
+	defer s.Shutdown()
+	a := NewTestAgent(t, `
+		node_name = "test-client"
+		bootstrap = false
+		server = false
+		use_streaming_backend=true
+		dns_config {
+			use_cache = true
+		}
+	`)
+	defer a.Shutdown()
+
+	addr := fmt.Sprintf("127.0.0.1:%d", s.Config.SerfPortLAN)
+	_, err := a.JoinLAN([]string{addr}, nil)
+	require.NoError(t, err)
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	var out struct{}
+	for _, node := range []string{"foo", "bar"} {
+		args := &structs.RegisterRequest{
+			Datacenter: "dc1",
+			Node:       node,
+			Address:    "127.0.0.1",
+			Service: &structs.NodeService{
+				Service: "db",
+				Tags:    []string{node},
+				Port:    12345,
+			},
+		}
+		require.NoError(t, a.RPC("Catalog.Register", args, &out))
+	}
+
+	var id string
+	{
+		args := &structs.PreparedQueryRequest{
+			Datacenter: "dc1",
+			Op:         structs.PreparedQueryCreate,
+			Query: &structs.PreparedQuery{
+				Name: "test",
+				Service: structs.ServiceQuery{
+					Service: "db",
+					Tags:    []string{"!bar"},
+				},
+			},
+		}
+		require.NoError(t, a.RPC("PreparedQuery.Apply", args, &id))
+	}
+
+	m := new(dns.Msg)
+	m.SetQuestion("test.query.consul.", dns.TypeSRV)
+	c := new(dns.Client)
+	in, _, err := c.Exchange(m, a.DNSAddr())
+	require.NoError(t, err)
+	require.Len(t, in.Answer, 1)
+	srvRec, ok := in.Answer[0].(*dns.SRV)
+	require.True(t, ok, "Bad: %#v", in.Answer[0])
+	require.Equal(t, "foo.node.dc1.consul.", srvRec.Target)
+
+	// The query is executed from a materialized view of the service.
+	entries := a.baseDeps.ViewStore.Entries()
+	require.Len(t, entries, 1)
+	require.Equal(t, pbsubscribe.Topic_ServiceHealth.String(), entries[0].Topic)
+	require.Equal(t, "dc1", entries[0].Datacenter)
+
+	// Later registrations are answered from the updated view.
+	args := &structs.RegisterRequest{
+		Datacenter: "dc1",
+		Node:       "baz",
+		Address:    "127.0.0.2",
+		Service: &structs.NodeService{
+			Service: "db",
+			Port:    12345,
+		},
+	}
+	require.NoError(t, a.RPC("Catalog.Register", args, &out))
+	retry.Run(t, func(r *retry.R) {
+		in, _, err := c.Exchange(m, a.DNSAddr())
+		require.NoError(r, err)
+		require.Len(r, in.Answer, 2)
+	})
+}
+
 func TestDNS_ServiceLookupWithInternalServiceAddress(t *testing.T) {
 	if testing.Short() {
 		t.Skip("too slow for testing.Short")
@@ -20,10 +20,28 @@ type ClientConnPool struct {
 	dialer        dialer
 	servers       ServerLocator
 	gwResolverDep gatewayResolverDep
+	keepalive     keepalive.ClientParameters
 	conns         map[string]*grpc.ClientConn
 	connsLock     sync.Mutex
 }
 
+// Keep alive parameters are based on the same default ones we used for
+// Yamux. These are somewhat arbitrary but we did observe in scale testing
+// that the gRPC defaults (servers send keepalives only every 2 hours,
+// clients never) seemed to result in TCP drops going undetected until
+// actual updates needed to be sent which caused unnecessary delays for
+// deliveries. These settings should be no more work for servers than
+// existing yamux clients but hopefully allow TCP drops to be detected
+// earlier and so have a smaller chance of going unnoticed until there are
+// actual updates to send out from the servers. The servers have a policy to
+// not accept pings any faster than once every MinKeepaliveInterval to
+// protect against abuse.
+const (
+	DefaultKeepaliveInterval = 30 * time.Second
+	DefaultKeepaliveTimeout  = 10 * time.Second
+	MinKeepaliveInterval     = 15 * time.Second
+)
+
 type ServerLocator interface {
 	// ServerForGlobalAddr returns server metadata for a server with the specified globally unique address.
 	ServerForGlobalAddr(globalAddr string) (*metadata.Server, error)
@@ -83,6 +101,13 @@ type ClientConnPoolConfig struct {
 	// DialingFromDatacenter is the datacenter of the consul agent using this
 	// pool.
 	DialingFromDatacenter string
+
+	// KeepaliveInterval is the time without activity on a connection after
+	// which the client pings the server, and KeepaliveTimeout is how long it
+	// waits for the reply before closing the connection. They default to
+	// DefaultKeepaliveInterval and DefaultKeepaliveTimeout.
+	KeepaliveInterval time.Duration
+	KeepaliveTimeout  time.Duration
 }
 
 // NewClientConnPool create new GRPC client pool to connect to servers using
@@ -90,7 +115,17 @@ type ClientConnPoolConfig struct {
 func NewClientConnPool(cfg ClientConnPoolConfig) *ClientConnPool {
 	c := &ClientConnPool{
 		servers: cfg.Servers,
-		conns:   make(map[string]*grpc.ClientConn),
+		keepalive: keepalive.ClientParameters{
+			Time:    DefaultKeepaliveInterval,
+			Timeout: DefaultKeepaliveTimeout,
+		},
+		conns: make(map[string]*grpc.ClientConn),
+	}
+	if cfg.KeepaliveInterval > 0 {
+		c.keepalive.Time = cfg.KeepaliveInterval
+	}
+	if cfg.KeepaliveTimeout > 0 {
+		c.keepalive.Timeout = cfg.KeepaliveTimeout
 	}
 	c.dialer = newDialer(cfg, &c.gwResolverDep)
 	return c
@@ -132,21 +167,7 @@ func (c *ClientConnPool) dial(datacenter string, serverType string) (*grpc.Clien
 		grpc.WithStatsHandler(newStatsHandler(defaultMetrics())),
 		// nolint:staticcheck // there is no other supported alternative to WithBalancerName
 		grpc.WithBalancerName("pick_first"),
-		// Keep alive parameters are based on the same default ones we used for
-		// Yamux. These are somewhat arbitrary but we did observe in scale testing
-		// that the gRPC defaults (servers send keepalives only every 2 hours,
-		// clients never) seemed to result in TCP drops going undetected until
-		// actual updates needed to be sent which caused unnecessary delays for
-		// deliveries. These settings should be no more work for servers than
-		// existing yamux clients but hopefully allow TCP drops to be detected
-		// earlier and so have a smaller chance of going unnoticed until there are
-		// actual updates to send out from the servers. The servers have a policy to
-		// not accept pings any faster than once every 15 seconds to protect against
-		// abuse.
-		grpc.WithKeepaliveParams(keepalive.ClientParameters{
-			Time:    30 * time.Second,
-			Timeout: 10 * time.Second,
-		}))
+		grpc.WithKeepaliveParams(c.keepalive))
 	if err != nil {
 		return nil, err
 	}
@@ -406,6 +406,61 @@ func TestClientConnPool_IntegrationWithGRPCResolver_Rebalance(t *testing.T) {
 	})
 }
 
+func TestClientConnPool_IntegrationWithGRPCResolver_CycleServer(t *testing.T) {
+	count := 3
+	res := resolver.NewServerResolverBuilder(newConfig(t))
+	registerWithGRPC(t, res)
+	pool := NewClientConnPool(ClientConnPoolConfig{
+		Servers:               res,
+		UseTLSForDC:           useTLSForDcAlwaysTrue,
+		DialingFromServer:     true,
+		DialingFromDatacenter: "dc1",
+	})
+
+	for i := 0; i < count; i++ {
+		name := fmt.Sprintf("server-%d", i)
+		srv := newTestServer(t, name, "dc1", nil)
+		res.AddServer(srv.Metadata())
+		t.Cleanup(srv.shutdown)
+	}
+
+	conn, err := pool.ClientConn("dc1")
+	require.NoError(t, err)
+	client := testservice.NewSimpleClient(conn)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	t.Cleanup(cancel)
+
+	first, err := client.Something(ctx, &testservice.Req{})
+	require.NoError(t, err)
+
+	t.Run("cycle a different DC, does nothing", func(t *testing.T) {
+		res.CycleServer("dc-other")
+
+		resp, err := client.Something(ctx, &testservice.Req{})
+		require.NoError(t, err)
+		require.Equal(t, resp.ServerName, first.ServerName)
+	})
+
+	t.Run("cycle the dc", func(t *testing.T) {
+		seen := map[string]bool{first.ServerName: true}
+		for i := 1; i < count; i++ {
+			res.CycleServer("dc1")
+
+			resp, err := client.Something(ctx, &testservice.Req{})
+			require.NoError(t, err)
+			require.False(t, seen[resp.ServerName], "server %v was used twice", resp.ServerName)
+			seen[resp.ServerName] = true
+		}
+
+		// After cycling through every server the first one is used again.
+		res.CycleServer("dc1")
+		resp, err := client.Something(ctx, &testservice.Req{})
+		require.NoError(t, err)
+		require.Equal(t, resp.ServerName, first.ServerName)
+	})
+}
+
 func TestClientConnPool_IntegrationWithGRPCResolver_MultiDC(t *testing.T) {
 	dcs := []string{"dc1", "dc2", "dc3"}
 
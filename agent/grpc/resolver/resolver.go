@@ -67,6 +67,28 @@ func (s *ServerResolverBuilder) NewRebalancer(dc string) func() {
 	}
 }
 
+// CycleServer moves the server currently used by the resolvers in dc to the end
+// of their server list, so that the connections to dc use the next server. It
+// is used when a stream to the server failed.
+func (s *ServerResolverBuilder) CycleServer(dc string) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	for _, r := range s.resolvers {
+		if r.datacenter != dc {
+			continue
+		}
+		r.addrLock.Lock()
+		if len(r.addrs) > 1 {
+			addrs := make([]resolver.Address, 0, len(r.addrs))
+			addrs = append(addrs, r.addrs[1:]...)
+			addrs = append(addrs, r.addrs[0])
+			r.updateAddrsLocked(addrs)
+		}
+		r.addrLock.Unlock()
+	}
+}
+
 // ServerForGlobalAddr returns server metadata for a server with the specified globally unique address.
 func (s *ServerResolverBuilder) ServerForGlobalAddr(globalAddr string) (*metadata.Server, error) {
 	s.lock.RLock()
@@ -85,9 +85,10 @@ func (s *HTTPHandlers) HealthNodeChecks(resp http.ResponseWriter, req *http.Requ
 
 	// Make the RPC request
 	var out structs.IndexedHealthChecks
+	var err error
 	defer setMeta(resp, &out.QueryMeta)
 RETRY_ONCE:
-	if err := s.agent.RPC("Health.NodeChecks", &args, &out); err != nil {
+	if out, err = s.agent.rpcClientNode.NodeChecks(req.Context(), args); err != nil {
 		return nil, err
 	}
 	if args.QueryOptions.AllowStale && args.MaxStaleDuration > 0 && args.MaxStaleDuration < out.LastContact {
@@ -219,6 +220,15 @@ func (s *HTTPHandlers) healthServiceNodes(resp http.ResponseWriter, req *http.Re
 		return nil, nil
 	}
 
+	// Filter to only passing if specified
+	passingOnly, err := getBoolQueryParam(params, api.HealthPassing)
+	if err != nil {
+		resp.WriteHeader(http.StatusBadRequest)
+		fmt.Fprint(resp, "Invalid value for ?passing")
+		return nil, nil
+	}
+	args.PassingOnly = passingOnly
+
 	out, md, err := s.agent.rpcClientHealth.ServiceNodes(req.Context(), args)
 	if err != nil {
 		return nil, err
@@ -230,20 +240,6 @@ func (s *HTTPHandlers) healthServiceNodes(resp http.ResponseWriter, req *http.Re
 	out.QueryMeta.ConsistencyLevel = args.QueryOptions.ConsistencyLevel()
 	setMeta(resp, &out.QueryMeta)
 
-	// FIXME: argument parsing should be done before performing the rpc
-	// Filter to only passing if specified
-	filter, err := getBoolQueryParam(params, api.HealthPassing)
-	if err != nil {
-		resp.WriteHeader(http.StatusBadRequest)
-		fmt.Fprint(resp, "Invalid value for ?passing")
-		return nil, nil
-	}
-
-	// FIXME: remove filterNonPassing, replace with nodes.Filter, which is used by DNSServer
-	if filter {
-		out.Nodes = filterNonPassing(out.Nodes)
-	}
-
 	// Translate addresses after filtering so we don't waste effort.
 	s.agent.TranslateAddresses(args.Datacenter, out.Nodes, TranslateAddressAcceptAny)
 
@@ -291,25 +287,3 @@ func getBoolQueryParam(params url.Values, key string) (bool, error) {
 	}
 	return param, nil
 }
-
-// filterNonPassing is used to filter out any nodes that have check that are not passing
-func filterNonPassing(nodes structs.CheckServiceNodes) structs.CheckServiceNodes {
-	n := len(nodes)
-
-	// Make a copy of the cached nodes rather than operating on the cache directly
-	out := append(nodes[:0:0], nodes...)
-
-OUTER:
-	for i := 0; i < n; i++ {
-		node := out[i]
-		for _, check := range node.Checks {
-			if check.Status != api.HealthPassing {
-				out[i], out[n-1] = out[n-1], structs.CheckServiceNode{}
-				n--
-				i--
-				continue OUTER
-			}
-		}
-	}
-	return out[:n]
-}
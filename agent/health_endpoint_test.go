@@ -7,7 +7,6 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"net/url"
-	"reflect"
 	"strconv"
 	"testing"
 	"time"
@@ -21,6 +20,7 @@ import (
 	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/sdk/testutil/retry"
 	"github.com/hashicorp/consul/testrpc"
+	"github.com/hashicorp/consul/types"
 )
 
 func TestHealthChecksInState(t *testing.T) {
@@ -287,6 +287,62 @@ func TestHealthNodeChecks(t *testing.T) {
 	}
 }
 
+func TestHealthNodeChecks_Blocking_Streaming(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	a := NewTestAgent(t, `
+rpc { enable_streaming = true }
+use_streaming_backend = true
+`)
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	register := func(check types.CheckID) error {
+		args := &structs.RegisterRequest{
+			Datacenter: "dc1",
+			Node:       "foo",
+			Address:    "127.0.0.1",
+			Check: &structs.HealthCheck{
+				Node:    "foo",
+				CheckID: check,
+				Name:    string(check),
+				Status:  api.HealthPassing,
+			},
+		}
+		var out struct{}
+		return a.RPC("Catalog.Register", args, &out)
+	}
+	require.NoError(t, register("disk"))
+
+	req, _ := http.NewRequest("GET", "/v1/health/node/foo", nil)
+	resp := httptest.NewRecorder()
+	_, err := a.srv.HealthNodeChecks(resp, req)
+	require.NoError(t, err)
+	index := resp.Header().Get("X-Consul-Index")
+
+	errCh := make(chan error, 1)
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		errCh <- register("mem")
+	}()
+
+	req, _ = http.NewRequest("GET", "/v1/health/node/foo?index="+index, nil)
+	resp = httptest.NewRecorder()
+	obj, err := a.srv.HealthNodeChecks(resp, req)
+	require.NoError(t, err)
+	require.NoError(t, <-errCh)
+	require.Equal(t, "streaming", resp.Header().Get("X-Consul-Query-Backend"))
+	require.NotEqual(t, index, resp.Header().Get("X-Consul-Index"))
+
+	checks := obj.(structs.HealthChecks)
+	require.Len(t, checks, 2)
+	require.Equal(t, types.CheckID("disk"), checks[0].CheckID)
+	require.Equal(t, types.CheckID("mem"), checks[1].CheckID)
+}
+
 func TestHealthNodeChecks_Filtering(t *testing.T) {
 	if testing.Short() {
 		t.Skip("too slow for testing.Short")
@@ -1670,40 +1726,3 @@ func TestHealthConnectServiceNodes_PassingFilter(t *testing.T) {
 		assert.True(bytes.Contains(body, []byte("Invalid value for ?passing")))
 	})
 }
-
-func TestFilterNonPassing(t *testing.T) {
-	t.Parallel()
-	nodes := structs.CheckServiceNodes{
-		structs.CheckServiceNode{
-			Checks: structs.HealthChecks{
-				&structs.HealthCheck{
-					Status: api.HealthCritical,
-				},
-				&structs.HealthCheck{
-					Status: api.HealthCritical,
-				},
-			},
-		},
-		structs.CheckServiceNode{
-			Checks: structs.HealthChecks{
-				&structs.HealthCheck{
-					Status: api.HealthCritical,
-				},
-				&structs.HealthCheck{
-					Status: api.HealthCritical,
-				},
-			},
-		},
-		structs.CheckServiceNode{
-			Checks: structs.HealthChecks{
-				&structs.HealthCheck{
-					Status: api.HealthPassing,
-				},
-			},
-		},
-	}
-	out := filterNonPassing(nodes)
-	if len(out) != 1 && reflect.DeepEqual(out[0], nodes[2]) {
-		t.Fatalf("bad: %v", out)
-	}
-}
@@ -5,7 +5,6 @@ import (
 	"net/http"
 	"strings"
 
-	cachetype "github.com/hashicorp/consul/agent/cache-types"
 	"github.com/hashicorp/consul/agent/consul"
 	"github.com/hashicorp/consul/agent/structs"
 )
@@ -155,33 +154,17 @@ func (s *HTTPHandlers) IntentionMatch(resp http.ResponseWriter, req *http.Reques
 	}
 
 	// Make the RPC request
-	var out structs.IndexedIntentionMatches
-	defer setMeta(resp, &out.QueryMeta)
-
-	if s.agent.config.HTTPUseCache && args.QueryOptions.UseCache {
-		raw, m, err := s.agent.cache.Get(req.Context(), cachetype.IntentionMatchName, args)
-		if err != nil {
-			return nil, err
-		}
-		defer setCacheMeta(resp, &m)
-
-		reply, ok := raw.(*structs.IndexedIntentionMatches)
-		if !ok {
-			// This should never happen, but we want to protect against panics
-			return nil, fmt.Errorf("internal error: response type not correct")
-		}
-		out = *reply
-	} else {
-	RETRY_ONCE:
-		if err := s.agent.RPC("Intention.Match", args, &out); err != nil {
-			return nil, err
-		}
-		if args.QueryOptions.AllowStale && args.MaxStaleDuration > 0 && args.MaxStaleDuration < out.LastContact {
-			args.AllowStale = false
-			args.MaxStaleDuration = 0
-			goto RETRY_ONCE
-		}
+	if !s.agent.config.HTTPUseCache {
+		args.QueryOptions.UseCache = false
 	}
+	out, m, err := s.agent.rpcClientIntention.Match(req.Context(), *args)
+	if err != nil {
+		return nil, err
+	}
+	if args.QueryOptions.UseCache {
+		setCacheMeta(resp, &m)
+	}
+	defer setMeta(resp, &out.QueryMeta)
 	out.ConsistencyLevel = args.QueryOptions.ConsistencyLevel()
 
 	// We must have an identical count of matches
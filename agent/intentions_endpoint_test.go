@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"net/http/httptest"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -229,6 +230,57 @@ func TestIntentionMatch(t *testing.T) {
 	})
 }
 
+func TestIntentionMatch_Blocking_Streaming(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	a := NewTestAgent(t, `
+rpc { enable_streaming = true }
+use_streaming_backend = true
+`)
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	upsert := func(src, dst string) {
+		ixn := structs.IntentionRequest{
+			Datacenter: "dc1",
+			Op:         structs.IntentionOpUpsert,
+			Intention:  structs.TestIntention(t),
+		}
+		ixn.Intention.SourceName = src
+		ixn.Intention.DestinationName = dst
+		var reply string
+		require.NoError(t, a.RPC("Intention.Apply", &ixn, &reply))
+	}
+	upsert("*", "*")
+	upsert("api", "db")
+
+	req, _ := http.NewRequest("GET", "/v1/connect/intentions/match?by=destination&name=web", nil)
+	resp := httptest.NewRecorder()
+	_, err := a.srv.IntentionMatch(resp, req)
+	require.NoError(t, err)
+	index := resp.Header().Get("X-Consul-Index")
+
+	go func() {
+		time.Sleep(100 * time.Millisecond)
+		upsert("api", "web")
+	}()
+
+	req, _ = http.NewRequest("GET", "/v1/connect/intentions/match?by=destination&name=web&index="+index, nil)
+	resp = httptest.NewRecorder()
+	obj, err := a.srv.IntentionMatch(resp, req)
+	require.NoError(t, err)
+	require.Equal(t, "streaming", resp.Header().Get("X-Consul-Query-Backend"))
+
+	var actual []string
+	for _, ixn := range obj.(map[string]structs.Intentions)["web"] {
+		actual = append(actual, ixn.SourceName+"->"+ixn.DestinationName)
+	}
+	require.Equal(t, []string{"api->web", "*->*"}, actual)
+}
+
 func TestIntentionCheck(t *testing.T) {
 	if testing.Short() {
 		t.Skip("too slow for testing.Short")
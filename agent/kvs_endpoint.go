@@ -69,7 +69,13 @@ func (s *HTTPHandlers) KVSGet(resp http.ResponseWriter, req *http.Request, args
 
 	// Make the RPC
 	var out structs.IndexedDirEntries
-	if err := s.agent.RPC(method, &args, &out); err != nil {
+	var err error
+	if method == "KVS.Get" {
+		out, err = s.agent.rpcClientKV.Get(req.Context(), *args)
+	} else {
+		out, err = s.agent.rpcClientKV.List(req.Context(), *args)
+	}
+	if err != nil {
 		return nil, err
 	}
 	setMeta(resp, &out.QueryMeta)
@@ -7,6 +7,9 @@ import (
 	"net/http/httptest"
 	"reflect"
 	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
 
 	"github.com/hashicorp/consul/testrpc"
 
@@ -572,3 +575,82 @@ func TestKVSEndpoint_DELETE_ConflictingFlags(t *testing.T) {
 		t.Fatalf("expected conflicting args error")
 	}
 }
+
+func TestKVSEndpoint_GET_Blocking_Streaming(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	t.Parallel()
+	a := NewTestAgent(t, `
+rpc { enable_streaming = true }
+use_streaming_backend = true
+`)
+	defer a.Shutdown()
+	testrpc.WaitForTestAgent(t, a.RPC, "dc1")
+
+	put := func(key, value string) {
+		req, _ := http.NewRequest("PUT", "/v1/kv/"+key, bytes.NewBufferString(value))
+		_, err := a.srv.KVSEndpoint(httptest.NewRecorder(), req)
+		require.NoError(t, err)
+	}
+	put("web/one", "1")
+	put("web/two", "2")
+
+	req, _ := http.NewRequest("GET", "/v1/kv/web/one", nil)
+	resp := httptest.NewRecorder()
+	_, err := a.srv.KVSEndpoint(resp, req)
+	require.NoError(t, err)
+	index := resp.Header().Get("X-Consul-Index")
+
+	{
+		// Get blocks until the key changes
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			put("web/one", "updated")
+		}()
+
+		req, _ := http.NewRequest("GET", "/v1/kv/web/one?index="+index, nil)
+		resp := httptest.NewRecorder()
+		obj, err := a.srv.KVSEndpoint(resp, req)
+		require.NoError(t, err)
+		require.Equal(t, "streaming", resp.Header().Get("X-Consul-Query-Backend"))
+
+		entries := obj.(structs.DirEntries)
+		require.Len(t, entries, 1)
+		require.Equal(t, "web/one", entries[0].Key)
+		require.Equal(t, []byte("updated"), entries[0].Value)
+		index = resp.Header().Get("X-Consul-Index")
+	}
+
+	{
+		// List returns every key with the prefix
+		req, _ := http.NewRequest("GET", "/v1/kv/web/?recurse&index=1", nil)
+		resp := httptest.NewRecorder()
+		obj, err := a.srv.KVSEndpoint(resp, req)
+		require.NoError(t, err)
+		require.Equal(t, "streaming", resp.Header().Get("X-Consul-Query-Backend"))
+
+		entries := obj.(structs.DirEntries)
+		require.Len(t, entries, 2)
+		require.Equal(t, "web/one", entries[0].Key)
+		require.Equal(t, "web/two", entries[1].Key)
+	}
+
+	{
+		// Get returns not found for a deleted key
+		go func() {
+			time.Sleep(100 * time.Millisecond)
+			req, _ := http.NewRequest("DELETE", "/v1/kv/web/one", nil)
+			_, err := a.srv.KVSEndpoint(httptest.NewRecorder(), req)
+			require.NoError(t, err)
+		}()
+
+		req, _ := http.NewRequest("GET", "/v1/kv/web/one?index="+index, nil)
+		resp := httptest.NewRecorder()
+		obj, err := a.srv.KVSEndpoint(resp, req)
+		require.NoError(t, err)
+		require.Nil(t, obj)
+		require.Equal(t, http.StatusNotFound, resp.Code)
+	}
+}
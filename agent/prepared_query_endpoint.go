@@ -6,7 +6,6 @@ import (
 	"strconv"
 	"strings"
 
-	cachetype "github.com/hashicorp/consul/agent/cache-types"
 	"github.com/hashicorp/consul/agent/structs"
 )
 
@@ -122,22 +121,17 @@ func (s *HTTPHandlers) preparedQueryExecute(id string, resp http.ResponseWriter,
 	defer setMeta(resp, &reply.QueryMeta)
 
 	if args.QueryOptions.UseCache {
-		raw, m, err := s.agent.cache.Get(req.Context(), cachetype.PreparedQueryName, &args)
+		r, m, err := s.agent.rpcClientPreparedQuery.Execute(req.Context(), &args)
 		if err != nil {
 			// Don't return error if StaleIfError is set and we are within it and had
 			// a cached value.
-			if raw != nil && m.Hit && args.QueryOptions.StaleIfError > m.Age {
+			if r != nil && m.Hit && args.QueryOptions.StaleIfError > m.Age {
 				// Fall through to the happy path below
 			} else {
 				return nil, err
 			}
 		}
 		defer setCacheMeta(resp, &m)
-		r, ok := raw.(*structs.PreparedQueryExecuteResponse)
-		if !ok {
-			// This should never happen, but we want to protect against panics
-			return nil, fmt.Errorf("internal error: response type not correct")
-		}
 		reply = *r
 	} else {
 	RETRY_ONCE:
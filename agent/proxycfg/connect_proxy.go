@@ -51,7 +51,7 @@ func (s *handlerConnectProxy) initialize(ctx context.Context) (ConfigSnapshot, e
 	}
 
 	// Watch for intention updates
-	err = s.cache.Notify(ctx, cachetype.IntentionMatchName, &structs.IntentionQueryRequest{
+	err = s.intentions.Notify(ctx, &structs.IntentionQueryRequest{
 		Datacenter:   s.source.Datacenter,
 		QueryOptions: structs.QueryOptions{Token: s.token},
 		Match: &structs.IntentionQueryMatch{
@@ -93,7 +93,7 @@ func (s *handlerConnectProxy) initialize(ctx context.Context) (ConfigSnapshot, e
 			return snap, err
 		}
 
-		err = s.cache.Notify(ctx, cachetype.ConfigEntryName, &structs.ConfigEntryQuery{
+		err = s.configEntries.NotifyEntry(ctx, &structs.ConfigEntryQuery{
 			Kind:           structs.MeshConfig,
 			Name:           structs.MeshConfigMesh,
 			Datacenter:     s.source.Datacenter,
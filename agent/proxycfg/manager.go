@@ -62,6 +62,12 @@ type ManagerConfig struct {
 	Cache *cache.Cache
 	// Health provides service health updates on a notification channel.
 	Health Health
+	// Intentions provides updates to the intentions of a service on a
+	// notification channel.
+	Intentions Intentions
+	// ConfigEntries provides updates to config entries on a notification
+	// channel.
+	ConfigEntries ConfigEntries
 	// state is the agent's local state to be watched for new proxy registrations.
 	State *local.State
 	// source describes the current agent's identity, it's used directly for
@@ -206,6 +212,8 @@ func (m *Manager) ensureProxyServiceLocked(ns *structs.NodeService) error {
 		logger:                m.Logger.With("service_id", sid.String()),
 		cache:                 m.Cache,
 		health:                m.Health,
+		intentions:            m.Intentions,
+		configEntries:         m.ConfigEntries,
 		source:                m.Source,
 		dnsConfig:             m.DNSConfig,
 		intentionDefaultAllow: m.IntentionDefaultAllow,
@@ -15,7 +15,9 @@ import (
 	"github.com/hashicorp/consul/agent/connect"
 	"github.com/hashicorp/consul/agent/consul/discoverychain"
 	"github.com/hashicorp/consul/agent/local"
+	"github.com/hashicorp/consul/agent/rpcclient/configentry"
 	"github.com/hashicorp/consul/agent/rpcclient/health"
+	"github.com/hashicorp/consul/agent/rpcclient/intention"
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/agent/token"
 	"github.com/hashicorp/consul/sdk/testutil"
@@ -361,11 +363,13 @@ func testManager_BasicLifecycle(
 
 	// Create manager
 	m, err := NewManager(ManagerConfig{
-		Cache:  c,
-		Health: &health.Client{Cache: c, CacheName: cachetype.HealthServicesName},
-		State:  state,
-		Source: source,
-		Logger: logger,
+		Cache:         c,
+		Health:        &health.Client{Cache: c, CacheName: cachetype.HealthServicesName},
+		Intentions:    &intention.Client{Cache: c, CacheName: cachetype.IntentionMatchName},
+		ConfigEntries: &configentry.Client{Cache: c},
+		State:         state,
+		Source:        source,
+		Logger:        logger,
 	})
 	require.NoError(err)
 
@@ -567,12 +571,14 @@ func TestManager_SyncState_DefaultToken(t *testing.T) {
 	state.TriggerSyncChanges = func() {}
 
 	m, err := NewManager(ManagerConfig{
-		Cache:  c,
-		Health: &health.Client{Cache: c, CacheName: cachetype.HealthServicesName},
-		State:  state,
-		Tokens: tokens,
-		Source: &structs.QuerySource{Datacenter: "dc1"},
-		Logger: logger,
+		Cache:         c,
+		Health:        &health.Client{Cache: c, CacheName: cachetype.HealthServicesName},
+		Intentions:    &intention.Client{Cache: c, CacheName: cachetype.IntentionMatchName},
+		ConfigEntries: &configentry.Client{Cache: c},
+		State:         state,
+		Tokens:        tokens,
+		Source:        &structs.QuerySource{Datacenter: "dc1"},
+		Logger:        logger,
 	})
 	require.NoError(t, err)
 	defer m.Close()
@@ -26,6 +26,15 @@ type Health interface {
 	Notify(ctx context.Context, req structs.ServiceSpecificRequest, correlationID string, ch chan<- cache.UpdateEvent) error
 }
 
+type Intentions interface {
+	Notify(ctx context.Context, req *structs.IntentionQueryRequest, correlationID string, ch chan<- cache.UpdateEvent) error
+}
+
+type ConfigEntries interface {
+	NotifyEntry(ctx context.Context, req *structs.ConfigEntryQuery, correlationID string, ch chan<- cache.UpdateEvent) error
+	NotifyEntries(ctx context.Context, req *structs.ConfigEntryQuery, correlationID string, ch chan<- cache.UpdateEvent) error
+}
+
 const (
 	coalesceTimeout                    = 200 * time.Millisecond
 	rootsWatchID                       = "roots"
@@ -55,6 +64,8 @@ type stateConfig struct {
 	source                *structs.QuerySource
 	cache                 CacheNotifier
 	health                Health
+	intentions            Intentions
+	configEntries         ConfigEntries
 	dnsConfig             DNSConfig
 	serverSNIFn           ServerSNIFunc
 	intentionDefaultAllow bool
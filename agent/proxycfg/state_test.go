@@ -14,7 +14,9 @@ import (
 	cachetype "github.com/hashicorp/consul/agent/cache-types"
 	"github.com/hashicorp/consul/agent/connect"
 	"github.com/hashicorp/consul/agent/consul/discoverychain"
+	"github.com/hashicorp/consul/agent/rpcclient/configentry"
 	"github.com/hashicorp/consul/agent/rpcclient/health"
+	"github.com/hashicorp/consul/agent/rpcclient/intention"
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/sdk/testutil"
 )
@@ -2249,9 +2251,11 @@ func TestState_WatchesAndUpdates(t *testing.T) {
 		t.Run(name, func(t *testing.T) {
 			cn := newTestCacheNotifier()
 			state, err := newState(&tc.ns, "", stateConfig{
-				logger: testutil.Logger(t),
-				cache:  cn,
-				health: &health.Client{Cache: cn, CacheName: cachetype.HealthServicesName},
+				logger:        testutil.Logger(t),
+				cache:         cn,
+				health:        &health.Client{Cache: cn, CacheName: cachetype.HealthServicesName},
+				intentions:    &intention.Client{Cache: cn, CacheName: cachetype.IntentionMatchName},
+				configEntries: &configentry.Client{Cache: cn},
 				source: &structs.QuerySource{
 					Datacenter: tc.sourceDC,
 				},
@@ -8,6 +8,8 @@ type ServerTracker interface {
 	NewRebalancer(dc string) func()
 	AddServer(*metadata.Server)
 	RemoveServer(*metadata.Server)
+	// CycleServer moves away from the server currently used for dc.
+	CycleServer(dc string)
 }
 
 // Rebalancer is called periodically to re-order the servers so that the load on the
@@ -28,3 +30,6 @@ func (NoOpServerTracker) AddServer(*metadata.Server) {}
 
 // RemoveServer does nothing
 func (NoOpServerTracker) RemoveServer(*metadata.Server) {}
+
+// CycleServer does nothing
+func (NoOpServerTracker) CycleServer(string) {}
@@ -362,6 +362,13 @@ func (r *Router) FailServer(areaID types.AreaID, s *metadata.Server) error {
 	return nil
 }
 
+// CycleGRPCServer moves the gRPC connections to the given datacenter away from
+// the server they currently use. It should be called when a gRPC stream to the
+// server failed, so that the next stream is not sent to the same server.
+func (r *Router) CycleGRPCServer(datacenter string) {
+	r.grpcServerTracker.CycleServer(datacenter)
+}
+
 // FindRoute returns a healthy server with a route to the given datacenter. The
 // Boolean return parameter will indicate if a server was available. In some
 // cases this may return a best-effort unhealthy server that can be used for a
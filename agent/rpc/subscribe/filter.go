@@ -0,0 +1,114 @@
+package subscribe
+
+import (
+	"fmt"
+	"reflect"
+
+	"github.com/hashicorp/go-bexpr"
+
+	"github.com/hashicorp/consul/agent/consul/state"
+	"github.com/hashicorp/consul/agent/consul/stream"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/proto/pbsubscribe"
+)
+
+// eventFilter applies the Filter expression or the ExactKey from a
+// SubscribeRequest to the events of a subscription.
+//
+// While a snapshot is being sent, events which do not match the expression are
+// dropped, because the subscriber has no previous state for them. After the
+// snapshot, a Register event which does not match is sent as a Deregister.
+// The subscriber may have received an earlier version which did match, and
+// must remove it from its view.
+//
+// With ExactKey, the KV events for the entries with a key other than the Key
+// of the request are dropped. The key of an entry never changes, so the
+// subscriber never received them.
+type eventFilter struct {
+	evaluator  *bexpr.Evaluator
+	inSnapshot bool
+	// exactKey is the Key of a request with ExactKey.
+	exactKey string
+}
+
+// newEventFilter returns an eventFilter for req, or nil if req has no Filter
+// and no ExactKey.
+func newEventFilter(req *pbsubscribe.SubscribeRequest) (*eventFilter, error) {
+	if req.ExactKey {
+		if req.Topic != pbsubscribe.Topic_KV {
+			return nil, fmt.Errorf("exact key is not supported for topic %v", req.Topic)
+		}
+		if req.Filter != "" {
+			return nil, fmt.Errorf("filter is not supported for topic %v", req.Topic)
+		}
+		return &eventFilter{exactKey: req.Key}, nil
+	}
+	if req.Filter == "" {
+		return nil, nil
+	}
+
+	switch req.Topic {
+	case pbsubscribe.Topic_ServiceHealth, pbsubscribe.Topic_ServiceHealthConnect:
+	default:
+		return nil, fmt.Errorf("filter is not supported for topic %v", req.Topic)
+	}
+
+	evaluator, err := bexpr.CreateEvaluatorForType(req.Filter, nil, reflect.TypeOf(structs.CheckServiceNode{}))
+	if err != nil {
+		return nil, fmt.Errorf("invalid filter: %w", err)
+	}
+	return &eventFilter{evaluator: evaluator, inSnapshot: req.Index == 0}, nil
+}
+
+// Apply returns the event to send to the subscriber, and false if the event
+// should not be sent.
+func (f *eventFilter) Apply(event stream.Event) (stream.Event, bool, error) {
+	switch {
+	case event.IsNewSnapshotToFollow():
+		f.inSnapshot = true
+		return event, true, nil
+	case event.IsEndOfSnapshot():
+		f.inSnapshot = false
+		return event, true, nil
+	}
+
+	switch p := event.Payload.(type) {
+	case *stream.PayloadEvents:
+		items := make([]stream.Event, 0, len(p.Items))
+		for _, item := range p.Items {
+			item, ok, err := f.Apply(item)
+			if err != nil {
+				return event, false, err
+			}
+			if ok {
+				items = append(items, item)
+			}
+		}
+		if len(items) == 0 {
+			return event, false, nil
+		}
+		event.Payload = &stream.PayloadEvents{Items: items}
+		return event, true, nil
+
+	case state.EventPayloadKV:
+		return event, p.Value.Key == f.exactKey, nil
+
+	case state.EventPayloadCheckServiceNode:
+		if p.Op != pbsubscribe.CatalogOp_Register {
+			return event, !f.inSnapshot, nil
+		}
+		match, err := f.evaluator.Evaluate(*p.Value)
+		switch {
+		case err != nil:
+			return event, false, err
+		case match:
+			return event, true, nil
+		case f.inSnapshot:
+			return event, false, nil
+		}
+		p.Op = pbsubscribe.CatalogOp_Deregister
+		event.Payload = p
+		return event, true, nil
+	}
+	return event, true, nil
+}
@@ -0,0 +1,161 @@
+package subscribe
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/consul/state"
+	"github.com/hashicorp/consul/agent/consul/stream"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/proto/pbsubscribe"
+)
+
+func TestNewEventFilter(t *testing.T) {
+	f, err := newEventFilter(&pbsubscribe.SubscribeRequest{Topic: pbsubscribe.Topic_ServiceHealth})
+	require.NoError(t, err)
+	require.Nil(t, f)
+
+	_, err = newEventFilter(&pbsubscribe.SubscribeRequest{
+		Topic:  pbsubscribe.Topic_KV,
+		Filter: `Key == "foo"`,
+	})
+	require.EqualError(t, err, "filter is not supported for topic KV")
+
+	_, err = newEventFilter(&pbsubscribe.SubscribeRequest{
+		Topic:  pbsubscribe.Topic_ServiceHealth,
+		Filter: `Bogus == "foo"`,
+	})
+	require.Error(t, err)
+	require.Contains(t, err.Error(), "invalid filter")
+}
+
+func TestEventFilter_Apply(t *testing.T) {
+	newEvent := func(index uint64, op pbsubscribe.CatalogOp, node, version string) stream.Event {
+		return stream.Event{
+			Index: index,
+			Payload: state.EventPayloadCheckServiceNode{
+				Op: op,
+				Value: &structs.CheckServiceNode{
+					Node: &structs.Node{Node: node},
+					Service: &structs.NodeService{
+						Service: "web",
+						Meta:    map[string]string{"version": version},
+					},
+				},
+			},
+		}
+	}
+	opOf := func(event stream.Event) pbsubscribe.CatalogOp {
+		return event.Payload.(state.EventPayloadCheckServiceNode).Op
+	}
+
+	f, err := newEventFilter(&pbsubscribe.SubscribeRequest{
+		Topic:  pbsubscribe.Topic_ServiceHealth,
+		Filter: `Service.Meta.version == "2"`,
+	})
+	require.NoError(t, err)
+
+	// During the snapshot events which do not match are dropped.
+	_, ok, err := f.Apply(newEvent(5, pbsubscribe.CatalogOp_Register, "node1", "1"))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	event, ok, err := f.Apply(newEvent(5, pbsubscribe.CatalogOp_Register, "node2", "2"))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, pbsubscribe.CatalogOp_Register, opOf(event))
+
+	_, ok, err = f.Apply(newEventFromSubscription(t, 0))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	// After the snapshot, events which no longer match become a Deregister.
+	event, ok, err = f.Apply(newEvent(6, pbsubscribe.CatalogOp_Register, "node2", "1"))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, pbsubscribe.CatalogOp_Deregister, opOf(event))
+
+	event, ok, err = f.Apply(newEvent(7, pbsubscribe.CatalogOp_Deregister, "node3", "1"))
+	require.NoError(t, err)
+	require.True(t, ok)
+	require.Equal(t, pbsubscribe.CatalogOp_Deregister, opOf(event))
+
+	// Events in a batch are filtered individually.
+	event, ok, err = f.Apply(stream.Event{
+		Index: 8,
+		Payload: newPayloadEvents(
+			newEvent(8, pbsubscribe.CatalogOp_Register, "node1", "2"),
+			newEvent(8, pbsubscribe.CatalogOp_Register, "node2", "1"),
+		),
+	})
+	require.NoError(t, err)
+	require.True(t, ok)
+	items := event.Payload.(*stream.PayloadEvents).Items
+	require.Len(t, items, 2)
+	require.Equal(t, pbsubscribe.CatalogOp_Register, opOf(items[0]))
+	require.Equal(t, pbsubscribe.CatalogOp_Deregister, opOf(items[1]))
+
+	// A new snapshot drops events which do not match again.
+	_, ok, err = f.Apply(newEventFromSubscription(t, 22))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	_, ok, err = f.Apply(stream.Event{
+		Index: 9,
+		Payload: newPayloadEvents(
+			newEvent(9, pbsubscribe.CatalogOp_Register, "node1", "1"),
+			newEvent(9, pbsubscribe.CatalogOp_Register, "node2", "1"),
+		),
+	})
+	require.NoError(t, err)
+	require.False(t, ok)
+}
+
+func TestEventFilter_ExactKey(t *testing.T) {
+	_, err := newEventFilter(&pbsubscribe.SubscribeRequest{
+		Topic:    pbsubscribe.Topic_ServiceHealth,
+		Key:      "web",
+		ExactKey: true,
+	})
+	require.EqualError(t, err, "exact key is not supported for topic ServiceHealth")
+
+	f, err := newEventFilter(&pbsubscribe.SubscribeRequest{
+		Topic:    pbsubscribe.Topic_KV,
+		Key:      "foo",
+		ExactKey: true,
+	})
+	require.NoError(t, err)
+
+	newEvent := func(key string) stream.Event {
+		return stream.Event{
+			Index:   5,
+			Payload: state.EventPayloadKV{Value: &structs.DirEntry{Key: key}},
+		}
+	}
+
+	_, ok, err := f.Apply(newEvent("foo"))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	_, ok, err = f.Apply(newEvent("foobar/baz"))
+	require.NoError(t, err)
+	require.False(t, ok)
+
+	_, ok, err = f.Apply(newEventFromSubscription(t, 0))
+	require.NoError(t, err)
+	require.True(t, ok)
+
+	event, ok, err := f.Apply(stream.Event{
+		Index: 6,
+		Payload: &stream.PayloadEvents{Items: []stream.Event{
+			newEvent("foo/bar"),
+			newEvent("foo"),
+		}},
+	})
+	require.NoError(t, err)
+	require.True(t, ok)
+	items := event.Payload.(*stream.PayloadEvents).Items
+	require.Len(t, items, 1)
+	require.Equal(t, "foo", items[0].Payload.(state.EventPayloadKV).Value.Key)
+}
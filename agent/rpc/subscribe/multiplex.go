@@ -0,0 +1,162 @@
+package subscribe
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"sync"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/hashicorp/consul/proto/pbsubscribe"
+)
+
+// SubscribeMultiplexed serves the subscriptions started by the requests of
+// serverStream. Each subscription is served by Subscribe in its own goroutine,
+// with a stream which sends its events to serverStream.
+func (h *Server) SubscribeMultiplexed(serverStream pbsubscribe.StateChangeSubscription_SubscribeMultiplexedServer) error {
+	h.Logger.Trace("new multiplexed stream")
+	defer h.Logger.Trace("multiplexed stream closed")
+
+	var wg sync.WaitGroup
+	defer wg.Wait()
+
+	ctx, cancel := context.WithCancel(serverStream.Context())
+	defer cancel()
+
+	mux := &multiplexer{
+		stream: serverStream,
+		subs:   make(map[uint64]context.CancelFunc),
+	}
+	for {
+		req, err := serverStream.Recv()
+		switch {
+		case err == io.EOF:
+			// The client will not start or stop any more subscriptions, which
+			// only happens when it is done with the stream.
+			return nil
+		case err != nil:
+			return err
+		}
+
+		switch op := req.Op.(type) {
+		case *pbsubscribe.MultiplexedRequest_Subscribe:
+			sub, err := mux.start(ctx, req.ID)
+			if err != nil {
+				return status.Error(codes.InvalidArgument, err.Error())
+			}
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				err := h.Subscribe(op.Subscribe, sub)
+				mux.end(sub, err)
+			}()
+		case *pbsubscribe.MultiplexedRequest_Unsubscribe:
+			mux.stop(req.ID)
+		default:
+			return status.Errorf(codes.InvalidArgument, "unexpected request for subscription %d: %T", req.ID, req.Op)
+		}
+	}
+}
+
+// multiplexer tracks the subscriptions of a SubscribeMultiplexed stream, and
+// serializes the events they send to the stream.
+type multiplexer struct {
+	stream pbsubscribe.StateChangeSubscription_SubscribeMultiplexedServer
+
+	// sendLock is held while sending to stream, which does not support
+	// concurrent calls to Send.
+	sendLock sync.Mutex
+
+	lock sync.Mutex
+	// subs are the active subscriptions of the stream, by ID, and the function
+	// used to stop them.
+	subs map[uint64]context.CancelFunc
+}
+
+// start records the subscription id, and returns the stream used by Subscribe
+// to serve it. The subscription is stopped once ctx is cancelled.
+func (m *multiplexer) start(ctx context.Context, id uint64) (*multiplexedSubscription, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if _, ok := m.subs[id]; ok {
+		return nil, fmt.Errorf("subscription %d is already active", id)
+	}
+	ctx, cancel := context.WithCancel(ctx)
+	m.subs[id] = cancel
+	return &multiplexedSubscription{
+		ServerStream: m.stream,
+		mux:          m,
+		id:           id,
+		ctx:          ctx,
+	}, nil
+}
+
+// stop the subscription id, if it is active.
+func (m *multiplexer) stop(id uint64) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if cancel, ok := m.subs[id]; ok {
+		cancel()
+		delete(m.subs, id)
+	}
+}
+
+// end removes sub once Subscribe returned err, and sends err to the client
+// unless the subscription was stopped, or the stream ended.
+func (m *multiplexer) end(sub *multiplexedSubscription, err error) {
+	stopped := sub.ctx.Err() != nil
+	m.stop(sub.id)
+	if stopped {
+		return
+	}
+
+	st := status.Convert(err)
+	_ = m.send(&pbsubscribe.MultiplexedEvent{
+		ID: sub.id,
+		Payload: &pbsubscribe.MultiplexedEvent_Error{
+			Error: &pbsubscribe.SubscriptionError{
+				Code:    uint32(st.Code()),
+				Message: st.Message(),
+			},
+		},
+	})
+}
+
+func (m *multiplexer) send(e *pbsubscribe.MultiplexedEvent) error {
+	m.sendLock.Lock()
+	defer m.sendLock.Unlock()
+	return m.stream.Send(e)
+}
+
+// multiplexedSubscription is the stream of a subscription served by Subscribe.
+// It sends the events of the subscription to the multiplexed stream.
+type multiplexedSubscription struct {
+	// ServerStream is the multiplexed stream, shared by every subscription.
+	grpc.ServerStream
+
+	mux *multiplexer
+	id  uint64
+	ctx context.Context
+}
+
+var _ pbsubscribe.StateChangeSubscription_SubscribeServer = (*multiplexedSubscription)(nil)
+
+// Context returns the context of the subscription, which is cancelled when
+// the subscription is stopped or the multiplexed stream ends.
+func (s *multiplexedSubscription) Context() context.Context {
+	return s.ctx
+}
+
+// Send an event of the subscription.
+func (s *multiplexedSubscription) Send(e *pbsubscribe.Event) error {
+	if err := s.ctx.Err(); err != nil {
+		return err
+	}
+	return s.mux.send(&pbsubscribe.MultiplexedEvent{
+		ID:      s.id,
+		Payload: &pbsubscribe.MultiplexedEvent_Event{Event: e},
+	})
+}
@@ -0,0 +1,138 @@
+package subscribe
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+	gogrpc "google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/proto/pbsubscribe"
+)
+
+func TestServer_SubscribeMultiplexed_IntegrationWithBackend(t *testing.T) {
+	backend, err := newTestBackend()
+	require.NoError(t, err)
+	addr := runTestServer(t, NewServer(backend, hclog.New(nil)))
+	ids := newCounter()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(cancel)
+
+	conn, err := gogrpc.DialContext(ctx, addr.String(), gogrpc.WithInsecure())
+	require.NoError(t, err)
+	t.Cleanup(logError(t, conn.Close))
+
+	streamClient := pbsubscribe.NewStateChangeSubscriptionClient(conn)
+	streamHandle, err := streamClient.SubscribeMultiplexed(ctx)
+	require.NoError(t, err)
+
+	chEvents := make(chan multiplexedEventOrError)
+	go func() {
+		defer close(chEvents)
+		for {
+			event, err := streamHandle.Recv()
+			chEvents <- multiplexedEventOrError{event: event, err: err}
+			if err != nil {
+				return
+			}
+		}
+	}()
+
+	subscribe := func(id uint64, req *pbsubscribe.SubscribeRequest) {
+		require.NoError(t, streamHandle.Send(&pbsubscribe.MultiplexedRequest{
+			ID: id,
+			Op: &pbsubscribe.MultiplexedRequest_Subscribe{Subscribe: req},
+		}))
+	}
+	register := func(service string) uint64 {
+		index := ids.Next("reg-" + service)
+		require.NoError(t, backend.store.EnsureRegistration(index, &structs.RegisterRequest{
+			Node:       "node1",
+			Address:    "3.4.5.6",
+			Datacenter: "dc1",
+			Service: &structs.NodeService{
+				ID:      service + "1",
+				Service: service,
+				Port:    8080,
+			},
+		}))
+		return index
+	}
+
+	runStep(t, "events are sent with the ID of their subscription", func(t *testing.T) {
+		subscribe(1, &pbsubscribe.SubscribeRequest{Topic: pbsubscribe.Topic_ServiceHealth, Key: "redis"})
+		subscribe(2, &pbsubscribe.SubscribeRequest{Topic: pbsubscribe.Topic_ServiceHealth, Key: "web"})
+
+		snapshots := make(map[uint64]bool)
+		for i := 0; i < 2; i++ {
+			event := getMultiplexedEvent(t, chEvents)
+			snapshots[event.ID] = event.GetEvent().GetEndOfSnapshot()
+		}
+		require.Equal(t, map[uint64]bool{1: true, 2: true}, snapshots)
+	})
+
+	runStep(t, "a failed subscription does not end the stream", func(t *testing.T) {
+		subscribe(3, &pbsubscribe.SubscribeRequest{
+			Topic:  pbsubscribe.Topic_ServiceHealth,
+			Key:    "redis",
+			Filter: "not a valid ((",
+		})
+
+		event := getMultiplexedEvent(t, chEvents)
+		require.Equal(t, uint64(3), event.ID)
+		require.Equal(t, uint32(codes.InvalidArgument), event.GetError().GetCode())
+	})
+
+	runStep(t, "no events are sent after a subscription is stopped", func(t *testing.T) {
+		require.NoError(t, streamHandle.Send(&pbsubscribe.MultiplexedRequest{
+			ID: 1,
+			Op: &pbsubscribe.MultiplexedRequest_Unsubscribe{Unsubscribe: true},
+		}))
+		// The requests are handled in order, so the subscription 1 is stopped
+		// once the snapshot of subscription 4 is received.
+		subscribe(4, &pbsubscribe.SubscribeRequest{Topic: pbsubscribe.Topic_ServiceHealth, Key: "redis"})
+		event := getMultiplexedEvent(t, chEvents)
+		require.Equal(t, uint64(4), event.ID)
+		require.True(t, event.GetEvent().GetEndOfSnapshot())
+
+		redisIndex := register("redis")
+		event = getMultiplexedEvent(t, chEvents)
+		require.Equal(t, uint64(4), event.ID)
+		require.Equal(t, redisIndex, event.GetEvent().Index)
+
+		webIndex := register("web")
+		event = getMultiplexedEvent(t, chEvents)
+		require.Equal(t, uint64(2), event.ID)
+		require.Equal(t, webIndex, event.GetEvent().Index)
+	})
+
+	runStep(t, "IDs of active subscriptions can not be reused", func(t *testing.T) {
+		subscribe(2, &pbsubscribe.SubscribeRequest{Topic: pbsubscribe.Topic_ServiceHealth, Key: "web"})
+
+		item := <-chEvents
+		require.Equal(t, codes.InvalidArgument, status.Code(item.err))
+	})
+}
+
+type multiplexedEventOrError struct {
+	event *pbsubscribe.MultiplexedEvent
+	err   error
+}
+
+func getMultiplexedEvent(t *testing.T, ch chan multiplexedEventOrError) *pbsubscribe.MultiplexedEvent {
+	t.Helper()
+	select {
+	case item := <-ch:
+		require.NoError(t, item.err)
+		return item.event
+	case <-time.After(2 * time.Second):
+		t.Fatalf("timeout waiting on event from server")
+	}
+	return nil
+}
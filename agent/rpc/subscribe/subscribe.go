@@ -1,8 +1,10 @@
 package subscribe
 
 import (
+	"context"
 	"errors"
 	"fmt"
+	"time"
 
 	"github.com/hashicorp/go-hclog"
 	"google.golang.org/grpc"
@@ -39,6 +41,9 @@ type Backend interface {
 	ResolveTokenAndDefaultMeta(token string, entMeta *structs.EnterpriseMeta, authzContext *acl.AuthorizerContext) (acl.Authorizer, error)
 	Forward(info structs.RPCInfo, f func(*grpc.ClientConn) error) (handled bool, err error)
 	Subscribe(req *stream.SubscribeRequest) (*stream.Subscription, error)
+	// KeyListPolicyEnabled returns true if the list permission is required to
+	// list the KV entries of a prefix. See acl.enable_key_list_policy.
+	KeyListPolicyEnabled() bool
 }
 
 func (h *Server) Subscribe(req *pbsubscribe.SubscribeRequest, serverStream pbsubscribe.StateChangeSubscription_SubscribeServer) error {
@@ -51,11 +56,20 @@ func (h *Server) Subscribe(req *pbsubscribe.SubscribeRequest, serverStream pbsub
 	logger.Trace("new subscription")
 	defer logger.Trace("subscription closed")
 
+	filter, err := newEventFilter(req)
+	if err != nil {
+		return status.Error(codes.InvalidArgument, err.Error())
+	}
+
 	entMeta := structs.NewEnterpriseMetaWithPartition(req.Partition, req.Namespace)
-	authz, err := h.Backend.ResolveTokenAndDefaultMeta(req.Token, &entMeta, nil)
+	var authzContext acl.AuthorizerContext
+	authz, err := h.Backend.ResolveTokenAndDefaultMeta(req.Token, &entMeta, &authzContext)
 	if err != nil {
 		return err
 	}
+	if err := h.authorizeKey(req, authz, &authzContext); err != nil {
+		return err
+	}
 
 	sub, err := h.Backend.Subscribe(toStreamSubscribeRequest(req, entMeta))
 	if err != nil {
@@ -65,9 +79,25 @@ func (h *Server) Subscribe(req *pbsubscribe.SubscribeRequest, serverStream pbsub
 
 	ctx := serverStream.Context()
 	elog := &eventLogger{logger: logger}
+	heartbeat := heartbeatInterval(req)
+	resumer := newSnapshotResumer(req)
+	var (
+		lastIndex uint64
+		lastSent  = time.Now()
+	)
 	for {
-		event, err := sub.Next(ctx)
+		event, err := nextEvent(ctx, sub, heartbeat, lastSent)
 		switch {
+		case errors.Is(err, errHeartbeatDue):
+			e := &pbsubscribe.Event{
+				Index:   lastIndex,
+				Payload: &pbsubscribe.Event_Heartbeat{Heartbeat: true},
+			}
+			if err := serverStream.Send(e); err != nil {
+				return err
+			}
+			lastSent = time.Now()
+			continue
 		case errors.Is(err, stream.ErrSubForceClosed):
 			logger.Trace("subscription reset by server")
 			return status.Error(codes.Aborted, err.Error())
@@ -79,12 +109,142 @@ func (h *Server) Subscribe(req *pbsubscribe.SubscribeRequest, serverStream pbsub
 			continue
 		}
 
+		if filter != nil {
+			var ok bool
+			event, ok, err = filter.Apply(event)
+			switch {
+			case err != nil:
+				return status.Error(codes.InvalidArgument, err.Error())
+			case !ok:
+				continue
+			}
+		}
+
 		elog.Trace(event)
-		e := newEventFromStreamEvent(event)
-		if err := serverStream.Send(e); err != nil {
+		e, err := newEventFromStreamEvent(event)
+		if err != nil {
+			return status.Error(codes.Internal, err.Error())
+		}
+		resumed, err := resumer.send(serverStream, e)
+		if err != nil {
 			return err
 		}
+		if resumed {
+			logger.Trace("resumed subscription with a matching view hash")
+		}
+		lastIndex, lastSent = event.Index, time.Now()
+	}
+}
+
+// authorizeKey checks the permissions a KV subscription requires for its key,
+// in addition to the read permission of each entry. Like the KVS.Get RPC, the
+// subscription to an exact key requires the read permission of the key, so
+// that a denied key is not mistaken for a missing one. Like the KVS.List RPC,
+// the subscription to a prefix requires the list permission of the prefix
+// when the key list policy is enabled.
+func (h *Server) authorizeKey(req *pbsubscribe.SubscribeRequest, authz acl.Authorizer, authzContext *acl.AuthorizerContext) error {
+	if req.Topic != pbsubscribe.Topic_KV {
+		return nil
+	}
+	switch {
+	case req.ExactKey && authz.KeyRead(req.Key, authzContext) != acl.Allow:
+		return status.Error(codes.PermissionDenied, acl.ErrPermissionDenied.Error())
+	case !req.ExactKey && h.Backend.KeyListPolicyEnabled() && authz.KeyList(req.Key, authzContext) != acl.Allow:
+		return status.Error(codes.PermissionDenied, acl.ErrPermissionDenied.Error())
+	}
+	return nil
+}
+
+// snapshotResumer holds back the NewSnapshotToFollow event and the snapshot
+// sent to a subscriber which resumes from an index with a ViewHash. Once the
+// snapshot is complete, the subscriber receives a ResumeStream event in place
+// of the held events if their hash matches the ViewHash, otherwise it receives
+// the held events.
+type snapshotResumer struct {
+	// viewHash is the ViewHash of the request. It is cleared once the snapshot
+	// is complete, so that only the first snapshot is compared.
+	viewHash uint64
+	holding  bool
+	held     []*pbsubscribe.Event
+	hash     pbsubscribe.ViewHash
+}
+
+func newSnapshotResumer(req *pbsubscribe.SubscribeRequest) *snapshotResumer {
+	r := &snapshotResumer{}
+	if req.Index > 0 {
+		r.viewHash = req.ViewHash
+	}
+	return r
+}
+
+// send e to the subscriber, unless it is held back. It returns true when a
+// ResumeStream event was sent in place of the held events.
+func (r *snapshotResumer) send(stream pbsubscribe.StateChangeSubscription_SubscribeServer, e *pbsubscribe.Event) (bool, error) {
+	switch {
+	case r.viewHash != 0 && !r.holding && e.GetNewSnapshotToFollow():
+		r.holding = true
+		r.held = []*pbsubscribe.Event{e}
+		return false, nil
+	case !r.holding:
+		return false, stream.Send(e)
+	case !e.GetEndOfSnapshot():
+		r.held = append(r.held, e)
+		r.hash.Add(e)
+		return false, nil
+	}
+
+	held, viewHash := append(r.held, e), r.viewHash
+	r.holding, r.held, r.viewHash = false, nil, 0
+	if r.hash.Sum() == viewHash {
+		return true, stream.Send(&pbsubscribe.Event{
+			Index:   e.Index,
+			Payload: &pbsubscribe.Event_ResumeStream{ResumeStream: true},
+		})
+	}
+	for _, e := range held {
+		if err := stream.Send(e); err != nil {
+			return false, err
+		}
+	}
+	return false, nil
+}
+
+// minHeartbeatInterval is the shortest HeartbeatIntervalMillis a subscriber
+// may request, to protect the servers from subscribers asking for a constant
+// stream of heartbeats.
+var minHeartbeatInterval = time.Second
+
+// heartbeatInterval returns the interval between heartbeats requested by req,
+// or 0 if req does not request heartbeats.
+func heartbeatInterval(req *pbsubscribe.SubscribeRequest) time.Duration {
+	if req.HeartbeatIntervalMillis == 0 {
+		return 0
+	}
+	interval := time.Duration(req.HeartbeatIntervalMillis) * time.Millisecond
+	if interval < minHeartbeatInterval {
+		return minHeartbeatInterval
 	}
+	return interval
+}
+
+// errHeartbeatDue is returned by nextEvent when a heartbeat must be sent.
+var errHeartbeatDue = errors.New("heartbeat is due")
+
+// nextEvent returns the next event of sub. When heartbeat is set, it returns
+// errHeartbeatDue if no event is available before heartbeat has passed since
+// lastSent.
+func nextEvent(ctx context.Context, sub *stream.Subscription, heartbeat time.Duration, lastSent time.Time) (stream.Event, error) {
+	if heartbeat == 0 {
+		return sub.Next(ctx)
+	}
+
+	nextCtx, cancel := context.WithDeadline(ctx, lastSent.Add(heartbeat))
+	defer cancel()
+	event, err := sub.Next(nextCtx)
+	if err != nil && ctx.Err() == nil && errors.Is(nextCtx.Err(), context.DeadlineExceeded) {
+		return event, errHeartbeatDue
+	}
+	return event, err
 }
 
 func toStreamSubscribeRequest(req *pbsubscribe.SubscribeRequest, entMeta structs.EnterpriseMeta) *stream.SubscribeRequest {
@@ -125,27 +285,33 @@ func forwardToDC(
 	}
 }
 
-func newEventFromStreamEvent(event stream.Event) *pbsubscribe.Event {
+func newEventFromStreamEvent(event stream.Event) (*pbsubscribe.Event, error) {
 	e := &pbsubscribe.Event{Index: event.Index}
 	switch {
 	case event.IsEndOfSnapshot():
 		e.Payload = &pbsubscribe.Event_EndOfSnapshot{EndOfSnapshot: true}
-		return e
+		return e, nil
 	case event.IsNewSnapshotToFollow():
 		e.Payload = &pbsubscribe.Event_NewSnapshotToFollow{NewSnapshotToFollow: true}
-		return e
+		return e, nil
 	}
-	setPayload(e, event.Payload)
-	return e
+	if err := setPayload(e, event.Payload); err != nil {
+		return nil, err
+	}
+	return e, nil
 }
 
-func setPayload(e *pbsubscribe.Event, payload stream.Payload) {
+// setPayload sets the Payload of e from payload. It returns an error if the
+// payload can not be converted, which ends the subscription.
+func setPayload(e *pbsubscribe.Event, payload stream.Payload) error {
 	switch p := payload.(type) {
 	case *stream.PayloadEvents:
+		events, err := batchEventsFromEventSlice(p.Items)
+		if err != nil {
+			return err
+		}
 		e.Payload = &pbsubscribe.Event_EventBatch{
-			EventBatch: &pbsubscribe.EventBatch{
-				Events: batchEventsFromEventSlice(p.Items),
-			},
+			EventBatch: &pbsubscribe.EventBatch{Events: events},
 		}
 	case state.EventPayloadCheckServiceNode:
 		e.Payload = &pbsubscribe.Event_ServiceHealth{
@@ -155,17 +321,66 @@ func setPayload(e *pbsubscribe.Event, payload stream.Payload) {
 				CheckServiceNode: pbservice.NewCheckServiceNodeFromStructs(p.Value),
 			},
 		}
+	case state.EventPayloadKV:
+		e.Payload = &pbsubscribe.Event_KV{
+			KV: &pbsubscribe.KVUpdate{
+				Op:    p.Op,
+				Entry: pbsubscribe.NewKVEntryFromStructs(p.Value),
+			},
+		}
+	case state.EventPayloadCatalogService:
+		entMeta := pbservice.NewEnterpriseMetaFromStructs(p.Value.EnterpriseMeta)
+		e.Payload = &pbsubscribe.Event_CatalogService{
+			CatalogService: &pbsubscribe.CatalogServiceUpdate{
+				Op:             p.Op,
+				Node:           p.Value.Node,
+				ServiceID:      p.Value.ServiceID,
+				ServiceName:    p.Value.ServiceName,
+				ServiceTags:    p.Value.ServiceTags,
+				EnterpriseMeta: &entMeta,
+			},
+		}
+	case state.EventPayloadIntention:
+		e.Payload = &pbsubscribe.Event_Intention{
+			Intention: &pbsubscribe.IntentionUpdate{
+				Op:        p.Op,
+				Intention: pbsubscribe.NewIntentionFromStructs(p.Value),
+			},
+		}
+	case state.EventPayloadConfigEntry:
+		update, err := pbsubscribe.NewConfigEntryUpdateFromStructs(p.Op, p.Value)
+		if err != nil {
+			return err
+		}
+		e.Payload = &pbsubscribe.Event_ConfigEntry{ConfigEntry: update}
+	case state.EventPayloadNode:
+		update := &pbsubscribe.NodeUpdate{Op: p.Op, NodeName: p.NodeName()}
+		switch {
+		case p.Service != nil:
+			svc := pbservice.NewNodeServiceFromStructs(*p.Service.ToNodeService())
+			update.Service = &svc
+		case p.Check != nil:
+			check := pbservice.NewHealthCheckFromStructs(*p.Check)
+			update.Check = &check
+		default:
+			node := pbservice.NewNodeFromStructs(*p.Node)
+			update.Node = &node
+		}
+		e.Payload = &pbsubscribe.Event_Node{Node: update}
 	default:
-		panic(fmt.Sprintf("unexpected payload: %T: %#v", p, p))
+		return fmt.Errorf("unexpected payload: %T", p)
 	}
+	return nil
 }
 
-func batchEventsFromEventSlice(events []stream.Event) []*pbsubscribe.Event {
+func batchEventsFromEventSlice(events []stream.Event) ([]*pbsubscribe.Event, error) {
 	result := make([]*pbsubscribe.Event, len(events))
 	for i := range events {
 		event := events[i]
 		result[i] = &pbsubscribe.Event{Index: event.Index}
-		setPayload(result[i], event.Payload)
+		if err := setPayload(result[i], event.Payload); err != nil {
+			return nil, err
+		}
 	}
-	return result
+	return result, nil
 }
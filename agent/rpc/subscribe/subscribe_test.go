@@ -279,9 +279,10 @@ func assertDeepEqual(t *testing.T, x, y interface{}, opts ...cmp.Option) {
 }
 
 type testBackend struct {
-	store       *state.Store
-	authorizer  func(token string, entMeta *structs.EnterpriseMeta) acl.Authorizer
-	forwardConn *gogrpc.ClientConn
+	store         *state.Store
+	authorizer    func(token string, entMeta *structs.EnterpriseMeta) acl.Authorizer
+	forwardConn   *gogrpc.ClientConn
+	keyListPolicy bool
 }
 
 func (b testBackend) ResolveTokenAndDefaultMeta(
@@ -303,6 +304,10 @@ func (b testBackend) Subscribe(req *stream.SubscribeRequest) (*stream.Subscripti
 	return b.store.EventPublisher().Subscribe(req)
 }
 
+func (b testBackend) KeyListPolicyEnabled() bool {
+	return b.keyListPolicy
+}
+
 func newTestBackend() (*testBackend, error) {
 	gc, err := state.NewTombstoneGC(time.Second, time.Millisecond)
 	if err != nil {
@@ -870,6 +875,231 @@ node "node1" {
 	})
 }
 
+func TestServer_Subscribe_IntegrationWithBackend_KVKeyPermissions(t *testing.T) {
+	backend, err := newTestBackend()
+	require.NoError(t, err)
+	addr := runTestServer(t, NewServer(backend, hclog.New(nil)))
+	token := "this-token-is-good"
+
+	rules := `
+key_prefix "foo/" {
+	policy = "read"
+}
+`
+	authorizer, err := acl.NewAuthorizerFromRules(
+		"1", 0, rules, acl.SyntaxCurrent,
+		&acl.Config{WildcardName: structs.WildcardSpecifier},
+		nil)
+	require.NoError(t, err)
+	authorizer = acl.NewChainedAuthorizer([]acl.Authorizer{authorizer, acl.DenyAll()})
+	backend.authorizer = func(tok string, _ *structs.EnterpriseMeta) acl.Authorizer {
+		if tok == token {
+			return authorizer
+		}
+		return acl.DenyAll()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(cancel)
+
+	conn, err := gogrpc.DialContext(ctx, addr.String(), gogrpc.WithInsecure())
+	require.NoError(t, err)
+	t.Cleanup(logError(t, conn.Close))
+	streamClient := pbsubscribe.NewStateChangeSubscriptionClient(conn)
+
+	subscribe := func(t *testing.T, key string, exact bool) (*pbsubscribe.Event, error) {
+		streamCtx, cancel := context.WithCancel(ctx)
+		t.Cleanup(cancel)
+		streamHandle, err := streamClient.Subscribe(streamCtx, &pbsubscribe.SubscribeRequest{
+			Topic:    pbsubscribe.Topic_KV,
+			Key:      key,
+			Token:    token,
+			ExactKey: exact,
+		})
+		require.NoError(t, err)
+		return streamHandle.Recv()
+	}
+
+	runStep(t, "prefix without the key list policy", func(t *testing.T) {
+		event, err := subscribe(t, "foo/", false)
+		require.NoError(t, err)
+		require.True(t, event.GetEndOfSnapshot())
+	})
+
+	runStep(t, "exact key with read permission", func(t *testing.T) {
+		event, err := subscribe(t, "foo/bar", true)
+		require.NoError(t, err)
+		require.True(t, event.GetEndOfSnapshot())
+	})
+
+	runStep(t, "exact key without read permission", func(t *testing.T) {
+		_, err := subscribe(t, "secret", true)
+		require.Equal(t, codes.PermissionDenied, status.Code(err))
+		require.True(t, acl.IsErrPermissionDenied(err))
+	})
+
+	runStep(t, "prefix with the key list policy", func(t *testing.T) {
+		backend.keyListPolicy = true
+		_, err := subscribe(t, "foo/", false)
+		require.Equal(t, codes.PermissionDenied, status.Code(err))
+	})
+}
+
+func TestServer_Subscribe_IntegrationWithBackend_Heartbeat(t *testing.T) {
+	orig := minHeartbeatInterval
+	minHeartbeatInterval = 10 * time.Millisecond
+	t.Cleanup(func() { minHeartbeatInterval = orig })
+
+	backend, err := newTestBackend()
+	require.NoError(t, err)
+	addr := runTestServer(t, NewServer(backend, hclog.New(nil)))
+	ids := newCounter()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(cancel)
+
+	conn, err := gogrpc.DialContext(ctx, addr.String(), gogrpc.WithInsecure())
+	require.NoError(t, err)
+	t.Cleanup(logError(t, conn.Close))
+
+	chEvents := make(chan eventOrError, 0)
+	streamClient := pbsubscribe.NewStateChangeSubscriptionClient(conn)
+	streamHandle, err := streamClient.Subscribe(ctx, &pbsubscribe.SubscribeRequest{
+		Topic:                   pbsubscribe.Topic_ServiceHealth,
+		Key:                     "redis",
+		HeartbeatIntervalMillis: 50,
+	})
+	require.NoError(t, err)
+	go recvEvents(chEvents, streamHandle)
+
+	snapshot := getEvent(t, chEvents)
+	require.True(t, snapshot.GetEndOfSnapshot())
+
+	runStep(t, "a heartbeat is sent when there are no events", func(t *testing.T) {
+		event := getEvent(t, chEvents)
+		require.True(t, event.GetHeartbeat())
+		require.Equal(t, snapshot.Index, event.Index)
+	})
+
+	runStep(t, "heartbeats carry the index of the last event", func(t *testing.T) {
+		req := &structs.RegisterRequest{
+			Node:       "node1",
+			Address:    "3.4.5.6",
+			Datacenter: "dc1",
+			Service: &structs.NodeService{
+				ID:      "redis1",
+				Service: "redis",
+				Port:    8080,
+			},
+		}
+		index := ids.Next("reg")
+		require.NoError(t, backend.store.EnsureRegistration(index, req))
+
+		// Heartbeats may be sent before the event is published.
+		event := getEvent(t, chEvents)
+		for event.GetHeartbeat() {
+			event = getEvent(t, chEvents)
+		}
+		require.Equal(t, index, event.Index)
+		require.NotNil(t, event.GetServiceHealth())
+
+		event = getEvent(t, chEvents)
+		require.True(t, event.GetHeartbeat())
+		require.Equal(t, index, event.Index)
+	})
+}
+
+func TestServer_Subscribe_IntegrationWithBackend_ResumeWithViewHash(t *testing.T) {
+	backend, err := newTestBackend()
+	require.NoError(t, err)
+	addr := runTestServer(t, NewServer(backend, hclog.New(nil)))
+	ids := newCounter()
+
+	register := func(t *testing.T, node string) {
+		req := &structs.RegisterRequest{
+			Node:       node,
+			Address:    "3.4.5.6",
+			Datacenter: "dc1",
+			Service: &structs.NodeService{
+				ID:      "redis1",
+				Service: "redis",
+				Port:    8080,
+			},
+		}
+		require.NoError(t, backend.store.EnsureRegistration(ids.Next(node), req))
+	}
+	register(t, "node1")
+	register(t, "node2")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(cancel)
+
+	conn, err := gogrpc.DialContext(ctx, addr.String(), gogrpc.WithInsecure())
+	require.NoError(t, err)
+	t.Cleanup(logError(t, conn.Close))
+	streamClient := pbsubscribe.NewStateChangeSubscriptionClient(conn)
+
+	subscribe := func(t *testing.T, req *pbsubscribe.SubscribeRequest) chan eventOrError {
+		ctx, cancel := context.WithCancel(ctx)
+		t.Cleanup(cancel)
+		streamHandle, err := streamClient.Subscribe(ctx, req)
+		require.NoError(t, err)
+		chEvents := make(chan eventOrError, 0)
+		go recvEvents(chEvents, streamHandle)
+		return chEvents
+	}
+
+	var hash pbsubscribe.ViewHash
+	chEvents := subscribe(t, &pbsubscribe.SubscribeRequest{Topic: pbsubscribe.Topic_ServiceHealth, Key: "redis"})
+	for event := getEvent(t, chEvents); !event.GetEndOfSnapshot(); event = getEvent(t, chEvents) {
+		hash.Add(event)
+	}
+	require.NotZero(t, hash.Sum())
+
+	// An index which is not the last event of the topic can not be resumed
+	// from the topic buffer, so the server builds a new snapshot.
+	resumeReq := &pbsubscribe.SubscribeRequest{
+		Topic:    pbsubscribe.Topic_ServiceHealth,
+		Key:      "redis",
+		Index:    ids.For("node1"),
+		ViewHash: hash.Sum(),
+	}
+
+	runStep(t, "a view which matches the snapshot is resumed", func(t *testing.T) {
+		chEvents := subscribe(t, resumeReq)
+		event := getEvent(t, chEvents)
+		require.True(t, event.GetResumeStream())
+		require.Equal(t, ids.Last(), event.Index)
+
+		register(t, "node3")
+		event = getEvent(t, chEvents)
+		require.Equal(t, ids.Last(), event.Index)
+		require.Equal(t, "node3", event.GetServiceHealth().CheckServiceNode.Node.Node)
+	})
+
+	runStep(t, "a view which does not match the snapshot is reset", func(t *testing.T) {
+		req := *resumeReq
+		req.ViewHash++
+		chEvents := subscribe(t, &req)
+		event := getEvent(t, chEvents)
+		require.True(t, event.GetNewSnapshotToFollow())
+
+		var snapshot []*pbsubscribe.Event
+		for event = getEvent(t, chEvents); !event.GetEndOfSnapshot(); event = getEvent(t, chEvents) {
+			snapshot = append(snapshot, event)
+		}
+		require.NotEmpty(t, snapshot)
+	})
+}
+
+func TestHeartbeatInterval(t *testing.T) {
+	require.Equal(t, time.Duration(0), heartbeatInterval(&pbsubscribe.SubscribeRequest{}))
+	require.Equal(t, minHeartbeatInterval,
+		heartbeatInterval(&pbsubscribe.SubscribeRequest{HeartbeatIntervalMillis: 1}))
+	require.Equal(t, 30*time.Second,
+		heartbeatInterval(&pbsubscribe.SubscribeRequest{HeartbeatIntervalMillis: 30000}))
+}
+
 func assertNoEvents(t *testing.T, chEvents chan eventOrError) {
 	t.Helper()
 	select {
@@ -903,7 +1133,8 @@ func TestNewEventFromSteamEvent(t *testing.T) {
 
 	fn := func(t *testing.T, tc testCase) {
 		expected := tc.expected
-		actual := newEventFromStreamEvent(tc.event)
+		actual, err := newEventFromStreamEvent(tc.event)
+		require.NoError(t, err)
 		assertDeepEqual(t, &expected, actual, cmpopts.EquateEmpty())
 	}
 
@@ -1017,6 +1248,24 @@ func TestNewEventFromSteamEvent(t *testing.T) {
 	}
 }
 
+type unexpectedPayload struct{}
+
+func (unexpectedPayload) MatchesKey(string, string, string) bool { return true }
+
+func (unexpectedPayload) HasReadPermission(acl.Authorizer) bool { return true }
+
+func TestNewEventFromStreamEvent_UnexpectedPayload(t *testing.T) {
+	_, err := newEventFromStreamEvent(stream.Event{Index: 2, Payload: unexpectedPayload{}})
+	require.Error(t, err)
+
+	batch := &stream.PayloadEvents{Items: []stream.Event{
+		{Index: 2, Payload: state.EventPayloadKV{Value: &structs.DirEntry{Key: "a"}}},
+		{Index: 2, Payload: unexpectedPayload{}},
+	}}
+	_, err = newEventFromStreamEvent(stream.Event{Index: 2, Payload: batch})
+	require.Error(t, err)
+}
+
 func newPayloadEvents(items ...stream.Event) *stream.PayloadEvents {
 	return &stream.PayloadEvents{Items: items}
 }
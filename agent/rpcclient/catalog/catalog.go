@@ -0,0 +1,143 @@
+package catalog
+
+import (
+	"context"
+
+	"github.com/hashicorp/consul/agent/cache"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/agent/submatview"
+	"github.com/hashicorp/consul/proto/pbsubscribe"
+)
+
+// Client provides access to the list of services in the catalog.
+type Client struct {
+	NetRPC              NetRPC
+	Cache               CacheGetter
+	ViewStore           MaterializedViewStore
+	StreamClient        submatview.StreamClient
+	CacheName           string
+	UseStreamingBackend bool
+	QueryOptionDefaults func(options *structs.QueryOptions)
+
+	// unsupported remembers the datacenters whose servers do not support the
+	// CatalogServices topic.
+	unsupported submatview.UnsupportedTopic
+}
+
+type NetRPC interface {
+	RPC(method string, args interface{}, reply interface{}) error
+}
+
+type CacheGetter interface {
+	Get(ctx context.Context, t string, r cache.Request) (interface{}, cache.ResultMeta, error)
+}
+
+type MaterializedViewStore interface {
+	Get(ctx context.Context, req submatview.Request) (submatview.Result, error)
+	NewRequest(spec submatview.RequestSpec) (submatview.Request, error)
+}
+
+// RegisterView registers the view used to materialize the CatalogServices
+// topic with the store. It must be called once for each store before using a
+// Client with UseStreamingBackend enabled.
+func RegisterView(store *submatview.Store) error {
+	return store.RegisterView(pbsubscribe.Topic_CatalogServices, func(_ pbsubscribe.SubscribeRequest) (submatview.View, error) {
+		return newServicesView(), nil
+	})
+}
+
+// ListServices returns the names of the services in the catalog along with
+// their tags. Blocking and cached queries are served by a materialized view
+// when the streaming backend is enabled.
+func (c *Client) ListServices(
+	ctx context.Context,
+	req structs.DCSpecificRequest,
+) (structs.IndexedServices, cache.ResultMeta, error) {
+	if c.useStreaming(req) && (req.QueryOptions.UseCache || req.QueryOptions.MinQueryIndex > 0) {
+		out, meta, err := c.getFromView(ctx, req)
+		if !c.unsupported.Record(req.Datacenter, err) {
+			return out, meta, err
+		}
+	}
+
+	out, md, err := c.listServices(ctx, req)
+	if err != nil {
+		return out, md, err
+	}
+
+	if req.QueryOptions.AllowStale && req.QueryOptions.MaxStaleDuration > 0 && out.QueryMeta.LastContact > req.MaxStaleDuration {
+		req.AllowStale = false
+		err := c.NetRPC.RPC("Catalog.ListServices", &req, &out)
+		return out, cache.ResultMeta{}, err
+	}
+
+	return out, md, err
+}
+
+func (c *Client) listServices(
+	ctx context.Context,
+	req structs.DCSpecificRequest,
+) (structs.IndexedServices, cache.ResultMeta, error) {
+	var out structs.IndexedServices
+	if !req.QueryOptions.UseCache {
+		err := c.NetRPC.RPC("Catalog.ListServices", &req, &out)
+		return out, cache.ResultMeta{}, err
+	}
+
+	raw, md, err := c.Cache.Get(ctx, c.CacheName, &req)
+	if err != nil {
+		return out, md, err
+	}
+
+	value, ok := raw.(*structs.IndexedServices)
+	if !ok {
+		panic("wrong response type for cachetype.CatalogListServicesName")
+	}
+
+	return *value, md, nil
+}
+
+// useStreaming returns true if the request can be served by the view. The
+// view does not include the node of each service instance, so requests which
+// filter by node meta are not supported, nor are the requests to a datacenter
+// whose servers do not support the CatalogServices topic. Consistent reads use
+// the RPC, because the view may be behind the leader.
+func (c *Client) useStreaming(req structs.DCSpecificRequest) bool {
+	return c.UseStreamingBackend &&
+		len(req.NodeMetaFilters) == 0 &&
+		!req.QueryOptions.RequireConsistent &&
+		!c.unsupported.Unsupported(req.Datacenter)
+}
+
+func (c *Client) getFromView(
+	ctx context.Context,
+	req structs.DCSpecificRequest,
+) (structs.IndexedServices, cache.ResultMeta, error) {
+	c.QueryOptionDefaults(&req.QueryOptions)
+
+	sr, err := c.ViewStore.NewRequest(submatview.RequestSpec{
+		Subscribe: pbsubscribe.SubscribeRequest{
+			Topic:      pbsubscribe.Topic_CatalogServices,
+			Token:      req.Token,
+			Datacenter: req.Datacenter,
+			Namespace:  req.EnterpriseMeta.NamespaceOrEmpty(),
+			Partition:  req.EnterpriseMeta.PartitionOrEmpty(),
+		},
+		MinIndex: req.QueryOptions.MinQueryIndex,
+		Timeout:  req.QueryOptions.MaxQueryTime,
+		Client:   c.StreamClient,
+	})
+	if err != nil {
+		return structs.IndexedServices{}, cache.ResultMeta{}, err
+	}
+
+	result, err := c.ViewStore.Get(ctx, sr)
+	if err != nil {
+		return structs.IndexedServices{}, cache.ResultMeta{}, err
+	}
+	meta := result.Meta()
+	out := *result.Value.(*structs.IndexedServices)
+	out.EnterpriseMeta = req.EnterpriseMeta
+	out.QueryMeta.LastContact = meta.Age
+	return out, meta, nil
+}
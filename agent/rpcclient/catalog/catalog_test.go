@@ -0,0 +1,59 @@
+package catalog
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/agent/submatview"
+)
+
+type fakeNetRPC struct {
+	calls []string
+}
+
+func (f *fakeNetRPC) RPC(method string, _ interface{}, _ interface{}) error {
+	f.calls = append(f.calls, method)
+	return nil
+}
+
+type fakeViewStore struct {
+	specs []submatview.RequestSpec
+}
+
+func (f *fakeViewStore) NewRequest(spec submatview.RequestSpec) (submatview.Request, error) {
+	f.specs = append(f.specs, spec)
+	return nil, nil
+}
+
+func (f *fakeViewStore) Get(context.Context, submatview.Request) (submatview.Result, error) {
+	return submatview.Result{Index: 10, Value: &structs.IndexedServices{}}, nil
+}
+
+func TestClient_ListServices_RequireConsistent(t *testing.T) {
+	store := &fakeViewStore{}
+	rpc := &fakeNetRPC{}
+	c := &Client{
+		NetRPC:              rpc,
+		ViewStore:           store,
+		UseStreamingBackend: true,
+		QueryOptionDefaults: func(*structs.QueryOptions) {},
+	}
+
+	req := structs.DCSpecificRequest{
+		Datacenter:   "dc1",
+		QueryOptions: structs.QueryOptions{MinQueryIndex: 5},
+	}
+	_, _, err := c.ListServices(context.Background(), req)
+	require.NoError(t, err)
+	require.Len(t, store.specs, 1)
+	require.Len(t, rpc.calls, 0)
+
+	req.QueryOptions.RequireConsistent = true
+	_, _, err = c.ListServices(context.Background(), req)
+	require.NoError(t, err)
+	require.Len(t, store.specs, 1)
+	require.Equal(t, []string{"Catalog.ListServices"}, rpc.calls)
+}
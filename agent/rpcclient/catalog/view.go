@@ -0,0 +1,108 @@
+package catalog
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/agent/submatview"
+	"github.com/hashicorp/consul/proto/pbservice"
+	"github.com/hashicorp/consul/proto/pbsubscribe"
+)
+
+func newServicesView() *servicesView {
+	return &servicesView{state: make(map[string]serviceInstance)}
+}
+
+// serviceInstance is the part of a service instance stored by servicesView.
+type serviceInstance struct {
+	name string
+	tags []string
+}
+
+// servicesView implements submatview.View for storing the view state of the
+// list of services in the catalog. The view stores every service instance,
+// keyed by node and service ID, so that the tags of a service can be
+// recomputed when an instance is deregistered.
+type servicesView struct {
+	state map[string]serviceInstance
+	// size is the approximate size of state in bytes.
+	size int
+}
+
+// Update implements View
+func (v *servicesView) Update(events []*pbsubscribe.Event) error {
+	for _, event := range events {
+		update := event.GetCatalogService()
+		if update == nil {
+			return fmt.Errorf("unexpected event type for catalog services view: %T",
+				event.GetPayload())
+		}
+
+		var entMeta structs.EnterpriseMeta
+		if update.EnterpriseMeta != nil {
+			entMeta = pbservice.EnterpriseMetaToStructs(*update.EnterpriseMeta)
+		}
+		id := fmt.Sprintf("%s/%s/%s/%s", entMeta.PartitionOrDefault(),
+			update.Node, entMeta.NamespaceOrDefault(), update.ServiceID)
+
+		switch update.Op {
+		case pbsubscribe.CatalogOp_Register:
+			v.delete(id)
+			instance := serviceInstance{name: update.ServiceName, tags: update.ServiceTags}
+			v.state[id] = instance
+			v.size += len(id) + submatview.ApproximateSize(instance)
+		case pbsubscribe.CatalogOp_Deregister:
+			v.delete(id)
+		}
+	}
+	return nil
+}
+
+func (v *servicesView) delete(id string) {
+	if instance, ok := v.state[id]; ok {
+		v.size -= len(id) + submatview.ApproximateSize(instance)
+		delete(v.state, id)
+	}
+}
+
+// SizeBytes implements submatview.SizedView
+func (v *servicesView) SizeBytes() int {
+	return v.size
+}
+
+// Result returns the structs.IndexedServices stored by this view. Like the
+// Catalog.ListServices RPC, the tags of a service are the unique set of tags
+// of all its instances.
+func (v *servicesView) Result(index uint64) interface{} {
+	unique := make(map[string]map[string]struct{})
+	for _, instance := range v.state {
+		tags, ok := unique[instance.name]
+		if !ok {
+			tags = make(map[string]struct{})
+			unique[instance.name] = tags
+		}
+		for _, tag := range instance.tags {
+			tags[tag] = struct{}{}
+		}
+	}
+
+	result := structs.IndexedServices{
+		Services: make(structs.Services, len(unique)),
+		QueryMeta: structs.QueryMeta{
+			Index:   index,
+			Backend: structs.QueryBackendStreaming,
+		},
+	}
+	for name, tags := range unique {
+		result.Services[name] = make([]string, 0, len(tags))
+		for tag := range tags {
+			result.Services[name] = append(result.Services[name], tag)
+		}
+	}
+	return &result
+}
+
+func (v *servicesView) Reset() {
+	v.state = make(map[string]serviceInstance)
+	v.size = 0
+}
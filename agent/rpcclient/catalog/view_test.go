@@ -0,0 +1,56 @@
+package catalog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/proto/pbsubscribe"
+)
+
+func newEventCatalogService(op pbsubscribe.CatalogOp, node, service string, tags ...string) *pbsubscribe.Event {
+	return &pbsubscribe.Event{
+		Payload: &pbsubscribe.Event_CatalogService{
+			CatalogService: &pbsubscribe.CatalogServiceUpdate{
+				Op:          op,
+				Node:        node,
+				ServiceID:   service,
+				ServiceName: service,
+				ServiceTags: tags,
+			},
+		},
+	}
+}
+
+func TestServicesView(t *testing.T) {
+	view := newServicesView()
+
+	err := view.Update([]*pbsubscribe.Event{
+		newEventCatalogService(pbsubscribe.CatalogOp_Register, "node1", "api", "v1", "primary"),
+		newEventCatalogService(pbsubscribe.CatalogOp_Register, "node2", "api", "v2", "primary"),
+		newEventCatalogService(pbsubscribe.CatalogOp_Register, "node1", "db"),
+	})
+	require.NoError(t, err)
+
+	result := view.Result(5).(*structs.IndexedServices)
+	require.Equal(t, uint64(5), result.Index)
+	require.Equal(t, structs.QueryBackendStreaming, result.Backend)
+	require.Len(t, result.Services, 2)
+	require.ElementsMatch(t, []string{"v1", "v2", "primary"}, result.Services["api"])
+	require.Empty(t, result.Services["db"])
+
+	err = view.Update([]*pbsubscribe.Event{
+		newEventCatalogService(pbsubscribe.CatalogOp_Deregister, "node1", "api"),
+		newEventCatalogService(pbsubscribe.CatalogOp_Deregister, "node1", "db"),
+	})
+	require.NoError(t, err)
+
+	result = view.Result(6).(*structs.IndexedServices)
+	require.Len(t, result.Services, 1)
+	require.ElementsMatch(t, []string{"v2", "primary"}, result.Services["api"])
+
+	view.Reset()
+	result = view.Result(7).(*structs.IndexedServices)
+	require.Len(t, result.Services, 0)
+}
@@ -0,0 +1,272 @@
+package configentry
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/consul/agent/cache"
+	cachetype "github.com/hashicorp/consul/agent/cache-types"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/agent/submatview"
+	"github.com/hashicorp/consul/proto/pbsubscribe"
+)
+
+// Client provides access to config entries. When the streaming backend is
+// enabled, all the requests for config entries of the same kind are served by
+// a single materialized view of that kind.
+type Client struct {
+	NetRPC              NetRPC
+	Cache               CacheGetter
+	ViewStore           MaterializedViewStore
+	StreamClient        submatview.StreamClient
+	UseStreamingBackend bool
+	QueryOptionDefaults func(options *structs.QueryOptions)
+
+	// unsupported remembers the datacenters whose servers do not support the
+	// ConfigEntries topic.
+	unsupported submatview.UnsupportedTopic
+}
+
+type NetRPC interface {
+	RPC(method string, args interface{}, reply interface{}) error
+}
+
+type CacheGetter interface {
+	Get(ctx context.Context, t string, r cache.Request) (interface{}, cache.ResultMeta, error)
+	Notify(ctx context.Context, t string, r cache.Request, cID string, ch chan<- cache.UpdateEvent) error
+}
+
+type MaterializedViewStore interface {
+	Get(ctx context.Context, req submatview.Request) (submatview.Result, error)
+	NotifyCallback(ctx context.Context, req submatview.Request, cID string, cb cache.Callback) error
+	NewRequest(spec submatview.RequestSpec) (submatview.Request, error)
+}
+
+// RegisterView registers the view used to materialize the ConfigEntries topic
+// with the store. It must be called once for each store before using a Client
+// with UseStreamingBackend enabled.
+func RegisterView(store *submatview.Store) error {
+	return store.RegisterView(pbsubscribe.Topic_ConfigEntries, func(req pbsubscribe.SubscribeRequest) (submatview.View, error) {
+		return newConfigEntriesView(req.Key), nil
+	})
+}
+
+// Get returns the config entry with the kind and name of req, like the
+// ConfigEntry.Get RPC. Blocking and cached queries are served by a
+// materialized view when the streaming backend is enabled.
+func (c *Client) Get(ctx context.Context, req structs.ConfigEntryQuery) (structs.ConfigEntryResponse, cache.ResultMeta, error) {
+	if c.useStreaming(req) && (req.QueryOptions.UseCache || req.QueryOptions.MinQueryIndex > 0) {
+		out, meta, err := c.getFromView(ctx, req, func(out *structs.IndexedConfigEntries) {
+			filterEntry(out, req)
+		})
+		if !c.unsupported.Record(req.Datacenter, err) {
+			return entryResponse(out), meta, err
+		}
+	}
+
+	var out structs.ConfigEntryResponse
+	if !req.QueryOptions.UseCache {
+		err := c.NetRPC.RPC("ConfigEntry.Get", &req, &out)
+		return out, cache.ResultMeta{}, err
+	}
+
+	raw, md, err := c.Cache.Get(ctx, cachetype.ConfigEntryName, &req)
+	if err != nil {
+		return out, md, err
+	}
+
+	value, ok := raw.(*structs.ConfigEntryResponse)
+	if !ok {
+		panic("wrong response type for cachetype.ConfigEntryName")
+	}
+	return *value, md, nil
+}
+
+// List returns the config entries with the kind of req, like the
+// ConfigEntry.List RPC. Blocking and cached queries are served by a
+// materialized view when the streaming backend is enabled.
+func (c *Client) List(ctx context.Context, req structs.ConfigEntryQuery) (structs.IndexedConfigEntries, cache.ResultMeta, error) {
+	if c.useStreaming(req) && (req.QueryOptions.UseCache || req.QueryOptions.MinQueryIndex > 0) {
+		out, meta, err := c.getFromView(ctx, req, func(*structs.IndexedConfigEntries) {})
+		if !c.unsupported.Record(req.Datacenter, err) {
+			return out, meta, err
+		}
+	}
+
+	var out structs.IndexedConfigEntries
+	if !req.QueryOptions.UseCache {
+		err := c.NetRPC.RPC("ConfigEntry.List", &req, &out)
+		return out, cache.ResultMeta{}, err
+	}
+
+	raw, md, err := c.Cache.Get(ctx, cachetype.ConfigEntriesName, &req)
+	if err != nil {
+		return out, md, err
+	}
+
+	value, ok := raw.(*structs.IndexedConfigEntries)
+	if !ok {
+		panic("wrong response type for cachetype.ConfigEntriesName")
+	}
+	return *value, md, nil
+}
+
+// NotifyEntry sends the config entry with the kind and name of req to ch every
+// time it changes. The result is a *structs.ConfigEntryResponse, the same as
+// the cachetype.ConfigEntryName cache type used when the streaming backend is
+// disabled.
+func (c *Client) NotifyEntry(
+	ctx context.Context,
+	req *structs.ConfigEntryQuery,
+	correlationID string,
+	ch chan<- cache.UpdateEvent,
+) error {
+	if !c.useStreaming(*req) {
+		return c.Cache.Notify(ctx, cachetype.ConfigEntryName, req, correlationID, ch)
+	}
+
+	sr, err := c.newRequest(*req, 0, 0)
+	if err != nil {
+		return err
+	}
+
+	// The view contains every entry of the kind, so it is updated by changes
+	// to other entries. Only send an update when the entry itself changed.
+	var (
+		sent      bool
+		lastIndex uint64
+	)
+	query := *req
+	return c.ViewStore.NotifyCallback(ctx, sr, correlationID, func(ctx context.Context, event cache.UpdateEvent) {
+		if event.Err == nil {
+			out := *event.Result.(*structs.IndexedConfigEntries)
+			index := filterEntry(&out, query)
+			if sent && index == lastIndex {
+				return
+			}
+			sent, lastIndex = true, index
+			resp := entryResponse(out)
+			event.Result = &resp
+		}
+		select {
+		case ch <- event:
+		case <-ctx.Done():
+		}
+	})
+}
+
+// NotifyEntries sends the config entries with the kind of req to ch every
+// time they change. The result is a *structs.IndexedConfigEntries, the same as
+// the cachetype.ConfigEntriesName cache type used when the streaming backend
+// is disabled.
+func (c *Client) NotifyEntries(
+	ctx context.Context,
+	req *structs.ConfigEntryQuery,
+	correlationID string,
+	ch chan<- cache.UpdateEvent,
+) error {
+	if !c.useStreaming(*req) {
+		return c.Cache.Notify(ctx, cachetype.ConfigEntriesName, req, correlationID, ch)
+	}
+
+	sr, err := c.newRequest(*req, 0, 0)
+	if err != nil {
+		return err
+	}
+	return c.ViewStore.NotifyCallback(ctx, sr, correlationID, func(ctx context.Context, event cache.UpdateEvent) {
+		select {
+		case ch <- event:
+		case <-ctx.Done():
+		}
+	})
+}
+
+// useStreaming returns true if the request can be served by the view. Views
+// are materialized for a single kind, so requests for every kind are not
+// supported, nor are the requests to a datacenter whose servers do not support
+// the ConfigEntries topic.
+func (c *Client) useStreaming(req structs.ConfigEntryQuery) bool {
+	return c.UseStreamingBackend &&
+		req.Kind != "" &&
+		!c.unsupported.Unsupported(req.Datacenter)
+}
+
+func (c *Client) newRequest(req structs.ConfigEntryQuery, minIndex uint64, timeout time.Duration) (submatview.Request, error) {
+	return c.ViewStore.NewRequest(submatview.RequestSpec{
+		Subscribe: pbsubscribe.SubscribeRequest{
+			Topic:      pbsubscribe.Topic_ConfigEntries,
+			Key:        req.Kind,
+			Token:      req.Token,
+			Datacenter: req.Datacenter,
+			Namespace:  req.EnterpriseMeta.NamespaceOrEmpty(),
+			Partition:  req.EnterpriseMeta.PartitionOrEmpty(),
+		},
+		MinIndex: minIndex,
+		Timeout:  timeout,
+		Client:   c.StreamClient,
+	})
+}
+
+// getFromView returns the entries from the view of the kind of req. filter is
+// called to select the entries, and to set the index of the result.
+//
+// The view is shared by every request for entries of the kind, so the view
+// may be updated without a change to the result. In that case getFromView
+// continues to wait for an update until the request times out.
+func (c *Client) getFromView(
+	ctx context.Context,
+	req structs.ConfigEntryQuery,
+	filter func(out *structs.IndexedConfigEntries),
+) (structs.IndexedConfigEntries, cache.ResultMeta, error) {
+	c.QueryOptionDefaults(&req.QueryOptions)
+
+	minIndex := req.QueryOptions.MinQueryIndex
+	deadline := time.Now().Add(req.QueryOptions.MaxQueryTime)
+	viewIndex := minIndex
+	for {
+		sr, err := c.newRequest(req, viewIndex, time.Until(deadline))
+		if err != nil {
+			return structs.IndexedConfigEntries{}, cache.ResultMeta{}, err
+		}
+
+		result, err := c.ViewStore.Get(ctx, sr)
+		if err != nil {
+			return structs.IndexedConfigEntries{}, cache.ResultMeta{}, err
+		}
+
+		meta := result.Meta()
+		out := *result.Value.(*structs.IndexedConfigEntries)
+		out.QueryMeta.LastContact = meta.Age
+		filter(&out)
+		if out.Index > minIndex || result.Index <= viewIndex || !time.Now().Before(deadline) {
+			return out, meta, nil
+		}
+		viewIndex = result.Index
+	}
+}
+
+// filterEntry removes every entry from out except the one with the name of
+// req. If the entry exists, the index of out is set to its ModifyIndex,
+// matching the index of a ConfigEntry.Get RPC, and the ModifyIndex is
+// returned. Otherwise out keeps the index of the view, and 0 is returned.
+func filterEntry(out *structs.IndexedConfigEntries, req structs.ConfigEntryQuery) uint64 {
+	entries := out.Entries
+	out.Entries = nil
+	id := configEntryID(req.Name, &req.EnterpriseMeta)
+	for _, entry := range entries {
+		if configEntryID(entry.GetName(), entry.GetEnterpriseMeta()) == id {
+			out.Entries = []structs.ConfigEntry{entry}
+			out.Index = entry.GetRaftIndex().ModifyIndex
+			return out.Index
+		}
+	}
+	return 0
+}
+
+func entryResponse(out structs.IndexedConfigEntries) structs.ConfigEntryResponse {
+	resp := structs.ConfigEntryResponse{QueryMeta: out.QueryMeta}
+	if len(out.Entries) > 0 {
+		resp.Entry = out.Entries[0]
+	}
+	return resp
+}
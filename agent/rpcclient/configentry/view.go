@@ -0,0 +1,92 @@
+package configentry
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/agent/submatview"
+	"github.com/hashicorp/consul/proto/pbsubscribe"
+)
+
+func newConfigEntriesView(kind string) *configEntriesView {
+	return &configEntriesView{kind: kind, state: make(map[string]structs.ConfigEntry)}
+}
+
+// configEntriesView implements submatview.View for storing the view state of
+// the config entries of a single kind, keyed by partition, namespace, and
+// name.
+type configEntriesView struct {
+	kind  string
+	state map[string]structs.ConfigEntry
+	// size is the approximate size of state in bytes.
+	size int
+}
+
+func configEntryID(name string, entMeta *structs.EnterpriseMeta) string {
+	return entMeta.PartitionOrDefault() + "/" + entMeta.NamespaceOrDefault() + "/" + name
+}
+
+// Update implements View
+func (v *configEntriesView) Update(events []*pbsubscribe.Event) error {
+	for _, event := range events {
+		update := event.GetConfigEntry()
+		if update == nil {
+			return fmt.Errorf("unexpected event type for config entries view: %T",
+				event.GetPayload())
+		}
+
+		entry, err := pbsubscribe.ConfigEntryUpdateToStructs(update)
+		if err != nil {
+			return err
+		}
+		id := configEntryID(entry.GetName(), entry.GetEnterpriseMeta())
+		switch update.Op {
+		case pbsubscribe.UpdateOp_Upsert:
+			v.delete(id)
+			v.state[id] = entry
+			v.size += len(id) + submatview.ApproximateSize(entry)
+		case pbsubscribe.UpdateOp_Delete:
+			v.delete(id)
+		}
+	}
+	return nil
+}
+
+func (v *configEntriesView) delete(id string) {
+	if entry, ok := v.state[id]; ok {
+		v.size -= len(id) + submatview.ApproximateSize(entry)
+		delete(v.state, id)
+	}
+}
+
+// SizeBytes implements submatview.SizedView
+func (v *configEntriesView) SizeBytes() int {
+	return v.size
+}
+
+// Result returns the structs.IndexedConfigEntries stored by this view, sorted
+// by name like the result of the ConfigEntry.List RPC.
+func (v *configEntriesView) Result(index uint64) interface{} {
+	result := structs.IndexedConfigEntries{
+		Kind:    v.kind,
+		Entries: make([]structs.ConfigEntry, 0, len(v.state)),
+		QueryMeta: structs.QueryMeta{
+			Index:   index,
+			Backend: structs.QueryBackendStreaming,
+		},
+	}
+	for _, entry := range v.state {
+		result.Entries = append(result.Entries, entry)
+	}
+	sort.Slice(result.Entries, func(i, j int) bool {
+		a, b := result.Entries[i], result.Entries[j]
+		return configEntryID(a.GetName(), a.GetEnterpriseMeta()) < configEntryID(b.GetName(), b.GetEnterpriseMeta())
+	})
+	return &result
+}
+
+func (v *configEntriesView) Reset() {
+	v.state = make(map[string]structs.ConfigEntry)
+	v.size = 0
+}
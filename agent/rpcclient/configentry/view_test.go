@@ -0,0 +1,109 @@
+package configentry
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/proto/pbsubscribe"
+)
+
+func newEventConfigEntry(t *testing.T, op pbsubscribe.UpdateOp, entry structs.ConfigEntry) *pbsubscribe.Event {
+	t.Helper()
+	update, err := pbsubscribe.NewConfigEntryUpdateFromStructs(op, entry)
+	require.NoError(t, err)
+	return &pbsubscribe.Event{
+		Payload: &pbsubscribe.Event_ConfigEntry{ConfigEntry: update},
+	}
+}
+
+func TestConfigEntriesView(t *testing.T) {
+	view := newConfigEntriesView(structs.IngressGateway)
+
+	err := view.Update([]*pbsubscribe.Event{
+		newEventConfigEntry(t, pbsubscribe.UpdateOp_Upsert, &structs.IngressGatewayConfigEntry{
+			Kind:      structs.IngressGateway,
+			Name:      "ingress-b",
+			RaftIndex: structs.RaftIndex{CreateIndex: 2, ModifyIndex: 2},
+		}),
+		newEventConfigEntry(t, pbsubscribe.UpdateOp_Upsert, &structs.IngressGatewayConfigEntry{
+			Kind: structs.IngressGateway,
+			Name: "ingress-a",
+			Listeners: []structs.IngressListener{{
+				Port:     8080,
+				Protocol: "http",
+				Services: []structs.IngressService{{Name: "web"}},
+			}},
+			RaftIndex: structs.RaftIndex{CreateIndex: 3, ModifyIndex: 3},
+		}),
+	})
+	require.NoError(t, err)
+
+	result := view.Result(5).(*structs.IndexedConfigEntries)
+	require.Equal(t, uint64(5), result.Index)
+	require.Equal(t, structs.QueryBackendStreaming, result.Backend)
+	require.Equal(t, structs.IngressGateway, result.Kind)
+	require.Equal(t, []string{"ingress-a", "ingress-b"}, entryNames(result.Entries))
+
+	ingress := result.Entries[0].(*structs.IngressGatewayConfigEntry)
+	require.Equal(t, uint64(3), ingress.ModifyIndex)
+	require.Len(t, ingress.Listeners, 1)
+	require.Equal(t, "web", ingress.Listeners[0].Services[0].Name)
+
+	err = view.Update([]*pbsubscribe.Event{
+		newEventConfigEntry(t, pbsubscribe.UpdateOp_Delete, &structs.IngressGatewayConfigEntry{
+			Kind: structs.IngressGateway,
+			Name: "ingress-a",
+		}),
+	})
+	require.NoError(t, err)
+
+	result = view.Result(6).(*structs.IndexedConfigEntries)
+	require.Equal(t, []string{"ingress-b"}, entryNames(result.Entries))
+
+	view.Reset()
+	result = view.Result(7).(*structs.IndexedConfigEntries)
+	require.Len(t, result.Entries, 0)
+}
+
+func TestFilterEntry(t *testing.T) {
+	out := structs.IndexedConfigEntries{
+		Kind: structs.ServiceDefaults,
+		Entries: []structs.ConfigEntry{
+			&structs.ServiceConfigEntry{
+				Kind:      structs.ServiceDefaults,
+				Name:      "api",
+				RaftIndex: structs.RaftIndex{ModifyIndex: 4},
+			},
+			&structs.ServiceConfigEntry{
+				Kind:      structs.ServiceDefaults,
+				Name:      "web",
+				RaftIndex: structs.RaftIndex{ModifyIndex: 7},
+			},
+		},
+		QueryMeta: structs.QueryMeta{Index: 10},
+	}
+
+	found := out
+	index := filterEntry(&found, structs.ConfigEntryQuery{Kind: structs.ServiceDefaults, Name: "api"})
+	require.Equal(t, uint64(4), index)
+	require.Equal(t, uint64(4), found.Index)
+	require.Equal(t, []string{"api"}, entryNames(found.Entries))
+	require.Equal(t, "api", entryResponse(found).Entry.GetName())
+
+	missing := out
+	index = filterEntry(&missing, structs.ConfigEntryQuery{Kind: structs.ServiceDefaults, Name: "db"})
+	require.Equal(t, uint64(0), index)
+	require.Equal(t, uint64(10), missing.Index)
+	require.Len(t, missing.Entries, 0)
+	require.Nil(t, entryResponse(missing).Entry)
+}
+
+func entryNames(entries []structs.ConfigEntry) []string {
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entry.GetName())
+	}
+	return names
+}
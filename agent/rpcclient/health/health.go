@@ -2,6 +2,9 @@ package health
 
 import (
 	"context"
+	"errors"
+	"fmt"
+	"time"
 
 	"github.com/hashicorp/consul/agent/cache"
 	"github.com/hashicorp/consul/agent/structs"
@@ -32,6 +35,7 @@ type CacheGetter interface {
 type MaterializedViewStore interface {
 	Get(ctx context.Context, req submatview.Request) (submatview.Result, error)
 	Notify(ctx context.Context, req submatview.Request, cID string, ch chan<- cache.UpdateEvent) error
+	NewRequest(spec submatview.RequestSpec) (submatview.Request, error)
 }
 
 func (c *Client) ServiceNodes(
@@ -42,11 +46,24 @@ func (c *Client) ServiceNodes(
 		c.QueryOptionDefaults(&req.QueryOptions)
 
 		result, err := c.ViewStore.Get(ctx, c.newServiceRequest(req))
+		if errors.Is(err, submatview.ErrViewTooStale) {
+			out, err := c.serviceNodesFromServers(req)
+			return out, cache.ResultMeta{}, err
+		}
 		if err != nil {
 			return structs.IndexedCheckServiceNodes{}, cache.ResultMeta{}, err
 		}
-		meta := cache.ResultMeta{Index: result.Index, Hit: result.Cached}
-		return *result.Value.(*structs.IndexedCheckServiceNodes), meta, err
+		meta := result.Meta()
+		out := *result.Value.(*structs.IndexedCheckServiceNodes)
+		out.QueryMeta.LastContact = meta.Age
+		// The view has been out of contact with the servers for longer than
+		// the request allows, so read from the leader instead.
+		if isTooStale(req, out.QueryMeta) {
+			req.AllowStale = false
+			out, err := c.serviceNodesFromServers(req)
+			return out, cache.ResultMeta{}, err
+		}
+		return out, meta, err
 	}
 
 	out, md, err := c.getServiceNodes(ctx, req)
@@ -55,15 +72,115 @@ func (c *Client) ServiceNodes(
 	}
 
 	// TODO: DNSServer emitted a metric here, do we still need it?
-	if req.QueryOptions.AllowStale && req.QueryOptions.MaxStaleDuration > 0 && out.QueryMeta.LastContact > req.MaxStaleDuration {
+	if isTooStale(req, out.QueryMeta) {
 		req.AllowStale = false
-		err := c.NetRPC.RPC("Health.ServiceNodes", &req, &out)
-		return out, cache.ResultMeta{}, err
+		md = cache.ResultMeta{}
+		err = c.NetRPC.RPC("Health.ServiceNodes", &req, &out)
 	}
 
+	// The servers return every instance, only the views filter the instances
+	// which are not passing.
+	if err == nil && req.PassingOnly {
+		out.Nodes = filterPassing(out.Nodes)
+	}
 	return out, md, err
 }
 
+// serviceNodesFromServers returns the instances from the Health.ServiceNodes
+// RPC. It is used when the view has been out of contact with the servers for
+// longer than the MaxStaleDuration or the MaxAge of the request, in the same
+// way agent/cache fetches a new result for an entry older than the MaxAge.
+func (c *Client) serviceNodesFromServers(req structs.ServiceSpecificRequest) (structs.IndexedCheckServiceNodes, error) {
+	var out structs.IndexedCheckServiceNodes
+	err := c.NetRPC.RPC("Health.ServiceNodes", &req, &out)
+	if err == nil && req.PassingOnly {
+		out.Nodes = filterPassing(out.Nodes)
+	}
+	return out, err
+}
+
+func isTooStale(req structs.ServiceSpecificRequest, meta structs.QueryMeta) bool {
+	return req.QueryOptions.AllowStale && req.QueryOptions.MaxStaleDuration > 0 && meta.LastContact > req.MaxStaleDuration
+}
+
+// ServiceNodesDelta returns the instances of a service like ServiceNodes. When
+// the streaming backend is used, and the materialized view still has the events
+// after req.MinQueryIndex, the result only contains the instances which changed
+// after that index.
+func (c *Client) ServiceNodesDelta(
+	ctx context.Context,
+	req structs.ServiceSpecificRequest,
+) (ServiceNodesDelta, cache.ResultMeta, error) {
+	if !c.useStreaming(req) || req.QueryOptions.MinQueryIndex == 0 {
+		out, meta, err := c.ServiceNodes(ctx, req)
+		return ServiceNodesDelta{Updated: out.Nodes, QueryMeta: out.QueryMeta}, meta, err
+	}
+
+	c.QueryOptionDefaults(&req.QueryOptions)
+	sr := c.newServiceRequest(req)
+	sr.delta = true
+
+	result, err := c.ViewStore.Get(ctx, sr)
+	if errors.Is(err, submatview.ErrViewTooStale) {
+		out, err := c.serviceNodesFromServers(req)
+		return ServiceNodesDelta{Updated: out.Nodes, QueryMeta: out.QueryMeta}, cache.ResultMeta{}, err
+	}
+	if err != nil {
+		return ServiceNodesDelta{}, cache.ResultMeta{}, err
+	}
+	meta := result.Meta()
+
+	var out ServiceNodesDelta
+	switch value := result.Value.(type) {
+	case *ServiceNodesDelta:
+		out = *value
+	case *structs.IndexedCheckServiceNodes:
+		out = ServiceNodesDelta{Updated: value.Nodes, QueryMeta: value.QueryMeta}
+	default:
+		return out, meta, fmt.Errorf("unexpected result type %T", result.Value)
+	}
+	out.QueryMeta.LastContact = meta.Age
+	return out, meta, nil
+}
+
+// ServiceNodesPage returns a page of the instances returned by ServiceNodes,
+// and the cursor of the next page, or an empty cursor for the last page. The
+// instances are sorted by node name and service ID. Pages are only served by
+// the streaming backend. Otherwise, and when the view is too stale for req,
+// the result contains every instance and the cursor is empty.
+func (c *Client) ServiceNodesPage(
+	ctx context.Context,
+	req structs.ServiceSpecificRequest,
+	page submatview.Page,
+) (structs.IndexedCheckServiceNodes, string, cache.ResultMeta, error) {
+	if !c.useStreaming(req) || !(req.QueryOptions.UseCache || req.QueryOptions.MinQueryIndex > 0) || page.Size == 0 {
+		out, meta, err := c.ServiceNodes(ctx, req)
+		return out, "", meta, err
+	}
+
+	c.QueryOptionDefaults(&req.QueryOptions)
+	sr := c.newServiceRequest(req)
+	sr.page = page
+
+	result, err := c.ViewStore.Get(ctx, sr)
+	if errors.Is(err, submatview.ErrViewTooStale) {
+		out, err := c.serviceNodesFromServers(req)
+		return out, "", cache.ResultMeta{}, err
+	}
+	if err != nil {
+		return structs.IndexedCheckServiceNodes{}, "", cache.ResultMeta{}, err
+	}
+	meta := result.Meta()
+	out := *result.Value.(*structs.IndexedCheckServiceNodes)
+	out.QueryMeta.LastContact = meta.Age
+	if isTooStale(req, out.QueryMeta) {
+		req.AllowStale = false
+		out, err := c.serviceNodesFromServers(req)
+		return out, "", cache.ResultMeta{}, err
+	}
+	return out, result.NextCursor, meta, nil
+}
+
 func (c *Client) getServiceNodes(
 	ctx context.Context,
 	req structs.ServiceSpecificRequest,
@@ -95,21 +212,35 @@ func (c *Client) Notify(
 ) error {
 	if c.useStreaming(req) {
 		sr := c.newServiceRequest(req)
+		// Notify is used by proxycfg to watch the upstreams of the proxies
+		// registered with the agent. The views are pinned so that a proxy
+		// does not have to wait for a new snapshot when its watches are
+		// restarted, for example after the proxy is re-registered.
+		sr.idleTTL = submatview.Pinned
 		return c.ViewStore.Notify(ctx, sr, correlationID, ch)
 	}
 
 	return c.Cache.Notify(ctx, c.CacheName, &req, correlationID, ch)
 }
 
+// UsesStreaming returns true if ServiceNodes uses a materialized view for req
+// when req.QueryOptions.UseCache is set.
+func (c *Client) UsesStreaming(req structs.ServiceSpecificRequest) bool {
+	return c.useStreaming(req)
+}
+
 func (c *Client) useStreaming(req structs.ServiceSpecificRequest) bool {
 	return c.UseStreamingBackend && !req.Ingress && req.Source.Node == ""
 }
 
 func (c *Client) newServiceRequest(req structs.ServiceSpecificRequest) serviceRequest {
-	return serviceRequest{
+	r := serviceRequest{
 		ServiceSpecificRequest: req,
 		deps:                   c.MaterializerDeps,
+		store:                  c.ViewStore,
 	}
+	r.sub, r.subErr = r.newSubscription()
+	return r
 }
 
 // Close any underlying connections used by the client.
@@ -122,11 +253,61 @@ func (c *Client) Close() error {
 
 type serviceRequest struct {
 	structs.ServiceSpecificRequest
-	deps MaterializerDeps
+	deps  MaterializerDeps
+	store MaterializedViewStore
+	// sub is the request created by the store for the subscription of the
+	// view, so that the Materializer uses the options of the store. It is
+	// created with the request, because the store is locked while it calls
+	// NewMaterializer.
+	sub    submatview.Request
+	subErr error
+	delta  bool
+	page   submatview.Page
+	// idleTTL of the view, see submatview.IdleTTLRequest.
+	idleTTL time.Duration
+}
+
+// AcceptsDelta implements submatview.DeltaRequest
+func (r serviceRequest) AcceptsDelta() bool {
+	return r.delta
+}
+
+// Page implements submatview.PagedRequest
+func (r serviceRequest) Page() submatview.Page {
+	return r.page
 }
 
+// IdleTTL implements submatview.IdleTTLRequest
+func (r serviceRequest) IdleTTL() time.Duration {
+	return r.idleTTL
+}
+
+// Tenancy implements submatview.TenancyRequest
+func (r serviceRequest) Tenancy() submatview.Tenancy {
+	return submatview.Tenancy{
+		Partition: r.EnterpriseMeta.PartitionOrEmpty(),
+		Namespace: r.EnterpriseMeta.NamespaceOrEmpty(),
+	}
+}
+
+// WithWildcardNamespace implements submatview.WildcardNamespaceRequest. The
+// view of the returned request subscribes to the instances of the service in
+// every namespace of the partition.
+func (r serviceRequest) WithWildcardNamespace() submatview.TenancyRequest {
+	r.EnterpriseMeta = *r.EnterpriseMeta.WithWildcardNamespace()
+	r.sub, r.subErr = r.newSubscription()
+	return r
+}
+
+// CacheInfo returns the CacheInfo of the request, with the token of the
+// subscription of the view, which is the name of its TokenSource when the view
+// uses one.
 func (r serviceRequest) CacheInfo() cache.RequestInfo {
-	return r.ServiceSpecificRequest.CacheInfo()
+	info := r.ServiceSpecificRequest.CacheInfo()
+	if r.sub != nil {
+		info.Token = r.sub.CacheInfo().Token
+	}
+	return info
 }
 
 func (r serviceRequest) Type() string {
@@ -134,14 +315,30 @@ func (r serviceRequest) Type() string {
 }
 
 func (r serviceRequest) NewMaterializer() (*submatview.Materializer, error) {
-	view, err := newHealthView(r.ServiceSpecificRequest)
-	if err != nil {
-		return nil, err
-	}
-	return submatview.NewMaterializer(submatview.Deps{
-		View:    view,
-		Client:  pbsubscribe.NewStateChangeSubscriptionClient(r.deps.Conn),
-		Logger:  r.deps.Logger,
-		Request: newMaterializerRequest(r.ServiceSpecificRequest),
-	}), nil
+	if r.subErr != nil {
+		return nil, r.subErr
+	}
+	return r.sub.NewMaterializer()
+}
+
+// newSubscription returns the request used to create the Materializer of the
+// view of r.
+func (r serviceRequest) newSubscription() (submatview.Request, error) {
+	client := r.deps.Client
+	if client == nil {
+		client = pbsubscribe.NewStateChangeSubscriptionClient(r.deps.Conn)
+	}
+	return r.store.NewRequest(submatview.RequestSpec{
+		Subscribe: newMaterializerRequest(r.ServiceSpecificRequest)(0),
+		Client:    client,
+		Logger:    r.deps.Logger,
+		NewView: func(_ pbsubscribe.SubscribeRequest) (submatview.View, error) {
+			view, err := newHealthView(r.ServiceSpecificRequest)
+			if err != nil {
+				return nil, err
+			}
+			return view, nil
+		},
+		ViewType: "health",
+	})
 }
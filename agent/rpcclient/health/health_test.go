@@ -2,6 +2,7 @@ package health
 
 import (
 	"context"
+	"fmt"
 	"testing"
 	"time"
 
@@ -11,6 +12,7 @@ import (
 	"github.com/hashicorp/consul/agent/config"
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/agent/submatview"
+	"github.com/hashicorp/consul/api"
 )
 
 func TestClient_ServiceNodes_BackendRouting(t *testing.T) {
@@ -144,11 +146,12 @@ func useCache(t *testing.T, c *Client) {
 
 type fakeCache struct {
 	calls []string
+	nodes structs.CheckServiceNodes
 }
 
 func (f *fakeCache) Get(_ context.Context, t string, _ cache.Request) (interface{}, cache.ResultMeta, error) {
 	f.calls = append(f.calls, t)
-	result := &structs.IndexedCheckServiceNodes{}
+	result := &structs.IndexedCheckServiceNodes{Nodes: f.nodes}
 	return result, cache.ResultMeta{}, nil
 }
 
@@ -167,12 +170,17 @@ func (f *fakeNetRPC) RPC(method string, _ interface{}, _ interface{}) error {
 }
 
 type fakeViewStore struct {
-	calls []submatview.Request
+	calls       []submatview.Request
+	lastContact time.Time
+	err         error
 }
 
 func (f *fakeViewStore) Get(_ context.Context, req submatview.Request) (submatview.Result, error) {
 	f.calls = append(f.calls, req)
-	return submatview.Result{Value: &structs.IndexedCheckServiceNodes{}}, nil
+	return submatview.Result{
+		Value:       &structs.IndexedCheckServiceNodes{},
+		LastContact: f.lastContact,
+	}, f.err
 }
 
 func (f *fakeViewStore) Notify(_ context.Context, req submatview.Request, _ string, _ chan<- cache.UpdateEvent) error {
@@ -180,6 +188,10 @@ func (f *fakeViewStore) Notify(_ context.Context, req submatview.Request, _ stri
 	return nil
 }
 
+func (f *fakeViewStore) NewRequest(_ submatview.RequestSpec) (submatview.Request, error) {
+	return nil, nil
+}
+
 func TestClient_Notify_BackendRouting(t *testing.T) {
 	type testCase struct {
 		name     string
@@ -261,3 +273,111 @@ func TestClient_ServiceNodes_SetsDefaults(t *testing.T) {
 	require.Len(t, store.calls, 1)
 	require.Equal(t, 100*time.Second, store.calls[0].CacheInfo().Timeout)
 }
+
+func TestClient_ServiceNodes_StaleView(t *testing.T) {
+	rpc := &fakeNetRPC{}
+	store := &fakeViewStore{lastContact: time.Now().Add(-time.Minute)}
+	c := &Client{
+		NetRPC:              rpc,
+		ViewStore:           store,
+		CacheName:           "cache-no-streaming",
+		UseStreamingBackend: true,
+		QueryOptionDefaults: config.ApplyDefaultQueryOptions(&config.RuntimeConfig{}),
+	}
+
+	req := structs.ServiceSpecificRequest{
+		Datacenter:  "dc1",
+		ServiceName: "web1",
+		QueryOptions: structs.QueryOptions{
+			UseCache:         true,
+			AllowStale:       true,
+			MaxStaleDuration: 2 * time.Minute,
+		},
+	}
+
+	t.Run("within max stale", func(t *testing.T) {
+		_, _, err := c.ServiceNodes(context.Background(), req)
+		require.NoError(t, err)
+		require.Len(t, store.calls, 1)
+		require.Len(t, rpc.calls, 0)
+	})
+
+	t.Run("exceeds max stale", func(t *testing.T) {
+		req.QueryOptions.MaxStaleDuration = 10 * time.Second
+		_, _, err := c.ServiceNodes(context.Background(), req)
+		require.NoError(t, err)
+		require.Len(t, store.calls, 2)
+		require.Equal(t, []string{"Health.ServiceNodes"}, rpc.calls)
+	})
+}
+
+func TestClient_ServiceNodes_MaxAge(t *testing.T) {
+	rpc := &fakeNetRPC{}
+	store := &fakeViewStore{err: fmt.Errorf("%w: last contact 1m0s ago", submatview.ErrViewTooStale)}
+	c := &Client{
+		NetRPC:              rpc,
+		ViewStore:           store,
+		UseStreamingBackend: true,
+		QueryOptionDefaults: config.ApplyDefaultQueryOptions(&config.RuntimeConfig{}),
+	}
+
+	req := structs.ServiceSpecificRequest{
+		Datacenter:  "dc1",
+		ServiceName: "web1",
+		QueryOptions: structs.QueryOptions{
+			UseCache: true,
+			MaxAge:   10 * time.Second,
+		},
+	}
+
+	// A view older than the MaxAge is replaced by a result from the servers.
+	_, _, err := c.ServiceNodes(context.Background(), req)
+	require.NoError(t, err)
+	_, _, _, err = c.ServiceNodesPage(context.Background(), req, submatview.Page{Size: 10})
+	require.NoError(t, err)
+	req.MinQueryIndex = 5
+	_, _, err = c.ServiceNodesDelta(context.Background(), req)
+	require.NoError(t, err)
+
+	require.Len(t, store.calls, 3)
+	require.Equal(t, []string{"Health.ServiceNodes", "Health.ServiceNodes", "Health.ServiceNodes"}, rpc.calls)
+}
+
+func TestClient_UsesStreaming(t *testing.T) {
+	c := &Client{UseStreamingBackend: true}
+	require.True(t, c.UsesStreaming(structs.ServiceSpecificRequest{ServiceName: "web"}))
+	require.False(t, c.UsesStreaming(structs.ServiceSpecificRequest{ServiceName: "web", Ingress: true}))
+
+	c.UseStreamingBackend = false
+	require.False(t, c.UsesStreaming(structs.ServiceSpecificRequest{ServiceName: "web"}))
+}
+
+func TestClient_ServiceNodes_PassingOnly(t *testing.T) {
+	nodes := structs.CheckServiceNodes{
+		{
+			Node:   &structs.Node{Node: "node1"},
+			Checks: structs.HealthChecks{{Status: api.HealthPassing}},
+		},
+		{
+			Node:   &structs.Node{Node: "node2"},
+			Checks: structs.HealthChecks{{Status: api.HealthCritical}},
+		},
+	}
+	c := &Client{
+		NetRPC:    &fakeNetRPC{},
+		Cache:     &fakeCache{nodes: nodes},
+		CacheName: "cache-no-streaming",
+	}
+
+	req := structs.ServiceSpecificRequest{
+		Datacenter:   "dc1",
+		ServiceName:  "web1",
+		PassingOnly:  true,
+		QueryOptions: structs.QueryOptions{UseCache: true},
+	}
+	out, _, err := c.ServiceNodes(context.Background(), req)
+	require.NoError(t, err)
+	require.Len(t, out.Nodes, 1)
+	require.Equal(t, "node1", out.Nodes[0].Node.Node)
+	require.Len(t, nodes, 2, "the cached result must not be modified")
+}
@@ -1,6 +1,8 @@
 package health
 
 import (
+	"bytes"
+	"encoding/base64"
 	"fmt"
 	"reflect"
 	"sort"
@@ -8,16 +10,26 @@ import (
 
 	"github.com/hashicorp/go-bexpr"
 	"github.com/hashicorp/go-hclog"
+	"github.com/hashicorp/go-msgpack/codec"
 	"google.golang.org/grpc"
 
 	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/agent/submatview"
+	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/proto/pbservice"
 	"github.com/hashicorp/consul/proto/pbsubscribe"
 )
 
+// MaterializerDeps are the dependencies of the Materializers of the views. The
+// other options of the Materializers are the options of the Client.ViewStore.
 type MaterializerDeps struct {
-	Conn   *grpc.ClientConn
+	Conn *grpc.ClientConn
+	// Logger is used by the Materializers. Defaults to the logger of the
+	// Client.ViewStore.
 	Logger hclog.Logger
+	// Client is used to subscribe to the servers. Defaults to a client of
+	// Conn with a stream for each subscription.
+	Client submatview.StreamClient
 }
 
 func newMaterializerRequest(srvReq structs.ServiceSpecificRequest) func(index uint64) pbsubscribe.SubscribeRequest {
@@ -30,6 +42,7 @@ func newMaterializerRequest(srvReq structs.ServiceSpecificRequest) func(index ui
 			Index:      index,
 			Namespace:  srvReq.EnterpriseMeta.NamespaceOrEmpty(),
 			Partition:  srvReq.EnterpriseMeta.PartitionOrEmpty(),
+			Filter:     srvReq.Filter,
 		}
 		if srvReq.Connect {
 			req.Topic = pbsubscribe.Topic_ServiceHealthConnect
@@ -57,6 +70,8 @@ func newHealthView(req structs.ServiceSpecificRequest) (*healthView, error) {
 type healthView struct {
 	state  map[string]structs.CheckServiceNode
 	filter filterEvaluator
+	// size is the approximate size of state in bytes.
+	size int
 }
 
 // Update implements View
@@ -77,16 +92,43 @@ func (s *healthView) Update(events []*pbsubscribe.Event) error {
 			case err != nil:
 				return err
 			case passed:
-				s.state[id] = csn
+				s.set(id, csn)
+			default:
+				// The instance may have matched the filters before this
+				// update, for example when a check of a PassingOnly view
+				// stopped passing.
+				s.delete(id)
 			}
 
 		case pbsubscribe.CatalogOp_Deregister:
-			delete(s.state, id)
+			s.delete(id)
 		}
 	}
 	return nil
 }
 
+func (s *healthView) set(id string, csn structs.CheckServiceNode) {
+	s.delete(id)
+	s.state[id] = csn
+	s.size += instanceSize(id, csn)
+}
+
+func (s *healthView) delete(id string) {
+	if csn, ok := s.state[id]; ok {
+		s.size -= instanceSize(id, csn)
+		delete(s.state, id)
+	}
+}
+
+func instanceSize(id string, csn structs.CheckServiceNode) int {
+	return len(id) + submatview.ApproximateSize(csn)
+}
+
+// SizeBytes implements submatview.SizedView
+func (s *healthView) SizeBytes() int {
+	return s.size
+}
+
 type filterEvaluator interface {
 	Evaluate(datum interface{}) (bool, error)
 }
@@ -112,6 +154,10 @@ func newFilterEvaluator(req structs.ServiceSpecificRequest) (filterEvaluator, er
 		evaluators = append(evaluators, serviceTagEvaluator{tags: req.ServiceTags})
 	}
 
+	if req.PassingOnly {
+		evaluators = append(evaluators, passingEvaluator{})
+	}
+
 	for key, value := range req.NodeMetaFilters {
 		expr := fmt.Sprintf(`"%s" in Node.Meta.%s`, value, key)
 		e, err := bexpr.CreateEvaluatorForType(expr, nil, typ)
@@ -184,8 +230,216 @@ func (s *healthView) Result(index uint64) interface{} {
 	return &result
 }
 
+// TenancyLen implements submatview.TenancyView.
+func (s *healthView) TenancyLen(tenancy submatview.Tenancy) int {
+	entMeta := structs.NewEnterpriseMetaWithPartition(tenancy.Partition, tenancy.Namespace)
+	n := 0
+	for _, node := range s.state {
+		if inTenancy(node, &entMeta) {
+			n++
+		}
+	}
+	return n
+}
+
+// TenancyResult implements submatview.TenancyView. The result only contains the
+// instances registered in the partition and namespace of tenancy, for the
+// requests which share the view of every namespace of the partition.
+func (s *healthView) TenancyResult(index uint64, tenancy submatview.Tenancy) interface{} {
+	entMeta := structs.NewEnterpriseMetaWithPartition(tenancy.Partition, tenancy.Namespace)
+	result := structs.IndexedCheckServiceNodes{
+		Nodes: make(structs.CheckServiceNodes, 0),
+		QueryMeta: structs.QueryMeta{
+			Index:   index,
+			Backend: structs.QueryBackendStreaming,
+		},
+	}
+	for _, node := range s.state {
+		if inTenancy(node, &entMeta) {
+			result.Nodes = append(result.Nodes, node)
+		}
+	}
+	sortCheckServiceNodes(&result)
+
+	return &result
+}
+
+// inTenancy returns true if the service instance is registered in the partition
+// and namespace of entMeta.
+func inTenancy(csn structs.CheckServiceNode, entMeta *structs.EnterpriseMeta) bool {
+	if csn.Service == nil {
+		return false
+	}
+	return strings.EqualFold(csn.Service.EnterpriseMeta.NamespaceOrDefault(), entMeta.NamespaceOrDefault()) &&
+		strings.EqualFold(csn.Service.EnterpriseMeta.PartitionOrDefault(), entMeta.PartitionOrDefault())
+}
+
+// Len implements submatview.PagedView
+func (s *healthView) Len() int {
+	return len(s.state)
+}
+
+// PageResult implements submatview.PagedView. The page contains the instances
+// sorted like Result, starting with the first instance at or after the cursor.
+// The cursor identifies an instance by its node and service ID, so that the
+// next page starts at the right position even if the instance was deregistered
+// in between.
+func (s *healthView) PageResult(index uint64, page submatview.Page) (interface{}, string, error) {
+	var start pageKey
+	if page.Cursor != "" {
+		var err error
+		if start, err = decodePageCursor(page.Cursor); err != nil {
+			return nil, "", err
+		}
+	}
+
+	all := structs.IndexedCheckServiceNodes{
+		Nodes: make(structs.CheckServiceNodes, 0, len(s.state)),
+	}
+	for _, node := range s.state {
+		if page.Cursor == "" || !newPageKey(node).less(start) {
+			all.Nodes = append(all.Nodes, node)
+		}
+	}
+	sortCheckServiceNodes(&all)
+
+	var next string
+	if len(all.Nodes) > page.Size {
+		next = newPageKey(all.Nodes[page.Size]).cursor()
+		all.Nodes = all.Nodes[:page.Size]
+	}
+
+	result := structs.IndexedCheckServiceNodes{
+		Nodes: all.Nodes,
+		QueryMeta: structs.QueryMeta{
+			Index:   index,
+			Backend: structs.QueryBackendStreaming,
+		},
+	}
+	return &result, next, nil
+}
+
+// pageKey is the position of an instance in the sorted result of a healthView.
+type pageKey struct {
+	node      string
+	serviceID string
+}
+
+func newPageKey(csn structs.CheckServiceNode) pageKey {
+	return pageKey{node: csn.Node.Node, serviceID: csn.Service.ID}
+}
+
+func (k pageKey) less(other pageKey) bool {
+	if k.node == other.node {
+		return k.serviceID < other.serviceID
+	}
+	return k.node < other.node
+}
+
+// cursor encodes the key as an opaque cursor. Node names may not contain a
+// NUL byte, so it separates the node from the service ID.
+func (k pageKey) cursor() string {
+	return base64.RawURLEncoding.EncodeToString([]byte(k.node + "\x00" + k.serviceID))
+}
+
+func decodePageCursor(cursor string) (pageKey, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return pageKey{}, fmt.Errorf("invalid page cursor %q", cursor)
+	}
+	parts := strings.SplitN(string(raw), "\x00", 2)
+	if len(parts) != 2 {
+		return pageKey{}, fmt.Errorf("invalid page cursor %q", cursor)
+	}
+	return pageKey{node: parts[0], serviceID: parts[1]}, nil
+}
+
 func (s *healthView) Reset() {
 	s.state = make(map[string]structs.CheckServiceNode)
+	s.size = 0
+}
+
+// ServiceNodesDelta is the result of Client.ServiceNodesDelta.
+type ServiceNodesDelta struct {
+	// Delta is true if Updated and Removed only contain the instances which
+	// changed after the MinQueryIndex of the request. Otherwise Updated
+	// contains every instance of the service.
+	Delta bool
+	// Updated contains the instances which were registered or changed.
+	Updated structs.CheckServiceNodes
+	// Removed contains the instances which were deregistered, or which no
+	// longer match the filters of the request.
+	Removed structs.CheckServiceNodes
+
+	structs.QueryMeta
+}
+
+// DeltaResult implements submatview.DeltaView
+func (s *healthView) DeltaResult(events []*pbsubscribe.Event, index uint64) interface{} {
+	type change struct {
+		csn     structs.CheckServiceNode
+		removed bool
+	}
+	changes := make(map[string]change)
+	for _, event := range events {
+		serviceHealth := event.GetServiceHealth()
+		if serviceHealth == nil {
+			continue
+		}
+
+		id := serviceHealth.CheckServiceNode.UniqueID()
+		csn := *pbservice.CheckServiceNodeToStructs(serviceHealth.CheckServiceNode)
+		removed := serviceHealth.Op == pbsubscribe.CatalogOp_Deregister
+		if !removed {
+			// Update already returned any error from the filter.
+			passed, _ := s.filter.Evaluate(csn)
+			removed = !passed
+		}
+		changes[id] = change{csn: csn, removed: removed}
+	}
+
+	result := &ServiceNodesDelta{
+		Delta: true,
+		QueryMeta: structs.QueryMeta{
+			Index:   index,
+			Backend: structs.QueryBackendStreaming,
+		},
+	}
+	updated := structs.IndexedCheckServiceNodes{}
+	removed := structs.IndexedCheckServiceNodes{}
+	for _, c := range changes {
+		if c.removed {
+			removed.Nodes = append(removed.Nodes, c.csn)
+		} else {
+			updated.Nodes = append(updated.Nodes, c.csn)
+		}
+	}
+	sortCheckServiceNodes(&updated)
+	sortCheckServiceNodes(&removed)
+	result.Updated = updated.Nodes
+	result.Removed = removed.Nodes
+	return result
+}
+
+// MarshalState implements submatview.PersistentView
+func (s *healthView) MarshalState() ([]byte, error) {
+	var buf bytes.Buffer
+	err := codec.NewEncoder(&buf, structs.MsgpackHandle).Encode(s.state)
+	return buf.Bytes(), err
+}
+
+// RestoreState implements submatview.PersistentView
+func (s *healthView) RestoreState(data []byte) error {
+	state := make(map[string]structs.CheckServiceNode)
+	if err := structs.Decode(data, &state); err != nil {
+		return err
+	}
+	s.state = state
+	s.size = 0
+	for id, csn := range state {
+		s.size += instanceSize(id, csn)
+	}
+	return nil
 }
 
 // serviceTagEvaluator implements the filterEvaluator to perform filtering
@@ -210,6 +464,40 @@ func (m serviceTagEvaluator) Evaluate(data interface{}) (bool, error) {
 	return true, nil
 }
 
+// passingEvaluator implements the filterEvaluator to filter out the instances
+// with a check which is not passing. It is used by PassingOnly views, so that
+// the non-passing instances are never stored by the view.
+type passingEvaluator struct{}
+
+func (passingEvaluator) Evaluate(data interface{}) (bool, error) {
+	csn, ok := data.(structs.CheckServiceNode)
+	if !ok {
+		return false, fmt.Errorf("unexpected type %T for structs.CheckServiceNode filter", data)
+	}
+	return isPassing(csn), nil
+}
+
+func isPassing(csn structs.CheckServiceNode) bool {
+	for _, check := range csn.Checks {
+		if check.Status != api.HealthPassing {
+			return false
+		}
+	}
+	return true
+}
+
+// filterPassing returns the instances of nodes whose checks are all passing.
+// nodes is not modified, because it may be shared with the agent cache.
+func filterPassing(nodes structs.CheckServiceNodes) structs.CheckServiceNodes {
+	out := make(structs.CheckServiceNodes, 0, len(nodes))
+	for _, csn := range nodes {
+		if isPassing(csn) {
+			out = append(out, csn)
+		}
+	}
+	return out
+}
+
 func serviceHasTag(sn *structs.NodeService, tag string) bool {
 	for _, t := range sn.Tags {
 		if strings.EqualFold(t, tag) {
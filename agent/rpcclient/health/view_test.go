@@ -17,6 +17,7 @@ import (
 
 	"github.com/hashicorp/consul/agent/structs"
 	"github.com/hashicorp/consul/agent/submatview"
+	"github.com/hashicorp/consul/api"
 	"github.com/hashicorp/consul/proto/pbcommon"
 	"github.com/hashicorp/consul/proto/pbservice"
 	"github.com/hashicorp/consul/proto/pbsubscribe"
@@ -80,7 +81,7 @@ func TestHealthView_IntegrationWithStore_WithEmptySnapshot(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	store := submatview.NewStore(hclog.New(nil))
+	store := submatview.NewStore(hclog.New(nil), submatview.StoreOptions{})
 	go store.Run(ctx)
 
 	// Initially there are no services registered. Server should send an
@@ -260,7 +261,7 @@ func TestHealthView_IntegrationWithStore_WithFullSnapshot(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	store := submatview.NewStore(hclog.New(nil))
+	store := submatview.NewStore(hclog.New(nil), submatview.StoreOptions{})
 
 	// Create an initial snapshot of 3 instances on different nodes
 	registerServiceWeb := func(index uint64, nodeNum int) *pbsubscribe.Event {
@@ -413,7 +414,7 @@ func TestHealthView_IntegrationWithStore_EventBatches(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	store := submatview.NewStore(hclog.New(nil))
+	store := submatview.NewStore(hclog.New(nil), submatview.StoreOptions{})
 
 	// Create an initial snapshot of 3 instances but in a single event batch
 	batchEv := newEventBatchWithEvents(
@@ -478,7 +479,7 @@ func TestHealthView_IntegrationWithStore_Filtering(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	store := submatview.NewStore(hclog.New(nil))
+	store := submatview.NewStore(hclog.New(nil), submatview.StoreOptions{})
 	go store.Run(ctx)
 
 	req := serviceRequestStub{
@@ -886,3 +887,289 @@ func TestNewFilterEvaluator(t *testing.T) {
 		})
 	}
 }
+
+func TestHealthView_PersistentState(t *testing.T) {
+	view, err := newHealthView(structs.ServiceSpecificRequest{})
+	require.NoError(t, err)
+
+	csn := structs.CheckServiceNode{
+		Node:    &structs.Node{Node: "node1", Address: "10.0.0.1"},
+		Service: &structs.NodeService{ID: "web1", Service: "web", Port: 8080},
+		Checks: structs.HealthChecks{
+			{Node: "node1", CheckID: "check1", Status: "passing", ServiceID: "web1"},
+		},
+	}
+	view.state["node1/web1"] = csn
+
+	data, err := view.MarshalState()
+	require.NoError(t, err)
+
+	restored, err := newHealthView(structs.ServiceSpecificRequest{})
+	require.NoError(t, err)
+	require.NoError(t, restored.RestoreState(data))
+	require.Equal(t, view.Result(5), restored.Result(5))
+}
+
+func TestHealthView_DeltaResult(t *testing.T) {
+	view, err := newHealthView(structs.ServiceSpecificRequest{
+		ServiceName:  "web",
+		QueryOptions: structs.QueryOptions{Filter: `Node.Node != "node3"`},
+	})
+	require.NoError(t, err)
+
+	events := []*pbsubscribe.Event{
+		newEventServiceHealthRegister(10, 1, "web"),
+		newEventServiceHealthRegister(11, 2, "web"),
+		newEventServiceHealthDeregister(12, 1, "web"),
+		newEventServiceHealthRegister(13, 3, "web"),
+		newEventServiceHealthRegister(14, 4, "web"),
+	}
+	require.NoError(t, view.Update(events))
+
+	delta := view.DeltaResult(events, 14).(*ServiceNodesDelta)
+	require.True(t, delta.Delta)
+	require.Equal(t, uint64(14), delta.Index)
+
+	nodeNames := func(nodes structs.CheckServiceNodes) []string {
+		var names []string
+		for _, n := range nodes {
+			names = append(names, n.Node.Node)
+		}
+		return names
+	}
+	require.Equal(t, []string{"node2", "node4"}, nodeNames(delta.Updated))
+	require.Equal(t, []string{"node1", "node3"}, nodeNames(delta.Removed),
+		"node1 was deregistered and node3 does not match the filter")
+}
+
+func TestHealthView_PassingOnly(t *testing.T) {
+	view, err := newHealthView(structs.ServiceSpecificRequest{
+		ServiceName: "web",
+		PassingOnly: true,
+	})
+	require.NoError(t, err)
+
+	withCheck := func(event *pbsubscribe.Event, status string) *pbsubscribe.Event {
+		csn := event.GetServiceHealth().CheckServiceNode
+		csn.Checks = []*pbservice.HealthCheck{{
+			Node:    csn.Node.Node,
+			CheckID: "web-check",
+			Status:  status,
+		}}
+		return event
+	}
+
+	events := []*pbsubscribe.Event{
+		withCheck(newEventServiceHealthRegister(10, 1, "web"), api.HealthPassing),
+		withCheck(newEventServiceHealthRegister(11, 2, "web"), api.HealthWarning),
+		withCheck(newEventServiceHealthRegister(12, 3, "web"), api.HealthPassing),
+		newEventServiceHealthRegister(13, 4, "web"),
+	}
+	require.NoError(t, view.Update(events))
+	require.Len(t, view.state, 3, "the view only stores passing instances")
+
+	result := view.Result(13).(*structs.IndexedCheckServiceNodes)
+	require.Equal(t, []string{"node1", "node3", "node4"}, checkServiceNodeNames(result.Nodes))
+
+	// An instance whose check stops passing is removed from the view.
+	events = []*pbsubscribe.Event{
+		withCheck(newEventServiceHealthRegister(14, 3, "web"), api.HealthCritical),
+		withCheck(newEventServiceHealthRegister(15, 2, "web"), api.HealthPassing),
+	}
+	require.NoError(t, view.Update(events))
+
+	result = view.Result(15).(*structs.IndexedCheckServiceNodes)
+	require.Equal(t, []string{"node1", "node2", "node4"}, checkServiceNodeNames(result.Nodes))
+
+	delta := view.DeltaResult(events, 15).(*ServiceNodesDelta)
+	require.Equal(t, []string{"node2"}, checkServiceNodeNames(delta.Updated))
+	require.Equal(t, []string{"node3"}, checkServiceNodeNames(delta.Removed))
+}
+
+func TestHealthView_PageResult(t *testing.T) {
+	view, err := newHealthView(structs.ServiceSpecificRequest{ServiceName: "web"})
+	require.NoError(t, err)
+
+	require.NoError(t, view.Update([]*pbsubscribe.Event{
+		newEventServiceHealthRegister(10, 3, "web"),
+		newEventServiceHealthRegister(11, 1, "web"),
+		newEventServiceHealthRegister(12, 4, "web"),
+		newEventServiceHealthRegister(13, 2, "web"),
+	}))
+	require.Equal(t, 4, view.Len())
+
+	raw, next, err := view.PageResult(13, submatview.Page{Size: 3})
+	require.NoError(t, err)
+	result := raw.(*structs.IndexedCheckServiceNodes)
+	require.Equal(t, uint64(13), result.Index)
+	require.Equal(t, structs.QueryBackendStreaming, result.Backend)
+	require.Equal(t, []string{"node1", "node2", "node3"}, checkServiceNodeNames(result.Nodes))
+	require.NotEqual(t, "", next)
+
+	// The next page starts at the same position when the first instance of
+	// the page was deregistered in the meantime.
+	require.NoError(t, view.Update([]*pbsubscribe.Event{
+		newEventServiceHealthDeregister(14, 4, "web"),
+		newEventServiceHealthRegister(15, 5, "web"),
+	}))
+	raw, next, err = view.PageResult(15, submatview.Page{Cursor: next, Size: 3})
+	require.NoError(t, err)
+	result = raw.(*structs.IndexedCheckServiceNodes)
+	require.Equal(t, []string{"node5"}, checkServiceNodeNames(result.Nodes))
+	require.Equal(t, "", next)
+
+	_, _, err = view.PageResult(15, submatview.Page{Cursor: "not a cursor", Size: 3})
+	require.Error(t, err)
+}
+
+func checkServiceNodeNames(nodes structs.CheckServiceNodes) []string {
+	var names []string
+	for _, n := range nodes {
+		names = append(names, n.Node.Node)
+	}
+	return names
+}
+
+func TestFilterPassing(t *testing.T) {
+	nodes := structs.CheckServiceNodes{
+		structs.CheckServiceNode{
+			Checks: structs.HealthChecks{
+				&structs.HealthCheck{Status: api.HealthCritical},
+				&structs.HealthCheck{Status: api.HealthCritical},
+			},
+		},
+		structs.CheckServiceNode{
+			Checks: structs.HealthChecks{
+				&structs.HealthCheck{Status: api.HealthPassing},
+				&structs.HealthCheck{Status: api.HealthWarning},
+			},
+		},
+		structs.CheckServiceNode{
+			Checks: structs.HealthChecks{
+				&structs.HealthCheck{Status: api.HealthPassing},
+			},
+		},
+	}
+	out := filterPassing(nodes)
+	require.Equal(t, structs.CheckServiceNodes{nodes[2]}, out)
+	require.Len(t, nodes, 3)
+	require.Equal(t, api.HealthCritical, nodes[0].Checks[0].Status)
+}
+
+func TestHealthView_SizeBytes(t *testing.T) {
+	view, err := newHealthView(structs.ServiceSpecificRequest{})
+	require.NoError(t, err)
+	require.Equal(t, 0, view.SizeBytes())
+
+	require.NoError(t, view.Update([]*pbsubscribe.Event{
+		newEventServiceHealthRegister(10, 1, "web"),
+	}))
+	one := view.SizeBytes()
+	require.True(t, one > 0, "expected a size, got %v", one)
+
+	require.NoError(t, view.Update([]*pbsubscribe.Event{
+		newEventServiceHealthRegister(11, 2, "web"),
+	}))
+	two := view.SizeBytes()
+	require.True(t, two > one, "expected the size to grow, got %v", two)
+
+	// Registering the same instance again replaces its size.
+	require.NoError(t, view.Update([]*pbsubscribe.Event{
+		newEventServiceHealthRegister(12, 2, "web"),
+	}))
+	require.Equal(t, two, view.SizeBytes())
+
+	data, err := view.MarshalState()
+	require.NoError(t, err)
+	restored, err := newHealthView(structs.ServiceSpecificRequest{})
+	require.NoError(t, err)
+	require.NoError(t, restored.RestoreState(data))
+	require.True(t, restored.SizeBytes() > one, "expected the size of both instances, got %v", restored.SizeBytes())
+
+	require.NoError(t, view.Update([]*pbsubscribe.Event{
+		newEventServiceHealthDeregister(13, 2, "web"),
+	}))
+	require.Equal(t, one, view.SizeBytes())
+
+	view.Reset()
+	require.Equal(t, 0, view.SizeBytes())
+}
+
+func TestHealthView_TenancyResult(t *testing.T) {
+	view, err := newHealthView(structs.ServiceSpecificRequest{})
+	require.NoError(t, err)
+	require.NoError(t, view.Update([]*pbsubscribe.Event{
+		newEventServiceHealthRegister(10, 2, "web"),
+		newEventServiceHealthRegister(10, 1, "web"),
+	}))
+
+	entMeta := structs.DefaultEnterpriseMetaInDefaultPartition()
+	result := view.TenancyResult(10, submatview.Tenancy{
+		Partition: entMeta.PartitionOrEmpty(),
+		Namespace: entMeta.NamespaceOrEmpty(),
+	}).(*structs.IndexedCheckServiceNodes)
+	require.Equal(t, uint64(10), result.Index)
+	require.Equal(t, view.Result(10), result)
+	require.Equal(t, 2, view.TenancyLen(submatview.Tenancy{
+		Partition: entMeta.PartitionOrEmpty(),
+		Namespace: entMeta.NamespaceOrEmpty(),
+	}))
+
+	// Without namespace support every tenancy is the default namespace.
+	if entMeta.NamespaceOrEmpty() != "" {
+		other := view.TenancyResult(10, submatview.Tenancy{Namespace: "other"}).(*structs.IndexedCheckServiceNodes)
+		require.Len(t, other.Nodes, 0)
+		require.Equal(t, 0, view.TenancyLen(submatview.Tenancy{Namespace: "other"}))
+	}
+}
+
+func TestServiceRequest_WithWildcardNamespace(t *testing.T) {
+	req := serviceRequest{
+		ServiceSpecificRequest: structs.ServiceSpecificRequest{
+			ServiceName:    "web",
+			EnterpriseMeta: *structs.DefaultEnterpriseMetaInDefaultPartition(),
+		},
+		store: &fakeViewStore{},
+	}
+	var _ submatview.WildcardNamespaceRequest = req
+
+	wildcard := req.WithWildcardNamespace()
+	require.Equal(t, req.Tenancy().Partition, wildcard.Tenancy().Partition)
+	require.Equal(t, req.Type(), wildcard.Type())
+	require.Equal(t, "web", wildcard.(serviceRequest).ServiceName)
+}
+
+func TestClient_ServiceNodesDelta_UsesStoreOptions(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	// The history of the view is only kept when the store is configured with
+	// an EventHistorySize.
+	store := submatview.NewStore(hclog.New(nil), submatview.StoreOptions{EventHistorySize: 4})
+	go store.Run(ctx)
+
+	streamClient := newStreamClient(nil)
+	streamClient.QueueEvents(
+		newEventServiceHealthRegister(5, 1, "web"),
+		newEndOfSnapshotEvent(5),
+		newEventServiceHealthRegister(10, 2, "web"))
+
+	c := &Client{
+		ViewStore:           store,
+		MaterializerDeps:    MaterializerDeps{Client: streamClient},
+		UseStreamingBackend: true,
+		QueryOptionDefaults: func(*structs.QueryOptions) {},
+	}
+	req := structs.ServiceSpecificRequest{
+		Datacenter:   "dc1",
+		ServiceName:  "web",
+		QueryOptions: structs.QueryOptions{MinQueryIndex: 5, MaxQueryTime: time.Second},
+	}
+
+	out, _, err := c.ServiceNodesDelta(ctx, req)
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), out.Index)
+	require.True(t, out.Delta)
+	require.Len(t, out.Updated, 1)
+	require.Equal(t, "node2", out.Updated[0].Node.Node)
+}
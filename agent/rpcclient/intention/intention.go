@@ -0,0 +1,173 @@
+package intention
+
+import (
+	"context"
+
+	"github.com/hashicorp/consul/agent/cache"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/agent/submatview"
+	"github.com/hashicorp/consul/proto/pbsubscribe"
+)
+
+// Client provides access to the intentions which match a service.
+type Client struct {
+	NetRPC              NetRPC
+	Cache               CacheGetter
+	ViewStore           MaterializedViewStore
+	StreamClient        submatview.StreamClient
+	CacheName           string
+	UseStreamingBackend bool
+	QueryOptionDefaults func(options *structs.QueryOptions)
+
+	// unsupported remembers the datacenters whose servers do not support the
+	// Intentions topic.
+	unsupported submatview.UnsupportedTopic
+}
+
+type NetRPC interface {
+	RPC(method string, args interface{}, reply interface{}) error
+}
+
+type CacheGetter interface {
+	Get(ctx context.Context, t string, r cache.Request) (interface{}, cache.ResultMeta, error)
+	Notify(ctx context.Context, t string, r cache.Request, cID string, ch chan<- cache.UpdateEvent) error
+}
+
+type MaterializedViewStore interface {
+	Get(ctx context.Context, req submatview.Request) (submatview.Result, error)
+	Notify(ctx context.Context, req submatview.Request, cID string, ch chan<- cache.UpdateEvent) error
+	NewRequest(spec submatview.RequestSpec) (submatview.Request, error)
+}
+
+// RegisterView registers the view used to materialize the Intentions topic
+// with the store. It must be called once for each store before using a Client
+// with UseStreamingBackend enabled.
+func RegisterView(store *submatview.Store) error {
+	return store.RegisterView(pbsubscribe.Topic_Intentions, func(_ pbsubscribe.SubscribeRequest) (submatview.View, error) {
+		return newIntentionsView(), nil
+	})
+}
+
+// Match returns the intentions which match the entries of req.Match, like the
+// Intention.Match RPC. Blocking and cached queries for the intentions of a
+// single destination are served by a materialized view when the streaming
+// backend is enabled.
+func (c *Client) Match(
+	ctx context.Context,
+	req structs.IntentionQueryRequest,
+) (structs.IndexedIntentionMatches, cache.ResultMeta, error) {
+	if c.useStreaming(req) && (req.QueryOptions.UseCache || req.QueryOptions.MinQueryIndex > 0) {
+		out, meta, err := c.getFromView(ctx, req)
+		if !c.unsupported.Record(req.Datacenter, err) {
+			return out, meta, err
+		}
+	}
+
+	out, md, err := c.match(ctx, req)
+	if err != nil {
+		return out, md, err
+	}
+
+	if req.QueryOptions.AllowStale && req.QueryOptions.MaxStaleDuration > 0 && out.QueryMeta.LastContact > req.MaxStaleDuration {
+		req.AllowStale = false
+		err := c.NetRPC.RPC("Intention.Match", &req, &out)
+		return out, cache.ResultMeta{}, err
+	}
+
+	return out, md, err
+}
+
+func (c *Client) match(
+	ctx context.Context,
+	req structs.IntentionQueryRequest,
+) (structs.IndexedIntentionMatches, cache.ResultMeta, error) {
+	var out structs.IndexedIntentionMatches
+	if !req.QueryOptions.UseCache {
+		err := c.NetRPC.RPC("Intention.Match", &req, &out)
+		return out, cache.ResultMeta{}, err
+	}
+
+	raw, md, err := c.Cache.Get(ctx, c.CacheName, &req)
+	if err != nil {
+		return out, md, err
+	}
+
+	value, ok := raw.(*structs.IndexedIntentionMatches)
+	if !ok {
+		panic("wrong response type for cachetype.IntentionMatchName")
+	}
+
+	return *value, md, nil
+}
+
+// Notify sends the intentions which match the entries of req.Match to ch
+// every time they change. The updates are sent from a materialized view when
+// the streaming backend is enabled and req matches a single destination.
+func (c *Client) Notify(
+	ctx context.Context,
+	req *structs.IntentionQueryRequest,
+	correlationID string,
+	ch chan<- cache.UpdateEvent,
+) error {
+	if c.useStreaming(*req) {
+		sr, err := c.newRequest(*req)
+		if err != nil {
+			return err
+		}
+		return c.ViewStore.Notify(ctx, sr, correlationID, ch)
+	}
+
+	return c.Cache.Notify(ctx, c.CacheName, req, correlationID, ch)
+}
+
+// useStreaming returns true if the request can be served by the view. The
+// view contains the intentions of a single destination service, so requests
+// which match by source, or match more than one entry, are not supported, nor
+// are the requests to a datacenter whose servers do not support the Intentions
+// topic.
+func (c *Client) useStreaming(req structs.IntentionQueryRequest) bool {
+	return c.UseStreamingBackend &&
+		req.Match != nil &&
+		req.Match.Type == structs.IntentionMatchDestination &&
+		len(req.Match.Entries) == 1 &&
+		req.Match.Entries[0].Name != structs.WildcardSpecifier &&
+		!c.unsupported.Unsupported(req.Datacenter)
+}
+
+func (c *Client) newRequest(req structs.IntentionQueryRequest) (submatview.Request, error) {
+	entry := req.Match.Entries[0]
+	return c.ViewStore.NewRequest(submatview.RequestSpec{
+		Subscribe: pbsubscribe.SubscribeRequest{
+			Topic:      pbsubscribe.Topic_Intentions,
+			Key:        entry.Name,
+			Token:      req.Token,
+			Datacenter: req.Datacenter,
+			Namespace:  entry.Namespace,
+			Partition:  entry.Partition,
+		},
+		MinIndex: req.QueryOptions.MinQueryIndex,
+		Timeout:  req.QueryOptions.MaxQueryTime,
+		Client:   c.StreamClient,
+	})
+}
+
+func (c *Client) getFromView(
+	ctx context.Context,
+	req structs.IntentionQueryRequest,
+) (structs.IndexedIntentionMatches, cache.ResultMeta, error) {
+	c.QueryOptionDefaults(&req.QueryOptions)
+
+	sr, err := c.newRequest(req)
+	if err != nil {
+		return structs.IndexedIntentionMatches{}, cache.ResultMeta{}, err
+	}
+
+	result, err := c.ViewStore.Get(ctx, sr)
+	if err != nil {
+		return structs.IndexedIntentionMatches{}, cache.ResultMeta{}, err
+	}
+	meta := result.Meta()
+	out := *result.Value.(*structs.IndexedIntentionMatches)
+	out.QueryMeta.LastContact = meta.Age
+	return out, meta, nil
+}
@@ -0,0 +1,91 @@
+package intention
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/agent/submatview"
+	"github.com/hashicorp/consul/proto/pbsubscribe"
+)
+
+func newIntentionsView() *intentionsView {
+	return &intentionsView{state: make(map[structs.ServiceName]map[structs.ServiceName]*structs.Intention)}
+}
+
+// intentionsView implements submatview.View for storing the view state of the
+// intentions with a destination service. The intentions are indexed by their
+// destination and source, which together identify an intention.
+type intentionsView struct {
+	state map[structs.ServiceName]map[structs.ServiceName]*structs.Intention
+	// size is the approximate size of the intentions in state in bytes.
+	size int
+}
+
+// Update implements View
+func (v *intentionsView) Update(events []*pbsubscribe.Event) error {
+	for _, event := range events {
+		update := event.GetIntention()
+		if update == nil {
+			return fmt.Errorf("unexpected event type for intentions view: %T",
+				event.GetPayload())
+		}
+
+		ixn := pbsubscribe.IntentionToStructs(update.Intention)
+		dst, src := ixn.DestinationServiceName(), ixn.SourceServiceName()
+		switch update.Op {
+		case pbsubscribe.UpdateOp_Upsert:
+			sources, ok := v.state[dst]
+			if !ok {
+				sources = make(map[structs.ServiceName]*structs.Intention)
+				v.state[dst] = sources
+			}
+			if old, ok := sources[src]; ok {
+				v.size -= submatview.ApproximateSize(old)
+			}
+			sources[src] = ixn
+			v.size += submatview.ApproximateSize(ixn)
+		case pbsubscribe.UpdateOp_Delete:
+			if old, ok := v.state[dst][src]; ok {
+				v.size -= submatview.ApproximateSize(old)
+			}
+			delete(v.state[dst], src)
+			if len(v.state[dst]) == 0 {
+				delete(v.state, dst)
+			}
+		}
+	}
+	return nil
+}
+
+// SizeBytes implements submatview.SizedView
+func (v *intentionsView) SizeBytes() int {
+	return v.size
+}
+
+// Result returns the structs.IndexedIntentionMatches stored by this view. The
+// view contains the intentions which match a single destination, so the
+// result has a single list of matches, sorted by precedence like the result
+// of the Intention.Match RPC.
+func (v *intentionsView) Result(index uint64) interface{} {
+	matches := make(structs.Intentions, 0, len(v.state))
+	for _, sources := range v.state {
+		for _, ixn := range sources {
+			matches = append(matches, ixn)
+		}
+	}
+	sort.Sort(structs.IntentionPrecedenceSorter(matches))
+
+	return &structs.IndexedIntentionMatches{
+		Matches: []structs.Intentions{matches},
+		QueryMeta: structs.QueryMeta{
+			Index:   index,
+			Backend: structs.QueryBackendStreaming,
+		},
+	}
+}
+
+func (v *intentionsView) Reset() {
+	v.state = make(map[structs.ServiceName]map[structs.ServiceName]*structs.Intention)
+	v.size = 0
+}
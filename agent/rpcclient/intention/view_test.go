@@ -0,0 +1,83 @@
+package intention
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/proto/pbsubscribe"
+)
+
+func newEventIntention(op pbsubscribe.UpdateOp, src, dst string, action structs.IntentionAction) *pbsubscribe.Event {
+	ixn := &structs.Intention{
+		SourceNS:        structs.IntentionDefaultNamespace,
+		SourceName:      src,
+		DestinationNS:   structs.IntentionDefaultNamespace,
+		DestinationName: dst,
+		SourceType:      structs.IntentionSourceConsul,
+		Action:          action,
+		Permissions: []*structs.IntentionPermission{{
+			Action: action,
+			HTTP: &structs.IntentionHTTPPermission{
+				PathPrefix: "/v1",
+				Header:     []structs.IntentionHTTPHeaderPermission{{Name: "x-env", Exact: "prod"}},
+			},
+		}},
+	}
+	ixn.UpdatePrecedence()
+	return &pbsubscribe.Event{
+		Payload: &pbsubscribe.Event_Intention{
+			Intention: &pbsubscribe.IntentionUpdate{
+				Op:        op,
+				Intention: pbsubscribe.NewIntentionFromStructs(ixn),
+			},
+		},
+	}
+}
+
+func TestIntentionsView(t *testing.T) {
+	view := newIntentionsView()
+
+	err := view.Update([]*pbsubscribe.Event{
+		newEventIntention(pbsubscribe.UpdateOp_Upsert, "*", "*", structs.IntentionActionDeny),
+		newEventIntention(pbsubscribe.UpdateOp_Upsert, "api", "web", structs.IntentionActionAllow),
+		newEventIntention(pbsubscribe.UpdateOp_Upsert, "admin", "web", structs.IntentionActionAllow),
+	})
+	require.NoError(t, err)
+
+	result := view.Result(5).(*structs.IndexedIntentionMatches)
+	require.Equal(t, uint64(5), result.Index)
+	require.Equal(t, structs.QueryBackendStreaming, result.Backend)
+	require.Len(t, result.Matches, 1)
+	require.Equal(t, []string{"admin->web", "api->web", "*->*"}, intentionNames(result.Matches[0]))
+
+	api := result.Matches[0][1]
+	require.Equal(t, structs.IntentionActionAllow, api.Action)
+	require.Len(t, api.Permissions, 1)
+	require.Equal(t, "/v1", api.Permissions[0].HTTP.PathPrefix)
+	require.Equal(t, "prod", api.Permissions[0].HTTP.Header[0].Exact)
+
+	err = view.Update([]*pbsubscribe.Event{
+		newEventIntention(pbsubscribe.UpdateOp_Upsert, "api", "web", structs.IntentionActionDeny),
+		newEventIntention(pbsubscribe.UpdateOp_Delete, "admin", "web", structs.IntentionActionAllow),
+	})
+	require.NoError(t, err)
+
+	result = view.Result(6).(*structs.IndexedIntentionMatches)
+	require.Equal(t, []string{"api->web", "*->*"}, intentionNames(result.Matches[0]))
+	require.Equal(t, structs.IntentionActionDeny, result.Matches[0][0].Action)
+
+	view.Reset()
+	result = view.Result(7).(*structs.IndexedIntentionMatches)
+	require.Len(t, result.Matches, 1)
+	require.Len(t, result.Matches[0], 0)
+}
+
+func intentionNames(ixns structs.Intentions) []string {
+	names := make([]string, 0, len(ixns))
+	for _, ixn := range ixns {
+		names = append(names, ixn.SourceName+"->"+ixn.DestinationName)
+	}
+	return names
+}
@@ -0,0 +1,160 @@
+package kv
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/agent/submatview"
+	"github.com/hashicorp/consul/proto/pbsubscribe"
+)
+
+// Client provides access to the KV store.
+type Client struct {
+	NetRPC              NetRPC
+	ViewStore           MaterializedViewStore
+	StreamClient        submatview.StreamClient
+	UseStreamingBackend bool
+	QueryOptionDefaults func(options *structs.QueryOptions)
+
+	// unsupported remembers the datacenters whose servers do not support the
+	// KV topic.
+	unsupported submatview.UnsupportedTopic
+}
+
+type NetRPC interface {
+	RPC(method string, args interface{}, reply interface{}) error
+}
+
+type MaterializedViewStore interface {
+	Get(ctx context.Context, req submatview.Request) (submatview.Result, error)
+	NewRequest(spec submatview.RequestSpec) (submatview.Request, error)
+}
+
+// RegisterView registers the view used to materialize the KV topic with the
+// store. It must be called once for each store before using a Client with
+// UseStreamingBackend enabled.
+func RegisterView(store *submatview.Store) error {
+	return store.RegisterView(pbsubscribe.Topic_KV, func(_ pbsubscribe.SubscribeRequest) (submatview.View, error) {
+		return newKVView(), nil
+	})
+}
+
+// Get returns the entry with the key req.Key. Blocking queries are served by
+// a materialized view when the streaming backend is enabled. Like the KVS.Get
+// RPC, Get returns acl.ErrPermissionDenied when the token can not read the key.
+func (c *Client) Get(ctx context.Context, req structs.KeyRequest) (structs.IndexedDirEntries, error) {
+	if c.useStreaming(req) {
+		out, err := c.getFromView(ctx, req, true, func(out *structs.IndexedDirEntries) {
+			entries := out.Entries
+			out.Entries = nil
+			for _, entry := range entries {
+				if entry.Key == req.Key {
+					// Match the index returned by the KVS.Get RPC.
+					out.Index = entry.ModifyIndex
+					out.Entries = structs.DirEntries{entry}
+					return
+				}
+			}
+		})
+		if !c.unsupported.Record(req.Datacenter, err) {
+			return out, permissionDenied(err)
+		}
+	}
+
+	var out structs.IndexedDirEntries
+	err := c.NetRPC.RPC("KVS.Get", &req, &out)
+	return out, err
+}
+
+// List returns all the entries with a key that has the prefix req.Key.
+// Blocking queries are served by a materialized view when the streaming
+// backend is enabled. Like the KVS.List RPC, List returns
+// acl.ErrPermissionDenied when acl.enable_key_list_policy is enabled, and the
+// token can not list the prefix.
+func (c *Client) List(ctx context.Context, req structs.KeyRequest) (structs.IndexedDirEntries, error) {
+	if c.useStreaming(req) {
+		out, err := c.getFromView(ctx, req, false, func(*structs.IndexedDirEntries) {})
+		if !c.unsupported.Record(req.Datacenter, err) {
+			return out, permissionDenied(err)
+		}
+	}
+
+	var out structs.IndexedDirEntries
+	err := c.NetRPC.RPC("KVS.List", &req, &out)
+	return out, err
+}
+
+// useStreaming returns true if the request can be served by a view. Consistent
+// reads use the RPC, because the view may be behind the leader.
+func (c *Client) useStreaming(req structs.KeyRequest) bool {
+	return c.UseStreamingBackend &&
+		req.QueryOptions.MinQueryIndex > 0 &&
+		!req.QueryOptions.RequireConsistent &&
+		!c.unsupported.Unsupported(req.Datacenter)
+}
+
+// permissionDenied returns acl.ErrPermissionDenied in place of the permission
+// denied error of a subscription, so that the error matches the one returned
+// by the RPC.
+func permissionDenied(err error) error {
+	if acl.IsErrPermissionDenied(err) {
+		return acl.ErrPermissionDenied
+	}
+	return err
+}
+
+// getFromView returns the entries from the view of req.Key. The view contains
+// the entry with the key req.Key when exact is true, otherwise every entry with
+// the prefix req.Key. filter is called to select the entries, and to set the
+// index of the result. Servers which do not support exact keys send every
+// entry with the prefix, so filter must select the entries in both cases.
+//
+// The view of a prefix is shared by every request for keys with the prefix,
+// so the view may be updated without a change to the result. In that case
+// getFromView continues to wait for an update until the request times out.
+func (c *Client) getFromView(
+	ctx context.Context,
+	req structs.KeyRequest,
+	exact bool,
+	filter func(out *structs.IndexedDirEntries),
+) (structs.IndexedDirEntries, error) {
+	c.QueryOptionDefaults(&req.QueryOptions)
+
+	minIndex := req.QueryOptions.MinQueryIndex
+	deadline := time.Now().Add(req.QueryOptions.MaxQueryTime)
+	viewIndex := minIndex
+	for {
+		sr, err := c.ViewStore.NewRequest(submatview.RequestSpec{
+			Subscribe: pbsubscribe.SubscribeRequest{
+				Topic:      pbsubscribe.Topic_KV,
+				Key:        req.Key,
+				Token:      req.Token,
+				Datacenter: req.Datacenter,
+				Namespace:  req.EnterpriseMeta.NamespaceOrEmpty(),
+				Partition:  req.EnterpriseMeta.PartitionOrEmpty(),
+				ExactKey:   exact,
+			},
+			MinIndex: viewIndex,
+			Timeout:  time.Until(deadline),
+			Client:   c.StreamClient,
+		})
+		if err != nil {
+			return structs.IndexedDirEntries{}, err
+		}
+
+		result, err := c.ViewStore.Get(ctx, sr)
+		if err != nil {
+			return structs.IndexedDirEntries{}, err
+		}
+
+		out := *result.Value.(*structs.IndexedDirEntries)
+		out.QueryMeta.LastContact = result.Meta().Age
+		filter(&out)
+		if out.Index > minIndex || result.Index <= viewIndex || !time.Now().Before(deadline) {
+			return out, nil
+		}
+		viewIndex = result.Index
+	}
+}
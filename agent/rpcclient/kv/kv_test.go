@@ -0,0 +1,152 @@
+package kv
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/agent/submatview"
+)
+
+type fakeNetRPC struct {
+	calls []string
+}
+
+func (f *fakeNetRPC) RPC(method string, _ interface{}, _ interface{}) error {
+	f.calls = append(f.calls, method)
+	return nil
+}
+
+type fakeViewStore struct {
+	specs []submatview.RequestSpec
+	err   error
+}
+
+func (f *fakeViewStore) NewRequest(spec submatview.RequestSpec) (submatview.Request, error) {
+	f.specs = append(f.specs, spec)
+	return nil, nil
+}
+
+func (f *fakeViewStore) Get(_ context.Context, _ submatview.Request) (submatview.Result, error) {
+	if f.err != nil {
+		return submatview.Result{}, f.err
+	}
+	entries := &structs.IndexedDirEntries{
+		Entries:   structs.DirEntries{{Key: "foo", RaftIndex: structs.RaftIndex{ModifyIndex: 10}}},
+		QueryMeta: structs.QueryMeta{Index: 10},
+	}
+	return submatview.Result{Index: 10, Value: entries}, nil
+}
+
+func newTestClient(store *fakeViewStore) (*Client, *fakeNetRPC) {
+	rpc := &fakeNetRPC{}
+	return &Client{
+		NetRPC:              rpc,
+		ViewStore:           store,
+		UseStreamingBackend: true,
+		QueryOptionDefaults: func(*structs.QueryOptions) {},
+	}, rpc
+}
+
+func TestClient_Get_ExactKey(t *testing.T) {
+	store := &fakeViewStore{}
+	c, rpc := newTestClient(store)
+
+	req := structs.KeyRequest{
+		Datacenter:   "dc1",
+		Key:          "foo",
+		QueryOptions: structs.QueryOptions{MinQueryIndex: 5, MaxQueryTime: time.Second},
+	}
+	out, err := c.Get(context.Background(), req)
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), out.Index)
+	require.Len(t, out.Entries, 1)
+
+	_, err = c.List(context.Background(), req)
+	require.NoError(t, err)
+
+	require.Len(t, rpc.calls, 0)
+	require.Len(t, store.specs, 2)
+	require.True(t, store.specs[0].Subscribe.ExactKey, "Get subscribes to the exact key")
+	require.False(t, store.specs[1].Subscribe.ExactKey, "List subscribes to the prefix")
+}
+
+func TestClient_Get_RequireConsistent(t *testing.T) {
+	store := &fakeViewStore{}
+	c, rpc := newTestClient(store)
+
+	req := structs.KeyRequest{
+		Datacenter: "dc1",
+		Key:        "foo",
+		QueryOptions: structs.QueryOptions{
+			MinQueryIndex:     5,
+			RequireConsistent: true,
+		},
+	}
+	_, err := c.Get(context.Background(), req)
+	require.NoError(t, err)
+	_, err = c.List(context.Background(), req)
+	require.NoError(t, err)
+
+	require.Len(t, store.specs, 0)
+	require.Equal(t, []string{"KVS.Get", "KVS.List"}, rpc.calls)
+}
+
+func TestClient_Get_UnknownTopic(t *testing.T) {
+	store := &fakeViewStore{err: errors.New("rpc error: code = Unknown desc = unknown topic KV")}
+	c, rpc := newTestClient(store)
+
+	req := structs.KeyRequest{
+		Datacenter:   "dc1",
+		Key:          "foo",
+		QueryOptions: structs.QueryOptions{MinQueryIndex: 5, MaxQueryTime: time.Second},
+	}
+
+	_, err := c.Get(context.Background(), req)
+	require.NoError(t, err)
+	require.Len(t, store.specs, 1)
+	require.Equal(t, []string{"KVS.Get"}, rpc.calls)
+
+	// The failure is remembered for the datacenter, so the view is not tried
+	// again.
+	_, err = c.List(context.Background(), req)
+	require.NoError(t, err)
+	require.Len(t, store.specs, 1)
+	require.Equal(t, []string{"KVS.Get", "KVS.List"}, rpc.calls)
+
+	req.Datacenter = "dc2"
+	_, err = c.Get(context.Background(), req)
+	require.NoError(t, err)
+	require.Len(t, store.specs, 2)
+
+	// The view is tried again once the retry interval has passed.
+	c.unsupported.RetryInterval = time.Nanosecond
+	req.Datacenter = "dc1"
+	store.err = nil
+	_, err = c.Get(context.Background(), req)
+	require.NoError(t, err)
+	require.Len(t, store.specs, 3)
+	require.Equal(t, []string{"KVS.Get", "KVS.List", "KVS.Get"}, rpc.calls)
+}
+
+func TestClient_Get_PermissionDenied(t *testing.T) {
+	store := &fakeViewStore{err: errors.New("rpc error: code = PermissionDenied desc = Permission denied")}
+	c, rpc := newTestClient(store)
+
+	req := structs.KeyRequest{
+		Datacenter:   "dc1",
+		Key:          "foo",
+		QueryOptions: structs.QueryOptions{MinQueryIndex: 5, MaxQueryTime: time.Second},
+	}
+	_, err := c.Get(context.Background(), req)
+	require.Equal(t, acl.ErrPermissionDenied, err)
+
+	_, err = c.List(context.Background(), req)
+	require.Equal(t, acl.ErrPermissionDenied, err)
+	require.Len(t, rpc.calls, 0)
+}
@@ -0,0 +1,98 @@
+package kv
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/agent/submatview"
+	"github.com/hashicorp/consul/proto/pbsubscribe"
+)
+
+func newKVView() *kvView {
+	return &kvView{state: make(map[string]*structs.DirEntry)}
+}
+
+// kvView implements submatview.View for storing the view state of the entries
+// in the KV store with a key prefix.
+type kvView struct {
+	state map[string]*structs.DirEntry
+	// deleteIndex is the index of the most recent delete event. It is used to
+	// report the same index as a KVS.List RPC, which includes the index of
+	// tombstones.
+	deleteIndex uint64
+	// size is the approximate size of state in bytes.
+	size int
+}
+
+// Update implements View
+func (v *kvView) Update(events []*pbsubscribe.Event) error {
+	for _, event := range events {
+		kv := event.GetKV()
+		if kv == nil {
+			return fmt.Errorf("unexpected event type for kv view: %T",
+				event.GetPayload())
+		}
+
+		entry := pbsubscribe.KVEntryToStructs(kv.Entry)
+		id := entry.EnterpriseMeta.PartitionOrDefault() + "/" +
+			entry.EnterpriseMeta.NamespaceOrDefault() + "/" + entry.Key
+		switch kv.Op {
+		case pbsubscribe.UpdateOp_Upsert:
+			v.delete(id)
+			v.state[id] = entry
+			v.size += len(id) + submatview.ApproximateSize(entry)
+		case pbsubscribe.UpdateOp_Delete:
+			v.delete(id)
+			if event.Index > v.deleteIndex {
+				v.deleteIndex = event.Index
+			}
+		}
+	}
+	return nil
+}
+
+func (v *kvView) delete(id string) {
+	if entry, ok := v.state[id]; ok {
+		v.size -= len(id) + submatview.ApproximateSize(entry)
+		delete(v.state, id)
+	}
+}
+
+// SizeBytes implements submatview.SizedView
+func (v *kvView) SizeBytes() int {
+	return v.size
+}
+
+// Result returns the structs.IndexedDirEntries stored by this view. The index
+// of the result is the highest index of the entries and delete events in the
+// view, matching the index of a KVS.List RPC. If there are none, the index of
+// the view is used.
+func (v *kvView) Result(index uint64) interface{} {
+	result := structs.IndexedDirEntries{
+		Entries: make(structs.DirEntries, 0, len(v.state)),
+		QueryMeta: structs.QueryMeta{
+			Index:   v.deleteIndex,
+			Backend: structs.QueryBackendStreaming,
+		},
+	}
+	for _, entry := range v.state {
+		result.Entries = append(result.Entries, entry)
+		if entry.ModifyIndex > result.Index {
+			result.Index = entry.ModifyIndex
+		}
+	}
+	if result.Index == 0 || result.Index > index {
+		result.Index = index
+	}
+	sort.Slice(result.Entries, func(i, j int) bool {
+		return result.Entries[i].Key < result.Entries[j].Key
+	})
+	return &result
+}
+
+func (v *kvView) Reset() {
+	v.state = make(map[string]*structs.DirEntry)
+	v.deleteIndex = 0
+	v.size = 0
+}
@@ -0,0 +1,84 @@
+package kv
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/proto/pbsubscribe"
+)
+
+func newEventKV(index uint64, op pbsubscribe.UpdateOp, key string, value string) *pbsubscribe.Event {
+	return &pbsubscribe.Event{
+		Index: index,
+		Payload: &pbsubscribe.Event_KV{
+			KV: &pbsubscribe.KVUpdate{
+				Op: op,
+				Entry: &pbsubscribe.KVEntry{
+					Key:         key,
+					Value:       []byte(value),
+					CreateIndex: index,
+					ModifyIndex: index,
+				},
+			},
+		},
+	}
+}
+
+func TestKVView(t *testing.T) {
+	view := newKVView()
+
+	err := view.Update([]*pbsubscribe.Event{
+		newEventKV(5, pbsubscribe.UpdateOp_Upsert, "web/b", "b"),
+		newEventKV(7, pbsubscribe.UpdateOp_Upsert, "web/a", "a"),
+		newEventKV(6, pbsubscribe.UpdateOp_Upsert, "web/c", "c"),
+	})
+	require.NoError(t, err)
+
+	result := view.Result(10).(*structs.IndexedDirEntries)
+	require.Equal(t, uint64(7), result.Index)
+	require.Equal(t, structs.QueryBackendStreaming, result.Backend)
+	require.Len(t, result.Entries, 3)
+	require.Equal(t, "web/a", result.Entries[0].Key)
+	require.Equal(t, []byte("a"), result.Entries[0].Value)
+	require.Equal(t, "web/b", result.Entries[1].Key)
+	require.Equal(t, "web/c", result.Entries[2].Key)
+
+	err = view.Update([]*pbsubscribe.Event{
+		newEventKV(11, pbsubscribe.UpdateOp_Delete, "web/b", ""),
+	})
+	require.NoError(t, err)
+
+	result = view.Result(11).(*structs.IndexedDirEntries)
+	require.Equal(t, uint64(11), result.Index)
+	require.Len(t, result.Entries, 2)
+
+	view.Reset()
+	result = view.Result(12).(*structs.IndexedDirEntries)
+	require.Equal(t, uint64(12), result.Index)
+	require.Len(t, result.Entries, 0)
+
+	err = view.Update([]*pbsubscribe.Event{{Payload: &pbsubscribe.Event_EndOfSnapshot{EndOfSnapshot: true}}})
+	require.Error(t, err)
+}
+
+func TestKVView_SizeBytes(t *testing.T) {
+	view := newKVView()
+
+	require.NoError(t, view.Update([]*pbsubscribe.Event{
+		newEventKV(5, pbsubscribe.UpdateOp_Upsert, "web/a", "a"),
+	}))
+	small := view.SizeBytes()
+	require.True(t, small > 0, "expected a size, got %v", small)
+
+	require.NoError(t, view.Update([]*pbsubscribe.Event{
+		newEventKV(6, pbsubscribe.UpdateOp_Upsert, "web/a", "a larger value"),
+	}))
+	require.True(t, view.SizeBytes() > small, "expected the size to grow, got %v", view.SizeBytes())
+
+	require.NoError(t, view.Update([]*pbsubscribe.Event{
+		newEventKV(7, pbsubscribe.UpdateOp_Delete, "web/a", ""),
+	}))
+	require.Equal(t, 0, view.SizeBytes())
+}
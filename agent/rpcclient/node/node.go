@@ -0,0 +1,156 @@
+package node
+
+import (
+	"context"
+	"time"
+
+	"github.com/hashicorp/go-uuid"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/agent/submatview"
+	"github.com/hashicorp/consul/proto/pbsubscribe"
+)
+
+// Client provides access to the services and checks registered on a node.
+type Client struct {
+	NetRPC              NetRPC
+	ViewStore           MaterializedViewStore
+	StreamClient        submatview.StreamClient
+	UseStreamingBackend bool
+	QueryOptionDefaults func(options *structs.QueryOptions)
+
+	// unsupported remembers the datacenters whose servers do not support the
+	// Node topic.
+	unsupported submatview.UnsupportedTopic
+}
+
+type NetRPC interface {
+	RPC(method string, args interface{}, reply interface{}) error
+}
+
+type MaterializedViewStore interface {
+	Get(ctx context.Context, req submatview.Request) (submatview.Result, error)
+	NewRequest(spec submatview.RequestSpec) (submatview.Request, error)
+}
+
+// RegisterView registers the view used to materialize the Node topic with the
+// store. It must be called once for each store before using a Client with
+// UseStreamingBackend enabled.
+func RegisterView(store *submatview.Store) error {
+	return store.RegisterView(pbsubscribe.Topic_Node, func(_ pbsubscribe.SubscribeRequest) (submatview.View, error) {
+		return newNodeView(), nil
+	})
+}
+
+// NodeServices returns the node req.Node along with its services. Blocking
+// queries are served by a materialized view when the streaming backend is
+// enabled.
+func (c *Client) NodeServices(ctx context.Context, req structs.NodeSpecificRequest) (structs.IndexedNodeServices, error) {
+	if c.useStreaming(req) {
+		result, err := c.getFromView(ctx, req, func(r *nodeResult) uint64 {
+			return r.NodeServices.Index
+		})
+		if !c.unsupported.Record(req.Datacenter, err) {
+			if err != nil {
+				return structs.IndexedNodeServices{}, err
+			}
+			return result.NodeServices, nil
+		}
+	}
+
+	var out structs.IndexedNodeServices
+	err := c.NetRPC.RPC("Catalog.NodeServices", &req, &out)
+	return out, err
+}
+
+// NodeChecks returns the checks registered on the node req.Node. Blocking
+// queries are served by a materialized view when the streaming backend is
+// enabled.
+func (c *Client) NodeChecks(ctx context.Context, req structs.NodeSpecificRequest) (structs.IndexedHealthChecks, error) {
+	if c.useStreaming(req) {
+		result, err := c.getFromView(ctx, req, func(r *nodeResult) uint64 {
+			return r.HealthChecks.Index
+		})
+		if !c.unsupported.Record(req.Datacenter, err) {
+			if err != nil {
+				return structs.IndexedHealthChecks{}, err
+			}
+			return result.HealthChecks, nil
+		}
+	}
+
+	var out structs.IndexedHealthChecks
+	err := c.NetRPC.RPC("Health.NodeChecks", &req, &out)
+	return out, err
+}
+
+// useStreaming returns true if the request can be served by the view. The
+// view is materialized for a node name, and does not evaluate filter
+// expressions, so requests for a node ID or with a filter use the RPC, as do
+// the requests to a datacenter whose servers do not support the Node topic.
+// Consistent reads use the RPC, because the view may be behind the leader.
+func (c *Client) useStreaming(req structs.NodeSpecificRequest) bool {
+	return c.UseStreamingBackend &&
+		req.QueryOptions.MinQueryIndex > 0 &&
+		!req.QueryOptions.RequireConsistent &&
+		req.QueryOptions.Filter == "" &&
+		!isNodeID(req.Node) &&
+		!c.unsupported.Unsupported(req.Datacenter)
+}
+
+// isNodeID returns true if node is a node ID rather than a node name.
+func isNodeID(node string) bool {
+	_, err := uuid.ParseUUID(node)
+	return err == nil
+}
+
+// getFromView returns the result of the view of req.Node. index is called to
+// select the index of the part of the result used by the caller.
+//
+// The view is shared by the requests for the services and the checks of the
+// node, so the view may be updated without a change to the part of the result
+// used by the caller. In that case getFromView continues to wait for an update
+// until the request times out.
+func (c *Client) getFromView(
+	ctx context.Context,
+	req structs.NodeSpecificRequest,
+	index func(r *nodeResult) uint64,
+) (*nodeResult, error) {
+	c.QueryOptionDefaults(&req.QueryOptions)
+
+	minIndex := req.QueryOptions.MinQueryIndex
+	deadline := time.Now().Add(req.QueryOptions.MaxQueryTime)
+	viewIndex := minIndex
+	for {
+		sr, err := c.ViewStore.NewRequest(submatview.RequestSpec{
+			Subscribe: pbsubscribe.SubscribeRequest{
+				Topic:      pbsubscribe.Topic_Node,
+				Key:        req.Node,
+				Token:      req.Token,
+				Datacenter: req.Datacenter,
+				Namespace:  req.EnterpriseMeta.NamespaceOrEmpty(),
+				Partition:  req.EnterpriseMeta.PartitionOrEmpty(),
+			},
+			MinIndex: viewIndex,
+			Timeout:  time.Until(deadline),
+			Client:   c.StreamClient,
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		result, err := c.ViewStore.Get(ctx, sr)
+		if err != nil {
+			return nil, err
+		}
+
+		out := result.Value.(*nodeResult)
+		age := result.Meta().Age
+		out.NodeServices.QueryMeta.LastContact = age
+		out.HealthChecks.QueryMeta.LastContact = age
+		if index(out) > minIndex || result.Index <= viewIndex || !time.Now().Before(deadline) {
+			return out, nil
+		}
+		viewIndex = result.Index
+	}
+}
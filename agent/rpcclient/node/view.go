@@ -0,0 +1,181 @@
+package node
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/agent/submatview"
+	"github.com/hashicorp/consul/proto/pbservice"
+	"github.com/hashicorp/consul/proto/pbsubscribe"
+)
+
+func newNodeView() *nodeView {
+	return &nodeView{state: make(map[string]*nodeState)}
+}
+
+// nodeState is the state of a node stored by nodeView.
+type nodeState struct {
+	// node is nil until the node is registered. Services and checks may be
+	// received before the node in the same batch of events.
+	node     *structs.Node
+	services map[string]*structs.NodeService
+	checks   map[string]*structs.HealthCheck
+}
+
+// nodeView implements submatview.View for storing the view state of a node,
+// and of the services and checks registered on the node. The state is indexed
+// by node name, so that a Deregister of a node, which is sent when the node is
+// deregistered or renamed, removes its services and checks.
+type nodeView struct {
+	state map[string]*nodeState
+	// servicesIndex and checksIndex are the index of the most recent event
+	// which changed the node or its services, and the node or its checks. They
+	// are used to report an index which only changes with the result, like
+	// the Catalog.NodeServices and Health.NodeChecks RPCs.
+	servicesIndex uint64
+	checksIndex   uint64
+}
+
+// nodeResult is the result of a nodeView. It contains the result of both the
+// Catalog.NodeServices and Health.NodeChecks RPCs, so that the requests for the
+// services and the checks of a node are served by the same view.
+type nodeResult struct {
+	NodeServices structs.IndexedNodeServices
+	HealthChecks structs.IndexedHealthChecks
+}
+
+// Update implements View
+func (v *nodeView) Update(events []*pbsubscribe.Event) error {
+	for _, event := range events {
+		update := event.GetNode()
+		if update == nil {
+			return fmt.Errorf("unexpected event type for node view: %T",
+				event.GetPayload())
+		}
+
+		name := update.NodeName
+		state, ok := v.state[name]
+		if !ok {
+			if update.Op == pbsubscribe.CatalogOp_Deregister {
+				continue
+			}
+			state = &nodeState{
+				services: make(map[string]*structs.NodeService),
+				checks:   make(map[string]*structs.HealthCheck),
+			}
+			v.state[name] = state
+		}
+
+		switch {
+		case update.Service != nil:
+			svc := pbservice.NodeServiceToStructs(*update.Service)
+			id := svc.EnterpriseMeta.NamespaceOrDefault() + "/" + svc.ID
+			if update.Op == pbsubscribe.CatalogOp_Register {
+				state.services[id] = &svc
+			} else {
+				delete(state.services, id)
+			}
+			v.servicesIndex = maxIndex(v.servicesIndex, event.Index)
+
+		case update.Check != nil:
+			check := pbservice.HealthCheckToStructs(*update.Check)
+			id := check.EnterpriseMeta.NamespaceOrDefault() + "/" + string(check.CheckID)
+			if update.Op == pbsubscribe.CatalogOp_Register {
+				state.checks[id] = &check
+			} else {
+				delete(state.checks, id)
+			}
+			v.checksIndex = maxIndex(v.checksIndex, event.Index)
+
+		case update.Node != nil:
+			if update.Op == pbsubscribe.CatalogOp_Register {
+				node := pbservice.NodeToStructs(*update.Node)
+				state.node = &node
+			} else {
+				delete(v.state, name)
+			}
+			v.servicesIndex = maxIndex(v.servicesIndex, event.Index)
+			v.checksIndex = maxIndex(v.checksIndex, event.Index)
+		}
+	}
+	return nil
+}
+
+// SizeBytes implements submatview.SizedView. The view is materialized for a
+// single node, so the size is computed from the whole state.
+func (v *nodeView) SizeBytes() int {
+	return submatview.ApproximateSize(v.state)
+}
+
+func maxIndex(a, b uint64) uint64 {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// Result returns the *nodeResult of this view. The view is materialized for a
+// single node, so the result contains the first registered node of the view.
+// If there is none, NodeServices is nil like the result of a
+// Catalog.NodeServices RPC for an unknown node.
+//
+// Each result is a copy of the state, so that callers may modify it.
+func (v *nodeView) Result(index uint64) interface{} {
+	result := nodeResult{
+		NodeServices: structs.IndexedNodeServices{
+			QueryMeta: structs.QueryMeta{
+				Index:   resultIndex(v.servicesIndex, index),
+				Backend: structs.QueryBackendStreaming,
+			},
+		},
+		HealthChecks: structs.IndexedHealthChecks{
+			HealthChecks: make(structs.HealthChecks, 0),
+			QueryMeta: structs.QueryMeta{
+				Index:   resultIndex(v.checksIndex, index),
+				Backend: structs.QueryBackendStreaming,
+			},
+		},
+	}
+
+	for _, state := range v.state {
+		if state.node == nil {
+			continue
+		}
+		node := *state.node
+		result.NodeServices.NodeServices = &structs.NodeServices{
+			Node:     &node,
+			Services: make(map[string]*structs.NodeService, len(state.services)),
+		}
+		for _, svc := range state.services {
+			svc := *svc
+			result.NodeServices.NodeServices.Services[svc.ID] = &svc
+		}
+		for _, check := range state.checks {
+			check := *check
+			result.HealthChecks.HealthChecks = append(result.HealthChecks.HealthChecks, &check)
+		}
+		break
+	}
+
+	// Match the order of the Health.NodeChecks RPC.
+	sort.Slice(result.HealthChecks.HealthChecks, func(i, j int) bool {
+		return result.HealthChecks.HealthChecks[i].CheckID < result.HealthChecks.HealthChecks[j].CheckID
+	})
+	return &result
+}
+
+// resultIndex returns the index of a part of the result which was last changed
+// at changed, or the index of the view if the part was never changed.
+func resultIndex(changed, index uint64) uint64 {
+	if changed == 0 {
+		return index
+	}
+	return changed
+}
+
+func (v *nodeView) Reset() {
+	v.state = make(map[string]*nodeState)
+	v.servicesIndex = 0
+	v.checksIndex = 0
+}
@@ -0,0 +1,136 @@
+package node
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/proto/pbservice"
+	"github.com/hashicorp/consul/proto/pbsubscribe"
+	"github.com/hashicorp/consul/types"
+)
+
+func newEventNode(index uint64, op pbsubscribe.CatalogOp, node string) *pbsubscribe.Event {
+	return &pbsubscribe.Event{
+		Index: index,
+		Payload: &pbsubscribe.Event_Node{
+			Node: &pbsubscribe.NodeUpdate{
+				Op:       op,
+				NodeName: node,
+				Node:     &pbservice.Node{Node: node, Address: "10.0.0.1"},
+			},
+		},
+	}
+}
+
+func newEventNodeService(index uint64, op pbsubscribe.CatalogOp, node, service string) *pbsubscribe.Event {
+	return &pbsubscribe.Event{
+		Index: index,
+		Payload: &pbsubscribe.Event_Node{
+			Node: &pbsubscribe.NodeUpdate{
+				Op:       op,
+				NodeName: node,
+				Service:  &pbservice.NodeService{ID: service, Service: service},
+			},
+		},
+	}
+}
+
+func newEventNodeCheck(index uint64, op pbsubscribe.CatalogOp, node, check string) *pbsubscribe.Event {
+	return &pbsubscribe.Event{
+		Index: index,
+		Payload: &pbsubscribe.Event_Node{
+			Node: &pbsubscribe.NodeUpdate{
+				Op:       op,
+				NodeName: node,
+				Check:    &pbservice.HealthCheck{Node: node, CheckID: types.CheckID(check), Status: "passing"},
+			},
+		},
+	}
+}
+
+func TestNodeView(t *testing.T) {
+	view := newNodeView()
+
+	result := view.Result(2).(*nodeResult)
+	require.Nil(t, result.NodeServices.NodeServices)
+	require.Equal(t, uint64(2), result.NodeServices.Index)
+	require.Empty(t, result.HealthChecks.HealthChecks)
+
+	err := view.Update([]*pbsubscribe.Event{
+		newEventNodeService(5, pbsubscribe.CatalogOp_Register, "node1", "web"),
+		newEventNode(5, pbsubscribe.CatalogOp_Register, "node1"),
+		newEventNodeService(5, pbsubscribe.CatalogOp_Register, "node1", "db"),
+		newEventNodeCheck(5, pbsubscribe.CatalogOp_Register, "node1", "serf-health"),
+		newEventNodeCheck(5, pbsubscribe.CatalogOp_Register, "node1", "service:db"),
+	})
+	require.NoError(t, err)
+
+	result = view.Result(5).(*nodeResult)
+	require.Equal(t, structs.QueryBackendStreaming, result.NodeServices.Backend)
+	require.Equal(t, "node1", result.NodeServices.NodeServices.Node.Node)
+	require.Len(t, result.NodeServices.NodeServices.Services, 2)
+	require.Contains(t, result.NodeServices.NodeServices.Services, "web")
+	require.Len(t, result.HealthChecks.HealthChecks, 2)
+	require.Equal(t, "serf-health", string(result.HealthChecks.HealthChecks[0].CheckID))
+
+	runStep(t, "check updates do not change the index of the services", func(t *testing.T) {
+		err := view.Update([]*pbsubscribe.Event{
+			newEventNodeCheck(8, pbsubscribe.CatalogOp_Deregister, "node1", "service:db"),
+		})
+		require.NoError(t, err)
+
+		result := view.Result(8).(*nodeResult)
+		require.Equal(t, uint64(5), result.NodeServices.Index)
+		require.Equal(t, uint64(8), result.HealthChecks.Index)
+		require.Len(t, result.HealthChecks.HealthChecks, 1)
+	})
+
+	runStep(t, "results are copies of the state", func(t *testing.T) {
+		result := view.Result(8).(*nodeResult)
+		result.NodeServices.NodeServices.Node.Address = "changed"
+		result.NodeServices.NodeServices.Services["web"].Port = 1234
+
+		result = view.Result(8).(*nodeResult)
+		require.Equal(t, "10.0.0.1", result.NodeServices.NodeServices.Node.Address)
+		require.Equal(t, 0, result.NodeServices.NodeServices.Services["web"].Port)
+	})
+
+	runStep(t, "deregistering the node removes its services and checks", func(t *testing.T) {
+		err := view.Update([]*pbsubscribe.Event{
+			newEventNode(9, pbsubscribe.CatalogOp_Deregister, "node1"),
+			newEventNodeService(9, pbsubscribe.CatalogOp_Deregister, "node1", "web"),
+		})
+		require.NoError(t, err)
+
+		result := view.Result(9).(*nodeResult)
+		require.Nil(t, result.NodeServices.NodeServices)
+		require.Equal(t, uint64(9), result.NodeServices.Index)
+		require.Empty(t, result.HealthChecks.HealthChecks)
+		require.Equal(t, uint64(9), result.HealthChecks.Index)
+	})
+
+	runStep(t, "the node registered again", func(t *testing.T) {
+		err := view.Update([]*pbsubscribe.Event{
+			newEventNode(10, pbsubscribe.CatalogOp_Register, "node1"),
+		})
+		require.NoError(t, err)
+
+		result := view.Result(10).(*nodeResult)
+		require.Equal(t, "node1", result.NodeServices.NodeServices.Node.Node)
+		require.Empty(t, result.NodeServices.NodeServices.Services)
+	})
+
+	view.Reset()
+	result = view.Result(11).(*nodeResult)
+	require.Nil(t, result.NodeServices.NodeServices)
+	require.Equal(t, uint64(11), result.HealthChecks.Index)
+}
+
+func runStep(t *testing.T, name string, fn func(t *testing.T)) {
+	t.Helper()
+	if !t.Run(name, fn) {
+		t.FailNow()
+	}
+}
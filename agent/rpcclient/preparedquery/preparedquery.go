@@ -0,0 +1,406 @@
+package preparedquery
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/cache"
+	cachetype "github.com/hashicorp/consul/agent/cache-types"
+	"github.com/hashicorp/consul/agent/structs"
+)
+
+// Client executes prepared queries for requests which accept cached results.
+type Client struct {
+	Cache               CacheGetter
+	Health              HealthClient
+	UseStreamingBackend bool
+	Logger              hclog.Logger
+}
+
+type CacheGetter interface {
+	Get(ctx context.Context, t string, r cache.Request) (interface{}, cache.ResultMeta, error)
+}
+
+// HealthClient returns the instances of a service. ServiceNodes is expected to
+// read them from a materialized view when the streaming backend is enabled.
+type HealthClient interface {
+	ServiceNodes(ctx context.Context, req structs.ServiceSpecificRequest) (structs.IndexedCheckServiceNodes, cache.ResultMeta, error)
+}
+
+// maxAge is how old the definitions of the prepared queries, the coordinates
+// of the nodes, and the list of datacenters used to execute a query on the
+// agent may be, unless the request asks for a lower QueryOptions.MaxAge.
+const maxAge = 30 * time.Second
+
+// redactedToken is the Token of a prepared query returned by the servers to a
+// token which is not allowed to read it.
+const redactedToken = "<hidden>"
+
+// errNotLocal is returned when a prepared query can not be executed by the
+// agent, so that it is executed by the servers instead.
+var errNotLocal = errors.New("the prepared query can not be executed by the agent")
+
+// Execute runs the prepared query args.QueryIDOrName. When the streaming
+// backend is enabled the query is executed by the agent: the instances of the
+// service are read from the materialized views of the agent, for the
+// datacenter of the request and for the datacenters it fails over to, and the
+// filters, distance sort, and failover of the query are applied locally.
+// Otherwise, and for queries which can not be executed by the agent, the query
+// is executed by the servers and the result is cached by the agent.
+//
+// The definition of the query is read from the servers with
+// PreparedQuery.Explain, which requires the token of the request to be
+// allowed to read it. It is cached for up to maxAge.
+func (c *Client) Execute(
+	ctx context.Context,
+	args *structs.PreparedQueryExecuteRequest,
+) (*structs.PreparedQueryExecuteResponse, cache.ResultMeta, error) {
+	if c.useStreaming(args) {
+		reply, meta, err := c.executeLocally(ctx, args)
+		switch {
+		case err == nil:
+			return reply, meta, nil
+		case structs.IsErrQueryNotFound(err):
+			return nil, cache.ResultMeta{}, err
+		case !errors.Is(err, errNotLocal):
+			c.Logger.Warn("failed to execute the prepared query on the agent, executing it on the servers",
+				"prepared_query", args.QueryIDOrName,
+				"error", err)
+		}
+	}
+
+	raw, meta, err := c.Cache.Get(ctx, cachetype.PreparedQueryName, args)
+	if err != nil {
+		// The value is returned with the error, for requests which allow
+		// stale results on errors.
+		reply, _ := raw.(*structs.PreparedQueryExecuteResponse)
+		return reply, meta, err
+	}
+	reply, ok := raw.(*structs.PreparedQueryExecuteResponse)
+	if !ok {
+		// This should never happen, but we want to protect against panics
+		return nil, meta, fmt.Errorf("internal error: response type not correct")
+	}
+	return reply, meta, nil
+}
+
+func (c *Client) useStreaming(args *structs.PreparedQueryExecuteRequest) bool {
+	return c.UseStreamingBackend && !args.RequireConsistent
+}
+
+// executeLocally executes the query like PreparedQuery.Execute on the servers.
+// It returns errNotLocal when the query can not be executed by the agent.
+func (c *Client) executeLocally(
+	ctx context.Context,
+	args *structs.PreparedQueryExecuteRequest,
+) (*structs.PreparedQueryExecuteResponse, cache.ResultMeta, error) {
+	query, err := c.explain(ctx, args)
+	if err != nil {
+		return nil, cache.ResultMeta{}, err
+	}
+
+	// If they supplied a token with the query, use that, otherwise use the
+	// token passed in with the request.
+	token := args.QueryOptions.Token
+	if query.Token != "" {
+		token = query.Token
+	}
+
+	dc := args.Datacenter
+	if dc == "" {
+		dc = args.Agent.Datacenter
+	}
+	reply, meta, err := c.serviceNodes(ctx, dc, query, args, token)
+	if err != nil {
+		return nil, cache.ResultMeta{}, err
+	}
+
+	// Shuffle the results in case coordinates are not available if they
+	// requested an RTT sort.
+	reply.Nodes.Shuffle()
+
+	qs, err := querySource(args, query, reply.Nodes)
+	if err != nil {
+		return nil, cache.ResultMeta{}, err
+	}
+
+	// Coordinates can not be compared across datacenters.
+	if qs.Node != "" && qs.Datacenter == dc {
+		if err := c.sortByDistanceFrom(ctx, qs, token, args, reply.Nodes); err != nil {
+			return nil, cache.ResultMeta{}, err
+		}
+
+		// Make sure that the node queried for is in position 0.
+		for i, node := range reply.Nodes {
+			if node.Node.Node == qs.Node {
+				reply.Nodes[0], reply.Nodes[i] = reply.Nodes[i], reply.Nodes[0]
+				break
+			}
+
+			// Put a cap on the depth of the search. The local agent should
+			// never be further in than this if distance sorting was applied.
+			if i == 9 {
+				break
+			}
+		}
+	}
+
+	// Apply the limit if given.
+	if args.Limit > 0 && len(reply.Nodes) > args.Limit {
+		reply.Nodes = reply.Nodes[:args.Limit]
+	}
+
+	// In the happy path where we found some healthy nodes we go with that
+	// and bail out. Otherwise, we fail over and try remote DCs, as allowed
+	// by the query setup.
+	if len(reply.Nodes) == 0 {
+		if err := c.failover(ctx, dc, query, args, token, reply); err != nil {
+			return nil, cache.ResultMeta{}, err
+		}
+	}
+	return reply, meta, nil
+}
+
+// explain returns the definition of the query, with its template rendered.
+func (c *Client) explain(ctx context.Context, args *structs.PreparedQueryExecuteRequest) (*structs.PreparedQuery, error) {
+	// Only the fields used to resolve the query are set, so that every
+	// execution of the query shares the cache entry.
+	req := &structs.PreparedQueryExecuteRequest{
+		Datacenter:    args.Datacenter,
+		QueryIDOrName: args.QueryIDOrName,
+		Agent:         args.Agent,
+		QueryOptions: structs.QueryOptions{
+			Token:  args.Token,
+			MaxAge: cacheMaxAge(args),
+		},
+	}
+	raw, _, err := c.Cache.Get(ctx, cachetype.PreparedQueryExplainName, req)
+	switch {
+	case acl.IsErrPermissionDenied(err):
+		// The token of the request is allowed to execute the query, but
+		// not to read it.
+		return nil, errNotLocal
+	case err != nil:
+		return nil, err
+	}
+	reply, ok := raw.(*structs.PreparedQueryExplainResponse)
+	if !ok {
+		// This should never happen, but we want to protect against panics
+		return nil, fmt.Errorf("internal error: response type not correct")
+	}
+
+	query := reply.Query
+	if query.Token == redactedToken {
+		// The query runs with a token which is not known by the agent.
+		return nil, errNotLocal
+	}
+	return &query, nil
+}
+
+// serviceNodes returns the instances of the service of the query in dc which
+// satisfy the query, like the execute method of the PreparedQuery endpoint.
+func (c *Client) serviceNodes(
+	ctx context.Context,
+	dc string,
+	query *structs.PreparedQuery,
+	args *structs.PreparedQueryExecuteRequest,
+	token string,
+) (*structs.PreparedQueryExecuteResponse, cache.ResultMeta, error) {
+	out, meta, err := c.Health.ServiceNodes(ctx, structs.ServiceSpecificRequest{
+		Datacenter:     dc,
+		ServiceName:    query.Service.Service,
+		Connect:        query.Service.Connect || args.Connect,
+		EnterpriseMeta: query.Service.EnterpriseMeta,
+		QueryOptions: structs.QueryOptions{
+			Token:            token,
+			UseCache:         true,
+			AllowStale:       args.AllowStale,
+			MaxStaleDuration: args.MaxStaleDuration,
+		},
+	})
+	if err != nil {
+		return nil, cache.ResultMeta{}, err
+	}
+
+	// The filters modify the slice, which may be shared with other results.
+	nodes := make(structs.CheckServiceNodes, len(out.Nodes))
+	copy(nodes, out.Nodes)
+
+	return &structs.PreparedQueryExecuteResponse{
+		Service:        query.Service.Service,
+		EnterpriseMeta: query.Service.EnterpriseMeta,
+		Nodes:          query.Service.FilterNodes(nodes),
+		DNS:            query.DNS,
+		Datacenter:     dc,
+		QueryMeta:      out.QueryMeta,
+	}, meta, nil
+}
+
+// querySource returns the source of the distance sort of the results. This
+// can be provided by the client, or by the prepared query. Client-specified
+// takes priority.
+func querySource(
+	args *structs.PreparedQueryExecuteRequest,
+	query *structs.PreparedQuery,
+	nodes structs.CheckServiceNodes,
+) (structs.QuerySource, error) {
+	qs := args.Source
+	if qs.Datacenter == "" {
+		qs.Datacenter = args.Agent.Datacenter
+	}
+	if query.Service.Near != "" && qs.Node == "" {
+		qs.Node = query.Service.Near
+	}
+
+	// Respect the magic "_agent" and "_ip" flags.
+	switch {
+	case qs.Node == "_agent":
+		qs.Node = args.Agent.Node
+	case qs.Node == "_ip" && args.Source.Ip != "":
+		// The servers look for the node with the source IP among every node
+		// of the catalog, which the agent does not have. The node is looked
+		// for in the results instead, and the query is executed by the
+		// servers when it is not one of them.
+		qs.Node = ""
+		for _, node := range nodes {
+			if node.Node.Address == args.Source.Ip {
+				qs.Node = node.Node.Node
+				break
+			}
+		}
+		if qs.Node == "" {
+			return qs, errNotLocal
+		}
+	case qs.Node == "_ip":
+		// No distance sorting is done without a source IP.
+		qs.Node = ""
+	}
+	return qs, nil
+}
+
+// failover tries the datacenters the query fails over to, in priority order,
+// until one of them returns some nodes. It sets the nodes of reply, and the
+// count of datacenters tried, like the failover of the PreparedQuery endpoint.
+func (c *Client) failover(
+	ctx context.Context,
+	dc string,
+	query *structs.PreparedQuery,
+	args *structs.PreparedQueryExecuteRequest,
+	token string,
+	reply *structs.PreparedQueryExecuteResponse,
+) error {
+	if query.Service.Failover.NearestN == 0 && len(query.Service.Failover.Datacenters) == 0 {
+		return nil
+	}
+
+	dcs, err := c.failoverDatacenters(ctx, dc, query, args)
+	if err != nil {
+		return err
+	}
+
+	failovers := 0
+	for _, remoteDC := range dcs {
+		// This keeps track of how many iterations we actually run.
+		failovers++
+
+		remote, _, err := c.serviceNodes(ctx, remoteDC, query, args, token)
+		if err != nil {
+			c.Logger.Warn("Failed querying for service in datacenter",
+				"service", query.Service.Service,
+				"datacenter", remoteDC,
+				"error", err,
+			)
+			continue
+		}
+
+		// We don't bother trying to do an RTT sort here since we are by
+		// definition in another DC. We just shuffle to make sure that we
+		// balance the load across the results.
+		remote.Nodes.Shuffle()
+		if args.Limit > 0 && len(remote.Nodes) > args.Limit {
+			remote.Nodes = remote.Nodes[:args.Limit]
+		}
+
+		// We can stop if we found some nodes.
+		if len(remote.Nodes) > 0 {
+			remote.Failovers = failovers
+			*reply = *remote
+			return nil
+		}
+	}
+	reply.Failovers = failovers
+	return nil
+}
+
+// failoverDatacenters returns the datacenters the query fails over to from dc,
+// starting with the nearest N by RTT, followed by the datacenters listed by the
+// query which are known to the servers.
+func (c *Client) failoverDatacenters(
+	ctx context.Context,
+	dc string,
+	query *structs.PreparedQuery,
+	args *structs.PreparedQueryExecuteRequest,
+) ([]string, error) {
+	raw, _, err := c.Cache.Get(ctx, cachetype.CatalogDatacentersName, &structs.DatacentersRequest{
+		QueryOptions: structs.QueryOptions{
+			Token:  args.Token,
+			MaxAge: cacheMaxAge(args),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+	all, ok := raw.(*[]string)
+	if !ok {
+		// This should never happen, but we want to protect against panics
+		return nil, fmt.Errorf("internal error: response type not correct")
+	}
+
+	// The list of other DCs is sorted by RTT in case the user has selected
+	// that.
+	var nearest []string
+	known := make(map[string]struct{})
+	for _, other := range *all {
+		if other != dc {
+			nearest = append(nearest, other)
+			known[other] = struct{}{}
+		}
+	}
+
+	var dcs []string
+	index := make(map[string]struct{})
+	for i, other := range nearest {
+		if !(i < query.Service.Failover.NearestN) {
+			break
+		}
+		dcs = append(dcs, other)
+		index[other] = struct{}{}
+	}
+
+	// Then add any DCs explicitly listed that weren't selected above.
+	for _, other := range query.Service.Failover.Datacenters {
+		if _, ok := known[other]; !ok {
+			c.Logger.Debug("Skipping unknown datacenter in prepared query", "datacenter", other)
+			continue
+		}
+		if _, ok := index[other]; !ok {
+			dcs = append(dcs, other)
+			index[other] = struct{}{}
+		}
+	}
+	return dcs, nil
+}
+
+// cacheMaxAge returns the MaxAge of the requests made to the agent cache to
+// execute args.
+func cacheMaxAge(args *structs.PreparedQueryExecuteRequest) time.Duration {
+	if args.MaxAge > 0 && args.MaxAge < maxAge {
+		return args.MaxAge
+	}
+	return maxAge
+}
@@ -0,0 +1,299 @@
+package preparedquery
+
+import (
+	"context"
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/cache"
+	cachetype "github.com/hashicorp/consul/agent/cache-types"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/lib"
+)
+
+func TestClient_Execute_BackendRouting(t *testing.T) {
+	query := structs.PreparedQuery{
+		Name:    "web",
+		Service: structs.ServiceQuery{Service: "web"},
+	}
+	c := newClient(query, map[string]structs.CheckServiceNodes{
+		"dc1": {newNode("node1", "web")},
+	})
+
+	t.Run("cached server results without the streaming backend", func(t *testing.T) {
+		c.UseStreamingBackend = false
+		defer func() { c.UseStreamingBackend = true }()
+
+		reply, _, err := c.Execute(context.Background(), newRequest())
+		require.NoError(t, err)
+		require.Equal(t, "server", reply.Service)
+		require.Empty(t, c.Health.(*fakeHealth).calls)
+	})
+
+	t.Run("cached server results for consistent requests", func(t *testing.T) {
+		req := newRequest()
+		req.RequireConsistent = true
+
+		reply, _, err := c.Execute(context.Background(), req)
+		require.NoError(t, err)
+		require.Equal(t, "server", reply.Service)
+	})
+
+	t.Run("streaming", func(t *testing.T) {
+		reply, _, err := c.Execute(context.Background(), newRequest())
+		require.NoError(t, err)
+		require.Equal(t, "web", reply.Service)
+		require.Equal(t, "dc1", reply.Datacenter)
+		require.Equal(t, []string{"node1"}, nodeNames(reply.Nodes))
+
+		calls := c.Health.(*fakeHealth).calls
+		require.Len(t, calls, 1)
+		require.True(t, calls[0].UseCache)
+		require.Equal(t, "a-token", calls[0].Token)
+	})
+
+	t.Run("the token of the query is used to read the service", func(t *testing.T) {
+		c := newClient(structs.PreparedQuery{
+			Service: structs.ServiceQuery{Service: "web"},
+			Token:   "query-token",
+		}, nil)
+
+		_, _, err := c.Execute(context.Background(), newRequest())
+		require.NoError(t, err)
+		require.Equal(t, "query-token", c.Health.(*fakeHealth).calls[0].Token)
+	})
+
+	t.Run("queries with a hidden token are executed by the servers", func(t *testing.T) {
+		c := newClient(structs.PreparedQuery{
+			Service: structs.ServiceQuery{Service: "web"},
+			Token:   redactedToken,
+		}, nil)
+
+		reply, _, err := c.Execute(context.Background(), newRequest())
+		require.NoError(t, err)
+		require.Equal(t, "server", reply.Service)
+	})
+
+	t.Run("queries which can not be read are executed by the servers", func(t *testing.T) {
+		c := newClient(query, nil)
+		c.Cache.(*fakeCache).explainErr = acl.ErrPermissionDenied
+
+		reply, _, err := c.Execute(context.Background(), newRequest())
+		require.NoError(t, err)
+		require.Equal(t, "server", reply.Service)
+	})
+
+	t.Run("unknown queries", func(t *testing.T) {
+		c := newClient(query, nil)
+		c.Cache.(*fakeCache).explainErr = structs.ErrQueryNotFound
+
+		_, _, err := c.Execute(context.Background(), newRequest())
+		require.True(t, structs.IsErrQueryNotFound(err))
+		require.Equal(t, []string{cachetype.PreparedQueryExplainName}, c.Cache.(*fakeCache).calls)
+	})
+}
+
+func TestClient_Execute_Filters(t *testing.T) {
+	c := newClient(structs.PreparedQuery{
+		Service: structs.ServiceQuery{
+			Service: "web",
+			Tags:    []string{"!canary"},
+		},
+	}, map[string]structs.CheckServiceNodes{
+		"dc1": {
+			newNode("node1", "web"),
+			newNode("node2", "web", "canary"),
+			newNode("node3", "web"),
+		},
+	})
+
+	req := newRequest()
+	req.Limit = 1
+	reply, _, err := c.Execute(context.Background(), req)
+	require.NoError(t, err)
+	require.Len(t, reply.Nodes, 1)
+	require.NotEqual(t, "node2", reply.Nodes[0].Node.Node)
+
+	// The results of the health client are not modified.
+	require.Len(t, c.Health.(*fakeHealth).nodes["dc1"], 3)
+}
+
+func TestClient_Execute_SortByDistance(t *testing.T) {
+	c := newClient(structs.PreparedQuery{
+		Service: structs.ServiceQuery{Service: "web", Near: "_agent"},
+	}, map[string]structs.CheckServiceNodes{
+		"dc1": {
+			newNode("node1", "web"),
+			newNode("node2", "web"),
+			newNode("node3", "web"),
+			newNode("node4", "web"),
+		},
+	})
+	c.Cache.(*fakeCache).coordinates = structs.Coordinates{
+		{Node: "agent", Coord: lib.GenerateCoordinate(0)},
+		{Node: "node1", Coord: lib.GenerateCoordinate(30 * time.Millisecond)},
+		{Node: "node3", Coord: lib.GenerateCoordinate(10 * time.Millisecond)},
+		{Node: "node4", Coord: lib.GenerateCoordinate(20 * time.Millisecond)},
+	}
+
+	for i := 0; i < 10; i++ {
+		reply, _, err := c.Execute(context.Background(), newRequest())
+		require.NoError(t, err)
+		require.Equal(t, []string{"node3", "node4", "node1", "node2"}, nodeNames(reply.Nodes))
+	}
+
+	t.Run("the source node goes first", func(t *testing.T) {
+		req := newRequest()
+		req.Source = structs.QuerySource{Node: "node4"}
+
+		reply, _, err := c.Execute(context.Background(), req)
+		require.NoError(t, err)
+		require.Equal(t, "node4", reply.Nodes[0].Node.Node)
+	})
+
+	t.Run("the source node from an IP", func(t *testing.T) {
+		req := newRequest()
+		req.Source = structs.QuerySource{Node: "_ip", Ip: "node1-addr"}
+
+		reply, _, err := c.Execute(context.Background(), req)
+		require.NoError(t, err)
+		require.Equal(t, []string{"node1", "node4", "node3", "node2"}, nodeNames(reply.Nodes))
+	})
+
+	t.Run("an IP which is not one of the results", func(t *testing.T) {
+		req := newRequest()
+		req.Source = structs.QuerySource{Node: "_ip", Ip: "other-addr"}
+
+		reply, _, err := c.Execute(context.Background(), req)
+		require.NoError(t, err)
+		require.Equal(t, "server", reply.Service)
+	})
+}
+
+func TestClient_Execute_Failover(t *testing.T) {
+	c := newClient(structs.PreparedQuery{
+		Service: structs.ServiceQuery{
+			Service: "web",
+			Failover: structs.QueryDatacenterOptions{
+				NearestN:    1,
+				Datacenters: []string{"dc4", "dc3", "unknown"},
+			},
+		},
+	}, map[string]structs.CheckServiceNodes{
+		"dc3": {newNode("node1", "web")},
+		"dc4": {newNode("node2", "web")},
+	})
+	c.Cache.(*fakeCache).datacenters = []string{"dc1", "dc2", "dc3", "dc4"}
+	health := c.Health.(*fakeHealth)
+	health.errs = map[string]error{"dc4": fmt.Errorf("dc4 is unavailable")}
+
+	reply, _, err := c.Execute(context.Background(), newRequest())
+	require.NoError(t, err)
+	require.Equal(t, "dc3", reply.Datacenter)
+	require.Equal(t, 3, reply.Failovers)
+	require.Equal(t, []string{"node1"}, nodeNames(reply.Nodes))
+
+	var dcs []string
+	for _, call := range health.calls {
+		dcs = append(dcs, call.Datacenter)
+	}
+	require.Equal(t, []string{"dc1", "dc2", "dc4", "dc3"}, dcs)
+
+	t.Run("no datacenter has nodes", func(t *testing.T) {
+		health.nodes = nil
+
+		reply, _, err := c.Execute(context.Background(), newRequest())
+		require.NoError(t, err)
+		require.Empty(t, reply.Nodes)
+		require.Equal(t, "dc1", reply.Datacenter)
+		require.Equal(t, 3, reply.Failovers)
+	})
+}
+
+func newClient(query structs.PreparedQuery, nodes map[string]structs.CheckServiceNodes) *Client {
+	return &Client{
+		Cache:               &fakeCache{query: query},
+		Health:              &fakeHealth{nodes: nodes},
+		UseStreamingBackend: true,
+		Logger:              hclog.NewNullLogger(),
+	}
+}
+
+func newRequest() *structs.PreparedQueryExecuteRequest {
+	return &structs.PreparedQueryExecuteRequest{
+		QueryIDOrName: "web",
+		Agent:         structs.QuerySource{Datacenter: "dc1", Node: "agent"},
+		QueryOptions:  structs.QueryOptions{Token: "a-token", UseCache: true},
+	}
+}
+
+func newNode(name string, service string, tags ...string) structs.CheckServiceNode {
+	return structs.CheckServiceNode{
+		Node:    &structs.Node{Node: name, Address: name + "-addr"},
+		Service: &structs.NodeService{ID: service, Service: service, Tags: tags},
+	}
+}
+
+func nodeNames(nodes structs.CheckServiceNodes) []string {
+	var names []string
+	for _, node := range nodes {
+		names = append(names, node.Node.Node)
+	}
+	return names
+}
+
+type fakeCache struct {
+	query       structs.PreparedQuery
+	explainErr  error
+	coordinates structs.Coordinates
+	datacenters []string
+	calls       []string
+}
+
+func (f *fakeCache) Get(_ context.Context, t string, _ cache.Request) (interface{}, cache.ResultMeta, error) {
+	f.calls = append(f.calls, t)
+	switch t {
+	case cachetype.PreparedQueryName:
+		return &structs.PreparedQueryExecuteResponse{Service: "server"}, cache.ResultMeta{}, nil
+	case cachetype.PreparedQueryExplainName:
+		if f.explainErr != nil {
+			return nil, cache.ResultMeta{}, f.explainErr
+		}
+		return &structs.PreparedQueryExplainResponse{Query: f.query}, cache.ResultMeta{}, nil
+	case cachetype.NodeCoordinatesName:
+		return &structs.IndexedCoordinates{Coordinates: f.coordinates}, cache.ResultMeta{}, nil
+	case cachetype.CatalogDatacentersName:
+		dcs := f.datacenters
+		return &dcs, cache.ResultMeta{}, nil
+	default:
+		return nil, cache.ResultMeta{}, fmt.Errorf("unexpected cache type %v", t)
+	}
+}
+
+type fakeHealth struct {
+	nodes map[string]structs.CheckServiceNodes
+	errs  map[string]error
+	calls []structs.ServiceSpecificRequest
+}
+
+func (f *fakeHealth) ServiceNodes(
+	_ context.Context,
+	req structs.ServiceSpecificRequest,
+) (structs.IndexedCheckServiceNodes, cache.ResultMeta, error) {
+	f.calls = append(f.calls, req)
+	if err := f.errs[req.Datacenter]; err != nil {
+		return structs.IndexedCheckServiceNodes{}, cache.ResultMeta{}, err
+	}
+	var nodes structs.CheckServiceNodes
+	for _, node := range f.nodes[req.Datacenter] {
+		if node.Service.Service == req.ServiceName {
+			nodes = append(nodes, node)
+		}
+	}
+	return structs.IndexedCheckServiceNodes{Nodes: nodes}, cache.ResultMeta{}, nil
+}
@@ -0,0 +1,103 @@
+package preparedquery
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	cachetype "github.com/hashicorp/consul/agent/cache-types"
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/lib"
+)
+
+// nodeKey identifies the coordinates of a node.
+type nodeKey struct {
+	node      string
+	partition string
+}
+
+// sortByDistanceFrom sorts nodes by the round trip time from the source node,
+// with the coordinates of the nodes cached by the agent. Nodes with missing
+// coordinates get stable sorted at the end of the list, like the servers
+// sort them.
+func (c *Client) sortByDistanceFrom(
+	ctx context.Context,
+	source structs.QuerySource,
+	token string,
+	args *structs.PreparedQueryExecuteRequest,
+	nodes structs.CheckServiceNodes,
+) error {
+	raw, _, err := c.Cache.Get(ctx, cachetype.NodeCoordinatesName, &structs.DCSpecificRequest{
+		Datacenter: source.Datacenter,
+		QueryOptions: structs.QueryOptions{
+			Token:  token,
+			MaxAge: cacheMaxAge(args),
+		},
+	})
+	if err != nil {
+		return err
+	}
+	reply, ok := raw.(*structs.IndexedCoordinates)
+	if !ok {
+		// This should never happen, but we want to protect against panics
+		return fmt.Errorf("internal error: response type not correct")
+	}
+
+	coords := make(map[nodeKey]lib.CoordinateSet)
+	for _, coord := range reply.Coordinates {
+		key := nodeKey{
+			node:      coord.Node,
+			partition: coord.GetEnterpriseMeta().PartitionOrDefault(),
+		}
+		if coords[key] == nil {
+			coords[key] = make(lib.CoordinateSet)
+		}
+		coords[key][coord.Segment] = coord.Coord
+	}
+
+	// There won't always be coordinates for the source node. If there are
+	// none then we can bail out because there's no meaning for the sort.
+	cs := coords[nodeKey{
+		node:      source.Node,
+		partition: source.NodeEnterpriseMeta().PartitionOrDefault(),
+	}]
+	if len(cs) == 0 {
+		return nil
+	}
+
+	vec := make([]float64, len(nodes))
+	for i, node := range nodes {
+		other := coords[nodeKey{
+			node:      node.Node.Node,
+			partition: node.Node.GetEnterpriseMeta().PartitionOrDefault(),
+		}]
+		c1, c2 := cs.Intersect(other)
+		vec[i] = lib.ComputeDistance(c1, c2)
+	}
+	sort.Stable(&nodeSorter{nodes: nodes, vec: vec})
+	return nil
+}
+
+// nodeSorter takes a list of service nodes and a parallel vector of distances
+// and implements sort.Interface, keeping both structures coherent and sorting
+// by distance.
+type nodeSorter struct {
+	nodes structs.CheckServiceNodes
+	vec   []float64
+}
+
+// See sort.Interface.
+func (n *nodeSorter) Len() int {
+	return len(n.nodes)
+}
+
+// See sort.Interface.
+func (n *nodeSorter) Swap(i, j int) {
+	n.nodes[i], n.nodes[j] = n.nodes[j], n.nodes[i]
+	n.vec[i], n.vec[j] = n.vec[j], n.vec[i]
+}
+
+// See sort.Interface.
+func (n *nodeSorter) Less(i, j int) bool {
+	return n.vec[i] < n.vec[j]
+}
@@ -25,6 +25,11 @@ import (
 	"github.com/hashicorp/consul/agent/local"
 	"github.com/hashicorp/consul/agent/pool"
 	"github.com/hashicorp/consul/agent/router"
+	"github.com/hashicorp/consul/agent/rpcclient/catalog"
+	"github.com/hashicorp/consul/agent/rpcclient/configentry"
+	"github.com/hashicorp/consul/agent/rpcclient/intention"
+	"github.com/hashicorp/consul/agent/rpcclient/kv"
+	"github.com/hashicorp/consul/agent/rpcclient/node"
 	"github.com/hashicorp/consul/agent/submatview"
 	"github.com/hashicorp/consul/agent/token"
 	"github.com/hashicorp/consul/agent/xds"
@@ -104,7 +109,11 @@ func NewBaseDeps(configLoader ConfigLoader, logOut io.Writer) (BaseDeps, error)
 	cfg.Cache.Logger = d.Logger.Named("cache")
 	// cache-types are not registered yet, but they won't be used until the components are started.
 	d.Cache = cache.New(cfg.Cache)
-	d.ViewStore = submatview.NewStore(d.Logger.Named("viewstore"))
+	cfg.ViewStore.Failover.LocalDatacenter = cfg.Datacenter
+	d.ViewStore = submatview.NewStore(d.Logger.Named("viewstore"), cfg.ViewStore)
+	if err := registerViews(d.ViewStore); err != nil {
+		return d, err
+	}
 	d.ConnPool = newConnPool(cfg, d.Logger, d.TLSConfigurator)
 
 	builder := resolver.NewServerResolverBuilder(resolver.Config{
@@ -122,6 +131,8 @@ func NewBaseDeps(configLoader ConfigLoader, logOut io.Writer) (BaseDeps, error)
 		UseTLSForDC:           d.TLSConfigurator.UseTLS,
 		DialingFromServer:     cfg.ServerMode,
 		DialingFromDatacenter: cfg.Datacenter,
+		KeepaliveInterval:     cfg.StreamingKeepaliveInterval,
+		KeepaliveTimeout:      cfg.StreamingKeepaliveTimeout,
 	})
 	d.LeaderForwarder = builder
 
@@ -157,6 +168,24 @@ func NewBaseDeps(configLoader ConfigLoader, logOut io.Writer) (BaseDeps, error)
 // causes data races when it is re-initialized.
 var grpcLogInitOnce sync.Once
 
+// registerViews registers the views used by the streaming clients of the agent.
+// They are registered once for the store, because the store is shared by every
+// agent created with the same BaseDeps.
+func registerViews(store *submatview.Store) error {
+	for _, register := range []func(*submatview.Store) error{
+		kv.RegisterView,
+		catalog.RegisterView,
+		intention.RegisterView,
+		configentry.RegisterView,
+		node.RegisterView,
+	} {
+		if err := register(store); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func newConnPool(config *config.RuntimeConfig, logger hclog.Logger, tls *tlsutil.Configurator) *pool.ConnPool {
 	var rpcSrcAddr *net.TCPAddr
 	if !ipaddr.IsAny(config.RPCBindAddr) {
@@ -208,6 +237,7 @@ func getPrometheusDefs(cfg lib.TelemetryConfig, isServer bool) ([]prometheus.Gau
 		consul.RPCGauges,
 		consul.SessionGauges,
 		grpc.StatsGauges,
+		submatview.Gauges,
 		xds.StatsGauges,
 		usagemetrics.Gauges,
 		consul.ReplicationGauges,
@@ -263,6 +293,7 @@ func getPrometheusDefs(cfg lib.TelemetryConfig, isServer bool) ([]prometheus.Gau
 		consul.RPCCounters,
 		grpc.StatsCounters,
 		local.StateCounters,
+		submatview.Counters,
 		raftCounters,
 	}
 	// Flatten definitions
@@ -316,6 +347,7 @@ func getPrometheusDefs(cfg lib.TelemetryConfig, isServer bool) ([]prometheus.Gau
 		consul.TxnSummaries,
 		fsm.CommandsSummaries,
 		fsm.SnapshotSummaries,
+		submatview.Summaries,
 		raftSummaries,
 	}
 	// Flatten definitions
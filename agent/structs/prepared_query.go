@@ -2,6 +2,7 @@ package structs
 
 import (
 	"strconv"
+	"strings"
 
 	"github.com/hashicorp/consul/agent/cache"
 	"github.com/hashicorp/consul/types"
@@ -81,6 +82,107 @@ type ServiceQuery struct {
 	EnterpriseMeta `hcl:",squash" mapstructure:",squash"`
 }
 
+// FilterNodes returns the nodes which satisfy the query: the nodes which are
+// healthy, as configured by OnlyPassing and IgnoreCheckIDs, and match the
+// NodeMeta, ServiceMeta, and Tags filters. Note for performance this modifies
+// the original slice.
+func (q *ServiceQuery) FilterNodes(nodes CheckServiceNodes) CheckServiceNodes {
+	// Filter out any unhealthy nodes.
+	nodes = nodes.FilterIgnore(q.OnlyPassing, q.IgnoreCheckIDs)
+
+	// Apply the node metadata filters, if any.
+	if len(q.NodeMeta) > 0 {
+		nodes = NodeMetaFilter(q.NodeMeta, nodes)
+	}
+
+	// Apply the service metadata filters, if any.
+	if len(q.ServiceMeta) > 0 {
+		nodes = serviceMetaFilter(q.ServiceMeta, nodes)
+	}
+
+	// Apply the tag filters, if any.
+	if len(q.Tags) > 0 {
+		nodes = tagFilter(q.Tags, nodes)
+	}
+	return nodes
+}
+
+// tagFilter returns a list of nodes who satisfy the given tags. Nodes must have
+// ALL the given tags, and NONE of the forbidden tags (prefixed with !). Note
+// for performance this modifies the original slice.
+func tagFilter(tags []string, nodes CheckServiceNodes) CheckServiceNodes {
+	// Build up lists of required and disallowed tags.
+	must, not := make([]string, 0), make([]string, 0)
+	for _, tag := range tags {
+		tag = strings.ToLower(tag)
+		if strings.HasPrefix(tag, "!") {
+			tag = tag[1:]
+			not = append(not, tag)
+		} else {
+			must = append(must, tag)
+		}
+	}
+
+	n := len(nodes)
+	for i := 0; i < n; i++ {
+		node := nodes[i]
+
+		// Index the tags so lookups this way are cheaper.
+		index := make(map[string]struct{})
+		if node.Service != nil {
+			for _, tag := range node.Service.Tags {
+				tag = strings.ToLower(tag)
+				index[tag] = struct{}{}
+			}
+		}
+
+		// Bail if any of the required tags are missing.
+		for _, tag := range must {
+			if _, ok := index[tag]; !ok {
+				goto DELETE
+			}
+		}
+
+		// Bail if any of the disallowed tags are present.
+		for _, tag := range not {
+			if _, ok := index[tag]; ok {
+				goto DELETE
+			}
+		}
+
+		// At this point, the service is ok to leave in the list.
+		continue
+
+	DELETE:
+		nodes[i], nodes[n-1] = nodes[n-1], CheckServiceNode{}
+		n--
+		i--
+	}
+	return nodes[:n]
+}
+
+// NodeMetaFilter returns a list of the nodes who satisfy the given metadata filters. Nodes
+// must have ALL the given tags.
+func NodeMetaFilter(filters map[string]string, nodes CheckServiceNodes) CheckServiceNodes {
+	var filtered CheckServiceNodes
+	for _, node := range nodes {
+		if SatisfiesMetaFilters(node.Node.Meta, filters) {
+			filtered = append(filtered, node)
+		}
+	}
+	return filtered
+}
+
+func serviceMetaFilter(filters map[string]string, nodes CheckServiceNodes) CheckServiceNodes {
+	var filtered CheckServiceNodes
+	for _, node := range nodes {
+		if SatisfiesMetaFilters(node.Service.Meta, filters) {
+			filtered = append(filtered, node)
+		}
+	}
+	return filtered
+}
+
 const (
 	// QueryTemplateTypeNamePrefixMatch uses the Name field of the query as
 	// a prefix to select the template.
@@ -1,6 +1,8 @@
 package structs
 
 import (
+	"sort"
+	"strings"
 	"testing"
 )
 
@@ -33,3 +35,95 @@ func TestPreparedQueryExecuteRequest_CacheInfoKey(t *testing.T) {
 	ignored := []string{"Agent", "QueryOptions"}
 	assertCacheInfoKeyIsComplete(t, &PreparedQueryExecuteRequest{}, ignored...)
 }
+
+func TestPreparedQuery_tagFilter(t *testing.T) {
+	t.Parallel()
+	testNodes := func() CheckServiceNodes {
+		return CheckServiceNodes{
+			CheckServiceNode{
+				Node:    &Node{Node: "node1"},
+				Service: &NodeService{Tags: []string{"foo"}},
+			},
+			CheckServiceNode{
+				Node:    &Node{Node: "node2"},
+				Service: &NodeService{Tags: []string{"foo", "BAR"}},
+			},
+			CheckServiceNode{
+				Node: &Node{Node: "node3"},
+			},
+			CheckServiceNode{
+				Node:    &Node{Node: "node4"},
+				Service: &NodeService{Tags: []string{"foo", "baz"}},
+			},
+			CheckServiceNode{
+				Node:    &Node{Node: "node5"},
+				Service: &NodeService{Tags: []string{"foo", "zoo"}},
+			},
+			CheckServiceNode{
+				Node:    &Node{Node: "node6"},
+				Service: &NodeService{Tags: []string{"bar"}},
+			},
+		}
+	}
+
+	// This always sorts so that it's not annoying to compare after the swap
+	// operations that the algorithm performs.
+	stringify := func(nodes CheckServiceNodes) string {
+		var names []string
+		for _, node := range nodes {
+			names = append(names, node.Node.Node)
+		}
+		sort.Strings(names)
+		return strings.Join(names, "|")
+	}
+
+	ret := stringify(tagFilter([]string{}, testNodes()))
+	if ret != "node1|node2|node3|node4|node5|node6" {
+		t.Fatalf("bad: %s", ret)
+	}
+
+	ret = stringify(tagFilter([]string{"foo"}, testNodes()))
+	if ret != "node1|node2|node4|node5" {
+		t.Fatalf("bad: %s", ret)
+	}
+
+	ret = stringify(tagFilter([]string{"!foo"}, testNodes()))
+	if ret != "node3|node6" {
+		t.Fatalf("bad: %s", ret)
+	}
+
+	ret = stringify(tagFilter([]string{"!foo", "bar"}, testNodes()))
+	if ret != "node6" {
+		t.Fatalf("bad: %s", ret)
+	}
+
+	ret = stringify(tagFilter([]string{"!foo", "!bar"}, testNodes()))
+	if ret != "node3" {
+		t.Fatalf("bad: %s", ret)
+	}
+
+	ret = stringify(tagFilter([]string{"nope"}, testNodes()))
+	if ret != "" {
+		t.Fatalf("bad: %s", ret)
+	}
+
+	ret = stringify(tagFilter([]string{"bar"}, testNodes()))
+	if ret != "node2|node6" {
+		t.Fatalf("bad: %s", ret)
+	}
+
+	ret = stringify(tagFilter([]string{"BAR"}, testNodes()))
+	if ret != "node2|node6" {
+		t.Fatalf("bad: %s", ret)
+	}
+
+	ret = stringify(tagFilter([]string{"bAr"}, testNodes()))
+	if ret != "node2|node6" {
+		t.Fatalf("bad: %s", ret)
+	}
+
+	ret = stringify(tagFilter([]string{""}, testNodes()))
+	if ret != "" {
+		t.Fatalf("bad: %s", ret)
+	}
+}
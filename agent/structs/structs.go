@@ -632,6 +632,12 @@ type ServiceSpecificRequest struct {
 	// Ingress if true will only search for Ingress gateways for the given service.
 	Ingress bool
 
+	// PassingOnly if true will only return the instances whose checks are all
+	// passing. It is applied by the agent, not by the servers. The
+	// materialized views of the streaming backend only store the passing
+	// instances.
+	PassingOnly bool
+
 	EnterpriseMeta `hcl:",squash" mapstructure:",squash"`
 	QueryOptions
 }
@@ -658,7 +664,7 @@ func (r *ServiceSpecificRequest) CacheInfo() cache.RequestInfo {
 	// here. We could alternatively use `hash:set` struct tag on an anonymous
 	// struct to make it more robust if it becomes significant.
 	sort.Strings(r.ServiceTags)
-	v, err := hashstructure.Hash([]interface{}{
+	fields := []interface{}{
 		r.NodeMetaFilters,
 		strings.ToLower(r.ServiceName),
 		// DEPRECATED (singular-service-tag) - remove this when upgrade RPC compat
@@ -677,7 +683,13 @@ func (r *ServiceSpecificRequest) CacheInfo() cache.RequestInfo {
 		r.EnterpriseMeta,
 		r.Ingress,
 		r.ServiceKind,
-	}, nil)
+	}
+	// PassingOnly is only hashed when it is set, so that the keys of other
+	// requests are unchanged.
+	if r.PassingOnly {
+		fields = append(fields, r.PassingOnly)
+	}
+	v, err := hashstructure.Hash(fields, nil)
 	if err == nil {
 		// If there is an error, we don't set the key. A blank key forces
 		// no cache for this request so the request is forwarded directly
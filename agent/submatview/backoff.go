@@ -0,0 +1,100 @@
+package submatview
+
+import (
+	"sync"
+	"time"
+
+	"github.com/armon/go-metrics"
+
+	"github.com/hashicorp/consul/lib/retry"
+)
+
+// Backoff configures how a Materializer waits before it subscribes again after
+// the subscription failed.
+type Backoff struct {
+	// InitialWait is the wait after the second consecutive failure. The wait
+	// doubles with each consecutive failure after that, up to MaxWait. The
+	// first failure is retried immediately.
+	InitialWait time.Duration
+
+	// MaxWait is the maximum wait between attempts.
+	MaxWait time.Duration
+
+	// Jitter is the maximum percentage of the wait that is added to it at
+	// random, so that many Materializers which failed at the same time do
+	// not retry at the same time. A value of 0 disables jitter.
+	Jitter int
+
+	// ResetAfter is how long a subscription must be connected before the
+	// events it receives reset the count of consecutive failures. A value of
+	// 0 resets the count on the first event.
+	ResetAfter time.Duration
+
+	// CircuitBreakerThreshold is the number of consecutive failures after
+	// which the circuit breaker opens. While it is open the Materializer waits
+	// CircuitBreakerCooldown between attempts. The circuit breaker closes when
+	// a subscription resets the count of consecutive failures. A value of 0
+	// disables the circuit breaker.
+	CircuitBreakerThreshold int
+
+	// CircuitBreakerCooldown is the wait between attempts while the circuit
+	// breaker is open. Defaults to MaxWait.
+	CircuitBreakerCooldown time.Duration
+}
+
+// DefaultBackoff is the Backoff used when none is configured. Backing off
+// starts with small increments (200-400ms) which double each attempt
+// (200-400, 400-800, 800-1600, 1600-3200, 3200-6000, 6000 after that). The
+// Waiter applies MaxWait after jitter.
+var DefaultBackoff = Backoff{
+	InitialWait: 200 * time.Millisecond,
+	MaxWait:     60 * time.Second,
+	Jitter:      100,
+}
+
+// withDefaults returns b with DefaultBackoff applied. The zero value is
+// replaced by DefaultBackoff, otherwise only unset wait times are defaulted.
+func (b Backoff) withDefaults() Backoff {
+	if b == (Backoff{}) {
+		return DefaultBackoff
+	}
+	if b.InitialWait == 0 {
+		b.InitialWait = DefaultBackoff.InitialWait
+	}
+	if b.MaxWait == 0 {
+		b.MaxWait = DefaultBackoff.MaxWait
+	}
+	if b.CircuitBreakerCooldown == 0 {
+		b.CircuitBreakerCooldown = b.MaxWait
+	}
+	return b
+}
+
+// waiter returns a retry.Waiter which implements the backoff.
+func (b Backoff) waiter() *retry.Waiter {
+	w := &retry.Waiter{
+		MinFailures: 1,
+		Factor:      b.InitialWait,
+		MinWait:     0,
+		MaxWait:     b.MaxWait,
+	}
+	if b.Jitter > 0 {
+		w.Jitter = retry.NewJitter(int64(b.Jitter))
+	}
+	return w
+}
+
+// openCircuits is the number of Materializers with an open circuit breaker,
+// reported by the submatview.materializer.circuits_open gauge.
+var openCircuits = struct {
+	sync.Mutex
+	count int
+}{}
+
+func updateOpenCircuits(delta int) {
+	openCircuits.Lock()
+	defer openCircuits.Unlock()
+	openCircuits.count += delta
+	metrics.SetGauge([]string{"submatview", "materializer", "circuits_open"},
+		float32(openCircuits.count))
+}
@@ -0,0 +1,100 @@
+package submatview
+
+import (
+	"time"
+
+	"github.com/hashicorp/consul/proto/pbsubscribe"
+)
+
+// Debounce configures how a Materializer accumulates the events it receives
+// before it applies them to the View.
+type Debounce struct {
+	// Window is how long events are accumulated after the first event of a
+	// batch is received. All the events received in the window are applied to
+	// the View in a single update, and watchers are notified once. A value of
+	// 0 applies every event as soon as it is received.
+	Window time.Duration
+
+	// BypassSnapshot applies the first snapshot of a subscription as soon as
+	// it is received, so that the first request for a view is not delayed by
+	// Window.
+	BypassSnapshot bool
+}
+
+// pendingEvents are the events received by a Materializer which have not yet
+// been applied to the View because of Debounce.
+type pendingEvents struct {
+	events []*pbsubscribe.Event
+	// index of the view after the events are applied, or 0 if there are no
+	// pending events.
+	index uint64
+	// received is the time the first pending event was received.
+	received time.Time
+	timer    *time.Timer
+}
+
+// debounceLocked returns true if the events should be added to the pending
+// events instead of being applied immediately. Must be called while holding
+// m.lock.
+func (m *Materializer) debounceLocked() bool {
+	debounce := m.deps.Debounce
+	if debounce.Window <= 0 {
+		return false
+	}
+	isSnapshot := m.index == 0 && m.pending.index == 0
+	return !isSnapshot || !debounce.BypassSnapshot
+}
+
+// addPendingLocked adds events to the pending events, and starts the timer
+// which applies them when the debounce window ends. Must be called while
+// holding m.lock.
+func (m *Materializer) addPendingLocked(events []*pbsubscribe.Event, index uint64) {
+	if m.pending.index == 0 {
+		m.pending.received = m.received
+		m.pending.timer = time.AfterFunc(m.deps.Debounce.Window, m.flushPending)
+	}
+	m.pending.events = append(m.pending.events, events...)
+	m.pending.index = index
+}
+
+// flushPending applies the pending events to the View.
+func (m *Materializer) flushPending() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.flushPendingLocked()
+}
+
+// flushPendingLocked applies the pending events to the View. If the View fails
+// to apply them, the view is reset and the subscription is restarted. Must be
+// called while holding m.lock.
+func (m *Materializer) flushPendingLocked() {
+	pending := m.pending
+	m.clearPendingLocked()
+	if pending.index == 0 {
+		return
+	}
+
+	if err := m.applyLocked(pending.events, pending.index, pending.received); err != nil {
+		m.deps.Logger.Error("failed to update view with debounced events",
+			"err", err,
+			"topic", m.topic)
+		m.view.Reset()
+		m.updateSizeLocked()
+		m.index = 0
+		m.history.reset()
+		m.resetHashLocked()
+		if m.cancelSub != nil {
+			m.resubscribe = true
+			m.cancelSub()
+		}
+	}
+}
+
+// clearPendingLocked discards the pending events. Must be called while holding
+// m.lock.
+func (m *Materializer) clearPendingLocked() {
+	if m.pending.timer != nil {
+		m.pending.timer.Stop()
+	}
+	m.pending = pendingEvents{}
+}
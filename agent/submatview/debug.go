@@ -0,0 +1,98 @@
+package submatview
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// SnapshotOptions configures the snapshot returned by Store.Snapshot.
+type SnapshotOptions struct {
+	// Redact replaces the values of the fields of every entry which identify
+	// the data of the entry with a hash: the Key, which may be the name of a
+	// service or a KV key, the Datacenter, the FailoverDatacenter, the
+	// Partition, and the Namespace, except for the WildcardNamespace. The
+	// values are also removed from the errors of the entries. The Type, the
+	// Topic, and the other fields of the entries are kept.
+	//
+	// The hash of a value is only stable within a snapshot, so that the
+	// entries of a key or a datacenter can still be correlated, but the value
+	// can not be guessed by hashing candidate values.
+	Redact bool
+}
+
+// StoreSnapshot is the state of a Store encoded by Store.Snapshot.
+type StoreSnapshot struct {
+	// Time the snapshot was taken.
+	Time time.Time
+	// Redacted is true if the entries were redacted, see SnapshotOptions.Redact.
+	Redacted bool
+	// SizeBytes is the approximate number of bytes used by the views of the
+	// entries. See Store.SizeBytes.
+	SizeBytes int64
+	Entries   []EntryInfo
+}
+
+// Snapshot returns the state of every entry in the Store, encoded as JSON, for
+// debug bundles such as the ones captured by consul debug. Like Entries, the
+// ACL tokens of the requests are never included.
+func (s *Store) Snapshot(opts SnapshotOptions) ([]byte, error) {
+	snap := StoreSnapshot{
+		Time:      time.Now().UTC(),
+		Redacted:  opts.Redact,
+		SizeBytes: s.SizeBytes(),
+		Entries:   s.Entries(),
+	}
+	if opts.Redact {
+		if err := redactEntries(snap.Entries); err != nil {
+			return nil, err
+		}
+	}
+	return json.Marshal(snap)
+}
+
+// redactEntries replaces the fields of every entry listed by
+// SnapshotOptions.Redact with an HMAC of their value, using a random secret
+// which is discarded once the entries are redacted.
+func redactEntries(entries []EntryInfo) error {
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return fmt.Errorf("failed to generate the secret used to redact keys: %w", err)
+	}
+
+	// The kind of a value is part of its hash, so that a Key and a
+	// Namespace with the same value are not correlated.
+	redact := func(kind, value string) string {
+		mac := hmac.New(sha256.New, secret)
+		mac.Write([]byte(kind + "/" + value))
+		return "redacted:" + hex.EncodeToString(mac.Sum(nil))[:16]
+	}
+
+	for i := range entries {
+		e := &entries[i]
+		var replacements []string
+		field := func(kind string, value *string) {
+			if *value == "" || (kind == "namespace" && *value == WildcardNamespace) {
+				return
+			}
+			redacted := redact(kind, *value)
+			replacements = append(replacements, *value, redacted)
+			*value = redacted
+		}
+		field("key", &e.Key)
+		field("datacenter", &e.Datacenter)
+		field("datacenter", &e.FailoverDatacenter)
+		field("partition", &e.Partition)
+		field("namespace", &e.Namespace)
+
+		if e.Error != "" && len(replacements) > 0 {
+			e.Error = strings.NewReplacer(replacements...).Replace(e.Error)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,280 @@
+package submatview
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/armon/go-metrics"
+
+	"github.com/hashicorp/consul/proto/pbsubscribe"
+)
+
+// Failover configures how a Materializer recovers when its subscription to a
+// remote datacenter fails.
+//
+// The subscriptions to a remote datacenter are forwarded by the local servers
+// to a server in that datacenter. When the subscription fails, the
+// Materializer resubscribes from the index of the view, and the local servers
+// forward the new subscription to another server in the datacenter.
+type Failover struct {
+	// LocalDatacenter is the datacenter of the agent. Subscriptions to the
+	// local datacenter do not fail over, and are not health checked.
+	LocalDatacenter string
+
+	// Datacenters are the datacenters to fall back to, in order of
+	// preference, when the subscriptions to a remote datacenter keep failing.
+	// The view is materialized from the events of the first datacenter which
+	// accepts the subscription. The indexes of different datacenters are
+	// unrelated, so the view is reset every time it moves to another
+	// datacenter.
+	Datacenters []string
+
+	// Threshold is the number of consecutive failures of the subscriptions to
+	// a datacenter after which the Materializer falls back to the next of
+	// Datacenters. A value of 0 disables falling back to other datacenters.
+	Threshold int
+
+	// HealthCheckInterval is how often the health of a remote datacenter is
+	// checked while a Materializer is subscribed to it, using the
+	// HealthCheckFunc set with Store.SetHealthCheckFunc. The subscription is
+	// restarted when the datacenter fails a check. While the Materializer is
+	// subscribed to one of Datacenters, the datacenter of the request is
+	// checked instead, and the Materializer fails back to it once it passes.
+	// A value of 0 disables health checks.
+	HealthCheckInterval time.Duration
+}
+
+// DefaultFailover is the Failover used by the agent when none is configured.
+// Falling back to other datacenters also requires Datacenters to be set.
+var DefaultFailover = Failover{
+	Threshold:           3,
+	HealthCheckInterval: 30 * time.Second,
+}
+
+// datacenters returns the datacenters a subscription to dc may use, starting
+// with dc. It returns nil when dc is the local datacenter.
+func (f Failover) datacenters(dc string) []string {
+	if dc == "" || f.LocalDatacenter == "" || dc == f.LocalDatacenter {
+		return nil
+	}
+	result := []string{dc}
+	if f.Threshold == 0 {
+		return result
+	}
+	for _, alt := range f.Datacenters {
+		if alt != "" && alt != dc {
+			result = append(result, alt)
+		}
+	}
+	return result
+}
+
+// HealthCheckFunc returns an error if the servers of datacenter dc are unable
+// to serve subscriptions.
+type HealthCheckFunc func(ctx context.Context, dc string) error
+
+// healthChecker shares the results of the health checks of each datacenter
+// between the Materializers of a Store, so that each datacenter is checked at
+// most once per interval regardless of the number of views.
+type healthChecker struct {
+	interval time.Duration
+
+	lock    sync.Mutex
+	fn      HealthCheckFunc
+	results map[string]healthCheckResult
+}
+
+type healthCheckResult struct {
+	checked time.Time
+	err     error
+}
+
+func newHealthChecker(interval time.Duration) *healthChecker {
+	return &healthChecker{
+		interval: interval,
+		results:  make(map[string]healthCheckResult),
+	}
+}
+
+func (h *healthChecker) setFunc(fn HealthCheckFunc) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	h.fn = fn
+	h.results = make(map[string]healthCheckResult)
+}
+
+// check returns the result of the most recent health check of dc, or checks dc
+// if it was not checked within the interval. The datacenter is healthy when no
+// HealthCheckFunc is set.
+func (h *healthChecker) check(ctx context.Context, dc string) error {
+	h.lock.Lock()
+	fn := h.fn
+	result, ok := h.results[dc]
+	h.lock.Unlock()
+
+	if fn == nil {
+		return nil
+	}
+	// The check is reused for slightly less than the interval, so that a
+	// Materializer checking every interval never reuses its own result.
+	if ok && time.Since(result.checked) < h.interval*9/10 {
+		return result.err
+	}
+
+	err := fn(ctx, dc)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+
+	h.lock.Lock()
+	h.results[dc] = healthCheckResult{checked: time.Now(), err: err}
+	h.lock.Unlock()
+	return err
+}
+
+// errFailback cancels a subscription to an alternate datacenter once the
+// datacenter of the request is healthy again.
+var errFailback = errors.New("datacenter of the request is healthy, failing back")
+
+// healthCheckErr is returned by a subscription which was cancelled because its
+// datacenter failed a health check. It is temporary so that the first retry
+// does not notify watchers.
+type healthCheckErr struct {
+	dc  string
+	err error
+}
+
+// Temporary Implements the internal Temporary interface
+func (e healthCheckErr) Temporary() bool {
+	return true
+}
+
+// Error implements error
+func (e healthCheckErr) Error() string {
+	return fmt.Sprintf("health check of datacenter %q failed: %v", e.dc, e.err)
+}
+
+// request returns the request for the next subscription, which resumes from
+// index. The datacenter of the request is replaced when the Materializer has
+// failed over to another datacenter.
+func (m *Materializer) request(index uint64) pbsubscribe.SubscribeRequest {
+	req := m.deps.Request(index)
+	if m.tokens != nil {
+		req.Token = m.tokens.token(req.Token)
+	}
+	if m.dcPos > 0 {
+		req.Datacenter = m.datacenters[m.dcPos]
+	}
+	return req
+}
+
+// failover records a failure of the subscription to the current datacenter.
+// Once Failover.Threshold consecutive subscriptions failed, or when force is
+// true, the Materializer moves on to the next datacenter and failover returns
+// true. It must only be called from the Run goroutine.
+func (m *Materializer) failover(req pbsubscribe.SubscribeRequest, err error, force bool) bool {
+	if len(m.datacenters) < 2 {
+		return false
+	}
+	m.dcFailures++
+	if !force && m.dcFailures < m.deps.Failover.Threshold {
+		return false
+	}
+
+	next := (m.dcPos + 1) % len(m.datacenters)
+	m.deps.Logger.Warn("subscriptions to datacenter keep failing, failing over to another datacenter",
+		"err", err,
+		"topic", req.Topic,
+		"key", req.Key,
+		"from", m.datacenters[m.dcPos],
+		"to", m.datacenters[next],
+		"failure_count", m.dcFailures)
+	m.switchDatacenter(next)
+	return true
+}
+
+// switchDatacenter moves the subscription to the datacenter at pos in
+// m.datacenters. The view is reset because the indexes of the datacenters are
+// unrelated. It must only be called from the Run goroutine.
+func (m *Materializer) switchDatacenter(pos int) {
+	m.dcPos = pos
+	m.dcFailures = 0
+	m.retryWaiter.Reset()
+	m.closeCircuit()
+	m.reset()
+
+	m.lock.Lock()
+	m.datacenter = ""
+	if pos > 0 {
+		m.datacenter = m.datacenters[pos]
+	}
+	m.lock.Unlock()
+
+	metrics.IncrCounterWithLabels([]string{"submatview", "materializer", "failover"}, 1,
+		m.metricsLabels())
+}
+
+// healthCheckEnabled returns true if the subscriptions of the Materializer are
+// health checked.
+func (m *Materializer) healthCheckEnabled() bool {
+	return len(m.datacenters) > 0 &&
+		m.deps.HealthCheck != nil &&
+		m.deps.Failover.HealthCheckInterval > 0
+}
+
+// watchHealth checks the health of the datacenters of the subscription to the
+// datacenter at pos every Failover.HealthCheckInterval, until ctx is
+// cancelled. The subscription is cancelled when its datacenter fails a check,
+// or, when it is subscribed to an alternate datacenter, when the datacenter of
+// the request passes a check.
+func (m *Materializer) watchHealth(ctx context.Context, cancel context.CancelFunc, pos int) {
+	ticker := time.NewTicker(m.deps.Failover.HealthCheckInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		dc := m.datacenters[pos]
+		if err := m.deps.HealthCheck(ctx, dc); err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			m.cancelSubscription(ctx, cancel, healthCheckErr{dc: dc, err: err})
+			return
+		}
+		if pos > 0 && m.deps.HealthCheck(ctx, m.datacenters[0]) == nil && ctx.Err() == nil {
+			m.cancelSubscription(ctx, cancel, errFailback)
+			return
+		}
+	}
+}
+
+// cancelSubscription cancels the subscription of ctx, which returns reason
+// instead of the error of the stream. It does nothing if the subscription has
+// already ended.
+func (m *Materializer) cancelSubscription(ctx context.Context, cancel context.CancelFunc, reason error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if ctx.Err() != nil {
+		return
+	}
+	m.cancelReason = reason
+	cancel()
+}
+
+// consumeCancelReason returns the reason the last subscription was cancelled by
+// cancelSubscription, and clears it.
+func (m *Materializer) consumeCancelReason() error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	reason := m.cancelReason
+	m.cancelReason = nil
+	return reason
+}
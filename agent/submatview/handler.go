@@ -60,11 +60,17 @@ func eventsFromEvent(event *pbsubscribe.Event) []*pbsubscribe.Event {
 
 // resumeStreamHandler checks if the event is a NewSnapshotToFollow event. If it
 // is it resets the view and returns a snapshotHandler to handle the next event.
-// Otherwise it uses eventStreamHandler to handle events.
+// A ResumeStream event, sent in place of a new snapshot which matched the
+// ViewHash of the request, moves the view to the index of the event without
+// changing it. Otherwise it uses eventStreamHandler to handle events.
 func resumeStreamHandler(state viewState, event *pbsubscribe.Event) (eventHandler, error) {
-	if event.GetNewSnapshotToFollow() {
+	switch {
+	case event.GetNewSnapshotToFollow():
 		state.reset()
 		return newSnapshotHandler(), nil
+	case event.GetResumeStream():
+		err := state.updateView(nil, event.Index)
+		return eventStreamHandler, err
 	}
 	return eventStreamHandler(state, event)
 }
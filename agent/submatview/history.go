@@ -0,0 +1,103 @@
+package submatview
+
+import (
+	"github.com/hashicorp/consul/proto/pbsubscribe"
+)
+
+// DeltaView is a View which can return a result that only contains the
+// changes made by a set of events, instead of the full state of the view.
+type DeltaView interface {
+	View
+
+	// DeltaResult returns a result which describes the changes made to the
+	// view by events, in the order they were applied. index is the index of
+	// the view after the last event.
+	DeltaResult(events []*pbsubscribe.Event, index uint64) interface{}
+}
+
+// DeltaRequest may be implemented by a Request to receive a delta result from
+// Store.Get. When the events after the MinIndex of the request are still in
+// the event history of the view, and the view is a DeltaView, the
+// Result.Value is the DeltaResult of those events and Result.Delta is true.
+// Otherwise the Result.Value is the full result of the view.
+type DeltaRequest interface {
+	Request
+	// AcceptsDelta returns true if the caller accepts a delta result.
+	AcceptsDelta() bool
+}
+
+// eventHistory is a ring buffer of the most recent updates applied to a view.
+// A nil eventHistory records nothing.
+type eventHistory struct {
+	entries []historyEntry
+	// start is the position of the oldest entry, and count is the number of
+	// entries in the buffer.
+	start int
+	count int
+	// from is the index of the view before the oldest entry was applied. The
+	// history contains every event after from.
+	from uint64
+}
+
+type historyEntry struct {
+	index  uint64
+	events []*pbsubscribe.Event
+}
+
+// newEventHistory returns an eventHistory which keeps the most recent size
+// updates, or nil if size is not positive.
+func newEventHistory(size int) *eventHistory {
+	if size <= 0 {
+		return nil
+	}
+	return &eventHistory{entries: make([]historyEntry, size)}
+}
+
+// reset removes all entries from the history.
+func (h *eventHistory) reset() {
+	if h == nil {
+		return
+	}
+	for i := range h.entries {
+		h.entries[i] = historyEntry{}
+	}
+	h.start, h.count, h.from = 0, 0, 0
+}
+
+// add records the events which moved the view from prevIndex to index. When
+// the buffer is full the oldest entry is replaced.
+func (h *eventHistory) add(prevIndex, index uint64, events []*pbsubscribe.Event) {
+	if h == nil {
+		return
+	}
+	if h.count == 0 {
+		h.from = prevIndex
+	}
+
+	entry := historyEntry{index: index, events: events}
+	if h.count == len(h.entries) {
+		h.from = h.entries[h.start].index
+		h.entries[h.start] = entry
+		h.start = (h.start + 1) % len(h.entries)
+		return
+	}
+	h.entries[(h.start+h.count)%len(h.entries)] = entry
+	h.count++
+}
+
+// since returns the events applied after minIndex, or false if the history
+// does not contain all of them.
+func (h *eventHistory) since(minIndex uint64) ([]*pbsubscribe.Event, bool) {
+	if h == nil || h.count == 0 || minIndex < h.from {
+		return nil, false
+	}
+
+	var events []*pbsubscribe.Event
+	for i := 0; i < h.count; i++ {
+		entry := h.entries[(h.start+i)%len(h.entries)]
+		if entry.index > minIndex {
+			events = append(events, entry.events...)
+		}
+	}
+	return events, len(events) > 0
+}
@@ -2,14 +2,19 @@ package submatview
 
 import (
 	"context"
+	"errors"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/armon/go-metrics"
 	"github.com/hashicorp/go-hclog"
 	"google.golang.org/grpc"
 	"google.golang.org/grpc/codes"
 	"google.golang.org/grpc/status"
 
+	"github.com/hashicorp/consul/acl"
+	"github.com/hashicorp/consul/agent/cache"
 	"github.com/hashicorp/consul/lib/retry"
 	"github.com/hashicorp/consul/proto/pbsubscribe"
 )
@@ -46,8 +51,28 @@ type View interface {
 // the scenes until the cache result is discarded when TTL expires.
 type Materializer struct {
 	deps        Deps
+	backoff     Backoff
 	retryWaiter *retry.Waiter
 	handler     eventHandler
+	// connected is the time the current subscription was established, and
+	// circuitOpen is true while the circuit breaker is open. Like handler
+	// they must only be accessed from the Run goroutine.
+	connected   time.Time
+	circuitOpen bool
+	// received is the time the most recent event was received from the
+	// stream. Like handler it must only be accessed from the Run goroutine.
+	received time.Time
+	// topic of the subscription, used to label metrics.
+	topic pbsubscribe.Topic
+	// datacenters are the datacenters the subscription may use, starting with
+	// the datacenter of the request, followed by the alternates of
+	// Deps.Failover. It is empty for a subscription to the local datacenter.
+	// dcPos is the position of the datacenter of the current subscription, and
+	// dcFailures is the count of consecutive failures of the subscriptions to
+	// it. Like handler they must only be accessed from the Run goroutine.
+	datacenters []string
+	dcPos       int
+	dcFailures  int
 
 	// lock protects the mutable state - all fields below it must only be accessed
 	// while holding lock.
@@ -56,14 +81,108 @@ type Materializer struct {
 	view     View
 	updateCh chan struct{}
 	err      error
+	// state of the subscription, one of the state constants. It is reported
+	// by Store.Entries.
+	state string
+	// terminalErr is set when the last subscription failed with an error that
+	// can not be resolved by retrying, until the view is updated again. The
+	// Materializer keeps retrying with a backoff, but calls to getFromView
+	// which fail fast return the error instead of waiting for an update.
+	terminalErr error
+	// lastContact is the last time the subscription was known to be receiving
+	// events from the servers. It is updated when an event is received, and
+	// when a connected subscription ends.
+	lastContact time.Time
+	// token is the ACL token of the most recent subscription. When Deps.Request
+	// returns a different token, the view is reset before subscribing again.
+	token      string
+	subscribed bool
+	// cancelSub cancels the current subscription, and resubscribe is set when
+	// the subscription was cancelled by refreshToken or clear. cleared is set
+	// by clear until the next subscription starts from a new snapshot.
+	cancelSub   context.CancelFunc
+	resubscribe bool
+	cleared     bool
+	// cancelReason is the error returned by a subscription cancelled by a
+	// health check, see watchHealth.
+	cancelReason error
+	// datacenter is the datacenter the view is materialized from after it
+	// failed over from the datacenter of the request. It is reported by
+	// Store.Entries.
+	datacenter string
+	// serverIndex is the index of the data read from the servers by the last
+	// index probe of the Store. It is reported by Store.Entries.
+	serverIndex uint64
+	// retries is the number of times the subscription was retried after a
+	// failure. It is reported by Store.Entries.
+	retries int
+	// history of the most recent updates, used to return delta results.
+	history *eventHistory
+	// hash of the view, sent as the ViewHash of the requests which resume the
+	// subscription. hashUnknown is set when the view was restored from a
+	// snapshot, until the view is reset.
+	hash        pbsubscribe.ViewHash
+	hashUnknown bool
+	// pending are the events waiting for the end of the debounce window.
+	pending pendingEvents
+	// size is the SizeBytes of the view when it is a SizedView, and onSize is
+	// notified when it changes. See updateSizeLocked.
+	size   int
+	onSize SizeFunc
+
+	// tokens are the tokens of the requests sharing the view when the entry
+	// is shared by tokens with the same ACL access, or nil. It is set before
+	// Run is called.
+	tokens *sharedTokens
 }
 
+// States of the subscription managed by a Materializer.
+const (
+	stateConnecting = "connecting"
+	stateConnected  = "connected"
+	stateRetrying   = "retrying"
+	stateFailed     = "failed"
+
+	// stateQueued is a subscription waiting for a slot to start from a new
+	// snapshot. See StoreOptions.MaxConcurrentSnapshots.
+	stateQueued = "queued"
+
+	// stateCircuitOpen is a retrying subscription for which the circuit
+	// breaker is open. See Backoff.CircuitBreakerThreshold.
+	stateCircuitOpen = "circuit-open"
+)
+
 type Deps struct {
-	View    View
-	Client  StreamClient
-	Logger  hclog.Logger
-	Waiter  *retry.Waiter
-	Request func(index uint64) pbsubscribe.SubscribeRequest
+	View   View
+	Client StreamClient
+	Logger hclog.Logger
+	// Waiter, when set, is used to wait between attempts instead of the
+	// waits configured by Backoff.
+	Waiter *retry.Waiter
+	// Backoff configures the retries after the subscription fails. Defaults
+	// to DefaultBackoff.
+	Backoff Backoff
+	// EventHistorySize is the number of recent updates kept to return delta
+	// results to a DeltaRequest. A value of 0 disables delta results.
+	EventHistorySize int
+	// Debounce configures how events are accumulated before they are applied
+	// to the View. By default every event is applied as soon as it is received.
+	Debounce Debounce
+	// Failover configures how subscriptions to a remote datacenter recover
+	// from failures.
+	Failover Failover
+	// HealthCheck is used to check the health of remote datacenters. Health
+	// checks are disabled when it is nil.
+	HealthCheck HealthCheckFunc
+	// WatchdogTimeout, when set, requests heartbeats from the servers, and
+	// restarts a subscription which did not receive an event or a heartbeat
+	// for that long. A value of 0 disables the watchdog.
+	WatchdogTimeout time.Duration
+	// SnapshotSlot, when set, is used to wait for a slot before subscribing
+	// from a new snapshot, to limit the number of snapshots fetched from the
+	// servers at the same time.
+	SnapshotSlot SnapshotSlotFunc
+	Request      func(index uint64) pbsubscribe.SubscribeRequest
 }
 
 // StreamClient provides a subscription to state change events.
@@ -75,21 +194,17 @@ type StreamClient interface {
 func NewMaterializer(deps Deps) *Materializer {
 	v := &Materializer{
 		deps:        deps,
+		backoff:     deps.Backoff.withDefaults(),
 		view:        deps.View,
 		retryWaiter: deps.Waiter,
 		updateCh:    make(chan struct{}),
+		topic:       deps.Request(0).Topic,
+		state:       stateConnecting,
+		history:     newEventHistory(deps.EventHistorySize),
+		datacenters: deps.Failover.datacenters(deps.Request(0).Datacenter),
 	}
 	if v.retryWaiter == nil {
-		v.retryWaiter = &retry.Waiter{
-			MinFailures: 1,
-			// Start backing off with small increments (200-400ms) which will double
-			// each attempt. (200-400, 400-800, 800-1600, 1600-3200, 3200-6000, 6000
-			// after that). (retry.Wait applies Max limit after jitter right now).
-			Factor:  200 * time.Millisecond,
-			MinWait: 0,
-			MaxWait: 60 * time.Second,
-			Jitter:  retry.NewJitter(100),
-		}
+		v.retryWaiter = v.backoff.waiter()
 	}
 	return v
 }
@@ -97,32 +212,229 @@ func NewMaterializer(deps Deps) *Materializer {
 // Run receives events from the StreamClient and sends them to the View. It runs
 // until ctx is cancelled, so it is expected to be run in a goroutine.
 func (m *Materializer) Run(ctx context.Context) {
+	defer m.closeCircuit()
 	for {
-		req := m.deps.Request(m.index)
+		if m.consumeCleared() {
+			m.reset()
+		}
+		req := m.request(m.index)
+		if m.tokenChanged(req.Token) {
+			// The view was materialized with a different token, which may not
+			// have access to the same data, so start again from a snapshot.
+			m.reset()
+			req = m.request(0)
+		}
+
 		err := m.runSubscription(ctx, req)
 		if ctx.Err() != nil {
 			return
 		}
+		if m.consumeResubscribe() {
+			continue
+		}
+		if errors.Is(err, errFailback) {
+			m.deps.Logger.Info("datacenter is healthy again, failing back",
+				"topic", req.Topic,
+				"key", req.Key,
+				"from", req.Datacenter,
+				"to", m.datacenters[0])
+			m.switchDatacenter(0)
+			continue
+		}
+
+		// The token of a view shared by tokens with the same ACL access may
+		// have been deleted, while the tokens of the other requests are still
+		// valid.
+		if m.tokens != nil && acl.IsErrNotFound(err) {
+			m.tokens.setNotFound(req.Token)
+		}
+
+		// The token may have been rotated while the subscription was running,
+		// in which case the error can be resolved by subscribing with the new
+		// token.
+		if isTerminalError(err) && m.currentToken() != req.Token {
+			m.deps.Logger.Debug("subscribe call failed, resubscribing with a new token",
+				"err", err,
+				"topic", req.Topic,
+				"key", req.Key)
+			continue
+		}
+
+		// A datacenter the view failed over to may not accept the
+		// subscription, for example because the token is local to the
+		// datacenter of the request, so move on to the next one.
+		if isTerminalError(err) && m.dcPos > 0 && m.failover(req, err, true) {
+			continue
+		}
+
+		terminal := isTerminalError(err)
+		if !terminal && m.failover(req, err, false) {
+			continue
+		}
 
 		failures := m.retryWaiter.Failures()
-		if isNonTemporaryOrConsecutiveFailure(err, failures) {
-			m.lock.Lock()
+		breakerOpen := m.breakerTripped(failures + 1)
+		if breakerOpen && !m.circuitOpen {
+			m.deps.Logger.Warn("too many consecutive subscribe failures, opening circuit breaker",
+				"topic", req.Topic,
+				"key", req.Key,
+				"failure_count", failures+1,
+				"cooldown", m.backoff.CircuitBreakerCooldown)
+			m.circuitOpen = true
+			updateOpenCircuits(1)
+		}
+
+		m.lock.Lock()
+		m.retries++
+		m.state = stateRetrying
+		m.terminalErr = nil
+		switch {
+		case breakerOpen:
+			m.state = stateCircuitOpen
+		case terminal:
+			m.state = stateFailed
+		}
+		if terminal {
+			m.terminalErr = err
+		}
+		if terminal || isNonTemporaryOrConsecutiveFailure(err, failures) {
 			m.notifyUpdateLocked(err)
-			m.lock.Unlock()
 		}
+		m.lock.Unlock()
 
 		m.deps.Logger.Error("subscribe call failed",
 			"err", err,
 			"topic", req.Topic,
 			"key", req.Key,
+			"non_retryable", terminal,
 			"failure_count", failures+1)
-
+		metrics.IncrCounterWithLabels([]string{"submatview", "materializer", "retry"}, 1,
+			m.metricsLabels())
+
+		if breakerOpen {
+			// The count of consecutive failures is not incremented while the
+			// circuit breaker is open, it stays at the threshold until an
+			// update resets it.
+			if err := waitFor(ctx, m.backoff.CircuitBreakerCooldown); err != nil {
+				return
+			}
+			continue
+		}
 		if err := m.retryWaiter.Wait(ctx); err != nil {
 			return
 		}
 	}
 }
 
+// breakerTripped returns true if failures consecutive failures reach the
+// threshold of the circuit breaker.
+func (m *Materializer) breakerTripped(failures int) bool {
+	threshold := m.backoff.CircuitBreakerThreshold
+	return threshold > 0 && failures >= threshold
+}
+
+// closeCircuit closes the circuit breaker if it is open. It must only be
+// called from the Run goroutine.
+func (m *Materializer) closeCircuit() {
+	if !m.circuitOpen {
+		return
+	}
+	m.circuitOpen = false
+	updateOpenCircuits(-1)
+}
+
+// resetFailures resets the count of consecutive failures once the subscription
+// has been connected for Backoff.ResetAfter. It must only be called from the
+// Run goroutine, because it updates the fields which are owned by it. It does
+// not acquire m.lock, so it may be called while holding it, as updateView does.
+func (m *Materializer) resetFailures() {
+	if time.Since(m.connected) < m.backoff.ResetAfter {
+		return
+	}
+	m.retryWaiter.Reset()
+	m.closeCircuit()
+	m.dcFailures = 0
+}
+
+// waitFor blocks for d, or until ctx is cancelled.
+func waitFor(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// tokenChanged records the token used to subscribe, and returns true if a
+// previous subscription used a different token.
+func (m *Materializer) tokenChanged(token string) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	changed := m.subscribed && m.token != token
+	m.token = token
+	m.subscribed = true
+	return changed
+}
+
+// currentToken returns the token the next subscription is made with.
+func (m *Materializer) currentToken() string {
+	token := m.deps.Request(0).Token
+	if m.tokens != nil {
+		token = m.tokens.token(token)
+	}
+	return token
+}
+
+// refreshToken cancels the current subscription if Deps.Request returns a
+// different token than the one used by the subscription. Run subscribes again
+// immediately with the new token.
+func (m *Materializer) refreshToken() {
+	token := m.currentToken()
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.cancelSub == nil || token == m.token {
+		return
+	}
+	m.resubscribe = true
+	m.cancelSub()
+}
+
+// clear restarts the subscription from a new snapshot. Run resets the view
+// before it subscribes again, so requests wait for the new snapshot.
+func (m *Materializer) clear() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.cleared = true
+	if m.cancelSub != nil {
+		m.resubscribe = true
+		m.cancelSub()
+	}
+}
+
+// consumeCleared returns true if the view was cleared by clear since the last
+// subscription started, and clears the flag.
+func (m *Materializer) consumeCleared() bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	cleared := m.cleared
+	m.cleared = false
+	return cleared
+}
+
+// consumeResubscribe returns true if the last subscription was cancelled by
+// refreshToken or clear, and clears the flag.
+func (m *Materializer) consumeResubscribe() bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	resubscribe := m.resubscribe
+	m.resubscribe = false
+	return resubscribe
+}
+
 // isNonTemporaryOrConsecutiveFailure returns true if the error is not a
 // temporary error or if failures > 0.
 func isNonTemporaryOrConsecutiveFailure(err error, failures int) bool {
@@ -134,21 +446,121 @@ func isNonTemporaryOrConsecutiveFailure(err error, failures int) bool {
 	return !ok || !temp.Temporary() || failures > 0
 }
 
+// isTerminalError returns true if the error returned by the subscription is
+// unlikely to be resolved by retrying the subscription with the same request.
+// For example, the ACL token does not have permission, or the requested topic or
+// namespace does not exist. The subscription is still retried with a backoff,
+// because the ACL policies or the namespaces may change, but requests which
+// block on the view fail fast instead of waiting for it.
+//
+// Errors returned by the servers do not always carry a meaningful gRPC status
+// code, so the message of the error is also checked.
+func isTerminalError(err error) bool {
+	if err == nil {
+		return false
+	}
+	switch status.Code(err) {
+	case codes.PermissionDenied, codes.Unauthenticated, codes.InvalidArgument,
+		codes.NotFound, codes.Unimplemented:
+		return true
+	case codes.Unknown:
+		msg := status.Convert(err).Message()
+		return acl.IsErrPermissionDenied(err) ||
+			acl.IsErrNotFound(err) ||
+			strings.HasPrefix(msg, "unknown topic") ||
+			strings.Contains(msg, "Namespace not found")
+	}
+	return false
+}
+
+// terminalError returns the error of the last subscription if it failed with a
+// non-retryable error, and the view has not been updated since.
+func (m *Materializer) terminalError() error {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.terminalErr
+}
+
+// setState of the subscription.
+func (m *Materializer) setState(state string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.state = state
+}
+
+// updateLastContact records that the subscription was receiving events from
+// the servers at the current time.
+func (m *Materializer) updateLastContact() {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.lastContact = time.Now()
+}
+
+// lastContactTime returns the last time the view was known to be up to date
+// with the servers. While the subscription is connected the servers send every
+// change as it happens, so the view is up to date at now.
+func (m *Materializer) lastContactTime(now time.Time) time.Time {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.state == stateConnected || m.lastContact.IsZero() {
+		return now
+	}
+	return m.lastContact
+}
+
 // runSubscription opens a new subscribe streaming call to the servers and runs
 // for it's lifetime or until the view is closed.
 func (m *Materializer) runSubscription(ctx context.Context, req pbsubscribe.SubscribeRequest) error {
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
+	m.lock.Lock()
+	m.cancelSub = cancel
+	m.lock.Unlock()
+	defer func() {
+		// Cancel the subscription before clearing cancelReason, so that
+		// watchHealth can not set it after the subscription ended.
+		cancel()
+		m.lock.Lock()
+		m.cancelSub = nil
+		m.cancelReason = nil
+		m.lock.Unlock()
+	}()
+
+	release, err := m.acquireSnapshotSlot(ctx, req.Index)
+	if err != nil {
+		return err
+	}
+	defer release()
+
 	m.handler = initialHandler(req.Index)
+	m.setState(stateConnecting)
 
+	req.HeartbeatIntervalMillis = m.heartbeatIntervalMillis()
+	if req.Index > 0 {
+		req.ViewHash = m.viewHash()
+	}
 	s, err := m.deps.Client.Subscribe(ctx, &req)
 	if err != nil {
 		return err
 	}
+	m.setState(stateConnected)
+	m.connected = time.Now()
+	if m.healthCheckEnabled() {
+		go m.watchHealth(ctx, cancel, m.dcPos)
+	}
+	watchdog := m.newWatchdog(ctx, cancel)
+	defer watchdog.stop()
+	defer m.updateLastContact()
+	// Apply any debounced events before the subscription is resumed from the
+	// index of the view.
+	defer m.flushPending()
 
 	for {
 		event, err := s.Recv()
+		if reason := m.consumeCancelReason(); reason != nil {
+			return reason
+		}
 		switch {
 		case isGrpcStatus(err, codes.Aborted):
 			m.reset()
@@ -157,11 +569,21 @@ func (m *Materializer) runSubscription(ctx context.Context, req pbsubscribe.Subs
 			return err
 		}
 
+		watchdog.received(event.GetHeartbeat())
+		m.updateLastContact()
+		if event.GetHeartbeat() {
+			continue
+		}
+
+		m.received = time.Now()
 		m.handler, err = m.handler(m, event)
 		if err != nil {
 			m.reset()
 			return err
 		}
+		if event.GetEndOfSnapshot() {
+			release()
+		}
 	}
 }
 
@@ -191,22 +613,62 @@ func (m *Materializer) reset() {
 	defer m.lock.Unlock()
 
 	m.view.Reset()
+	m.updateSizeLocked()
 	m.index = 0
+	m.history.reset()
+	m.resetHashLocked()
+	m.clearPendingLocked()
 }
 
+// updateView applies the events to the view, or adds them to the pending
+// events when they are debounced.
 func (m *Materializer) updateView(events []*pbsubscribe.Event, index uint64) error {
 	m.lock.Lock()
 	defer m.lock.Unlock()
 
-	if err := m.view.Update(events); err != nil {
+	// The subscription is receiving events, so it no longer fails with the
+	// error of a previous subscription.
+	m.terminalErr = nil
+	if m.debounceLocked() {
+		m.addPendingLocked(events, index)
+		m.resetFailures()
+		return nil
+	}
+	if err := m.applyLocked(events, index, m.received); err != nil {
 		return err
 	}
+	m.resetFailures()
+	return nil
+}
+
+// applyLocked updates the view with events, which were received at received,
+// and notifies watchers. Must be called while holding m.lock.
+func (m *Materializer) applyLocked(events []*pbsubscribe.Event, index uint64, received time.Time) error {
+	err := m.view.Update(events)
+	// The view may have been partially updated before it returned an error.
+	m.updateSizeLocked()
+	if err != nil {
+		return err
+	}
+	m.hash.Add(events...)
+	// The first update after a reset is a snapshot, which can not be returned
+	// as a delta.
+	if m.index == 0 {
+		m.history.reset()
+	} else {
+		m.history.add(m.index, index, events)
+	}
 	m.index = index
 	m.notifyUpdateLocked(nil)
-	m.retryWaiter.Reset()
+	metrics.MeasureSinceWithLabels([]string{"submatview", "materializer", "event_lag"},
+		received, m.metricsLabels())
 	return nil
 }
 
+func (m *Materializer) metricsLabels() []metrics.Label {
+	return []metrics.Label{{Name: "topic", Value: m.topic.String()}}
+}
+
 // notifyUpdateLocked closes the current update channel and recreates a new
 // one. It must be called while holding the s.lock lock.
 func (m *Materializer) notifyUpdateLocked(err error) {
@@ -222,27 +684,55 @@ type Result struct {
 	// Cached is true if the requested value was already available locally. If
 	// the value is false, it indicates that getFromView had to wait for an update,
 	Cached bool
+	// LastContact is the last time the view was known to be up to date with
+	// the servers. It is the time the result was returned while the
+	// subscription is connected, otherwise it is the time the last event was
+	// received before the connection to the servers was lost.
+	LastContact time.Time
+	// Delta is true if Value is a DeltaResult which only contains the changes
+	// after the MinIndex of the request. See DeltaRequest.
+	Delta bool
+	// NextCursor is the cursor of the next page when Value is a page of the
+	// result, or empty if Value contains the last item. See PagedRequest.
+	NextCursor string
+}
+
+// Meta returns the cache.ResultMeta for the result. Age is the time since
+// LastContact, which matches the semantics of Age for cache types which
+// perform background refresh.
+func (r Result) Meta() cache.ResultMeta {
+	meta := cache.ResultMeta{Index: r.Index, Hit: r.Cached}
+	if !r.LastContact.IsZero() {
+		meta.Age = time.Since(r.LastContact)
+	}
+	return meta
 }
 
 // getFromView blocks until the index of the View is greater than opts.MinIndex,
-//or the context is cancelled.
-func (m *Materializer) getFromView(ctx context.Context, minIndex uint64) (Result, error) {
+//or the context is cancelled. opts selects a delta result or a page of the
+// result if possible, see DeltaRequest and PagedRequest.
+func (m *Materializer) getFromView(ctx context.Context, minIndex uint64, opts resultOptions) (Result, error) {
 	m.lock.Lock()
 
-	result := Result{
-		Index: m.index,
-		Value: m.view.Result(m.index),
-	}
+	result := Result{Index: m.index}
+	valueErr := m.setResultValueLocked(&result, minIndex, opts)
 
 	updateCh := m.updateCh
+	terminalErr := m.terminalErr
 	m.lock.Unlock()
 
+	// The subscription has failed with an error which is unlikely to go away
+	// soon, so do not wait for an update.
+	if opts.failFast && terminalErr != nil {
+		return result, terminalErr
+	}
+
 	// If our index is > req.Index return right away. If index is zero then we
 	// haven't loaded a snapshot at all yet which means we should wait for one on
 	// the update chan.
 	if result.Index > 0 && result.Index > minIndex {
 		result.Cached = true
-		return result, nil
+		return result, valueErr
 	}
 
 	for {
@@ -264,17 +754,68 @@ func (m *Materializer) getFromView(ctx context.Context, minIndex uint64) (Result
 				continue
 			}
 
-			result.Value = m.view.Result(m.index)
+			err := m.setResultValueLocked(&result, minIndex, opts)
 			m.lock.Unlock()
-			return result, nil
+			return result, err
 
 		case <-ctx.Done():
 			// Update the result value to the latest because callers may still
 			// use the value when the error is context.DeadlineExceeded
 			m.lock.Lock()
-			result.Value = m.view.Result(m.index)
+			if err := m.setResultValueLocked(&result, minIndex, opts); err != nil {
+				m.lock.Unlock()
+				return result, err
+			}
 			m.lock.Unlock()
 			return result, ctx.Err()
 		}
 	}
 }
+
+// setResultValueLocked sets result.Value to the result of the view. When
+// opts.tenancy is set and the view is a TenancyView, the value is the result of
+// that tenancy, and an error is returned if it exceeds opts.maxItems. Otherwise, when opts.delta is true, the view is a DeltaView,
+// and the history contains every event after minIndex, the value is the
+// DeltaResult of those events. Otherwise, when the view is a PagedView, the
+// value is the page selected by opts, and an error is returned if the result
+// exceeds opts.maxItems. Must be called while holding m.lock.
+func (m *Materializer) setResultValueLocked(result *Result, minIndex uint64, opts resultOptions) error {
+	result.Delta = false
+	result.NextCursor = ""
+	if tv, ok := m.view.(TenancyView); ok && opts.tenancy != nil {
+		// Requests for a page are not shared, so only the limit of the full
+		// result applies.
+		if _, err := opts.pageSize(tv.TenancyLen(*opts.tenancy)); err != nil {
+			result.Value = nil
+			return err
+		}
+		result.Value = tv.TenancyResult(m.index, *opts.tenancy)
+		return nil
+	}
+	if dv, ok := m.view.(DeltaView); ok && opts.delta && m.index > minIndex {
+		if events, ok := m.history.since(minIndex); ok {
+			result.Value = dv.DeltaResult(events, m.index)
+			result.Delta = true
+			return nil
+		}
+	}
+	if pv, ok := m.view.(PagedView); ok {
+		size, err := opts.pageSize(pv.Len())
+		if err != nil {
+			result.Value = nil
+			return err
+		}
+		if size > 0 {
+			value, next, err := pv.PageResult(m.index, Page{Cursor: opts.page.Cursor, Size: size})
+			if err != nil {
+				result.Value = nil
+				return err
+			}
+			result.Value = value
+			result.NextCursor = next
+			return nil
+		}
+	}
+	result.Value = m.view.Result(m.index)
+	return nil
+}
@@ -0,0 +1,425 @@
+package submatview
+
+import (
+	"context"
+	"io"
+	"sync"
+
+	"github.com/hashicorp/go-hclog"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/hashicorp/consul/proto/pbsubscribe"
+)
+
+// MultiplexedClient is a StreamClient which carries the subscriptions of many
+// Materializers over a single SubscribeMultiplexed stream, instead of opening
+// a stream for each subscription. The stream is opened by the first
+// subscription, and closed once it has no subscriptions left.
+//
+// When the servers do not implement SubscribeMultiplexed, the subscriptions
+// fall back to Subscribe.
+type MultiplexedClient struct {
+	client pbsubscribe.StateChangeSubscriptionClient
+	logger hclog.Logger
+	// maxQueuedEvents is the maximum number of events queued for a
+	// subscription, see maxMultiplexedQueuedEvents.
+	maxQueuedEvents int
+
+	lock sync.Mutex
+	// stream is the stream used by new subscriptions, or nil if a new stream
+	// must be opened.
+	stream *multiplexedStream
+	// opening is closed once the stream being opened by a subscription is
+	// ready, or nil if no stream is being opened.
+	opening chan struct{}
+	// nextID is the ID of the next subscription.
+	nextID uint64
+	// unsupported is set once the servers returned codes.Unimplemented for
+	// a SubscribeMultiplexed stream.
+	unsupported bool
+}
+
+var _ StreamClient = (*MultiplexedClient)(nil)
+
+// NewMultiplexedClient returns a MultiplexedClient which opens its streams
+// with client.
+func NewMultiplexedClient(client pbsubscribe.StateChangeSubscriptionClient, logger hclog.Logger) *MultiplexedClient {
+	return &MultiplexedClient{
+		client:          client,
+		logger:          logger,
+		maxQueuedEvents: maxMultiplexedQueuedEvents,
+	}
+}
+
+// Subscribe starts a subscription on the shared stream. The subscription is
+// stopped once ctx is cancelled. opts are only used when a new stream is
+// opened.
+func (c *MultiplexedClient) Subscribe(
+	ctx context.Context,
+	req *pbsubscribe.SubscribeRequest,
+	opts ...grpc.CallOption,
+) (pbsubscribe.StateChangeSubscription_SubscribeClient, error) {
+	s, err := c.getStream(ctx, opts)
+	switch {
+	case err != nil:
+		return nil, err
+	case s == nil:
+		return c.client.Subscribe(ctx, req, opts...)
+	}
+	c.nextID++
+	sub := s.add(ctx, c.nextID, req, c.maxQueuedEvents)
+	c.lock.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		c.unsubscribe(s, sub.id)
+	}()
+
+	err = s.send(&pbsubscribe.MultiplexedRequest{
+		ID: sub.id,
+		Op: &pbsubscribe.MultiplexedRequest_Subscribe{Subscribe: req},
+	})
+	if err != nil {
+		return nil, err
+	}
+	return sub, nil
+}
+
+// getStream returns the stream used by new subscriptions, and returns with
+// c.lock held, so that a subscription can be added before the stream ends.
+// It returns a nil stream, without holding c.lock, when the servers do not
+// support SubscribeMultiplexed.
+//
+// The stream is opened without holding c.lock, so that other subscriptions are
+// not blocked by the call to the servers. Subscriptions which start while the
+// stream is being opened wait for it.
+func (c *MultiplexedClient) getStream(ctx context.Context, opts []grpc.CallOption) (*multiplexedStream, error) {
+	for {
+		c.lock.Lock()
+		switch {
+		case c.unsupported:
+			c.lock.Unlock()
+			return nil, nil
+		case c.stream != nil:
+			return c.stream, nil
+		case c.opening != nil:
+			opening := c.opening
+			c.lock.Unlock()
+			select {
+			case <-opening:
+				continue
+			case <-ctx.Done():
+				return nil, ctx.Err()
+			}
+		}
+		opening := make(chan struct{})
+		c.opening = opening
+		c.lock.Unlock()
+
+		s, err := c.openStream(opts)
+
+		c.lock.Lock()
+		c.opening = nil
+		if err == nil {
+			c.stream = s
+			// The events are received once the stream is set, so that
+			// streamEnded does not race with the new stream.
+			go c.receive(s)
+		}
+		c.lock.Unlock()
+		close(opening)
+		if err != nil {
+			return nil, err
+		}
+	}
+}
+
+// openStream opens a new stream. The caller must start receiving its events
+// with receive.
+func (c *MultiplexedClient) openStream(opts []grpc.CallOption) (*multiplexedStream, error) {
+	ctx, cancel := context.WithCancel(context.Background())
+	stream, err := c.client.SubscribeMultiplexed(ctx, opts...)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+	return &multiplexedStream{
+		stream: stream,
+		cancel: cancel,
+		subs:   make(map[uint64]*multiplexedSubscription),
+	}, nil
+}
+
+// receive delivers the events of s to its subscriptions until the stream
+// ends.
+func (c *MultiplexedClient) receive(s *multiplexedStream) {
+	for {
+		e, err := s.stream.Recv()
+		if err != nil {
+			c.streamEnded(s, err)
+			return
+		}
+
+		s.lock.Lock()
+		sub, ok := s.subs[e.ID]
+		if ok && e.GetError() != nil {
+			delete(s.subs, e.ID)
+		}
+		s.lock.Unlock()
+		if !ok {
+			// The subscription was stopped.
+			continue
+		}
+
+		switch payload := e.Payload.(type) {
+		case *pbsubscribe.MultiplexedEvent_Event:
+			if sub.push(payload.Event, nil) {
+				c.logger.Warn("too many events queued for a multiplexed subscription, ending the subscription",
+					"topic", sub.req.Topic,
+					"key", sub.req.Key,
+					"limit", sub.maxEvents)
+			}
+		case *pbsubscribe.MultiplexedEvent_Error:
+			sub.push(nil, subscriptionError(payload.Error))
+		}
+	}
+}
+
+// subscriptionError returns the error which ended a subscription.
+func subscriptionError(e *pbsubscribe.SubscriptionError) error {
+	code := codes.Code(e.Code)
+	if code == codes.OK {
+		return io.EOF
+	}
+	return status.Error(code, e.Message)
+}
+
+// streamEnded ends every subscription of s with err, and records whether the
+// servers support SubscribeMultiplexed.
+func (c *MultiplexedClient) streamEnded(s *multiplexedStream, err error) {
+	unimplemented := status.Code(err) == codes.Unimplemented
+
+	c.lock.Lock()
+	if c.stream == s {
+		c.stream = nil
+	}
+	if unimplemented && !c.unsupported {
+		c.logger.Info("servers do not support multiplexed subscriptions, falling back to a stream for each subscription")
+		c.unsupported = true
+	}
+	c.lock.Unlock()
+
+	s.lock.Lock()
+	subs := s.subs
+	s.subs = nil
+	s.lock.Unlock()
+	s.cancel()
+
+	for _, sub := range subs {
+		if unimplemented {
+			sub.fallBack(c.client)
+			continue
+		}
+		sub.push(nil, err)
+	}
+}
+
+// unsubscribe stops the subscription id of s. The stream is closed once it
+// has no subscriptions left.
+func (c *MultiplexedClient) unsubscribe(s *multiplexedStream, id uint64) {
+	c.lock.Lock()
+	s.lock.Lock()
+	_, active := s.subs[id]
+	delete(s.subs, id)
+	idle := s.subs != nil && len(s.subs) == 0
+	if idle && c.stream == s {
+		c.stream = nil
+	}
+	s.lock.Unlock()
+	c.lock.Unlock()
+
+	switch {
+	case idle:
+		s.cancel()
+	case active:
+		_ = s.send(&pbsubscribe.MultiplexedRequest{
+			ID: id,
+			Op: &pbsubscribe.MultiplexedRequest_Unsubscribe{Unsubscribe: true},
+		})
+	}
+}
+
+// multiplexedStream is a SubscribeMultiplexed stream and its subscriptions.
+type multiplexedStream struct {
+	stream pbsubscribe.StateChangeSubscription_SubscribeMultiplexedClient
+	// cancel closes the stream.
+	cancel context.CancelFunc
+
+	// sendLock is held while sending to stream, which does not support
+	// concurrent calls to Send.
+	sendLock sync.Mutex
+
+	lock sync.Mutex
+	// subs are the active subscriptions, by ID. It is nil once the stream
+	// ended.
+	subs map[uint64]*multiplexedSubscription
+}
+
+func (s *multiplexedStream) add(ctx context.Context, id uint64, req *pbsubscribe.SubscribeRequest, maxEvents int) *multiplexedSubscription {
+	sub := &multiplexedSubscription{
+		ClientStream: s.stream,
+		id:           id,
+		ctx:          ctx,
+		req:          req,
+		maxEvents:    maxEvents,
+		notify:       make(chan struct{}, 1),
+	}
+	s.lock.Lock()
+	s.subs[id] = sub
+	s.lock.Unlock()
+	return sub
+}
+
+func (s *multiplexedStream) send(req *pbsubscribe.MultiplexedRequest) error {
+	s.sendLock.Lock()
+	defer s.sendLock.Unlock()
+	return s.stream.Send(req)
+}
+
+// maxMultiplexedQueuedEvents is the maximum number of events queued for a
+// multiplexed subscription. With a stream for each subscription, flow control
+// slows down the servers when a subscriber falls behind. The shared stream can
+// not be slowed down for a single subscription, so the subscription of a
+// subscriber which falls too far behind is ended instead, and the subscriber
+// subscribes again.
+const maxMultiplexedQueuedEvents = 10000
+
+// errTooManyQueuedEvents ends a multiplexed subscription when its subscriber
+// falls too far behind.
+var errTooManyQueuedEvents = status.Error(codes.ResourceExhausted, "too many events queued for the multiplexed subscription")
+
+// multiplexedSubscription is a subscription of a multiplexed stream. It
+// queues the events received for the subscription, so that a subscriber which
+// is slow to receive its events does not block the other subscriptions of the
+// stream.
+type multiplexedSubscription struct {
+	// ClientStream is the multiplexed stream, shared by every subscription.
+	// CloseSend is a no-op, the subscription is stopped by cancelling its
+	// context.
+	grpc.ClientStream
+
+	id  uint64
+	ctx context.Context
+	req *pbsubscribe.SubscribeRequest
+
+	// maxEvents is the maximum number of events in events.
+	maxEvents int
+
+	lock   sync.Mutex
+	events []*pbsubscribe.Event
+	// err ended the subscription, it is returned by Recv once the events are
+	// received.
+	err error
+	// fallbackClient is set when the servers returned codes.Unimplemented for
+	// the multiplexed stream, and Recv uses it to open fallback, the stream
+	// used by the subscription instead.
+	fallbackClient pbsubscribe.StateChangeSubscriptionClient
+	fallback       pbsubscribe.StateChangeSubscription_SubscribeClient
+	// notify is signalled when an event or an error is pushed.
+	notify chan struct{}
+}
+
+var _ pbsubscribe.StateChangeSubscription_SubscribeClient = (*multiplexedSubscription)(nil)
+
+// push queues an event, or the error which ended the subscription. Once the
+// subscription ended, the events are discarded. When more than maxEvents
+// events are queued, the queue is discarded, and the subscription ends with
+// errTooManyQueuedEvents. push returns true in that case.
+func (s *multiplexedSubscription) push(e *pbsubscribe.Event, err error) bool {
+	var overflow bool
+	s.lock.Lock()
+	switch {
+	case s.err != nil:
+	case err != nil:
+		s.err = err
+	case len(s.events) >= s.maxEvents:
+		s.events = nil
+		s.err = errTooManyQueuedEvents
+		overflow = true
+	default:
+		s.events = append(s.events, e)
+	}
+	s.lock.Unlock()
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+	return overflow
+}
+
+// fallBack subscribes with client once the events received so far are
+// returned by Recv.
+func (s *multiplexedSubscription) fallBack(client pbsubscribe.StateChangeSubscriptionClient) {
+	s.lock.Lock()
+	s.fallbackClient = client
+	s.lock.Unlock()
+
+	select {
+	case s.notify <- struct{}{}:
+	default:
+	}
+}
+
+// Recv returns the next event of the subscription.
+func (s *multiplexedSubscription) Recv() (*pbsubscribe.Event, error) {
+	for {
+		s.lock.Lock()
+		switch {
+		case s.fallback != nil:
+			s.lock.Unlock()
+			return s.fallback.Recv()
+		case len(s.events) > 0:
+			e := s.events[0]
+			s.events[0] = nil
+			s.events = s.events[1:]
+			s.lock.Unlock()
+			return e, nil
+		case s.err != nil:
+			err := s.err
+			s.lock.Unlock()
+			return nil, err
+		case s.fallbackClient != nil:
+			// The servers do not implement SubscribeMultiplexed, which is only
+			// returned before any event is received.
+			fallback, err := s.fallbackClient.Subscribe(s.ctx, s.req)
+			if err != nil {
+				s.lock.Unlock()
+				return nil, err
+			}
+			s.fallback = fallback
+			s.lock.Unlock()
+			continue
+		}
+		s.lock.Unlock()
+
+		select {
+		case <-s.notify:
+		case <-s.ctx.Done():
+			return nil, s.ctx.Err()
+		}
+	}
+}
+
+// Context returns the context of the subscription.
+func (s *multiplexedSubscription) Context() context.Context {
+	return s.ctx
+}
+
+// CloseSend implements grpc.ClientStream. The multiplexed stream is shared
+// with other subscriptions, so it is not closed.
+func (s *multiplexedSubscription) CloseSend() error {
+	return nil
+}
@@ -0,0 +1,89 @@
+package submatview
+
+import (
+	"fmt"
+)
+
+// Page selects a page of the result of a PagedView.
+type Page struct {
+	// Cursor is the position of the first item of the page. It is the
+	// Result.NextCursor of the previous page, or empty for the first page.
+	Cursor string
+
+	// Size is the maximum number of items in the page. A value of 0 requests
+	// the full result.
+	Size int
+}
+
+// PagedView is a View which can return its result one page at a time, so that
+// a request for a part of a large result does not copy the whole state of the
+// view.
+type PagedView interface {
+	View
+
+	// Len returns the number of items in the result of the view.
+	Len() int
+
+	// PageResult returns a result which only contains the items at or after
+	// page.Cursor, up to page.Size items, in the order of Result. next is the
+	// cursor of the following page, or empty if the page contains the last
+	// item.
+	PageResult(index uint64, page Page) (result interface{}, next string, err error)
+}
+
+// PagedRequest may be implemented by a Request to receive a page of the
+// result from Store.Get. When the view is a PagedView and Page().Size is set,
+// the Result.Value only contains the items of the page, and Result.NextCursor
+// is the cursor of the next page. Otherwise the Result.Value is the full
+// result of the view.
+//
+// Delta results are never paged.
+type PagedRequest interface {
+	Request
+	Page() Page
+}
+
+// ResultTooLargeError is returned by Store.Get when the result of a PagedView
+// has more items than StoreOptions.MaxResultItems, and the request did not ask
+// for a page.
+type ResultTooLargeError struct {
+	Items int
+	Max   int
+}
+
+// Error implements error
+func (e ResultTooLargeError) Error() string {
+	return fmt.Sprintf("result of %d items exceeds the limit of %d items, request the result in pages", e.Items, e.Max)
+}
+
+// resultOptions selects the result returned by Materializer.getFromView.
+type resultOptions struct {
+	// delta requests a delta result, see DeltaRequest.
+	delta bool
+	// page requests a page of the result, see PagedRequest.
+	page Page
+	// maxItems is the maximum number of items of a result which is not paged.
+	// Pages are limited to maxItems. A value of 0 disables the limit.
+	maxItems int
+	// tenancy, when set, requests the TenancyView.TenancyResult of a view
+	// shared by the namespaces of a partition, see WildcardNamespaceRequest.
+	tenancy *Tenancy
+	// failFast returns the error of a subscription which failed with a
+	// non-retryable error, instead of waiting for the view to be updated.
+	failFast bool
+}
+
+// pageSize returns the size of the page to return for a view with n items, or
+// an error if the full result was requested and n exceeds the limit.
+func (o resultOptions) pageSize(n int) (int, error) {
+	size := o.page.Size
+	if o.maxItems > 0 {
+		if size == 0 && n > o.maxItems {
+			return 0, ResultTooLargeError{Items: n, Max: o.maxItems}
+		}
+		if size > o.maxItems {
+			size = o.maxItems
+		}
+	}
+	return size, nil
+}
@@ -0,0 +1,182 @@
+package submatview
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hashicorp/consul/lib/file"
+)
+
+// PersistentView is a View whose state can be saved to disk by the Store, so
+// that after the agent restarts the view can resume its subscription from the
+// saved index instead of requesting a new snapshot from the servers.
+type PersistentView interface {
+	View
+
+	// MarshalState returns the current state of the view, encoded so that it
+	// can be passed to RestoreState.
+	MarshalState() ([]byte, error)
+
+	// RestoreState replaces the state of the view with a state previously
+	// returned by MarshalState.
+	RestoreState(data []byte) error
+}
+
+const snapshotsPath = "submatview-snapshots.json"
+
+type persistedSnapshots struct {
+	Views []persistedView `json:"views"`
+}
+
+// persistedView is the saved state of a PersistentView. Key is a hash of the
+// key of the entry, so that the ACL tokens in the keys are not written to
+// disk.
+type persistedView struct {
+	Key   string    `json:"key"`
+	Index uint64    `json:"index"`
+	Saved time.Time `json:"saved"`
+	State []byte    `json:"state"`
+}
+
+func snapshotKey(key string) string {
+	h := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(h[:])
+}
+
+// loadSnapshots reads the snapshots persisted by SaveSnapshots. Snapshots
+// which were saved more than idleTTL ago are discarded, because the entry
+// would have expired if the agent had kept running.
+func (s *Store) loadSnapshots() error {
+	filename := filepath.Join(s.snapshotDir, snapshotsPath)
+	buf, err := ioutil.ReadFile(filename)
+	switch {
+	case os.IsNotExist(err):
+		return nil
+	case err != nil:
+		return fmt.Errorf("failed reading snapshots file %q: %w", filename, err)
+	}
+
+	var snapshots persistedSnapshots
+	if err := json.Unmarshal(buf, &snapshots); err != nil {
+		return fmt.Errorf("failed to decode snapshots file %q: %w", filename, err)
+	}
+
+	for _, view := range snapshots.Views {
+		if time.Since(view.Saved) > s.idleTTL {
+			continue
+		}
+		s.snapshots[view.Key] = view
+	}
+	return nil
+}
+
+// restoreLocked restores the state of the materializer for the entry with key
+// from a persisted snapshot, if one exists. A snapshot is only restored once.
+// Must be called while holding s.lock, and before the materializer is run.
+func (s *Store) restoreLocked(key string, mat *Materializer) {
+	if len(s.snapshots) == 0 {
+		return
+	}
+	hashed := snapshotKey(key)
+	view, ok := s.snapshots[hashed]
+	if !ok {
+		return
+	}
+	delete(s.snapshots, hashed)
+
+	if err := mat.restore(view.Index, view.State, view.Saved); err != nil {
+		s.logger.Debug("failed to restore materialized view from snapshot",
+			"topic", mat.topic, "error", err)
+	}
+}
+
+// SaveSnapshots writes the state of every entry with a PersistentView to the
+// directory set by StoreOptions.SnapshotDir, so that the entries can
+// resume their subscriptions when a new Store is created with the same
+// directory. It is intended to be called when the agent shuts down. It does
+// nothing if StoreOptions.SnapshotDir is not set.
+func (s *Store) SaveSnapshots() error {
+	if s.snapshotDir == "" {
+		return nil
+	}
+
+	s.lock.RLock()
+	snapshots := persistedSnapshots{Views: make([]persistedView, 0, len(s.byKey))}
+	for key, e := range s.byKey {
+		index, state, ok, err := e.materializer.persistentState()
+		switch {
+		case err != nil:
+			s.logger.Debug("failed to snapshot materialized view",
+				"topic", e.topic, "error", err)
+			continue
+		case !ok:
+			continue
+		}
+		snapshots.Views = append(snapshots.Views, persistedView{
+			Key:   snapshotKey(key),
+			Index: index,
+			Saved: time.Now(),
+			State: state,
+		})
+	}
+	s.lock.RUnlock()
+
+	data, err := json.Marshal(snapshots)
+	if err != nil {
+		return fmt.Errorf("failed to marshal snapshots for persistence: %w", err)
+	}
+	filename := filepath.Join(s.snapshotDir, snapshotsPath)
+	if err := file.WriteAtomicWithPerms(filename, data, 0700, 0600); err != nil {
+		return fmt.Errorf("failed to persist snapshots: %w", err)
+	}
+	return nil
+}
+
+// persistentState returns the index and encoded state of the view, or false if
+// the view is not a PersistentView or has no state worth saving.
+func (m *Materializer) persistentState() (uint64, []byte, bool, error) {
+	v, ok := m.view.(PersistentView)
+	if !ok {
+		return 0, nil, false, nil
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.index == 0 || m.terminalErr != nil {
+		return 0, nil, false, nil
+	}
+	state, err := v.MarshalState()
+	if err != nil {
+		return 0, nil, false, err
+	}
+	return m.index, state, true, nil
+}
+
+// restore the state of the view from a snapshot saved at lastContact, so that
+// Run resumes the subscription from index. It must be called before Run.
+func (m *Materializer) restore(index uint64, state []byte, lastContact time.Time) error {
+	v, ok := m.view.(PersistentView)
+	if !ok {
+		return fmt.Errorf("view %T does not support persistence", m.view)
+	}
+
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	defer m.updateSizeLocked()
+	if err := v.RestoreState(state); err != nil {
+		v.Reset()
+		return err
+	}
+	m.index = index
+	m.lastContact = lastContact
+	// The events the view was built from are not saved, so the subscription
+	// resumes without a ViewHash.
+	m.hashUnknown = true
+	return nil
+}
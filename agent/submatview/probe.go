@@ -0,0 +1,207 @@
+package submatview
+
+import (
+	"context"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/armon/go-metrics"
+
+	"github.com/hashicorp/consul/proto/pbsubscribe"
+)
+
+// IndexProbeFunc returns the current index of the data subscribed to by req,
+// read from the servers of req.Datacenter. req.Index is the index of the view,
+// so that the function can use a blocking query which does not return the data
+// while it has not changed. It returns 0 when the index of the topic of req can
+// not be probed.
+type IndexProbeFunc func(ctx context.Context, req pbsubscribe.SubscribeRequest) (uint64, error)
+
+// maxIndexProbesPerInterval is the maximum number of views probed every
+// StoreOptions.IndexProbeInterval. When more views have active requests, the
+// next views are probed in the following intervals.
+var maxIndexProbesPerInterval = 100
+
+// maxConcurrentIndexProbes is the maximum number of views probed at the same
+// time.
+var maxConcurrentIndexProbes = 8
+
+// SetIndexProbeFunc sets the function used to probe the index of the data of
+// the views when StoreOptions.IndexProbeInterval is set. It is set after the
+// Store is created because the RPC client is not available until later.
+func (s *Store) SetIndexProbeFunc(fn IndexProbeFunc) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.indexProbe = fn
+}
+
+// runIndexProbe probes the index of the entries with active requests every
+// interval, until ctx is cancelled.
+func (s *Store) runIndexProbe(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	// reported are the topics with a gauge, which is reset once the topic
+	// has no active entries, so that it does not report a stale lag.
+	reported := make(map[string]struct{})
+	// last is the key of the last entry probed, the next probes start after
+	// it.
+	var last string
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+
+		var lags map[string]uint64
+		lags, last = s.probeIndexes(ctx, last)
+		if ctx.Err() != nil {
+			return
+		}
+		for topic := range reported {
+			if _, ok := lags[topic]; !ok {
+				lags[topic] = 0
+				delete(reported, topic)
+			}
+		}
+		for topic, lag := range lags {
+			metrics.SetGaugeWithLabels([]string{"submatview", "index_lag"}, float32(lag),
+				[]metrics.Label{{Name: "topic", Value: topic}})
+			if lag > 0 {
+				reported[topic] = struct{}{}
+			}
+		}
+	}
+}
+
+// probeIndexes probes the index of up to maxIndexProbesPerInterval entries with
+// active requests, starting with the first entry after the key last, and
+// returns the key of the last entry probed. It then compares the index of each
+// entry with active requests to the last index probed for it, and returns the
+// largest difference for each topic.
+func (s *Store) probeIndexes(ctx context.Context, last string) (map[string]uint64, string) {
+	s.lock.RLock()
+	fn := s.indexProbe
+	keys := make([]string, 0, len(s.byKey))
+	active := make(map[string]*Materializer, len(s.byKey))
+	for key, e := range s.byKey {
+		if e.requests > 0 {
+			keys = append(keys, key)
+			active[key] = e.materializer
+		}
+	}
+	s.lock.RUnlock()
+	sort.Strings(keys)
+
+	if fn != nil && len(keys) > 0 {
+		probe := keys
+		if len(keys) > maxIndexProbesPerInterval {
+			start := sort.SearchStrings(keys, last)
+			if start < len(keys) && keys[start] == last {
+				start++
+			}
+			probe = make([]string, 0, maxIndexProbesPerInterval)
+			for i := 0; i < maxIndexProbesPerInterval; i++ {
+				probe = append(probe, keys[(start+i)%len(keys)])
+			}
+		}
+		s.probeEntries(ctx, fn, probe, active)
+		last = probe[len(probe)-1]
+	}
+
+	lags := make(map[string]uint64)
+	if ctx.Err() != nil {
+		return lags, last
+	}
+	for _, m := range active {
+		lag, ok := m.indexLag()
+		if !ok {
+			continue
+		}
+		topic := m.topic.String()
+		if lag >= lags[topic] {
+			lags[topic] = lag
+		}
+	}
+	return lags, last
+}
+
+// probeEntries probes the index of the materializers of the entries with keys,
+// running up to maxConcurrentIndexProbes probes at the same time.
+func (s *Store) probeEntries(ctx context.Context, fn IndexProbeFunc, keys []string, active map[string]*Materializer) {
+	sem := make(chan struct{}, maxConcurrentIndexProbes)
+	var wg sync.WaitGroup
+	for _, key := range keys {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			wg.Wait()
+			return
+		}
+		wg.Add(1)
+		go func(m *Materializer) {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			s.probeEntry(ctx, fn, m)
+		}(active[key])
+	}
+	wg.Wait()
+}
+
+// probeEntry probes the index of the data of m, and records it.
+func (s *Store) probeEntry(ctx context.Context, fn IndexProbeFunc, m *Materializer) {
+	req := m.probeRequest()
+	serverIndex, err := fn(ctx, req)
+	switch {
+	case ctx.Err() != nil:
+	case err != nil:
+		s.logger.Debug("failed to probe the index of a materialized view",
+			"topic", req.Topic,
+			"key", req.Key,
+			"error", err)
+	case serverIndex != 0:
+		m.setServerIndex(serverIndex)
+	}
+}
+
+// probeRequest returns the request used to probe the index of the view. It is
+// the request of the current subscription, with the index of the view.
+func (m *Materializer) probeRequest() pbsubscribe.SubscribeRequest {
+	req := m.deps.Request(0)
+	if m.tokens != nil {
+		req.Token = m.tokens.token(req.Token)
+	}
+	m.lock.Lock()
+	if m.datacenter != "" {
+		req.Datacenter = m.datacenter
+	}
+	req.Index = m.index
+	m.lock.Unlock()
+	return req
+}
+
+// setServerIndex records the index of the data read from the servers.
+func (m *Materializer) setServerIndex(index uint64) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.serverIndex = index
+}
+
+// indexLag returns the number of indexes the view is behind the index last
+// read from the servers, or false if the index of the view was never probed. A
+// view which has not received a snapshot yet is behind by the whole index.
+func (m *Materializer) indexLag() (uint64, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.serverIndex == 0 {
+		return 0, false
+	}
+	if m.serverIndex <= m.index {
+		return 0, true
+	}
+	return m.serverIndex - m.index, true
+}
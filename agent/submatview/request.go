@@ -0,0 +1,247 @@
+package submatview
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/hashicorp/go-hclog"
+
+	"github.com/hashicorp/consul/agent/cache"
+	"github.com/hashicorp/consul/proto/pbsubscribe"
+)
+
+// ViewFactory returns a new View which materializes the events received by a
+// subscription made with req.
+type ViewFactory func(req pbsubscribe.SubscribeRequest) (View, error)
+
+// RegisterView registers a ViewFactory for topic. Once registered, requests for
+// the topic can be created with NewRequest. Only one ViewFactory may be
+// registered for each topic.
+func (s *Store) RegisterView(topic pbsubscribe.Topic, factory ViewFactory) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.views[topic]; ok {
+		return fmt.Errorf("a view is already registered for topic %v", topic)
+	}
+	s.views[topic] = factory
+	return nil
+}
+
+// RequestSpec describes a request for a materialized view of a topic. It is
+// used with Store.NewRequest.
+type RequestSpec struct {
+	// Subscribe is the request used to subscribe to the topic. Subscribe.Index
+	// is ignored, the Materializer sets it when it resumes a subscription.
+	Subscribe pbsubscribe.SubscribeRequest
+
+	// MinIndex is the index the view must have exceeded before Store.Get
+	// returns. See cache.RequestInfo.MinIndex.
+	MinIndex uint64
+
+	// Timeout is the maximum amount of time Store.Get will block waiting for
+	// MinIndex to be exceeded. See cache.RequestInfo.Timeout.
+	Timeout time.Duration
+
+	// MaxAge, when set, limits how long the view may have been out of contact
+	// with the servers for Store.Get to return a result from it. See
+	// cache.RequestInfo.MaxAge.
+	MaxAge time.Duration
+
+	// Client is used to subscribe to the topic.
+	Client StreamClient
+
+	// Logger is used by the Materializer. Defaults to the logger of the Store.
+	Logger hclog.Logger
+
+	// Delta requests a delta result from Store.Get when one is available.
+	// See DeltaRequest.
+	Delta bool
+
+	// TokenSource, when set, resolves the ACL token each time the view
+	// subscribes to the topic, and Subscribe.Token is ignored. It allows a
+	// long-lived view to continue after the token is rotated. See
+	// Store.RefreshTokens. The view is keyed by the name of the source, even
+	// when StoreOptions.ShareByACLPolicies is enabled. Defaults to the source
+	// set with Store.SetDefaultTokenSource when Subscribe.Token is its current
+	// token.
+	TokenSource TokenSource
+
+	// Page requests a page of the result from Store.Get when the view is a
+	// PagedView. See PagedRequest.
+	Page Page
+
+	// IdleTTL overrides StoreOptions.IdleTTL for the view. Pinned prevents
+	// the view from expiring. See IdleTTLRequest.
+	IdleTTL time.Duration
+
+	// NewView, when set, creates the View in place of the ViewFactory
+	// registered for Subscribe.Topic. It is used by views which depend on
+	// more than the subscription, for example a filter evaluated by the view.
+	NewView ViewFactory
+
+	// ViewType identifies the View created by NewView, and is required when
+	// NewView is set. Requests for the same subscription share a view only
+	// when they have the same ViewType.
+	ViewType string
+}
+
+// tokenSourcePrefix is the prefix of the CacheInfo().Token of the requests
+// with a TokenSource, followed by the name of the source.
+const tokenSourcePrefix = "token-source:"
+
+// usesTokenSource returns true if the view of req uses a TokenSource. Its
+// CacheInfo().Token is the name of the source, not a token.
+func usesTokenSource(req Request) bool {
+	return strings.HasPrefix(req.CacheInfo().Token, tokenSourcePrefix)
+}
+
+// TokenSource resolves the ACL token used by a view.
+type TokenSource interface {
+	// Name identifies the source of the token. Requests with the same
+	// TokenSource name share a view, regardless of the current token.
+	Name() string
+	// Token returns the current token.
+	Token() string
+}
+
+// NewRequest returns a Request for a view of spec.Subscribe.Topic, using the
+// ViewFactory registered for the topic with RegisterView, unless spec.NewView is
+// set. The returned Request may be passed to Get or Notify.
+func (s *Store) NewRequest(spec RequestSpec) (Request, error) {
+	s.lock.RLock()
+	factory, ok := s.views[spec.Subscribe.Topic]
+	defaultTokenSource := s.defaultTokenSource
+	s.lock.RUnlock()
+
+	if spec.NewView != nil {
+		if spec.ViewType == "" {
+			return nil, fmt.Errorf("a ViewType is required with NewView")
+		}
+		factory, ok = spec.NewView, true
+	}
+	if !ok {
+		return nil, fmt.Errorf("no view is registered for topic %v", spec.Subscribe.Topic)
+	}
+	if spec.Client == nil {
+		return nil, fmt.Errorf("a StreamClient is required")
+	}
+	if spec.Logger == nil {
+		spec.Logger = s.logger
+	}
+	if spec.TokenSource == nil && defaultTokenSource != nil &&
+		spec.Subscribe.Token == defaultTokenSource.Token() {
+		spec.TokenSource = defaultTokenSource
+	}
+	return &viewRequest{
+		spec:             spec,
+		factory:          factory,
+		backoff:          s.backoff,
+		eventHistorySize: s.eventHistorySize,
+		debounce:         s.debounce,
+		failover:         s.failover,
+		watchdogTimeout:  s.watchdogTimeout,
+		healthCheck:      s.health.check,
+		snapshotSlot:     s.AcquireSnapshotSlot,
+	}, nil
+}
+
+// viewRequest implements Request for views registered with Store.RegisterView.
+type viewRequest struct {
+	spec             RequestSpec
+	factory          ViewFactory
+	backoff          Backoff
+	eventHistorySize int
+	debounce         Debounce
+	failover         Failover
+	watchdogTimeout  time.Duration
+	healthCheck      HealthCheckFunc
+	snapshotSlot     SnapshotSlotFunc
+}
+
+func (r *viewRequest) CacheInfo() cache.RequestInfo {
+	sub := r.spec.Subscribe
+	if src := r.spec.TokenSource; src != nil {
+		// Key the view by the source, so that it is not replaced when the
+		// token changes.
+		sub.Token = tokenSourcePrefix + src.Name()
+	}
+	// A view with a filter or an exact key only contains the matching events,
+	// so it must not be shared with requests for the same key without them.
+	params := url.Values{}
+	if sub.Filter != "" {
+		params.Set("filter", sub.Filter)
+	}
+	if sub.ExactKey {
+		params.Set("exact", "true")
+	}
+	key := sub.Key
+	if len(params) > 0 {
+		key += "?" + params.Encode()
+	}
+	return cache.RequestInfo{
+		Token:      sub.Token,
+		Datacenter: sub.Datacenter,
+		Key:        key,
+		MinIndex:   r.spec.MinIndex,
+		Timeout:    r.spec.Timeout,
+		MaxAge:     r.spec.MaxAge,
+	}
+}
+
+// Tenancy implements TenancyRequest.
+func (r *viewRequest) Tenancy() Tenancy {
+	return Tenancy{Partition: r.spec.Subscribe.Partition, Namespace: r.spec.Subscribe.Namespace}
+}
+
+// AcceptsDelta implements DeltaRequest.
+func (r *viewRequest) AcceptsDelta() bool {
+	return r.spec.Delta
+}
+
+// Page implements PagedRequest.
+func (r *viewRequest) Page() Page {
+	return r.spec.Page
+}
+
+// IdleTTL implements IdleTTLRequest.
+func (r *viewRequest) IdleTTL() time.Duration {
+	return r.spec.IdleTTL
+}
+
+func (r *viewRequest) Type() string {
+	typ := "agent.submatview." + r.spec.Subscribe.Topic.String()
+	if r.spec.ViewType != "" {
+		typ += "." + r.spec.ViewType
+	}
+	return typ
+}
+
+func (r *viewRequest) NewMaterializer() (*Materializer, error) {
+	view, err := r.factory(r.spec.Subscribe)
+	if err != nil {
+		return nil, err
+	}
+	return NewMaterializer(Deps{
+		View:             view,
+		Client:           r.spec.Client,
+		Logger:           r.spec.Logger,
+		Backoff:          r.backoff,
+		EventHistorySize: r.eventHistorySize,
+		Debounce:         r.debounce,
+		Failover:         r.failover,
+		WatchdogTimeout:  r.watchdogTimeout,
+		HealthCheck:      r.healthCheck,
+		SnapshotSlot:     r.snapshotSlot,
+		Request: func(index uint64) pbsubscribe.SubscribeRequest {
+			req := r.spec.Subscribe
+			req.Index = index
+			if r.spec.TokenSource != nil {
+				req.Token = r.spec.TokenSource.Token()
+			}
+			return req
+		},
+	}), nil
+}
@@ -0,0 +1,84 @@
+package submatview
+
+import (
+	"sync"
+)
+
+// sharedTokens are the ACL tokens of the active requests for an entry shared by
+// tokens with the same ACL access, see StoreOptions.ShareByACLPolicies. The
+// view subscribes with the token of the request which created the entry. When
+// the servers do not find that token, for example because it was deleted, the
+// view subscribes again with the token of another active request, instead of
+// failing for every request which shares the view.
+type sharedTokens struct {
+	lock sync.Mutex
+	// active is the number of active requests made with each token, and order
+	// is the order in which the tokens were first used.
+	active map[string]int
+	order  []string
+	// notFound are the tokens the servers did not find. They are not used
+	// again by the view.
+	notFound map[string]struct{}
+}
+
+func newSharedTokens() *sharedTokens {
+	return &sharedTokens{
+		active:   make(map[string]int),
+		notFound: make(map[string]struct{}),
+	}
+}
+
+// add records an active request made with token.
+func (t *sharedTokens) add(token string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.active[token] == 0 {
+		t.order = append(t.order, token)
+	}
+	t.active[token]++
+}
+
+// remove records the end of a request made with token.
+func (t *sharedTokens) remove(token string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	switch t.active[token] {
+	case 0:
+		return
+	case 1:
+		delete(t.active, token)
+		for i, tok := range t.order {
+			if tok == token {
+				t.order = append(t.order[:i], t.order[i+1:]...)
+				break
+			}
+		}
+	default:
+		t.active[token]--
+	}
+}
+
+// setNotFound records that the servers did not find token.
+func (t *sharedTokens) setNotFound(token string) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	t.notFound[token] = struct{}{}
+}
+
+// token returns the token used to subscribe. It is token, the token of the
+// request which created the entry, unless the servers did not find it, in
+// which case it is the first token of an active request which was found.
+// token is returned when there is none.
+func (t *sharedTokens) token(token string) string {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if _, ok := t.notFound[token]; !ok {
+		return token
+	}
+	for _, tok := range t.order {
+		if _, ok := t.notFound[tok]; !ok {
+			return tok
+		}
+	}
+	return token
+}
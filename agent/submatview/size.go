@@ -0,0 +1,182 @@
+package submatview
+
+import (
+	"reflect"
+	"sync/atomic"
+
+	"github.com/armon/go-metrics"
+)
+
+// SizedView is a View which reports the approximate memory used by its state,
+// so that the Store can account for the memory used by each entry. See
+// StoreOptions.MaxSizeBytes.
+type SizedView interface {
+	View
+
+	// SizeBytes returns the approximate number of bytes used by the state of
+	// the view. It is called after every call to Update, Reset, and
+	// PersistentView.RestoreState, so it is expected to be kept up to date by
+	// those methods instead of being computed from the whole state.
+	SizeBytes() int
+}
+
+// ApproximateSize returns the approximate number of bytes of memory used by v,
+// including the strings, slices, maps, and pointers it refers to. It is
+// intended to be used by the Update method of a SizedView to estimate the size
+// of the items added to or removed from the view. The overhead of the memory
+// allocator and of the buckets of maps is not included.
+func ApproximateSize(v interface{}) int {
+	if v == nil {
+		return 0
+	}
+	rv := reflect.ValueOf(v)
+	return int(rv.Type().Size()) + referencedSize(rv, make(map[uintptr]struct{}))
+}
+
+// referencedSize returns the number of bytes referenced by v, excluding the
+// size of v itself. seen contains the pointers which were already counted.
+func referencedSize(v reflect.Value, seen map[uintptr]struct{}) int {
+	switch v.Kind() {
+	case reflect.String:
+		return v.Len()
+
+	case reflect.Ptr:
+		if v.IsNil() {
+			return 0
+		}
+		if _, ok := seen[v.Pointer()]; ok {
+			return 0
+		}
+		seen[v.Pointer()] = struct{}{}
+		elem := v.Elem()
+		return int(elem.Type().Size()) + referencedSize(elem, seen)
+
+	case reflect.Interface:
+		if v.IsNil() {
+			return 0
+		}
+		elem := v.Elem()
+		size := referencedSize(elem, seen)
+		if elem.Kind() != reflect.Ptr {
+			size += int(elem.Type().Size())
+		}
+		return size
+
+	case reflect.Slice:
+		if v.IsNil() {
+			return 0
+		}
+		size := v.Cap() * int(v.Type().Elem().Size())
+		for i := 0; i < v.Len(); i++ {
+			size += referencedSize(v.Index(i), seen)
+		}
+		return size
+
+	case reflect.Array:
+		size := 0
+		for i := 0; i < v.Len(); i++ {
+			size += referencedSize(v.Index(i), seen)
+		}
+		return size
+
+	case reflect.Map:
+		if v.IsNil() {
+			return 0
+		}
+		entrySize := int(v.Type().Key().Size() + v.Type().Elem().Size())
+		size := v.Len() * entrySize
+		iter := v.MapRange()
+		for iter.Next() {
+			size += referencedSize(iter.Key(), seen) + referencedSize(iter.Value(), seen)
+		}
+		return size
+
+	case reflect.Struct:
+		size := 0
+		for i := 0; i < v.NumField(); i++ {
+			size += referencedSize(v.Field(i), seen)
+		}
+		return size
+
+	default:
+		return 0
+	}
+}
+
+// SizeFunc is notified of the changes to the size of a Materializer's view,
+// as a difference in bytes from the previous size.
+type SizeFunc func(delta int)
+
+// updateSizeLocked records the size of the view after it changed, and reports
+// the difference to the SizeFunc of the Materializer. Views which are not a
+// SizedView have a size of 0. Must be called while holding m.lock.
+func (m *Materializer) updateSizeLocked() {
+	sv, ok := m.view.(SizedView)
+	if !ok {
+		return
+	}
+	size := sv.SizeBytes()
+	delta := size - m.size
+	m.size = size
+	if delta != 0 && m.onSize != nil {
+		m.onSize(delta)
+	}
+}
+
+// setSizeFunc sets the function notified of the changes to the size of the
+// view, and returns the current size. The size of the view is no longer
+// reported once fn is nil.
+func (m *Materializer) setSizeFunc(fn SizeFunc) int {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	m.onSize = fn
+	return m.size
+}
+
+// addSize adds delta to the total size of the views of the Store. It is the
+// SizeFunc of the Materializers of the Store, so it must not acquire s.lock,
+// which may be held while the Materializers hold their lock.
+func (s *Store) addSize(delta int) {
+	total := atomic.AddInt64(&s.sizeBytes, int64(delta))
+	metrics.SetGauge([]string{"submatview", "size_bytes"}, float32(total))
+	s.checkSizeLimit(total)
+}
+
+// checkSizeLimit wakes up Run to evict idle entries if total exceeds
+// StoreOptions.MaxSizeBytes.
+func (s *Store) checkSizeLimit(total int64) {
+	if s.maxSizeBytes <= 0 || total <= s.maxSizeBytes {
+		return
+	}
+	select {
+	case s.overBudgetCh <- struct{}{}:
+	default:
+	}
+}
+
+// SizeBytes returns the approximate number of bytes used by the views of every
+// entry in the Store. Only views which implement SizedView are accounted for.
+func (s *Store) SizeBytes() int64 {
+	return atomic.LoadInt64(&s.sizeBytes)
+}
+
+// evictOverBudgetLocked removes the least recently used entries with no active
+// requests until the total size of the views is within StoreOptions.MaxSizeBytes.
+// Entries with active requests are never removed, so the total may remain
+// above the limit. Must be called while holding s.lock.
+func (s *Store) evictOverBudgetLocked() {
+	if s.maxSizeBytes <= 0 {
+		return
+	}
+	for s.SizeBytes() > s.maxSizeBytes {
+		elem := s.idle.Front()
+		if elem == nil {
+			s.logger.Debug("store has reached max size, but all entries are in use",
+				"max_size_bytes", s.maxSizeBytes,
+				"size_bytes", s.SizeBytes())
+			return
+		}
+		s.evictIdleLocked(elem)
+		metrics.IncrCounter([]string{"submatview", "evict_size"}, 1)
+	}
+}
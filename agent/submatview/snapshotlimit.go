@@ -0,0 +1,54 @@
+package submatview
+
+import (
+	"context"
+	"time"
+
+	"github.com/armon/go-metrics"
+)
+
+// SnapshotSlotFunc waits for a slot to start a subscription from a new
+// snapshot, until ctx is cancelled. The returned release function must be
+// called once the snapshot is received, or the subscription ended.
+type SnapshotSlotFunc func(ctx context.Context) (release func(), err error)
+
+// AcquireSnapshotSlot waits for one of the StoreOptions.MaxConcurrentSnapshots
+// slots shared by the Materializers of the Store. The slots are granted in the
+// order they are requested. It returns immediately when there is no limit.
+func (s *Store) AcquireSnapshotSlot(ctx context.Context) (func(), error) {
+	if s.snapshotSlots == nil {
+		return func() {}, nil
+	}
+	if err := s.snapshotSlots.Acquire(ctx); err != nil {
+		return nil, err
+	}
+	return s.snapshotSlots.Release, nil
+}
+
+// acquireSnapshotSlot waits for a slot with Deps.SnapshotSlot before a
+// subscription which starts from a new snapshot at index 0. Subscriptions
+// which resume from an index are not limited, because the servers only send
+// them a new snapshot when the events since the index are no longer available.
+// The returned function releases the slot, and may be called more than once.
+func (m *Materializer) acquireSnapshotSlot(ctx context.Context, index uint64) (func(), error) {
+	if m.deps.SnapshotSlot == nil || index > 0 {
+		return func() {}, nil
+	}
+
+	m.setState(stateQueued)
+	start := time.Now()
+	release, err := m.deps.SnapshotSlot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	metrics.MeasureSinceWithLabels([]string{"submatview", "materializer", "snapshot_wait"}, start,
+		m.metricsLabels())
+
+	released := false
+	return func() {
+		if !released {
+			released = true
+			release()
+		}
+	}, nil
+}
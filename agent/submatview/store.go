@@ -1,18 +1,94 @@
 package submatview
 
 import (
+	"container/list"
 	"context"
 	"errors"
 	"fmt"
+	"sort"
+	"strings"
 	"sync"
 	"time"
 
+	"github.com/armon/go-metrics"
+	"github.com/armon/go-metrics/prometheus"
 	"github.com/hashicorp/go-hclog"
 
 	"github.com/hashicorp/consul/agent/cache"
+	"github.com/hashicorp/consul/lib/semaphore"
 	"github.com/hashicorp/consul/lib/ttlcache"
+	"github.com/hashicorp/consul/proto/pbsubscribe"
 )
 
+var Gauges = []prometheus.GaugeDefinition{
+	{
+		Name: []string{"submatview", "entries_count"},
+		Help: "Represents the number of materialized views in the store.",
+	},
+	{
+		Name: []string{"submatview", "subscriptions"},
+		Help: "Represents the number of materialized views in the store, labeled by the topic they subscribe to.",
+	},
+	{
+		Name: []string{"submatview", "index_lag"},
+		Help: "Represents the largest difference between the index of a materialized view and the index of its data on the servers, labeled by topic.",
+	},
+	{
+		Name: []string{"submatview", "materializer", "circuits_open"},
+		Help: "Represents the number of materializers with an open circuit breaker.",
+	},
+	{
+		Name: []string{"submatview", "size_bytes"},
+		Help: "Represents the approximate number of bytes used by the materialized views in the store.",
+	},
+}
+
+var Counters = []prometheus.CounterDefinition{
+	{
+		Name: []string{"submatview", "evict_expired"},
+		Help: "Counts the number of expired materialized views that are evicted from the store.",
+	},
+	{
+		Name: []string{"submatview", "evict_lru"},
+		Help: "Counts the number of idle materialized views that are evicted from the store because it reached its maximum number of entries.",
+	},
+	{
+		Name: []string{"submatview", "evict_size"},
+		Help: "Counts the number of idle materialized views that are evicted from the store because the size of the views exceeded its maximum size.",
+	},
+	{
+		Name: []string{"submatview", "notify", "coalesced"},
+		Help: "Counts the number of updates which were replaced by a newer update before a slow Notify receiver was ready for them.",
+	},
+	{
+		Name: []string{"submatview", "materializer", "retry"},
+		Help: "Counts the number of times a materializer had to re-establish its subscription after an error.",
+	},
+	{
+		Name: []string{"submatview", "cleared"},
+		Help: "Counts the number of materialized views cleared with Store.Clear.",
+	},
+	{
+		Name: []string{"submatview", "materializer", "stream_timeout"},
+		Help: "Counts the number of subscriptions restarted because they received no events or heartbeats within the watchdog timeout.",
+	},
+	{
+		Name: []string{"submatview", "materializer", "failover"},
+		Help: "Counts the number of times a materializer moved its subscription to another datacenter.",
+	},
+}
+
+var Summaries = []prometheus.SummaryDefinition{
+	{
+		Name: []string{"submatview", "materializer", "event_lag"},
+		Help: "Measures the time between an event being received from the servers and the materialized view being updated.",
+	},
+	{
+		Name: []string{"submatview", "materializer", "snapshot_wait"},
+		Help: "Measures the time a materialized view waited for a slot to subscribe from a new snapshot, when the number of concurrent snapshots is limited.",
+	},
+}
+
 // Store of Materializers. Store implements an interface similar to
 // agent/cache.Cache, and allows a single Materializer to fulfil multiple requests
 // as long as the requests are identical.
@@ -20,10 +96,18 @@ import (
 // backend there is no longer any need to run a background goroutine to refresh
 // stored values.
 type Store struct {
+	// sizeBytes is the total size of the views of the entries, see addSize.
+	// It is the first field so that it is 64-bit aligned for atomic access.
+	sizeBytes int64
+
 	logger hclog.Logger
 	lock   sync.RWMutex
 	byKey  map[string]entry
 
+	// byTopic tracks the number of entries subscribed to each topic. It is
+	// used to report the per-topic subscription metrics.
+	byTopic map[string]int
+
 	// expiryHeap tracks entries with 0 remaining requests. Entries are ordered
 	// by most recent expiry first.
 	expiryHeap *ttlcache.ExpiryHeap
@@ -32,30 +116,298 @@ type Store struct {
 	// last request for that entry has been terminated. It is a field on the struct
 	// so that it can be patched in tests without needing a global lock.
 	idleTTL time.Duration
+
+	// maxEntries is the maximum number of entries in the Store. When the limit
+	// is reached the least recently used idle entry is evicted to make room for
+	// a new one. A value of 0 means there is no limit.
+	maxEntries int
+
+	// idle is a list of the keys of the entries with 0 remaining requests,
+	// ordered from least to most recently used. It is used to evict entries
+	// when maxEntries is reached.
+	idle *list.List
+
+	// maxSizeBytes is the StoreOptions.MaxSizeBytes. overBudgetCh wakes up Run
+	// to evict idle entries when sizeBytes exceeds it.
+	maxSizeBytes int64
+	overBudgetCh chan struct{}
+
+	// views are the ViewFactory registered for each topic with RegisterView.
+	views map[pbsubscribe.Topic]ViewFactory
+
+	// shareByACLPolicies enables keying entries by the ACL access of the token
+	// using tokenKey, instead of by the token.
+	shareByACLPolicies bool
+	tokenKey           TokenKeyFunc
+
+	// defaultTokenSource is the TokenSource of the requests made with its
+	// current token. See SetDefaultTokenSource.
+	defaultTokenSource TokenSource
+
+	// shareNamespaces enables serving a WildcardNamespaceRequest from the
+	// entry of its wildcard namespace request.
+	shareNamespaces bool
+
+	// coalesceNotify enables coalescing the updates of Notify.
+	coalesceNotify bool
+
+	// backoff, eventHistorySize, debounce, failover, and watchdogTimeout are
+	// used by the Materializers of requests created with NewRequest.
+	backoff          Backoff
+	eventHistorySize int
+	debounce         Debounce
+	failover         Failover
+	watchdogTimeout  time.Duration
+	// maxResultItems is the MaxResultItems of the StoreOptions.
+	maxResultItems int
+	// health checks the remote datacenters used by the Materializers of
+	// requests created with NewRequest.
+	health *healthChecker
+
+	// indexProbeInterval is how often runIndexProbe compares the index of the
+	// entries to the index read from the servers with indexProbe.
+	indexProbeInterval time.Duration
+	indexProbe         IndexProbeFunc
+
+	// snapshotSlots limits the number of Materializers fetching a new
+	// snapshot at the same time, or is nil when there is no limit. See
+	// StoreOptions.MaxConcurrentSnapshots.
+	snapshotSlots *semaphore.Dynamic
+
+	// snapshotDir is the directory used by SaveSnapshots, and snapshots are
+	// the persisted snapshots which have not been restored yet, keyed by the
+	// hash of the entry key.
+	snapshotDir string
+	snapshots   map[string]persistedView
+}
+
+// DefaultIdleTTL is the default duration of time an entry remains in the Store
+// after the last request for that entry has been terminated.
+const DefaultIdleTTL = 20 * time.Minute
+
+// DefaultEventHistorySize is the default number of recent updates the agent
+// keeps for each materialized view, to return delta results.
+const DefaultEventHistorySize = 64
+
+// StoreOptions are options for the Store.
+type StoreOptions struct {
+	// IdleTTL is the duration of time an entry should remain in the Store after
+	// the last request for that entry has been terminated. Defaults to
+	// DefaultIdleTTL.
+	IdleTTL time.Duration
+
+	// MaxEntries is the maximum number of entries to keep in the Store. When
+	// the limit is reached, the least recently used entry with no active
+	// requests is evicted. Entries with active requests are never evicted, so
+	// the limit may be exceeded while all entries are in use. A value of 0
+	// disables the limit.
+	MaxEntries int
+
+	// MaxSizeBytes is the maximum approximate number of bytes used by the views
+	// of the entries in the Store, as reported by the views which implement
+	// SizedView. When the limit is exceeded, the least recently used entries
+	// with no active requests are evicted until the size is within the limit.
+	// Entries with active requests are never evicted, so the limit may be
+	// exceeded while they are in use. A value of 0 disables the limit.
+	MaxSizeBytes int64
+
+	// ShareByACLPolicies keys entries by the ACL policies and roles of the
+	// token instead of by the token, so that requests from tokens with
+	// identical access share a view. The servers still enforce ACLs using the
+	// token of the request which created the view, or the token of another
+	// active request once that token is not found. Requires a TokenKeyFunc to
+	// be set with Store.SetTokenKeyFunc. Requests with a RequestSpec.TokenSource
+	// are still keyed by the name of the source, because the token of the
+	// source may change.
+	ShareByACLPolicies bool
+
+	// ShareNamespaces serves the requests for the same data in different
+	// namespaces of a partition from a single view of every namespace, when
+	// the requests implement WildcardNamespaceRequest. It reduces the number
+	// of views of an agent which serves more than one namespace. The servers
+	// must support subscriptions to the wildcard namespace.
+	ShareNamespaces bool
+
+	// CoalesceNotify coalesces the updates delivered by Store.Notify and
+	// Store.NotifyCallback while the receiver is not ready for them, so that a
+	// slow receiver skips to the latest result instead of delaying the next
+	// update until it has received the previous one.
+	CoalesceNotify bool
+
+	// Backoff configures the retries of the Materializers of requests
+	// created with Store.NewRequest. Defaults to DefaultBackoff.
+	Backoff Backoff
+
+	// EventHistorySize is the number of recent updates kept by the
+	// Materializers of requests created with Store.NewRequest, to return
+	// delta results to a DeltaRequest. A value of 0 disables delta results.
+	EventHistorySize int
+
+	// Debounce configures how the Materializers of requests created with
+	// Store.NewRequest accumulate events before they update the view.
+	Debounce Debounce
+
+	// MaxResultItems is the maximum number of items in a result returned by
+	// Store.Get for a view which implements PagedView, or for the namespace of
+	// a request which shares a TenancyView. Requests for the full result of a
+	// larger view fail with a ResultTooLargeError, and pages are limited to
+	// MaxResultItems. Results delivered by Notify are not limited. A value of
+	// 0 disables the limit.
+	MaxResultItems int
+
+	// Failover configures how the Materializers of requests created with
+	// Store.NewRequest recover when their subscription to a remote datacenter
+	// fails.
+	Failover Failover
+
+	// WatchdogTimeout, when set, restarts the subscriptions of Materializers
+	// of requests created with Store.NewRequest which did not receive an event
+	// or a heartbeat from the servers for that long. See Deps.WatchdogTimeout.
+	WatchdogTimeout time.Duration
+
+	// IndexProbeInterval, when set, is how often Store.Run compares the index
+	// of the entries with active requests to the index of their data on the
+	// servers, read with the IndexProbeFunc set by Store.SetIndexProbeFunc.
+	// Up to 100 entries are probed every interval, and the others in the
+	// following intervals. The largest difference of each topic is reported
+	// as the submatview.index_lag gauge. A value of 0 disables the probe.
+	IndexProbeInterval time.Duration
+
+	// MaxConcurrentSnapshots is the maximum number of Materializers of
+	// requests created with Store.NewRequest, or using the SnapshotSlotFunc
+	// Store.AcquireSnapshotSlot, which subscribe from a new snapshot at the
+	// same time. The other Materializers wait for a slot, in the order they
+	// started to wait, so that the servers are not asked for the snapshots of
+	// every view at once when the agent restarts. A value of 0 disables the
+	// limit.
+	MaxConcurrentSnapshots int
+
+	// SnapshotDir, when set, is the directory where Store.SaveSnapshots saves
+	// the state of views which implement PersistentView. NewStore loads the
+	// saved state, and views created for the same requests resume their
+	// subscriptions from it.
+	SnapshotDir string
+}
+
+// TokenKeyFunc returns a key which identifies the ACL access granted by token.
+// Tokens which are granted the same access must return the same key.
+type TokenKeyFunc func(token string) (string, error)
+
+// applyDefaultValuesOnOptions sets default values on options and returns the
+// updated value.
+func applyDefaultValuesOnOptions(options StoreOptions) StoreOptions {
+	if options.IdleTTL == 0 {
+		options.IdleTTL = DefaultIdleTTL
+	}
+	return options
 }
 
 type entry struct {
 	materializer *Materializer
 	expiry       *ttlcache.Entry
 	stop         func()
+	topic        string
+	// typ and info identify the request that created the entry. They are
+	// reported by Store.Entries. The token is removed from info so that it is
+	// never exposed.
+	typ     string
+	info    cache.RequestInfo
+	tenancy Tenancy
+	// idle is the element of Store.idle for this entry, or nil if the entry
+	// has active requests.
+	idle *list.Element
 	// requests is the count of active requests using this entry. This entry will
 	// remain in the store as long as this count remains > 0.
 	requests int
+	// idleTTL is the duration of time the entry remains in the store once
+	// requests reaches 0, or Pinned if it never expires.
+	idleTTL time.Duration
 }
 
 // NewStore creates and returns a Store that is ready for use. The caller must
 // call Store.Run (likely in a separate goroutine) to start the expiration loop.
-func NewStore(logger hclog.Logger) *Store {
-	return &Store{
+func NewStore(logger hclog.Logger, options StoreOptions) *Store {
+	options = applyDefaultValuesOnOptions(options)
+	s := &Store{
 		logger:     logger,
 		byKey:      make(map[string]entry),
+		byTopic:    make(map[string]int),
 		expiryHeap: ttlcache.NewExpiryHeap(),
-		idleTTL:    20 * time.Minute,
+		idleTTL:    options.IdleTTL,
+		maxEntries: options.MaxEntries,
+		idle:       list.New(),
+		views:      make(map[pbsubscribe.Topic]ViewFactory),
+
+		maxSizeBytes: options.MaxSizeBytes,
+		overBudgetCh: make(chan struct{}, 1),
+
+		shareByACLPolicies: options.ShareByACLPolicies,
+		shareNamespaces:    options.ShareNamespaces,
+		coalesceNotify:     options.CoalesceNotify,
+		backoff:            options.Backoff,
+		eventHistorySize:   options.EventHistorySize,
+		debounce:           options.Debounce,
+		maxResultItems:     options.MaxResultItems,
+		failover:           options.Failover,
+		watchdogTimeout:    options.WatchdogTimeout,
+		health:             newHealthChecker(options.Failover.HealthCheckInterval),
+		indexProbeInterval: options.IndexProbeInterval,
+		snapshotDir:        options.SnapshotDir,
+		snapshots:          make(map[string]persistedView),
+	}
+	if options.MaxConcurrentSnapshots > 0 {
+		s.snapshotSlots = semaphore.NewDynamic(int64(options.MaxConcurrentSnapshots))
+	}
+	if s.snapshotDir != "" {
+		if err := s.loadSnapshots(); err != nil {
+			logger.Warn("unable to load persisted materialized views", "error", err)
+		}
 	}
+	return s
 }
 
-// Run the expiration loop until the context is cancelled.
+// SetTokenKeyFunc sets the function used to key entries by the ACL access of
+// their token when StoreOptions.ShareByACLPolicies is enabled. It is set after
+// the Store is created because the ACL resolver is not available until later.
+func (s *Store) SetTokenKeyFunc(fn TokenKeyFunc) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.tokenKey = fn
+}
+
+// SetDefaultTokenSource sets the TokenSource used by NewRequest for the
+// requests which do not set a RequestSpec.TokenSource, and subscribe with the
+// current token of src. The agent sets the source of its default token, so
+// that the views of DNS lookups, and of the proxies registered without a
+// token, continue with the new token after it is updated.
+func (s *Store) SetDefaultTokenSource(src TokenSource) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.defaultTokenSource = src
+}
+
+// SetHealthCheckFunc sets the function used to check the health of remote
+// datacenters when StoreOptions.Failover.HealthCheckInterval is set. It is set
+// after the Store is created because the RPC client is not available until
+// later.
+func (s *Store) SetHealthCheckFunc(fn HealthCheckFunc) {
+	s.health.setFunc(fn)
+}
+
+// CheckHealth checks the health of datacenter dc with the HealthCheckFunc set
+// by SetHealthCheckFunc, sharing the result with the views of the Store. It
+// can be used as the Deps.HealthCheck of Materializers which are not created
+// by the Store.
+func (s *Store) CheckHealth(ctx context.Context, dc string) error {
+	return s.health.check(ctx, dc)
+}
+
+// Run the expiration loop, and the index probe when
+// StoreOptions.IndexProbeInterval is set, until the context is cancelled.
 func (s *Store) Run(ctx context.Context) {
+	if s.indexProbeInterval > 0 {
+		go s.runIndexProbe(ctx, s.indexProbeInterval)
+	}
 	for {
 		s.lock.RLock()
 		timer := s.expiryHeap.Next()
@@ -72,19 +424,31 @@ func (s *Store) Run(ctx context.Context) {
 			timer.Stop()
 			continue
 
+		// the views exceeded the maximum size, evict idle entries.
+		case <-s.overBudgetCh:
+			timer.Stop()
+			s.lock.Lock()
+			s.evictOverBudgetLocked()
+			s.lock.Unlock()
+
 		// the TTL for the first item has been reached, attempt an expiration.
 		case <-timer.Wait():
 			s.lock.Lock()
 
 			he := timer.Entry
+			// The entry may have been evicted while waiting for the lock.
+			if he.Index() == ttlcache.NotIndexed {
+				s.lock.Unlock()
+				continue
+			}
 			s.expiryHeap.Remove(he.Index())
 
 			e := s.byKey[he.Key()]
 
 			// Only stop the materializer if there are no active requests.
 			if e.requests == 0 {
-				e.stop()
-				delete(s.byKey, he.Key())
+				s.removeEntryLocked(he.Key(), e)
+				metrics.IncrCounter([]string{"submatview", "evict_expired"}, 1)
 			}
 
 			s.lock.Unlock()
@@ -94,7 +458,7 @@ func (s *Store) Run(ctx context.Context) {
 
 // Request is used to request data from the Store.
 // Note that cache.Request is required, but some of the fields cache.RequestInfo
-// fields are ignored (ex: MaxAge, and MustRevalidate).
+// fields are ignored (ex: MustRevalidate). MaxAge is only used by Store.Get.
 type Request interface {
 	cache.Request
 	// NewMaterializer will be called if there is no active materializer to fulfil
@@ -107,16 +471,49 @@ type Request interface {
 	Type() string
 }
 
+// IdleTTLRequest may be implemented by a Request to override
+// StoreOptions.IdleTTL for its entry. When the requests for an entry return
+// different values, the entry uses the longest of them.
+type IdleTTLRequest interface {
+	Request
+	// IdleTTL returns the duration of time the entry should remain in the
+	// Store after its last request has terminated. A value of 0 uses
+	// StoreOptions.IdleTTL, and Pinned prevents the entry from expiring.
+	IdleTTL() time.Duration
+}
+
+// Pinned may be returned by IdleTTLRequest.IdleTTL to prevent the entry from
+// expiring. A pinned entry remains in the Store until it is evicted because
+// StoreOptions.MaxEntries was reached, or it is removed by Store.Clear.
+const Pinned time.Duration = -1
+
+// ErrViewTooStale is returned by Store.Get when the view has not been in
+// contact with the servers for longer than the MaxAge of the request.
+var ErrViewTooStale = errors.New("materialized view is older than the max age of the request")
+
 // Get a value from the store, blocking if the store has not yet seen the
 // req.Index value.
 // See agent/cache.Cache.Get for complete documentation.
+//
+// Get does not read from the leader, so consistent reads must be sent to the
+// servers. If req.CacheInfo().MaxAge is set, Get returns ErrViewTooStale
+// instead of a result from a view which has not been in contact with the
+// servers within MaxAge, so that the caller can read from the servers. If req
+// is a DeltaRequest, the result
+// may only contain the changes after req.CacheInfo().MinIndex. If req is a
+// PagedRequest, the result may only contain a page of the view. Get returns a
+// ResultTooLargeError instead of a result with more items than
+// StoreOptions.MaxResultItems. When the subscription of the view failed with a
+// non-retryable error, for example because the ACL token was not found, Get
+// returns the error instead of waiting for the subscription to be retried.
 func (s *Store) Get(ctx context.Context, req Request) (Result, error) {
 	info := req.CacheInfo()
-	key, materializer, err := s.readEntry(req)
+	entryReq, tenancy := s.sharedRequest(req)
+	key, materializer, err := s.readEntry(entryReq)
 	if err != nil {
 		return Result{}, err
 	}
-	defer s.releaseEntry(key)
+	defer s.releaseEntry(key, info.Token)
 
 	if info.Timeout > 0 {
 		var cancel context.CancelFunc
@@ -124,13 +521,28 @@ func (s *Store) Get(ctx context.Context, req Request) (Result, error) {
 		defer cancel()
 	}
 
-	result, err := materializer.getFromView(ctx, info.MinIndex)
+	opts := resultOptions{maxItems: s.maxResultItems, tenancy: tenancy, failFast: true}
+	if dr, ok := req.(DeltaRequest); ok {
+		opts.delta = dr.AcceptsDelta()
+	}
+	if pr, ok := req.(PagedRequest); ok {
+		opts.page = pr.Page()
+	}
+
+	result, err := materializer.getFromView(ctx, info.MinIndex, opts)
+	result.LastContact = materializer.lastContactTime(time.Now())
 	// context.DeadlineExceeded is translated to nil to match the timeout
 	// behaviour of agent/cache.Cache.Get.
-	if err == nil || errors.Is(err, context.DeadlineExceeded) {
-		return result, nil
+	if err != nil && !errors.Is(err, context.DeadlineExceeded) {
+		return result, err
+	}
+
+	if info.MaxAge > 0 {
+		if age := result.Meta().Age; age > info.MaxAge {
+			return result, fmt.Errorf("%w: last contact %v ago", ErrViewTooStale, age.Round(time.Millisecond))
+		}
 	}
-	return result, err
+	return result, nil
 }
 
 // Notify the updateCh when there are updates to the entry identified by req.
@@ -138,27 +550,82 @@ func (s *Store) Get(ctx context.Context, req Request) (Result, error) {
 //
 // Request.CacheInfo().Timeout is ignored because it is not really relevant in
 // this case. Instead set a deadline on the context.
+//
+// Each update is sent to updateCh before the view is read again. When
+// StoreOptions.CoalesceNotify is enabled, updates are instead coalesced while
+// updateCh is not ready to receive them. Only the most recent update that has
+// not been delivered is kept, so a slow receiver skips intermediate updates and
+// always receives the latest result.
+//
+// The first update is the current result of the view, sent as soon as the view
+// has received its snapshot and its index is at least
+// Request.CacheInfo().MinIndex. A subscriber which attaches to an existing
+// entry receives it immediately, instead of waiting for the next event.
+//
+// Non-retryable subscription errors, such as a permission denied error, are
+// delivered as the Err of an update. The subscription is retried with a
+// backoff, and updates resume once it succeeds.
 func (s *Store) Notify(
 	ctx context.Context,
 	req Request,
 	correlationID string,
 	updateCh chan<- cache.UpdateEvent,
+) error {
+	return s.NotifyCallback(ctx, req, correlationID, func(ctx context.Context, event cache.UpdateEvent) {
+		select {
+		case updateCh <- event:
+		case <-ctx.Done():
+		}
+	})
+}
+
+// NotifyCallback allows you to receive notifications about changes to the
+// entry identified by req in the same way as Notify, but accepts a callback
+// function instead of a channel. When StoreOptions.CoalesceNotify is enabled,
+// updates are coalesced while the callback is running, so the next call
+// receives the latest result.
+func (s *Store) NotifyCallback(
+	ctx context.Context,
+	req Request,
+	correlationID string,
+	cb cache.Callback,
 ) error {
 	info := req.CacheInfo()
-	key, materializer, err := s.readEntry(req)
+	entryReq, tenancy := s.sharedRequest(req)
+	key, materializer, err := s.readEntry(entryReq)
 	if err != nil {
 		return err
 	}
 
 	go func() {
-		defer s.releaseEntry(key)
+		defer s.releaseEntry(key, info.Token)
 
-		index := info.MinIndex
+		deliver := func(u cache.UpdateEvent) { cb(ctx, u) }
+		if s.coalesceNotify {
+			latest := make(chan cache.UpdateEvent, 1)
+			defer close(latest)
+			go deliverUpdates(ctx, latest, cb)
+			deliver = func(u cache.UpdateEvent) { replaceUpdate(latest, u) }
+		}
+
+		index := initialNotifyIndex(info.MinIndex)
 		for {
-			result, err := materializer.getFromView(ctx, index)
+			result, err := materializer.getFromView(ctx, index, resultOptions{tenancy: tenancy})
+			result.LastContact = materializer.lastContactTime(time.Now())
 			switch {
 			case ctx.Err() != nil:
 				return
+			case isTerminalError(err):
+				// The subscription is retried with a backoff, so deliver the
+				// error and keep waiting for the view to recover, like
+				// agent/cache.Cache.Notify.
+				deliver(cache.UpdateEvent{
+					CorrelationID: correlationID,
+					Result:        result.Value,
+					Meta:          result.Meta(),
+					Err:           err,
+				})
+				continue
 			case err != nil:
 				s.logger.Warn("handling error in Store.Notify",
 					"error", err,
@@ -168,32 +635,128 @@ func (s *Store) Notify(
 			}
 
 			index = result.Index
-			u := cache.UpdateEvent{
+			deliver(cache.UpdateEvent{
 				CorrelationID: correlationID,
 				Result:        result.Value,
-				Meta:          cache.ResultMeta{Index: result.Index, Hit: result.Cached},
-			}
-			select {
-			case updateCh <- u:
-			case <-ctx.Done():
-				return
-			}
+				Meta:          result.Meta(),
+			})
 		}
 	}()
 	return nil
 }
 
+// initialNotifyIndex returns the index the first update of a Notify waits for.
+// getFromView waits for an index greater than the one it is passed, so the
+// current result is delivered once the view reaches minIndex, including when
+// the entry already existed at that index.
+func initialNotifyIndex(minIndex uint64) uint64 {
+	if minIndex == 0 {
+		return 0
+	}
+	return minIndex - 1
+}
+
+// replaceUpdate puts u in latest, replacing any update which has not been
+// delivered yet. latest must have a buffer of 1, and replaceUpdate must be the
+// only sender, so that the send never blocks.
+func replaceUpdate(latest chan cache.UpdateEvent, u cache.UpdateEvent) {
+	select {
+	case <-latest:
+		metrics.IncrCounter([]string{"submatview", "notify", "coalesced"}, 1)
+	default:
+	}
+	latest <- u
+}
+
+// deliverUpdates calls cb with the updates received from latest, until latest
+// is closed or the context is cancelled.
+func deliverUpdates(ctx context.Context, latest <-chan cache.UpdateEvent, cb cache.Callback) {
+	for u := range latest {
+		if ctx.Err() != nil {
+			return
+		}
+		cb(ctx, u)
+	}
+}
+
+// RefreshTokens restarts the subscription of every entry whose request now
+// resolves to a different ACL token, for example a request with a TokenSource
+// after the token was rotated. The view of those entries is rebuilt from a new
+// snapshot, and requests for the entries continue to receive updates.
+func (s *Store) RefreshTokens() {
+	s.lock.RLock()
+	materializers := make([]*Materializer, 0, len(s.byKey))
+	for _, e := range s.byKey {
+		materializers = append(materializers, e.materializer)
+	}
+	s.lock.RUnlock()
+
+	for _, m := range materializers {
+		m.refreshToken()
+	}
+}
+
+// Clear discards the materialized views of the entries whose Type, Datacenter,
+// and Key, as reported by Entries and joined with "/", start with prefix. An
+// empty prefix matches every entry. It returns the number of entries cleared.
+//
+// Entries without active requests are removed, so the next request creates a
+// new view from a snapshot. The subscriptions of entries with active requests
+// are restarted from a new snapshot, and the requests continue to receive
+// updates. The views restored from StoreOptions.SnapshotDir which have not
+// been used yet are discarded, because they may be just as stale.
+func (s *Store) Clear(prefix string) int {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	s.snapshots = nil
+
+	var cleared int
+	for key, e := range s.byKey {
+		if !strings.HasPrefix(entryID(e), prefix) {
+			continue
+		}
+		cleared++
+		if e.requests > 0 {
+			e.materializer.clear()
+			continue
+		}
+		if e.expiry.Index() != ttlcache.NotIndexed {
+			s.expiryHeap.Remove(e.expiry.Index())
+		}
+		s.removeEntryLocked(key, e)
+	}
+	if cleared > 0 {
+		metrics.IncrCounter([]string{"submatview", "cleared"}, float32(cleared))
+	}
+	return cleared
+}
+
+// entryID identifies an entry by the fields reported by Entries.
+func entryID(e entry) string {
+	return e.typ + "/" + e.info.Datacenter + "/" + e.info.Key
+}
+
 // readEntry from the store, and increment the requests counter. releaseEntry
 // must be called when the request is finished to decrement the counter.
 func (s *Store) readEntry(req Request) (string, *Materializer, error) {
 	info := req.CacheInfo()
-	key := makeEntryKey(req.Type(), info)
+	key, shared := s.sharedEntryKey(req)
+	ttl := s.requestIdleTTL(req)
 
 	s.lock.Lock()
 	defer s.lock.Unlock()
 	e, ok := s.byKey[key]
 	if ok {
+		if tokens := e.materializer.tokens; tokens != nil {
+			tokens.add(info.Token)
+		}
 		e.requests++
+		e.idleTTL = longestIdleTTL(e.idleTTL, ttl)
+		if e.idle != nil {
+			s.idle.Remove(e.idle)
+			e.idle = nil
+		}
 		s.byKey[key] = e
 		return key, e.materializer, nil
 	}
@@ -203,24 +766,125 @@ func (s *Store) readEntry(req Request) (string, *Materializer, error) {
 		return "", nil, err
 	}
 
+	if s.maxEntries > 0 && len(s.byKey) >= s.maxEntries {
+		s.evictLRULocked()
+	}
+
+	mat.setSizeFunc(s.addSize)
+	s.restoreLocked(key, mat)
+	if shared {
+		mat.tokens = newSharedTokens()
+		mat.tokens.add(info.Token)
+	}
+
 	ctx, cancel := context.WithCancel(context.Background())
 	go mat.Run(ctx)
 
+	info.Token = ""
 	e = entry{
 		materializer: mat,
 		stop:         cancel,
+		topic:        mat.topic.String(),
+		typ:          req.Type(),
+		info:         info,
+		tenancy:      requestTenancy(req),
 		requests:     1,
+		idleTTL:      ttl,
 	}
 	s.byKey[key] = e
+	s.byTopic[e.topic]++
+	s.emitEntryMetricsLocked(e.topic)
 	return key, e.materializer, nil
 }
 
+// requestIdleTTL returns the IdleTTL of req if it is an IdleTTLRequest, or the
+// IdleTTL of the store.
+func (s *Store) requestIdleTTL(req Request) time.Duration {
+	if r, ok := req.(IdleTTLRequest); ok {
+		switch ttl := r.IdleTTL(); {
+		case ttl < 0:
+			return Pinned
+		case ttl > 0:
+			return ttl
+		}
+	}
+	return s.idleTTL
+}
+
+// longestIdleTTL returns the longest of the idle TTLs a and b, where Pinned is
+// longer than any other value.
+func longestIdleTTL(a, b time.Duration) time.Duration {
+	if a == Pinned || b == Pinned {
+		return Pinned
+	}
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// evictLRULocked removes the least recently used entry that has no active
+// requests. If every entry has active requests, nothing is evicted. Must be
+// called while holding s.lock.
+func (s *Store) evictLRULocked() {
+	elem := s.idle.Front()
+	if elem == nil {
+		s.logger.Debug("store has reached max entries, but all entries are in use",
+			"max_entries", s.maxEntries)
+		return
+	}
+	s.evictIdleLocked(elem)
+	metrics.IncrCounter([]string{"submatview", "evict_lru"}, 1)
+}
+
+// evictIdleLocked removes the entry of elem, an element of s.idle. Must be
+// called while holding s.lock.
+func (s *Store) evictIdleLocked(elem *list.Element) {
+	key := elem.Value.(string)
+	e := s.byKey[key]
+	if e.expiry.Index() != ttlcache.NotIndexed {
+		s.expiryHeap.Remove(e.expiry.Index())
+	}
+	s.removeEntryLocked(key, e)
+}
+
+// removeEntryLocked stops the materializer for the entry and removes it from
+// the store. The caller is responsible for removing the entry from the
+// expiryHeap. Must be called while holding s.lock.
+func (s *Store) removeEntryLocked(key string, e entry) {
+	e.stop()
+	// The view is no longer accounted for, even if the materializer is still
+	// applying an update.
+	if size := e.materializer.setSizeFunc(nil); size != 0 {
+		s.addSize(-size)
+	}
+	if e.idle != nil {
+		s.idle.Remove(e.idle)
+	}
+	delete(s.byKey, key)
+	s.byTopic[e.topic]--
+	s.emitEntryMetricsLocked(e.topic)
+}
+
+// emitEntryMetricsLocked reports the number of entries in the store, and the
+// number of entries subscribed to topic. Must be called while holding s.lock.
+func (s *Store) emitEntryMetricsLocked(topic string) {
+	metrics.SetGauge([]string{"submatview", "entries_count"}, float32(len(s.byKey)))
+	metrics.SetGaugeWithLabels([]string{"submatview", "subscriptions"},
+		float32(s.byTopic[topic]),
+		[]metrics.Label{{Name: "topic", Value: topic}})
+}
+
 // releaseEntry decrements the request count and starts an expiry timer if the
-// count has reached 0. Must be called once for every call to readEntry.
-func (s *Store) releaseEntry(key string) {
+// count has reached 0. Must be called once for every call to readEntry, with
+// the token of the request.
+func (s *Store) releaseEntry(key string, token string) {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 	e := s.byKey[key]
+	if tokens := e.materializer.tokens; tokens != nil {
+		tokens.remove(token)
+	}
 	e.requests--
 	s.byKey[key] = e
 
@@ -228,16 +892,163 @@ func (s *Store) releaseEntry(key string) {
 		return
 	}
 
+	// The materializer has stopped because of an error that can not be
+	// resolved by retrying. Remove the entry so that the next request creates
+	// a new materializer, instead of receiving the same error until the entry
+	// expires.
+	if e.materializer.terminalError() != nil {
+		if e.expiry.Index() != ttlcache.NotIndexed {
+			s.expiryHeap.Remove(e.expiry.Index())
+		}
+		s.removeEntryLocked(key, e)
+		return
+	}
+
+	e.idle = s.idle.PushBack(key)
+	s.byKey[key] = e
+
+	// The entry may be evicted now that it is idle.
+	s.checkSizeLimit(s.SizeBytes())
+
+	if e.idleTTL == Pinned {
+		if e.expiry.Index() != ttlcache.NotIndexed {
+			s.expiryHeap.Remove(e.expiry.Index())
+		}
+		return
+	}
+
 	if e.expiry.Index() == ttlcache.NotIndexed {
-		e.expiry = s.expiryHeap.Add(key, s.idleTTL)
+		e.expiry = s.expiryHeap.Add(key, e.idleTTL)
 		s.byKey[key] = e
 		return
 	}
 
-	s.expiryHeap.Update(e.expiry.Index(), s.idleTTL)
+	s.expiryHeap.Update(e.expiry.Index(), e.idleTTL)
 }
 
-// makeEntryKey matches agent/cache.makeEntryKey, but may change in the future.
-func makeEntryKey(typ string, r cache.RequestInfo) string {
-	return fmt.Sprintf("%s/%s/%s/%s", typ, r.Datacenter, r.Token, r.Key)
+// EntryInfo describes an entry in the Store. It is used to inspect the contents
+// of the Store when troubleshooting.
+type EntryInfo struct {
+	// Type of the request that created the entry.
+	Type       string
+	Datacenter string
+	Key        string
+	Topic      string
+	// Partition and Namespace of the request that created the entry, when it
+	// is a TenancyRequest. Namespace is WildcardNamespace for the entries
+	// shared by the namespaces of a partition. See StoreOptions.ShareNamespaces.
+	Partition string `json:",omitempty"`
+	Namespace string `json:",omitempty"`
+	// Index of the materialized view.
+	Index uint64
+	// Requests is the number of active requests using the entry.
+	Requests int
+	// Expires is the time the entry will be removed from the Store. It is nil
+	// while the entry has active requests, and for pinned entries.
+	Expires *time.Time
+	// Pinned is true if the entry never expires. See IdleTTLRequest.
+	Pinned bool
+	// State of the subscription used to materialize the view.
+	State string
+	// Error returned by the subscription since the view was last updated, if
+	// any.
+	Error string `json:",omitempty"`
+	// FailoverDatacenter is the datacenter the view is materialized from when
+	// it failed over from Datacenter. See Failover.
+	FailoverDatacenter string `json:",omitempty"`
+	// ServerIndex is the index of the data on the servers, read by the last
+	// index probe. See StoreOptions.IndexProbeInterval.
+	ServerIndex uint64 `json:",omitempty"`
+	// Retries is the number of times the subscription was retried after a
+	// failure since the entry was created.
+	Retries int
+	// SizeBytes is the approximate number of bytes used by the view, or 0 if
+	// the view does not implement SizedView.
+	SizeBytes int
+}
+
+// Entries returns information about every entry in the Store, ordered by type,
+// key, and tenancy. The ACL token used by the request is never included.
+func (s *Store) Entries() []EntryInfo {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+
+	result := make([]EntryInfo, 0, len(s.byKey))
+	for _, e := range s.byKey {
+		info := EntryInfo{
+			Type:       e.typ,
+			Datacenter: e.info.Datacenter,
+			Key:        e.info.Key,
+			Topic:      e.topic,
+			Partition:  e.tenancy.Partition,
+			Namespace:  e.tenancy.Namespace,
+			Requests:   e.requests,
+			Pinned:     e.idleTTL == Pinned,
+		}
+		if e.requests == 0 && e.expiry.Index() != ttlcache.NotIndexed {
+			expires := e.expiry.Expiry()
+			info.Expires = &expires
+		}
+
+		m := e.materializer
+		m.lock.Lock()
+		info.Index = m.index
+		info.State = m.state
+		if m.err != nil {
+			info.Error = m.err.Error()
+		}
+		info.FailoverDatacenter = m.datacenter
+		info.ServerIndex = m.serverIndex
+		info.Retries = m.retries
+		info.SizeBytes = m.size
+		m.lock.Unlock()
+
+		result = append(result, info)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Type != result[j].Type {
+			return result[i].Type < result[j].Type
+		}
+		if result[i].Key != result[j].Key {
+			return result[i].Key < result[j].Key
+		}
+		if result[i].Partition != result[j].Partition {
+			return result[i].Partition < result[j].Partition
+		}
+		return result[i].Namespace < result[j].Namespace
+	})
+	return result
+}
+
+// entryKey returns the key of the entry for a request. When ShareByACLPolicies
+// is enabled the token is replaced by the key of its ACL access. If the access
+// of the token can not be resolved the entry is keyed by the token.
+func (s *Store) entryKey(req Request) string {
+	key, _ := s.sharedEntryKey(req)
+	return key
+}
+
+// sharedEntryKey returns the entryKey of req, and true if the entry is keyed
+// by the ACL access of the token instead of by the token.
+func (s *Store) sharedEntryKey(req Request) (string, bool) {
+	typ, info, tenancy := req.Type(), req.CacheInfo(), requestTenancy(req)
+
+	s.lock.RLock()
+	tokenKey := s.tokenKey
+	s.lock.RUnlock()
+
+	if !s.shareByACLPolicies || tokenKey == nil || usesTokenSource(req) {
+		return makeEntryKey(typ, info, tenancy), false
+	}
+
+	aclKey, err := tokenKey(info.Token)
+	if err != nil {
+		s.logger.Debug("failed to resolve the ACL access of a token, keying the view by token",
+			"error", err,
+			"request-type", typ)
+		return makeEntryKey(typ, info, tenancy), false
+	}
+	info.Token = "acl:" + aclKey
+	return makeEntryKey(typ, info, tenancy), true
 }
@@ -17,6 +17,8 @@ import (
 	"github.com/stretchr/testify/require"
 	"golang.org/x/sync/errgroup"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
 	"github.com/hashicorp/consul/acl"
 	"github.com/hashicorp/consul/agent/cache"
@@ -52,7 +54,7 @@ func TestStore_IntegrationWithBackend(t *testing.T) {
 	defer cancel()
 	go pub.Run(ctx)
 
-	store := submatview.NewStore(hclog.New(nil))
+	store := submatview.NewStore(hclog.New(nil), submatview.StoreOptions{})
 	go store.Run(ctx)
 
 	addr := runServer(t, pub)
@@ -96,6 +98,110 @@ func TestStore_IntegrationWithBackend(t *testing.T) {
 	}
 }
 
+func TestMultiplexedClient_IntegrationWithBackend(t *testing.T) {
+	if testing.Short() {
+		t.Skip("too slow for testing.Short")
+	}
+
+	run := func(t *testing.T, srv *countingServer) {
+		var maxIndex uint64 = 100
+		count := &counter{latest: 3}
+		producers := map[string]*eventProducer{
+			"srv1": newEventProducer(pbsubscribe.Topic_ServiceHealth, "srv1", count, maxIndex),
+			"srv2": newEventProducer(pbsubscribe.Topic_ServiceHealth, "srv2", count, maxIndex),
+			"srv3": newEventProducer(pbsubscribe.Topic_ServiceHealth, "srv3", count, maxIndex),
+		}
+
+		sh := snapshotHandler{producers: producers}
+		handlers := map[stream.Topic]stream.SnapshotFunc{
+			pbsubscribe.Topic_ServiceHealth: sh.Snapshot,
+		}
+		pub := stream.NewEventPublisher(handlers, 10*time.Millisecond)
+
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		go pub.Run(ctx)
+
+		store := submatview.NewStore(hclog.New(nil), submatview.StoreOptions{})
+		go store.Run(ctx)
+
+		srv.Server = &subscribe.Server{Backend: backend{pub: pub}, Logger: hclog.New(nil)}
+		addr := runSubscribeServer(t, srv)
+
+		conn, err := grpc.Dial(addr.String(), grpc.WithInsecure())
+		require.NoError(t, err)
+		t.Cleanup(func() { conn.Close() })
+		client := submatview.NewMultiplexedClient(pbsubscribe.NewStateChangeSubscriptionClient(conn), hclog.New(nil))
+
+		var consumers []*consumer
+		for name := range producers {
+			c := newConsumer(t, addr, store, name)
+			c.healthClient.MaterializerDeps.Client = client
+			consumers = append(consumers, c)
+		}
+
+		group, gctx := errgroup.WithContext(ctx)
+		for i := range producers {
+			producer := producers[i]
+			group.Go(func() error {
+				producer.Produce(gctx, pub)
+				return nil
+			})
+		}
+		for i := range consumers {
+			consumer := consumers[i]
+			group.Go(func() error {
+				return consumer.Consume(gctx, maxIndex)
+			})
+		}
+		_ = group.Wait()
+
+		for _, consumer := range consumers {
+			require.True(t, len(consumer.states) > 2, "expected more than %d events", len(consumer.states))
+
+			expected := producers[consumer.srvName].nodesByIndex
+			for idx, nodes := range consumer.states {
+				assertDeepEqual(t, idx, expected[idx], nodes)
+			}
+		}
+	}
+
+	t.Run("subscriptions share a stream", func(t *testing.T) {
+		srv := &countingServer{}
+		run(t, srv)
+		require.Equal(t, int32(0), atomic.LoadInt32(&srv.subscribes))
+		require.Equal(t, int32(1), atomic.LoadInt32(&srv.streams))
+	})
+
+	t.Run("servers without multiplexed streams", func(t *testing.T) {
+		srv := &countingServer{legacy: true}
+		run(t, srv)
+		require.Equal(t, int32(3), atomic.LoadInt32(&srv.subscribes))
+	})
+}
+
+// countingServer counts the streams opened by the subscribers. When legacy is
+// set, it does not implement SubscribeMultiplexed, like older servers.
+type countingServer struct {
+	*subscribe.Server
+	legacy     bool
+	subscribes int32
+	streams    int32
+}
+
+func (s *countingServer) Subscribe(req *pbsubscribe.SubscribeRequest, stream pbsubscribe.StateChangeSubscription_SubscribeServer) error {
+	atomic.AddInt32(&s.subscribes, 1)
+	return s.Server.Subscribe(req, stream)
+}
+
+func (s *countingServer) SubscribeMultiplexed(stream pbsubscribe.StateChangeSubscription_SubscribeMultiplexedServer) error {
+	if s.legacy {
+		return status.Error(codes.Unimplemented, "method SubscribeMultiplexed not implemented")
+	}
+	atomic.AddInt32(&s.streams, 1)
+	return s.Server.SubscribeMultiplexed(stream)
+}
+
 func assertDeepEqual(t *testing.T, idx uint64, x, y interface{}) {
 	t.Helper()
 	if diff := cmp.Diff(x, y, cmpopts.EquateEmpty()); diff != "" {
@@ -118,6 +224,10 @@ func runServer(t *testing.T, pub *stream.EventPublisher) net.Addr {
 		Backend: backend{pub: pub},
 		Logger:  hclog.New(nil),
 	}
+	return runSubscribeServer(t, subSrv)
+}
+
+func runSubscribeServer(t *testing.T, subSrv pbsubscribe.StateChangeSubscriptionServer) net.Addr {
 	srv := grpc.NewServer()
 	pbsubscribe.RegisterStateChangeSubscriptionServer(srv, subSrv)
 
@@ -154,6 +264,10 @@ func (b backend) Subscribe(req *stream.SubscribeRequest) (*stream.Subscription,
 	return b.pub.Subscribe(req)
 }
 
+func (b backend) KeyListPolicyEnabled() bool {
+	return false
+}
+
 var _ subscribe.Backend = (*backend)(nil)
 
 type eventProducer struct {
@@ -2,18 +2,34 @@ package submatview
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"io/ioutil"
+	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"sync"
 	"testing"
 	"time"
 
+	"github.com/armon/go-metrics"
 	"github.com/hashicorp/go-hclog"
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 
+	"github.com/hashicorp/consul/acl"
 	"github.com/hashicorp/consul/agent/cache"
+	"github.com/hashicorp/consul/agent/submatview/submatviewtest"
 	"github.com/hashicorp/consul/lib/ttlcache"
 	"github.com/hashicorp/consul/proto/pbcommon"
 	"github.com/hashicorp/consul/proto/pbservice"
 	"github.com/hashicorp/consul/proto/pbsubscribe"
+	"github.com/hashicorp/consul/sdk/testutil"
 	"github.com/hashicorp/consul/sdk/testutil/retry"
 )
 
@@ -21,14 +37,14 @@ func TestStore_Get(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	store := NewStore(hclog.New(nil))
+	store := NewStore(hclog.New(nil), StoreOptions{})
 	go store.Run(ctx)
 
 	req := &fakeRequest{
-		client: NewTestStreamingClient(pbcommon.DefaultEnterpriseMeta.Namespace),
+		client: submatviewtest.NewStreamingClient(pbcommon.DefaultEnterpriseMeta.Namespace),
 	}
 	req.client.QueueEvents(
-		newEndOfSnapshotEvent(2),
+		submatviewtest.NewEndOfSnapshotEvent(2),
 		newEventServiceHealthRegister(10, 1, "srv1"),
 		newEventServiceHealthRegister(22, 2, "srv1"))
 
@@ -49,7 +65,7 @@ func TestStore_Get(t *testing.T) {
 		store.lock.Lock()
 		defer store.lock.Unlock()
 		require.Len(t, store.byKey, 1)
-		e := store.byKey[makeEntryKey(req.Type(), req.CacheInfo())]
+		e := store.byKey[makeEntryKey(req.Type(), req.CacheInfo(), Tenancy{})]
 		require.Equal(t, 0, e.expiry.Index())
 		require.Equal(t, 0, e.requests)
 
@@ -70,7 +86,7 @@ func TestStore_Get(t *testing.T) {
 		store.lock.Lock()
 		defer store.lock.Unlock()
 		require.Len(t, store.byKey, 1)
-		e := store.byKey[makeEntryKey(req.Type(), req.CacheInfo())]
+		e := store.byKey[makeEntryKey(req.Type(), req.CacheInfo(), Tenancy{})]
 		require.Equal(t, 0, e.expiry.Index())
 		require.Equal(t, 0, e.requests)
 
@@ -92,7 +108,7 @@ func TestStore_Get(t *testing.T) {
 		}
 
 		store.lock.Lock()
-		e := store.byKey[makeEntryKey(req.Type(), req.CacheInfo())]
+		e := store.byKey[makeEntryKey(req.Type(), req.CacheInfo(), Tenancy{})]
 		store.lock.Unlock()
 		require.Equal(t, 1, e.requests)
 	})
@@ -107,7 +123,7 @@ func TestStore_Get(t *testing.T) {
 		}
 
 		store.lock.Lock()
-		e := store.byKey[makeEntryKey(req.Type(), req.CacheInfo())]
+		e := store.byKey[makeEntryKey(req.Type(), req.CacheInfo(), Tenancy{})]
 		store.lock.Unlock()
 		require.Equal(t, 1, e.requests)
 	})
@@ -132,7 +148,7 @@ func TestStore_Get(t *testing.T) {
 		store.lock.Lock()
 		defer store.lock.Unlock()
 		require.Len(t, store.byKey, 1)
-		e := store.byKey[makeEntryKey(req.Type(), req.CacheInfo())]
+		e := store.byKey[makeEntryKey(req.Type(), req.CacheInfo(), Tenancy{})]
 		require.Equal(t, 0, e.expiry.Index())
 		require.Equal(t, 0, e.requests)
 
@@ -153,7 +169,7 @@ func TestStore_Get(t *testing.T) {
 		store.lock.Lock()
 		defer store.lock.Unlock()
 		require.Len(t, store.byKey, 1)
-		e := store.byKey[makeEntryKey(req.Type(), req.CacheInfo())]
+		e := store.byKey[makeEntryKey(req.Type(), req.CacheInfo(), Tenancy{})]
 		require.Equal(t, 0, e.expiry.Index())
 		require.Equal(t, 0, e.requests)
 
@@ -200,10 +216,12 @@ type resultOrError struct {
 }
 
 type fakeRequest struct {
+	token   string
 	index   uint64
 	timeout time.Duration
+	maxAge  time.Duration
 	key     string
-	client  *TestStreamingClient
+	client  *submatviewtest.StreamingClient
 }
 
 func (r *fakeRequest) CacheInfo() cache.RequestInfo {
@@ -211,11 +229,16 @@ func (r *fakeRequest) CacheInfo() cache.RequestInfo {
 	if key == "" {
 		key = "key"
 	}
+	token := r.token
+	if token == "" {
+		token = "abcd"
+	}
 	return cache.RequestInfo{
 		Key:        key,
-		Token:      "abcd",
+		Token:      token,
 		Datacenter: "dc1",
 		Timeout:    r.timeout,
+		MaxAge:     r.maxAge,
 		MinIndex:   r.index,
 	}
 }
@@ -288,14 +311,14 @@ func TestStore_Notify(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	store := NewStore(hclog.New(nil))
+	store := NewStore(hclog.New(nil), StoreOptions{})
 	go store.Run(ctx)
 
 	req := &fakeRequest{
-		client: NewTestStreamingClient(pbcommon.DefaultEnterpriseMeta.Namespace),
+		client: submatviewtest.NewStreamingClient(pbcommon.DefaultEnterpriseMeta.Namespace),
 	}
 	req.client.QueueEvents(
-		newEndOfSnapshotEvent(2),
+		submatviewtest.NewEndOfSnapshotEvent(2),
 		newEventServiceHealthRegister(22, 2, "srv1"))
 
 	cID := "correlate"
@@ -308,7 +331,7 @@ func TestStore_Notify(t *testing.T) {
 		store.lock.Lock()
 		defer store.lock.Unlock()
 		require.Len(t, store.byKey, 1)
-		e := store.byKey[makeEntryKey(req.Type(), req.CacheInfo())]
+		e := store.byKey[makeEntryKey(req.Type(), req.CacheInfo(), Tenancy{})]
 		require.Equal(t, ttlcache.NotIndexed, e.expiry.Index())
 		require.Equal(t, 1, e.requests)
 	})
@@ -345,7 +368,7 @@ func TestStore_Notify(t *testing.T) {
 		retry.Run(t, func(r *retry.R) {
 			store.lock.Lock()
 			defer store.lock.Unlock()
-			e := store.byKey[makeEntryKey(req.Type(), req.CacheInfo())]
+			e := store.byKey[makeEntryKey(req.Type(), req.CacheInfo(), Tenancy{})]
 			require.Equal(r, 0, e.expiry.Index())
 			require.Equal(r, 0, e.requests)
 			require.Equal(r, store.expiryHeap.Next().Entry, e.expiry)
@@ -353,17 +376,242 @@ func TestStore_Notify(t *testing.T) {
 	})
 }
 
+func TestStore_NotifyCallback(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := NewStore(hclog.New(nil), StoreOptions{})
+	go store.Run(ctx)
+
+	req := &fakeRequest{
+		client: submatviewtest.NewStreamingClient(pbcommon.DefaultEnterpriseMeta.Namespace),
+	}
+	req.client.QueueEvents(
+		submatviewtest.NewEndOfSnapshotEvent(2),
+		newEventServiceHealthRegister(22, 2, "srv1"))
+
+	ch := make(chan cache.UpdateEvent, 1)
+	cb := func(_ context.Context, event cache.UpdateEvent) {
+		ch <- event
+	}
+	require.NoError(t, store.NotifyCallback(ctx, req, "correlate", cb))
+
+	for _, index := range []uint64{22, 24} {
+		if index > 22 {
+			req.client.QueueEvents(newEventServiceHealthRegister(index, 2, "srv1"))
+		}
+		select {
+		case update := <-ch:
+			require.NoError(t, update.Err)
+			require.Equal(t, "correlate", update.CorrelationID)
+			require.Equal(t, index, update.Meta.Index)
+			require.Equal(t, index, update.Result.(fakeResult).index)
+		case <-time.After(time.Second):
+			t.Fatalf("expected the callback to be called for index %d", index)
+		}
+	}
+
+	runStep(t, "cancelling the context releases the entry", func(t *testing.T) {
+		cancel()
+
+		retry.Run(t, func(r *retry.R) {
+			assertRequestCount(r, store, req, 0)
+		})
+	})
+}
+
+func TestStore_Notify_LateSubscriberReceivesCurrentResult(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := NewStore(hclog.New(nil), StoreOptions{})
+	go store.Run(ctx)
+
+	client := submatviewtest.NewStreamingClient(pbcommon.DefaultEnterpriseMeta.Namespace)
+	client.QueueEvents(
+		newEventServiceHealthRegister(22, 2, "srv1"),
+		submatviewtest.NewEndOfSnapshotEvent(22))
+
+	requireUpdate := func(t *testing.T, ch chan cache.UpdateEvent, index uint64) {
+		t.Helper()
+		select {
+		case update := <-ch:
+			require.NoError(t, update.Err)
+			require.Equal(t, index, update.Meta.Index)
+			require.Equal(t, index, update.Result.(fakeResult).index)
+		case <-time.After(time.Second):
+			t.Fatalf("expected an update at index %d", index)
+		}
+	}
+
+	first := make(chan cache.UpdateEvent, 1)
+	require.NoError(t, store.Notify(ctx, &fakeRequest{client: client}, "first", first))
+	requireUpdate(t, first, 22)
+
+	runStep(t, "a subscriber at the index of the view receives the current result", func(t *testing.T) {
+		late := make(chan cache.UpdateEvent, 1)
+		require.NoError(t, store.Notify(ctx, &fakeRequest{client: client, index: 22}, "late", late))
+		requireUpdate(t, late, 22)
+
+		// Both subscribers share the entry, and receive the next update.
+		assertRequestCount(t, store, &fakeRequest{}, 2)
+		client.QueueEvents(newEventServiceHealthRegister(24, 2, "srv1"))
+		requireUpdate(t, first, 24)
+		requireUpdate(t, late, 24)
+	})
+
+	runStep(t, "a subscriber ahead of the view waits for its index", func(t *testing.T) {
+		ahead := make(chan cache.UpdateEvent, 1)
+		require.NoError(t, store.Notify(ctx, &fakeRequest{client: client, index: 30}, "ahead", ahead))
+
+		client.QueueEvents(newEventServiceHealthRegister(26, 2, "srv1"))
+		requireUpdate(t, first, 26)
+		select {
+		case update := <-ahead:
+			t.Fatalf("unexpected update at index %d", update.Meta.Index)
+		case <-time.After(50 * time.Millisecond):
+		}
+
+		client.QueueEvents(newEventServiceHealthRegister(30, 2, "srv1"))
+		requireUpdate(t, ahead, 30)
+	})
+}
+
+func TestStore_Notify_DeliversEveryUpdateToSlowReceiver(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	sink := metrics.NewInmemSink(time.Minute, time.Minute)
+	cfg := metrics.DefaultConfig("test")
+	cfg.EnableHostname = false
+	_, err := metrics.NewGlobal(cfg, sink)
+	require.NoError(t, err)
+
+	store := NewStore(hclog.New(nil), StoreOptions{})
+	go store.Run(ctx)
+
+	req := &fakeRequest{
+		client: submatviewtest.NewStreamingClient(pbcommon.DefaultEnterpriseMeta.Namespace),
+	}
+	req.client.QueueEvents(
+		submatviewtest.NewEndOfSnapshotEvent(2),
+		newEventServiceHealthRegister(10, 1, "srv1"))
+
+	// The slow receiver blocks on its first update until it is released.
+	slowCh := make(chan uint64, 10)
+	release := make(chan struct{})
+	require.NoError(t, store.NotifyCallback(ctx, req, "slow", func(ctx context.Context, u cache.UpdateEvent) {
+		slowCh <- u.Meta.Index
+		<-release
+	}))
+	select {
+	case index := <-slowCh:
+		require.Equal(t, uint64(10), index)
+	case <-time.After(time.Second):
+		t.Fatalf("expected an update for index 10")
+	}
+
+	fastCh := make(chan cache.UpdateEvent)
+	require.NoError(t, store.Notify(ctx, req, "fast", fastCh))
+
+	for _, index := range []uint64{10, 12, 14, 16} {
+		if index > 10 {
+			req.client.QueueEvents(newEventServiceHealthRegister(index, 1, "srv1"))
+		}
+		select {
+		case u := <-fastCh:
+			require.Equal(t, index, u.Meta.Index)
+		case <-time.After(time.Second):
+			t.Fatalf("expected an update for index %d", index)
+		}
+	}
+
+	// The view is not read again until the slow receiver has received the
+	// previous update, which then receives the latest result.
+	close(release)
+	select {
+	case index := <-slowCh:
+		require.Equal(t, uint64(16), index)
+	case <-time.After(time.Second):
+		t.Fatalf("expected an update for index 16")
+	}
+
+	for _, interval := range sink.Data() {
+		interval.RLock()
+		_, ok := interval.Counters["test.submatview.notify.coalesced"]
+		interval.RUnlock()
+		require.False(t, ok, "expected no coalesced updates")
+	}
+}
+
+func TestStore_Notify_CoalescesUpdatesForSlowReceiver(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := NewStore(hclog.New(nil), StoreOptions{CoalesceNotify: true})
+	go store.Run(ctx)
+
+	req := &fakeRequest{
+		client: submatviewtest.NewStreamingClient(pbcommon.DefaultEnterpriseMeta.Namespace),
+	}
+	req.client.QueueEvents(
+		submatviewtest.NewEndOfSnapshotEvent(2),
+		newEventServiceHealthRegister(10, 1, "srv1"))
+
+	slowCh := make(chan cache.UpdateEvent)
+	require.NoError(t, store.Notify(ctx, req, "slow", slowCh))
+
+	fastCh := make(chan cache.UpdateEvent)
+	require.NoError(t, store.Notify(ctx, req, "fast", fastCh))
+
+	for _, index := range []uint64{10, 12, 14, 16} {
+		if index > 10 {
+			req.client.QueueEvents(newEventServiceHealthRegister(index, 1, "srv1"))
+		}
+		// The slow receiver does not stop updates from being delivered to
+		// other receivers.
+		select {
+		case u := <-fastCh:
+			require.Equal(t, index, u.Meta.Index)
+		case <-time.After(time.Second):
+			t.Fatalf("expected an update for index %d", index)
+		}
+	}
+
+	// Give the slow receiver time to observe the latest update.
+	time.Sleep(50 * time.Millisecond)
+
+	// At most one update was waiting to be delivered, the rest are coalesced
+	// into the latest update.
+	var indexes []uint64
+	for len(indexes) == 0 || indexes[len(indexes)-1] != 16 {
+		select {
+		case u := <-slowCh:
+			indexes = append(indexes, u.Meta.Index)
+		case <-time.After(time.Second):
+			t.Fatalf("expected an update, got %v", indexes)
+		}
+	}
+	require.LessOrEqual(t, len(indexes), 2, "unexpected updates %v", indexes)
+
+	select {
+	case u := <-slowCh:
+		t.Fatalf("expected no more updates, got index %d", u.Meta.Index)
+	case <-time.After(50 * time.Millisecond):
+	}
+}
+
 func TestStore_Notify_ManyRequests(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	store := NewStore(hclog.New(nil))
+	store := NewStore(hclog.New(nil), StoreOptions{})
 	go store.Run(ctx)
 
 	req := &fakeRequest{
-		client: NewTestStreamingClient(pbcommon.DefaultEnterpriseMeta.Namespace),
+		client: submatviewtest.NewStreamingClient(pbcommon.DefaultEnterpriseMeta.Namespace),
 	}
-	req.client.QueueEvents(newEndOfSnapshotEvent(2))
+	req.client.QueueEvents(submatviewtest.NewEndOfSnapshotEvent(2))
 
 	cID := "correlate"
 	ch1 := make(chan cache.UpdateEvent)
@@ -436,8 +684,8 @@ func TestStore_Notify_ManyRequests(t *testing.T) {
 	runStep(t, "the expiry heap should contain two entries", func(t *testing.T) {
 		store.lock.Lock()
 		defer store.lock.Unlock()
-		e := store.byKey[makeEntryKey(req.Type(), req.CacheInfo())]
-		e2 := store.byKey[makeEntryKey(req2.Type(), req2.CacheInfo())]
+		e := store.byKey[makeEntryKey(req.Type(), req.CacheInfo(), Tenancy{})]
+		e2 := store.byKey[makeEntryKey(req2.Type(), req2.CacheInfo(), Tenancy{})]
 		require.Equal(t, 0, e2.expiry.Index())
 		require.Equal(t, 1, e.expiry.Index())
 
@@ -453,7 +701,7 @@ type testingT interface {
 func assertRequestCount(t testingT, s *Store, req Request, expected int) {
 	t.Helper()
 
-	key := makeEntryKey(req.Type(), req.CacheInfo())
+	key := makeEntryKey(req.Type(), req.CacheInfo(), requestTenancy(req))
 
 	s.lock.Lock()
 	defer s.lock.Unlock()
@@ -467,15 +715,14 @@ func TestStore_Run_ExpiresEntries(t *testing.T) {
 	ctx, cancel := context.WithCancel(context.Background())
 	defer cancel()
 
-	store := NewStore(hclog.New(nil))
 	ttl := 10 * time.Millisecond
-	store.idleTTL = ttl
+	store := NewStore(hclog.New(nil), StoreOptions{IdleTTL: ttl})
 	go store.Run(ctx)
 
 	req := &fakeRequest{
-		client: NewTestStreamingClient(pbcommon.DefaultEnterpriseMeta.Namespace),
+		client: submatviewtest.NewStreamingClient(pbcommon.DefaultEnterpriseMeta.Namespace),
 	}
-	req.client.QueueEvents(newEndOfSnapshotEvent(2))
+	req.client.QueueEvents(submatviewtest.NewEndOfSnapshotEvent(2))
 
 	cID := "correlate"
 	ch1 := make(chan cache.UpdateEvent)
@@ -488,7 +735,7 @@ func TestStore_Run_ExpiresEntries(t *testing.T) {
 
 	// Get a copy of the entry so that we can check it was expired later
 	store.lock.Lock()
-	e := store.byKey[makeEntryKey(req.Type(), req.CacheInfo())]
+	e := store.byKey[makeEntryKey(req.Type(), req.CacheInfo(), Tenancy{})]
 	store.lock.Unlock()
 
 	reqCancel()
@@ -505,9 +752,2425 @@ func TestStore_Run_ExpiresEntries(t *testing.T) {
 	require.Equal(t, ttlcache.NotIndexed, e.expiry.Index())
 }
 
-func runStep(t *testing.T, name string, fn func(t *testing.T)) {
-	t.Helper()
-	if !t.Run(name, fn) {
-		t.FailNow()
+func TestStore_Metrics(t *testing.T) {
+	// Only have a single interval for the test
+	sink := metrics.NewInmemSink(time.Minute, time.Minute)
+	cfg := metrics.DefaultConfig("test")
+	cfg.EnableHostname = false
+	metrics.NewGlobal(cfg, sink)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ttl := 10 * time.Millisecond
+	store := NewStore(hclog.New(nil), StoreOptions{IdleTTL: ttl})
+	go store.Run(ctx)
+
+	req := &fakeRequest{
+		client: submatviewtest.NewStreamingClient(pbcommon.DefaultEnterpriseMeta.Namespace),
+	}
+	req.client.QueueEvents(submatviewtest.NewEndOfSnapshotEvent(2))
+
+	reqCtx, reqCancel := context.WithCancel(context.Background())
+	defer reqCancel()
+	require.NoError(t, store.Notify(reqCtx, req, "correlate", make(chan cache.UpdateEvent)))
+
+	gauge := func(t require.TestingT, key string) float32 {
+		data := sink.Data()
+		require.Len(t, data, 1)
+		val, ok := data[0].Gauges[key]
+		require.True(t, ok, "missing gauge %v", key)
+		return val.Value
+	}
+
+	require.Equal(t, float32(1), gauge(t, "test.submatview.entries_count"))
+	require.Equal(t, float32(1), gauge(t, "test.submatview.subscriptions;topic=ServiceHealth"))
+
+	reqCancel()
+	retry.Run(t, func(r *retry.R) {
+		require.Equal(r, float32(0), gauge(r, "test.submatview.entries_count"))
+	})
+	require.Equal(t, float32(0), gauge(t, "test.submatview.subscriptions;topic=ServiceHealth"))
+
+	data := sink.Data()
+	require.Len(t, data, 1)
+	expired, ok := data[0].Counters["test.submatview.evict_expired"]
+	require.True(t, ok, "missing counter evict_expired")
+	require.Equal(t, 1, expired.Count)
+}
+
+type idleTTLRequest struct {
+	*fakeRequest
+	ttl time.Duration
+}
+
+func (r *idleTTLRequest) IdleTTL() time.Duration {
+	return r.ttl
+}
+
+func TestStore_IdleTTLRequest(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ttl := 10 * time.Millisecond
+	store := NewStore(hclog.New(nil), StoreOptions{IdleTTL: ttl})
+	go store.Run(ctx)
+
+	client := submatviewtest.NewStreamingClient(pbcommon.DefaultEnterpriseMeta.Namespace)
+	client.QueueEvents(submatviewtest.NewEndOfSnapshotEvent(2))
+
+	defaultTTL := &fakeRequest{client: client, key: "default"}
+	longTTL := &idleTTLRequest{fakeRequest: &fakeRequest{client: client, key: "long"}, ttl: time.Hour}
+	pinned := &idleTTLRequest{fakeRequest: &fakeRequest{client: client, key: "pinned"}, ttl: Pinned}
+
+	for _, req := range []Request{defaultTTL, longTTL, pinned} {
+		_, err := store.Get(ctx, req)
+		require.NoError(t, err)
+	}
+	// A request with the default TTL does not unpin the entry.
+	_, err := store.Get(ctx, &fakeRequest{client: client, key: "pinned"})
+	require.NoError(t, err)
+
+	// wait for the entry with the default TTL to expire, with lots of buffer
+	time.Sleep(3 * ttl)
+
+	entries := store.Entries()
+	require.Len(t, entries, 2)
+
+	require.Equal(t, "long", entries[0].Key)
+	require.False(t, entries[0].Pinned)
+	require.NotNil(t, entries[0].Expires)
+	require.True(t, entries[0].Expires.After(time.Now().Add(50*time.Minute)))
+
+	require.Equal(t, "pinned", entries[1].Key)
+	require.True(t, entries[1].Pinned)
+	require.Nil(t, entries[1].Expires)
+}
+
+func TestStore_MaxEntries_EvictsLeastRecentlyUsed(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := NewStore(hclog.New(nil), StoreOptions{MaxEntries: 2})
+	go store.Run(ctx)
+
+	client := submatviewtest.NewStreamingClient(pbcommon.DefaultEnterpriseMeta.Namespace)
+	client.QueueEvents(submatviewtest.NewEndOfSnapshotEvent(2))
+
+	get := func(t *testing.T, key string) *fakeRequest {
+		t.Helper()
+		req := &fakeRequest{client: client, key: key}
+		_, err := store.Get(ctx, req)
+		require.NoError(t, err)
+		return req
+	}
+
+	assertKeys := func(t *testing.T, reqs ...*fakeRequest) {
+		t.Helper()
+		store.lock.Lock()
+		defer store.lock.Unlock()
+		require.Len(t, store.byKey, len(reqs))
+		for _, req := range reqs {
+			require.Contains(t, store.byKey, makeEntryKey(req.Type(), req.CacheInfo(), Tenancy{}))
+		}
+	}
+
+	one := get(t, "one")
+	two := get(t, "two")
+	// use one again, so that two is the least recently used entry
+	get(t, "one")
+
+	runStep(t, "evicts the least recently used idle entry", func(t *testing.T) {
+		three := get(t, "three")
+		assertKeys(t, one, three)
+	})
+
+	reqCtx, reqCancel := context.WithCancel(ctx)
+	defer reqCancel()
+	require.NoError(t, store.Notify(reqCtx, one, "one", make(chan cache.UpdateEvent)))
+
+	runStep(t, "does not evict entries with active requests", func(t *testing.T) {
+		four := &fakeRequest{client: client, key: "four"}
+		require.NoError(t, store.Notify(reqCtx, four, "four", make(chan cache.UpdateEvent)))
+		assertKeys(t, one, four)
+
+		five := &fakeRequest{client: client, key: "five"}
+		require.NoError(t, store.Notify(reqCtx, five, "five", make(chan cache.UpdateEvent)))
+		assertKeys(t, one, four, five)
+	})
+
+	runStep(t, "evicted entries can be requested again", func(t *testing.T) {
+		reqCancel()
+		retry.Run(t, func(r *retry.R) {
+			assertRequestCount(r, store, one, 0)
+		})
+
+		get(t, "two")
+		store.lock.Lock()
+		defer store.lock.Unlock()
+		require.Len(t, store.byKey, 3)
+		require.Contains(t, store.byKey, makeEntryKey(two.Type(), two.CacheInfo(), Tenancy{}))
+		require.Equal(t, 3, store.idle.Len())
+	})
+}
+
+func TestStore_TerminalErrors(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := NewStore(hclog.New(nil), StoreOptions{})
+	go store.Run(ctx)
+
+	runStep(t, "Get returns the error before the timeout", func(t *testing.T) {
+		req := &fakeRequest{
+			client:  submatviewtest.NewStreamingClient(pbcommon.DefaultEnterpriseMeta.Namespace),
+			timeout: 10 * time.Second,
+		}
+		req.client.QueueErr(status.Error(codes.PermissionDenied, "Permission denied"))
+
+		start := time.Now()
+		_, err := store.Get(ctx, req)
+		require.Error(t, err)
+		require.Contains(t, err.Error(), "Permission denied")
+		require.True(t, time.Since(start) < time.Second,
+			"Get should have returned before the timeout")
+
+		// The failed entry is removed so that the next request starts a new
+		// materializer.
+		store.lock.Lock()
+		defer store.lock.Unlock()
+		require.Len(t, store.byKey, 0)
+	})
+
+	runStep(t, "Notify sends the error as an UpdateEvent and recovers", func(t *testing.T) {
+		client := &failingClient{
+			StreamingClient: submatviewtest.NewStreamingClient(pbcommon.DefaultEnterpriseMeta.Namespace),
+			fails:           3,
+			err:             status.Error(codes.Unknown, "ACL not found"),
+		}
+		client.QueueEvents(submatviewtest.NewEndOfSnapshotEvent(2))
+		req := &terminalErrRequest{fakeRequest: &fakeRequest{}, client: client}
+
+		ch := make(chan cache.UpdateEvent)
+		require.NoError(t, store.Notify(ctx, req, "correlate", ch))
+
+		select {
+		case update := <-ch:
+			require.Error(t, update.Err)
+			require.Equal(t, "correlate", update.CorrelationID)
+		case <-time.After(time.Second):
+			t.Fatalf("expected Notify to send the error")
+		}
+
+		runStep(t, "Get fails fast while the subscription is failing", func(t *testing.T) {
+			entries := store.Entries()
+			require.Len(t, entries, 1)
+			if entries[0].Index > 0 {
+				t.Skip("the subscription recovered before Get")
+			}
+			getReq := &terminalErrRequest{fakeRequest: &fakeRequest{timeout: 10 * time.Second}, client: client}
+			start := time.Now()
+			_, err := store.Get(ctx, getReq)
+			require.Error(t, err)
+			require.Contains(t, err.Error(), "ACL not found")
+			require.True(t, time.Since(start) < time.Second,
+				"Get should have returned before the timeout")
+		})
+
+		// The subscription is retried, so Notify delivers the view once the
+		// error goes away.
+		timeout := time.After(5 * time.Second)
+		for {
+			var update cache.UpdateEvent
+			select {
+			case update = <-ch:
+			case <-timeout:
+				t.Fatalf("expected Notify to recover from the error")
+			}
+			if update.Err == nil {
+				require.Equal(t, uint64(2), update.Meta.Index)
+				break
+			}
+		}
+		require.Equal(t, stateConnected, store.Entries()[0].State)
+		require.Equal(t, 4, client.attemptCount())
+	})
+}
+
+// terminalErrRequest is a fakeRequest which subscribes with a failingClient.
+type terminalErrRequest struct {
+	*fakeRequest
+	client *failingClient
+}
+
+func (r *terminalErrRequest) NewMaterializer() (*Materializer, error) {
+	m, err := r.fakeRequest.NewMaterializer()
+	if err != nil {
+		return nil, err
+	}
+	m.deps.Client = r.client
+	return m, nil
+}
+
+func TestStore_Entries(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := NewStore(hclog.New(nil), StoreOptions{})
+	go store.Run(ctx)
+
+	client := submatviewtest.NewStreamingClient(pbcommon.DefaultEnterpriseMeta.Namespace)
+	client.QueueEvents(submatviewtest.NewEndOfSnapshotEvent(2))
+
+	idle := &fakeRequest{client: client, key: "idle"}
+	_, err := store.Get(ctx, idle)
+	require.NoError(t, err)
+
+	active := &fakeRequest{client: client, key: "active"}
+	require.NoError(t, store.Notify(ctx, active, "active", make(chan cache.UpdateEvent, 1)))
+
+	retry.Run(t, func(r *retry.R) {
+		entries := store.Entries()
+		require.Len(r, entries, 2)
+
+		require.Equal(r, "active", entries[0].Key)
+		require.Equal(r, 1, entries[0].Requests)
+		require.Nil(r, entries[0].Expires)
+		require.Equal(r, uint64(2), entries[0].Index)
+
+		require.Equal(r, "idle", entries[1].Key)
+		require.Equal(r, "dc1", entries[1].Datacenter)
+		require.Equal(r, "ServiceHealth", entries[1].Topic)
+		require.Equal(r, 0, entries[1].Requests)
+		require.NotNil(r, entries[1].Expires)
+		require.Equal(r, stateConnected, entries[1].State)
+	})
+}
+
+func TestStore_Clear(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := NewStore(hclog.New(nil), StoreOptions{})
+	go store.Run(ctx)
+
+	client := submatviewtest.NewStreamingClient(pbcommon.DefaultEnterpriseMeta.Namespace)
+	client.QueueEvents(submatviewtest.NewEndOfSnapshotEvent(2))
+
+	idle := &fakeRequest{client: client, key: "idle"}
+	_, err := store.Get(ctx, idle)
+	require.NoError(t, err)
+
+	active := &fakeRequest{client: client, key: "active"}
+	require.NoError(t, store.Notify(ctx, active, "active", make(chan cache.UpdateEvent, 1)))
+
+	subscriptions := func() int {
+		return len(client.Requests())
+	}
+	retry.Run(t, func(r *retry.R) {
+		require.Equal(r, 2, subscriptions())
+	})
+
+	runStep(t, "prefix matches no entries", func(t *testing.T) {
+		require.Equal(t, 0, store.Clear(idle.Type()+"/dc2/"))
+		require.Len(t, store.Entries(), 2)
+	})
+
+	runStep(t, "idle entry is removed", func(t *testing.T) {
+		require.Equal(t, 1, store.Clear(idle.Type()+"/dc1/idle"))
+
+		entries := store.Entries()
+		require.Len(t, entries, 1)
+		require.Equal(t, "active", entries[0].Key)
+	})
+
+	runStep(t, "active entry resubscribes", func(t *testing.T) {
+		require.Equal(t, 1, store.Clear(""))
+
+		retry.Run(t, func(r *retry.R) {
+			require.Equal(r, 3, subscriptions())
+		})
+		retry.Run(t, func(r *retry.R) {
+			entries := store.Entries()
+			require.Len(r, entries, 1)
+			require.Equal(r, uint64(2), entries[0].Index)
+		})
+	})
+}
+
+func TestStore_RegisterView(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := NewStore(hclog.New(nil), StoreOptions{})
+	go store.Run(ctx)
+
+	client := submatviewtest.NewStreamingClient(pbcommon.DefaultEnterpriseMeta.Namespace)
+	client.QueueEvents(
+		submatviewtest.NewEndOfSnapshotEvent(2),
+		newEventServiceHealthRegister(10, 1, "srv1"))
+
+	spec := RequestSpec{
+		Subscribe: pbsubscribe.SubscribeRequest{
+			Topic:      pbsubscribe.Topic_ServiceHealth,
+			Key:        "srv1",
+			Datacenter: "dc1",
+			Namespace:  pbcommon.DefaultEnterpriseMeta.Namespace,
+		},
+		MinIndex: 5,
+		Client:   client,
 	}
+
+	runStep(t, "NewRequest fails without a registered view", func(t *testing.T) {
+		_, err := store.NewRequest(spec)
+		require.Error(t, err)
+	})
+
+	var subscribed pbsubscribe.SubscribeRequest
+	factory := func(req pbsubscribe.SubscribeRequest) (View, error) {
+		subscribed = req
+		return &fakeView{srvs: make(map[string]*pbservice.CheckServiceNode)}, nil
+	}
+	require.NoError(t, store.RegisterView(pbsubscribe.Topic_ServiceHealth, factory))
+
+	runStep(t, "RegisterView fails when the topic is already registered", func(t *testing.T) {
+		err := store.RegisterView(pbsubscribe.Topic_ServiceHealth, factory)
+		require.Error(t, err)
+	})
+
+	runStep(t, "Get uses the registered view", func(t *testing.T) {
+		req, err := store.NewRequest(spec)
+		require.NoError(t, err)
+
+		result, err := store.Get(ctx, req)
+		require.NoError(t, err)
+		require.Equal(t, uint64(10), result.Index)
+		require.Len(t, result.Value.(fakeResult).srvs, 1)
+		require.Equal(t, "srv1", subscribed.Key)
+	})
+
+	runStep(t, "requests with a different filter do not share a view", func(t *testing.T) {
+		req, err := store.NewRequest(spec)
+		require.NoError(t, err)
+
+		filtered := spec
+		filtered.Subscribe.Filter = `Service.Meta.version == "2"`
+		filteredReq, err := store.NewRequest(filtered)
+		require.NoError(t, err)
+
+		require.NotEqual(t, req.CacheInfo().Key, filteredReq.CacheInfo().Key)
+	})
+}
+
+func TestStore_Get_MaxAge(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := NewStore(hclog.New(nil), StoreOptions{})
+	go store.Run(ctx)
+
+	req := &fakeRequest{
+		client: submatviewtest.NewStreamingClient(pbcommon.DefaultEnterpriseMeta.Namespace),
+		maxAge: 20 * time.Millisecond,
+	}
+	req.client.QueueEvents(
+		submatviewtest.NewEndOfSnapshotEvent(2),
+		newEventServiceHealthRegister(10, 1, "srv1"))
+
+	runStep(t, "returns a result while the subscription is connected", func(t *testing.T) {
+		result, err := store.Get(ctx, req)
+		require.NoError(t, err)
+		require.Equal(t, uint64(10), result.Index)
+
+		time.Sleep(2 * req.maxAge)
+		result, err = store.Get(ctx, req)
+		require.NoError(t, err)
+		// The view is up to date while the subscription is connected.
+		require.True(t, result.Meta().Age < req.maxAge, "unexpected age: %v", result.Meta().Age)
+	})
+
+	runStep(t, "errors when the view has been disconnected longer than MaxAge", func(t *testing.T) {
+		store.lock.Lock()
+		m := store.byKey[makeEntryKey(req.Type(), req.CacheInfo(), Tenancy{})].materializer
+		store.lock.Unlock()
+
+		// Simulate a subscription which lost its connection a minute ago.
+		lastContact := time.Now().Add(-time.Minute)
+		m.lock.Lock()
+		m.state = stateRetrying
+		m.lastContact = lastContact
+		m.lock.Unlock()
+
+		result, err := store.Get(ctx, req)
+		require.True(t, errors.Is(err, ErrViewTooStale), "unexpected error: %v", err)
+		require.Equal(t, uint64(10), result.Index)
+		require.Equal(t, lastContact, result.LastContact)
+		require.True(t, result.Meta().Age >= time.Minute)
+	})
+
+	runStep(t, "MaxAge of 0 allows a stale view", func(t *testing.T) {
+		req.maxAge = 0
+		result, err := store.Get(ctx, req)
+		require.NoError(t, err)
+		require.Equal(t, uint64(10), result.Index)
+		require.True(t, result.Meta().Age >= time.Minute)
+	})
+
+	runStep(t, "Notify reports the age of the view", func(t *testing.T) {
+		ch := make(chan cache.UpdateEvent)
+		require.NoError(t, store.Notify(ctx, req, "update", ch))
+
+		select {
+		case u := <-ch:
+			require.Equal(t, uint64(10), u.Meta.Index)
+			require.True(t, u.Meta.Age >= time.Minute)
+		case <-time.After(time.Second):
+			t.Fatalf("expected an update")
+		}
+	})
+}
+
+type fakeTokenSource struct {
+	lock  sync.Mutex
+	token string
+}
+
+func (s *fakeTokenSource) Name() string {
+	return "fake"
+}
+
+func (s *fakeTokenSource) Token() string {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return s.token
+}
+
+func (s *fakeTokenSource) setToken(token string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.token = token
+}
+
+// tokenRecordingClient records the token of each subscribe call.
+type tokenRecordingClient struct {
+	*submatviewtest.StreamingClient
+	lock   sync.Mutex
+	tokens []string
+}
+
+func (c *tokenRecordingClient) Subscribe(
+	ctx context.Context,
+	req *pbsubscribe.SubscribeRequest,
+	opts ...grpc.CallOption,
+) (pbsubscribe.StateChangeSubscription_SubscribeClient, error) {
+	c.lock.Lock()
+	c.tokens = append(c.tokens, req.Token)
+	c.lock.Unlock()
+	return c.StreamingClient.Subscribe(ctx, req, opts...)
+}
+
+func (c *tokenRecordingClient) lastToken() string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if len(c.tokens) == 0 {
+		return ""
+	}
+	return c.tokens[len(c.tokens)-1]
+}
+
+func TestStore_SetDefaultTokenSource(t *testing.T) {
+	store := NewStore(hclog.New(nil), StoreOptions{})
+	factory := func(req pbsubscribe.SubscribeRequest) (View, error) {
+		return &fakeView{srvs: make(map[string]*pbservice.CheckServiceNode)}, nil
+	}
+	require.NoError(t, store.RegisterView(pbsubscribe.Topic_ServiceHealth, factory))
+	store.SetDefaultTokenSource(&fakeTokenSource{token: "one"})
+
+	newRequest := func(token string) Request {
+		req, err := store.NewRequest(RequestSpec{
+			Subscribe: pbsubscribe.SubscribeRequest{
+				Topic: pbsubscribe.Topic_ServiceHealth,
+				Key:   "srv1",
+				Token: token,
+			},
+			Client: submatviewtest.NewStreamingClient(""),
+		})
+		require.NoError(t, err)
+		return req
+	}
+
+	// Requests made with the current token of the source use the source.
+	req := newRequest("one")
+	require.True(t, usesTokenSource(req))
+	require.Equal(t, "token-source:fake", req.CacheInfo().Token)
+
+	req = newRequest("other")
+	require.False(t, usesTokenSource(req))
+	require.Equal(t, "other", req.CacheInfo().Token)
+}
+
+func TestStore_NewRequest_NewView(t *testing.T) {
+	store := NewStore(hclog.New(nil), StoreOptions{})
+	newView := func(req pbsubscribe.SubscribeRequest) (View, error) {
+		return &fakeView{srvs: make(map[string]*pbservice.CheckServiceNode)}, nil
+	}
+	newRequest := func(viewType string) (Request, error) {
+		return store.NewRequest(RequestSpec{
+			Subscribe: pbsubscribe.SubscribeRequest{
+				Topic: pbsubscribe.Topic_ServiceHealth,
+				Key:   "srv1",
+			},
+			Client:   submatviewtest.NewStreamingClient(""),
+			NewView:  newView,
+			ViewType: viewType,
+		})
+	}
+
+	_, err := newRequest("")
+	require.Error(t, err)
+
+	one, err := newRequest("one")
+	require.NoError(t, err)
+	two, err := newRequest("two")
+	require.NoError(t, err)
+	require.NotEqual(t, store.entryKey(one), store.entryKey(two))
+
+	other, err := newRequest("one")
+	require.NoError(t, err)
+	require.Equal(t, store.entryKey(one), store.entryKey(other))
+}
+
+func TestStore_RefreshTokens(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := NewStore(hclog.New(nil), StoreOptions{})
+	go store.Run(ctx)
+
+	factory := func(req pbsubscribe.SubscribeRequest) (View, error) {
+		return &fakeView{srvs: make(map[string]*pbservice.CheckServiceNode)}, nil
+	}
+	require.NoError(t, store.RegisterView(pbsubscribe.Topic_ServiceHealth, factory))
+
+	client := &tokenRecordingClient{
+		StreamingClient: submatviewtest.NewStreamingClient(pbcommon.DefaultEnterpriseMeta.Namespace),
+	}
+	client.QueueEvents(
+		submatviewtest.NewEndOfSnapshotEvent(2),
+		newEventServiceHealthRegister(10, 1, "srv1"))
+
+	source := &fakeTokenSource{token: "one"}
+	newRequest := func() Request {
+		req, err := store.NewRequest(RequestSpec{
+			Subscribe: pbsubscribe.SubscribeRequest{
+				Topic:      pbsubscribe.Topic_ServiceHealth,
+				Key:        "srv1",
+				Token:      "ignored",
+				Datacenter: "dc1",
+				Namespace:  pbcommon.DefaultEnterpriseMeta.Namespace,
+			},
+			Client:      client,
+			TokenSource: source,
+		})
+		require.NoError(t, err)
+		return req
+	}
+
+	ch := make(chan cache.UpdateEvent)
+	require.NoError(t, store.Notify(ctx, newRequest(), "update", ch))
+
+	select {
+	case u := <-ch:
+		require.Equal(t, uint64(10), u.Meta.Index)
+	case <-time.After(time.Second):
+		t.Fatalf("expected an update")
+	}
+	require.Equal(t, "one", client.lastToken())
+
+	runStep(t, "RefreshTokens is a no-op when the token did not change", func(t *testing.T) {
+		store.RefreshTokens()
+		time.Sleep(20 * time.Millisecond)
+
+		client.lock.Lock()
+		defer client.lock.Unlock()
+		require.Equal(t, []string{"one"}, client.tokens)
+	})
+
+	runStep(t, "RefreshTokens resubscribes with the new token", func(t *testing.T) {
+		source.setToken("two")
+		store.RefreshTokens()
+
+		retry.Run(t, func(r *retry.R) {
+			require.Equal(r, "two", client.lastToken())
+		})
+
+		// The request is keyed by the source, so it shares the existing view.
+		require.Len(t, store.Entries(), 1)
+		result, err := store.Get(ctx, newRequest())
+		require.NoError(t, err)
+		require.Equal(t, uint64(10), result.Index)
+	})
+
+	runStep(t, "Notify continues to deliver updates", func(t *testing.T) {
+		client.QueueEvents(newEventServiceHealthRegister(12, 1, "srv1"))
+
+		for {
+			select {
+			case u := <-ch:
+				require.NoError(t, u.Err)
+				if u.Meta.Index == 12 {
+					return
+				}
+			case <-time.After(time.Second):
+				t.Fatalf("expected an update for index 12")
+			}
+		}
+	})
+}
+
+func TestStore_ShareByACLPolicies(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := NewStore(hclog.New(nil), StoreOptions{ShareByACLPolicies: true})
+	go store.Run(ctx)
+
+	client := submatviewtest.NewStreamingClient(pbcommon.DefaultEnterpriseMeta.Namespace)
+	client.QueueEvents(
+		submatviewtest.NewEndOfSnapshotEvent(2),
+		newEventServiceHealthRegister(10, 1, "srv1"))
+
+	get := func(token string) {
+		req := &fakeRequest{client: client, token: token}
+		result, err := store.Get(ctx, req)
+		require.NoError(t, err)
+		require.Equal(t, uint64(10), result.Index)
+	}
+
+	runStep(t, "entries are keyed by token without a TokenKeyFunc", func(t *testing.T) {
+		get("one")
+		get("two")
+		require.Len(t, store.Entries(), 2)
+	})
+
+	store.SetTokenKeyFunc(func(token string) (string, error) {
+		switch token {
+		case "three", "four":
+			return "same-access", nil
+		}
+		return "", fmt.Errorf("token %v can not share a view", token)
+	})
+
+	runStep(t, "tokens with the same access share an entry", func(t *testing.T) {
+		get("three")
+		get("four")
+		require.Len(t, store.Entries(), 3)
+	})
+
+	runStep(t, "tokens which can not be resolved are keyed by token", func(t *testing.T) {
+		get("five")
+		require.Len(t, store.Entries(), 4)
+	})
+
+	runStep(t, "requests with a TokenSource are keyed by the source", func(t *testing.T) {
+		var resolved []string
+		store.SetTokenKeyFunc(func(token string) (string, error) {
+			resolved = append(resolved, token)
+			return "same-access", nil
+		})
+		factory := func(req pbsubscribe.SubscribeRequest) (View, error) {
+			return &fakeView{srvs: make(map[string]*pbservice.CheckServiceNode)}, nil
+		}
+		require.NoError(t, store.RegisterView(pbsubscribe.Topic_ServiceHealth, factory))
+
+		source := &fakeTokenSource{token: "three"}
+		for i := 0; i < 2; i++ {
+			req, err := store.NewRequest(RequestSpec{
+				Subscribe: pbsubscribe.SubscribeRequest{
+					Topic:      pbsubscribe.Topic_ServiceHealth,
+					Key:        "srv1",
+					Datacenter: "dc1",
+					Namespace:  pbcommon.DefaultEnterpriseMeta.Namespace,
+				},
+				Client:      client,
+				TokenSource: source,
+			})
+			require.NoError(t, err)
+			result, err := store.Get(ctx, req)
+			require.NoError(t, err)
+			require.Equal(t, uint64(10), result.Index)
+			source.setToken("four")
+		}
+		require.Len(t, store.Entries(), 5)
+		require.Empty(t, resolved)
+	})
+}
+
+// revokingClient rejects the subscriptions made with a deleted token.
+type revokingClient struct {
+	*submatviewtest.StreamingClient
+	lock    sync.Mutex
+	deleted map[string]bool
+}
+
+func (c *revokingClient) deleteToken(token string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.deleted[token] = true
+}
+
+func (c *revokingClient) Subscribe(
+	ctx context.Context,
+	req *pbsubscribe.SubscribeRequest,
+	opts ...grpc.CallOption,
+) (pbsubscribe.StateChangeSubscription_SubscribeClient, error) {
+	c.lock.Lock()
+	deleted := c.deleted[req.Token]
+	c.lock.Unlock()
+	if deleted {
+		return nil, status.Error(codes.Unknown, acl.ErrNotFound.Error())
+	}
+	return c.StreamingClient.Subscribe(ctx, req, opts...)
+}
+
+func TestStore_ShareByACLPolicies_DeletedToken(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := NewStore(hclog.New(nil), StoreOptions{ShareByACLPolicies: true})
+	go store.Run(ctx)
+	store.SetTokenKeyFunc(func(string) (string, error) {
+		return "same-access", nil
+	})
+	factory := func(req pbsubscribe.SubscribeRequest) (View, error) {
+		return &fakeView{srvs: make(map[string]*pbservice.CheckServiceNode)}, nil
+	}
+	require.NoError(t, store.RegisterView(pbsubscribe.Topic_ServiceHealth, factory))
+
+	client := &revokingClient{
+		StreamingClient: submatviewtest.NewStreamingClient(pbcommon.DefaultEnterpriseMeta.Namespace),
+		deleted:         make(map[string]bool),
+	}
+	client.QueueEvents(
+		submatviewtest.NewEndOfSnapshotEvent(2),
+		newEventServiceHealthRegister(10, 1, "srv1"))
+
+	updates := make(chan cache.UpdateEvent, 10)
+	for _, token := range []string{"one", "two"} {
+		req, err := store.NewRequest(RequestSpec{
+			Subscribe: pbsubscribe.SubscribeRequest{
+				Topic:      pbsubscribe.Topic_ServiceHealth,
+				Key:        "srv1",
+				Datacenter: "dc1",
+				Namespace:  pbcommon.DefaultEnterpriseMeta.Namespace,
+				Token:      token,
+			},
+			Client: client,
+		})
+		require.NoError(t, err)
+		require.NoError(t, store.Notify(ctx, req, token, updates))
+	}
+	require.Len(t, store.Entries(), 1)
+
+	waitForIndex := func(t *testing.T, index uint64) {
+		t.Helper()
+		received := make(map[string]bool)
+		for len(received) < 2 {
+			select {
+			case u := <-updates:
+				require.NoError(t, u.Err)
+				if u.Meta.Index == index {
+					received[u.CorrelationID] = true
+				}
+			case <-time.After(5 * time.Second):
+				t.Fatalf("timeout waiting for index %d, received %v", index, received)
+			}
+		}
+	}
+	waitForIndex(t, 10)
+
+	// The token of the subscription is deleted, which ends the subscription.
+	client.deleteToken("one")
+	client.FailAtIndex(11, status.Error(codes.Unknown, acl.ErrNotFound.Error()))
+	client.QueueEvents(newEventServiceHealthRegister(11, 2, "srv1"))
+
+	waitForIndex(t, 11)
+	requests := client.Requests()
+	require.Equal(t, "two", requests[len(requests)-1].Token)
+}
+
+func TestStore_ShareNamespaces(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := submatviewtest.NewStreamingClient(pbcommon.DefaultEnterpriseMeta.Namespace)
+	client.AcceptNamespaces("ns1", "ns2", WildcardNamespace)
+	client.QueueEvents(
+		newEventServiceHealthRegisterInNamespace(2, 1, "srv1", "ns1"),
+		newEventServiceHealthRegisterInNamespace(2, 2, "srv1", "ns2"),
+		submatviewtest.NewEndOfSnapshotEvent(2))
+
+	get := func(t *testing.T, store *Store, ns string) fakeResult {
+		t.Helper()
+		req := &fakeTenancyRequest{
+			fakeRequest: fakeRequest{client: client},
+			tenancy:     Tenancy{Namespace: ns},
+		}
+		result, err := store.Get(ctx, req)
+		require.NoError(t, err)
+		require.Equal(t, uint64(2), result.Index)
+		return result.Value.(fakeResult)
+	}
+
+	runStep(t, "each namespace has an entry by default", func(t *testing.T) {
+		store := NewStore(hclog.New(nil), StoreOptions{})
+		go store.Run(ctx)
+
+		get(t, store, "ns1")
+		get(t, store, "ns2")
+
+		entries := store.Entries()
+		require.Len(t, entries, 2)
+		require.Equal(t, "ns1", entries[0].Namespace)
+		require.Equal(t, "ns2", entries[1].Namespace)
+	})
+
+	runStep(t, "namespaces share the entry of the wildcard namespace", func(t *testing.T) {
+		store := NewStore(hclog.New(nil), StoreOptions{ShareNamespaces: true})
+		go store.Run(ctx)
+
+		subscriptions := len(client.Requests())
+		ns1 := get(t, store, "ns1")
+		require.Len(t, ns1.srvs, 1)
+		require.Equal(t, "node1", ns1.srvs[0].Node.Node)
+
+		ns2 := get(t, store, "ns2")
+		require.Len(t, ns2.srvs, 1)
+		require.Equal(t, "node2", ns2.srvs[0].Node.Node)
+
+		require.Len(t, get(t, store, "ns3").srvs, 0)
+
+		entries := store.Entries()
+		require.Len(t, entries, 1)
+		require.Equal(t, WildcardNamespace, entries[0].Namespace)
+
+		requests := client.Requests()
+		require.Len(t, requests, subscriptions+1)
+		require.Equal(t, WildcardNamespace, requests[len(requests)-1].Namespace)
+	})
+
+	runStep(t, "Notify receives the result of its namespace", func(t *testing.T) {
+		store := NewStore(hclog.New(nil), StoreOptions{ShareNamespaces: true})
+		go store.Run(ctx)
+
+		req := &fakeTenancyRequest{
+			fakeRequest: fakeRequest{client: client},
+			tenancy:     Tenancy{Namespace: "ns2"},
+		}
+		ch := make(chan cache.UpdateEvent, 1)
+		require.NoError(t, store.Notify(ctx, req, "ns2", ch))
+
+		select {
+		case update := <-ch:
+			require.NoError(t, update.Err)
+			srvs := update.Result.(fakeResult).srvs
+			require.Len(t, srvs, 1)
+			require.Equal(t, "node2", srvs[0].Node.Node)
+		case <-time.After(time.Second):
+			t.Fatal("expected an update")
+		}
+	})
+
+	runStep(t, "the result of a namespace is limited by MaxResultItems", func(t *testing.T) {
+		client := submatviewtest.NewStreamingClient(pbcommon.DefaultEnterpriseMeta.Namespace)
+		client.AcceptNamespaces("ns1", "ns2", WildcardNamespace)
+		client.QueueEvents(
+			newEventServiceHealthRegisterInNamespace(2, 1, "srv1", "ns1"),
+			newEventServiceHealthRegisterInNamespace(2, 2, "srv1", "ns1"),
+			newEventServiceHealthRegisterInNamespace(2, 3, "srv1", "ns2"),
+			submatviewtest.NewEndOfSnapshotEvent(2))
+
+		store := NewStore(hclog.New(nil), StoreOptions{ShareNamespaces: true, MaxResultItems: 1})
+		go store.Run(ctx)
+
+		newRequest := func(ns string) *fakeTenancyRequest {
+			return &fakeTenancyRequest{
+				fakeRequest: fakeRequest{client: client},
+				tenancy:     Tenancy{Namespace: ns},
+			}
+		}
+
+		// The view of every namespace has 3 items, but only the items of the
+		// namespace of the request are counted.
+		result, err := store.Get(ctx, newRequest("ns2"))
+		require.NoError(t, err)
+		require.Len(t, result.Value.(fakeResult).srvs, 1)
+
+		_, err = store.Get(ctx, newRequest("ns1"))
+		require.Equal(t, ResultTooLargeError{Items: 2, Max: 1}, err)
+	})
+}
+
+// fakeTenancyRequest is a fakeRequest in a namespace, which implements
+// WildcardNamespaceRequest.
+type fakeTenancyRequest struct {
+	fakeRequest
+	tenancy Tenancy
+}
+
+func (r *fakeTenancyRequest) Tenancy() Tenancy {
+	return r.tenancy
+}
+
+func (r *fakeTenancyRequest) WithWildcardNamespace() TenancyRequest {
+	wildcard := *r
+	wildcard.tenancy.Namespace = WildcardNamespace
+	return &wildcard
+}
+
+func (r *fakeTenancyRequest) NewMaterializer() (*Materializer, error) {
+	return NewMaterializer(Deps{
+		View:   &fakeTenancyView{fakeView: &fakeView{srvs: make(map[string]*pbservice.CheckServiceNode)}},
+		Client: r.client,
+		Logger: hclog.New(nil),
+		Request: func(index uint64) pbsubscribe.SubscribeRequest {
+			return pbsubscribe.SubscribeRequest{
+				Topic:      pbsubscribe.Topic_ServiceHealth,
+				Key:        "key",
+				Token:      "abcd",
+				Datacenter: "dc1",
+				Index:      index,
+				Namespace:  r.tenancy.Namespace,
+			}
+		},
+	}), nil
+}
+
+// fakeTenancyView is a fakeView which implements TenancyView.
+type fakeTenancyView struct {
+	*fakeView
+}
+
+func (f *fakeTenancyView) TenancyLen(tenancy Tenancy) int {
+	n := 0
+	for _, srv := range f.srvs {
+		if srv.Service.EnterpriseMeta.Namespace == tenancy.Namespace {
+			n++
+		}
+	}
+	return n
+}
+
+func (f *fakeTenancyView) TenancyResult(index uint64, tenancy Tenancy) interface{} {
+	srvs := make([]*pbservice.CheckServiceNode, 0, len(f.srvs))
+	for _, srv := range f.srvs {
+		if srv.Service.EnterpriseMeta.Namespace == tenancy.Namespace {
+			srvs = append(srvs, srv)
+		}
+	}
+	return fakeResult{srvs: srvs, index: index}
+}
+
+func newEventServiceHealthRegisterInNamespace(index uint64, nodeNum int, svc, ns string) *pbsubscribe.Event {
+	event := newEventServiceHealthRegister(index, nodeNum, svc)
+	event.GetServiceHealth().CheckServiceNode.Service.EnterpriseMeta.Namespace = ns
+	return event
+}
+
+func runStep(t *testing.T, name string, fn func(t *testing.T)) {
+	t.Helper()
+	if !t.Run(name, fn) {
+		t.FailNow()
+	}
+}
+
+// failingClient fails the first fails calls to Subscribe with err, or with a
+// retryable error if err is nil, and counts the calls.
+type failingClient struct {
+	*submatviewtest.StreamingClient
+	lock     sync.Mutex
+	fails    int
+	err      error
+	attempts int
+}
+
+func (c *failingClient) Subscribe(
+	ctx context.Context,
+	req *pbsubscribe.SubscribeRequest,
+	opts ...grpc.CallOption,
+) (pbsubscribe.StateChangeSubscription_SubscribeClient, error) {
+	c.lock.Lock()
+	c.attempts++
+	attempt := c.attempts
+	c.lock.Unlock()
+	if attempt <= c.fails && c.err != nil {
+		return nil, c.err
+	}
+	if attempt <= c.fails {
+		return nil, status.Error(codes.Unavailable, "servers are restarting")
+	}
+	return c.StreamingClient.Subscribe(ctx, req, opts...)
+}
+
+func (c *failingClient) attemptCount() int {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return c.attempts
+}
+
+func TestStore_Backoff_CircuitBreaker(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := NewStore(hclog.New(nil), StoreOptions{
+		Backoff: Backoff{
+			InitialWait:             time.Millisecond,
+			MaxWait:                 5 * time.Millisecond,
+			CircuitBreakerThreshold: 3,
+			CircuitBreakerCooldown:  200 * time.Millisecond,
+		},
+	})
+	go store.Run(ctx)
+
+	factory := func(req pbsubscribe.SubscribeRequest) (View, error) {
+		return &fakeView{srvs: make(map[string]*pbservice.CheckServiceNode)}, nil
+	}
+	require.NoError(t, store.RegisterView(pbsubscribe.Topic_ServiceHealth, factory))
+
+	client := &failingClient{
+		StreamingClient: submatviewtest.NewStreamingClient(pbcommon.DefaultEnterpriseMeta.Namespace),
+		fails:           4,
+	}
+	client.QueueEvents(
+		submatviewtest.NewEndOfSnapshotEvent(2),
+		newEventServiceHealthRegister(10, 1, "srv1"))
+
+	req, err := store.NewRequest(RequestSpec{
+		Subscribe: pbsubscribe.SubscribeRequest{
+			Topic:      pbsubscribe.Topic_ServiceHealth,
+			Key:        "srv1",
+			Datacenter: "dc1",
+			Namespace:  pbcommon.DefaultEnterpriseMeta.Namespace,
+		},
+		Client: client,
+	})
+	require.NoError(t, err)
+
+	ch := make(chan cache.UpdateEvent)
+	require.NoError(t, store.Notify(ctx, req, "update", ch))
+
+	runStep(t, "the circuit breaker opens after the threshold", func(t *testing.T) {
+		retry.Run(t, func(r *retry.R) {
+			entries := store.Entries()
+			require.Len(r, entries, 1)
+			require.Equal(r, stateCircuitOpen, entries[0].State)
+		})
+		require.Equal(t, 3, client.attemptCount())
+
+		openCircuits.Lock()
+		defer openCircuits.Unlock()
+		require.Equal(t, 1, openCircuits.count)
+	})
+
+	runStep(t, "the circuit breaker closes when the subscription recovers", func(t *testing.T) {
+		timeout := time.After(2 * time.Second)
+		for {
+			var u cache.UpdateEvent
+			select {
+			case u = <-ch:
+			case <-timeout:
+				t.Fatalf("expected an update for index 10")
+			}
+			if u.Err == nil && u.Meta.Index == 10 {
+				break
+			}
+		}
+		require.Equal(t, 5, client.attemptCount())
+		require.Equal(t, stateConnected, store.Entries()[0].State)
+
+		openCircuits.Lock()
+		defer openCircuits.Unlock()
+		require.Equal(t, 0, openCircuits.count)
+	})
+}
+
+func TestBackoff_withDefaults(t *testing.T) {
+	require.Equal(t, DefaultBackoff, Backoff{}.withDefaults())
+
+	b := Backoff{MaxWait: time.Second, CircuitBreakerThreshold: 5}.withDefaults()
+	require.Equal(t, Backoff{
+		InitialWait:             DefaultBackoff.InitialWait,
+		MaxWait:                 time.Second,
+		CircuitBreakerThreshold: 5,
+		CircuitBreakerCooldown:  time.Second,
+	}, b)
+}
+
+// persistentFakeView is a fakeView which implements PersistentView.
+type persistentFakeView struct {
+	fakeView
+}
+
+func (f *persistentFakeView) MarshalState() ([]byte, error) {
+	return json.Marshal(f.srvs)
+}
+
+func (f *persistentFakeView) RestoreState(data []byte) error {
+	srvs := make(map[string]*pbservice.CheckServiceNode)
+	if err := json.Unmarshal(data, &srvs); err != nil {
+		return err
+	}
+	f.srvs = srvs
+	return nil
+}
+
+// indexRecordingClient records the index of each call to Subscribe.
+type indexRecordingClient struct {
+	*submatviewtest.StreamingClient
+	lock    sync.Mutex
+	indexes []uint64
+}
+
+func (c *indexRecordingClient) Subscribe(
+	ctx context.Context,
+	req *pbsubscribe.SubscribeRequest,
+	opts ...grpc.CallOption,
+) (pbsubscribe.StateChangeSubscription_SubscribeClient, error) {
+	c.lock.Lock()
+	c.indexes = append(c.indexes, req.Index)
+	c.lock.Unlock()
+	return c.StreamingClient.Subscribe(ctx, req, opts...)
+}
+
+func (c *indexRecordingClient) recordedIndexes() []uint64 {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]uint64(nil), c.indexes...)
+}
+
+func TestStore_SaveSnapshots(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	dir := testutil.TempDir(t, "submatview")
+	newStore := func() *Store {
+		store := NewStore(hclog.New(nil), StoreOptions{SnapshotDir: dir})
+		go store.Run(ctx)
+		factory := func(req pbsubscribe.SubscribeRequest) (View, error) {
+			return &persistentFakeView{
+				fakeView: fakeView{srvs: make(map[string]*pbservice.CheckServiceNode)},
+			}, nil
+		}
+		require.NoError(t, store.RegisterView(pbsubscribe.Topic_ServiceHealth, factory))
+		return store
+	}
+	newRequest := func(store *Store, client StreamClient) Request {
+		req, err := store.NewRequest(RequestSpec{
+			Subscribe: pbsubscribe.SubscribeRequest{
+				Topic:      pbsubscribe.Topic_ServiceHealth,
+				Key:        "srv1",
+				Token:      "abcd",
+				Datacenter: "dc1",
+				Namespace:  pbcommon.DefaultEnterpriseMeta.Namespace,
+			},
+			Client: client,
+		})
+		require.NoError(t, err)
+		return req
+	}
+
+	store := newStore()
+	client := submatviewtest.NewStreamingClient(pbcommon.DefaultEnterpriseMeta.Namespace)
+	client.QueueEvents(
+		submatviewtest.NewEndOfSnapshotEvent(2),
+		newEventServiceHealthRegister(10, 1, "srv1"))
+
+	result, err := store.Get(ctx, newRequest(store, client))
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), result.Index)
+	require.NoError(t, store.SaveSnapshots())
+
+	data, err := ioutil.ReadFile(filepath.Join(dir, snapshotsPath))
+	require.NoError(t, err)
+	require.NotContains(t, string(data), "abcd", "the token must not be persisted")
+
+	runStep(t, "a new store resumes the view from the snapshot", func(t *testing.T) {
+		restored := newStore()
+		client := &indexRecordingClient{
+			StreamingClient: submatviewtest.NewStreamingClient(pbcommon.DefaultEnterpriseMeta.Namespace),
+		}
+
+		result, err := restored.Get(ctx, newRequest(restored, client))
+		require.NoError(t, err)
+		require.Equal(t, uint64(10), result.Index)
+		require.True(t, result.Cached)
+		require.Len(t, result.Value.(fakeResult).srvs, 1)
+
+		retry.Run(t, func(r *retry.R) {
+			require.Equal(r, []uint64{10}, client.recordedIndexes())
+		})
+	})
+
+	runStep(t, "snapshots older than the idle TTL are discarded", func(t *testing.T) {
+		expired := NewStore(hclog.New(nil), StoreOptions{SnapshotDir: dir, IdleTTL: time.Nanosecond})
+		require.Len(t, expired.snapshots, 0)
+	})
+
+	runStep(t, "SaveSnapshots does nothing without a SnapshotDir", func(t *testing.T) {
+		store := NewStore(hclog.New(nil), StoreOptions{})
+		require.NoError(t, store.SaveSnapshots())
+	})
+}
+
+func TestEventHistory(t *testing.T) {
+	var nilHistory *eventHistory
+	nilHistory.add(1, 2, nil)
+	_, ok := nilHistory.since(1)
+	require.False(t, ok)
+	require.Nil(t, newEventHistory(0))
+
+	e3 := newEventServiceHealthRegister(3, 1, "srv1")
+	e4 := newEventServiceHealthRegister(4, 2, "srv1")
+	e5 := newEventServiceHealthRegister(5, 3, "srv1")
+
+	h := newEventHistory(2)
+	h.add(2, 3, []*pbsubscribe.Event{e3})
+	h.add(3, 4, []*pbsubscribe.Event{e4})
+
+	events, ok := h.since(2)
+	require.True(t, ok)
+	require.Equal(t, []*pbsubscribe.Event{e3, e4}, events)
+
+	events, ok = h.since(3)
+	require.True(t, ok)
+	require.Equal(t, []*pbsubscribe.Event{e4}, events)
+
+	_, ok = h.since(4)
+	require.False(t, ok, "no events after the latest index")
+
+	h.add(4, 5, []*pbsubscribe.Event{e5})
+	_, ok = h.since(2)
+	require.False(t, ok, "the oldest entry was replaced")
+
+	events, ok = h.since(3)
+	require.True(t, ok)
+	require.Equal(t, []*pbsubscribe.Event{e4, e5}, events)
+
+	h.reset()
+	_, ok = h.since(3)
+	require.False(t, ok)
+}
+
+// deltaFakeView is a fakeView which implements DeltaView. The delta result is
+// the events.
+type deltaFakeView struct {
+	fakeView
+}
+
+func (f *deltaFakeView) DeltaResult(events []*pbsubscribe.Event, _ uint64) interface{} {
+	return events
+}
+
+func TestStore_Get_Delta(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := NewStore(hclog.New(nil), StoreOptions{EventHistorySize: 4})
+	go store.Run(ctx)
+
+	factory := func(req pbsubscribe.SubscribeRequest) (View, error) {
+		return &deltaFakeView{
+			fakeView: fakeView{srvs: make(map[string]*pbservice.CheckServiceNode)},
+		}, nil
+	}
+	require.NoError(t, store.RegisterView(pbsubscribe.Topic_ServiceHealth, factory))
+
+	client := submatviewtest.NewStreamingClient(pbcommon.DefaultEnterpriseMeta.Namespace)
+	client.QueueEvents(
+		submatviewtest.NewEndOfSnapshotEvent(2),
+		newEventServiceHealthRegister(10, 1, "srv1"))
+
+	newRequest := func(minIndex uint64, delta bool) Request {
+		req, err := store.NewRequest(RequestSpec{
+			Subscribe: pbsubscribe.SubscribeRequest{
+				Topic:      pbsubscribe.Topic_ServiceHealth,
+				Key:        "srv1",
+				Token:      "abcd",
+				Datacenter: "dc1",
+				Namespace:  pbcommon.DefaultEnterpriseMeta.Namespace,
+			},
+			MinIndex: minIndex,
+			Timeout:  time.Second,
+			Client:   client,
+			Delta:    delta,
+		})
+		require.NoError(t, err)
+		return req
+	}
+
+	result, err := store.Get(ctx, newRequest(0, true))
+	require.NoError(t, err)
+	require.Equal(t, uint64(10), result.Index)
+	require.False(t, result.Delta, "the snapshot is not a delta")
+
+	runStep(t, "a MinIndex within the history returns a delta", func(t *testing.T) {
+		event := newEventServiceHealthRegister(12, 2, "srv1")
+		client.QueueEvents(event)
+
+		result, err := store.Get(ctx, newRequest(10, true))
+		require.NoError(t, err)
+		require.Equal(t, uint64(12), result.Index)
+		require.True(t, result.Delta)
+		require.Len(t, result.Value, 1)
+		require.Equal(t, event.Index, result.Value.([]*pbsubscribe.Event)[0].Index)
+	})
+
+	runStep(t, "a MinIndex before the history returns the full result", func(t *testing.T) {
+		result, err := store.Get(ctx, newRequest(1, true))
+		require.NoError(t, err)
+		require.False(t, result.Delta)
+		require.Len(t, result.Value.(fakeResult).srvs, 2)
+	})
+
+	runStep(t, "a request which does not accept a delta returns the full result", func(t *testing.T) {
+		result, err := store.Get(ctx, newRequest(10, false))
+		require.NoError(t, err)
+		require.False(t, result.Delta)
+		require.Len(t, result.Value.(fakeResult).srvs, 2)
+	})
+}
+
+// pagedFakeView is a fakeView which implements PagedView. The items are sorted
+// by their ID, and the cursor is the ID of the first item of the page.
+type pagedFakeView struct {
+	fakeView
+}
+
+func (f *pagedFakeView) Len() int {
+	return len(f.srvs)
+}
+
+func (f *pagedFakeView) PageResult(index uint64, page Page) (interface{}, string, error) {
+	ids := make([]string, 0, len(f.srvs))
+	for id := range f.srvs {
+		if id >= page.Cursor {
+			ids = append(ids, id)
+		}
+	}
+	sort.Strings(ids)
+
+	var next string
+	if len(ids) > page.Size {
+		next = ids[page.Size]
+		ids = ids[:page.Size]
+	}
+	srvs := make([]*pbservice.CheckServiceNode, 0, len(ids))
+	for _, id := range ids {
+		srvs = append(srvs, f.srvs[id])
+	}
+	return fakeResult{srvs: srvs, index: index}, next, nil
+}
+
+func TestStore_Get_Paged(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := NewStore(hclog.New(nil), StoreOptions{MaxResultItems: 3})
+	go store.Run(ctx)
+
+	factory := func(req pbsubscribe.SubscribeRequest) (View, error) {
+		return &pagedFakeView{
+			fakeView: fakeView{srvs: make(map[string]*pbservice.CheckServiceNode)},
+		}, nil
+	}
+	require.NoError(t, store.RegisterView(pbsubscribe.Topic_ServiceHealth, factory))
+
+	client := submatviewtest.NewStreamingClient(pbcommon.DefaultEnterpriseMeta.Namespace)
+	client.QueueEvents(
+		newEventServiceHealthRegister(2, 1, "srv1"),
+		newEventServiceHealthRegister(2, 2, "srv1"),
+		newEventServiceHealthRegister(2, 3, "srv1"),
+		submatviewtest.NewEndOfSnapshotEvent(2))
+
+	newRequest := func(page Page) Request {
+		req, err := store.NewRequest(RequestSpec{
+			Subscribe: pbsubscribe.SubscribeRequest{
+				Topic:      pbsubscribe.Topic_ServiceHealth,
+				Key:        "srv1",
+				Token:      "abcd",
+				Datacenter: "dc1",
+				Namespace:  pbcommon.DefaultEnterpriseMeta.Namespace,
+			},
+			Timeout: time.Second,
+			Client:  client,
+			Page:    page,
+		})
+		require.NoError(t, err)
+		return req
+	}
+
+	runStep(t, "a result within the limit is returned in full", func(t *testing.T) {
+		result, err := store.Get(ctx, newRequest(Page{}))
+		require.NoError(t, err)
+		require.Equal(t, uint64(2), result.Index)
+		require.Len(t, result.Value.(fakeResult).srvs, 3)
+		require.Equal(t, "", result.NextCursor)
+	})
+
+	runStep(t, "pages follow the cursor", func(t *testing.T) {
+		var nodes []string
+		page := Page{Size: 2}
+		for i := 0; i < 3; i++ {
+			result, err := store.Get(ctx, newRequest(page))
+			require.NoError(t, err)
+			for _, srv := range result.Value.(fakeResult).srvs {
+				nodes = append(nodes, srv.Node.Node)
+			}
+			if result.NextCursor == "" {
+				break
+			}
+			page.Cursor = result.NextCursor
+		}
+		require.Equal(t, []string{"node1", "node2", "node3"}, nodes)
+	})
+
+	client.QueueEvents(newEventServiceHealthRegister(4, 4, "srv1"))
+
+	runStep(t, "a result over the limit is an error", func(t *testing.T) {
+		req := newRequest(Page{})
+		retry.Run(t, func(r *retry.R) {
+			_, err := store.Get(ctx, req)
+			require.Equal(r, ResultTooLargeError{Items: 4, Max: 3}, err)
+		})
+	})
+
+	runStep(t, "pages are limited to the maximum", func(t *testing.T) {
+		result, err := store.Get(ctx, newRequest(Page{Size: 10}))
+		require.NoError(t, err)
+		require.Equal(t, uint64(4), result.Index)
+		require.Len(t, result.Value.(fakeResult).srvs, 3)
+		require.NotEqual(t, "", result.NextCursor)
+	})
+}
+
+// countingView is a fakeView which counts the calls to Update.
+type countingView struct {
+	fakeView
+	lock    sync.Mutex
+	updates int
+}
+
+func (v *countingView) Update(events []*pbsubscribe.Event) error {
+	v.lock.Lock()
+	v.updates++
+	v.lock.Unlock()
+	return v.fakeView.Update(events)
+}
+
+func (v *countingView) updateCount() int {
+	v.lock.Lock()
+	defer v.lock.Unlock()
+	return v.updates
+}
+
+func TestStore_Debounce(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	setup := func(t *testing.T, debounce Debounce) (*Store, *submatviewtest.StreamingClient, *countingView) {
+		store := NewStore(hclog.New(nil), StoreOptions{Debounce: debounce})
+		go store.Run(ctx)
+
+		view := &countingView{fakeView: fakeView{srvs: make(map[string]*pbservice.CheckServiceNode)}}
+		factory := func(req pbsubscribe.SubscribeRequest) (View, error) {
+			return view, nil
+		}
+		require.NoError(t, store.RegisterView(pbsubscribe.Topic_ServiceHealth, factory))
+		return store, submatviewtest.NewStreamingClient(pbcommon.DefaultEnterpriseMeta.Namespace), view
+	}
+	newRequest := func(t *testing.T, store *Store, client StreamClient, minIndex uint64) Request {
+		req, err := store.NewRequest(RequestSpec{
+			Subscribe: pbsubscribe.SubscribeRequest{
+				Topic:      pbsubscribe.Topic_ServiceHealth,
+				Key:        "srv1",
+				Token:      "abcd",
+				Datacenter: "dc1",
+				Namespace:  pbcommon.DefaultEnterpriseMeta.Namespace,
+			},
+			MinIndex: minIndex,
+			Timeout:  time.Second,
+			Client:   client,
+		})
+		require.NoError(t, err)
+		return req
+	}
+
+	t.Run("events in the window are applied in a single update", func(t *testing.T) {
+		store, client, view := setup(t, Debounce{Window: 50 * time.Millisecond, BypassSnapshot: true})
+		client.QueueEvents(
+			submatviewtest.NewEndOfSnapshotEvent(2),
+			newEventServiceHealthRegister(10, 1, "srv1"))
+
+		result, err := store.Get(ctx, newRequest(t, store, client, 0))
+		require.NoError(t, err)
+		require.Equal(t, uint64(2), result.Index, "the snapshot bypasses the debounce window")
+
+		result, err = store.Get(ctx, newRequest(t, store, client, 2))
+		require.NoError(t, err)
+		require.Equal(t, uint64(10), result.Index)
+
+		client.QueueEvents(
+			newEventServiceHealthRegister(11, 2, "srv1"),
+			newEventServiceHealthRegister(12, 3, "srv1"),
+			newEventServiceHealthRegister(13, 4, "srv1"))
+
+		result, err = store.Get(ctx, newRequest(t, store, client, 10))
+		require.NoError(t, err)
+		require.Equal(t, uint64(13), result.Index)
+		require.Len(t, result.Value.(fakeResult).srvs, 4)
+		require.Equal(t, 3, view.updateCount())
+	})
+
+	t.Run("the snapshot is debounced without BypassSnapshot", func(t *testing.T) {
+		store, client, view := setup(t, Debounce{Window: 50 * time.Millisecond})
+		client.QueueEvents(
+			submatviewtest.NewEndOfSnapshotEvent(2),
+			newEventServiceHealthRegister(10, 1, "srv1"))
+
+		result, err := store.Get(ctx, newRequest(t, store, client, 0))
+		require.NoError(t, err)
+		require.Equal(t, uint64(10), result.Index)
+		require.Equal(t, 1, view.updateCount())
+	})
+}
+
+func TestStore_ResumeWithViewHash(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := NewStore(hclog.New(nil), StoreOptions{})
+	go store.Run(ctx)
+
+	client := submatviewtest.NewStreamingClient(pbcommon.DefaultEnterpriseMeta.Namespace)
+	client.QueueEvents(
+		newEventServiceHealthRegister(2, 1, "srv1"),
+		submatviewtest.NewEndOfSnapshotEvent(2))
+
+	result, err := store.Get(ctx, &fakeRequest{client: client})
+	require.NoError(t, err)
+	require.Equal(t, uint64(2), result.Index)
+
+	// getAfter waits for an update of the view after index. The subscription
+	// fails while the client is restarted, so the error is retried.
+	getAfter := func(t *testing.T, index uint64) Result {
+		var result Result
+		retry.Run(t, func(r *retry.R) {
+			var err error
+			result, err = store.Get(ctx, &fakeRequest{client: client, index: index, timeout: time.Second})
+			require.NoError(r, err)
+			require.Greater(r, result.Index, index)
+		})
+		return result
+	}
+
+	runStep(t, "a view which matches the new snapshot is resumed", func(t *testing.T) {
+		client.QueueNewSnapshot(10, newEventServiceHealthRegister(2, 1, "srv1"))
+
+		result := getAfter(t, 2)
+		require.Equal(t, uint64(10), result.Index)
+		require.Len(t, result.Value.(fakeResult).srvs, 1)
+
+		reqs := client.Requests()
+		require.Len(t, reqs, 2)
+		require.Equal(t, uint64(2), reqs[1].Index)
+		require.NotZero(t, reqs[1].ViewHash)
+	})
+
+	runStep(t, "updates after a resumed stream are applied", func(t *testing.T) {
+		client.QueueEvents(newEventServiceHealthRegister(11, 2, "srv1"))
+
+		result := getAfter(t, 10)
+		require.Equal(t, uint64(11), result.Index)
+		require.Len(t, result.Value.(fakeResult).srvs, 2)
+	})
+
+	runStep(t, "a view which does not match the new snapshot is reset", func(t *testing.T) {
+		client.QueueNewSnapshot(20, newEventServiceHealthRegister(20, 3, "srv1"))
+
+		result := getAfter(t, 11)
+		require.Equal(t, uint64(20), result.Index)
+		srvs := result.Value.(fakeResult).srvs
+		require.Len(t, srvs, 1)
+		require.Equal(t, "node3", srvs[0].Node.Node)
+	})
+}
+
+// datacenterClient routes each call to Subscribe to the client of the
+// datacenter of the request, and records the datacenter and index of each call.
+type datacenterClient struct {
+	clients map[string]StreamClient
+	lock    sync.Mutex
+	calls   []string
+}
+
+func (c *datacenterClient) Subscribe(
+	ctx context.Context,
+	req *pbsubscribe.SubscribeRequest,
+	opts ...grpc.CallOption,
+) (pbsubscribe.StateChangeSubscription_SubscribeClient, error) {
+	c.lock.Lock()
+	c.calls = append(c.calls, fmt.Sprintf("%s@%d", req.Datacenter, req.Index))
+	c.lock.Unlock()
+	return c.clients[req.Datacenter].Subscribe(ctx, req, opts...)
+}
+
+func (c *datacenterClient) recordedCalls() []string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]string(nil), c.calls...)
+}
+
+func TestStore_Failover(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := NewStore(hclog.New(nil), StoreOptions{
+		Backoff: Backoff{InitialWait: time.Millisecond, MaxWait: 5 * time.Millisecond},
+		Failover: Failover{
+			LocalDatacenter: "dc1",
+			Datacenters:     []string{"dc2", "dc3"},
+			Threshold:       2,
+		},
+	})
+	go store.Run(ctx)
+
+	factory := func(req pbsubscribe.SubscribeRequest) (View, error) {
+		return &fakeView{srvs: make(map[string]*pbservice.CheckServiceNode)}, nil
+	}
+	require.NoError(t, store.RegisterView(pbsubscribe.Topic_ServiceHealth, factory))
+
+	dc2 := &failingClient{
+		StreamingClient: submatviewtest.NewStreamingClient(pbcommon.DefaultEnterpriseMeta.Namespace),
+		fails:           100,
+	}
+
+	dc3 := submatviewtest.NewStreamingClient(pbcommon.DefaultEnterpriseMeta.Namespace)
+	dc3.QueueEvents(
+		newEventServiceHealthRegister(20, 1, "srv1"),
+		newEventServiceHealthRegister(20, 2, "srv1"),
+		submatviewtest.NewEndOfSnapshotEvent(20))
+
+	client := &datacenterClient{clients: map[string]StreamClient{"dc2": dc2, "dc3": dc3}}
+	req, err := store.NewRequest(RequestSpec{
+		Subscribe: pbsubscribe.SubscribeRequest{
+			Topic:      pbsubscribe.Topic_ServiceHealth,
+			Key:        "srv1",
+			Datacenter: "dc2",
+			Namespace:  pbcommon.DefaultEnterpriseMeta.Namespace,
+		},
+		Client: client,
+	})
+	require.NoError(t, err)
+
+	ch := make(chan cache.UpdateEvent)
+	require.NoError(t, store.Notify(ctx, req, "update", ch))
+
+	timeout := time.After(2 * time.Second)
+	for {
+		var u cache.UpdateEvent
+		select {
+		case u = <-ch:
+		case <-timeout:
+			t.Fatalf("expected an update from dc3")
+		}
+		if u.Err == nil && u.Meta.Index == 20 {
+			require.Len(t, u.Result.(fakeResult).srvs, 2)
+			break
+		}
+	}
+
+	require.Equal(t, []string{"dc2@0", "dc2@0", "dc3@0"}, client.recordedCalls())
+	entries := store.Entries()
+	require.Len(t, entries, 1)
+	require.Equal(t, "dc2", entries[0].Datacenter)
+	require.Equal(t, "dc3", entries[0].FailoverDatacenter)
+}
+
+func TestStore_Failover_HealthCheck(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := NewStore(hclog.New(nil), StoreOptions{
+		Backoff: Backoff{InitialWait: time.Millisecond, MaxWait: 5 * time.Millisecond},
+		Failover: Failover{
+			LocalDatacenter:     "dc1",
+			Datacenters:         []string{"dc3"},
+			Threshold:           1,
+			HealthCheckInterval: 10 * time.Millisecond,
+		},
+	})
+	go store.Run(ctx)
+
+	var healthLock sync.Mutex
+	unhealthy := map[string]bool{"dc2": true}
+	store.SetHealthCheckFunc(func(_ context.Context, dc string) error {
+		healthLock.Lock()
+		defer healthLock.Unlock()
+		if unhealthy[dc] {
+			return fmt.Errorf("no leader in %v", dc)
+		}
+		return nil
+	})
+
+	factory := func(req pbsubscribe.SubscribeRequest) (View, error) {
+		return &fakeView{srvs: make(map[string]*pbservice.CheckServiceNode)}, nil
+	}
+	require.NoError(t, store.RegisterView(pbsubscribe.Topic_ServiceHealth, factory))
+
+	dc2 := submatviewtest.NewStreamingClient(pbcommon.DefaultEnterpriseMeta.Namespace)
+	dc2.QueueEvents(submatviewtest.NewEndOfSnapshotEvent(5))
+	dc3 := submatviewtest.NewStreamingClient(pbcommon.DefaultEnterpriseMeta.Namespace)
+	dc3.QueueEvents(submatviewtest.NewEndOfSnapshotEvent(20))
+
+	client := &datacenterClient{clients: map[string]StreamClient{"dc2": dc2, "dc3": dc3}}
+	req, err := store.NewRequest(RequestSpec{
+		Subscribe: pbsubscribe.SubscribeRequest{
+			Topic:      pbsubscribe.Topic_ServiceHealth,
+			Key:        "srv1",
+			Datacenter: "dc2",
+			Namespace:  pbcommon.DefaultEnterpriseMeta.Namespace,
+		},
+		Client: client,
+	})
+	require.NoError(t, err)
+
+	ch := make(chan cache.UpdateEvent, 10)
+	require.NoError(t, store.Notify(ctx, req, "update", ch))
+
+	runStep(t, "fails over when the datacenter fails a health check", func(t *testing.T) {
+		retry.Run(t, func(r *retry.R) {
+			entries := store.Entries()
+			require.Len(r, entries, 1)
+			require.Equal(r, "dc3", entries[0].FailoverDatacenter)
+			require.Equal(r, uint64(20), entries[0].Index)
+		})
+		require.Equal(t, []string{"dc2@0", "dc3@0"}, client.recordedCalls())
+	})
+
+	runStep(t, "fails back when the datacenter is healthy again", func(t *testing.T) {
+		healthLock.Lock()
+		unhealthy["dc2"] = false
+		healthLock.Unlock()
+
+		retry.Run(t, func(r *retry.R) {
+			entries := store.Entries()
+			require.Len(r, entries, 1)
+			require.Equal(r, "", entries[0].FailoverDatacenter)
+			require.Equal(r, uint64(5), entries[0].Index)
+		})
+		require.Equal(t, []string{"dc2@0", "dc3@0", "dc2@0"}, client.recordedCalls())
+	})
+}
+
+func TestFailover_datacenters(t *testing.T) {
+	f := Failover{LocalDatacenter: "dc1", Datacenters: []string{"dc2", "dc3"}, Threshold: 3}
+	require.Nil(t, f.datacenters(""))
+	require.Nil(t, f.datacenters("dc1"))
+	require.Equal(t, []string{"dc2", "dc3"}, f.datacenters("dc2"))
+	require.Equal(t, []string{"dc4", "dc2", "dc3"}, f.datacenters("dc4"))
+
+	f.Threshold = 0
+	require.Equal(t, []string{"dc2"}, f.datacenters("dc2"))
+}
+
+// watchdogClient records the requests of every call to Subscribe. Each
+// subscription receives a snapshot when it starts from index 0, optionally
+// followed by a heartbeat, and then no more events.
+type watchdogClient struct {
+	heartbeat bool
+	lock      sync.Mutex
+	reqs      []pbsubscribe.SubscribeRequest
+}
+
+func (c *watchdogClient) Subscribe(
+	ctx context.Context,
+	req *pbsubscribe.SubscribeRequest,
+	_ ...grpc.CallOption,
+) (pbsubscribe.StateChangeSubscription_SubscribeClient, error) {
+	c.lock.Lock()
+	c.reqs = append(c.reqs, *req)
+	c.lock.Unlock()
+
+	// Each subscription gets its own client, so that a resumed subscription
+	// does not replay the events of the previous one.
+	sub := submatviewtest.NewStreamingClient(req.Namespace)
+	if req.Index == 0 {
+		sub.QueueEvents(
+			newEventServiceHealthRegister(2, 1, "srv1"),
+			submatviewtest.NewEndOfSnapshotEvent(2))
+	}
+	if c.heartbeat {
+		sub.QueueEvents(&pbsubscribe.Event{
+			Index:   2,
+			Payload: &pbsubscribe.Event_Heartbeat{Heartbeat: true},
+		})
+	}
+	return sub.Subscribe(ctx, req)
+}
+
+func (c *watchdogClient) requests() []pbsubscribe.SubscribeRequest {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return append([]pbsubscribe.SubscribeRequest(nil), c.reqs...)
+}
+
+func TestStore_Watchdog(t *testing.T) {
+	setup := func(t *testing.T, client StreamClient) *Store {
+		ctx, cancel := context.WithCancel(context.Background())
+		t.Cleanup(cancel)
+
+		store := NewStore(hclog.New(nil), StoreOptions{WatchdogTimeout: 90 * time.Millisecond})
+		go store.Run(ctx)
+
+		factory := func(req pbsubscribe.SubscribeRequest) (View, error) {
+			return &fakeView{srvs: make(map[string]*pbservice.CheckServiceNode)}, nil
+		}
+		require.NoError(t, store.RegisterView(pbsubscribe.Topic_ServiceHealth, factory))
+
+		req, err := store.NewRequest(RequestSpec{
+			Subscribe: pbsubscribe.SubscribeRequest{
+				Topic:      pbsubscribe.Topic_ServiceHealth,
+				Key:        "srv1",
+				Token:      "abcd",
+				Datacenter: "dc1",
+			},
+			Timeout: time.Second,
+			Client:  client,
+		})
+		require.NoError(t, err)
+
+		result, err := store.Get(ctx, req)
+		require.NoError(t, err)
+		require.Equal(t, uint64(2), result.Index)
+		require.Len(t, result.Value.(fakeResult).srvs, 1, "heartbeats are not applied to the view")
+		return store
+	}
+
+	t.Run("a subscription without events after a heartbeat is restarted", func(t *testing.T) {
+		client := &watchdogClient{heartbeat: true}
+		setup(t, client)
+
+		retry.Run(t, func(r *retry.R) {
+			reqs := client.requests()
+			require.GreaterOrEqual(r, len(reqs), 2)
+			require.Equal(r, uint64(30), reqs[0].HeartbeatIntervalMillis)
+			require.Equal(r, uint64(2), reqs[1].Index, "the subscription resumes from the index of the view")
+		})
+	})
+
+	t.Run("the watchdog is not armed without heartbeats", func(t *testing.T) {
+		client := &watchdogClient{}
+		setup(t, client)
+
+		time.Sleep(300 * time.Millisecond)
+		require.Len(t, client.requests(), 1)
+	})
+}
+
+func TestRedactEntries(t *testing.T) {
+	entries := []EntryInfo{
+		{
+			Type:               "kv",
+			Datacenter:         "dc1",
+			FailoverDatacenter: "dc2",
+			Key:                "secret/path",
+			Topic:              "KV",
+			Partition:          "team",
+			Namespace:          "ops",
+			Error:              "subscription to secret/path failed",
+		},
+		{
+			Type:       "health",
+			Datacenter: "dc2",
+			Key:        "web",
+			Topic:      "ServiceHealth",
+			Partition:  "team",
+			Namespace:  WildcardNamespace,
+		},
+	}
+	require.NoError(t, redactEntries(entries))
+
+	first, second := entries[0], entries[1]
+	for _, value := range []string{first.Key, first.Datacenter, first.FailoverDatacenter, first.Partition, first.Namespace} {
+		require.True(t, strings.HasPrefix(value, "redacted:"), value)
+	}
+	require.Equal(t, "subscription to "+first.Key+" failed", first.Error)
+	require.Equal(t, "kv", first.Type)
+	require.Equal(t, "KV", first.Topic)
+
+	// The values of the entries can still be correlated.
+	require.Equal(t, first.FailoverDatacenter, second.Datacenter)
+	require.Equal(t, first.Partition, second.Partition)
+	require.Equal(t, WildcardNamespace, second.Namespace)
+}
+
+func TestStore_IndexProbe(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := NewStore(hclog.New(nil), StoreOptions{IndexProbeInterval: 10 * time.Millisecond})
+
+	probed := make(chan pbsubscribe.SubscribeRequest, 10)
+	store.SetIndexProbeFunc(func(_ context.Context, req pbsubscribe.SubscribeRequest) (uint64, error) {
+		select {
+		case probed <- req:
+		default:
+		}
+		return 12, nil
+	})
+	go store.Run(ctx)
+
+	client := submatviewtest.NewStreamingClient(pbcommon.DefaultEnterpriseMeta.Namespace)
+	client.QueueEvents(submatviewtest.NewEndOfSnapshotEvent(2))
+
+	idle := &fakeRequest{client: client, key: "idle"}
+	_, err := store.Get(ctx, idle)
+	require.NoError(t, err)
+
+	active := &fakeRequest{client: client, key: "active"}
+	require.NoError(t, store.Notify(ctx, active, "active", make(chan cache.UpdateEvent, 1)))
+
+	retry.Run(t, func(r *retry.R) {
+		entries := store.Entries()
+		require.Len(r, entries, 2)
+		require.Equal(r, "active", entries[0].Key)
+		require.Equal(r, uint64(12), entries[0].ServerIndex)
+		require.Equal(r, "idle", entries[1].Key)
+		require.Equal(r, uint64(0), entries[1].ServerIndex, "idle entries are not probed")
+	})
+
+	req := <-probed
+	require.Equal(t, pbsubscribe.Topic_ServiceHealth, req.Topic)
+	require.Equal(t, "key", req.Key)
+	require.Equal(t, "dc1", req.Datacenter)
+	require.Equal(t, uint64(2), req.Index, "the view is probed from its index")
+
+	lags, _ := store.probeIndexes(ctx, "")
+	require.Equal(t, map[string]uint64{"ServiceHealth": 10}, lags)
+}
+
+func TestStore_IndexProbe_Limits(t *testing.T) {
+	defer func(n, c int) {
+		maxIndexProbesPerInterval, maxConcurrentIndexProbes = n, c
+	}(maxIndexProbesPerInterval, maxConcurrentIndexProbes)
+	maxIndexProbesPerInterval, maxConcurrentIndexProbes = 3, 2
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := NewStore(hclog.New(nil), StoreOptions{})
+	go store.Run(ctx)
+
+	var lock sync.Mutex
+	var probed []string
+	var running, maxRunning int
+	store.SetIndexProbeFunc(func(_ context.Context, req pbsubscribe.SubscribeRequest) (uint64, error) {
+		lock.Lock()
+		probed = append(probed, req.Key)
+		running++
+		if running > maxRunning {
+			maxRunning = running
+		}
+		lock.Unlock()
+
+		time.Sleep(10 * time.Millisecond)
+
+		lock.Lock()
+		running--
+		lock.Unlock()
+		return 12, nil
+	})
+
+	factory := func(req pbsubscribe.SubscribeRequest) (View, error) {
+		return &fakeView{srvs: make(map[string]*pbservice.CheckServiceNode)}, nil
+	}
+	require.NoError(t, store.RegisterView(pbsubscribe.Topic_ServiceHealth, factory))
+
+	for _, key := range []string{"a", "b", "c", "d", "e"} {
+		client := submatviewtest.NewStreamingClient(pbcommon.DefaultEnterpriseMeta.Namespace)
+		client.QueueEvents(submatviewtest.NewEndOfSnapshotEvent(2))
+		req, err := store.NewRequest(RequestSpec{
+			Subscribe: pbsubscribe.SubscribeRequest{
+				Topic:      pbsubscribe.Topic_ServiceHealth,
+				Key:        key,
+				Datacenter: "dc1",
+				Namespace:  pbcommon.DefaultEnterpriseMeta.Namespace,
+			},
+			Client: client,
+		})
+		require.NoError(t, err)
+		require.NoError(t, store.Notify(ctx, req, key, make(chan cache.UpdateEvent, 1)))
+	}
+	retry.Run(t, func(r *retry.R) {
+		for _, e := range store.Entries() {
+			require.Equal(r, uint64(2), e.Index)
+		}
+	})
+
+	probedKeys := func(last string) ([]string, string) {
+		lock.Lock()
+		probed = nil
+		lock.Unlock()
+
+		_, last = store.probeIndexes(ctx, last)
+
+		lock.Lock()
+		defer lock.Unlock()
+		sort.Strings(probed)
+		return probed, last
+	}
+
+	first, last := probedKeys("")
+	require.Equal(t, []string{"a", "b", "c"}, first)
+
+	// The next probes start after the last entry probed, and wrap around.
+	second, _ := probedKeys(last)
+	require.Equal(t, []string{"a", "d", "e"}, second)
+	lock.Lock()
+	require.Equal(t, 2, maxRunning)
+	lock.Unlock()
+}
+
+func TestStore_MaxConcurrentSnapshots(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := NewStore(hclog.New(nil), StoreOptions{MaxConcurrentSnapshots: 1})
+	go store.Run(ctx)
+
+	factory := func(req pbsubscribe.SubscribeRequest) (View, error) {
+		return &fakeView{srvs: make(map[string]*pbservice.CheckServiceNode)}, nil
+	}
+	require.NoError(t, store.RegisterView(pbsubscribe.Topic_ServiceHealth, factory))
+
+	newRequest := func(t *testing.T, key string, client StreamClient) Request {
+		req, err := store.NewRequest(RequestSpec{
+			Subscribe: pbsubscribe.SubscribeRequest{
+				Topic:      pbsubscribe.Topic_ServiceHealth,
+				Key:        key,
+				Datacenter: "dc1",
+				Namespace:  pbcommon.DefaultEnterpriseMeta.Namespace,
+			},
+			Client: client,
+		})
+		require.NoError(t, err)
+		return req
+	}
+
+	// The snapshot of the first view does not end until the test queues its
+	// EndOfSnapshot event, so it holds the only slot.
+	first := submatviewtest.NewStreamingClient(pbcommon.DefaultEnterpriseMeta.Namespace)
+	firstReq := newRequest(t, "first", first)
+	require.NoError(t, store.Notify(ctx, firstReq, "first", make(chan cache.UpdateEvent, 1)))
+	retry.Run(t, func(r *retry.R) {
+		require.Len(r, first.Requests(), 1)
+	})
+
+	second := submatviewtest.NewStreamingClient(pbcommon.DefaultEnterpriseMeta.Namespace)
+	second.QueueEvents(submatviewtest.NewEndOfSnapshotEvent(5))
+	secondReq := newRequest(t, "second", second)
+	secondCh := make(chan cache.UpdateEvent, 1)
+	require.NoError(t, store.Notify(ctx, secondReq, "second", secondCh))
+
+	runStep(t, "the second view waits for a slot", func(t *testing.T) {
+		retry.Run(t, func(r *retry.R) {
+			entries := store.Entries()
+			require.Len(r, entries, 2)
+			require.Contains(r, entries[0].Key, "first")
+			require.Equal(r, stateConnected, entries[0].State)
+			require.Equal(r, stateQueued, entries[1].State)
+		})
+		require.Empty(t, second.Requests())
+	})
+
+	runStep(t, "the slot is released at the end of the snapshot", func(t *testing.T) {
+		first.QueueEvents(submatviewtest.NewEndOfSnapshotEvent(2))
+
+		select {
+		case update := <-secondCh:
+			require.NoError(t, update.Err)
+			require.Equal(t, uint64(5), update.Meta.Index)
+		case <-time.After(time.Second):
+			t.Fatal("the second view did not receive its snapshot")
+		}
+	})
+
+	runStep(t, "subscriptions which resume from an index do not wait", func(t *testing.T) {
+		// Hold the slot with a third view, which never receives its snapshot.
+		third := submatviewtest.NewStreamingClient(pbcommon.DefaultEnterpriseMeta.Namespace)
+		require.NoError(t, store.Notify(ctx, newRequest(t, "third", third), "third", make(chan cache.UpdateEvent, 1)))
+		retry.Run(t, func(r *retry.R) {
+			require.Len(r, third.Requests(), 1)
+		})
+
+		second.FailAtIndex(6, fmt.Errorf("connection lost"))
+		second.QueueEvents(newEventServiceHealthRegister(6, 1, "srv1"))
+
+		select {
+		case update := <-secondCh:
+			require.NoError(t, update.Err)
+			require.Equal(t, uint64(6), update.Meta.Index)
+		case <-time.After(5 * time.Second):
+			t.Fatal("the second view did not resume")
+		}
+		reqs := second.Requests()
+		require.Len(t, reqs, 2)
+		require.Equal(t, uint64(5), reqs[1].Index)
+	})
+}
+
+// sizedFakeView is a fakeView which implements SizedView.
+type sizedFakeView struct {
+	fakeView
+}
+
+func (f *sizedFakeView) SizeBytes() int {
+	return ApproximateSize(f.srvs)
+}
+
+func TestStore_MaxSizeBytes(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	store := NewStore(hclog.New(nil), StoreOptions{MaxSizeBytes: 1})
+	go store.Run(ctx)
+
+	factory := func(req pbsubscribe.SubscribeRequest) (View, error) {
+		return &sizedFakeView{fakeView{srvs: make(map[string]*pbservice.CheckServiceNode)}}, nil
+	}
+	require.NoError(t, store.RegisterView(pbsubscribe.Topic_ServiceHealth, factory))
+
+	client := submatviewtest.NewStreamingClient(pbcommon.DefaultEnterpriseMeta.Namespace)
+	client.QueueEvents(
+		newEventServiceHealthRegister(2, 1, "srv1"),
+		submatviewtest.NewEndOfSnapshotEvent(2))
+
+	newRequest := func(t *testing.T, key string) Request {
+		req, err := store.NewRequest(RequestSpec{
+			Subscribe: pbsubscribe.SubscribeRequest{
+				Topic:      pbsubscribe.Topic_ServiceHealth,
+				Key:        key,
+				Datacenter: "dc1",
+				Namespace:  pbcommon.DefaultEnterpriseMeta.Namespace,
+			},
+			Client: client,
+		})
+		require.NoError(t, err)
+		return req
+	}
+
+	reqCtx, reqCancel := context.WithCancel(ctx)
+	defer reqCancel()
+	updateCh := make(chan cache.UpdateEvent, 1)
+	require.NoError(t, store.Notify(reqCtx, newRequest(t, "one"), "one", updateCh))
+	<-updateCh
+
+	var size int
+	runStep(t, "the size of the views is reported", func(t *testing.T) {
+		entries := store.Entries()
+		require.Len(t, entries, 1)
+		size = entries[0].SizeBytes
+		require.True(t, size > 0, "expected a size, got %v", size)
+		require.Equal(t, int64(size), store.SizeBytes())
+
+		client.QueueEvents(newEventServiceHealthRegister(3, 2, "srv1"))
+		retry.Run(t, func(r *retry.R) {
+			require.True(r, store.Entries()[0].SizeBytes > size, "the size did not grow")
+		})
+		size = store.Entries()[0].SizeBytes
+		require.Equal(t, int64(size), store.SizeBytes())
+	})
+
+	runStep(t, "idle entries are evicted when the size exceeds the limit", func(t *testing.T) {
+		_, err := store.Get(ctx, newRequest(t, "two"))
+		require.NoError(t, err)
+
+		retry.Run(t, func(r *retry.R) {
+			entries := store.Entries()
+			require.Len(r, entries, 1)
+			require.Contains(r, entries[0].Key, "one")
+		})
+		require.Equal(t, int64(size), store.SizeBytes())
+	})
+
+	runStep(t, "entries with active requests are evicted once they are idle", func(t *testing.T) {
+		reqCancel()
+		retry.Run(t, func(r *retry.R) {
+			require.Empty(r, store.Entries())
+		})
+		require.Equal(t, int64(0), store.SizeBytes())
+	})
+}
+
+func TestApproximateSize(t *testing.T) {
+	type item struct {
+		Name string
+		Tags []string
+		Meta map[string]string
+		Next *item
+	}
+
+	empty := ApproximateSize(item{})
+	require.True(t, empty > 0)
+
+	t.Run("referenced data is included", func(t *testing.T) {
+		v := item{
+			Name: "web",
+			Tags: []string{"a", "bc"},
+			Meta: map[string]string{"key": "value"},
+		}
+		s := int(reflect.TypeOf("").Size())
+		expected := empty + 3 + // Name
+			2*s + 1 + 2 + // Tags
+			2*s + 3 + 5 // Meta
+		require.Equal(t, expected, ApproximateSize(v))
+	})
+
+	t.Run("pointers are counted once", func(t *testing.T) {
+		v := &item{Name: "web"}
+		v.Next = v
+		require.Equal(t, int(reflect.TypeOf(v).Size())+empty+3, ApproximateSize(v))
+	})
+
+	t.Run("nil", func(t *testing.T) {
+		require.Equal(t, 0, ApproximateSize(nil))
+	})
+}
+
+func TestUnsupportedTopic(t *testing.T) {
+	var u UnsupportedTopic
+	require.False(t, u.Unsupported("dc1"))
+
+	require.False(t, u.Record("dc1", nil))
+	require.False(t, u.Record("dc1", errors.New("Permission denied")))
+	require.False(t, u.Unsupported("dc1"))
+
+	require.True(t, u.Record("dc1", errors.New("rpc error: code = Unknown desc = unknown topic Node")))
+	require.True(t, u.Unsupported("dc1"))
+	require.False(t, u.Unsupported("dc2"))
+
+	u.RetryInterval = time.Nanosecond
+	time.Sleep(time.Millisecond)
+	require.False(t, u.Unsupported("dc1"))
+}
+
+// fakeMultiplexedClient opens a fakeMultiplexedStream once release is closed.
+type fakeMultiplexedClient struct {
+	pbsubscribe.StateChangeSubscriptionClient
+	release chan struct{}
+	stream  *fakeMultiplexedStream
+
+	lock   sync.Mutex
+	opened int
+}
+
+func (f *fakeMultiplexedClient) SubscribeMultiplexed(
+	context.Context,
+	...grpc.CallOption,
+) (pbsubscribe.StateChangeSubscription_SubscribeMultiplexedClient, error) {
+	f.lock.Lock()
+	f.opened++
+	f.lock.Unlock()
+	<-f.release
+	return f.stream, nil
+}
+
+type fakeMultiplexedStream struct {
+	grpc.ClientStream
+	events chan *pbsubscribe.MultiplexedEvent
+	sent   chan *pbsubscribe.MultiplexedRequest
+}
+
+func (f *fakeMultiplexedStream) Send(req *pbsubscribe.MultiplexedRequest) error {
+	f.sent <- req
+	return nil
+}
+
+func (f *fakeMultiplexedStream) Recv() (*pbsubscribe.MultiplexedEvent, error) {
+	e, ok := <-f.events
+	if !ok {
+		return nil, io.EOF
+	}
+	return e, nil
+}
+
+func TestMultiplexedClient_Subscribe(t *testing.T) {
+	stream := &fakeMultiplexedStream{
+		events: make(chan *pbsubscribe.MultiplexedEvent),
+		sent:   make(chan *pbsubscribe.MultiplexedRequest, 10),
+	}
+	defer close(stream.events)
+	fake := &fakeMultiplexedClient{release: make(chan struct{}), stream: stream}
+	client := NewMultiplexedClient(fake, hclog.New(nil))
+	client.maxQueuedEvents = 2
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	type subOrError struct {
+		sub pbsubscribe.StateChangeSubscription_SubscribeClient
+		err error
+	}
+	subs := make(chan subOrError, 2)
+	for i := 0; i < 2; i++ {
+		go func() {
+			sub, err := client.Subscribe(ctx, &pbsubscribe.SubscribeRequest{Topic: pbsubscribe.Topic_ServiceHealth, Key: "web"})
+			subs <- subOrError{sub: sub, err: err}
+		}()
+	}
+
+	runStep(t, "the stream is opened without holding the lock", func(t *testing.T) {
+		retry.Run(t, func(r *retry.R) {
+			fake.lock.Lock()
+			defer fake.lock.Unlock()
+			require.Equal(r, 1, fake.opened)
+		})
+		client.lock.Lock()
+		require.NotNil(t, client.opening)
+		client.lock.Unlock()
+	})
+
+	var sub pbsubscribe.StateChangeSubscription_SubscribeClient
+	runStep(t, "subscriptions share the stream", func(t *testing.T) {
+		close(fake.release)
+		for i := 0; i < 2; i++ {
+			s := <-subs
+			require.NoError(t, s.err)
+			if sub == nil {
+				sub = s.sub
+			}
+		}
+		fake.lock.Lock()
+		defer fake.lock.Unlock()
+		require.Equal(t, 1, fake.opened)
+	})
+
+	runStep(t, "a subscription which falls behind is ended", func(t *testing.T) {
+		ms := sub.(*multiplexedSubscription)
+		for i := uint64(1); i <= 3; i++ {
+			stream.events <- &pbsubscribe.MultiplexedEvent{
+				ID:      ms.id,
+				Payload: &pbsubscribe.MultiplexedEvent_Event{Event: &pbsubscribe.Event{Index: i}},
+			}
+		}
+		retry.Run(t, func(r *retry.R) {
+			ms.lock.Lock()
+			defer ms.lock.Unlock()
+			require.Error(r, ms.err)
+		})
+
+		// The queued events are discarded.
+		_, err := sub.Recv()
+		require.Equal(t, codes.ResourceExhausted, status.Code(err))
+	})
 }
@@ -1,11 +1,7 @@
 package submatview
 
 import (
-	"context"
 	"fmt"
-	"sync"
-
-	"google.golang.org/grpc"
 
 	"github.com/hashicorp/consul/proto/pbcommon"
 	"github.com/hashicorp/consul/proto/pbservice"
@@ -13,97 +9,6 @@ import (
 	"github.com/hashicorp/consul/types"
 )
 
-// TestStreamingClient is a mock StreamingClient for testing that allows
-// for queueing up custom events to a subscriber.
-type TestStreamingClient struct {
-	expectedNamespace string
-	subClients        []*subscribeClient
-	lock              sync.RWMutex
-	events            []eventOrErr
-}
-
-type eventOrErr struct {
-	Err   error
-	Event *pbsubscribe.Event
-}
-
-func NewTestStreamingClient(ns string) *TestStreamingClient {
-	return &TestStreamingClient{expectedNamespace: ns}
-}
-
-func (s *TestStreamingClient) Subscribe(
-	ctx context.Context,
-	req *pbsubscribe.SubscribeRequest,
-	_ ...grpc.CallOption,
-) (pbsubscribe.StateChangeSubscription_SubscribeClient, error) {
-	if req.Namespace != s.expectedNamespace {
-		return nil, fmt.Errorf("wrong SubscribeRequest.Namespace %v, expected %v",
-			req.Namespace, s.expectedNamespace)
-	}
-	c := &subscribeClient{
-		events: make(chan eventOrErr, 32),
-		ctx:    ctx,
-	}
-	s.lock.Lock()
-	s.subClients = append(s.subClients, c)
-	for _, event := range s.events {
-		c.events <- event
-	}
-	s.lock.Unlock()
-	return c, nil
-}
-
-type subscribeClient struct {
-	grpc.ClientStream
-	events chan eventOrErr
-	ctx    context.Context
-}
-
-func (s *TestStreamingClient) QueueEvents(events ...*pbsubscribe.Event) {
-	s.lock.Lock()
-	for _, e := range events {
-		s.events = append(s.events, eventOrErr{Event: e})
-		for _, c := range s.subClients {
-			c.events <- eventOrErr{Event: e}
-		}
-	}
-	s.lock.Unlock()
-}
-
-func (s *TestStreamingClient) QueueErr(err error) {
-	s.lock.Lock()
-	s.events = append(s.events, eventOrErr{Err: err})
-	for _, c := range s.subClients {
-		c.events <- eventOrErr{Err: err}
-	}
-	s.lock.Unlock()
-}
-
-func (c *subscribeClient) Recv() (*pbsubscribe.Event, error) {
-	select {
-	case eoe := <-c.events:
-		if eoe.Err != nil {
-			return nil, eoe.Err
-		}
-		return eoe.Event, nil
-	case <-c.ctx.Done():
-		return nil, c.ctx.Err()
-	}
-}
-
-func newEndOfSnapshotEvent(index uint64) *pbsubscribe.Event {
-	return &pbsubscribe.Event{
-		Index:   index,
-		Payload: &pbsubscribe.Event_EndOfSnapshot{EndOfSnapshot: true},
-	}
-}
-
-func newNewSnapshotToFollowEvent() *pbsubscribe.Event {
-	return &pbsubscribe.Event{
-		Payload: &pbsubscribe.Event_NewSnapshotToFollow{NewSnapshotToFollow: true},
-	}
-}
-
 func newEventServiceHealthRegister(index uint64, nodeNum int, svc string) *pbsubscribe.Event {
 	node := fmt.Sprintf("node%d", nodeNum)
 	nodeID := types.NodeID(fmt.Sprintf("11111111-2222-3333-4444-%012d", nodeNum))
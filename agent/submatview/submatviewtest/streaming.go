@@ -0,0 +1,381 @@
+// Package submatviewtest provides a StreamClient for testing views and the
+// Materializers which run them, without a server.
+package submatviewtest
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/hashicorp/consul/proto/pbsubscribe"
+)
+
+// StreamingClient is a mock submatview.StreamClient for testing that allows
+// for queueing up custom events to its subscriptions.
+//
+// Every subscription receives the events queued before it subscribed, followed
+// by the events queued while it is active. A subscription which resumes from
+// an index only receives the events queued after the last event at or below
+// that index, like a server which still has those events in its buffer.
+type StreamingClient struct {
+	lock sync.Mutex
+	// history is every event and error queued by the test, in order.
+	history []item
+	// changed is closed and replaced when history or the flow control of the
+	// client changes, to wake the subscriptions waiting in Recv.
+	changed chan struct{}
+
+	namespaces map[string]struct{}
+	subs       []*subscribeClient
+	requests   []*pbsubscribe.SubscribeRequest
+
+	// failures are the errors injected with FailAtIndex.
+	failures []failure
+	delay    time.Duration
+	paused   bool
+	// resetIndex is the index of the snapshot queued by the last call to
+	// QueueNewSnapshot. Subscriptions which resume from a lower index receive
+	// a NewSnapshotToFollow event, or a ResumeStream event when the ViewHash
+	// of the request matches snapshotHash.
+	resetIndex   uint64
+	snapshotHash uint64
+	// snapshotEnd is the position in history after the snapshot.
+	snapshotEnd int
+}
+
+// item is an event or an error queued by the test.
+type item struct {
+	event *pbsubscribe.Event
+	err   error
+	// tenancy selects the subscriptions which receive the item, nil selects
+	// every subscription.
+	tenancy *Tenancy
+}
+
+type failure struct {
+	index uint64
+	err   error
+}
+
+// Tenancy is the namespace and partition of a subscription. An empty
+// Namespace or Partition is the default one.
+type Tenancy struct {
+	Namespace string
+	Partition string
+}
+
+func (t Tenancy) matches(req *pbsubscribe.SubscribeRequest) bool {
+	return orDefault(t.Namespace) == orDefault(req.Namespace) &&
+		orDefault(t.Partition) == orDefault(req.Partition)
+}
+
+func orDefault(name string) string {
+	if name == "" {
+		return "default"
+	}
+	return name
+}
+
+// NewStreamingClient returns a StreamingClient which accepts subscriptions
+// to the namespace ns. Subscriptions to other namespaces fail, unless they are
+// accepted with AcceptNamespaces.
+func NewStreamingClient(ns string) *StreamingClient {
+	return &StreamingClient{
+		changed:    make(chan struct{}),
+		namespaces: map[string]struct{}{ns: {}},
+	}
+}
+
+// AcceptNamespaces accepts subscriptions to namespaces, in addition to the
+// namespace the client was created with. Use QueueEventsForTenancy to send
+// events to the subscriptions of one namespace.
+func (s *StreamingClient) AcceptNamespaces(namespaces ...string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, ns := range namespaces {
+		s.namespaces[ns] = struct{}{}
+	}
+}
+
+func (s *StreamingClient) Subscribe(
+	ctx context.Context,
+	req *pbsubscribe.SubscribeRequest,
+	_ ...grpc.CallOption,
+) (pbsubscribe.StateChangeSubscription_SubscribeClient, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if _, ok := s.namespaces[req.Namespace]; !ok {
+		return nil, fmt.Errorf("wrong SubscribeRequest.Namespace %v, expected one of %v",
+			req.Namespace, s.namespaceList())
+	}
+
+	c := &subscribeClient{client: s, ctx: ctx, req: req}
+	switch {
+	case req.Index > 0 && req.Index < s.resetIndex && req.ViewHash != 0 && req.ViewHash == s.snapshotHash:
+		c.pending = []*pbsubscribe.Event{NewResumeStreamEvent(s.resetIndex)}
+		c.pos = s.snapshotEnd
+	case req.Index > 0 && req.Index < s.resetIndex:
+		c.pending = []*pbsubscribe.Event{NewSnapshotToFollowEvent()}
+	case req.Index > 0:
+		c.pos = s.resumePositionLocked(req)
+	}
+	s.subs = append(s.subs, c)
+	s.requests = append(s.requests, req)
+	return c, nil
+}
+
+func (s *StreamingClient) namespaceList() []string {
+	var names []string
+	for ns := range s.namespaces {
+		names = append(names, ns)
+	}
+	return names
+}
+
+// resumePositionLocked returns the position in the history after the last event
+// at or below req.Index, which was already received by the subscriber.
+func (s *StreamingClient) resumePositionLocked(req *pbsubscribe.SubscribeRequest) int {
+	pos := 0
+	for i, item := range s.history {
+		if item.event != nil && item.event.Index <= req.Index {
+			pos = i + 1
+		}
+	}
+	return pos
+}
+
+// Requests returns the requests of every subscription, in the order they were
+// received.
+func (s *StreamingClient) Requests() []*pbsubscribe.SubscribeRequest {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	return append([]*pbsubscribe.SubscribeRequest(nil), s.requests...)
+}
+
+// QueueEvents queues events for every subscription.
+func (s *StreamingClient) QueueEvents(events ...*pbsubscribe.Event) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, e := range events {
+		s.history = append(s.history, item{event: e})
+	}
+	s.notifyLocked()
+}
+
+// QueueEventsForTenancy queues events for the subscriptions to the namespace
+// and partition of tenancy.
+func (s *StreamingClient) QueueEventsForTenancy(tenancy Tenancy, events ...*pbsubscribe.Event) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for _, e := range events {
+		s.history = append(s.history, item{event: e, tenancy: &tenancy})
+	}
+	s.notifyLocked()
+}
+
+// QueueErr queues an error for every subscription. The error is returned to
+// every subscription which reaches it, including the subscriptions which
+// resume from an index before the error, so it is best suited to errors which
+// do not go away when the Materializer retries. Use FailAtIndex to return an
+// error once.
+func (s *StreamingClient) QueueErr(err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.history = append(s.history, item{err: err})
+	s.notifyLocked()
+}
+
+// FailAtIndex returns err once, to the first subscription which is about to
+// receive an event at or above index. The event is not received by that
+// subscription, so a Materializer which resubscribes from its last index
+// receives it next.
+func (s *StreamingClient) FailAtIndex(index uint64, err error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.failures = append(s.failures, failure{index: index, err: err})
+	s.notifyLocked()
+}
+
+// takeFailureLocked returns and removes the first failure injected for
+// an event at index, or nil if there is none.
+func (s *StreamingClient) takeFailureLocked(index uint64) error {
+	for i, f := range s.failures {
+		if index >= f.index {
+			s.failures = append(s.failures[:i], s.failures[i+1:]...)
+			return f.err
+		}
+	}
+	return nil
+}
+
+// QueueNewSnapshot replaces the events of the client with a new snapshot of
+// events at index, like a server which restarted and lost the events buffered
+// for the active subscriptions. The active subscriptions end with a
+// codes.Unavailable error, like a lost connection. Use QueueErr with a
+// codes.Aborted error to reset the views of the active subscriptions instead.
+// Subscriptions which resume from an index below index receive a
+// NewSnapshotToFollow event followed by the new snapshot, and new
+// subscriptions receive the new snapshot. Like a server, a subscription which
+// resumes with the ViewHash of the new snapshot receives a ResumeStream event
+// in place of the snapshot.
+func (s *StreamingClient) QueueNewSnapshot(index uint64, events ...*pbsubscribe.Event) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	var hash pbsubscribe.ViewHash
+	hash.Add(events...)
+
+	s.history = nil
+	for _, e := range events {
+		s.history = append(s.history, item{event: e})
+	}
+	s.history = append(s.history, item{event: NewEndOfSnapshotEvent(index)})
+	s.resetIndex = index
+	s.snapshotHash = hash.Sum()
+	s.snapshotEnd = len(s.history)
+
+	for _, c := range s.subs {
+		if c.ctx.Err() == nil {
+			c.ended = status.Error(codes.Unavailable, "the server was restarted")
+		}
+	}
+	s.subs = nil
+	s.notifyLocked()
+}
+
+// SetDelay delays the delivery of each event to the subscriptions by delay. A
+// delay of 0 delivers events as soon as they are queued.
+func (s *StreamingClient) SetDelay(delay time.Duration) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.delay = delay
+	s.notifyLocked()
+}
+
+// Pause holds the events and errors queued for the subscriptions until Resume
+// is called. Errors caused by QueueNewSnapshot are not held.
+func (s *StreamingClient) Pause() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.paused = true
+}
+
+// Resume delivers the events held since Pause was called.
+func (s *StreamingClient) Resume() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.paused = false
+	s.notifyLocked()
+}
+
+func (s *StreamingClient) notifyLocked() {
+	close(s.changed)
+	s.changed = make(chan struct{})
+}
+
+type subscribeClient struct {
+	grpc.ClientStream
+	client *StreamingClient
+	ctx    context.Context
+	req    *pbsubscribe.SubscribeRequest
+
+	// The fields below are protected by client.lock.
+
+	// pos is the position of the next item of client.history.
+	pos int
+	// pending are events received before client.history.
+	pending []*pbsubscribe.Event
+	// ended is returned by Recv once it is set by QueueNewSnapshot.
+	ended error
+}
+
+func (c *subscribeClient) Recv() (*pbsubscribe.Event, error) {
+	for {
+		c.client.lock.Lock()
+		event, ok, err := c.nextLocked()
+		changed, delay := c.client.changed, c.client.delay
+		c.client.lock.Unlock()
+
+		switch {
+		case !ok:
+			select {
+			case <-changed:
+				continue
+			case <-c.ctx.Done():
+				return nil, c.ctx.Err()
+			}
+		case err != nil:
+			return nil, err
+		}
+
+		if delay > 0 {
+			timer := time.NewTimer(delay)
+			select {
+			case <-timer.C:
+			case <-c.ctx.Done():
+				timer.Stop()
+				return nil, c.ctx.Err()
+			}
+		}
+		return event, nil
+	}
+}
+
+// nextLocked returns the next event or error of the subscription. ok is false
+// if there is none yet.
+func (c *subscribeClient) nextLocked() (event *pbsubscribe.Event, ok bool, err error) {
+	s := c.client
+	switch {
+	case c.ended != nil:
+		return nil, true, c.ended
+	case s.paused:
+		return nil, false, nil
+	case len(c.pending) > 0:
+		event, c.pending = c.pending[0], c.pending[1:]
+		return event, true, nil
+	}
+
+	for ; c.pos < len(s.history); c.pos++ {
+		item := s.history[c.pos]
+		if item.tenancy != nil && !item.tenancy.matches(c.req) {
+			continue
+		}
+		if item.event != nil {
+			if err := s.takeFailureLocked(item.event.Index); err != nil {
+				return nil, true, err
+			}
+		}
+		c.pos++
+		return item.event, true, item.err
+	}
+	return nil, false, nil
+}
+
+// NewEndOfSnapshotEvent returns an EndOfSnapshot event at index.
+func NewEndOfSnapshotEvent(index uint64) *pbsubscribe.Event {
+	return &pbsubscribe.Event{
+		Index:   index,
+		Payload: &pbsubscribe.Event_EndOfSnapshot{EndOfSnapshot: true},
+	}
+}
+
+// NewSnapshotToFollowEvent returns a NewSnapshotToFollow event.
+func NewSnapshotToFollowEvent() *pbsubscribe.Event {
+	return &pbsubscribe.Event{
+		Payload: &pbsubscribe.Event_NewSnapshotToFollow{NewSnapshotToFollow: true},
+	}
+}
+
+// NewResumeStreamEvent returns a ResumeStream event at index.
+func NewResumeStreamEvent(index uint64) *pbsubscribe.Event {
+	return &pbsubscribe.Event{
+		Index:   index,
+		Payload: &pbsubscribe.Event_ResumeStream{ResumeStream: true},
+	}
+}
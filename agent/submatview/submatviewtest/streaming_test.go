@@ -0,0 +1,175 @@
+package submatviewtest
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+
+	"github.com/hashicorp/consul/proto/pbsubscribe"
+)
+
+func newHeartbeatEvent(index uint64) *pbsubscribe.Event {
+	return &pbsubscribe.Event{
+		Index:   index,
+		Payload: &pbsubscribe.Event_Heartbeat{Heartbeat: true},
+	}
+}
+
+func subscribe(t *testing.T, client *StreamingClient, req *pbsubscribe.SubscribeRequest) pbsubscribe.StateChangeSubscription_SubscribeClient {
+	t.Helper()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	t.Cleanup(cancel)
+	sub, err := client.Subscribe(ctx, req)
+	require.NoError(t, err)
+	return sub
+}
+
+func requireRecvIndex(t *testing.T, sub pbsubscribe.StateChangeSubscription_SubscribeClient, index uint64) {
+	t.Helper()
+	event, err := sub.Recv()
+	require.NoError(t, err)
+	require.Equal(t, index, event.Index)
+}
+
+func TestStreamingClient_Resume(t *testing.T) {
+	client := NewStreamingClient("")
+	client.QueueEvents(newHeartbeatEvent(2), NewEndOfSnapshotEvent(2), newHeartbeatEvent(4))
+
+	sub := subscribe(t, client, &pbsubscribe.SubscribeRequest{})
+	requireRecvIndex(t, sub, 2)
+	requireRecvIndex(t, sub, 2)
+	requireRecvIndex(t, sub, 4)
+
+	sub = subscribe(t, client, &pbsubscribe.SubscribeRequest{Index: 2})
+	requireRecvIndex(t, sub, 4)
+
+	client.QueueEvents(newHeartbeatEvent(6))
+	requireRecvIndex(t, sub, 6)
+	require.Len(t, client.Requests(), 2)
+}
+
+func TestStreamingClient_FailAtIndex(t *testing.T) {
+	client := NewStreamingClient("")
+	client.QueueEvents(NewEndOfSnapshotEvent(2), newHeartbeatEvent(4), newHeartbeatEvent(6))
+	fail := errors.New("stream reset")
+	client.FailAtIndex(4, fail)
+
+	sub := subscribe(t, client, &pbsubscribe.SubscribeRequest{})
+	requireRecvIndex(t, sub, 2)
+	_, err := sub.Recv()
+	require.Equal(t, fail, err)
+
+	sub = subscribe(t, client, &pbsubscribe.SubscribeRequest{Index: 2})
+	requireRecvIndex(t, sub, 4)
+	requireRecvIndex(t, sub, 6)
+}
+
+func TestStreamingClient_QueueNewSnapshot(t *testing.T) {
+	client := NewStreamingClient("")
+	client.QueueEvents(NewEndOfSnapshotEvent(2))
+
+	sub := subscribe(t, client, &pbsubscribe.SubscribeRequest{})
+	requireRecvIndex(t, sub, 2)
+
+	client.QueueNewSnapshot(10, newHeartbeatEvent(10))
+	_, err := sub.Recv()
+	require.Equal(t, codes.Unavailable, status.Code(err))
+
+	sub = subscribe(t, client, &pbsubscribe.SubscribeRequest{Index: 2})
+	event, err := sub.Recv()
+	require.NoError(t, err)
+	require.True(t, event.GetNewSnapshotToFollow())
+	requireRecvIndex(t, sub, 10)
+
+	event, err = sub.Recv()
+	require.NoError(t, err)
+	require.True(t, event.GetEndOfSnapshot())
+	require.Equal(t, uint64(10), event.Index)
+}
+
+func TestStreamingClient_QueueNewSnapshot_ViewHash(t *testing.T) {
+	client := NewStreamingClient("")
+	snapshot := []*pbsubscribe.Event{newKVEvent(2, "a")}
+	client.QueueEvents(append(snapshot, NewEndOfSnapshotEvent(2))...)
+	client.QueueNewSnapshot(10, snapshot...)
+	client.QueueEvents(newKVEvent(11, "b"))
+
+	var hash pbsubscribe.ViewHash
+	hash.Add(snapshot...)
+
+	sub := subscribe(t, client, &pbsubscribe.SubscribeRequest{Index: 2, ViewHash: hash.Sum()})
+	event, err := sub.Recv()
+	require.NoError(t, err)
+	require.True(t, event.GetResumeStream())
+	require.Equal(t, uint64(10), event.Index)
+	requireRecvIndex(t, sub, 11)
+
+	sub = subscribe(t, client, &pbsubscribe.SubscribeRequest{Index: 2, ViewHash: hash.Sum() + 1})
+	event, err = sub.Recv()
+	require.NoError(t, err)
+	require.True(t, event.GetNewSnapshotToFollow())
+}
+
+func newKVEvent(index uint64, key string) *pbsubscribe.Event {
+	return &pbsubscribe.Event{
+		Index:   index,
+		Payload: &pbsubscribe.Event_KV{KV: &pbsubscribe.KVUpdate{Entry: &pbsubscribe.KVEntry{Key: key}}},
+	}
+}
+
+func TestStreamingClient_Tenancy(t *testing.T) {
+	client := NewStreamingClient("")
+	client.AcceptNamespaces("ns1")
+
+	_, err := client.Subscribe(context.Background(), &pbsubscribe.SubscribeRequest{Namespace: "ns2"})
+	require.Error(t, err)
+
+	client.QueueEventsForTenancy(Tenancy{Namespace: "ns1"}, newHeartbeatEvent(2))
+	client.QueueEventsForTenancy(Tenancy{Partition: "part1"}, newHeartbeatEvent(3))
+	client.QueueEvents(newHeartbeatEvent(4))
+
+	sub := subscribe(t, client, &pbsubscribe.SubscribeRequest{Namespace: "ns1"})
+	requireRecvIndex(t, sub, 2)
+	requireRecvIndex(t, sub, 4)
+
+	sub = subscribe(t, client, &pbsubscribe.SubscribeRequest{})
+	requireRecvIndex(t, sub, 4)
+}
+
+func TestStreamingClient_FlowControl(t *testing.T) {
+	client := NewStreamingClient("")
+	sub := subscribe(t, client, &pbsubscribe.SubscribeRequest{})
+
+	client.Pause()
+	client.QueueEvents(newHeartbeatEvent(2))
+
+	received := make(chan uint64, 1)
+	go func() {
+		event, err := sub.Recv()
+		if err == nil {
+			received <- event.Index
+		}
+	}()
+
+	select {
+	case <-received:
+		t.Fatal("received an event while paused")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	client.SetDelay(50 * time.Millisecond)
+	start := time.Now()
+	client.Resume()
+	select {
+	case index := <-received:
+		require.Equal(t, uint64(2), index)
+		require.True(t, time.Since(start) >= 50*time.Millisecond, "event was not delayed")
+	case <-time.After(time.Second):
+		t.Fatal("did not receive the event after Resume")
+	}
+}
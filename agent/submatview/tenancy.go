@@ -0,0 +1,125 @@
+package submatview
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hashicorp/consul/agent/cache"
+)
+
+// WildcardNamespace is the Tenancy.Namespace of a request for the data of every
+// namespace of a partition.
+const WildcardNamespace = "*"
+
+// Tenancy is the admin partition and namespace of the data requested by a
+// Request. Empty values are the default partition and namespace.
+type Tenancy struct {
+	Partition string
+	Namespace string
+}
+
+// IsZero returns true if the tenancy is the default partition and namespace.
+func (t Tenancy) IsZero() bool {
+	return t.Partition == "" && t.Namespace == ""
+}
+
+// String returns the tenancy as "partition/namespace".
+func (t Tenancy) String() string {
+	return t.Partition + "/" + t.Namespace
+}
+
+// matches returns true if t and other identify the same data. Partitions and
+// namespaces are case insensitive, like in the state store.
+func (t Tenancy) matches(other Tenancy) bool {
+	return strings.EqualFold(t.Partition, other.Partition) &&
+		strings.EqualFold(t.Namespace, other.Namespace)
+}
+
+// TenancyRequest may be implemented by a Request to key its entry by the admin
+// partition and namespace of the request, in addition to the fields of
+// CacheInfo(). The CacheInfo().Key of a TenancyRequest does not need to include
+// them.
+type TenancyRequest interface {
+	Request
+	Tenancy() Tenancy
+}
+
+// WildcardNamespaceRequest may be implemented by a TenancyRequest which can be
+// served by a view of every namespace of its partition. When
+// StoreOptions.ShareNamespaces is enabled, the requests for the same data in
+// different namespaces share the entry of the request returned by
+// WithWildcardNamespace, and receive the TenancyView.TenancyResult of their
+// own namespace.
+//
+// Requests for a delta result or for a page of the result are not shared,
+// because the history and the pages of the view contain every namespace. The
+// results of shared requests returned by Store.Get are limited to
+// StoreOptions.MaxResultItems, counting the items of their own namespace.
+type WildcardNamespaceRequest interface {
+	TenancyRequest
+	// WithWildcardNamespace returns a request for the same data in every
+	// namespace of the partition of the request. The View of its Materializer
+	// must implement TenancyView.
+	WithWildcardNamespace() TenancyRequest
+}
+
+// TenancyView is a View of the data of more than one namespace, which can return
+// the result of a single namespace. See WildcardNamespaceRequest.
+type TenancyView interface {
+	View
+	// TenancyLen returns the number of items in the TenancyResult of tenancy.
+	TenancyLen(tenancy Tenancy) int
+	// TenancyResult returns the result of Result, containing only the data
+	// of tenancy.
+	TenancyResult(index uint64, tenancy Tenancy) interface{}
+}
+
+// requestTenancy returns the Tenancy of req, or the default tenancy if req is
+// not a TenancyRequest.
+func requestTenancy(req Request) Tenancy {
+	if tr, ok := req.(TenancyRequest); ok {
+		return tr.Tenancy()
+	}
+	return Tenancy{}
+}
+
+// sharedRequest returns the request whose entry serves req. When req is shared
+// with the other namespaces of its partition, the returned request is its
+// wildcard namespace request, and the returned tenancy selects the result of
+// the namespace of req. Otherwise req is returned with a nil tenancy.
+func (s *Store) sharedRequest(req Request) (Request, *Tenancy) {
+	if !s.shareNamespaces {
+		return req, nil
+	}
+	wr, ok := req.(WildcardNamespaceRequest)
+	if !ok {
+		return req, nil
+	}
+	if dr, ok := req.(DeltaRequest); ok && dr.AcceptsDelta() {
+		return req, nil
+	}
+	if pr, ok := req.(PagedRequest); ok && pr.Page().Size > 0 {
+		return req, nil
+	}
+
+	tenancy := wr.Tenancy()
+	if tenancy.Namespace == WildcardNamespace {
+		return req, nil
+	}
+	shared := wr.WithWildcardNamespace()
+	if shared.Tenancy().matches(tenancy) {
+		// Without namespaces the wildcard request is the same as req.
+		return shared, nil
+	}
+	return shared, &tenancy
+}
+
+// makeEntryKey matches agent/cache.makeEntryKey, with the tenancy of the request
+// added before the key. The tenancy is omitted when it is the default one, so
+// that the keys of the other requests are unchanged.
+func makeEntryKey(typ string, r cache.RequestInfo, t Tenancy) string {
+	if t.IsZero() {
+		return fmt.Sprintf("%s/%s/%s/%s", typ, r.Datacenter, r.Token, r.Key)
+	}
+	return fmt.Sprintf("%s/%s/%s/%s/%s", typ, r.Datacenter, r.Token, t, r.Key)
+}
@@ -0,0 +1,65 @@
+package submatview
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// defaultUnsupportedTopicRetryInterval is the default
+// UnsupportedTopic.RetryInterval.
+const defaultUnsupportedTopicRetryInterval = 5 * time.Minute
+
+// IsUnknownTopicError returns true if err was returned by a server which does
+// not support the topic of the subscription.
+func IsUnknownTopicError(err error) bool {
+	return err != nil && strings.Contains(err.Error(), "unknown topic")
+}
+
+// UnsupportedTopic remembers the datacenters whose servers were found not to
+// support the topic used by a client of the Store. The client uses the RPC for
+// the requests to those datacenters, so that client agents may be upgraded
+// before the servers, without creating a view which fails for every request.
+// The view is tried again after RetryInterval, so that the servers are used
+// once they are upgraded.
+//
+// The zero value is ready to use.
+type UnsupportedTopic struct {
+	// RetryInterval is how long the requests to a datacenter use the RPC after
+	// its servers did not support the topic. Defaults to 5 minutes.
+	RetryInterval time.Duration
+
+	lock   sync.Mutex
+	failed map[string]time.Time
+}
+
+// Unsupported returns true if the servers of dc were found not to support the
+// topic within the last RetryInterval.
+func (u *UnsupportedTopic) Unsupported(dc string) bool {
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	failed, ok := u.failed[dc]
+	return ok && time.Since(failed) < u.retryInterval()
+}
+
+// Record returns true if err was returned by a server which does not support
+// the topic, and records it for dc. The caller uses the RPC in that case.
+func (u *UnsupportedTopic) Record(dc string, err error) bool {
+	if !IsUnknownTopicError(err) {
+		return false
+	}
+	u.lock.Lock()
+	defer u.lock.Unlock()
+	if u.failed == nil {
+		u.failed = make(map[string]time.Time)
+	}
+	u.failed[dc] = time.Now()
+	return true
+}
+
+func (u *UnsupportedTopic) retryInterval() time.Duration {
+	if u.RetryInterval > 0 {
+		return u.RetryInterval
+	}
+	return defaultUnsupportedTopicRetryInterval
+}
@@ -0,0 +1,21 @@
+package submatview
+
+// viewHash returns the ViewHash of the request which resumes the subscription
+// from the index of the view, so that the servers can resume the stream
+// without a new snapshot if the view matches it. It returns 0 if the hash of
+// the view is unknown.
+func (m *Materializer) viewHash() uint64 {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.hashUnknown {
+		return 0
+	}
+	return m.hash.Sum()
+}
+
+// resetHashLocked resets the hash of the view along with the view. Must be
+// called while holding m.lock.
+func (m *Materializer) resetHashLocked() {
+	m.hash.Reset()
+	m.hashUnknown = false
+}
@@ -0,0 +1,82 @@
+package submatview
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/armon/go-metrics"
+)
+
+// streamTimeoutErr is returned by a subscription which was cancelled by its
+// watchdog. It is temporary so that the first retry does not notify watchers.
+type streamTimeoutErr struct {
+	timeout time.Duration
+}
+
+// Temporary Implements the internal Temporary interface
+func (e streamTimeoutErr) Temporary() bool {
+	return true
+}
+
+// Error implements error
+func (e streamTimeoutErr) Error() string {
+	return fmt.Sprintf("no events or heartbeats were received for %v", e.timeout)
+}
+
+// heartbeatIntervalMillis returns the interval between heartbeats requested
+// from the servers. A third of the watchdog timeout allows two heartbeats to be
+// lost before the subscription is restarted.
+func (m *Materializer) heartbeatIntervalMillis() uint64 {
+	return uint64(m.deps.WatchdogTimeout / 3 / time.Millisecond)
+}
+
+// streamWatchdog cancels a subscription which did not receive an event or a
+// heartbeat for the watchdog timeout. It is only armed once the first
+// heartbeat is received, so that subscriptions to servers which do not send
+// heartbeats are not restarted while they are idle.
+type streamWatchdog struct {
+	timeout time.Duration
+	expired func()
+	timer   *time.Timer
+}
+
+// newWatchdog returns the watchdog of the subscription of ctx, or nil if
+// Deps.WatchdogTimeout is not set.
+func (m *Materializer) newWatchdog(ctx context.Context, cancel context.CancelFunc) *streamWatchdog {
+	timeout := m.deps.WatchdogTimeout
+	if timeout <= 0 {
+		return nil
+	}
+	return &streamWatchdog{
+		timeout: timeout,
+		expired: func() {
+			m.deps.Logger.Warn("no events or heartbeats received from the servers, restarting the subscription",
+				"topic", m.topic,
+				"timeout", timeout)
+			metrics.IncrCounterWithLabels([]string{"submatview", "materializer", "stream_timeout"}, 1,
+				m.metricsLabels())
+			m.cancelSubscription(ctx, cancel, streamTimeoutErr{timeout: timeout})
+		},
+	}
+}
+
+// received restarts the timeout after an event or heartbeat was received, and
+// arms the watchdog on the first heartbeat. It must only be called from the Run
+// goroutine.
+func (w *streamWatchdog) received(heartbeat bool) {
+	switch {
+	case w == nil:
+	case w.timer != nil:
+		w.timer.Reset(w.timeout)
+	case heartbeat:
+		w.timer = time.AfterFunc(w.timeout, w.expired)
+	}
+}
+
+// stop the watchdog once the subscription ended.
+func (w *streamWatchdog) stop() {
+	if w != nil && w.timer != nil {
+		w.timer.Stop()
+	}
+}
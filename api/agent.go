@@ -491,6 +491,31 @@ func (a *Agent) Host() (map[string]interface{}, error) {
 	return out, nil
 }
 
+// StreamingCacheSnapshot is used to retrieve a snapshot of the materialized
+// views held by the agent to serve blocking queries with the streaming
+// backend. When redact is true the views are redacted, see the API docs. The
+// structure of the snapshot is not stable, so it is returned as a generic map.
+func (a *Agent) StreamingCacheSnapshot(redact bool) (map[string]interface{}, error) {
+	r := a.c.newRequest("GET", "/v1/agent/streaming-cache")
+	r.params.Set("snapshot", "")
+	if redact {
+		r.params.Set("redact", "")
+	}
+	_, resp, err := a.c.doRequest(r)
+	if err != nil {
+		return nil, err
+	}
+	defer closeResponseBody(resp)
+	if err := requireOK(resp); err != nil {
+		return nil, err
+	}
+	var out map[string]interface{}
+	if err := decodeBody(resp, &out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // Metrics is used to query the agent we are speaking to for
 // its current internal metric data
 func (a *Agent) Metrics() (*MetricsInfo, error) {
@@ -83,6 +83,18 @@ func TestAPI_AgentHost(t *testing.T) {
 	})
 }
 
+func TestAPI_AgentStreamingCacheSnapshot(t *testing.T) {
+	t.Parallel()
+	c, s := makeClient(t)
+	defer s.Stop()
+
+	agent := c.Agent()
+	snap, err := agent.StreamingCacheSnapshot(true)
+	require.NoError(t, err)
+	require.Equal(t, true, snap["Redacted"])
+	require.Contains(t, snap, "Entries")
+}
+
 func TestAPI_AgentReload(t *testing.T) {
 	t.Parallel()
 
@@ -81,7 +81,11 @@ type cmd struct {
 	output   string
 	archive  bool
 	capture  []string
-	client   *api.Client
+	// redactStreamingCache replaces the keys, datacenters, partitions, and
+	// namespaces of the streaming cache entries with opaque identifiers in the
+	// streaming-cache target.
+	redactStreamingCache bool
+	client               *api.Client
 	// validateTiming can be used to skip validation of interval, duration. This
 	// is primarily useful for testing
 	validateTiming bool
@@ -133,6 +137,10 @@ func (c *cmd) init() {
 	c.flags.StringVar(&c.output, "output", defaultFilename, "The path "+
 		"to the compressed archive that will be created with the "+
 		"information after collection.")
+	c.flags.BoolVar(&c.redactStreamingCache, "redact-streaming-cache", true, "Boolean value "+
+		"for if the keys, datacenters, partitions, and namespaces of the streaming cache "+
+		"entries should be redacted from the streaming-cache target, so that they can "+
+		"not be traced back to the requested service names or KV keys. Defaults to true.")
 
 	c.http = &flags.HTTPFlags{}
 	flags.Merge(c.flags, c.http.ClientFlags())
@@ -326,6 +334,16 @@ func (c *cmd) captureStatic() error {
 			errs = multierror.Append(errs, err)
 		}
 	}
+
+	if c.captureTarget(targetStreamingCache) {
+		snap, err := c.client.Agent().StreamingCacheSnapshot(c.redactStreamingCache)
+		if err != nil {
+			errs = multierror.Append(errs, err)
+		}
+		if err := writeJSONFile(filepath.Join(c.output, targetStreamingCache+".json"), snap); err != nil {
+			errs = multierror.Append(errs, err)
+		}
+	}
 	return errs
 }
 
@@ -688,6 +706,8 @@ const (
 	targetHost     = "host"
 	targetAgent    = "agent"
 	targetMembers  = "members"
+	// targetStreamingCache is the state of the views of the streaming cache
+	targetStreamingCache = "streaming-cache"
 	// targetCluster is the now deprecated name for targetMembers
 	targetCluster = "cluster"
 )
@@ -700,6 +720,7 @@ var defaultTargets = []string{
 	targetHost,
 	targetAgent,
 	targetMembers,
+	targetStreamingCache,
 }
 
 var deprecatedTargets = []string{targetCluster}
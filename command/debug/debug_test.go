@@ -75,6 +75,7 @@ func TestDebugCommand(t *testing.T) {
 			fs.WithFile("agent.json", "", fs.MatchFileContent(validJSON)),
 			fs.WithFile("host.json", "", fs.MatchFileContent(validJSON)),
 			fs.WithFile("members.json", "", fs.MatchFileContent(validJSON)),
+			fs.WithFile("streaming-cache.json", "", fs.MatchFileContent(validJSON)),
 			fs.WithFile("metrics.json", "", fs.MatchAnyFileContent),
 			fs.WithFile("consul.log", "", fs.MatchFileContent(validLogFile)),
 			fs.WithFile("profile.prof", "", fs.MatchFileContent(validProfileData)),
@@ -327,11 +328,11 @@ func TestDebugCommand_CaptureTargets(t *testing.T) {
 		"single": {
 			[]string{"agent"},
 			[]string{"agent.json"},
-			[]string{"host.json", "members.json"},
+			[]string{"host.json", "members.json", "streaming-cache.json"},
 		},
 		"static": {
-			[]string{"agent", "host", "cluster"},
-			[]string{"agent.json", "host.json", "members.json"},
+			[]string{"agent", "host", "cluster", "streaming-cache"},
+			[]string{"agent.json", "host.json", "members.json", "streaming-cache.json"},
 			[]string{"metrics.json"},
 		},
 		"metrics-only": {
@@ -0,0 +1,221 @@
+package pbsubscribe
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/gogo/protobuf/types"
+	"github.com/hashicorp/go-msgpack/codec"
+
+	"github.com/hashicorp/consul/agent/structs"
+	"github.com/hashicorp/consul/proto/pbservice"
+)
+
+// KVEntryToStructs converts a KVEntry to a structs.DirEntry.
+func KVEntryToStructs(s *KVEntry) *structs.DirEntry {
+	if s == nil {
+		return nil
+	}
+	t := &structs.DirEntry{
+		Key:       s.Key,
+		Value:     s.Value,
+		Flags:     s.Flags,
+		Session:   s.Session,
+		LockIndex: s.LockIndex,
+		RaftIndex: structs.RaftIndex{
+			CreateIndex: s.CreateIndex,
+			ModifyIndex: s.ModifyIndex,
+		},
+	}
+	if s.EnterpriseMeta != nil {
+		t.EnterpriseMeta = pbservice.EnterpriseMetaToStructs(*s.EnterpriseMeta)
+	}
+	return t
+}
+
+// NewKVEntryFromStructs converts a structs.DirEntry to a KVEntry.
+func NewKVEntryFromStructs(t *structs.DirEntry) *KVEntry {
+	if t == nil {
+		return nil
+	}
+	entMeta := pbservice.NewEnterpriseMetaFromStructs(t.EnterpriseMeta)
+	return &KVEntry{
+		Key:            t.Key,
+		Value:          t.Value,
+		Flags:          t.Flags,
+		Session:        t.Session,
+		LockIndex:      t.LockIndex,
+		CreateIndex:    t.CreateIndex,
+		ModifyIndex:    t.ModifyIndex,
+		EnterpriseMeta: &entMeta,
+	}
+}
+
+// IntentionToStructs converts an Intention to a structs.Intention.
+func IntentionToStructs(s *Intention) *structs.Intention {
+	if s == nil {
+		return nil
+	}
+	t := &structs.Intention{
+		ID:                   s.ID,
+		Description:          s.Description,
+		SourcePartition:      s.SourcePartition,
+		SourceNS:             s.SourceNS,
+		SourceName:           s.SourceName,
+		DestinationPartition: s.DestinationPartition,
+		DestinationNS:        s.DestinationNS,
+		DestinationName:      s.DestinationName,
+		SourceType:           structs.IntentionSourceType(s.SourceType),
+		Action:               structs.IntentionAction(s.Action),
+		DefaultAddr:          s.DefaultAddr,
+		DefaultPort:          int(s.DefaultPort),
+		Meta:                 s.Meta,
+		Precedence:           int(s.Precedence),
+		CreatedAt:            timeFromProto(s.CreatedAt),
+		UpdatedAt:            timeFromProto(s.UpdatedAt),
+		Hash:                 s.Hash,
+		RaftIndex: structs.RaftIndex{
+			CreateIndex: s.CreateIndex,
+			ModifyIndex: s.ModifyIndex,
+		},
+	}
+	for _, perm := range s.Permissions {
+		t.Permissions = append(t.Permissions, intentionPermissionToStructs(perm))
+	}
+	return t
+}
+
+func intentionPermissionToStructs(s *IntentionPermission) *structs.IntentionPermission {
+	t := &structs.IntentionPermission{Action: structs.IntentionAction(s.Action)}
+	if s.HTTP == nil {
+		return t
+	}
+	t.HTTP = &structs.IntentionHTTPPermission{
+		PathExact:  s.HTTP.PathExact,
+		PathPrefix: s.HTTP.PathPrefix,
+		PathRegex:  s.HTTP.PathRegex,
+		Methods:    s.HTTP.Methods,
+	}
+	for _, hdr := range s.HTTP.Header {
+		t.HTTP.Header = append(t.HTTP.Header, structs.IntentionHTTPHeaderPermission{
+			Name:    hdr.Name,
+			Present: hdr.Present,
+			Exact:   hdr.Exact,
+			Prefix:  hdr.Prefix,
+			Suffix:  hdr.Suffix,
+			Regex:   hdr.Regex,
+			Invert:  hdr.Invert,
+		})
+	}
+	return t
+}
+
+// NewIntentionFromStructs converts a structs.Intention to an Intention.
+func NewIntentionFromStructs(t *structs.Intention) *Intention {
+	if t == nil {
+		return nil
+	}
+	s := &Intention{
+		ID:                   t.ID,
+		Description:          t.Description,
+		SourcePartition:      t.SourcePartition,
+		SourceNS:             t.SourceNS,
+		SourceName:           t.SourceName,
+		DestinationPartition: t.DestinationPartition,
+		DestinationNS:        t.DestinationNS,
+		DestinationName:      t.DestinationName,
+		SourceType:           string(t.SourceType),
+		Action:               string(t.Action),
+		DefaultAddr:          t.DefaultAddr,
+		DefaultPort:          int32(t.DefaultPort),
+		Meta:                 t.Meta,
+		Precedence:           int32(t.Precedence),
+		CreatedAt:            newProtoFromTime(t.CreatedAt),
+		UpdatedAt:            newProtoFromTime(t.UpdatedAt),
+		Hash:                 t.Hash,
+		CreateIndex:          t.CreateIndex,
+		ModifyIndex:          t.ModifyIndex,
+	}
+	for _, perm := range t.Permissions {
+		s.Permissions = append(s.Permissions, newIntentionPermissionFromStructs(perm))
+	}
+	return s
+}
+
+func newIntentionPermissionFromStructs(t *structs.IntentionPermission) *IntentionPermission {
+	s := &IntentionPermission{Action: string(t.Action)}
+	if t.HTTP == nil {
+		return s
+	}
+	s.HTTP = &IntentionHTTPPermission{
+		PathExact:  t.HTTP.PathExact,
+		PathPrefix: t.HTTP.PathPrefix,
+		PathRegex:  t.HTTP.PathRegex,
+		Methods:    t.HTTP.Methods,
+	}
+	for _, hdr := range t.HTTP.Header {
+		s.HTTP.Header = append(s.HTTP.Header, &IntentionHTTPHeaderPermission{
+			Name:    hdr.Name,
+			Present: hdr.Present,
+			Exact:   hdr.Exact,
+			Prefix:  hdr.Prefix,
+			Suffix:  hdr.Suffix,
+			Regex:   hdr.Regex,
+			Invert:  hdr.Invert,
+		})
+	}
+	return s
+}
+
+// timeFromProto returns the time of ts, or the zero time if ts is not set.
+func timeFromProto(ts *types.Timestamp) time.Time {
+	if ts == nil {
+		return time.Time{}
+	}
+	t, err := types.TimestampFromProto(ts)
+	if err != nil {
+		return time.Time{}
+	}
+	return t
+}
+
+// newProtoFromTime returns t as a Timestamp, or nil if t is the zero time.
+func newProtoFromTime(t time.Time) *types.Timestamp {
+	if t.IsZero() {
+		return nil
+	}
+	ts, err := types.TimestampProto(t)
+	if err != nil {
+		return nil
+	}
+	return ts
+}
+
+// ConfigEntryUpdateToStructs decodes the config entry of a ConfigEntryUpdate.
+func ConfigEntryUpdateToStructs(s *ConfigEntryUpdate) (structs.ConfigEntry, error) {
+	entry, err := structs.MakeConfigEntry(s.Kind, s.Name)
+	if err != nil {
+		return nil, err
+	}
+	if err := codec.NewDecoderBytes(s.Entry, structs.MsgpackHandle).Decode(entry); err != nil {
+		return nil, fmt.Errorf("failed to decode %s config entry %q: %w", s.Kind, s.Name, err)
+	}
+	return entry, nil
+}
+
+// NewConfigEntryUpdateFromStructs converts a structs.ConfigEntry to a
+// ConfigEntryUpdate.
+func NewConfigEntryUpdateFromStructs(op UpdateOp, t structs.ConfigEntry) (*ConfigEntryUpdate, error) {
+	var buf []byte
+	if err := codec.NewEncoderBytes(&buf, structs.MsgpackHandle).Encode(t); err != nil {
+		return nil, fmt.Errorf("failed to encode %s config entry %q: %w", t.GetKind(), t.GetName(), err)
+	}
+	entMeta := pbservice.NewEnterpriseMetaFromStructs(*t.GetEnterpriseMeta())
+	return &ConfigEntryUpdate{
+		Op:             op,
+		Kind:           t.GetKind(),
+		Name:           t.GetName(),
+		EnterpriseMeta: &entMeta,
+		Entry:          buf,
+	}, nil
+}
@@ -0,0 +1,172 @@
+package pbsubscribe
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"strings"
+
+	"github.com/hashicorp/consul/proto/pbcommon"
+)
+
+// ViewHash computes the hash of a view from the events applied to it, for
+// SubscribeRequest.ViewHash. The hash only depends on the latest event of each
+// item of the view, and not on the order of the events, so a view built from a
+// snapshot followed by updates has the same hash as a new snapshot of the same
+// items.
+//
+// The zero value is ready to use. ViewHash is not safe for concurrent use.
+type ViewHash struct {
+	// items is the hash of the latest event of each item, by item key.
+	items map[string]uint64
+	sum   uint64
+	// unknown is set when an event can not be hashed, for example because the
+	// payload type is not supported. Sum returns 0 until Reset is called.
+	unknown bool
+}
+
+// Add updates the hash with events, in the order they are applied to the view.
+// The events of an EventBatch are added in order, and framing events are
+// ignored.
+func (h *ViewHash) Add(events ...*Event) {
+	for _, event := range events {
+		if h.unknown {
+			return
+		}
+		if batch := event.GetEventBatch(); batch != nil {
+			h.Add(batch.Events...)
+			continue
+		}
+		h.add(event)
+	}
+}
+
+func (h *ViewHash) add(event *Event) {
+	switch event.Payload.(type) {
+	case *Event_EndOfSnapshot, *Event_NewSnapshotToFollow, *Event_Heartbeat, *Event_ResumeStream:
+		return
+	}
+
+	key, removed, ok := itemKey(event)
+	if !ok {
+		h.unknown = true
+		return
+	}
+	if removed {
+		if update := event.GetNode(); update != nil && update.Node != nil {
+			// A Deregister of a node also removes its services and checks.
+			h.removePrefix(key)
+			return
+		}
+		h.remove(key)
+		return
+	}
+
+	value, err := hashEvent(event)
+	if err != nil {
+		h.unknown = true
+		return
+	}
+	h.remove(key)
+	if h.items == nil {
+		h.items = make(map[string]uint64)
+	}
+	h.items[key] = value
+	h.sum += value
+}
+
+func (h *ViewHash) remove(key string) {
+	if value, ok := h.items[key]; ok {
+		h.sum -= value
+		delete(h.items, key)
+	}
+}
+
+func (h *ViewHash) removePrefix(prefix string) {
+	for key := range h.items {
+		if strings.HasPrefix(key, prefix) {
+			h.remove(key)
+		}
+	}
+}
+
+// Sum returns the hash of the view, or 0 if the hash is unknown.
+func (h *ViewHash) Sum() uint64 {
+	if h.unknown {
+		return 0
+	}
+	return h.sum
+}
+
+// Reset the hash to the hash of an empty view.
+func (h *ViewHash) Reset() {
+	h.items = nil
+	h.sum = 0
+	h.unknown = false
+}
+
+// hashEvent returns the hash of the payload of event. The payload is encoded
+// as JSON, which, unlike the protobuf encoding, sorts the keys of maps. The
+// event is first decoded from its protobuf encoding, so that the hash of an
+// event sent by a server matches the hash of the event received by a client.
+// For example an empty slice is received as a nil slice.
+func hashEvent(event *Event) (uint64, error) {
+	data, err := event.Marshal()
+	if err != nil {
+		return 0, err
+	}
+	var decoded Event
+	if err := decoded.Unmarshal(data); err != nil {
+		return 0, err
+	}
+	payload, err := json.Marshal(decoded.Payload)
+	if err != nil {
+		return 0, err
+	}
+	h := fnv.New64a()
+	h.Write(payload)
+	return h.Sum64(), nil
+}
+
+// itemKey returns the key of the item of the view changed by event, and true
+// if the event removes the item from the view. ok is false if the payload of
+// event is not supported.
+func itemKey(event *Event) (key string, removed bool, ok bool) {
+	switch p := event.Payload.(type) {
+	case *Event_ServiceHealth:
+		csn := p.ServiceHealth.CheckServiceNode
+		return "service-health/" + csn.UniqueID(), p.ServiceHealth.Op == CatalogOp_Deregister, true
+	case *Event_KV:
+		entry := p.KV.Entry
+		return "kv/" + entMetaKey(entry.EnterpriseMeta) + entry.Key,
+			p.KV.Op == UpdateOp_Delete, true
+	case *Event_CatalogService:
+		svc := p.CatalogService
+		return "catalog-service/" + entMetaKey(svc.EnterpriseMeta) +
+			svc.Node + "/" + svc.ServiceID, svc.Op == CatalogOp_Deregister, true
+	case *Event_Intention:
+		return "intention/" + p.Intention.Intention.GetID(), p.Intention.Op == UpdateOp_Delete, true
+	case *Event_ConfigEntry:
+		entry := p.ConfigEntry
+		return "config-entry/" + entMetaKey(entry.EnterpriseMeta) +
+			entry.Kind + "/" + entry.Name, entry.Op == UpdateOp_Delete, true
+	case *Event_Node:
+		update := p.Node
+		key := "node/" + update.NodeName + "/"
+		switch {
+		case update.Service != nil:
+			key += "service/" + entMetaKey(&update.Service.EnterpriseMeta) + update.Service.ID
+		case update.Check != nil:
+			key += "check/" + entMetaKey(&update.Check.EnterpriseMeta) + string(update.Check.CheckID)
+		}
+		return key, update.Op == CatalogOp_Deregister, true
+	default:
+		return "", false, false
+	}
+}
+
+func entMetaKey(entMeta *pbcommon.EnterpriseMeta) string {
+	if entMeta == nil {
+		return "//"
+	}
+	return entMeta.Partition + "/" + entMeta.Namespace + "/"
+}
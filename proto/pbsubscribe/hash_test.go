@@ -0,0 +1,109 @@
+package pbsubscribe
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/hashicorp/consul/proto/pbservice"
+)
+
+func newNodeServiceEvent(op CatalogOp, node, service string, port int32) *Event {
+	return &Event{
+		Payload: &Event_Node{Node: &NodeUpdate{
+			Op:       op,
+			NodeName: node,
+			Service:  &pbservice.NodeService{ID: service, Service: service, Port: port},
+		}},
+	}
+}
+
+func newKVEvent(op UpdateOp, key string, value string) *Event {
+	return &Event{
+		Payload: &Event_KV{KV: &KVUpdate{
+			Op:    op,
+			Entry: &KVEntry{Key: key, Value: []byte(value)},
+		}},
+	}
+}
+
+func viewHash(events ...*Event) uint64 {
+	var h ViewHash
+	h.Add(events...)
+	return h.Sum()
+}
+
+func TestViewHash(t *testing.T) {
+	snapshot := viewHash(
+		newKVEvent(UpdateOp_Upsert, "a", "1"),
+		newKVEvent(UpdateOp_Upsert, "b", "2"))
+	require.NotZero(t, snapshot)
+
+	t.Run("the order of the events does not change the hash", func(t *testing.T) {
+		require.Equal(t, snapshot, viewHash(
+			newKVEvent(UpdateOp_Upsert, "b", "2"),
+			newKVEvent(UpdateOp_Upsert, "a", "1")))
+	})
+
+	t.Run("only the latest event of an item is hashed", func(t *testing.T) {
+		require.Equal(t, snapshot, viewHash(
+			newKVEvent(UpdateOp_Upsert, "a", "0"),
+			newKVEvent(UpdateOp_Upsert, "c", "3"),
+			&Event{Payload: &Event_EventBatch{EventBatch: &EventBatch{Events: []*Event{
+				newKVEvent(UpdateOp_Upsert, "b", "2"),
+				newKVEvent(UpdateOp_Delete, "c", ""),
+			}}}},
+			newKVEvent(UpdateOp_Upsert, "a", "1")))
+	})
+
+	t.Run("the index and framing events are not hashed", func(t *testing.T) {
+		b := newKVEvent(UpdateOp_Upsert, "b", "2")
+		b.Index = 7
+		require.Equal(t, snapshot, viewHash(
+			newKVEvent(UpdateOp_Upsert, "a", "1"),
+			&Event{Index: 7, Payload: &Event_Heartbeat{Heartbeat: true}},
+			b,
+			&Event{Index: 7, Payload: &Event_EndOfSnapshot{EndOfSnapshot: true}}))
+	})
+
+	t.Run("a changed item changes the hash", func(t *testing.T) {
+		require.NotEqual(t, snapshot, viewHash(
+			newKVEvent(UpdateOp_Upsert, "a", "1"),
+			newKVEvent(UpdateOp_Upsert, "b", "3")))
+	})
+
+	t.Run("an empty view", func(t *testing.T) {
+		require.Zero(t, viewHash(
+			newKVEvent(UpdateOp_Upsert, "a", "1"),
+			newKVEvent(UpdateOp_Delete, "a", "")))
+	})
+
+	t.Run("the deregistration of a node removes its services", func(t *testing.T) {
+		node := &Event{Payload: &Event_Node{Node: &NodeUpdate{
+			Op:       CatalogOp_Register,
+			NodeName: "node1",
+			Node:     &pbservice.Node{Node: "node1"},
+		}}}
+		other := newNodeServiceEvent(CatalogOp_Register, "node2", "web", 8080)
+
+		require.Equal(t, viewHash(other), viewHash(
+			node,
+			newNodeServiceEvent(CatalogOp_Register, "node1", "web", 8080),
+			other,
+			&Event{Payload: &Event_Node{Node: &NodeUpdate{
+				Op:       CatalogOp_Deregister,
+				NodeName: "node1",
+				Node:     &pbservice.Node{Node: "node1"},
+			}}}))
+	})
+
+	t.Run("unsupported events", func(t *testing.T) {
+		var h ViewHash
+		h.Add(newKVEvent(UpdateOp_Upsert, "a", "1"), &Event{})
+		require.Zero(t, h.Sum())
+
+		h.Reset()
+		h.Add(newKVEvent(UpdateOp_Upsert, "a", "1"))
+		require.NotZero(t, h.Sum())
+	})
+}
@@ -6,7 +6,9 @@ package pbsubscribe
 import (
 	context "context"
 	fmt "fmt"
+	types "github.com/gogo/protobuf/types"
 	proto "github.com/golang/protobuf/proto"
+	pbcommon "github.com/hashicorp/consul/proto/pbcommon"
 	pbservice "github.com/hashicorp/consul/proto/pbservice"
 	grpc "google.golang.org/grpc"
 	codes "google.golang.org/grpc/codes"
@@ -37,18 +39,51 @@ const (
 	// ServiceHealthConnect topic contains events for any changes to service
 	// health for connect-enabled services.
 	Topic_ServiceHealthConnect Topic = 2
+	// KV topic contains events for any changes to the KV store. The Key of
+	// the SubscribeRequest is used as a prefix, and events are sent for every
+	// entry with a key that has the prefix.
+	Topic_KV Topic = 3
+	// CatalogServices topic contains events for any changes to the service
+	// instances registered in the catalog. It is used to list the services
+	// in the catalog.
+	Topic_CatalogServices Topic = 4
+	// Intentions topic contains events for any changes to intentions. The Key
+	// of the SubscribeRequest is the name of a destination service, and events
+	// are sent for every intention with that destination, or with a wildcard
+	// destination. An empty Key sends events for every intention.
+	Topic_Intentions Topic = 5
+	// ConfigEntries topic contains events for any changes to config entries.
+	// The Key of the SubscribeRequest is the kind of the config entries, and
+	// events are sent for every entry of that kind. An empty Key sends events
+	// for config entries of every kind.
+	Topic_ConfigEntries Topic = 6
+	// Node topic contains events for any changes to a node, and to the
+	// services and checks registered on the node. The Key of the
+	// SubscribeRequest is the name of the node. An empty Key sends events for
+	// every node.
+	Topic_Node Topic = 7
 )
 
 var Topic_name = map[int32]string{
 	0: "Unknown",
 	1: "ServiceHealth",
 	2: "ServiceHealthConnect",
+	3: "KV",
+	4: "CatalogServices",
+	5: "Intentions",
+	6: "ConfigEntries",
+	7: "Node",
 }
 
 var Topic_value = map[string]int32{
 	"Unknown":              0,
 	"ServiceHealth":        1,
 	"ServiceHealthConnect": 2,
+	"KV":                   3,
+	"CatalogServices":      4,
+	"Intentions":           5,
+	"ConfigEntries":        6,
+	"Node":                 7,
 }
 
 func (x Topic) String() string {
@@ -84,6 +119,34 @@ func (CatalogOp) EnumDescriptor() ([]byte, []int) {
 	return fileDescriptor_ab3eb8c810e315fb, []int{1}
 }
 
+// UpdateOp is the operation of an update to an entry of the KV store, an
+// intention, or a config entry. An Upsert creates or replaces the entry, and
+// a Delete removes it.
+type UpdateOp int32
+
+const (
+	UpdateOp_Upsert UpdateOp = 0
+	UpdateOp_Delete UpdateOp = 1
+)
+
+var UpdateOp_name = map[int32]string{
+	0: "Upsert",
+	1: "Delete",
+}
+
+var UpdateOp_value = map[string]int32{
+	"Upsert": 0,
+	"Delete": 1,
+}
+
+func (x UpdateOp) String() string {
+	return proto.EnumName(UpdateOp_name, int32(x))
+}
+
+func (UpdateOp) EnumDescriptor() ([]byte, []int) {
+	return fileDescriptor_ab3eb8c810e315fb, []int{2}
+}
+
 // SubscribeRequest used to subscribe to a topic.
 type SubscribeRequest struct {
 	// Topic identifies the set of events the subscriber is interested in.
@@ -118,7 +181,37 @@ type SubscribeRequest struct {
 	// default partition will be used.
 	//
 	// Partition is an enterprise-only feature.
-	Partition            string   `protobuf:"bytes,7,opt,name=Partition,proto3" json:"Partition,omitempty"`
+	Partition string `protobuf:"bytes,7,opt,name=Partition,proto3" json:"Partition,omitempty"`
+	// Filter is a go-bexpr expression used to filter the events sent to the
+	// subscriber. Only the ServiceHealth and ServiceHealthConnect topics support
+	// a Filter, the expression is evaluated against a CheckServiceNode. An
+	// empty Filter sends all events.
+	//
+	// Once the snapshot is complete, an update which no longer matches the
+	// Filter is sent as a Deregister so that the subscriber can remove it from
+	// its view. Servers which do not support a Filter ignore it, so subscribers
+	// must continue to filter the events they receive.
+	Filter string `protobuf:"bytes,8,opt,name=Filter,proto3" json:"Filter,omitempty"`
+	// HeartbeatIntervalMillis, when set, requests a Heartbeat event whenever
+	// no other event was sent to the subscriber for that many milliseconds, so
+	// that the subscriber can detect a stream which stopped receiving events.
+	// Servers raise intervals below their minimum to the minimum. Servers which
+	// do not support heartbeats ignore it, so subscribers must not expect a
+	// Heartbeat until they received one.
+	HeartbeatIntervalMillis uint64 `protobuf:"varint,9,opt,name=HeartbeatIntervalMillis,proto3" json:"HeartbeatIntervalMillis,omitempty"`
+	// ViewHash is the hash of the view the subscriber materialized at Index,
+	// computed with pbsubscribe.ViewHash. When the stream can not be resumed
+	// from Index, the server compares ViewHash to the hash of the new snapshot
+	// it would send. If they match, the view is still correct, and the server
+	// sends a ResumeStream event in place of the NewSnapshotToFollow event and
+	// the snapshot. A ViewHash of 0 always receives the snapshot. Servers which
+	// do not support ViewHash ignore it.
+	ViewHash uint64 `protobuf:"varint,10,opt,name=ViewHash,proto3" json:"ViewHash,omitempty"`
+	// ExactKey, when set, only sends the events of the resource identified by
+	// exactly Key, for topics where Key is a prefix. Only the KV topic supports
+	// ExactKey. Servers which do not support ExactKey ignore it, so subscribers
+	// must continue to filter the events they receive.
+	ExactKey             bool     `protobuf:"varint,11,opt,name=ExactKey,proto3" json:"ExactKey,omitempty"`
 	XXX_NoUnkeyedLiteral struct{} `json:"-"`
 	XXX_unrecognized     []byte   `json:"-"`
 	XXX_sizecache        int32    `json:"-"`
@@ -206,6 +299,290 @@ func (m *SubscribeRequest) GetPartition() string {
 	return ""
 }
 
+func (m *SubscribeRequest) GetFilter() string {
+	if m != nil {
+		return m.Filter
+	}
+	return ""
+}
+
+func (m *SubscribeRequest) GetHeartbeatIntervalMillis() uint64 {
+	if m != nil {
+		return m.HeartbeatIntervalMillis
+	}
+	return 0
+}
+
+func (m *SubscribeRequest) GetViewHash() uint64 {
+	if m != nil {
+		return m.ViewHash
+	}
+	return 0
+}
+
+func (m *SubscribeRequest) GetExactKey() bool {
+	if m != nil {
+		return m.ExactKey
+	}
+	return false
+}
+
+// MultiplexedRequest starts or stops a subscription of a SubscribeMultiplexed
+// stream.
+type MultiplexedRequest struct {
+	// ID identifies the subscription within the stream. It must not be reused
+	// for another subscription of the same stream.
+	ID uint64 `protobuf:"varint,1,opt,name=ID,proto3" json:"ID,omitempty"`
+	// Types that are valid to be assigned to Op:
+	//	*MultiplexedRequest_Subscribe
+	//	*MultiplexedRequest_Unsubscribe
+	Op                   isMultiplexedRequest_Op `protobuf_oneof:"Op"`
+	XXX_NoUnkeyedLiteral struct{}                `json:"-"`
+	XXX_unrecognized     []byte                  `json:"-"`
+	XXX_sizecache        int32                   `json:"-"`
+}
+
+func (m *MultiplexedRequest) Reset()         { *m = MultiplexedRequest{} }
+func (m *MultiplexedRequest) String() string { return proto.CompactTextString(m) }
+func (*MultiplexedRequest) ProtoMessage()    {}
+func (*MultiplexedRequest) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ab3eb8c810e315fb, []int{1}
+}
+func (m *MultiplexedRequest) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *MultiplexedRequest) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_MultiplexedRequest.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *MultiplexedRequest) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MultiplexedRequest.Merge(m, src)
+}
+func (m *MultiplexedRequest) XXX_Size() int {
+	return m.Size()
+}
+func (m *MultiplexedRequest) XXX_DiscardUnknown() {
+	xxx_messageInfo_MultiplexedRequest.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MultiplexedRequest proto.InternalMessageInfo
+
+type isMultiplexedRequest_Op interface {
+	isMultiplexedRequest_Op()
+	MarshalTo([]byte) (int, error)
+	Size() int
+}
+
+type MultiplexedRequest_Subscribe struct {
+	Subscribe *SubscribeRequest `protobuf:"bytes,2,opt,name=Subscribe,proto3,oneof" json:"Subscribe,omitempty"`
+}
+type MultiplexedRequest_Unsubscribe struct {
+	Unsubscribe bool `protobuf:"varint,3,opt,name=Unsubscribe,proto3,oneof" json:"Unsubscribe,omitempty"`
+}
+
+func (*MultiplexedRequest_Subscribe) isMultiplexedRequest_Op()   {}
+func (*MultiplexedRequest_Unsubscribe) isMultiplexedRequest_Op() {}
+
+func (m *MultiplexedRequest) GetOp() isMultiplexedRequest_Op {
+	if m != nil {
+		return m.Op
+	}
+	return nil
+}
+
+func (m *MultiplexedRequest) GetID() uint64 {
+	if m != nil {
+		return m.ID
+	}
+	return 0
+}
+
+func (m *MultiplexedRequest) GetSubscribe() *SubscribeRequest {
+	if x, ok := m.GetOp().(*MultiplexedRequest_Subscribe); ok {
+		return x.Subscribe
+	}
+	return nil
+}
+
+func (m *MultiplexedRequest) GetUnsubscribe() bool {
+	if x, ok := m.GetOp().(*MultiplexedRequest_Unsubscribe); ok {
+		return x.Unsubscribe
+	}
+	return false
+}
+
+// XXX_OneofWrappers is for the internal use of the proto package.
+func (*MultiplexedRequest) XXX_OneofWrappers() []interface{} {
+	return []interface{}{
+		(*MultiplexedRequest_Subscribe)(nil),
+		(*MultiplexedRequest_Unsubscribe)(nil),
+	}
+}
+
+// MultiplexedEvent is an event of a subscription of a SubscribeMultiplexed
+// stream, or the error that ended the subscription.
+type MultiplexedEvent struct {
+	// ID of the subscription, from the MultiplexedRequest which started it.
+	ID uint64 `protobuf:"varint,1,opt,name=ID,proto3" json:"ID,omitempty"`
+	// Types that are valid to be assigned to Payload:
+	//	*MultiplexedEvent_Event
+	//	*MultiplexedEvent_Error
+	Payload              isMultiplexedEvent_Payload `protobuf_oneof:"Payload"`
+	XXX_NoUnkeyedLiteral struct{}                   `json:"-"`
+	XXX_unrecognized     []byte                     `json:"-"`
+	XXX_sizecache        int32                      `json:"-"`
+}
+
+func (m *MultiplexedEvent) Reset()         { *m = MultiplexedEvent{} }
+func (m *MultiplexedEvent) String() string { return proto.CompactTextString(m) }
+func (*MultiplexedEvent) ProtoMessage()    {}
+func (*MultiplexedEvent) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ab3eb8c810e315fb, []int{2}
+}
+func (m *MultiplexedEvent) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *MultiplexedEvent) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_MultiplexedEvent.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *MultiplexedEvent) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_MultiplexedEvent.Merge(m, src)
+}
+func (m *MultiplexedEvent) XXX_Size() int {
+	return m.Size()
+}
+func (m *MultiplexedEvent) XXX_DiscardUnknown() {
+	xxx_messageInfo_MultiplexedEvent.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_MultiplexedEvent proto.InternalMessageInfo
+
+type isMultiplexedEvent_Payload interface {
+	isMultiplexedEvent_Payload()
+	MarshalTo([]byte) (int, error)
+	Size() int
+}
+
+type MultiplexedEvent_Event struct {
+	Event *Event `protobuf:"bytes,2,opt,name=Event,proto3,oneof" json:"Event,omitempty"`
+}
+type MultiplexedEvent_Error struct {
+	Error *SubscriptionError `protobuf:"bytes,3,opt,name=Error,proto3,oneof" json:"Error,omitempty"`
+}
+
+func (*MultiplexedEvent_Event) isMultiplexedEvent_Payload() {}
+func (*MultiplexedEvent_Error) isMultiplexedEvent_Payload() {}
+
+func (m *MultiplexedEvent) GetPayload() isMultiplexedEvent_Payload {
+	if m != nil {
+		return m.Payload
+	}
+	return nil
+}
+
+func (m *MultiplexedEvent) GetID() uint64 {
+	if m != nil {
+		return m.ID
+	}
+	return 0
+}
+
+func (m *MultiplexedEvent) GetEvent() *Event {
+	if x, ok := m.GetPayload().(*MultiplexedEvent_Event); ok {
+		return x.Event
+	}
+	return nil
+}
+
+func (m *MultiplexedEvent) GetError() *SubscriptionError {
+	if x, ok := m.GetPayload().(*MultiplexedEvent_Error); ok {
+		return x.Error
+	}
+	return nil
+}
+
+// XXX_OneofWrappers is for the internal use of the proto package.
+func (*MultiplexedEvent) XXX_OneofWrappers() []interface{} {
+	return []interface{}{
+		(*MultiplexedEvent_Event)(nil),
+		(*MultiplexedEvent_Error)(nil),
+	}
+}
+
+// SubscriptionError is the gRPC status of a subscription which ended with an
+// error.
+type SubscriptionError struct {
+	Code                 uint32   `protobuf:"varint,1,opt,name=Code,proto3" json:"Code,omitempty"`
+	Message              string   `protobuf:"bytes,2,opt,name=Message,proto3" json:"Message,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *SubscriptionError) Reset()         { *m = SubscriptionError{} }
+func (m *SubscriptionError) String() string { return proto.CompactTextString(m) }
+func (*SubscriptionError) ProtoMessage()    {}
+func (*SubscriptionError) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ab3eb8c810e315fb, []int{3}
+}
+func (m *SubscriptionError) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *SubscriptionError) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_SubscriptionError.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *SubscriptionError) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_SubscriptionError.Merge(m, src)
+}
+func (m *SubscriptionError) XXX_Size() int {
+	return m.Size()
+}
+func (m *SubscriptionError) XXX_DiscardUnknown() {
+	xxx_messageInfo_SubscriptionError.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_SubscriptionError proto.InternalMessageInfo
+
+func (m *SubscriptionError) GetCode() uint32 {
+	if m != nil {
+		return m.Code
+	}
+	return 0
+}
+
+func (m *SubscriptionError) GetMessage() string {
+	if m != nil {
+		return m.Message
+	}
+	return ""
+}
+
 // Event describes a streaming update on a subscription. Events are used both to
 // describe the current "snapshot" of the result as well as ongoing mutations to
 // that snapshot.
@@ -222,7 +599,14 @@ type Event struct {
 	//	*Event_EndOfSnapshot
 	//	*Event_NewSnapshotToFollow
 	//	*Event_EventBatch
+	//	*Event_Heartbeat
+	//	*Event_ResumeStream
 	//	*Event_ServiceHealth
+	//	*Event_KV
+	//	*Event_CatalogService
+	//	*Event_Intention
+	//	*Event_ConfigEntry
+	//	*Event_Node
 	Payload              isEvent_Payload `protobuf_oneof:"Payload"`
 	XXX_NoUnkeyedLiteral struct{}        `json:"-"`
 	XXX_unrecognized     []byte          `json:"-"`
@@ -233,7 +617,7 @@ func (m *Event) Reset()         { *m = Event{} }
 func (m *Event) String() string { return proto.CompactTextString(m) }
 func (*Event) ProtoMessage()    {}
 func (*Event) Descriptor() ([]byte, []int) {
-	return fileDescriptor_ab3eb8c810e315fb, []int{1}
+	return fileDescriptor_ab3eb8c810e315fb, []int{4}
 }
 func (m *Event) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -277,14 +661,42 @@ type Event_NewSnapshotToFollow struct {
 type Event_EventBatch struct {
 	EventBatch *EventBatch `protobuf:"bytes,4,opt,name=EventBatch,proto3,oneof" json:"EventBatch,omitempty"`
 }
+type Event_Heartbeat struct {
+	Heartbeat bool `protobuf:"varint,5,opt,name=Heartbeat,proto3,oneof" json:"Heartbeat,omitempty"`
+}
+type Event_ResumeStream struct {
+	ResumeStream bool `protobuf:"varint,6,opt,name=ResumeStream,proto3,oneof" json:"ResumeStream,omitempty"`
+}
 type Event_ServiceHealth struct {
 	ServiceHealth *ServiceHealthUpdate `protobuf:"bytes,10,opt,name=ServiceHealth,proto3,oneof" json:"ServiceHealth,omitempty"`
 }
+type Event_KV struct {
+	KV *KVUpdate `protobuf:"bytes,11,opt,name=KV,proto3,oneof" json:"KV,omitempty"`
+}
+type Event_CatalogService struct {
+	CatalogService *CatalogServiceUpdate `protobuf:"bytes,12,opt,name=CatalogService,proto3,oneof" json:"CatalogService,omitempty"`
+}
+type Event_Intention struct {
+	Intention *IntentionUpdate `protobuf:"bytes,13,opt,name=Intention,proto3,oneof" json:"Intention,omitempty"`
+}
+type Event_ConfigEntry struct {
+	ConfigEntry *ConfigEntryUpdate `protobuf:"bytes,14,opt,name=ConfigEntry,proto3,oneof" json:"ConfigEntry,omitempty"`
+}
+type Event_Node struct {
+	Node *NodeUpdate `protobuf:"bytes,15,opt,name=Node,proto3,oneof" json:"Node,omitempty"`
+}
 
 func (*Event_EndOfSnapshot) isEvent_Payload()       {}
 func (*Event_NewSnapshotToFollow) isEvent_Payload() {}
 func (*Event_EventBatch) isEvent_Payload()          {}
+func (*Event_Heartbeat) isEvent_Payload()           {}
+func (*Event_ResumeStream) isEvent_Payload()        {}
 func (*Event_ServiceHealth) isEvent_Payload()       {}
+func (*Event_KV) isEvent_Payload()                  {}
+func (*Event_CatalogService) isEvent_Payload()      {}
+func (*Event_Intention) isEvent_Payload()           {}
+func (*Event_ConfigEntry) isEvent_Payload()         {}
+func (*Event_Node) isEvent_Payload()                {}
 
 func (m *Event) GetPayload() isEvent_Payload {
 	if m != nil {
@@ -321,6 +733,20 @@ func (m *Event) GetEventBatch() *EventBatch {
 	return nil
 }
 
+func (m *Event) GetHeartbeat() bool {
+	if x, ok := m.GetPayload().(*Event_Heartbeat); ok {
+		return x.Heartbeat
+	}
+	return false
+}
+
+func (m *Event) GetResumeStream() bool {
+	if x, ok := m.GetPayload().(*Event_ResumeStream); ok {
+		return x.ResumeStream
+	}
+	return false
+}
+
 func (m *Event) GetServiceHealth() *ServiceHealthUpdate {
 	if x, ok := m.GetPayload().(*Event_ServiceHealth); ok {
 		return x.ServiceHealth
@@ -328,13 +754,55 @@ func (m *Event) GetServiceHealth() *ServiceHealthUpdate {
 	return nil
 }
 
+func (m *Event) GetKV() *KVUpdate {
+	if x, ok := m.GetPayload().(*Event_KV); ok {
+		return x.KV
+	}
+	return nil
+}
+
+func (m *Event) GetCatalogService() *CatalogServiceUpdate {
+	if x, ok := m.GetPayload().(*Event_CatalogService); ok {
+		return x.CatalogService
+	}
+	return nil
+}
+
+func (m *Event) GetIntention() *IntentionUpdate {
+	if x, ok := m.GetPayload().(*Event_Intention); ok {
+		return x.Intention
+	}
+	return nil
+}
+
+func (m *Event) GetConfigEntry() *ConfigEntryUpdate {
+	if x, ok := m.GetPayload().(*Event_ConfigEntry); ok {
+		return x.ConfigEntry
+	}
+	return nil
+}
+
+func (m *Event) GetNode() *NodeUpdate {
+	if x, ok := m.GetPayload().(*Event_Node); ok {
+		return x.Node
+	}
+	return nil
+}
+
 // XXX_OneofWrappers is for the internal use of the proto package.
 func (*Event) XXX_OneofWrappers() []interface{} {
 	return []interface{}{
 		(*Event_EndOfSnapshot)(nil),
 		(*Event_NewSnapshotToFollow)(nil),
 		(*Event_EventBatch)(nil),
+		(*Event_Heartbeat)(nil),
+		(*Event_ResumeStream)(nil),
 		(*Event_ServiceHealth)(nil),
+		(*Event_KV)(nil),
+		(*Event_CatalogService)(nil),
+		(*Event_Intention)(nil),
+		(*Event_ConfigEntry)(nil),
+		(*Event_Node)(nil),
 	}
 }
 
@@ -349,7 +817,7 @@ func (m *EventBatch) Reset()         { *m = EventBatch{} }
 func (m *EventBatch) String() string { return proto.CompactTextString(m) }
 func (*EventBatch) ProtoMessage()    {}
 func (*EventBatch) Descriptor() ([]byte, []int) {
-	return fileDescriptor_ab3eb8c810e315fb, []int{2}
+	return fileDescriptor_ab3eb8c810e315fb, []int{5}
 }
 func (m *EventBatch) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -397,7 +865,7 @@ func (m *ServiceHealthUpdate) Reset()         { *m = ServiceHealthUpdate{} }
 func (m *ServiceHealthUpdate) String() string { return proto.CompactTextString(m) }
 func (*ServiceHealthUpdate) ProtoMessage()    {}
 func (*ServiceHealthUpdate) Descriptor() ([]byte, []int) {
-	return fileDescriptor_ab3eb8c810e315fb, []int{3}
+	return fileDescriptor_ab3eb8c810e315fb, []int{6}
 }
 func (m *ServiceHealthUpdate) XXX_Unmarshal(b []byte) error {
 	return m.Unmarshal(b)
@@ -440,628 +908,6497 @@ func (m *ServiceHealthUpdate) GetCheckServiceNode() *pbservice.CheckServiceNode
 	return nil
 }
 
-func init() {
-	proto.RegisterEnum("subscribe.Topic", Topic_name, Topic_value)
-	proto.RegisterEnum("subscribe.CatalogOp", CatalogOp_name, CatalogOp_value)
-	proto.RegisterType((*SubscribeRequest)(nil), "subscribe.SubscribeRequest")
-	proto.RegisterType((*Event)(nil), "subscribe.Event")
-	proto.RegisterType((*EventBatch)(nil), "subscribe.EventBatch")
-	proto.RegisterType((*ServiceHealthUpdate)(nil), "subscribe.ServiceHealthUpdate")
+// CatalogServiceUpdate describes a change to a service instance. Only the
+// fields required to list the services in the catalog are included.
+type CatalogServiceUpdate struct {
+	Op                   CatalogOp                `protobuf:"varint,1,opt,name=Op,proto3,enum=subscribe.CatalogOp" json:"Op,omitempty"`
+	Node                 string                   `protobuf:"bytes,2,opt,name=Node,proto3" json:"Node,omitempty"`
+	ServiceID            string                   `protobuf:"bytes,3,opt,name=ServiceID,proto3" json:"ServiceID,omitempty"`
+	ServiceName          string                   `protobuf:"bytes,4,opt,name=ServiceName,proto3" json:"ServiceName,omitempty"`
+	ServiceTags          []string                 `protobuf:"bytes,5,rep,name=ServiceTags,proto3" json:"ServiceTags,omitempty"`
+	EnterpriseMeta       *pbcommon.EnterpriseMeta `protobuf:"bytes,6,opt,name=EnterpriseMeta,proto3" json:"EnterpriseMeta,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                 `json:"-"`
+	XXX_unrecognized     []byte                   `json:"-"`
+	XXX_sizecache        int32                    `json:"-"`
 }
 
-func init() { proto.RegisterFile("proto/pbsubscribe/subscribe.proto", fileDescriptor_ab3eb8c810e315fb) }
-
-var fileDescriptor_ab3eb8c810e315fb = []byte{
-	// 550 bytes of a gzipped FileDescriptorProto
-	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x6c, 0x53, 0xcb, 0x6e, 0xd3, 0x4c,
-	0x14, 0xf6, 0xa4, 0x57, 0x9f, 0xfc, 0xad, 0xfc, 0x4f, 0x83, 0xb0, 0x52, 0x64, 0x85, 0x08, 0x55,
-	0xa1, 0x12, 0x31, 0x0a, 0x12, 0xec, 0x40, 0x24, 0x6d, 0x09, 0x42, 0x4a, 0x2a, 0xa7, 0x5d, 0xc0,
-	0x6e, 0x62, 0x1f, 0x62, 0x2b, 0xee, 0x8c, 0xb1, 0x27, 0x0d, 0xdd, 0xc3, 0x3b, 0xf0, 0x48, 0x2c,
-	0x59, 0xf0, 0x00, 0x28, 0xbc, 0x08, 0xf2, 0xc4, 0x71, 0x9c, 0xa4, 0xbb, 0x39, 0xdf, 0x65, 0xce,
-	0xcc, 0xb9, 0xc0, 0xe3, 0x28, 0x16, 0x52, 0xd8, 0xd1, 0x30, 0x99, 0x0c, 0x13, 0x37, 0x0e, 0x86,
-	0x68, 0xe7, 0xa7, 0xa6, 0xe2, 0xa8, 0x9e, 0x03, 0xd5, 0x6a, 0xae, 0xc6, 0xf8, 0x36, 0x70, 0xd1,
-	0xe6, 0xc2, 0xcb, 0x64, 0xf5, 0xdf, 0x04, 0x8c, 0xc1, 0x42, 0xe9, 0xe0, 0x97, 0x09, 0x26, 0x92,
-	0x9e, 0xc0, 0xce, 0x95, 0x88, 0x02, 0xd7, 0x24, 0x35, 0xd2, 0x38, 0x6c, 0x19, 0xcd, 0xe5, 0xe5,
-	0x0a, 0x77, 0xe6, 0x34, 0x35, 0x60, 0xeb, 0x03, 0xde, 0x99, 0xa5, 0x1a, 0x69, 0xe8, 0x4e, 0x7a,
-	0xa4, 0x95, 0xd4, 0x39, 0x46, 0x6e, 0x6e, 0x29, 0x6c, 0x1e, 0xa4, 0xe8, 0x7b, 0xee, 0xe1, 0x57,
-	0x73, 0xbb, 0x46, 0x1a, 0xdb, 0xce, 0x3c, 0xa0, 0x16, 0xc0, 0x19, 0x93, 0xcc, 0x45, 0x2e, 0x31,
-	0x36, 0x77, 0x94, 0xa1, 0x80, 0xd0, 0x47, 0xa0, 0xf7, 0xd8, 0x0d, 0x26, 0x11, 0x73, 0xd1, 0xdc,
-	0x55, 0xf4, 0x12, 0x48, 0xd9, 0x4b, 0x16, 0xcb, 0x40, 0x06, 0x82, 0x9b, 0x7b, 0x73, 0x36, 0x07,
-	0xea, 0xdf, 0x4b, 0xb0, 0x73, 0x7e, 0x8b, 0x5c, 0x2e, 0x73, 0x93, 0x62, 0xee, 0x13, 0x38, 0x38,
-	0xe7, 0x5e, 0xff, 0xf3, 0x80, 0xb3, 0x28, 0xf1, 0x85, 0x54, 0x7f, 0xd8, 0xef, 0x6a, 0xce, 0x2a,
-	0x4c, 0x5b, 0x70, 0xd4, 0xc3, 0xe9, 0x22, 0xbc, 0x12, 0x17, 0x22, 0x0c, 0xc5, 0x54, 0xfd, 0x2e,
-	0x55, 0xdf, 0x47, 0xd2, 0x57, 0x00, 0x2a, 0x75, 0x9b, 0x49, 0xd7, 0x57, 0x5f, 0x2e, 0xb7, 0x1e,
-	0x14, 0x4a, 0xb8, 0x24, 0xbb, 0x9a, 0x53, 0x90, 0xd2, 0x0b, 0x38, 0x18, 0xcc, 0x3b, 0xd4, 0x45,
-	0x16, 0x4a, 0xdf, 0x04, 0xe5, 0xb5, 0x0a, 0xde, 0x15, 0xfe, 0x3a, 0xf2, 0x98, 0xc4, 0xf4, 0xd1,
-	0x2b, 0x70, 0x5b, 0x87, 0xbd, 0x4b, 0x76, 0x17, 0x0a, 0xe6, 0xd5, 0x5f, 0x16, 0xdf, 0x42, 0x1b,
-	0xb0, 0xab, 0xa2, 0xc4, 0x24, 0xb5, 0xad, 0x46, 0x79, 0xa5, 0xb1, 0x8a, 0x70, 0x32, 0xbe, 0xfe,
-	0x8d, 0xc0, 0xd1, 0x3d, 0xb9, 0xe8, 0x13, 0x28, 0xf5, 0xa3, 0x6c, 0x2c, 0x2a, 0x05, 0x77, 0x87,
-	0x49, 0x16, 0x8a, 0x51, 0x3f, 0x72, 0x4a, 0xfd, 0x88, 0xbe, 0x03, 0xa3, 0xe3, 0xa3, 0x3b, 0xce,
-	0x6e, 0xe8, 0x09, 0x0f, 0x55, 0x81, 0xcb, 0xad, 0xe3, 0x66, 0x3e, 0x85, 0xcd, 0x75, 0x89, 0xb3,
-	0x61, 0x3a, 0x7d, 0x9b, 0x0d, 0x22, 0x2d, 0xc3, 0xde, 0x35, 0x1f, 0x73, 0x31, 0xe5, 0x86, 0x46,
-	0xff, 0x5f, 0xab, 0x93, 0x41, 0xa8, 0x09, 0x95, 0x15, 0xa8, 0x23, 0x38, 0x47, 0x57, 0x1a, 0xa5,
-	0xd3, 0xa7, 0xa0, 0xe7, 0x8f, 0xa3, 0xff, 0xc1, 0xbe, 0x83, 0xa3, 0x20, 0x91, 0x18, 0x1b, 0x1a,
-	0x3d, 0x04, 0x38, 0xc3, 0x78, 0x11, 0x93, 0xd6, 0x47, 0x78, 0x38, 0x90, 0x4c, 0x62, 0xc7, 0x67,
-	0x7c, 0x84, 0xd9, 0x56, 0x44, 0xe9, 0x3c, 0xd1, 0xd7, 0xa0, 0xe7, 0x5b, 0x42, 0x8f, 0x8b, 0x0d,
-	0x59, 0xdb, 0x9d, 0xea, 0x46, 0x4d, 0xeb, 0xda, 0x73, 0xd2, 0x7e, 0xf3, 0x73, 0x66, 0x91, 0x5f,
-	0x33, 0x8b, 0xfc, 0x99, 0x59, 0xe4, 0xc7, 0x5f, 0x4b, 0xfb, 0xf4, 0x6c, 0x14, 0x48, 0x7f, 0x32,
-	0x6c, 0xba, 0xe2, 0xc6, 0xf6, 0x59, 0xe2, 0x07, 0xae, 0x88, 0x23, 0xdb, 0x15, 0x3c, 0x99, 0x84,
-	0xf6, 0xc6, 0x7a, 0x0f, 0x77, 0x15, 0xf4, 0xe2, 0x5f, 0x00, 0x00, 0x00, 0xff, 0xff, 0xc9, 0xb6,
-	0x48, 0xa0, 0xfa, 0x03, 0x00, 0x00,
+func (m *CatalogServiceUpdate) Reset()         { *m = CatalogServiceUpdate{} }
+func (m *CatalogServiceUpdate) String() string { return proto.CompactTextString(m) }
+func (*CatalogServiceUpdate) ProtoMessage()    {}
+func (*CatalogServiceUpdate) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ab3eb8c810e315fb, []int{7}
+}
+func (m *CatalogServiceUpdate) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *CatalogServiceUpdate) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_CatalogServiceUpdate.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *CatalogServiceUpdate) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_CatalogServiceUpdate.Merge(m, src)
+}
+func (m *CatalogServiceUpdate) XXX_Size() int {
+	return m.Size()
+}
+func (m *CatalogServiceUpdate) XXX_DiscardUnknown() {
+	xxx_messageInfo_CatalogServiceUpdate.DiscardUnknown(m)
 }
 
-// Reference imports to suppress errors if they are not otherwise used.
-var _ context.Context
-var _ grpc.ClientConn
+var xxx_messageInfo_CatalogServiceUpdate proto.InternalMessageInfo
 
-// This is a compile-time assertion to ensure that this generated file
-// is compatible with the grpc package it is being compiled against.
-const _ = grpc.SupportPackageIsVersion4
+func (m *CatalogServiceUpdate) GetOp() CatalogOp {
+	if m != nil {
+		return m.Op
+	}
+	return CatalogOp_Register
+}
 
-// StateChangeSubscriptionClient is the client API for StateChangeSubscription service.
-//
-// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
-type StateChangeSubscriptionClient interface {
-	// Subscribe to a topic to receive events when there are changes to the topic.
-	//
-	// If SubscribeRequest.Index is 0 the event stream will start with one or
-	// more snapshot events, followed by an EndOfSnapshot event. Subsequent
-	// events will be a live stream of events as they happen.
-	//
-	// If SubscribeRequest.Index is > 0 it is assumed the client already has a
-	// snapshot, and is trying to resume a stream that was disconnected. The
-	// client will either receive a NewSnapshotToFollow event, indicating the
-	// client view is stale and it must reset its view and prepare for a new
-	// snapshot. Or, if no NewSnapshotToFollow event is received, the client
-	// view is still fresh, and all events will be the live stream.
-	//
-	// Subscribe may return a gRPC status error with codes.ABORTED to indicate
-	// the client view is now stale due to a change on the server. The client
-	// must reset its view and issue a new Subscribe call to restart the stream.
-	// This error is used when the server can no longer correctly maintain the
-	// stream, for example because the ACL permissions for the token changed, or
-	// because the server state was restored from a snapshot.
-	Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (StateChangeSubscription_SubscribeClient, error)
+func (m *CatalogServiceUpdate) GetNode() string {
+	if m != nil {
+		return m.Node
+	}
+	return ""
 }
 
-type stateChangeSubscriptionClient struct {
-	cc *grpc.ClientConn
+func (m *CatalogServiceUpdate) GetServiceID() string {
+	if m != nil {
+		return m.ServiceID
+	}
+	return ""
 }
 
-func NewStateChangeSubscriptionClient(cc *grpc.ClientConn) StateChangeSubscriptionClient {
-	return &stateChangeSubscriptionClient{cc}
+func (m *CatalogServiceUpdate) GetServiceName() string {
+	if m != nil {
+		return m.ServiceName
+	}
+	return ""
 }
 
-func (c *stateChangeSubscriptionClient) Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (StateChangeSubscription_SubscribeClient, error) {
-	stream, err := c.cc.NewStream(ctx, &_StateChangeSubscription_serviceDesc.Streams[0], "/subscribe.StateChangeSubscription/Subscribe", opts...)
-	if err != nil {
-		return nil, err
+func (m *CatalogServiceUpdate) GetServiceTags() []string {
+	if m != nil {
+		return m.ServiceTags
 	}
-	x := &stateChangeSubscriptionSubscribeClient{stream}
-	if err := x.ClientStream.SendMsg(in); err != nil {
-		return nil, err
+	return nil
+}
+
+func (m *CatalogServiceUpdate) GetEnterpriseMeta() *pbcommon.EnterpriseMeta {
+	if m != nil {
+		return m.EnterpriseMeta
 	}
-	if err := x.ClientStream.CloseSend(); err != nil {
-		return nil, err
+	return nil
+}
+
+// NodeUpdate describes a change to a node, or to a service or a check
+// registered on the node. Exactly one of Node, Service, or Check is set. A
+// Deregister of the Node removes the node along with its services and checks.
+type NodeUpdate struct {
+	Op CatalogOp `protobuf:"varint,1,opt,name=Op,proto3,enum=subscribe.CatalogOp" json:"Op,omitempty"`
+	// NodeName is the name of the node the update applies to.
+	NodeName             string                 `protobuf:"bytes,2,opt,name=NodeName,proto3" json:"NodeName,omitempty"`
+	Node                 *pbservice.Node        `protobuf:"bytes,3,opt,name=Node,proto3" json:"Node,omitempty"`
+	Service              *pbservice.NodeService `protobuf:"bytes,4,opt,name=Service,proto3" json:"Service,omitempty"`
+	Check                *pbservice.HealthCheck `protobuf:"bytes,5,opt,name=Check,proto3" json:"Check,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}               `json:"-"`
+	XXX_unrecognized     []byte                 `json:"-"`
+	XXX_sizecache        int32                  `json:"-"`
+}
+
+func (m *NodeUpdate) Reset()         { *m = NodeUpdate{} }
+func (m *NodeUpdate) String() string { return proto.CompactTextString(m) }
+func (*NodeUpdate) ProtoMessage()    {}
+func (*NodeUpdate) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ab3eb8c810e315fb, []int{8}
+}
+func (m *NodeUpdate) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *NodeUpdate) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_NodeUpdate.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
 	}
-	return x, nil
+}
+func (m *NodeUpdate) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_NodeUpdate.Merge(m, src)
+}
+func (m *NodeUpdate) XXX_Size() int {
+	return m.Size()
+}
+func (m *NodeUpdate) XXX_DiscardUnknown() {
+	xxx_messageInfo_NodeUpdate.DiscardUnknown(m)
 }
 
-type StateChangeSubscription_SubscribeClient interface {
-	Recv() (*Event, error)
-	grpc.ClientStream
+var xxx_messageInfo_NodeUpdate proto.InternalMessageInfo
+
+func (m *NodeUpdate) GetOp() CatalogOp {
+	if m != nil {
+		return m.Op
+	}
+	return CatalogOp_Register
 }
 
-type stateChangeSubscriptionSubscribeClient struct {
-	grpc.ClientStream
+func (m *NodeUpdate) GetNodeName() string {
+	if m != nil {
+		return m.NodeName
+	}
+	return ""
 }
 
-func (x *stateChangeSubscriptionSubscribeClient) Recv() (*Event, error) {
-	m := new(Event)
-	if err := x.ClientStream.RecvMsg(m); err != nil {
-		return nil, err
+func (m *NodeUpdate) GetNode() *pbservice.Node {
+	if m != nil {
+		return m.Node
 	}
-	return m, nil
+	return nil
 }
 
-// StateChangeSubscriptionServer is the server API for StateChangeSubscription service.
-type StateChangeSubscriptionServer interface {
-	// Subscribe to a topic to receive events when there are changes to the topic.
-	//
-	// If SubscribeRequest.Index is 0 the event stream will start with one or
-	// more snapshot events, followed by an EndOfSnapshot event. Subsequent
-	// events will be a live stream of events as they happen.
-	//
-	// If SubscribeRequest.Index is > 0 it is assumed the client already has a
-	// snapshot, and is trying to resume a stream that was disconnected. The
-	// client will either receive a NewSnapshotToFollow event, indicating the
-	// client view is stale and it must reset its view and prepare for a new
-	// snapshot. Or, if no NewSnapshotToFollow event is received, the client
-	// view is still fresh, and all events will be the live stream.
-	//
-	// Subscribe may return a gRPC status error with codes.ABORTED to indicate
-	// the client view is now stale due to a change on the server. The client
-	// must reset its view and issue a new Subscribe call to restart the stream.
-	// This error is used when the server can no longer correctly maintain the
-	// stream, for example because the ACL permissions for the token changed, or
-	// because the server state was restored from a snapshot.
-	Subscribe(*SubscribeRequest, StateChangeSubscription_SubscribeServer) error
+func (m *NodeUpdate) GetService() *pbservice.NodeService {
+	if m != nil {
+		return m.Service
+	}
+	return nil
 }
 
-// UnimplementedStateChangeSubscriptionServer can be embedded to have forward compatible implementations.
-type UnimplementedStateChangeSubscriptionServer struct {
+func (m *NodeUpdate) GetCheck() *pbservice.HealthCheck {
+	if m != nil {
+		return m.Check
+	}
+	return nil
 }
 
-func (*UnimplementedStateChangeSubscriptionServer) Subscribe(req *SubscribeRequest, srv StateChangeSubscription_SubscribeServer) error {
-	return status.Errorf(codes.Unimplemented, "method Subscribe not implemented")
+type KVUpdate struct {
+	Op                   UpdateOp `protobuf:"varint,1,opt,name=Op,proto3,enum=subscribe.UpdateOp" json:"Op,omitempty"`
+	Entry                *KVEntry `protobuf:"bytes,2,opt,name=Entry,proto3" json:"Entry,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
 }
 
-func RegisterStateChangeSubscriptionServer(s *grpc.Server, srv StateChangeSubscriptionServer) {
-	s.RegisterService(&_StateChangeSubscription_serviceDesc, srv)
+func (m *KVUpdate) Reset()         { *m = KVUpdate{} }
+func (m *KVUpdate) String() string { return proto.CompactTextString(m) }
+func (*KVUpdate) ProtoMessage()    {}
+func (*KVUpdate) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ab3eb8c810e315fb, []int{9}
+}
+func (m *KVUpdate) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *KVUpdate) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_KVUpdate.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *KVUpdate) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_KVUpdate.Merge(m, src)
+}
+func (m *KVUpdate) XXX_Size() int {
+	return m.Size()
+}
+func (m *KVUpdate) XXX_DiscardUnknown() {
+	xxx_messageInfo_KVUpdate.DiscardUnknown(m)
 }
 
-func _StateChangeSubscription_Subscribe_Handler(srv interface{}, stream grpc.ServerStream) error {
-	m := new(SubscribeRequest)
-	if err := stream.RecvMsg(m); err != nil {
-		return err
+var xxx_messageInfo_KVUpdate proto.InternalMessageInfo
+
+func (m *KVUpdate) GetOp() UpdateOp {
+	if m != nil {
+		return m.Op
 	}
-	return srv.(StateChangeSubscriptionServer).Subscribe(m, &stateChangeSubscriptionSubscribeServer{stream})
+	return UpdateOp_Upsert
 }
 
-type StateChangeSubscription_SubscribeServer interface {
-	Send(*Event) error
-	grpc.ServerStream
+func (m *KVUpdate) GetEntry() *KVEntry {
+	if m != nil {
+		return m.Entry
+	}
+	return nil
 }
 
-type stateChangeSubscriptionSubscribeServer struct {
-	grpc.ServerStream
+// KVEntry is an entry in the KV store.
+type KVEntry struct {
+	Key                  string                   `protobuf:"bytes,1,opt,name=Key,proto3" json:"Key,omitempty"`
+	Value                []byte                   `protobuf:"bytes,2,opt,name=Value,proto3" json:"Value,omitempty"`
+	Flags                uint64                   `protobuf:"varint,3,opt,name=Flags,proto3" json:"Flags,omitempty"`
+	Session              string                   `protobuf:"bytes,4,opt,name=Session,proto3" json:"Session,omitempty"`
+	LockIndex            uint64                   `protobuf:"varint,5,opt,name=LockIndex,proto3" json:"LockIndex,omitempty"`
+	CreateIndex          uint64                   `protobuf:"varint,6,opt,name=CreateIndex,proto3" json:"CreateIndex,omitempty"`
+	ModifyIndex          uint64                   `protobuf:"varint,7,opt,name=ModifyIndex,proto3" json:"ModifyIndex,omitempty"`
+	EnterpriseMeta       *pbcommon.EnterpriseMeta `protobuf:"bytes,8,opt,name=EnterpriseMeta,proto3" json:"EnterpriseMeta,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                 `json:"-"`
+	XXX_unrecognized     []byte                   `json:"-"`
+	XXX_sizecache        int32                    `json:"-"`
 }
 
-func (x *stateChangeSubscriptionSubscribeServer) Send(m *Event) error {
-	return x.ServerStream.SendMsg(m)
+func (m *KVEntry) Reset()         { *m = KVEntry{} }
+func (m *KVEntry) String() string { return proto.CompactTextString(m) }
+func (*KVEntry) ProtoMessage()    {}
+func (*KVEntry) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ab3eb8c810e315fb, []int{10}
+}
+func (m *KVEntry) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *KVEntry) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_KVEntry.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *KVEntry) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_KVEntry.Merge(m, src)
+}
+func (m *KVEntry) XXX_Size() int {
+	return m.Size()
+}
+func (m *KVEntry) XXX_DiscardUnknown() {
+	xxx_messageInfo_KVEntry.DiscardUnknown(m)
 }
 
-var _StateChangeSubscription_serviceDesc = grpc.ServiceDesc{
-	ServiceName: "subscribe.StateChangeSubscription",
-	HandlerType: (*StateChangeSubscriptionServer)(nil),
-	Methods:     []grpc.MethodDesc{},
-	Streams: []grpc.StreamDesc{
-		{
-			StreamName:    "Subscribe",
-			Handler:       _StateChangeSubscription_Subscribe_Handler,
-			ServerStreams: true,
-		},
-	},
-	Metadata: "proto/pbsubscribe/subscribe.proto",
+var xxx_messageInfo_KVEntry proto.InternalMessageInfo
+
+func (m *KVEntry) GetKey() string {
+	if m != nil {
+		return m.Key
+	}
+	return ""
 }
 
-func (m *SubscribeRequest) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+func (m *KVEntry) GetValue() []byte {
+	if m != nil {
+		return m.Value
 	}
-	return dAtA[:n], nil
+	return nil
 }
 
-func (m *SubscribeRequest) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+func (m *KVEntry) GetFlags() uint64 {
+	if m != nil {
+		return m.Flags
+	}
+	return 0
 }
 
-func (m *SubscribeRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.XXX_unrecognized != nil {
-		i -= len(m.XXX_unrecognized)
-		copy(dAtA[i:], m.XXX_unrecognized)
+func (m *KVEntry) GetSession() string {
+	if m != nil {
+		return m.Session
 	}
-	if len(m.Partition) > 0 {
-		i -= len(m.Partition)
-		copy(dAtA[i:], m.Partition)
-		i = encodeVarintSubscribe(dAtA, i, uint64(len(m.Partition)))
-		i--
-		dAtA[i] = 0x3a
+	return ""
+}
+
+func (m *KVEntry) GetLockIndex() uint64 {
+	if m != nil {
+		return m.LockIndex
 	}
-	if len(m.Namespace) > 0 {
-		i -= len(m.Namespace)
-		copy(dAtA[i:], m.Namespace)
-		i = encodeVarintSubscribe(dAtA, i, uint64(len(m.Namespace)))
-		i--
-		dAtA[i] = 0x32
+	return 0
+}
+
+func (m *KVEntry) GetCreateIndex() uint64 {
+	if m != nil {
+		return m.CreateIndex
 	}
-	if len(m.Datacenter) > 0 {
-		i -= len(m.Datacenter)
-		copy(dAtA[i:], m.Datacenter)
-		i = encodeVarintSubscribe(dAtA, i, uint64(len(m.Datacenter)))
-		i--
-		dAtA[i] = 0x2a
+	return 0
+}
+
+func (m *KVEntry) GetModifyIndex() uint64 {
+	if m != nil {
+		return m.ModifyIndex
 	}
-	if m.Index != 0 {
-		i = encodeVarintSubscribe(dAtA, i, uint64(m.Index))
-		i--
-		dAtA[i] = 0x20
+	return 0
+}
+
+func (m *KVEntry) GetEnterpriseMeta() *pbcommon.EnterpriseMeta {
+	if m != nil {
+		return m.EnterpriseMeta
 	}
-	if len(m.Token) > 0 {
-		i -= len(m.Token)
-		copy(dAtA[i:], m.Token)
-		i = encodeVarintSubscribe(dAtA, i, uint64(len(m.Token)))
-		i--
-		dAtA[i] = 0x1a
-	}
-	if len(m.Key) > 0 {
-		i -= len(m.Key)
-		copy(dAtA[i:], m.Key)
-		i = encodeVarintSubscribe(dAtA, i, uint64(len(m.Key)))
-		i--
-		dAtA[i] = 0x12
-	}
-	if m.Topic != 0 {
-		i = encodeVarintSubscribe(dAtA, i, uint64(m.Topic))
-		i--
-		dAtA[i] = 0x8
-	}
-	return len(dAtA) - i, nil
+	return nil
 }
 
-func (m *Event) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
-	}
-	return dAtA[:n], nil
+type IntentionUpdate struct {
+	Op                   UpdateOp   `protobuf:"varint,1,opt,name=Op,proto3,enum=subscribe.UpdateOp" json:"Op,omitempty"`
+	Intention            *Intention `protobuf:"bytes,2,opt,name=Intention,proto3" json:"Intention,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}   `json:"-"`
+	XXX_unrecognized     []byte     `json:"-"`
+	XXX_sizecache        int32      `json:"-"`
 }
 
-func (m *Event) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+func (m *IntentionUpdate) Reset()         { *m = IntentionUpdate{} }
+func (m *IntentionUpdate) String() string { return proto.CompactTextString(m) }
+func (*IntentionUpdate) ProtoMessage()    {}
+func (*IntentionUpdate) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ab3eb8c810e315fb, []int{11}
 }
-
-func (m *Event) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.XXX_unrecognized != nil {
-		i -= len(m.XXX_unrecognized)
-		copy(dAtA[i:], m.XXX_unrecognized)
-	}
-	if m.Payload != nil {
-		{
-			size := m.Payload.Size()
-			i -= size
-			if _, err := m.Payload.MarshalTo(dAtA[i:]); err != nil {
-				return 0, err
-			}
+func (m *IntentionUpdate) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *IntentionUpdate) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_IntentionUpdate.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
 		}
+		return b[:n], nil
 	}
-	if m.Index != 0 {
-		i = encodeVarintSubscribe(dAtA, i, uint64(m.Index))
-		i--
-		dAtA[i] = 0x8
-	}
-	return len(dAtA) - i, nil
+}
+func (m *IntentionUpdate) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_IntentionUpdate.Merge(m, src)
+}
+func (m *IntentionUpdate) XXX_Size() int {
+	return m.Size()
+}
+func (m *IntentionUpdate) XXX_DiscardUnknown() {
+	xxx_messageInfo_IntentionUpdate.DiscardUnknown(m)
 }
 
-func (m *Event_EndOfSnapshot) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+var xxx_messageInfo_IntentionUpdate proto.InternalMessageInfo
+
+func (m *IntentionUpdate) GetOp() UpdateOp {
+	if m != nil {
+		return m.Op
+	}
+	return UpdateOp_Upsert
 }
 
-func (m *Event_EndOfSnapshot) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	i--
-	if m.EndOfSnapshot {
-		dAtA[i] = 1
-	} else {
-		dAtA[i] = 0
+func (m *IntentionUpdate) GetIntention() *Intention {
+	if m != nil {
+		return m.Intention
 	}
-	i--
-	dAtA[i] = 0x10
-	return len(dAtA) - i, nil
+	return nil
 }
-func (m *Event_NewSnapshotToFollow) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+
+// Intention is an intention from a source service to a destination service.
+// It mirrors the fields of structs.Intention.
+type Intention struct {
+	ID                   string                 `protobuf:"bytes,1,opt,name=ID,proto3" json:"ID,omitempty"`
+	Description          string                 `protobuf:"bytes,2,opt,name=Description,proto3" json:"Description,omitempty"`
+	SourcePartition      string                 `protobuf:"bytes,3,opt,name=SourcePartition,proto3" json:"SourcePartition,omitempty"`
+	SourceNS             string                 `protobuf:"bytes,4,opt,name=SourceNS,proto3" json:"SourceNS,omitempty"`
+	SourceName           string                 `protobuf:"bytes,5,opt,name=SourceName,proto3" json:"SourceName,omitempty"`
+	DestinationPartition string                 `protobuf:"bytes,6,opt,name=DestinationPartition,proto3" json:"DestinationPartition,omitempty"`
+	DestinationNS        string                 `protobuf:"bytes,7,opt,name=DestinationNS,proto3" json:"DestinationNS,omitempty"`
+	DestinationName      string                 `protobuf:"bytes,8,opt,name=DestinationName,proto3" json:"DestinationName,omitempty"`
+	SourceType           string                 `protobuf:"bytes,9,opt,name=SourceType,proto3" json:"SourceType,omitempty"`
+	Action               string                 `protobuf:"bytes,10,opt,name=Action,proto3" json:"Action,omitempty"`
+	Permissions          []*IntentionPermission `protobuf:"bytes,11,rep,name=Permissions,proto3" json:"Permissions,omitempty"`
+	DefaultAddr          string                 `protobuf:"bytes,12,opt,name=DefaultAddr,proto3" json:"DefaultAddr,omitempty"`
+	DefaultPort          int32                  `protobuf:"varint,13,opt,name=DefaultPort,proto3" json:"DefaultPort,omitempty"`
+	Meta                 map[string]string      `protobuf:"bytes,14,rep,name=Meta,proto3" json:"Meta,omitempty" protobuf_key:"bytes,1,opt,name=key,proto3" protobuf_val:"bytes,2,opt,name=value,proto3"`
+	Precedence           int32                  `protobuf:"varint,15,opt,name=Precedence,proto3" json:"Precedence,omitempty"`
+	CreatedAt            *types.Timestamp       `protobuf:"bytes,16,opt,name=CreatedAt,proto3" json:"CreatedAt,omitempty"`
+	UpdatedAt            *types.Timestamp       `protobuf:"bytes,17,opt,name=UpdatedAt,proto3" json:"UpdatedAt,omitempty"`
+	Hash                 []byte                 `protobuf:"bytes,18,opt,name=Hash,proto3" json:"Hash,omitempty"`
+	CreateIndex          uint64                 `protobuf:"varint,19,opt,name=CreateIndex,proto3" json:"CreateIndex,omitempty"`
+	ModifyIndex          uint64                 `protobuf:"varint,20,opt,name=ModifyIndex,proto3" json:"ModifyIndex,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}               `json:"-"`
+	XXX_unrecognized     []byte                 `json:"-"`
+	XXX_sizecache        int32                  `json:"-"`
 }
 
-func (m *Event_NewSnapshotToFollow) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	i--
-	if m.NewSnapshotToFollow {
-		dAtA[i] = 1
+func (m *Intention) Reset()         { *m = Intention{} }
+func (m *Intention) String() string { return proto.CompactTextString(m) }
+func (*Intention) ProtoMessage()    {}
+func (*Intention) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ab3eb8c810e315fb, []int{12}
+}
+func (m *Intention) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *Intention) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_Intention.Marshal(b, m, deterministic)
 	} else {
-		dAtA[i] = 0
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
 	}
-	i--
-	dAtA[i] = 0x18
-	return len(dAtA) - i, nil
 }
-func (m *Event_EventBatch) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+func (m *Intention) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_Intention.Merge(m, src)
+}
+func (m *Intention) XXX_Size() int {
+	return m.Size()
+}
+func (m *Intention) XXX_DiscardUnknown() {
+	xxx_messageInfo_Intention.DiscardUnknown(m)
 }
 
-func (m *Event_EventBatch) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	if m.EventBatch != nil {
-		{
-			size, err := m.EventBatch.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintSubscribe(dAtA, i, uint64(size))
-		}
-		i--
-		dAtA[i] = 0x22
+var xxx_messageInfo_Intention proto.InternalMessageInfo
+
+func (m *Intention) GetID() string {
+	if m != nil {
+		return m.ID
 	}
-	return len(dAtA) - i, nil
-}
-func (m *Event_ServiceHealth) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+	return ""
 }
 
-func (m *Event_ServiceHealth) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	if m.ServiceHealth != nil {
-		{
-			size, err := m.ServiceHealth.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintSubscribe(dAtA, i, uint64(size))
-		}
-		i--
-		dAtA[i] = 0x52
+func (m *Intention) GetDescription() string {
+	if m != nil {
+		return m.Description
 	}
-	return len(dAtA) - i, nil
+	return ""
 }
-func (m *EventBatch) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+
+func (m *Intention) GetSourcePartition() string {
+	if m != nil {
+		return m.SourcePartition
 	}
-	return dAtA[:n], nil
+	return ""
 }
 
-func (m *EventBatch) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+func (m *Intention) GetSourceNS() string {
+	if m != nil {
+		return m.SourceNS
+	}
+	return ""
 }
 
-func (m *EventBatch) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.XXX_unrecognized != nil {
-		i -= len(m.XXX_unrecognized)
-		copy(dAtA[i:], m.XXX_unrecognized)
+func (m *Intention) GetSourceName() string {
+	if m != nil {
+		return m.SourceName
 	}
-	if len(m.Events) > 0 {
-		for iNdEx := len(m.Events) - 1; iNdEx >= 0; iNdEx-- {
-			{
-				size, err := m.Events[iNdEx].MarshalToSizedBuffer(dAtA[:i])
-				if err != nil {
-					return 0, err
-				}
-				i -= size
-				i = encodeVarintSubscribe(dAtA, i, uint64(size))
-			}
-			i--
-			dAtA[i] = 0xa
-		}
+	return ""
+}
+
+func (m *Intention) GetDestinationPartition() string {
+	if m != nil {
+		return m.DestinationPartition
 	}
-	return len(dAtA) - i, nil
+	return ""
 }
 
-func (m *ServiceHealthUpdate) Marshal() (dAtA []byte, err error) {
-	size := m.Size()
-	dAtA = make([]byte, size)
-	n, err := m.MarshalToSizedBuffer(dAtA[:size])
-	if err != nil {
-		return nil, err
+func (m *Intention) GetDestinationNS() string {
+	if m != nil {
+		return m.DestinationNS
 	}
-	return dAtA[:n], nil
+	return ""
 }
 
-func (m *ServiceHealthUpdate) MarshalTo(dAtA []byte) (int, error) {
-	size := m.Size()
-	return m.MarshalToSizedBuffer(dAtA[:size])
+func (m *Intention) GetDestinationName() string {
+	if m != nil {
+		return m.DestinationName
+	}
+	return ""
 }
 
-func (m *ServiceHealthUpdate) MarshalToSizedBuffer(dAtA []byte) (int, error) {
-	i := len(dAtA)
-	_ = i
-	var l int
-	_ = l
-	if m.XXX_unrecognized != nil {
-		i -= len(m.XXX_unrecognized)
-		copy(dAtA[i:], m.XXX_unrecognized)
+func (m *Intention) GetSourceType() string {
+	if m != nil {
+		return m.SourceType
 	}
-	if m.CheckServiceNode != nil {
-		{
-			size, err := m.CheckServiceNode.MarshalToSizedBuffer(dAtA[:i])
-			if err != nil {
-				return 0, err
-			}
-			i -= size
-			i = encodeVarintSubscribe(dAtA, i, uint64(size))
-		}
-		i--
-		dAtA[i] = 0x12
+	return ""
+}
+
+func (m *Intention) GetAction() string {
+	if m != nil {
+		return m.Action
 	}
-	if m.Op != 0 {
-		i = encodeVarintSubscribe(dAtA, i, uint64(m.Op))
-		i--
-		dAtA[i] = 0x8
+	return ""
+}
+
+func (m *Intention) GetPermissions() []*IntentionPermission {
+	if m != nil {
+		return m.Permissions
 	}
-	return len(dAtA) - i, nil
+	return nil
 }
 
-func encodeVarintSubscribe(dAtA []byte, offset int, v uint64) int {
-	offset -= sovSubscribe(v)
-	base := offset
-	for v >= 1<<7 {
-		dAtA[offset] = uint8(v&0x7f | 0x80)
-		v >>= 7
-		offset++
+func (m *Intention) GetDefaultAddr() string {
+	if m != nil {
+		return m.DefaultAddr
 	}
-	dAtA[offset] = uint8(v)
-	return base
+	return ""
 }
-func (m *SubscribeRequest) Size() (n int) {
-	if m == nil {
-		return 0
+
+func (m *Intention) GetDefaultPort() int32 {
+	if m != nil {
+		return m.DefaultPort
 	}
-	var l int
-	_ = l
-	if m.Topic != 0 {
-		n += 1 + sovSubscribe(uint64(m.Topic))
+	return 0
+}
+
+func (m *Intention) GetMeta() map[string]string {
+	if m != nil {
+		return m.Meta
 	}
-	l = len(m.Key)
-	if l > 0 {
-		n += 1 + l + sovSubscribe(uint64(l))
+	return nil
+}
+
+func (m *Intention) GetPrecedence() int32 {
+	if m != nil {
+		return m.Precedence
 	}
-	l = len(m.Token)
-	if l > 0 {
-		n += 1 + l + sovSubscribe(uint64(l))
+	return 0
+}
+
+func (m *Intention) GetCreatedAt() *types.Timestamp {
+	if m != nil {
+		return m.CreatedAt
 	}
-	if m.Index != 0 {
-		n += 1 + sovSubscribe(uint64(m.Index))
+	return nil
+}
+
+func (m *Intention) GetUpdatedAt() *types.Timestamp {
+	if m != nil {
+		return m.UpdatedAt
 	}
-	l = len(m.Datacenter)
-	if l > 0 {
-		n += 1 + l + sovSubscribe(uint64(l))
+	return nil
+}
+
+func (m *Intention) GetHash() []byte {
+	if m != nil {
+		return m.Hash
 	}
-	l = len(m.Namespace)
-	if l > 0 {
-		n += 1 + l + sovSubscribe(uint64(l))
+	return nil
+}
+
+func (m *Intention) GetCreateIndex() uint64 {
+	if m != nil {
+		return m.CreateIndex
 	}
-	l = len(m.Partition)
-	if l > 0 {
-		n += 1 + l + sovSubscribe(uint64(l))
+	return 0
+}
+
+func (m *Intention) GetModifyIndex() uint64 {
+	if m != nil {
+		return m.ModifyIndex
 	}
-	if m.XXX_unrecognized != nil {
-		n += len(m.XXX_unrecognized)
+	return 0
+}
+
+type IntentionPermission struct {
+	Action               string                   `protobuf:"bytes,1,opt,name=Action,proto3" json:"Action,omitempty"`
+	HTTP                 *IntentionHTTPPermission `protobuf:"bytes,2,opt,name=HTTP,proto3" json:"HTTP,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                 `json:"-"`
+	XXX_unrecognized     []byte                   `json:"-"`
+	XXX_sizecache        int32                    `json:"-"`
+}
+
+func (m *IntentionPermission) Reset()         { *m = IntentionPermission{} }
+func (m *IntentionPermission) String() string { return proto.CompactTextString(m) }
+func (*IntentionPermission) ProtoMessage()    {}
+func (*IntentionPermission) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ab3eb8c810e315fb, []int{13}
+}
+func (m *IntentionPermission) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *IntentionPermission) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_IntentionPermission.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
 	}
-	return n
+}
+func (m *IntentionPermission) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_IntentionPermission.Merge(m, src)
+}
+func (m *IntentionPermission) XXX_Size() int {
+	return m.Size()
+}
+func (m *IntentionPermission) XXX_DiscardUnknown() {
+	xxx_messageInfo_IntentionPermission.DiscardUnknown(m)
 }
 
-func (m *Event) Size() (n int) {
-	if m == nil {
-		return 0
+var xxx_messageInfo_IntentionPermission proto.InternalMessageInfo
+
+func (m *IntentionPermission) GetAction() string {
+	if m != nil {
+		return m.Action
 	}
-	var l int
-	_ = l
-	if m.Index != 0 {
-		n += 1 + sovSubscribe(uint64(m.Index))
+	return ""
+}
+
+func (m *IntentionPermission) GetHTTP() *IntentionHTTPPermission {
+	if m != nil {
+		return m.HTTP
 	}
-	if m.Payload != nil {
-		n += m.Payload.Size()
+	return nil
+}
+
+type IntentionHTTPPermission struct {
+	PathExact            string                           `protobuf:"bytes,1,opt,name=PathExact,proto3" json:"PathExact,omitempty"`
+	PathPrefix           string                           `protobuf:"bytes,2,opt,name=PathPrefix,proto3" json:"PathPrefix,omitempty"`
+	PathRegex            string                           `protobuf:"bytes,3,opt,name=PathRegex,proto3" json:"PathRegex,omitempty"`
+	Header               []*IntentionHTTPHeaderPermission `protobuf:"bytes,4,rep,name=Header,proto3" json:"Header,omitempty"`
+	Methods              []string                         `protobuf:"bytes,5,rep,name=Methods,proto3" json:"Methods,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                         `json:"-"`
+	XXX_unrecognized     []byte                           `json:"-"`
+	XXX_sizecache        int32                            `json:"-"`
+}
+
+func (m *IntentionHTTPPermission) Reset()         { *m = IntentionHTTPPermission{} }
+func (m *IntentionHTTPPermission) String() string { return proto.CompactTextString(m) }
+func (*IntentionHTTPPermission) ProtoMessage()    {}
+func (*IntentionHTTPPermission) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ab3eb8c810e315fb, []int{14}
+}
+func (m *IntentionHTTPPermission) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *IntentionHTTPPermission) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_IntentionHTTPPermission.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
 	}
-	if m.XXX_unrecognized != nil {
-		n += len(m.XXX_unrecognized)
+}
+func (m *IntentionHTTPPermission) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_IntentionHTTPPermission.Merge(m, src)
+}
+func (m *IntentionHTTPPermission) XXX_Size() int {
+	return m.Size()
+}
+func (m *IntentionHTTPPermission) XXX_DiscardUnknown() {
+	xxx_messageInfo_IntentionHTTPPermission.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_IntentionHTTPPermission proto.InternalMessageInfo
+
+func (m *IntentionHTTPPermission) GetPathExact() string {
+	if m != nil {
+		return m.PathExact
 	}
-	return n
+	return ""
 }
 
-func (m *Event_EndOfSnapshot) Size() (n int) {
-	if m == nil {
-		return 0
+func (m *IntentionHTTPPermission) GetPathPrefix() string {
+	if m != nil {
+		return m.PathPrefix
 	}
-	var l int
-	_ = l
-	n += 2
-	return n
+	return ""
 }
-func (m *Event_NewSnapshotToFollow) Size() (n int) {
-	if m == nil {
-		return 0
+
+func (m *IntentionHTTPPermission) GetPathRegex() string {
+	if m != nil {
+		return m.PathRegex
 	}
-	var l int
-	_ = l
-	n += 2
-	return n
+	return ""
 }
-func (m *Event_EventBatch) Size() (n int) {
-	if m == nil {
-		return 0
+
+func (m *IntentionHTTPPermission) GetHeader() []*IntentionHTTPHeaderPermission {
+	if m != nil {
+		return m.Header
 	}
-	var l int
-	_ = l
-	if m.EventBatch != nil {
-		l = m.EventBatch.Size()
-		n += 1 + l + sovSubscribe(uint64(l))
+	return nil
+}
+
+func (m *IntentionHTTPPermission) GetMethods() []string {
+	if m != nil {
+		return m.Methods
 	}
-	return n
+	return nil
 }
-func (m *Event_ServiceHealth) Size() (n int) {
-	if m == nil {
-		return 0
+
+type IntentionHTTPHeaderPermission struct {
+	Name                 string   `protobuf:"bytes,1,opt,name=Name,proto3" json:"Name,omitempty"`
+	Present              bool     `protobuf:"varint,2,opt,name=Present,proto3" json:"Present,omitempty"`
+	Exact                string   `protobuf:"bytes,3,opt,name=Exact,proto3" json:"Exact,omitempty"`
+	Prefix               string   `protobuf:"bytes,4,opt,name=Prefix,proto3" json:"Prefix,omitempty"`
+	Suffix               string   `protobuf:"bytes,5,opt,name=Suffix,proto3" json:"Suffix,omitempty"`
+	Regex                string   `protobuf:"bytes,6,opt,name=Regex,proto3" json:"Regex,omitempty"`
+	Invert               bool     `protobuf:"varint,7,opt,name=Invert,proto3" json:"Invert,omitempty"`
+	XXX_NoUnkeyedLiteral struct{} `json:"-"`
+	XXX_unrecognized     []byte   `json:"-"`
+	XXX_sizecache        int32    `json:"-"`
+}
+
+func (m *IntentionHTTPHeaderPermission) Reset()         { *m = IntentionHTTPHeaderPermission{} }
+func (m *IntentionHTTPHeaderPermission) String() string { return proto.CompactTextString(m) }
+func (*IntentionHTTPHeaderPermission) ProtoMessage()    {}
+func (*IntentionHTTPHeaderPermission) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ab3eb8c810e315fb, []int{15}
+}
+func (m *IntentionHTTPHeaderPermission) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *IntentionHTTPHeaderPermission) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_IntentionHTTPHeaderPermission.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
 	}
-	var l int
-	_ = l
-	if m.ServiceHealth != nil {
-		l = m.ServiceHealth.Size()
-		n += 1 + l + sovSubscribe(uint64(l))
+}
+func (m *IntentionHTTPHeaderPermission) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_IntentionHTTPHeaderPermission.Merge(m, src)
+}
+func (m *IntentionHTTPHeaderPermission) XXX_Size() int {
+	return m.Size()
+}
+func (m *IntentionHTTPHeaderPermission) XXX_DiscardUnknown() {
+	xxx_messageInfo_IntentionHTTPHeaderPermission.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_IntentionHTTPHeaderPermission proto.InternalMessageInfo
+
+func (m *IntentionHTTPHeaderPermission) GetName() string {
+	if m != nil {
+		return m.Name
 	}
-	return n
+	return ""
 }
-func (m *EventBatch) Size() (n int) {
-	if m == nil {
-		return 0
+
+func (m *IntentionHTTPHeaderPermission) GetPresent() bool {
+	if m != nil {
+		return m.Present
 	}
-	var l int
-	_ = l
-	if len(m.Events) > 0 {
-		for _, e := range m.Events {
-			l = e.Size()
-			n += 1 + l + sovSubscribe(uint64(l))
+	return false
+}
+
+func (m *IntentionHTTPHeaderPermission) GetExact() string {
+	if m != nil {
+		return m.Exact
+	}
+	return ""
+}
+
+func (m *IntentionHTTPHeaderPermission) GetPrefix() string {
+	if m != nil {
+		return m.Prefix
+	}
+	return ""
+}
+
+func (m *IntentionHTTPHeaderPermission) GetSuffix() string {
+	if m != nil {
+		return m.Suffix
+	}
+	return ""
+}
+
+func (m *IntentionHTTPHeaderPermission) GetRegex() string {
+	if m != nil {
+		return m.Regex
+	}
+	return ""
+}
+
+func (m *IntentionHTTPHeaderPermission) GetInvert() bool {
+	if m != nil {
+		return m.Invert
+	}
+	return false
+}
+
+// ConfigEntryUpdate describes a change to a config entry. Config entries of
+// every kind are sent in the same message, so the entry is encoded with the
+// msgpack encoding used by the RPC responses.
+type ConfigEntryUpdate struct {
+	Op                   UpdateOp                 `protobuf:"varint,1,opt,name=Op,proto3,enum=subscribe.UpdateOp" json:"Op,omitempty"`
+	Kind                 string                   `protobuf:"bytes,2,opt,name=Kind,proto3" json:"Kind,omitempty"`
+	Name                 string                   `protobuf:"bytes,3,opt,name=Name,proto3" json:"Name,omitempty"`
+	EnterpriseMeta       *pbcommon.EnterpriseMeta `protobuf:"bytes,4,opt,name=EnterpriseMeta,proto3" json:"EnterpriseMeta,omitempty"`
+	Entry                []byte                   `protobuf:"bytes,5,opt,name=Entry,proto3" json:"Entry,omitempty"`
+	XXX_NoUnkeyedLiteral struct{}                 `json:"-"`
+	XXX_unrecognized     []byte                   `json:"-"`
+	XXX_sizecache        int32                    `json:"-"`
+}
+
+func (m *ConfigEntryUpdate) Reset()         { *m = ConfigEntryUpdate{} }
+func (m *ConfigEntryUpdate) String() string { return proto.CompactTextString(m) }
+func (*ConfigEntryUpdate) ProtoMessage()    {}
+func (*ConfigEntryUpdate) Descriptor() ([]byte, []int) {
+	return fileDescriptor_ab3eb8c810e315fb, []int{16}
+}
+func (m *ConfigEntryUpdate) XXX_Unmarshal(b []byte) error {
+	return m.Unmarshal(b)
+}
+func (m *ConfigEntryUpdate) XXX_Marshal(b []byte, deterministic bool) ([]byte, error) {
+	if deterministic {
+		return xxx_messageInfo_ConfigEntryUpdate.Marshal(b, m, deterministic)
+	} else {
+		b = b[:cap(b)]
+		n, err := m.MarshalToSizedBuffer(b)
+		if err != nil {
+			return nil, err
+		}
+		return b[:n], nil
+	}
+}
+func (m *ConfigEntryUpdate) XXX_Merge(src proto.Message) {
+	xxx_messageInfo_ConfigEntryUpdate.Merge(m, src)
+}
+func (m *ConfigEntryUpdate) XXX_Size() int {
+	return m.Size()
+}
+func (m *ConfigEntryUpdate) XXX_DiscardUnknown() {
+	xxx_messageInfo_ConfigEntryUpdate.DiscardUnknown(m)
+}
+
+var xxx_messageInfo_ConfigEntryUpdate proto.InternalMessageInfo
+
+func (m *ConfigEntryUpdate) GetOp() UpdateOp {
+	if m != nil {
+		return m.Op
+	}
+	return UpdateOp_Upsert
+}
+
+func (m *ConfigEntryUpdate) GetKind() string {
+	if m != nil {
+		return m.Kind
+	}
+	return ""
+}
+
+func (m *ConfigEntryUpdate) GetName() string {
+	if m != nil {
+		return m.Name
+	}
+	return ""
+}
+
+func (m *ConfigEntryUpdate) GetEnterpriseMeta() *pbcommon.EnterpriseMeta {
+	if m != nil {
+		return m.EnterpriseMeta
+	}
+	return nil
+}
+
+func (m *ConfigEntryUpdate) GetEntry() []byte {
+	if m != nil {
+		return m.Entry
+	}
+	return nil
+}
+
+func init() {
+	proto.RegisterEnum("subscribe.Topic", Topic_name, Topic_value)
+	proto.RegisterEnum("subscribe.CatalogOp", CatalogOp_name, CatalogOp_value)
+	proto.RegisterEnum("subscribe.UpdateOp", UpdateOp_name, UpdateOp_value)
+	proto.RegisterType((*SubscribeRequest)(nil), "subscribe.SubscribeRequest")
+	proto.RegisterType((*MultiplexedRequest)(nil), "subscribe.MultiplexedRequest")
+	proto.RegisterType((*MultiplexedEvent)(nil), "subscribe.MultiplexedEvent")
+	proto.RegisterType((*SubscriptionError)(nil), "subscribe.SubscriptionError")
+	proto.RegisterType((*Event)(nil), "subscribe.Event")
+	proto.RegisterType((*EventBatch)(nil), "subscribe.EventBatch")
+	proto.RegisterType((*ServiceHealthUpdate)(nil), "subscribe.ServiceHealthUpdate")
+	proto.RegisterType((*CatalogServiceUpdate)(nil), "subscribe.CatalogServiceUpdate")
+	proto.RegisterType((*NodeUpdate)(nil), "subscribe.NodeUpdate")
+	proto.RegisterType((*KVUpdate)(nil), "subscribe.KVUpdate")
+	proto.RegisterType((*KVEntry)(nil), "subscribe.KVEntry")
+	proto.RegisterType((*IntentionUpdate)(nil), "subscribe.IntentionUpdate")
+	proto.RegisterType((*Intention)(nil), "subscribe.Intention")
+	proto.RegisterMapType((map[string]string)(nil), "subscribe.Intention.MetaEntry")
+	proto.RegisterType((*IntentionPermission)(nil), "subscribe.IntentionPermission")
+	proto.RegisterType((*IntentionHTTPPermission)(nil), "subscribe.IntentionHTTPPermission")
+	proto.RegisterType((*IntentionHTTPHeaderPermission)(nil), "subscribe.IntentionHTTPHeaderPermission")
+	proto.RegisterType((*ConfigEntryUpdate)(nil), "subscribe.ConfigEntryUpdate")
+}
+
+func init() { proto.RegisterFile("proto/pbsubscribe/subscribe.proto", fileDescriptor_ab3eb8c810e315fb) }
+
+var fileDescriptor_ab3eb8c810e315fb = []byte{
+	// 1748 bytes of a gzipped FileDescriptorProto
+	0x1f, 0x8b, 0x08, 0x00, 0x00, 0x00, 0x00, 0x00, 0x02, 0xff, 0x94, 0x58, 0x4b, 0x6f, 0x23, 0x4b,
+	0x15, 0x76, 0xfb, 0xdd, 0xc7, 0x79, 0x74, 0x2a, 0xe6, 0x4e, 0xcb, 0x73, 0x6f, 0xae, 0xe9, 0x3b,
+	0x5c, 0x99, 0x01, 0xe2, 0x91, 0x41, 0x33, 0xa3, 0x41, 0x1a, 0x26, 0x89, 0x13, 0x1c, 0x85, 0x24,
+	0x56, 0x3b, 0x89, 0x80, 0x5d, 0xa5, 0x5d, 0xb1, 0x9b, 0xb4, 0xbb, 0x9b, 0xee, 0x72, 0x1e, 0x6b,
+	0xd8, 0x8d, 0xd8, 0xf3, 0x3f, 0x90, 0x58, 0x22, 0xb1, 0x63, 0x09, 0x62, 0x8f, 0xd0, 0xb0, 0x61,
+	0xcd, 0x2f, 0x40, 0xf5, 0xe8, 0xee, 0xf2, 0x23, 0x33, 0x99, 0x95, 0xfb, 0x7c, 0xe7, 0x9c, 0xaa,
+	0x53, 0xe7, 0x59, 0x65, 0xf8, 0x6e, 0x18, 0x05, 0x34, 0x68, 0x87, 0x97, 0xf1, 0xf4, 0x32, 0x76,
+	0x22, 0xf7, 0x92, 0xb4, 0xd3, 0xaf, 0x6d, 0xce, 0x43, 0x7a, 0x0a, 0x34, 0xbe, 0x1e, 0x05, 0xc1,
+	0xc8, 0x23, 0x6d, 0xce, 0xb8, 0x9c, 0x5e, 0xb5, 0xa9, 0x3b, 0x21, 0x31, 0xc5, 0x93, 0x50, 0xc8,
+	0x36, 0x9e, 0x26, 0xcb, 0x39, 0xc1, 0x64, 0x12, 0xf8, 0x6d, 0xf1, 0x23, 0x99, 0xd9, 0x5e, 0x24,
+	0xba, 0x71, 0x1d, 0xd2, 0x1e, 0x13, 0xec, 0xd1, 0xb1, 0x33, 0x26, 0xce, 0xb5, 0x14, 0x69, 0xcc,
+	0x8b, 0xf8, 0xc1, 0x50, 0xda, 0x61, 0xfd, 0x2b, 0x0f, 0xc6, 0x20, 0x31, 0xc5, 0x26, 0xbf, 0x9d,
+	0x92, 0x98, 0xa2, 0x6f, 0xa1, 0x74, 0x16, 0x84, 0xae, 0x63, 0x6a, 0x4d, 0xad, 0xb5, 0xd6, 0x31,
+	0xb6, 0x33, 0xeb, 0x39, 0x6e, 0x0b, 0x36, 0x32, 0xa0, 0x70, 0x44, 0xee, 0xcd, 0x7c, 0x53, 0x6b,
+	0xe9, 0x36, 0xfb, 0x44, 0x75, 0xa6, 0x79, 0x4d, 0x7c, 0xb3, 0xc0, 0x31, 0x41, 0x30, 0xf4, 0xd0,
+	0x1f, 0x92, 0x3b, 0xb3, 0xd8, 0xd4, 0x5a, 0x45, 0x5b, 0x10, 0x68, 0x0b, 0xa0, 0x8b, 0x29, 0x76,
+	0x88, 0x4f, 0x49, 0x64, 0x96, 0xb8, 0x82, 0x82, 0xa0, 0x2f, 0x41, 0x3f, 0xc1, 0x13, 0x12, 0x87,
+	0xd8, 0x21, 0x66, 0x99, 0xb3, 0x33, 0x80, 0x71, 0xfb, 0x38, 0xa2, 0x2e, 0x75, 0x03, 0xdf, 0xac,
+	0x08, 0x6e, 0x0a, 0xa0, 0x2f, 0xa0, 0x7c, 0xe0, 0x7a, 0x6c, 0xdd, 0x2a, 0x67, 0x49, 0x0a, 0xbd,
+	0x86, 0x27, 0x3d, 0x82, 0x23, 0x7a, 0x49, 0x30, 0x3d, 0x64, 0xbb, 0xdc, 0x60, 0xef, 0xd8, 0xf5,
+	0x3c, 0x37, 0x36, 0x75, 0x6e, 0xdb, 0x43, 0x6c, 0xd4, 0x80, 0xea, 0x85, 0x4b, 0x6e, 0x7b, 0x38,
+	0x1e, 0x9b, 0xc0, 0x45, 0x53, 0x9a, 0xf1, 0xf6, 0xef, 0xb0, 0x43, 0x99, 0x33, 0x6a, 0x4d, 0xad,
+	0x55, 0xb5, 0x53, 0xda, 0x7a, 0xaf, 0x01, 0x3a, 0x9e, 0x7a, 0xd4, 0x0d, 0x3d, 0x72, 0x47, 0x86,
+	0x89, 0x8b, 0xd7, 0x20, 0x7f, 0xd8, 0xe5, 0xfe, 0x2d, 0xda, 0xf9, 0xc3, 0x2e, 0xfa, 0x29, 0xe8,
+	0x69, 0x18, 0xb8, 0x43, 0x6b, 0x9d, 0xa7, 0x8a, 0xdb, 0xe7, 0x43, 0xd4, 0xcb, 0xd9, 0x99, 0x3c,
+	0xb2, 0xa0, 0x76, 0xee, 0xa7, 0xc2, 0xdc, 0xf7, 0xd5, 0x5e, 0xce, 0x56, 0xc1, 0xdd, 0x22, 0xe4,
+	0x4f, 0x43, 0xeb, 0x0f, 0x1a, 0x18, 0x8a, 0x35, 0xfb, 0x37, 0xc4, 0x5f, 0xb4, 0xa5, 0x05, 0x25,
+	0xce, 0x90, 0x76, 0xa8, 0xe1, 0xe7, 0x78, 0x2f, 0x67, 0x0b, 0x01, 0xf4, 0x13, 0x28, 0xed, 0x47,
+	0x51, 0x10, 0xf1, 0x2d, 0x6b, 0x9d, 0x2f, 0x17, 0x2d, 0x0e, 0x59, 0x38, 0xb8, 0x0c, 0xd7, 0x62,
+	0x1f, 0xbb, 0x3a, 0x54, 0xfa, 0xf8, 0xde, 0x0b, 0xf0, 0xd0, 0xda, 0x81, 0x8d, 0x05, 0x41, 0x84,
+	0xa0, 0xb8, 0x17, 0x0c, 0x09, 0xb7, 0x68, 0xd5, 0xe6, 0xdf, 0xc8, 0x84, 0xca, 0x31, 0x89, 0x63,
+	0x3c, 0x22, 0x32, 0xdd, 0x12, 0xd2, 0xfa, 0x6f, 0x51, 0x9a, 0x9b, 0xa5, 0x99, 0xa6, 0xa6, 0xd9,
+	0xb7, 0xb0, 0xba, 0xef, 0x0f, 0x4f, 0xaf, 0x06, 0x3e, 0x0e, 0xe3, 0x71, 0x20, 0x4e, 0xc5, 0xdc,
+	0x33, 0x0b, 0xa3, 0x0e, 0x6c, 0x9e, 0x90, 0xdb, 0x84, 0x3c, 0x0b, 0x0e, 0x02, 0xcf, 0x0b, 0x6e,
+	0x53, 0x67, 0x2e, 0x63, 0xa2, 0x57, 0x00, 0x7c, 0xeb, 0x5d, 0x4c, 0x9d, 0x31, 0xcf, 0xee, 0x5a,
+	0xe7, 0x3b, 0xf3, 0xee, 0xe2, 0xcc, 0x5e, 0xce, 0x56, 0x44, 0xd1, 0x16, 0xe8, 0x69, 0xa2, 0x99,
+	0x25, 0xb9, 0x45, 0x06, 0xa1, 0x67, 0xb0, 0x62, 0x93, 0x78, 0x3a, 0x21, 0x03, 0x1a, 0x11, 0x3c,
+	0x31, 0xcb, 0x52, 0x64, 0x06, 0x45, 0x07, 0xb0, 0x3a, 0x10, 0x25, 0xdd, 0xe3, 0x45, 0xcf, 0x13,
+	0xb3, 0xd6, 0xd9, 0x52, 0xc3, 0xa0, 0xf2, 0xcf, 0xc3, 0x21, 0xa6, 0x84, 0x1d, 0x7d, 0x06, 0x46,
+	0xdf, 0x83, 0xfc, 0xd1, 0x05, 0xcf, 0xdc, 0x5a, 0x67, 0x53, 0x51, 0x3e, 0xba, 0x48, 0x35, 0xf2,
+	0x47, 0x17, 0xe8, 0x10, 0xd6, 0xf6, 0x30, 0xc5, 0x5e, 0x30, 0x92, 0xea, 0xe6, 0x0a, 0x57, 0xf9,
+	0x5a, 0x51, 0x99, 0x15, 0x48, 0xd5, 0xe7, 0x14, 0xd1, 0x1b, 0xd0, 0x59, 0x7d, 0xf9, 0xbc, 0x7a,
+	0x57, 0xf9, 0x2a, 0x0d, 0x65, 0x95, 0x94, 0x97, 0x2e, 0x90, 0x89, 0xa3, 0x77, 0x50, 0xdb, 0x0b,
+	0xfc, 0x2b, 0x77, 0xb4, 0xef, 0xd3, 0xe8, 0xde, 0x5c, 0x5b, 0x48, 0x3d, 0x85, 0x9b, 0xea, 0xab,
+	0x2a, 0xe8, 0x07, 0x50, 0x3c, 0x61, 0x09, 0xb6, 0xbe, 0x10, 0x30, 0x06, 0xa7, 0x3a, 0x5c, 0x48,
+	0xcd, 0xd6, 0x97, 0x6a, 0xb8, 0x51, 0x0b, 0xca, 0x9c, 0x8a, 0x4d, 0xad, 0x59, 0x58, 0x56, 0x27,
+	0xb6, 0xe4, 0x5b, 0xbf, 0xd7, 0x60, 0x73, 0x49, 0x20, 0xd0, 0x33, 0x56, 0x93, 0xb2, 0xc9, 0xd6,
+	0x17, 0x9d, 0x78, 0x1a, 0xda, 0xf9, 0xd3, 0x10, 0xfd, 0x1c, 0x8c, 0x3d, 0xd6, 0xcd, 0xe5, 0x0a,
+	0xdc, 0xf2, 0xa4, 0x43, 0xa4, 0x3d, 0x7d, 0x7b, 0x5e, 0xc4, 0x5e, 0x50, 0xb2, 0xfe, 0xa7, 0x41,
+	0x7d, 0x59, 0x7c, 0x1e, 0x69, 0x07, 0x82, 0x62, 0xba, 0xb7, 0x2e, 0x9c, 0xc3, 0xba, 0xb0, 0x5c,
+	0xea, 0xb0, 0x2b, 0x7b, 0x7e, 0x06, 0xa0, 0x26, 0xd4, 0x92, 0xfd, 0xf1, 0x84, 0xf0, 0xfa, 0xd0,
+	0x6d, 0x15, 0x52, 0x24, 0xce, 0xf0, 0x28, 0x36, 0x4b, 0xcd, 0x82, 0x22, 0xc1, 0x20, 0xf4, 0x16,
+	0xd6, 0xf6, 0x59, 0x27, 0x0e, 0x23, 0x37, 0x26, 0xc7, 0x84, 0x62, 0x5e, 0x0b, 0xb5, 0xce, 0x17,
+	0xdb, 0x72, 0x0c, 0xce, 0x72, 0xed, 0x39, 0x69, 0xeb, 0x9f, 0x1a, 0x40, 0x16, 0xd5, 0x47, 0x1e,
+	0xb5, 0x01, 0x55, 0xa6, 0xc3, 0xad, 0x16, 0xc7, 0x4d, 0x69, 0xf4, 0x8d, 0x74, 0x83, 0x68, 0x79,
+	0xeb, 0x4a, 0x08, 0xb8, 0xdb, 0x85, 0x5f, 0x5e, 0x40, 0x25, 0xa9, 0x91, 0xa2, 0x34, 0x77, 0x56,
+	0x4e, 0x72, 0xed, 0x44, 0x0c, 0xfd, 0x10, 0x4a, 0x3c, 0x60, 0x66, 0x69, 0x41, 0x5e, 0xe4, 0x0c,
+	0xe7, 0xda, 0x42, 0xc8, 0xfa, 0x15, 0x54, 0x93, 0xe2, 0x44, 0xdf, 0x28, 0x47, 0x52, 0xab, 0x57,
+	0xb0, 0xe5, 0x89, 0x58, 0x4f, 0xe7, 0xe5, 0x22, 0x32, 0x07, 0xcd, 0x54, 0x39, 0xe7, 0xd8, 0x42,
+	0xc0, 0xfa, 0x5d, 0x1e, 0x2a, 0x12, 0x4a, 0x06, 0xbc, 0x36, 0x33, 0xe0, 0x2f, 0xb0, 0x37, 0x15,
+	0x6e, 0x59, 0xb1, 0x05, 0xc1, 0xd0, 0x03, 0x8f, 0x05, 0xb0, 0x20, 0x3a, 0x2f, 0x27, 0x58, 0xcf,
+	0x1e, 0x90, 0x38, 0x66, 0x25, 0x2e, 0x42, 0x9f, 0x90, 0x2c, 0x6d, 0x7e, 0x11, 0x38, 0xd7, 0xa2,
+	0x5b, 0x97, 0xb8, 0x4e, 0x06, 0xb0, 0xa4, 0xd8, 0x8b, 0x08, 0xa6, 0x44, 0xf0, 0xcb, 0x9c, 0xaf,
+	0x42, 0x4c, 0xe2, 0x38, 0x18, 0xba, 0x57, 0xf7, 0x42, 0xa2, 0x22, 0x24, 0x14, 0x68, 0x49, 0xda,
+	0x54, 0x3f, 0x2b, 0x6d, 0x7e, 0x03, 0xeb, 0x73, 0x4d, 0xe8, 0x71, 0x7e, 0xee, 0xa8, 0x8d, 0x4d,
+	0xf8, 0xba, 0xbe, 0xac, 0xb1, 0x29, 0x0d, 0xcd, 0xfa, 0x4b, 0x59, 0x51, 0x52, 0xa6, 0xb1, 0xce,
+	0xa7, 0x71, 0x13, 0x6a, 0x5d, 0x92, 0x4e, 0x48, 0x99, 0x8e, 0x2a, 0x84, 0x5a, 0xb0, 0x3e, 0x08,
+	0xa6, 0x91, 0x43, 0xb2, 0x0b, 0x91, 0x28, 0xc5, 0x79, 0x98, 0xe5, 0xb5, 0x80, 0x4e, 0x06, 0x32,
+	0x24, 0x29, 0xcd, 0xae, 0x63, 0xf2, 0x9b, 0x65, 0xbd, 0xbc, 0x8e, 0x65, 0x08, 0xea, 0x40, 0xbd,
+	0x4b, 0x62, 0xea, 0xfa, 0x98, 0x2d, 0x95, 0x6d, 0x25, 0x6e, 0x66, 0x4b, 0x79, 0xe8, 0x19, 0xac,
+	0x2a, 0xf8, 0xc9, 0x40, 0x5e, 0xd4, 0x66, 0x41, 0x66, 0xbf, 0x0a, 0xb0, 0xed, 0xc5, 0xad, 0x6d,
+	0x1e, 0xce, 0x6c, 0x3c, 0xbb, 0x0f, 0x89, 0xa9, 0xab, 0x36, 0x32, 0x84, 0x5d, 0xfb, 0x76, 0x1c,
+	0x6e, 0x15, 0x70, 0x9e, 0xa4, 0xd8, 0xc8, 0xe8, 0x93, 0x68, 0xe2, 0xf2, 0xec, 0x8b, 0xcd, 0x5a,
+	0xb3, 0x30, 0x37, 0x26, 0x53, 0xf7, 0x67, 0x62, 0xb6, 0xaa, 0x22, 0xa2, 0x70, 0x85, 0xa7, 0x1e,
+	0xdd, 0x19, 0x0e, 0x23, 0x73, 0x25, 0x89, 0x42, 0x0a, 0x29, 0x12, 0xfd, 0x20, 0xa2, 0x7c, 0xa8,
+	0x95, 0x6c, 0x15, 0x42, 0x1d, 0x28, 0xf2, 0x4c, 0x5c, 0x7b, 0x78, 0xfb, 0x6d, 0x26, 0x20, 0xca,
+	0x91, 0xcb, 0xb2, 0x13, 0xf7, 0x23, 0xe2, 0x90, 0x21, 0xf1, 0x1d, 0x31, 0xb0, 0x4a, 0xb6, 0x82,
+	0xa0, 0xd7, 0xa0, 0x8b, 0xc2, 0x18, 0xee, 0x50, 0xd3, 0x90, 0x83, 0x54, 0x3c, 0x28, 0xb6, 0x93,
+	0x07, 0xc5, 0xf6, 0x59, 0xf2, 0xa0, 0xb0, 0x33, 0x61, 0xa6, 0x29, 0x32, 0x97, 0x69, 0x6e, 0x7c,
+	0x5a, 0x33, 0x15, 0x66, 0x83, 0x80, 0x5f, 0x83, 0x11, 0x6f, 0x01, 0xfc, 0x7b, 0xbe, 0x66, 0x37,
+	0x3f, 0x59, 0xb3, 0xf5, 0x85, 0x9a, 0x6d, 0xbc, 0x02, 0x3d, 0x3d, 0x3e, 0x6b, 0x3d, 0xd7, 0x59,
+	0xeb, 0xb9, 0x16, 0xad, 0xe7, 0x26, 0x6d, 0x3d, 0xba, 0x2d, 0x88, 0x37, 0xf9, 0xd7, 0x9a, 0x45,
+	0x60, 0x73, 0x49, 0x00, 0x95, 0x6c, 0xd0, 0x66, 0xb2, 0xe1, 0x25, 0x14, 0x7b, 0x67, 0x67, 0x7d,
+	0x59, 0x9e, 0xd6, 0xb2, 0x38, 0x30, 0xbe, 0x92, 0x0a, 0x5c, 0xde, 0xfa, 0x87, 0x06, 0x4f, 0x1e,
+	0x90, 0x10, 0xcf, 0x11, 0x3a, 0xe6, 0xd7, 0x7e, 0xb9, 0x5d, 0x06, 0xf0, 0x28, 0x62, 0x3a, 0xee,
+	0x47, 0xe4, 0xca, 0xbd, 0x93, 0xf6, 0x2b, 0x48, 0xa2, 0x6d, 0x93, 0x11, 0xb9, 0x4b, 0xc6, 0x68,
+	0x0a, 0xa0, 0x77, 0x50, 0xee, 0x11, 0x3c, 0x24, 0x91, 0x59, 0xe4, 0x99, 0xd3, 0x7a, 0xc8, 0x62,
+	0x21, 0xa5, 0xd8, 0x2d, 0xf5, 0xc4, 0xed, 0x99, 0x8e, 0x83, 0x61, 0x32, 0x62, 0x13, 0xd2, 0xfa,
+	0xab, 0x06, 0x5f, 0x7d, 0x74, 0x0d, 0x3e, 0xf6, 0x59, 0x49, 0x6a, 0x72, 0xec, 0xb3, 0x3a, 0x34,
+	0xa1, 0xd2, 0x8f, 0x48, 0x9c, 0xbc, 0x11, 0xaa, 0x76, 0x42, 0xb2, 0x20, 0x09, 0x1f, 0xc8, 0x07,
+	0x20, 0x27, 0x58, 0x24, 0xe4, 0xd9, 0x45, 0xd7, 0x91, 0x14, 0xc3, 0x07, 0xd3, 0x2b, 0x86, 0x8b,
+	0x7e, 0x23, 0x29, 0xb6, 0x8a, 0xf0, 0x85, 0x68, 0x2e, 0x82, 0x60, 0xd2, 0x87, 0xfe, 0x0d, 0x89,
+	0x28, 0x6f, 0x23, 0x55, 0x5b, 0x52, 0xd6, 0x9f, 0x35, 0xd8, 0x58, 0xb8, 0xf3, 0x3d, 0xae, 0x5d,
+	0x23, 0x28, 0x1e, 0xb9, 0xfe, 0x30, 0xb9, 0xd3, 0xb0, 0xef, 0xf4, 0xc0, 0x05, 0xe5, 0xc0, 0x8b,
+	0xe3, 0xa4, 0xf8, 0x39, 0xe3, 0x84, 0xbb, 0x85, 0x8f, 0xdf, 0x92, 0x18, 0x9b, 0x9c, 0x78, 0xfe,
+	0x5e, 0x93, 0x0f, 0x6d, 0x54, 0x83, 0xca, 0xb9, 0x7f, 0xed, 0x07, 0xb7, 0xbe, 0x91, 0x43, 0x1b,
+	0x73, 0xd7, 0x7a, 0x43, 0x43, 0x26, 0xd4, 0x67, 0xa0, 0xbd, 0xc0, 0xf7, 0x89, 0x43, 0x8d, 0x3c,
+	0x2a, 0xb3, 0xbb, 0xbb, 0x51, 0x40, 0x9b, 0xb0, 0x3e, 0x7b, 0xb7, 0x8b, 0x8d, 0x22, 0x5a, 0x03,
+	0x48, 0x83, 0x1b, 0x1b, 0x25, 0xb6, 0x72, 0xe6, 0x28, 0x97, 0xc4, 0x46, 0x19, 0x55, 0xc5, 0x75,
+	0xc6, 0xa8, 0x3c, 0xff, 0x3e, 0xe8, 0xe9, 0x2d, 0x08, 0xad, 0x40, 0xd5, 0x26, 0x23, 0x37, 0xa6,
+	0x24, 0x32, 0x72, 0x6c, 0x9d, 0x2e, 0x89, 0x12, 0x5a, 0x7b, 0x6e, 0x41, 0x35, 0x71, 0x23, 0x02,
+	0x28, 0x9f, 0x87, 0x31, 0x89, 0xa8, 0x91, 0x63, 0xdf, 0x5d, 0xe2, 0x11, 0x4a, 0x0c, 0xad, 0xf3,
+	0x27, 0x0d, 0x9e, 0x0c, 0x28, 0xa6, 0x64, 0x6f, 0x8c, 0xfd, 0x11, 0x51, 0x9f, 0x79, 0xe8, 0xad,
+	0xf2, 0xda, 0x45, 0x1f, 0x7b, 0xe7, 0x36, 0x16, 0x2e, 0xd5, 0x56, 0xee, 0x85, 0x86, 0x7e, 0x09,
+	0xf5, 0x54, 0x52, 0x79, 0xce, 0xa2, 0xaf, 0x14, 0xe9, 0xc5, 0x47, 0x77, 0xe3, 0xe9, 0x72, 0xb6,
+	0x5c, 0xb7, 0xa5, 0xbd, 0xd0, 0x76, 0x7f, 0xf6, 0xb7, 0x0f, 0x5b, 0xda, 0xdf, 0x3f, 0x6c, 0x69,
+	0xff, 0xfe, 0xb0, 0xa5, 0xfd, 0xf1, 0x3f, 0x5b, 0xb9, 0x5f, 0xff, 0x68, 0xe4, 0xd2, 0xf1, 0xf4,
+	0x92, 0x05, 0xba, 0x3d, 0xc6, 0xf1, 0xd8, 0x75, 0x82, 0x28, 0x6c, 0x3b, 0x81, 0x1f, 0x4f, 0xbd,
+	0xf6, 0xc2, 0x1f, 0x3d, 0x97, 0x65, 0x0e, 0xfd, 0xf8, 0xff, 0x03, 0x00, 0x42, 0xb3, 0x17, 0x87,
+	0x04, 0x12, 0x00, 0x00,
+}
+
+// Reference imports to suppress errors if they are not otherwise used.
+var _ context.Context
+var _ grpc.ClientConn
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+const _ = grpc.SupportPackageIsVersion4
+
+// StateChangeSubscriptionClient is the client API for StateChangeSubscription service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://godoc.org/google.golang.org/grpc#ClientConn.NewStream.
+type StateChangeSubscriptionClient interface {
+	// Subscribe to a topic to receive events when there are changes to the topic.
+	//
+	// If SubscribeRequest.Index is 0 the event stream will start with one or
+	// more snapshot events, followed by an EndOfSnapshot event. Subsequent
+	// events will be a live stream of events as they happen.
+	//
+	// If SubscribeRequest.Index is > 0 it is assumed the client already has a
+	// snapshot, and is trying to resume a stream that was disconnected. The
+	// client will either receive a NewSnapshotToFollow event, indicating the
+	// client view is stale and it must reset its view and prepare for a new
+	// snapshot. Or, if no NewSnapshotToFollow event is received, the client
+	// view is still fresh, and all events will be the live stream.
+	//
+	// If SubscribeRequest.ViewHash is also set, the client may receive a
+	// ResumeStream event in place of the NewSnapshotToFollow event and the new
+	// snapshot, indicating the client view matches the new snapshot. The
+	// client must not change its view, and all subsequent events will be the
+	// live stream.
+	//
+	// Subscribe may return a gRPC status error with codes.ABORTED to indicate
+	// the client view is now stale due to a change on the server. The client
+	// must reset its view and issue a new Subscribe call to restart the stream.
+	// This error is used when the server can no longer correctly maintain the
+	// stream, for example because the ACL permissions for the token changed, or
+	// because the server state was restored from a snapshot.
+	Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (StateChangeSubscription_SubscribeClient, error)
+	// SubscribeMultiplexed carries many subscriptions over a single stream.
+	// Each MultiplexedRequest starts or stops one subscription, identified by
+	// an ID chosen by the client. The events of each subscription are sent in
+	// a MultiplexedEvent with the same ID, in the order Subscribe would send
+	// them.
+	//
+	// A subscription ends when the client stops it, or with a
+	// MultiplexedEvent which contains the error Subscribe would have returned.
+	// The other subscriptions of the stream continue. When the stream ends,
+	// every subscription of the stream ends.
+	SubscribeMultiplexed(ctx context.Context, opts ...grpc.CallOption) (StateChangeSubscription_SubscribeMultiplexedClient, error)
+}
+
+type stateChangeSubscriptionClient struct {
+	cc *grpc.ClientConn
+}
+
+func NewStateChangeSubscriptionClient(cc *grpc.ClientConn) StateChangeSubscriptionClient {
+	return &stateChangeSubscriptionClient{cc}
+}
+
+func (c *stateChangeSubscriptionClient) Subscribe(ctx context.Context, in *SubscribeRequest, opts ...grpc.CallOption) (StateChangeSubscription_SubscribeClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_StateChangeSubscription_serviceDesc.Streams[0], "/subscribe.StateChangeSubscription/Subscribe", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &stateChangeSubscriptionSubscribeClient{stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+type StateChangeSubscription_SubscribeClient interface {
+	Recv() (*Event, error)
+	grpc.ClientStream
+}
+
+type stateChangeSubscriptionSubscribeClient struct {
+	grpc.ClientStream
+}
+
+func (x *stateChangeSubscriptionSubscribeClient) Recv() (*Event, error) {
+	m := new(Event)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+func (c *stateChangeSubscriptionClient) SubscribeMultiplexed(ctx context.Context, opts ...grpc.CallOption) (StateChangeSubscription_SubscribeMultiplexedClient, error) {
+	stream, err := c.cc.NewStream(ctx, &_StateChangeSubscription_serviceDesc.Streams[1], "/subscribe.StateChangeSubscription/SubscribeMultiplexed", opts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &stateChangeSubscriptionSubscribeMultiplexedClient{stream}
+	return x, nil
+}
+
+type StateChangeSubscription_SubscribeMultiplexedClient interface {
+	Send(*MultiplexedRequest) error
+	Recv() (*MultiplexedEvent, error)
+	grpc.ClientStream
+}
+
+type stateChangeSubscriptionSubscribeMultiplexedClient struct {
+	grpc.ClientStream
+}
+
+func (x *stateChangeSubscriptionSubscribeMultiplexedClient) Send(m *MultiplexedRequest) error {
+	return x.ClientStream.SendMsg(m)
+}
+
+func (x *stateChangeSubscriptionSubscribeMultiplexedClient) Recv() (*MultiplexedEvent, error) {
+	m := new(MultiplexedEvent)
+	if err := x.ClientStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+// StateChangeSubscriptionServer is the server API for StateChangeSubscription service.
+type StateChangeSubscriptionServer interface {
+	// Subscribe to a topic to receive events when there are changes to the topic.
+	//
+	// If SubscribeRequest.Index is 0 the event stream will start with one or
+	// more snapshot events, followed by an EndOfSnapshot event. Subsequent
+	// events will be a live stream of events as they happen.
+	//
+	// If SubscribeRequest.Index is > 0 it is assumed the client already has a
+	// snapshot, and is trying to resume a stream that was disconnected. The
+	// client will either receive a NewSnapshotToFollow event, indicating the
+	// client view is stale and it must reset its view and prepare for a new
+	// snapshot. Or, if no NewSnapshotToFollow event is received, the client
+	// view is still fresh, and all events will be the live stream.
+	//
+	// If SubscribeRequest.ViewHash is also set, the client may receive a
+	// ResumeStream event in place of the NewSnapshotToFollow event and the new
+	// snapshot, indicating the client view matches the new snapshot. The
+	// client must not change its view, and all subsequent events will be the
+	// live stream.
+	//
+	// Subscribe may return a gRPC status error with codes.ABORTED to indicate
+	// the client view is now stale due to a change on the server. The client
+	// must reset its view and issue a new Subscribe call to restart the stream.
+	// This error is used when the server can no longer correctly maintain the
+	// stream, for example because the ACL permissions for the token changed, or
+	// because the server state was restored from a snapshot.
+	Subscribe(*SubscribeRequest, StateChangeSubscription_SubscribeServer) error
+	// SubscribeMultiplexed carries many subscriptions over a single stream.
+	// Each MultiplexedRequest starts or stops one subscription, identified by
+	// an ID chosen by the client. The events of each subscription are sent in
+	// a MultiplexedEvent with the same ID, in the order Subscribe would send
+	// them.
+	//
+	// A subscription ends when the client stops it, or with a
+	// MultiplexedEvent which contains the error Subscribe would have returned.
+	// The other subscriptions of the stream continue. When the stream ends,
+	// every subscription of the stream ends.
+	SubscribeMultiplexed(StateChangeSubscription_SubscribeMultiplexedServer) error
+}
+
+// UnimplementedStateChangeSubscriptionServer can be embedded to have forward compatible implementations.
+type UnimplementedStateChangeSubscriptionServer struct {
+}
+
+func (*UnimplementedStateChangeSubscriptionServer) Subscribe(req *SubscribeRequest, srv StateChangeSubscription_SubscribeServer) error {
+	return status.Errorf(codes.Unimplemented, "method Subscribe not implemented")
+}
+func (*UnimplementedStateChangeSubscriptionServer) SubscribeMultiplexed(srv StateChangeSubscription_SubscribeMultiplexedServer) error {
+	return status.Errorf(codes.Unimplemented, "method SubscribeMultiplexed not implemented")
+}
+
+func RegisterStateChangeSubscriptionServer(s *grpc.Server, srv StateChangeSubscriptionServer) {
+	s.RegisterService(&_StateChangeSubscription_serviceDesc, srv)
+}
+
+func _StateChangeSubscription_Subscribe_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(SubscribeRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(StateChangeSubscriptionServer).Subscribe(m, &stateChangeSubscriptionSubscribeServer{stream})
+}
+
+type StateChangeSubscription_SubscribeServer interface {
+	Send(*Event) error
+	grpc.ServerStream
+}
+
+type stateChangeSubscriptionSubscribeServer struct {
+	grpc.ServerStream
+}
+
+func (x *stateChangeSubscriptionSubscribeServer) Send(m *Event) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func _StateChangeSubscription_SubscribeMultiplexed_Handler(srv interface{}, stream grpc.ServerStream) error {
+	return srv.(StateChangeSubscriptionServer).SubscribeMultiplexed(&stateChangeSubscriptionSubscribeMultiplexedServer{stream})
+}
+
+type StateChangeSubscription_SubscribeMultiplexedServer interface {
+	Send(*MultiplexedEvent) error
+	Recv() (*MultiplexedRequest, error)
+	grpc.ServerStream
+}
+
+type stateChangeSubscriptionSubscribeMultiplexedServer struct {
+	grpc.ServerStream
+}
+
+func (x *stateChangeSubscriptionSubscribeMultiplexedServer) Send(m *MultiplexedEvent) error {
+	return x.ServerStream.SendMsg(m)
+}
+
+func (x *stateChangeSubscriptionSubscribeMultiplexedServer) Recv() (*MultiplexedRequest, error) {
+	m := new(MultiplexedRequest)
+	if err := x.ServerStream.RecvMsg(m); err != nil {
+		return nil, err
+	}
+	return m, nil
+}
+
+var _StateChangeSubscription_serviceDesc = grpc.ServiceDesc{
+	ServiceName: "subscribe.StateChangeSubscription",
+	HandlerType: (*StateChangeSubscriptionServer)(nil),
+	Methods:     []grpc.MethodDesc{},
+	Streams: []grpc.StreamDesc{
+		{
+			StreamName:    "Subscribe",
+			Handler:       _StateChangeSubscription_Subscribe_Handler,
+			ServerStreams: true,
+		},
+		{
+			StreamName:    "SubscribeMultiplexed",
+			Handler:       _StateChangeSubscription_SubscribeMultiplexed_Handler,
+			ServerStreams: true,
+			ClientStreams: true,
+		},
+	},
+	Metadata: "proto/pbsubscribe/subscribe.proto",
+}
+
+func (m *SubscribeRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *SubscribeRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *SubscribeRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.ExactKey {
+		i--
+		if m.ExactKey {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x58
+	}
+	if m.ViewHash != 0 {
+		i = encodeVarintSubscribe(dAtA, i, uint64(m.ViewHash))
+		i--
+		dAtA[i] = 0x50
+	}
+	if m.HeartbeatIntervalMillis != 0 {
+		i = encodeVarintSubscribe(dAtA, i, uint64(m.HeartbeatIntervalMillis))
+		i--
+		dAtA[i] = 0x48
+	}
+	if len(m.Filter) > 0 {
+		i -= len(m.Filter)
+		copy(dAtA[i:], m.Filter)
+		i = encodeVarintSubscribe(dAtA, i, uint64(len(m.Filter)))
+		i--
+		dAtA[i] = 0x42
+	}
+	if len(m.Partition) > 0 {
+		i -= len(m.Partition)
+		copy(dAtA[i:], m.Partition)
+		i = encodeVarintSubscribe(dAtA, i, uint64(len(m.Partition)))
+		i--
+		dAtA[i] = 0x3a
+	}
+	if len(m.Namespace) > 0 {
+		i -= len(m.Namespace)
+		copy(dAtA[i:], m.Namespace)
+		i = encodeVarintSubscribe(dAtA, i, uint64(len(m.Namespace)))
+		i--
+		dAtA[i] = 0x32
+	}
+	if len(m.Datacenter) > 0 {
+		i -= len(m.Datacenter)
+		copy(dAtA[i:], m.Datacenter)
+		i = encodeVarintSubscribe(dAtA, i, uint64(len(m.Datacenter)))
+		i--
+		dAtA[i] = 0x2a
+	}
+	if m.Index != 0 {
+		i = encodeVarintSubscribe(dAtA, i, uint64(m.Index))
+		i--
+		dAtA[i] = 0x20
+	}
+	if len(m.Token) > 0 {
+		i -= len(m.Token)
+		copy(dAtA[i:], m.Token)
+		i = encodeVarintSubscribe(dAtA, i, uint64(len(m.Token)))
+		i--
+		dAtA[i] = 0x1a
+	}
+	if len(m.Key) > 0 {
+		i -= len(m.Key)
+		copy(dAtA[i:], m.Key)
+		i = encodeVarintSubscribe(dAtA, i, uint64(len(m.Key)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.Topic != 0 {
+		i = encodeVarintSubscribe(dAtA, i, uint64(m.Topic))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *MultiplexedRequest) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *MultiplexedRequest) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *MultiplexedRequest) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.Op != nil {
+		{
+			size := m.Op.Size()
+			i -= size
+			if _, err := m.Op.MarshalTo(dAtA[i:]); err != nil {
+				return 0, err
+			}
+		}
+	}
+	if m.ID != 0 {
+		i = encodeVarintSubscribe(dAtA, i, uint64(m.ID))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *MultiplexedRequest_Subscribe) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *MultiplexedRequest_Subscribe) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	if m.Subscribe != nil {
+		{
+			size, err := m.Subscribe.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintSubscribe(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	return len(dAtA) - i, nil
+}
+func (m *MultiplexedRequest_Unsubscribe) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *MultiplexedRequest_Unsubscribe) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	i--
+	if m.Unsubscribe {
+		dAtA[i] = 1
+	} else {
+		dAtA[i] = 0
+	}
+	i--
+	dAtA[i] = 0x18
+	return len(dAtA) - i, nil
+}
+func (m *MultiplexedEvent) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *MultiplexedEvent) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *MultiplexedEvent) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.Payload != nil {
+		{
+			size := m.Payload.Size()
+			i -= size
+			if _, err := m.Payload.MarshalTo(dAtA[i:]); err != nil {
+				return 0, err
+			}
+		}
+	}
+	if m.ID != 0 {
+		i = encodeVarintSubscribe(dAtA, i, uint64(m.ID))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *MultiplexedEvent_Event) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *MultiplexedEvent_Event) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	if m.Event != nil {
+		{
+			size, err := m.Event.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintSubscribe(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	return len(dAtA) - i, nil
+}
+func (m *MultiplexedEvent_Error) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *MultiplexedEvent_Error) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	if m.Error != nil {
+		{
+			size, err := m.Error.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintSubscribe(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1a
+	}
+	return len(dAtA) - i, nil
+}
+func (m *SubscriptionError) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *SubscriptionError) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *SubscriptionError) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if len(m.Message) > 0 {
+		i -= len(m.Message)
+		copy(dAtA[i:], m.Message)
+		i = encodeVarintSubscribe(dAtA, i, uint64(len(m.Message)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.Code != 0 {
+		i = encodeVarintSubscribe(dAtA, i, uint64(m.Code))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *Event) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Event) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Event) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.Payload != nil {
+		{
+			size := m.Payload.Size()
+			i -= size
+			if _, err := m.Payload.MarshalTo(dAtA[i:]); err != nil {
+				return 0, err
+			}
+		}
+	}
+	if m.Index != 0 {
+		i = encodeVarintSubscribe(dAtA, i, uint64(m.Index))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *Event_EndOfSnapshot) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Event_EndOfSnapshot) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	i--
+	if m.EndOfSnapshot {
+		dAtA[i] = 1
+	} else {
+		dAtA[i] = 0
+	}
+	i--
+	dAtA[i] = 0x10
+	return len(dAtA) - i, nil
+}
+func (m *Event_NewSnapshotToFollow) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Event_NewSnapshotToFollow) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	i--
+	if m.NewSnapshotToFollow {
+		dAtA[i] = 1
+	} else {
+		dAtA[i] = 0
+	}
+	i--
+	dAtA[i] = 0x18
+	return len(dAtA) - i, nil
+}
+func (m *Event_EventBatch) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Event_EventBatch) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	if m.EventBatch != nil {
+		{
+			size, err := m.EventBatch.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintSubscribe(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x22
+	}
+	return len(dAtA) - i, nil
+}
+func (m *Event_Heartbeat) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Event_Heartbeat) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	i--
+	if m.Heartbeat {
+		dAtA[i] = 1
+	} else {
+		dAtA[i] = 0
+	}
+	i--
+	dAtA[i] = 0x28
+	return len(dAtA) - i, nil
+}
+func (m *Event_ResumeStream) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Event_ResumeStream) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	i--
+	if m.ResumeStream {
+		dAtA[i] = 1
+	} else {
+		dAtA[i] = 0
+	}
+	i--
+	dAtA[i] = 0x30
+	return len(dAtA) - i, nil
+}
+func (m *Event_ServiceHealth) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Event_ServiceHealth) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	if m.ServiceHealth != nil {
+		{
+			size, err := m.ServiceHealth.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintSubscribe(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x52
+	}
+	return len(dAtA) - i, nil
+}
+func (m *Event_KV) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Event_KV) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	if m.KV != nil {
+		{
+			size, err := m.KV.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintSubscribe(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x5a
+	}
+	return len(dAtA) - i, nil
+}
+func (m *Event_CatalogService) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Event_CatalogService) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	if m.CatalogService != nil {
+		{
+			size, err := m.CatalogService.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintSubscribe(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x62
+	}
+	return len(dAtA) - i, nil
+}
+func (m *Event_Intention) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Event_Intention) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	if m.Intention != nil {
+		{
+			size, err := m.Intention.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintSubscribe(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x6a
+	}
+	return len(dAtA) - i, nil
+}
+func (m *Event_ConfigEntry) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Event_ConfigEntry) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	if m.ConfigEntry != nil {
+		{
+			size, err := m.ConfigEntry.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintSubscribe(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x72
+	}
+	return len(dAtA) - i, nil
+}
+func (m *Event_Node) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Event_Node) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	if m.Node != nil {
+		{
+			size, err := m.Node.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintSubscribe(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x7a
+	}
+	return len(dAtA) - i, nil
+}
+func (m *EventBatch) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *EventBatch) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *EventBatch) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if len(m.Events) > 0 {
+		for iNdEx := len(m.Events) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Events[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintSubscribe(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0xa
+		}
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ServiceHealthUpdate) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ServiceHealthUpdate) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ServiceHealthUpdate) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.CheckServiceNode != nil {
+		{
+			size, err := m.CheckServiceNode.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintSubscribe(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.Op != 0 {
+		i = encodeVarintSubscribe(dAtA, i, uint64(m.Op))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *CatalogServiceUpdate) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *CatalogServiceUpdate) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *CatalogServiceUpdate) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.EnterpriseMeta != nil {
+		{
+			size, err := m.EnterpriseMeta.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintSubscribe(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x32
+	}
+	if len(m.ServiceTags) > 0 {
+		for iNdEx := len(m.ServiceTags) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.ServiceTags[iNdEx])
+			copy(dAtA[i:], m.ServiceTags[iNdEx])
+			i = encodeVarintSubscribe(dAtA, i, uint64(len(m.ServiceTags[iNdEx])))
+			i--
+			dAtA[i] = 0x2a
+		}
+	}
+	if len(m.ServiceName) > 0 {
+		i -= len(m.ServiceName)
+		copy(dAtA[i:], m.ServiceName)
+		i = encodeVarintSubscribe(dAtA, i, uint64(len(m.ServiceName)))
+		i--
+		dAtA[i] = 0x22
+	}
+	if len(m.ServiceID) > 0 {
+		i -= len(m.ServiceID)
+		copy(dAtA[i:], m.ServiceID)
+		i = encodeVarintSubscribe(dAtA, i, uint64(len(m.ServiceID)))
+		i--
+		dAtA[i] = 0x1a
+	}
+	if len(m.Node) > 0 {
+		i -= len(m.Node)
+		copy(dAtA[i:], m.Node)
+		i = encodeVarintSubscribe(dAtA, i, uint64(len(m.Node)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.Op != 0 {
+		i = encodeVarintSubscribe(dAtA, i, uint64(m.Op))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *NodeUpdate) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *NodeUpdate) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *NodeUpdate) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.Check != nil {
+		{
+			size, err := m.Check.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintSubscribe(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x2a
+	}
+	if m.Service != nil {
+		{
+			size, err := m.Service.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintSubscribe(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x22
+	}
+	if m.Node != nil {
+		{
+			size, err := m.Node.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintSubscribe(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1a
+	}
+	if len(m.NodeName) > 0 {
+		i -= len(m.NodeName)
+		copy(dAtA[i:], m.NodeName)
+		i = encodeVarintSubscribe(dAtA, i, uint64(len(m.NodeName)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.Op != 0 {
+		i = encodeVarintSubscribe(dAtA, i, uint64(m.Op))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *KVUpdate) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *KVUpdate) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *KVUpdate) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.Entry != nil {
+		{
+			size, err := m.Entry.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintSubscribe(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.Op != 0 {
+		i = encodeVarintSubscribe(dAtA, i, uint64(m.Op))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *KVEntry) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *KVEntry) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *KVEntry) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.EnterpriseMeta != nil {
+		{
+			size, err := m.EnterpriseMeta.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintSubscribe(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x42
+	}
+	if m.ModifyIndex != 0 {
+		i = encodeVarintSubscribe(dAtA, i, uint64(m.ModifyIndex))
+		i--
+		dAtA[i] = 0x38
+	}
+	if m.CreateIndex != 0 {
+		i = encodeVarintSubscribe(dAtA, i, uint64(m.CreateIndex))
+		i--
+		dAtA[i] = 0x30
+	}
+	if m.LockIndex != 0 {
+		i = encodeVarintSubscribe(dAtA, i, uint64(m.LockIndex))
+		i--
+		dAtA[i] = 0x28
+	}
+	if len(m.Session) > 0 {
+		i -= len(m.Session)
+		copy(dAtA[i:], m.Session)
+		i = encodeVarintSubscribe(dAtA, i, uint64(len(m.Session)))
+		i--
+		dAtA[i] = 0x22
+	}
+	if m.Flags != 0 {
+		i = encodeVarintSubscribe(dAtA, i, uint64(m.Flags))
+		i--
+		dAtA[i] = 0x18
+	}
+	if len(m.Value) > 0 {
+		i -= len(m.Value)
+		copy(dAtA[i:], m.Value)
+		i = encodeVarintSubscribe(dAtA, i, uint64(len(m.Value)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.Key) > 0 {
+		i -= len(m.Key)
+		copy(dAtA[i:], m.Key)
+		i = encodeVarintSubscribe(dAtA, i, uint64(len(m.Key)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *IntentionUpdate) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *IntentionUpdate) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *IntentionUpdate) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.Intention != nil {
+		{
+			size, err := m.Intention.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintSubscribe(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.Op != 0 {
+		i = encodeVarintSubscribe(dAtA, i, uint64(m.Op))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *Intention) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *Intention) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *Intention) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.ModifyIndex != 0 {
+		i = encodeVarintSubscribe(dAtA, i, uint64(m.ModifyIndex))
+		i--
+		dAtA[i] = 0x1
+		i--
+		dAtA[i] = 0xa0
+	}
+	if m.CreateIndex != 0 {
+		i = encodeVarintSubscribe(dAtA, i, uint64(m.CreateIndex))
+		i--
+		dAtA[i] = 0x1
+		i--
+		dAtA[i] = 0x98
+	}
+	if len(m.Hash) > 0 {
+		i -= len(m.Hash)
+		copy(dAtA[i:], m.Hash)
+		i = encodeVarintSubscribe(dAtA, i, uint64(len(m.Hash)))
+		i--
+		dAtA[i] = 0x1
+		i--
+		dAtA[i] = 0x92
+	}
+	if m.UpdatedAt != nil {
+		{
+			size, err := m.UpdatedAt.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintSubscribe(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1
+		i--
+		dAtA[i] = 0x8a
+	}
+	if m.CreatedAt != nil {
+		{
+			size, err := m.CreatedAt.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintSubscribe(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x1
+		i--
+		dAtA[i] = 0x82
+	}
+	if m.Precedence != 0 {
+		i = encodeVarintSubscribe(dAtA, i, uint64(m.Precedence))
+		i--
+		dAtA[i] = 0x78
+	}
+	if len(m.Meta) > 0 {
+		for k := range m.Meta {
+			v := m.Meta[k]
+			baseI := i
+			i -= len(v)
+			copy(dAtA[i:], v)
+			i = encodeVarintSubscribe(dAtA, i, uint64(len(v)))
+			i--
+			dAtA[i] = 0x12
+			i -= len(k)
+			copy(dAtA[i:], k)
+			i = encodeVarintSubscribe(dAtA, i, uint64(len(k)))
+			i--
+			dAtA[i] = 0xa
+			i = encodeVarintSubscribe(dAtA, i, uint64(baseI-i))
+			i--
+			dAtA[i] = 0x72
+		}
+	}
+	if m.DefaultPort != 0 {
+		i = encodeVarintSubscribe(dAtA, i, uint64(m.DefaultPort))
+		i--
+		dAtA[i] = 0x68
+	}
+	if len(m.DefaultAddr) > 0 {
+		i -= len(m.DefaultAddr)
+		copy(dAtA[i:], m.DefaultAddr)
+		i = encodeVarintSubscribe(dAtA, i, uint64(len(m.DefaultAddr)))
+		i--
+		dAtA[i] = 0x62
+	}
+	if len(m.Permissions) > 0 {
+		for iNdEx := len(m.Permissions) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Permissions[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintSubscribe(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x5a
+		}
+	}
+	if len(m.Action) > 0 {
+		i -= len(m.Action)
+		copy(dAtA[i:], m.Action)
+		i = encodeVarintSubscribe(dAtA, i, uint64(len(m.Action)))
+		i--
+		dAtA[i] = 0x52
+	}
+	if len(m.SourceType) > 0 {
+		i -= len(m.SourceType)
+		copy(dAtA[i:], m.SourceType)
+		i = encodeVarintSubscribe(dAtA, i, uint64(len(m.SourceType)))
+		i--
+		dAtA[i] = 0x4a
+	}
+	if len(m.DestinationName) > 0 {
+		i -= len(m.DestinationName)
+		copy(dAtA[i:], m.DestinationName)
+		i = encodeVarintSubscribe(dAtA, i, uint64(len(m.DestinationName)))
+		i--
+		dAtA[i] = 0x42
+	}
+	if len(m.DestinationNS) > 0 {
+		i -= len(m.DestinationNS)
+		copy(dAtA[i:], m.DestinationNS)
+		i = encodeVarintSubscribe(dAtA, i, uint64(len(m.DestinationNS)))
+		i--
+		dAtA[i] = 0x3a
+	}
+	if len(m.DestinationPartition) > 0 {
+		i -= len(m.DestinationPartition)
+		copy(dAtA[i:], m.DestinationPartition)
+		i = encodeVarintSubscribe(dAtA, i, uint64(len(m.DestinationPartition)))
+		i--
+		dAtA[i] = 0x32
+	}
+	if len(m.SourceName) > 0 {
+		i -= len(m.SourceName)
+		copy(dAtA[i:], m.SourceName)
+		i = encodeVarintSubscribe(dAtA, i, uint64(len(m.SourceName)))
+		i--
+		dAtA[i] = 0x2a
+	}
+	if len(m.SourceNS) > 0 {
+		i -= len(m.SourceNS)
+		copy(dAtA[i:], m.SourceNS)
+		i = encodeVarintSubscribe(dAtA, i, uint64(len(m.SourceNS)))
+		i--
+		dAtA[i] = 0x22
+	}
+	if len(m.SourcePartition) > 0 {
+		i -= len(m.SourcePartition)
+		copy(dAtA[i:], m.SourcePartition)
+		i = encodeVarintSubscribe(dAtA, i, uint64(len(m.SourcePartition)))
+		i--
+		dAtA[i] = 0x1a
+	}
+	if len(m.Description) > 0 {
+		i -= len(m.Description)
+		copy(dAtA[i:], m.Description)
+		i = encodeVarintSubscribe(dAtA, i, uint64(len(m.Description)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.ID) > 0 {
+		i -= len(m.ID)
+		copy(dAtA[i:], m.ID)
+		i = encodeVarintSubscribe(dAtA, i, uint64(len(m.ID)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *IntentionPermission) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *IntentionPermission) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *IntentionPermission) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.HTTP != nil {
+		{
+			size, err := m.HTTP.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintSubscribe(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.Action) > 0 {
+		i -= len(m.Action)
+		copy(dAtA[i:], m.Action)
+		i = encodeVarintSubscribe(dAtA, i, uint64(len(m.Action)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *IntentionHTTPPermission) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *IntentionHTTPPermission) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *IntentionHTTPPermission) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if len(m.Methods) > 0 {
+		for iNdEx := len(m.Methods) - 1; iNdEx >= 0; iNdEx-- {
+			i -= len(m.Methods[iNdEx])
+			copy(dAtA[i:], m.Methods[iNdEx])
+			i = encodeVarintSubscribe(dAtA, i, uint64(len(m.Methods[iNdEx])))
+			i--
+			dAtA[i] = 0x2a
+		}
+	}
+	if len(m.Header) > 0 {
+		for iNdEx := len(m.Header) - 1; iNdEx >= 0; iNdEx-- {
+			{
+				size, err := m.Header[iNdEx].MarshalToSizedBuffer(dAtA[:i])
+				if err != nil {
+					return 0, err
+				}
+				i -= size
+				i = encodeVarintSubscribe(dAtA, i, uint64(size))
+			}
+			i--
+			dAtA[i] = 0x22
+		}
+	}
+	if len(m.PathRegex) > 0 {
+		i -= len(m.PathRegex)
+		copy(dAtA[i:], m.PathRegex)
+		i = encodeVarintSubscribe(dAtA, i, uint64(len(m.PathRegex)))
+		i--
+		dAtA[i] = 0x1a
+	}
+	if len(m.PathPrefix) > 0 {
+		i -= len(m.PathPrefix)
+		copy(dAtA[i:], m.PathPrefix)
+		i = encodeVarintSubscribe(dAtA, i, uint64(len(m.PathPrefix)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if len(m.PathExact) > 0 {
+		i -= len(m.PathExact)
+		copy(dAtA[i:], m.PathExact)
+		i = encodeVarintSubscribe(dAtA, i, uint64(len(m.PathExact)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *IntentionHTTPHeaderPermission) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *IntentionHTTPHeaderPermission) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *IntentionHTTPHeaderPermission) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if m.Invert {
+		i--
+		if m.Invert {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x38
+	}
+	if len(m.Regex) > 0 {
+		i -= len(m.Regex)
+		copy(dAtA[i:], m.Regex)
+		i = encodeVarintSubscribe(dAtA, i, uint64(len(m.Regex)))
+		i--
+		dAtA[i] = 0x32
+	}
+	if len(m.Suffix) > 0 {
+		i -= len(m.Suffix)
+		copy(dAtA[i:], m.Suffix)
+		i = encodeVarintSubscribe(dAtA, i, uint64(len(m.Suffix)))
+		i--
+		dAtA[i] = 0x2a
+	}
+	if len(m.Prefix) > 0 {
+		i -= len(m.Prefix)
+		copy(dAtA[i:], m.Prefix)
+		i = encodeVarintSubscribe(dAtA, i, uint64(len(m.Prefix)))
+		i--
+		dAtA[i] = 0x22
+	}
+	if len(m.Exact) > 0 {
+		i -= len(m.Exact)
+		copy(dAtA[i:], m.Exact)
+		i = encodeVarintSubscribe(dAtA, i, uint64(len(m.Exact)))
+		i--
+		dAtA[i] = 0x1a
+	}
+	if m.Present {
+		i--
+		if m.Present {
+			dAtA[i] = 1
+		} else {
+			dAtA[i] = 0
+		}
+		i--
+		dAtA[i] = 0x10
+	}
+	if len(m.Name) > 0 {
+		i -= len(m.Name)
+		copy(dAtA[i:], m.Name)
+		i = encodeVarintSubscribe(dAtA, i, uint64(len(m.Name)))
+		i--
+		dAtA[i] = 0xa
+	}
+	return len(dAtA) - i, nil
+}
+
+func (m *ConfigEntryUpdate) Marshal() (dAtA []byte, err error) {
+	size := m.Size()
+	dAtA = make([]byte, size)
+	n, err := m.MarshalToSizedBuffer(dAtA[:size])
+	if err != nil {
+		return nil, err
+	}
+	return dAtA[:n], nil
+}
+
+func (m *ConfigEntryUpdate) MarshalTo(dAtA []byte) (int, error) {
+	size := m.Size()
+	return m.MarshalToSizedBuffer(dAtA[:size])
+}
+
+func (m *ConfigEntryUpdate) MarshalToSizedBuffer(dAtA []byte) (int, error) {
+	i := len(dAtA)
+	_ = i
+	var l int
+	_ = l
+	if m.XXX_unrecognized != nil {
+		i -= len(m.XXX_unrecognized)
+		copy(dAtA[i:], m.XXX_unrecognized)
+	}
+	if len(m.Entry) > 0 {
+		i -= len(m.Entry)
+		copy(dAtA[i:], m.Entry)
+		i = encodeVarintSubscribe(dAtA, i, uint64(len(m.Entry)))
+		i--
+		dAtA[i] = 0x2a
+	}
+	if m.EnterpriseMeta != nil {
+		{
+			size, err := m.EnterpriseMeta.MarshalToSizedBuffer(dAtA[:i])
+			if err != nil {
+				return 0, err
+			}
+			i -= size
+			i = encodeVarintSubscribe(dAtA, i, uint64(size))
+		}
+		i--
+		dAtA[i] = 0x22
+	}
+	if len(m.Name) > 0 {
+		i -= len(m.Name)
+		copy(dAtA[i:], m.Name)
+		i = encodeVarintSubscribe(dAtA, i, uint64(len(m.Name)))
+		i--
+		dAtA[i] = 0x1a
+	}
+	if len(m.Kind) > 0 {
+		i -= len(m.Kind)
+		copy(dAtA[i:], m.Kind)
+		i = encodeVarintSubscribe(dAtA, i, uint64(len(m.Kind)))
+		i--
+		dAtA[i] = 0x12
+	}
+	if m.Op != 0 {
+		i = encodeVarintSubscribe(dAtA, i, uint64(m.Op))
+		i--
+		dAtA[i] = 0x8
+	}
+	return len(dAtA) - i, nil
+}
+
+func encodeVarintSubscribe(dAtA []byte, offset int, v uint64) int {
+	offset -= sovSubscribe(v)
+	base := offset
+	for v >= 1<<7 {
+		dAtA[offset] = uint8(v&0x7f | 0x80)
+		v >>= 7
+		offset++
+	}
+	dAtA[offset] = uint8(v)
+	return base
+}
+func (m *SubscribeRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Topic != 0 {
+		n += 1 + sovSubscribe(uint64(m.Topic))
+	}
+	l = len(m.Key)
+	if l > 0 {
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	l = len(m.Token)
+	if l > 0 {
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	if m.Index != 0 {
+		n += 1 + sovSubscribe(uint64(m.Index))
+	}
+	l = len(m.Datacenter)
+	if l > 0 {
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	l = len(m.Namespace)
+	if l > 0 {
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	l = len(m.Partition)
+	if l > 0 {
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	l = len(m.Filter)
+	if l > 0 {
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	if m.HeartbeatIntervalMillis != 0 {
+		n += 1 + sovSubscribe(uint64(m.HeartbeatIntervalMillis))
+	}
+	if m.ViewHash != 0 {
+		n += 1 + sovSubscribe(uint64(m.ViewHash))
+	}
+	if m.ExactKey {
+		n += 2
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *MultiplexedRequest) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.ID != 0 {
+		n += 1 + sovSubscribe(uint64(m.ID))
+	}
+	if m.Op != nil {
+		n += m.Op.Size()
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *MultiplexedRequest_Subscribe) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Subscribe != nil {
+		l = m.Subscribe.Size()
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	return n
+}
+func (m *MultiplexedRequest_Unsubscribe) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	n += 2
+	return n
+}
+func (m *MultiplexedEvent) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.ID != 0 {
+		n += 1 + sovSubscribe(uint64(m.ID))
+	}
+	if m.Payload != nil {
+		n += m.Payload.Size()
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *MultiplexedEvent_Event) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Event != nil {
+		l = m.Event.Size()
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	return n
+}
+func (m *MultiplexedEvent_Error) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Error != nil {
+		l = m.Error.Size()
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	return n
+}
+func (m *SubscriptionError) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Code != 0 {
+		n += 1 + sovSubscribe(uint64(m.Code))
+	}
+	l = len(m.Message)
+	if l > 0 {
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *Event) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Index != 0 {
+		n += 1 + sovSubscribe(uint64(m.Index))
+	}
+	if m.Payload != nil {
+		n += m.Payload.Size()
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *Event_EndOfSnapshot) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	n += 2
+	return n
+}
+func (m *Event_NewSnapshotToFollow) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	n += 2
+	return n
+}
+func (m *Event_EventBatch) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.EventBatch != nil {
+		l = m.EventBatch.Size()
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	return n
+}
+func (m *Event_Heartbeat) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	n += 2
+	return n
+}
+func (m *Event_ResumeStream) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	n += 2
+	return n
+}
+func (m *Event_ServiceHealth) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.ServiceHealth != nil {
+		l = m.ServiceHealth.Size()
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	return n
+}
+func (m *Event_KV) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.KV != nil {
+		l = m.KV.Size()
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	return n
+}
+func (m *Event_CatalogService) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.CatalogService != nil {
+		l = m.CatalogService.Size()
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	return n
+}
+func (m *Event_Intention) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Intention != nil {
+		l = m.Intention.Size()
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	return n
+}
+func (m *Event_ConfigEntry) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.ConfigEntry != nil {
+		l = m.ConfigEntry.Size()
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	return n
+}
+func (m *Event_Node) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Node != nil {
+		l = m.Node.Size()
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	return n
+}
+func (m *EventBatch) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if len(m.Events) > 0 {
+		for _, e := range m.Events {
+			l = e.Size()
+			n += 1 + l + sovSubscribe(uint64(l))
+		}
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *ServiceHealthUpdate) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Op != 0 {
+		n += 1 + sovSubscribe(uint64(m.Op))
+	}
+	if m.CheckServiceNode != nil {
+		l = m.CheckServiceNode.Size()
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *CatalogServiceUpdate) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Op != 0 {
+		n += 1 + sovSubscribe(uint64(m.Op))
+	}
+	l = len(m.Node)
+	if l > 0 {
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	l = len(m.ServiceID)
+	if l > 0 {
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	l = len(m.ServiceName)
+	if l > 0 {
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	if len(m.ServiceTags) > 0 {
+		for _, s := range m.ServiceTags {
+			l = len(s)
+			n += 1 + l + sovSubscribe(uint64(l))
+		}
+	}
+	if m.EnterpriseMeta != nil {
+		l = m.EnterpriseMeta.Size()
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *NodeUpdate) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Op != 0 {
+		n += 1 + sovSubscribe(uint64(m.Op))
+	}
+	l = len(m.NodeName)
+	if l > 0 {
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	if m.Node != nil {
+		l = m.Node.Size()
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	if m.Service != nil {
+		l = m.Service.Size()
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	if m.Check != nil {
+		l = m.Check.Size()
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *KVUpdate) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Op != 0 {
+		n += 1 + sovSubscribe(uint64(m.Op))
+	}
+	if m.Entry != nil {
+		l = m.Entry.Size()
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *KVEntry) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Key)
+	if l > 0 {
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	l = len(m.Value)
+	if l > 0 {
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	if m.Flags != 0 {
+		n += 1 + sovSubscribe(uint64(m.Flags))
+	}
+	l = len(m.Session)
+	if l > 0 {
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	if m.LockIndex != 0 {
+		n += 1 + sovSubscribe(uint64(m.LockIndex))
+	}
+	if m.CreateIndex != 0 {
+		n += 1 + sovSubscribe(uint64(m.CreateIndex))
+	}
+	if m.ModifyIndex != 0 {
+		n += 1 + sovSubscribe(uint64(m.ModifyIndex))
+	}
+	if m.EnterpriseMeta != nil {
+		l = m.EnterpriseMeta.Size()
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *IntentionUpdate) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Op != 0 {
+		n += 1 + sovSubscribe(uint64(m.Op))
+	}
+	if m.Intention != nil {
+		l = m.Intention.Size()
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *Intention) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.ID)
+	if l > 0 {
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	l = len(m.Description)
+	if l > 0 {
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	l = len(m.SourcePartition)
+	if l > 0 {
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	l = len(m.SourceNS)
+	if l > 0 {
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	l = len(m.SourceName)
+	if l > 0 {
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	l = len(m.DestinationPartition)
+	if l > 0 {
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	l = len(m.DestinationNS)
+	if l > 0 {
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	l = len(m.DestinationName)
+	if l > 0 {
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	l = len(m.SourceType)
+	if l > 0 {
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	l = len(m.Action)
+	if l > 0 {
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	if len(m.Permissions) > 0 {
+		for _, e := range m.Permissions {
+			l = e.Size()
+			n += 1 + l + sovSubscribe(uint64(l))
+		}
+	}
+	l = len(m.DefaultAddr)
+	if l > 0 {
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	if m.DefaultPort != 0 {
+		n += 1 + sovSubscribe(uint64(m.DefaultPort))
+	}
+	if len(m.Meta) > 0 {
+		for k, v := range m.Meta {
+			_ = k
+			_ = v
+			mapEntrySize := 1 + len(k) + sovSubscribe(uint64(len(k))) + 1 + len(v) + sovSubscribe(uint64(len(v)))
+			n += mapEntrySize + 1 + sovSubscribe(uint64(mapEntrySize))
+		}
+	}
+	if m.Precedence != 0 {
+		n += 1 + sovSubscribe(uint64(m.Precedence))
+	}
+	if m.CreatedAt != nil {
+		l = m.CreatedAt.Size()
+		n += 2 + l + sovSubscribe(uint64(l))
+	}
+	if m.UpdatedAt != nil {
+		l = m.UpdatedAt.Size()
+		n += 2 + l + sovSubscribe(uint64(l))
+	}
+	l = len(m.Hash)
+	if l > 0 {
+		n += 2 + l + sovSubscribe(uint64(l))
+	}
+	if m.CreateIndex != 0 {
+		n += 2 + sovSubscribe(uint64(m.CreateIndex))
+	}
+	if m.ModifyIndex != 0 {
+		n += 2 + sovSubscribe(uint64(m.ModifyIndex))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *IntentionPermission) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Action)
+	if l > 0 {
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	if m.HTTP != nil {
+		l = m.HTTP.Size()
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *IntentionHTTPPermission) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.PathExact)
+	if l > 0 {
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	l = len(m.PathPrefix)
+	if l > 0 {
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	l = len(m.PathRegex)
+	if l > 0 {
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	if len(m.Header) > 0 {
+		for _, e := range m.Header {
+			l = e.Size()
+			n += 1 + l + sovSubscribe(uint64(l))
+		}
+	}
+	if len(m.Methods) > 0 {
+		for _, s := range m.Methods {
+			l = len(s)
+			n += 1 + l + sovSubscribe(uint64(l))
+		}
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *IntentionHTTPHeaderPermission) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	l = len(m.Name)
+	if l > 0 {
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	if m.Present {
+		n += 2
+	}
+	l = len(m.Exact)
+	if l > 0 {
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	l = len(m.Prefix)
+	if l > 0 {
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	l = len(m.Suffix)
+	if l > 0 {
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	l = len(m.Regex)
+	if l > 0 {
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	if m.Invert {
+		n += 2
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func (m *ConfigEntryUpdate) Size() (n int) {
+	if m == nil {
+		return 0
+	}
+	var l int
+	_ = l
+	if m.Op != 0 {
+		n += 1 + sovSubscribe(uint64(m.Op))
+	}
+	l = len(m.Kind)
+	if l > 0 {
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	l = len(m.Name)
+	if l > 0 {
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	if m.EnterpriseMeta != nil {
+		l = m.EnterpriseMeta.Size()
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	l = len(m.Entry)
+	if l > 0 {
+		n += 1 + l + sovSubscribe(uint64(l))
+	}
+	if m.XXX_unrecognized != nil {
+		n += len(m.XXX_unrecognized)
+	}
+	return n
+}
+
+func sovSubscribe(x uint64) (n int) {
+	return (math_bits.Len64(x|1) + 6) / 7
+}
+func sozSubscribe(x uint64) (n int) {
+	return sovSubscribe(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+}
+func (m *SubscribeRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSubscribe
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: SubscribeRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: SubscribeRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Topic", wireType)
+			}
+			m.Topic = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Topic |= Topic(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Key", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Key = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Token", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Token = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 4:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Index", wireType)
+			}
+			m.Index = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Index |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Datacenter", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Datacenter = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Namespace", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Namespace = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Partition", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Partition = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 8:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Filter", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Filter = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 9:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field HeartbeatIntervalMillis", wireType)
+			}
+			m.HeartbeatIntervalMillis = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.HeartbeatIntervalMillis |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 10:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ViewHash", wireType)
+			}
+			m.ViewHash = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ViewHash |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 11:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ExactKey", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.ExactKey = bool(v != 0)
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSubscribe(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *MultiplexedRequest) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSubscribe
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: MultiplexedRequest: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: MultiplexedRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ID", wireType)
+			}
+			m.ID = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ID |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Subscribe", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &SubscribeRequest{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Op = &MultiplexedRequest_Subscribe{v}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Unsubscribe", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			b := bool(v != 0)
+			m.Op = &MultiplexedRequest_Unsubscribe{b}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSubscribe(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *MultiplexedEvent) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSubscribe
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: MultiplexedEvent: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: MultiplexedEvent: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ID", wireType)
+			}
+			m.ID = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ID |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Event", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &Event{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Payload = &MultiplexedEvent_Event{v}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Error", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &SubscriptionError{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Payload = &MultiplexedEvent_Error{v}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSubscribe(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *SubscriptionError) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSubscribe
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: SubscriptionError: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: SubscriptionError: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Code", wireType)
+			}
+			m.Code = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Code |= uint32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Message", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Message = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSubscribe(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Event) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSubscribe
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Event: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Event: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Index", wireType)
+			}
+			m.Index = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Index |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EndOfSnapshot", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			b := bool(v != 0)
+			m.Payload = &Event_EndOfSnapshot{b}
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field NewSnapshotToFollow", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			b := bool(v != 0)
+			m.Payload = &Event_NewSnapshotToFollow{b}
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EventBatch", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &EventBatch{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Payload = &Event_EventBatch{v}
+			iNdEx = postIndex
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Heartbeat", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			b := bool(v != 0)
+			m.Payload = &Event_Heartbeat{b}
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ResumeStream", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			b := bool(v != 0)
+			m.Payload = &Event_ResumeStream{b}
+		case 10:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ServiceHealth", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &ServiceHealthUpdate{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Payload = &Event_ServiceHealth{v}
+			iNdEx = postIndex
+		case 11:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field KV", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &KVUpdate{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Payload = &Event_KV{v}
+			iNdEx = postIndex
+		case 12:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CatalogService", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &CatalogServiceUpdate{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Payload = &Event_CatalogService{v}
+			iNdEx = postIndex
+		case 13:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Intention", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &IntentionUpdate{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Payload = &Event_Intention{v}
+			iNdEx = postIndex
+		case 14:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ConfigEntry", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &ConfigEntryUpdate{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Payload = &Event_ConfigEntry{v}
+			iNdEx = postIndex
+		case 15:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Node", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			v := &NodeUpdate{}
+			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			m.Payload = &Event_Node{v}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSubscribe(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *EventBatch) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSubscribe
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: EventBatch: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: EventBatch: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Events", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Events = append(m.Events, &Event{})
+			if err := m.Events[len(m.Events)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSubscribe(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *ServiceHealthUpdate) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSubscribe
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: ServiceHealthUpdate: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: ServiceHealthUpdate: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Op", wireType)
+			}
+			m.Op = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Op |= CatalogOp(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CheckServiceNode", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.CheckServiceNode == nil {
+				m.CheckServiceNode = &pbservice.CheckServiceNode{}
+			}
+			if err := m.CheckServiceNode.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSubscribe(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *CatalogServiceUpdate) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSubscribe
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: CatalogServiceUpdate: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: CatalogServiceUpdate: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Op", wireType)
+			}
+			m.Op = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Op |= CatalogOp(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Node", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Node = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ServiceID", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ServiceID = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ServiceName", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ServiceName = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ServiceTags", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ServiceTags = append(m.ServiceTags, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EnterpriseMeta", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.EnterpriseMeta == nil {
+				m.EnterpriseMeta = &pbcommon.EnterpriseMeta{}
+			}
+			if err := m.EnterpriseMeta.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSubscribe(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *NodeUpdate) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSubscribe
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: NodeUpdate: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: NodeUpdate: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Op", wireType)
+			}
+			m.Op = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Op |= CatalogOp(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field NodeName", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.NodeName = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Node", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Node == nil {
+				m.Node = &pbservice.Node{}
+			}
+			if err := m.Node.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Service", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Service == nil {
+				m.Service = &pbservice.NodeService{}
+			}
+			if err := m.Service.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Check", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Check == nil {
+				m.Check = &pbservice.HealthCheck{}
+			}
+			if err := m.Check.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSubscribe(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *KVUpdate) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSubscribe
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: KVUpdate: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: KVUpdate: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Op", wireType)
+			}
+			m.Op = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Op |= UpdateOp(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Entry", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Entry == nil {
+				m.Entry = &KVEntry{}
+			}
+			if err := m.Entry.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSubscribe(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *KVEntry) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSubscribe
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: KVEntry: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: KVEntry: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Key", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Key = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Value", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Value = append(m.Value[:0], dAtA[iNdEx:postIndex]...)
+			if m.Value == nil {
+				m.Value = []byte{}
+			}
+			iNdEx = postIndex
+		case 3:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Flags", wireType)
+			}
+			m.Flags = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Flags |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Session", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Session = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 5:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field LockIndex", wireType)
+			}
+			m.LockIndex = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.LockIndex |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 6:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CreateIndex", wireType)
+			}
+			m.CreateIndex = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.CreateIndex |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ModifyIndex", wireType)
+			}
+			m.ModifyIndex = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ModifyIndex |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 8:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field EnterpriseMeta", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.EnterpriseMeta == nil {
+				m.EnterpriseMeta = &pbcommon.EnterpriseMeta{}
+			}
+			if err := m.EnterpriseMeta.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSubscribe(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *IntentionUpdate) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSubscribe
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: IntentionUpdate: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: IntentionUpdate: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Op", wireType)
+			}
+			m.Op = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Op |= UpdateOp(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Intention", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Intention == nil {
+				m.Intention = &Intention{}
+			}
+			if err := m.Intention.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSubscribe(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *Intention) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSubscribe
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: Intention: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: Intention: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ID", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.ID = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Description", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Description = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SourcePartition", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.SourcePartition = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SourceNS", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.SourceNS = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SourceName", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.SourceName = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 6:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DestinationPartition", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.DestinationPartition = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 7:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DestinationNS", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.DestinationNS = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 8:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DestinationName", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.DestinationName = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 9:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field SourceType", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.SourceType = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 10:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Action", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Action = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 11:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Permissions", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Permissions = append(m.Permissions, &IntentionPermission{})
+			if err := m.Permissions[len(m.Permissions)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 12:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DefaultAddr", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.DefaultAddr = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 13:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field DefaultPort", wireType)
+			}
+			m.DefaultPort = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.DefaultPort |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 14:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Meta", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.Meta == nil {
+				m.Meta = make(map[string]string)
+			}
+			var mapkey string
+			var mapvalue string
+			for iNdEx < postIndex {
+				entryPreIndex := iNdEx
+				var wire uint64
+				for shift := uint(0); ; shift += 7 {
+					if shift >= 64 {
+						return ErrIntOverflowSubscribe
+					}
+					if iNdEx >= l {
+						return io.ErrUnexpectedEOF
+					}
+					b := dAtA[iNdEx]
+					iNdEx++
+					wire |= uint64(b&0x7F) << shift
+					if b < 0x80 {
+						break
+					}
+				}
+				fieldNum := int32(wire >> 3)
+				if fieldNum == 1 {
+					var stringLenmapkey uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowSubscribe
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapkey |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapkey := int(stringLenmapkey)
+					if intStringLenmapkey < 0 {
+						return ErrInvalidLengthSubscribe
+					}
+					postStringIndexmapkey := iNdEx + intStringLenmapkey
+					if postStringIndexmapkey < 0 {
+						return ErrInvalidLengthSubscribe
+					}
+					if postStringIndexmapkey > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapkey = string(dAtA[iNdEx:postStringIndexmapkey])
+					iNdEx = postStringIndexmapkey
+				} else if fieldNum == 2 {
+					var stringLenmapvalue uint64
+					for shift := uint(0); ; shift += 7 {
+						if shift >= 64 {
+							return ErrIntOverflowSubscribe
+						}
+						if iNdEx >= l {
+							return io.ErrUnexpectedEOF
+						}
+						b := dAtA[iNdEx]
+						iNdEx++
+						stringLenmapvalue |= uint64(b&0x7F) << shift
+						if b < 0x80 {
+							break
+						}
+					}
+					intStringLenmapvalue := int(stringLenmapvalue)
+					if intStringLenmapvalue < 0 {
+						return ErrInvalidLengthSubscribe
+					}
+					postStringIndexmapvalue := iNdEx + intStringLenmapvalue
+					if postStringIndexmapvalue < 0 {
+						return ErrInvalidLengthSubscribe
+					}
+					if postStringIndexmapvalue > l {
+						return io.ErrUnexpectedEOF
+					}
+					mapvalue = string(dAtA[iNdEx:postStringIndexmapvalue])
+					iNdEx = postStringIndexmapvalue
+				} else {
+					iNdEx = entryPreIndex
+					skippy, err := skipSubscribe(dAtA[iNdEx:])
+					if err != nil {
+						return err
+					}
+					if (skippy < 0) || (iNdEx+skippy) < 0 {
+						return ErrInvalidLengthSubscribe
+					}
+					if (iNdEx + skippy) > postIndex {
+						return io.ErrUnexpectedEOF
+					}
+					iNdEx += skippy
+				}
+			}
+			m.Meta[mapkey] = mapvalue
+			iNdEx = postIndex
+		case 15:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Precedence", wireType)
+			}
+			m.Precedence = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.Precedence |= int32(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 16:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CreatedAt", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.CreatedAt == nil {
+				m.CreatedAt = &types.Timestamp{}
+			}
+			if err := m.CreatedAt.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 17:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field UpdatedAt", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.UpdatedAt == nil {
+				m.UpdatedAt = &types.Timestamp{}
+			}
+			if err := m.UpdatedAt.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 18:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Hash", wireType)
+			}
+			var byteLen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				byteLen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if byteLen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + byteLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Hash = append(m.Hash[:0], dAtA[iNdEx:postIndex]...)
+			if m.Hash == nil {
+				m.Hash = []byte{}
+			}
+			iNdEx = postIndex
+		case 19:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field CreateIndex", wireType)
+			}
+			m.CreateIndex = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.CreateIndex |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		case 20:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field ModifyIndex", wireType)
+			}
+			m.ModifyIndex = 0
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				m.ModifyIndex |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSubscribe(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *IntentionPermission) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSubscribe
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: IntentionPermission: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: IntentionPermission: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Action", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Action = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field HTTP", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			if m.HTTP == nil {
+				m.HTTP = &IntentionHTTPPermission{}
+			}
+			if err := m.HTTP.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSubscribe(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
 		}
 	}
-	if m.XXX_unrecognized != nil {
-		n += len(m.XXX_unrecognized)
-	}
-	return n
-}
 
-func (m *ServiceHealthUpdate) Size() (n int) {
-	if m == nil {
-		return 0
-	}
-	var l int
-	_ = l
-	if m.Op != 0 {
-		n += 1 + sovSubscribe(uint64(m.Op))
-	}
-	if m.CheckServiceNode != nil {
-		l = m.CheckServiceNode.Size()
-		n += 1 + l + sovSubscribe(uint64(l))
-	}
-	if m.XXX_unrecognized != nil {
-		n += len(m.XXX_unrecognized)
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
 	}
-	return n
-}
-
-func sovSubscribe(x uint64) (n int) {
-	return (math_bits.Len64(x|1) + 6) / 7
-}
-func sozSubscribe(x uint64) (n int) {
-	return sovSubscribe(uint64((x << 1) ^ uint64((int64(x) >> 63))))
+	return nil
 }
-func (m *SubscribeRequest) Unmarshal(dAtA []byte) error {
+func (m *IntentionHTTPPermission) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1084,17 +7421,17 @@ func (m *SubscribeRequest) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: SubscribeRequest: wiretype end group for non-group")
+			return fmt.Errorf("proto: IntentionHTTPPermission: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: SubscribeRequest: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: IntentionHTTPPermission: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Topic", wireType)
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field PathExact", wireType)
 			}
-			m.Topic = 0
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowSubscribe
@@ -1104,14 +7441,27 @@ func (m *SubscribeRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Topic |= Topic(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.PathExact = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
 		case 2:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Key", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field PathPrefix", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -1139,11 +7489,11 @@ func (m *SubscribeRequest) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Key = string(dAtA[iNdEx:postIndex])
+			m.PathPrefix = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
 		case 3:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Token", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field PathRegex", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -1171,13 +7521,162 @@ func (m *SubscribeRequest) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Token = string(dAtA[iNdEx:postIndex])
+			m.PathRegex = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
 		case 4:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Header", wireType)
+			}
+			var msglen int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				msglen |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			if msglen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + msglen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Header = append(m.Header, &IntentionHTTPHeaderPermission{})
+			if err := m.Header[len(m.Header)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
+				return err
+			}
+			iNdEx = postIndex
+		case 5:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Methods", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Methods = append(m.Methods, string(dAtA[iNdEx:postIndex]))
+			iNdEx = postIndex
+		default:
+			iNdEx = preIndex
+			skippy, err := skipSubscribe(dAtA[iNdEx:])
+			if err != nil {
+				return err
+			}
+			if (skippy < 0) || (iNdEx+skippy) < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if (iNdEx + skippy) > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
+			iNdEx += skippy
+		}
+	}
+
+	if iNdEx > l {
+		return io.ErrUnexpectedEOF
+	}
+	return nil
+}
+func (m *IntentionHTTPHeaderPermission) Unmarshal(dAtA []byte) error {
+	l := len(dAtA)
+	iNdEx := 0
+	for iNdEx < l {
+		preIndex := iNdEx
+		var wire uint64
+		for shift := uint(0); ; shift += 7 {
+			if shift >= 64 {
+				return ErrIntOverflowSubscribe
+			}
+			if iNdEx >= l {
+				return io.ErrUnexpectedEOF
+			}
+			b := dAtA[iNdEx]
+			iNdEx++
+			wire |= uint64(b&0x7F) << shift
+			if b < 0x80 {
+				break
+			}
+		}
+		fieldNum := int32(wire >> 3)
+		wireType := int(wire & 0x7)
+		if wireType == 4 {
+			return fmt.Errorf("proto: IntentionHTTPHeaderPermission: wiretype end group for non-group")
+		}
+		if fieldNum <= 0 {
+			return fmt.Errorf("proto: IntentionHTTPHeaderPermission: illegal tag %d (wire type %d)", fieldNum, wire)
+		}
+		switch fieldNum {
+		case 1:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Name", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Name = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 2:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Index", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Present", wireType)
 			}
-			m.Index = 0
+			var v int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowSubscribe
@@ -1187,14 +7686,47 @@ func (m *SubscribeRequest) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Index |= uint64(b&0x7F) << shift
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Present = bool(v != 0)
+		case 3:
+			if wireType != 2 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Exact", wireType)
+			}
+			var stringLen uint64
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-		case 5:
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			postIndex := iNdEx + intStringLen
+			if postIndex < 0 {
+				return ErrInvalidLengthSubscribe
+			}
+			if postIndex > l {
+				return io.ErrUnexpectedEOF
+			}
+			m.Exact = string(dAtA[iNdEx:postIndex])
+			iNdEx = postIndex
+		case 4:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Datacenter", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Prefix", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -1222,11 +7754,11 @@ func (m *SubscribeRequest) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Datacenter = string(dAtA[iNdEx:postIndex])
+			m.Prefix = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
-		case 6:
+		case 5:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Namespace", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Suffix", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -1254,11 +7786,11 @@ func (m *SubscribeRequest) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Namespace = string(dAtA[iNdEx:postIndex])
+			m.Suffix = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
-		case 7:
+		case 6:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Partition", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Regex", wireType)
 			}
 			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
@@ -1286,8 +7818,28 @@ func (m *SubscribeRequest) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Partition = string(dAtA[iNdEx:postIndex])
+			m.Regex = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
+		case 7:
+			if wireType != 0 {
+				return fmt.Errorf("proto: wrong wireType = %d for field Invert", wireType)
+			}
+			var v int
+			for shift := uint(0); ; shift += 7 {
+				if shift >= 64 {
+					return ErrIntOverflowSubscribe
+				}
+				if iNdEx >= l {
+					return io.ErrUnexpectedEOF
+				}
+				b := dAtA[iNdEx]
+				iNdEx++
+				v |= int(b&0x7F) << shift
+				if b < 0x80 {
+					break
+				}
+			}
+			m.Invert = bool(v != 0)
 		default:
 			iNdEx = preIndex
 			skippy, err := skipSubscribe(dAtA[iNdEx:])
@@ -1310,7 +7862,7 @@ func (m *SubscribeRequest) Unmarshal(dAtA []byte) error {
 	}
 	return nil
 }
-func (m *Event) Unmarshal(dAtA []byte) error {
+func (m *ConfigEntryUpdate) Unmarshal(dAtA []byte) error {
 	l := len(dAtA)
 	iNdEx := 0
 	for iNdEx < l {
@@ -1333,17 +7885,17 @@ func (m *Event) Unmarshal(dAtA []byte) error {
 		fieldNum := int32(wire >> 3)
 		wireType := int(wire & 0x7)
 		if wireType == 4 {
-			return fmt.Errorf("proto: Event: wiretype end group for non-group")
+			return fmt.Errorf("proto: ConfigEntryUpdate: wiretype end group for non-group")
 		}
 		if fieldNum <= 0 {
-			return fmt.Errorf("proto: Event: illegal tag %d (wire type %d)", fieldNum, wire)
+			return fmt.Errorf("proto: ConfigEntryUpdate: illegal tag %d (wire type %d)", fieldNum, wire)
 		}
 		switch fieldNum {
 		case 1:
 			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Index", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Op", wireType)
 			}
-			m.Index = 0
+			m.Op = 0
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowSubscribe
@@ -1353,58 +7905,16 @@ func (m *Event) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				m.Index |= uint64(b&0x7F) << shift
+				m.Op |= UpdateOp(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
 		case 2:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field EndOfSnapshot", wireType)
-			}
-			var v int
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowSubscribe
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				v |= int(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-			b := bool(v != 0)
-			m.Payload = &Event_EndOfSnapshot{b}
-		case 3:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field NewSnapshotToFollow", wireType)
-			}
-			var v int
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowSubscribe
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				v |= int(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-			b := bool(v != 0)
-			m.Payload = &Event_NewSnapshotToFollow{b}
-		case 4:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field EventBatch", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Kind", wireType)
 			}
-			var msglen int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowSubscribe
@@ -1414,32 +7924,29 @@ func (m *Event) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthSubscribe
 			}
-			postIndex := iNdEx + msglen
+			postIndex := iNdEx + intStringLen
 			if postIndex < 0 {
 				return ErrInvalidLengthSubscribe
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			v := &EventBatch{}
-			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
-			m.Payload = &Event_EventBatch{v}
+			m.Kind = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
-		case 10:
+		case 3:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field ServiceHealth", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Name", wireType)
 			}
-			var msglen int
+			var stringLen uint64
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowSubscribe
@@ -1449,81 +7956,27 @@ func (m *Event) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				stringLen |= uint64(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
+			intStringLen := int(stringLen)
+			if intStringLen < 0 {
 				return ErrInvalidLengthSubscribe
 			}
-			postIndex := iNdEx + msglen
+			postIndex := iNdEx + intStringLen
 			if postIndex < 0 {
 				return ErrInvalidLengthSubscribe
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			v := &ServiceHealthUpdate{}
-			if err := v.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
-			}
-			m.Payload = &Event_ServiceHealth{v}
+			m.Name = string(dAtA[iNdEx:postIndex])
 			iNdEx = postIndex
-		default:
-			iNdEx = preIndex
-			skippy, err := skipSubscribe(dAtA[iNdEx:])
-			if err != nil {
-				return err
-			}
-			if (skippy < 0) || (iNdEx+skippy) < 0 {
-				return ErrInvalidLengthSubscribe
-			}
-			if (iNdEx + skippy) > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
-			iNdEx += skippy
-		}
-	}
-
-	if iNdEx > l {
-		return io.ErrUnexpectedEOF
-	}
-	return nil
-}
-func (m *EventBatch) Unmarshal(dAtA []byte) error {
-	l := len(dAtA)
-	iNdEx := 0
-	for iNdEx < l {
-		preIndex := iNdEx
-		var wire uint64
-		for shift := uint(0); ; shift += 7 {
-			if shift >= 64 {
-				return ErrIntOverflowSubscribe
-			}
-			if iNdEx >= l {
-				return io.ErrUnexpectedEOF
-			}
-			b := dAtA[iNdEx]
-			iNdEx++
-			wire |= uint64(b&0x7F) << shift
-			if b < 0x80 {
-				break
-			}
-		}
-		fieldNum := int32(wire >> 3)
-		wireType := int(wire & 0x7)
-		if wireType == 4 {
-			return fmt.Errorf("proto: EventBatch: wiretype end group for non-group")
-		}
-		if fieldNum <= 0 {
-			return fmt.Errorf("proto: EventBatch: illegal tag %d (wire type %d)", fieldNum, wire)
-		}
-		switch fieldNum {
-		case 1:
+		case 4:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Events", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field EnterpriseMeta", wireType)
 			}
 			var msglen int
 			for shift := uint(0); ; shift += 7 {
@@ -1550,86 +8003,18 @@ func (m *EventBatch) Unmarshal(dAtA []byte) error {
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			m.Events = append(m.Events, &Event{})
-			if err := m.Events[len(m.Events)-1].Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
+			if m.EnterpriseMeta == nil {
+				m.EnterpriseMeta = &pbcommon.EnterpriseMeta{}
 			}
-			iNdEx = postIndex
-		default:
-			iNdEx = preIndex
-			skippy, err := skipSubscribe(dAtA[iNdEx:])
-			if err != nil {
+			if err := m.EnterpriseMeta.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
 				return err
 			}
-			if (skippy < 0) || (iNdEx+skippy) < 0 {
-				return ErrInvalidLengthSubscribe
-			}
-			if (iNdEx + skippy) > l {
-				return io.ErrUnexpectedEOF
-			}
-			m.XXX_unrecognized = append(m.XXX_unrecognized, dAtA[iNdEx:iNdEx+skippy]...)
-			iNdEx += skippy
-		}
-	}
-
-	if iNdEx > l {
-		return io.ErrUnexpectedEOF
-	}
-	return nil
-}
-func (m *ServiceHealthUpdate) Unmarshal(dAtA []byte) error {
-	l := len(dAtA)
-	iNdEx := 0
-	for iNdEx < l {
-		preIndex := iNdEx
-		var wire uint64
-		for shift := uint(0); ; shift += 7 {
-			if shift >= 64 {
-				return ErrIntOverflowSubscribe
-			}
-			if iNdEx >= l {
-				return io.ErrUnexpectedEOF
-			}
-			b := dAtA[iNdEx]
-			iNdEx++
-			wire |= uint64(b&0x7F) << shift
-			if b < 0x80 {
-				break
-			}
-		}
-		fieldNum := int32(wire >> 3)
-		wireType := int(wire & 0x7)
-		if wireType == 4 {
-			return fmt.Errorf("proto: ServiceHealthUpdate: wiretype end group for non-group")
-		}
-		if fieldNum <= 0 {
-			return fmt.Errorf("proto: ServiceHealthUpdate: illegal tag %d (wire type %d)", fieldNum, wire)
-		}
-		switch fieldNum {
-		case 1:
-			if wireType != 0 {
-				return fmt.Errorf("proto: wrong wireType = %d for field Op", wireType)
-			}
-			m.Op = 0
-			for shift := uint(0); ; shift += 7 {
-				if shift >= 64 {
-					return ErrIntOverflowSubscribe
-				}
-				if iNdEx >= l {
-					return io.ErrUnexpectedEOF
-				}
-				b := dAtA[iNdEx]
-				iNdEx++
-				m.Op |= CatalogOp(b&0x7F) << shift
-				if b < 0x80 {
-					break
-				}
-			}
-		case 2:
+			iNdEx = postIndex
+		case 5:
 			if wireType != 2 {
-				return fmt.Errorf("proto: wrong wireType = %d for field CheckServiceNode", wireType)
+				return fmt.Errorf("proto: wrong wireType = %d for field Entry", wireType)
 			}
-			var msglen int
+			var byteLen int
 			for shift := uint(0); ; shift += 7 {
 				if shift >= 64 {
 					return ErrIntOverflowSubscribe
@@ -1639,26 +8024,24 @@ func (m *ServiceHealthUpdate) Unmarshal(dAtA []byte) error {
 				}
 				b := dAtA[iNdEx]
 				iNdEx++
-				msglen |= int(b&0x7F) << shift
+				byteLen |= int(b&0x7F) << shift
 				if b < 0x80 {
 					break
 				}
 			}
-			if msglen < 0 {
+			if byteLen < 0 {
 				return ErrInvalidLengthSubscribe
 			}
-			postIndex := iNdEx + msglen
+			postIndex := iNdEx + byteLen
 			if postIndex < 0 {
 				return ErrInvalidLengthSubscribe
 			}
 			if postIndex > l {
 				return io.ErrUnexpectedEOF
 			}
-			if m.CheckServiceNode == nil {
-				m.CheckServiceNode = &pbservice.CheckServiceNode{}
-			}
-			if err := m.CheckServiceNode.Unmarshal(dAtA[iNdEx:postIndex]); err != nil {
-				return err
+			m.Entry = append(m.Entry[:0], dAtA[iNdEx:postIndex]...)
+			if m.Entry == nil {
+				m.Entry = []byte{}
 			}
 			iNdEx = postIndex
 		default: